@@ -90,6 +90,9 @@ type AppConfig struct {
 	OpenAIModel          string
 	OpenAIMaxTokens      int
 	OpenAITemperature    float64
+	
+	// Realtime Configuration
+	RealtimeBroker       string // memory for a single replica, postgres to fan out across replicas
 }
 
 // LoadConfig loads configuration from environment variables
@@ -178,6 +181,9 @@ func LoadConfig() *AppConfig {
 		OpenAIModel:          getEnv("OPENAI_MODEL", "gpt-3.5-turbo"),
 		OpenAIMaxTokens:      getEnvAsInt("OPENAI_MAX_TOKENS", 500),
 		OpenAITemperature:    getEnvAsFloat64("OPENAI_TEMPERATURE", 0.7),
+		
+		// Realtime Configuration
+		RealtimeBroker:       getEnv("REALTIME_BROKER", "memory"),
 	}
 	
 	return config
@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"treesindia/database"
 	"treesindia/models"
+	"treesindia/services"
 	"treesindia/views"
 
 	"github.com/gin-gonic/gin"
@@ -347,7 +349,6 @@ func (ac *AdminController) UpdateUserByID(c *gin.Context) {
 	user.Gender = req.Gender
 	user.IsActive = req.IsActive
 	user.RoleApplicationStatus = req.RoleApplicationStatus
-	user.WalletBalance = req.WalletBalance
 
 	user.HasActiveSubscription = req.HasActiveSubscription
 
@@ -356,6 +357,16 @@ func (ac *AdminController) UpdateUserByID(c *gin.Context) {
 		return
 	}
 
+	// Wallet balance changes go through the wallet journal as an admin adjustment
+	if adjustment := math.Round((req.WalletBalance-user.WalletBalance)*100) / 100; adjustment != 0 {
+		payment, err := services.NewUnifiedWalletService().AdminAdjustWallet(user.ID, adjustment, "Balance updated from user profile", ac.GetUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to update wallet balance", err.Error()))
+			return
+		}
+		user.WalletBalance = *payment.BalanceAfter
+	}
+
 	c.JSON(http.StatusOK, views.CreateSuccessResponse("User updated successfully", gin.H{
 		"user": user,
 	}))
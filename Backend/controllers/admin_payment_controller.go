@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"time"
 	"treesindia/models"
 	"treesindia/services"
 	"treesindia/views"
@@ -13,14 +14,16 @@ import (
 // AdminPaymentController handles admin payment and transaction management
 type AdminPaymentController struct {
 	*BaseController
-	paymentService *services.PaymentService
+	paymentService     *services.PaymentService
+	refundRetryService *services.RefundRetryService
 }
 
 // NewAdminPaymentController creates a new admin payment controller
 func NewAdminPaymentController() *AdminPaymentController {
 	return &AdminPaymentController{
-		BaseController: NewBaseController(),
-		paymentService: services.NewPaymentService(),
+		BaseController:     NewBaseController(),
+		paymentService:     services.NewPaymentService(),
+		refundRetryService: services.NewRefundRetryService(),
 	}
 }
 
@@ -333,6 +336,61 @@ func (apc *AdminPaymentController) RefundTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, views.CreateSuccessResponse("Transaction refunded successfully", transaction))
 }
 
+// GetPendingRefunds lists cancellation refunds that could not be issued and are being retried
+// @Summary Get pending refunds
+// @Description List refunds owed on cancelled bookings that failed when the booking was cancelled, with their retry attempts and last error (admin only)
+// @Tags Admin Transactions
+// @Produce json
+// @Param status query string false "Pending refund status (pending, issued)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} views.Response
+// @Failure 401 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/transactions/pending-refunds [get]
+func (apc *AdminPaymentController) GetPendingRefunds(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filters := &models.PendingRefundFilters{
+		Status: c.DefaultQuery("status", string(models.PendingRefundStatusPending)),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	refunds, pagination, err := apc.refundRetryService.GetPendingRefunds(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to fetch pending refunds", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Pending refunds retrieved successfully", gin.H{
+		"refunds":    refunds,
+		"pagination": pagination,
+	}))
+}
+
+// RetryPendingRefunds retries the pending refunds that are due now
+// @Summary Retry pending refunds
+// @Description Retry the pending refunds whose next attempt is due without waiting for the retry job (admin only)
+// @Tags Admin Transactions
+// @Produce json
+// @Success 200 {object} views.Response{data=models.RefundRetryResult}
+// @Failure 401 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/transactions/pending-refunds/retry [post]
+func (apc *AdminPaymentController) RetryPendingRefunds(c *gin.Context) {
+	result, err := apc.refundRetryService.RetryDue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to retry pending refunds", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Pending refunds retried", result))
+}
+
 // GetTransactionFilters gets available filter options for transactions
 // @Summary Get transaction filter options
 // @Description Get available filter options for transaction queries
@@ -360,11 +418,12 @@ func (apc *AdminPaymentController) GetTransactionFilters(c *gin.Context) {
 			string(models.PaymentStatusPending),
 			string(models.PaymentStatusCompleted),
 			string(models.PaymentStatusFailed),
+			string(models.PaymentStatusPartiallyRefunded),
 			string(models.PaymentStatusRefunded),
 			string(models.PaymentStatusCancelled),
 		},
 		"payment_methods": []string{
-			"razorpay",
+			models.PaymentMethodOnline,
 			"wallet",
 			"cash",
 			"admin",
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"net/http"
+	"treesindia/services"
 	"treesindia/utils"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +79,15 @@ func (bc *BaseController) GetUserType(c *gin.Context) string {
 func (bc *BaseController) GetUser(c *gin.Context) interface{} {
 	return c.MustGet("user")
 }
+
+// ErrorStatus returns 409 Conflict for booking state transition errors, 403 Forbidden for plan
+// entitlement errors and the given status otherwise
+func (bc *BaseController) ErrorStatus(err error, status int) int {
+	if services.IsStateTransitionError(err) {
+		return http.StatusConflict
+	}
+	if services.IsEntitlementError(err) {
+		return http.StatusForbidden
+	}
+	return status
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"treesindia/repositories"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookingActivityController handles booking audit trail HTTP requests
+type BookingActivityController struct {
+	BaseController
+	activityService *services.BookingActivityService
+}
+
+// NewBookingActivityController creates a new instance of BookingActivityController
+func NewBookingActivityController() *BookingActivityController {
+	return &BookingActivityController{
+		BaseController:  *NewBaseController(),
+		activityService: services.NewBookingActivityService(),
+	}
+}
+
+// GetBookingActivity gets the full activity history of a booking (admin only)
+func (ac *BookingActivityController) GetBookingActivity(c *gin.Context) {
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	activities, err := ac.activityService.GetBookingActivity(uint(bookingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking activity", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"booking_id": bookingID,
+		"activities": activities,
+	})
+}
+
+// GetActivities gets activity entries across bookings with filters (admin only)
+func (ac *BookingActivityController) GetActivities(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filters := &repositories.BookingActivityFilters{
+		Action:    c.Query("action"),
+		ActorType: c.Query("actor_type"),
+		Page:      page,
+		Limit:     limit,
+	}
+
+	if bookingID, err := strconv.ParseUint(c.Query("booking_id"), 10, 32); err == nil {
+		id := uint(bookingID)
+		filters.BookingID = &id
+	}
+	if actorID, err := strconv.ParseUint(c.Query("actor_id"), 10, 32); err == nil {
+		id := uint(actorID)
+		filters.ActorID = &id
+	}
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		filters.From = &fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		// Include the whole day
+		endOfDay := toDate.Add(24*time.Hour - time.Nanosecond)
+		filters.To = &endOfDay
+	}
+
+	activities, pagination, err := ac.activityService.GetActivities(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking activity", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activities": activities,
+		"pagination": pagination,
+	})
+}
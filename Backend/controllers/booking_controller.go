@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"treesindia/models"
@@ -328,7 +329,7 @@ func (bc *BookingController) VerifyPayment(c *gin.Context) {
 	booking, err = bc.bookingService.VerifyPayment(&req)
 	if err != nil {
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment verification failed. Please contact support.", "details": err.Error()})
+		c.JSON(bc.ErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Payment verification failed. Please contact support.", "details": err.Error()})
 		return
 	}
 
@@ -458,8 +459,12 @@ func (bc *BookingController) CancelUserBooking(c *gin.Context) {
 	}
 
 	result, err := bc.bookingService.CancelUserBooking(userID, uint(bookingID), &req)
+	if errors.Is(err, services.ErrRefundPending) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Booking cancelled but the refund is pending", "details": err.Error(), "result": result})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to cancel booking", "details": err.Error()})
+		c.JSON(bc.ErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to cancel booking", "details": err.Error()})
 		return
 	}
 
@@ -469,6 +474,31 @@ func (bc *BookingController) CancelUserBooking(c *gin.Context) {
 	})
 }
 
+// GetCancellationPreview shows the cancellation fee and refund for a booking
+func (bc *BookingController) GetCancellationPreview(c *gin.Context) {
+	userID := bc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	preview, err := bc.bookingService.GetCancellationPreview(userID, uint(bookingID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to calculate cancellation refund", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cancellation": preview,
+	})
+}
+
 // AdminGetAllBookings gets all bookings (admin only)
 func (bc *BookingController) AdminGetAllBookings(c *gin.Context) {
 	userType := bc.GetUserType(c)
@@ -562,9 +592,9 @@ func (bc *BookingController) AdminUpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	booking, err := bc.bookingService.UpdateBookingStatus(uint(bookingID), models.BookingStatus(req.Status), req.Reason)
+	booking, err := bc.bookingService.UpdateBookingStatus(uint(bookingID), bc.GetUserID(c), models.BookingStatus(req.Status), req.Reason)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update booking status", "details": err.Error()})
+		c.JSON(bc.ErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to update booking status", "details": err.Error()})
 		return
 	}
 
@@ -595,6 +625,23 @@ func (bc *BookingController) AdminAssignWorker(c *gin.Context) {
 		return
 	}
 
+	adminID := bc.GetUserID(c)
+
+	// Let the matching engine pick the worker when auto_assign is set
+	if autoAssign, ok := jsonData["auto_assign"].(bool); ok && autoAssign {
+		assignment, err := bc.bookingService.AutoAssignWorker(uint(bookingID), adminID)
+		if err != nil {
+			c.JSON(bc.ErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to auto-assign worker", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Worker assigned successfully",
+			"assignment": assignment,
+		})
+		return
+	}
+
 	// Extract worker_id from the map
 	var workerID uint
 	if workerIDVal, exists := jsonData["worker_id"]; exists {
@@ -616,10 +663,9 @@ func (bc *BookingController) AdminAssignWorker(c *gin.Context) {
 		return
 	}
 
-	adminID := bc.GetUserID(c)
 	assignment, err := bc.bookingService.AssignWorkerToBooking(uint(bookingID), workerID, adminID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to assign worker", "details": err.Error()})
+		c.JSON(bc.ErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to assign worker", "details": err.Error()})
 		return
 	}
 
@@ -629,6 +675,34 @@ func (bc *BookingController) AdminAssignWorker(c *gin.Context) {
 	})
 }
 
+// AdminGetSuggestedWorkers ranks the workers who could take a booking (admin only)
+func (bc *BookingController) AdminGetSuggestedWorkers(c *gin.Context) {
+	userType := bc.GetUserType(c)
+	if userType != string(models.UserTypeAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	workers, err := bc.bookingService.GetSuggestedWorkers(uint(bookingID), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get suggested workers", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"booking_id": bookingID,
+		"workers":    workers,
+	})
+}
+
 // GetBookingStats gets booking statistics (admin only)
 func (bc *BookingController) GetBookingStats(c *gin.Context) {
 	userType := bc.GetUserType(c)
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+	"treesindia/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BookingDisputeController handles booking dispute HTTP requests
+type BookingDisputeController struct {
+	BaseController
+	disputeService *services.BookingDisputeService
+}
+
+// NewBookingDisputeController creates a new instance of BookingDisputeController
+func NewBookingDisputeController() *BookingDisputeController {
+	cloudinaryService, err := services.NewCloudinaryService()
+	if err != nil {
+		logrus.Errorf("Failed to initialize CloudinaryService: %v", err)
+		// Evidence uploads will be rejected, disputes without photos still work
+		cloudinaryService = nil
+	}
+
+	return &BookingDisputeController{
+		BaseController: *NewBaseController(),
+		disputeService: services.NewBookingDisputeService(cloudinaryService),
+	}
+}
+
+// OpenDispute raises a dispute on a booking with optional photo evidence
+func (dc *BookingDisputeController) OpenDispute(c *gin.Context) {
+	userID := dc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req models.CreateDisputeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	var evidence []*multipart.FileHeader
+	if strings.HasPrefix(c.GetHeader("Content-Type"), "multipart/form-data") {
+		form, err := c.MultipartForm()
+		if err == nil {
+			evidence = form.File["evidence"]
+		}
+	}
+
+	for _, file := range evidence {
+		// Validate file type
+		if !utils.IsValidImageType(file.Header.Get("Content-Type")) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type", "details": "Only JPEG, PNG, and WebP images are allowed"})
+			return
+		}
+
+		// Validate file size (max 5MB)
+		if file.Size > 5*1024*1024 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File too large", "details": "Image size must be less than 5MB"})
+			return
+		}
+	}
+
+	dispute, err := dc.disputeService.OpenDispute(userID, uint(bookingID), &req, evidence)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "unauthorized" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Failed to open dispute", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Dispute raised successfully",
+		"dispute": dispute,
+	})
+}
+
+// GetBookingDisputes gets the disputes for a booking
+func (dc *BookingDisputeController) GetBookingDisputes(c *gin.Context) {
+	userID := dc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	disputes, err := dc.disputeService.GetBookingDisputes(userID, dc.GetUserType(c), uint(bookingID))
+	if err != nil {
+		status := http.StatusNotFound
+		if err.Error() == "unauthorized" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Failed to fetch disputes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes": disputes,
+	})
+}
+
+// AdminGetDisputes gets all disputes with filters (admin only)
+func (dc *BookingDisputeController) AdminGetDisputes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := &repositories.DisputeFilters{
+		Status:       c.Query("status"),
+		Reason:       c.Query("reason"),
+		RaisedByRole: c.Query("raised_by_role"),
+		Page:         page,
+		Limit:        limit,
+	}
+
+	if bookingID, err := strconv.ParseUint(c.Query("booking_id"), 10, 32); err == nil {
+		id := uint(bookingID)
+		filters.BookingID = &id
+	}
+
+	disputes, pagination, err := dc.disputeService.GetDisputes(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes":   disputes,
+		"pagination": pagination,
+	})
+}
+
+// AdminGetDispute gets a dispute with its comments (admin only)
+func (dc *BookingDisputeController) AdminGetDispute(c *gin.Context) {
+	disputeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	dispute, err := dc.disputeService.GetDispute(uint(disputeID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dispute": dispute,
+	})
+}
+
+// AdminAddComment adds a comment or internal note to a dispute (admin only)
+func (dc *BookingDisputeController) AdminAddComment(c *gin.Context) {
+	adminID := dc.GetUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	disputeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	var req models.AddDisputeCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	comment, err := dc.disputeService.AddComment(adminID, uint(disputeID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to add comment", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment added successfully",
+		"comment": comment,
+	})
+}
+
+// AdminResolveDispute resolves a dispute with a refund, partial refund, rework or rejection (admin only)
+func (dc *BookingDisputeController) AdminResolveDispute(c *gin.Context) {
+	adminID := dc.GetUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	disputeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	dispute, err := dc.disputeService.ResolveDispute(adminID, uint(disputeID), &req)
+	if errors.Is(err, services.ErrDisputeRefundIncomplete) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Dispute refund incomplete, dispute left under review", "details": err.Error(), "dispute": dispute})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to resolve dispute", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Dispute resolved successfully",
+		"dispute": dispute,
+	})
+}
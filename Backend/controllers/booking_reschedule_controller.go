@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookingRescheduleController handles booking reschedule HTTP requests
+type BookingRescheduleController struct {
+	BaseController
+	rescheduleService *services.BookingRescheduleService
+}
+
+// NewBookingRescheduleController creates a new instance of BookingRescheduleController
+func NewBookingRescheduleController() *BookingRescheduleController {
+	return &BookingRescheduleController{
+		BaseController:    *NewBaseController(),
+		rescheduleService: services.NewBookingRescheduleService(),
+	}
+}
+
+// RequestReschedule asks to move the customer's booking to a new time
+func (rc *BookingRescheduleController) RequestReschedule(c *gin.Context) {
+	rc.requestReschedule(c, models.ActivityActorUser)
+}
+
+// AdminRequestReschedule moves a booking to a new time (admin only)
+func (rc *BookingRescheduleController) AdminRequestReschedule(c *gin.Context) {
+	rc.requestReschedule(c, models.ActivityActorAdmin)
+}
+
+// GetBookingRequests gets the reschedule history of a booking
+func (rc *BookingRescheduleController) GetBookingRequests(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	requests, err := rc.rescheduleService.GetBookingRequests(userID, rc.actorRole(c), uint(bookingID))
+	if err != nil {
+		c.JSON(rc.rescheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": "Failed to fetch reschedule requests", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reschedule_requests": requests,
+	})
+}
+
+// CancelRequest withdraws a pending reschedule request
+func (rc *BookingRescheduleController) CancelRequest(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("request_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reschedule request ID"})
+		return
+	}
+
+	request, err := rc.rescheduleService.CancelRequest(userID, rc.actorRole(c), uint(bookingID), uint(requestID))
+	if err != nil {
+		c.JSON(rc.rescheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to cancel reschedule request", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Reschedule request cancelled successfully",
+		"reschedule_request": request,
+	})
+}
+
+// GetWorkerPendingRequests gets the reschedule requests waiting for the worker's answer
+func (rc *BookingRescheduleController) GetWorkerPendingRequests(c *gin.Context) {
+	workerID := rc.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requests, err := rc.rescheduleService.GetWorkerPendingRequests(workerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reschedule requests", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reschedule_requests": requests,
+	})
+}
+
+// AcceptReschedule accepts the new time of a booking
+func (rc *BookingRescheduleController) AcceptReschedule(c *gin.Context) {
+	workerID, requestID, req, ok := rc.respondParams(c)
+	if !ok {
+		return
+	}
+
+	request, err := rc.rescheduleService.AcceptReschedule(workerID, requestID, req.Notes)
+	if err != nil {
+		c.JSON(rc.rescheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to accept reschedule request", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Booking rescheduled successfully",
+		"reschedule_request": request,
+	})
+}
+
+// DeclineReschedule declines the new time of a booking
+func (rc *BookingRescheduleController) DeclineReschedule(c *gin.Context) {
+	workerID, requestID, req, ok := rc.respondParams(c)
+	if !ok {
+		return
+	}
+
+	request, err := rc.rescheduleService.DeclineReschedule(workerID, requestID, req.Notes)
+	if err != nil {
+		c.JSON(rc.rescheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to decline reschedule request", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Reschedule request declined",
+		"reschedule_request": request,
+	})
+}
+
+// AdminGetRequests gets all reschedule requests with filters (admin only)
+func (rc *BookingRescheduleController) AdminGetRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := &repositories.RescheduleFilters{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	if bookingID, err := strconv.ParseUint(c.Query("booking_id"), 10, 32); err == nil {
+		id := uint(bookingID)
+		filters.BookingID = &id
+	}
+	if workerID, err := strconv.ParseUint(c.Query("worker_id"), 10, 32); err == nil {
+		id := uint(workerID)
+		filters.WorkerID = &id
+	}
+
+	requests, pagination, err := rc.rescheduleService.GetRequests(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reschedule requests", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reschedule_requests": requests,
+		"pagination":          pagination,
+	})
+}
+
+// requestReschedule creates a reschedule request on behalf of the given role
+func (rc *BookingRescheduleController) requestReschedule(c *gin.Context, role models.ActivityActorType) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req models.CreateRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	request, err := rc.rescheduleService.RequestReschedule(userID, role, uint(bookingID), &req)
+	if err != nil {
+		c.JSON(rc.rescheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to reschedule booking", "details": err.Error()})
+		return
+	}
+
+	message := "Reschedule request sent to the assigned worker"
+	if request.Status == models.RescheduleStatusAccepted {
+		message = "Booking rescheduled successfully"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            message,
+		"reschedule_request": request,
+	})
+}
+
+// respondParams reads the worker, the request ID and the response body, writing the error response when invalid
+func (rc *BookingRescheduleController) respondParams(c *gin.Context) (uint, uint, models.RespondRescheduleRequest, bool) {
+	var req models.RespondRescheduleRequest
+
+	workerID := rc.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return 0, 0, req, false
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reschedule request ID"})
+		return 0, 0, req, false
+	}
+
+	// The notes are optional, so an empty body is fine
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+			return 0, 0, req, false
+		}
+	}
+
+	return workerID, uint(requestID), req, true
+}
+
+// actorRole treats admins as admins and everyone else as the booking's customer
+func (rc *BookingRescheduleController) actorRole(c *gin.Context) models.ActivityActorType {
+	if rc.GetUserType(c) == string(models.UserTypeAdmin) {
+		return models.ActivityActorAdmin
+	}
+	return models.ActivityActorUser
+}
+
+// rescheduleErrorStatus maps ownership errors to 403 and state errors to 409
+func (rc *BookingRescheduleController) rescheduleErrorStatus(err error, fallback int) int {
+	if err.Error() == "unauthorized" {
+		return http.StatusForbidden
+	}
+	return rc.ErrorStatus(err, fallback)
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookingReviewController handles booking review HTTP requests
+type BookingReviewController struct {
+	BaseController
+	reviewService *services.BookingReviewService
+}
+
+// NewBookingReviewController creates a new instance of BookingReviewController
+func NewBookingReviewController() *BookingReviewController {
+	return &BookingReviewController{
+		BaseController: *NewBaseController(),
+		reviewService:  services.NewBookingReviewService(),
+	}
+}
+
+// SubmitReview submits a review for a completed booking
+func (rc *BookingReviewController) SubmitReview(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req models.ReviewBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	review, err := rc.reviewService.SubmitReview(userID, uint(bookingID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to submit review", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Review submitted successfully",
+		"review":  review,
+	})
+}
+
+// UpdateReview updates a booking review within the edit window
+func (rc *BookingReviewController) UpdateReview(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req models.ReviewBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	review, err := rc.reviewService.UpdateReview(userID, uint(bookingID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update review", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review updated successfully",
+		"review":  review,
+	})
+}
+
+// GetBookingReview gets the review for a booking
+func (rc *BookingReviewController) GetBookingReview(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	review, err := rc.reviewService.GetBookingReview(uint(bookingID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	// Only the reviewer, the reviewed worker or an admin can see the review
+	userType := rc.GetUserType(c)
+	isWorker := review.WorkerID != nil && *review.WorkerID == userID
+	if review.UserID != userID && !isWorker && userType != string(models.UserTypeAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"review":      review,
+		"is_editable": review.UserID == userID && review.IsEditable(),
+	})
+}
+
+// GetWorkerReviews gets published reviews for a worker (public)
+func (rc *BookingReviewController) GetWorkerReviews(c *gin.Context) {
+	workerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid worker ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	reviews, summary, pagination, err := rc.reviewService.GetWorkerReviews(uint(workerID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Failed to fetch reviews", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":    reviews,
+		"summary":    summary,
+		"pagination": pagination,
+	})
+}
+
+// AdminGetReviews gets all reviews with filters (admin only)
+func (rc *BookingReviewController) AdminGetReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	minRating, _ := strconv.Atoi(c.Query("min_rating"))
+	maxRating, _ := strconv.Atoi(c.Query("max_rating"))
+
+	filters := &repositories.ReviewFilters{
+		Status:    c.Query("status"),
+		MinRating: minRating,
+		MaxRating: maxRating,
+		Page:      page,
+		Limit:     limit,
+	}
+
+	if workerID, err := strconv.ParseUint(c.Query("worker_id"), 10, 32); err == nil {
+		id := uint(workerID)
+		filters.WorkerID = &id
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		id := uint(userID)
+		filters.UserID = &id
+	}
+	if bookingID, err := strconv.ParseUint(c.Query("booking_id"), 10, 32); err == nil {
+		id := uint(bookingID)
+		filters.BookingID = &id
+	}
+
+	reviews, pagination, err := rc.reviewService.GetReviews(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":    reviews,
+		"pagination": pagination,
+	})
+}
+
+// AdminModerateReview publishes, hides or flags a review (admin only)
+func (rc *BookingReviewController) AdminModerateReview(c *gin.Context) {
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	adminID := rc.GetUserID(c)
+	review, err := rc.reviewService.ModerateReview(uint(reviewID), adminID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to moderate review", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review moderated successfully",
+		"review":  review,
+	})
+}
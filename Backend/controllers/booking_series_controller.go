@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookingSeriesController handles recurring booking HTTP requests
+type BookingSeriesController struct {
+	BaseController
+	seriesService *services.BookingSeriesService
+}
+
+// NewBookingSeriesController creates a new instance of BookingSeriesController
+func NewBookingSeriesController() *BookingSeriesController {
+	return &BookingSeriesController{
+		BaseController: *NewBaseController(),
+		seriesService:  services.NewBookingSeriesService(),
+	}
+}
+
+// CreateSeries creates a recurring booking
+func (sc *BookingSeriesController) CreateSeries(c *gin.Context) {
+	userID := sc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	series, err := sc.seriesService.CreateSeries(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create recurring booking", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recurring booking created successfully",
+		"series":  series,
+	})
+}
+
+// GetUserSeries gets the user's recurring bookings
+func (sc *BookingSeriesController) GetUserSeries(c *gin.Context) {
+	userID := sc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := &repositories.BookingSeriesFilters{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	series, pagination, err := sc.seriesService.GetUserSeries(userID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring bookings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":     series,
+		"pagination": pagination,
+	})
+}
+
+// GetSeries gets one of the user's recurring bookings with its occurrences
+func (sc *BookingSeriesController) GetSeries(c *gin.Context) {
+	userID := sc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	seriesID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	series, err := sc.seriesService.GetSeriesByID(userID, uint(seriesID))
+	if err != nil {
+		c.JSON(sc.seriesErrorStatus(err, http.StatusNotFound), gin.H{"error": "Failed to fetch recurring booking", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+	})
+}
+
+// CancelSeries cancels all upcoming visits of a recurring booking
+func (sc *BookingSeriesController) CancelSeries(c *gin.Context) {
+	userID := sc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	seriesID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req models.CancelBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	series, err := sc.seriesService.CancelSeries(userID, uint(seriesID), &req)
+	if err != nil {
+		c.JSON(sc.seriesErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to cancel recurring booking", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recurring booking cancelled successfully",
+		"series":  series,
+	})
+}
+
+// SkipOccurrence skips one visit of a recurring booking
+func (sc *BookingSeriesController) SkipOccurrence(c *gin.Context) {
+	userID, seriesID, occurrenceID, ok := sc.occurrenceParams(c)
+	if !ok {
+		return
+	}
+
+	occurrence, err := sc.seriesService.SkipOccurrence(userID, seriesID, occurrenceID)
+	if err != nil {
+		c.JSON(sc.seriesErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to skip visit", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Visit skipped successfully",
+		"occurrence": occurrence,
+	})
+}
+
+// RescheduleOccurrence moves one visit of a recurring booking
+func (sc *BookingSeriesController) RescheduleOccurrence(c *gin.Context) {
+	userID, seriesID, occurrenceID, ok := sc.occurrenceParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.RescheduleOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	occurrence, err := sc.seriesService.RescheduleOccurrence(userID, seriesID, occurrenceID, &req)
+	if err != nil {
+		c.JSON(sc.seriesErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to reschedule visit", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Visit rescheduled successfully",
+		"occurrence": occurrence,
+	})
+}
+
+// CancelOccurrence cancels one visit of a recurring booking
+func (sc *BookingSeriesController) CancelOccurrence(c *gin.Context) {
+	userID, seriesID, occurrenceID, ok := sc.occurrenceParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	occurrence, cancellation, err := sc.seriesService.CancelOccurrence(userID, seriesID, occurrenceID, req.Reason)
+	if errors.Is(err, services.ErrRefundPending) {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":        "Visit cancelled but the refund is pending",
+			"details":      err.Error(),
+			"occurrence":   occurrence,
+			"cancellation": cancellation,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(sc.seriesErrorStatus(err, http.StatusBadRequest), gin.H{"error": "Failed to cancel visit", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Visit cancelled successfully",
+		"occurrence":   occurrence,
+		"cancellation": cancellation,
+	})
+}
+
+// AdminGetSeries gets all recurring bookings with filters (admin only)
+func (sc *BookingSeriesController) AdminGetSeries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := &repositories.BookingSeriesFilters{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		id := uint(userID)
+		filters.UserID = &id
+	}
+	if serviceID, err := strconv.ParseUint(c.Query("service_id"), 10, 32); err == nil {
+		id := uint(serviceID)
+		filters.ServiceID = &id
+	}
+
+	series, pagination, err := sc.seriesService.GetSeries(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring bookings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":     series,
+		"pagination": pagination,
+	})
+}
+
+// occurrenceParams reads the user and the series and occurrence IDs, writing the error response when invalid
+func (sc *BookingSeriesController) occurrenceParams(c *gin.Context) (uint, uint, uint, bool) {
+	userID := sc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return 0, 0, 0, false
+	}
+
+	seriesID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return 0, 0, 0, false
+	}
+
+	occurrenceID, err := strconv.ParseUint(c.Param("occurrence_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+		return 0, 0, 0, false
+	}
+
+	return userID, uint(seriesID), uint(occurrenceID), true
+}
+
+// seriesErrorStatus maps ownership errors to 403 and state errors to 409
+func (sc *BookingSeriesController) seriesErrorStatus(err error, fallback int) int {
+	if err.Error() == "unauthorized" {
+		return http.StatusForbidden
+	}
+	return sc.ErrorStatus(err, fallback)
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"treesindia/models"
+	"treesindia/services"
+	"treesindia/views"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// chatMediaClient fetches attachment media from storage to stream it to participants
+var chatMediaClient = &http.Client{Timeout: 60 * time.Second}
+
+// ChatAttachmentController handles images, documents and location pins shared in chat rooms and conversations
+type ChatAttachmentController struct {
+	attachmentService *services.ChatAttachmentService
+}
+
+// NewChatAttachmentController creates a new chat attachment controller
+func NewChatAttachmentController() *ChatAttachmentController {
+	return &ChatAttachmentController{
+		attachmentService: services.NewChatAttachmentService(),
+	}
+}
+
+// UploadRoomAttachment uploads an image or document to a chat room
+// @Summary Upload chat room attachment
+// @Description Upload a JPEG, PNG or WebP image or a PDF document to share in a chat room. The file type is detected from its content and a thumbnail is generated. Send it by passing the returned ID in attachment_ids when sending a message.
+// @Tags Chat
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param file formData file true "Image or PDF document"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/attachments [post]
+func (ac *ChatAttachmentController) UploadRoomAttachment(c *gin.Context) {
+	ac.upload(c, models.ChatThreadRoom, "room_id")
+}
+
+// UploadConversationAttachment uploads an image or document to a conversation
+// @Summary Upload conversation attachment
+// @Description Upload a JPEG, PNG or WebP image or a PDF document to share in a conversation. The file type is detected from its content and a thumbnail is generated. Send it by passing the returned ID in attachment_ids when sending a message.
+// @Tags Conversations
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param file formData file true "Image or PDF document"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/attachments [post]
+func (ac *ChatAttachmentController) UploadConversationAttachment(c *gin.Context) {
+	ac.upload(c, models.ChatThreadConversation, "id")
+}
+
+// ShareRoomLocation creates a location pin in a chat room
+// @Summary Share location in chat room
+// @Description Create a location pin to share in a chat room. Send it by passing the returned ID in attachment_ids when sending a message.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param request body models.ShareLocationRequest true "Location"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/attachments/location [post]
+func (ac *ChatAttachmentController) ShareRoomLocation(c *gin.Context) {
+	ac.shareLocation(c, models.ChatThreadRoom, "room_id")
+}
+
+// ShareConversationLocation creates a location pin in a conversation
+// @Summary Share location in conversation
+// @Description Create a location pin to share in a conversation. Send it by passing the returned ID in attachment_ids when sending a message.
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param request body models.ShareLocationRequest true "Location"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/attachments/location [post]
+func (ac *ChatAttachmentController) ShareConversationLocation(c *gin.Context) {
+	ac.shareLocation(c, models.ChatThreadConversation, "id")
+}
+
+// GetAttachmentMedia streams the media of a chat attachment
+// @Summary Get chat attachment media
+// @Description Stream the image or document of a chat attachment. Only participants of its chat room or conversation and admins can fetch it; attachments not sent yet only by their uploader.
+// @Tags Chat
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 403 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /chat/attachments/{id} [get]
+func (ac *ChatAttachmentController) GetAttachmentMedia(c *gin.Context) {
+	ac.streamMedia(c, false)
+}
+
+// GetAttachmentThumbnail streams the thumbnail of a chat attachment
+// @Summary Get chat attachment thumbnail
+// @Description Stream the JPEG thumbnail of an image or PDF chat attachment. Only participants of its chat room or conversation and admins can fetch it; attachments not sent yet only by their uploader.
+// @Tags Chat
+// @Produce jpeg
+// @Security BearerAuth
+// @Param id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 403 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /chat/attachments/{id}/thumbnail [get]
+func (ac *ChatAttachmentController) GetAttachmentThumbnail(c *gin.Context) {
+	ac.streamMedia(c, true)
+}
+
+// upload handles an attachment upload to a thread
+func (ac *ChatAttachmentController) upload(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("File is required", err.Error()))
+		return
+	}
+
+	attachment, err := ac.attachmentService.Upload(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, file)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to upload attachment", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, views.CreateSuccessResponse("Attachment uploaded successfully", attachment.Info()))
+}
+
+// shareLocation handles a location pin for a thread
+func (ac *ChatAttachmentController) shareLocation(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	var req models.ShareLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	attachment, err := ac.attachmentService.ShareLocation(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, &req)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to share location", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, views.CreateSuccessResponse("Location shared successfully", attachment.Info()))
+}
+
+// streamMedia checks access to an attachment and streams its media or thumbnail from storage, through a
+// signed URL for authenticated uploads
+func (ac *ChatAttachmentController) streamMedia(c *gin.Context, thumbnail bool) {
+	attachmentID, ok := threadIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	attachment, mediaURL, err := ac.attachmentService.GetMedia(c.GetUint("user_id"), c.GetString("user_type"), attachmentID, thumbnail)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to get attachment", err.Error()))
+		return
+	}
+
+	resp, err := chatMediaClient.Get(mediaURL)
+	if err != nil {
+		logrus.Errorf("Failed to fetch media of chat attachment %d: %v", attachment.ID, err)
+		c.JSON(http.StatusBadGateway, views.CreateErrorResponse("Failed to get attachment", "media is unavailable"))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logrus.Errorf("Fetching media of chat attachment %d returned status %d", attachment.ID, resp.StatusCode)
+		c.JSON(http.StatusBadGateway, views.CreateErrorResponse("Failed to get attachment", "media is unavailable"))
+		return
+	}
+
+	contentType := attachment.MimeType
+	headers := map[string]string{
+		"Cache-Control": "private, max-age=3600",
+	}
+	if thumbnail {
+		contentType = "image/jpeg"
+	} else {
+		disposition := "inline"
+		if attachment.Kind == models.MessageTypeFile {
+			disposition = "attachment"
+		}
+		headers["Content-Disposition"] = fmt.Sprintf("%s; filename=%s", disposition, strconv.Quote(attachment.FileName))
+	}
+
+	c.DataFromReader(http.StatusOK, resp.ContentLength, contentType, resp.Body, headers)
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+	"treesindia/views"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatModerationController handles message reports, the admin review queue and chat mutes
+type ChatModerationController struct {
+	moderationService *services.ChatModerationService
+}
+
+// NewChatModerationController creates a new chat moderation controller
+func NewChatModerationController() *ChatModerationController {
+	return &ChatModerationController{
+		moderationService: services.NewChatModerationService(),
+	}
+}
+
+// ReportRoomMessage reports a chat room message to admins
+// @Summary Report chat room message
+// @Description Report a message another participant sent in a chat room, such as one sharing contact details or asking for payment off the platform. Each message can be reported once per user.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param message_id path int true "Message ID"
+// @Param request body models.ReportChatMessageRequest true "Report"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/messages/{message_id}/report [post]
+func (mc *ChatModerationController) ReportRoomMessage(c *gin.Context) {
+	mc.reportMessage(c, models.ChatThreadRoom)
+}
+
+// ReportConversationMessage reports a conversation message to admins
+// @Summary Report conversation message
+// @Description Report a message the other participant sent in a conversation. Each message can be reported once per user.
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param message_id path int true "Message ID"
+// @Param request body models.ReportChatMessageRequest true "Report"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/messages/{message_id}/report [post]
+func (mc *ChatModerationController) ReportConversationMessage(c *gin.Context) {
+	mc.reportMessage(c, models.ChatThreadConversation)
+}
+
+// GetReports gets the review queue of flagged chat messages
+// @Summary Get flagged chat messages
+// @Description Get chat messages participants reported or moderation flagged for masking contact details or blocked words, newest first (admin only)
+// @Tags Admin Chat Moderation
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, dismissed or actioned"
+// @Param source query string false "user or auto"
+// @Param reason query string false "abuse, spam, contact_sharing, off_platform_payment or other"
+// @Param thread_type query string false "chat_room or conversation"
+// @Param sender_id query int false "Author of the messages"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10)"
+// @Success 200 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/chat-moderation/reports [get]
+func (mc *ChatModerationController) GetReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := &repositories.ChatReportFilters{
+		Status:     c.Query("status"),
+		Source:     c.Query("source"),
+		Reason:     c.Query("reason"),
+		ThreadType: c.Query("thread_type"),
+		Page:       page,
+		Limit:      limit,
+	}
+	if senderID, err := strconv.ParseUint(c.Query("sender_id"), 10, 32); err == nil {
+		id := uint(senderID)
+		filters.SenderID = &id
+	}
+
+	reports, pagination, err := mc.moderationService.GetReports(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get reports", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Reports retrieved successfully", gin.H{
+		"reports":    reports,
+		"pagination": pagination,
+	}))
+}
+
+// GetReport gets a flagged chat message
+// @Summary Get flagged chat message
+// @Description Get a flagged chat message with the text its sender wrote before masking (admin only)
+// @Tags Admin Chat Moderation
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report ID"
+// @Success 200 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /admin/chat-moderation/reports/{id} [get]
+func (mc *ChatModerationController) GetReport(c *gin.Context) {
+	reportID, ok := threadIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	report, err := mc.moderationService.GetReport(reportID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, views.CreateErrorResponse("Failed to get report", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Report retrieved successfully", report))
+}
+
+// ReviewReport dismisses a flagged chat message or marks it actioned
+// @Summary Review flagged chat message
+// @Description Dismiss a flagged chat message or mark it actioned, optionally muting its sender in the chat or in every chat (admin only)
+// @Tags Admin Chat Moderation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report ID"
+// @Param request body models.ReviewChatReportRequest true "Review"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /admin/chat-moderation/reports/{id}/review [put]
+func (mc *ChatModerationController) ReviewReport(c *gin.Context) {
+	reportID, ok := threadIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewChatReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	report, err := mc.moderationService.ReviewReport(c.GetUint("user_id"), reportID, &req)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to review report", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Report reviewed successfully", report))
+}
+
+// GetMutes gets the chat mutes in force
+// @Summary Get chat mutes
+// @Description Get the chat mutes in force, optionally of one user (admin only)
+// @Tags Admin Chat Moderation
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Muted user"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10)"
+// @Success 200 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/chat-moderation/mutes [get]
+func (mc *ChatModerationController) GetMutes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var userID *uint
+	if value, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		id := uint(value)
+		userID = &id
+	}
+
+	mutes, pagination, err := mc.moderationService.GetActiveMutes(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get mutes", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Mutes retrieved successfully", gin.H{
+		"mutes":      mutes,
+		"pagination": pagination,
+	}))
+}
+
+// MuteUser mutes a chat participant
+// @Summary Mute chat participant
+// @Description Stop a user from sending messages in one chat room or conversation, or in every chat when thread_type is empty, for a number of hours or until lifted (admin only)
+// @Tags Admin Chat Moderation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MuteChatUserRequest true "Mute"
+// @Success 201 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Router /admin/chat-moderation/mutes [post]
+func (mc *ChatModerationController) MuteUser(c *gin.Context) {
+	var req models.MuteChatUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	mute, err := mc.moderationService.MuteUser(c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to mute user", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, views.CreateSuccessResponse("User muted successfully", mute))
+}
+
+// LiftMute lifts a chat mute
+// @Summary Lift chat mute
+// @Description Let a muted user send chat messages again (admin only)
+// @Tags Admin Chat Moderation
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Mute ID"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /admin/chat-moderation/mutes/{id}/lift [put]
+func (mc *ChatModerationController) LiftMute(c *gin.Context) {
+	muteID, ok := threadIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	mute, err := mc.moderationService.LiftMute(c.GetUint("user_id"), muteID)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to lift mute", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Mute lifted successfully", mute))
+}
+
+// reportMessage handles a message report for a thread type
+func (mc *ChatModerationController) reportMessage(c *gin.Context, threadType models.ChatThreadType) {
+	messageID, ok := threadIDParam(c, "message_id")
+	if !ok {
+		return
+	}
+
+	var req models.ReportChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	report, err := mc.moderationService.ReportMessage(c.GetUint("user_id"), c.GetString("user_type"), threadType, messageID, &req)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to report message", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, views.CreateSuccessResponse("Message reported successfully", gin.H{
+		"report_id": report.ID,
+		"status":    report.Status,
+	}))
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"treesindia/models"
+	"treesindia/services"
+	"treesindia/views"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatSyncController handles message sync, receipts and typing indicators of chat rooms and conversations
+type ChatSyncController struct {
+	syncService *services.ChatSyncService
+}
+
+// NewChatSyncController creates a new chat sync controller
+func NewChatSyncController(syncService *services.ChatSyncService) *ChatSyncController {
+	return &ChatSyncController{
+		syncService: syncService,
+	}
+}
+
+// SyncRoomMessages gets the messages of a chat room after a message
+// @Summary Sync chat room messages
+// @Description Get the messages of a chat room after since_message_id, oldest first. Without since_message_id the device's cursor is used. Returned messages are marked delivered and the device's cursor moves past them; pass the returned cursor as since_message_id while has_more is true.
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param since_message_id query int false "Return messages after this one"
+// @Param device_id query string false "Device whose cursor is read and advanced"
+// @Param limit query int false "Messages to return (default 100, max 500)"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/sync [get]
+func (sc *ChatSyncController) SyncRoomMessages(c *gin.Context) {
+	sc.syncMessages(c, models.ChatThreadRoom, "room_id")
+}
+
+// SyncConversationMessages gets the messages of a conversation after a message
+// @Summary Sync conversation messages
+// @Description Get the messages of a conversation after since_message_id, oldest first. Without since_message_id the device's cursor is used. Returned messages are marked delivered and the device's cursor moves past them; pass the returned cursor as since_message_id while has_more is true.
+// @Tags Conversations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param since_message_id query int false "Return messages after this one"
+// @Param device_id query string false "Device whose cursor is read and advanced"
+// @Param limit query int false "Messages to return (default 100, max 500)"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/sync [get]
+func (sc *ChatSyncController) SyncConversationMessages(c *gin.Context) {
+	sc.syncMessages(c, models.ChatThreadConversation, "id")
+}
+
+// UpdateRoomReceipts acknowledges chat room messages as delivered or read
+// @Summary Acknowledge chat room messages
+// @Description Mark the messages others sent in a chat room up to up_to_message_id as delivered or read. The other participants get a receipt event on the room's realtime topic.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param request body models.UpdateChatReceiptsRequest true "Receipt"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/receipts [post]
+func (sc *ChatSyncController) UpdateRoomReceipts(c *gin.Context) {
+	sc.updateReceipts(c, models.ChatThreadRoom, "room_id")
+}
+
+// UpdateConversationReceipts acknowledges conversation messages as delivered or read
+// @Summary Acknowledge conversation messages
+// @Description Mark the messages the other participant sent in a conversation up to up_to_message_id as delivered or read. They get a receipt event on the conversation's realtime topic.
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param request body models.UpdateChatReceiptsRequest true "Receipt"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/receipts [post]
+func (sc *ChatSyncController) UpdateConversationReceipts(c *gin.Context) {
+	sc.updateReceipts(c, models.ChatThreadConversation, "id")
+}
+
+// GetRoomReceipts gets the receipts of chat room messages
+// @Summary Get chat room message receipts
+// @Description Get when each participant received and read the given messages of a chat room
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param message_ids query string true "Comma separated message IDs"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/receipts [get]
+func (sc *ChatSyncController) GetRoomReceipts(c *gin.Context) {
+	sc.getReceipts(c, models.ChatThreadRoom, "room_id")
+}
+
+// GetConversationReceipts gets the receipts of conversation messages
+// @Summary Get conversation message receipts
+// @Description Get when the participants received and read the given messages of a conversation
+// @Tags Conversations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param message_ids query string true "Comma separated message IDs"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/receipts [get]
+func (sc *ChatSyncController) GetConversationReceipts(c *gin.Context) {
+	sc.getReceipts(c, models.ChatThreadConversation, "id")
+}
+
+// SetRoomTyping sends a typing indicator to a chat room
+// @Summary Send chat room typing indicator
+// @Description Tell the other participants of a chat room whether the user is typing, through a typing event on the room's realtime topic
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param room_id path int true "Chat room ID"
+// @Param request body models.ChatTypingRequest true "Typing indicator"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /chat/rooms/{room_id}/typing [post]
+func (sc *ChatSyncController) SetRoomTyping(c *gin.Context) {
+	sc.setTyping(c, models.ChatThreadRoom, "room_id")
+}
+
+// SetConversationTyping sends a typing indicator to a conversation
+// @Summary Send conversation typing indicator
+// @Description Tell the other participant of a conversation whether the user is typing, through a typing event on the conversation's realtime topic
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param request body models.ChatTypingRequest true "Typing indicator"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 403 {object} views.Response
+// @Router /conversations/{id}/typing [post]
+func (sc *ChatSyncController) SetConversationTyping(c *gin.Context) {
+	sc.setTyping(c, models.ChatThreadConversation, "id")
+}
+
+// syncMessages handles a sync request for a thread
+func (sc *ChatSyncController) syncMessages(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	var req models.ChatSyncRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	response, err := sc.syncService.SyncMessages(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, &req)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to sync messages", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Messages synced successfully", response))
+}
+
+// updateReceipts handles a receipt for a thread
+func (sc *ChatSyncController) updateReceipts(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateChatReceiptsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	if err := sc.syncService.UpdateReceipts(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, &req); err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to update receipts", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Receipts updated successfully", nil))
+}
+
+// getReceipts handles a receipts request for a thread
+func (sc *ChatSyncController) getReceipts(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	var messageIDs []uint
+	for _, value := range strings.Split(c.Query("message_ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		messageID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid message ID", err.Error()))
+			return
+		}
+		messageIDs = append(messageIDs, uint(messageID))
+	}
+	if len(messageIDs) == 0 {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", "message_ids is required"))
+		return
+	}
+
+	receipts, err := sc.syncService.GetReceipts(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, messageIDs)
+	if err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to get receipts", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Receipts retrieved successfully", gin.H{
+		"receipts": receipts,
+	}))
+}
+
+// setTyping handles a typing indicator for a thread
+func (sc *ChatSyncController) setTyping(c *gin.Context, threadType models.ChatThreadType, param string) {
+	threadID, ok := threadIDParam(c, param)
+	if !ok {
+		return
+	}
+
+	var req models.ChatTypingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	if err := sc.syncService.SetTyping(c.GetUint("user_id"), c.GetString("user_type"), threadType, threadID, req.Typing); err != nil {
+		c.JSON(chatSyncErrorStatus(err), views.CreateErrorResponse("Failed to send typing indicator", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Typing indicator sent successfully", nil))
+}
+
+// threadIDParam reads the chat room or conversation ID from the path
+func threadIDParam(c *gin.Context, param string) (uint, bool) {
+	threadID, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid ID", err.Error()))
+		return 0, false
+	}
+	return uint(threadID), true
+}
+
+// chatSyncErrorStatus maps chat sync errors to HTTP statuses
+func chatSyncErrorStatus(err error) int {
+	switch {
+	case err.Error() == "access denied", strings.HasPrefix(err.Error(), "only participants"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -255,6 +255,53 @@ func (cc *ChatbotController) GetUserSessions(c *gin.Context) {
 	c.JSON(http.StatusOK, views.CreateSuccessResponse("Sessions retrieved successfully", sessions))
 }
 
+// GetLeads godoc
+// @Summary Get chatbot property leads
+// @Description Get logged-in users who recently searched for property through the chatbot; requires a plan with chatbot lead access
+// @Tags Chatbot
+// @Accept json
+// @Produce json
+// @Param limit query int false "Number of leads to return (max 100)"
+// @Success 200 {object} views.Response{data=[]models.ChatbotLead} "Leads retrieved successfully"
+// @Failure 401 {object} views.Response "Unauthorized"
+// @Failure 403 {object} views.Response "Plan does not include chatbot leads"
+// @Failure 500 {object} views.Response "Internal server error"
+// @Router /api/v1/chatbot/leads [get]
+// @Security ApiKeyAuth
+func (cc *ChatbotController) GetLeads(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("Unauthorized", "User not authenticated"))
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("Unauthorized", "Invalid user ID"))
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	leads, err := cc.chatbotService.GetLeads(uid, limit)
+	if err != nil {
+		logrus.Errorf("ChatbotController.GetLeads service error: %v", err)
+		if services.IsEntitlementError(err) {
+			c.JSON(http.StatusForbidden, views.CreateErrorResponse("Chatbot leads not available", err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to retrieve leads", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Leads retrieved successfully", leads))
+}
+
 // DeleteSession godoc
 // @Summary Delete a chatbot session
 // @Description Delete a chatbot session (soft delete)
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CouponController handles coupon HTTP requests
+type CouponController struct {
+	BaseController
+	couponService *services.CouponService
+}
+
+// NewCouponController creates a new instance of CouponController
+func NewCouponController() *CouponController {
+	return &CouponController{
+		BaseController: *NewBaseController(),
+		couponService:  services.NewCouponService(),
+	}
+}
+
+// ValidateCoupon previews a coupon on a booking, quote payment or subscription before paying
+func (cc *CouponController) ValidateCoupon(c *gin.Context) {
+	userID := cc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ValidateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	application, err := cc.couponService.Preview(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Coupon cannot be applied", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupon": application,
+	})
+}
+
+// GetCoupons gets coupons (admin)
+func (cc *CouponController) GetCoupons(c *gin.Context) {
+	filters := &models.CouponFilters{
+		Search: c.Query("search"),
+	}
+	if isActive, err := strconv.ParseBool(c.Query("is_active")); err == nil {
+		filters.IsActive = &isActive
+	}
+	filters.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	coupons, pagination, err := cc.couponService.GetCoupons(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coupons", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupons":    coupons,
+		"pagination": pagination,
+	})
+}
+
+// GetCoupon gets a coupon (admin)
+func (cc *CouponController) GetCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	coupon, err := cc.couponService.GetCoupon(uint(couponID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupon": coupon,
+	})
+}
+
+// CreateCoupon creates a coupon (admin)
+func (cc *CouponController) CreateCoupon(c *gin.Context) {
+	adminID := cc.GetUserID(c)
+
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	coupon, err := cc.couponService.CreateCoupon(adminID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create coupon", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Coupon created successfully",
+		"coupon":  coupon,
+	})
+}
+
+// UpdateCoupon updates a coupon (admin)
+func (cc *CouponController) UpdateCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	coupon, err := cc.couponService.UpdateCoupon(uint(couponID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update coupon", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon updated successfully",
+		"coupon":  coupon,
+	})
+}
+
+// DeleteCoupon deletes a coupon (admin)
+func (cc *CouponController) DeleteCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	if err := cc.couponService.DeleteCoupon(uint(couponID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete coupon", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon deleted successfully",
+	})
+}
+
+// GetCouponRedemptions gets the redemptions of a coupon (admin)
+func (cc *CouponController) GetCouponRedemptions(c *gin.Context) {
+	couponID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	redemptions, pagination, err := cc.couponService.GetRedemptions(uint(couponID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coupon redemptions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"redemptions": redemptions,
+		"pagination":  pagination,
+	})
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceController handles invoice, credit note and billing detail HTTP requests
+type InvoiceController struct {
+	BaseController
+	invoiceService *services.InvoiceService
+}
+
+// NewInvoiceController creates a new instance of InvoiceController
+func NewInvoiceController() *InvoiceController {
+	return &InvoiceController{
+		BaseController: *NewBaseController(),
+		invoiceService: services.NewInvoiceService(),
+	}
+}
+
+// GetMyInvoices gets the user's invoices, credit notes and receipts
+func (ic *InvoiceController) GetMyInvoices(c *gin.Context) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filters, ok := invoiceFiltersFromQuery(c)
+	if !ok {
+		return
+	}
+	filters.UserID = userID
+
+	invoices, pagination, err := ic.invoiceService.GetInvoices(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invoices", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices":   invoices,
+		"pagination": pagination,
+	})
+}
+
+// GetMyInvoice gets one of the user's invoices
+func (ic *InvoiceController) GetMyInvoice(c *gin.Context) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoice, err := ic.invoiceService.GetUserInvoice(userID, uint(invoiceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": invoice,
+	})
+}
+
+// DownloadMyInvoice downloads one of the user's invoices as a PDF
+func (ic *InvoiceController) DownloadMyInvoice(c *gin.Context) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoice, err := ic.invoiceService.GetUserInvoice(userID, uint(invoiceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found", "details": err.Error()})
+		return
+	}
+
+	ic.sendPDF(c, invoice)
+}
+
+// GetPaymentInvoice gets the billing document of one of the user's payments, issuing it if needed
+func (ic *InvoiceController) GetPaymentInvoice(c *gin.Context) {
+	invoice, ok := ic.paymentInvoice(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": invoice,
+	})
+}
+
+// DownloadPaymentInvoice downloads the billing document of one of the user's payments as a PDF
+func (ic *InvoiceController) DownloadPaymentInvoice(c *gin.Context) {
+	invoice, ok := ic.paymentInvoice(c)
+	if !ok {
+		return
+	}
+
+	ic.sendPDF(c, invoice)
+}
+
+// GetBillingDetails gets the business name and GSTIN printed on the user's invoices
+func (ic *InvoiceController) GetBillingDetails(c *gin.Context) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	details, err := ic.invoiceService.GetBillingDetails(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get billing details", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"billing_details": details,
+	})
+}
+
+// UpdateBillingDetails updates the business name and GSTIN printed on the user's future invoices
+func (ic *InvoiceController) UpdateBillingDetails(c *gin.Context) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdateBillingDetailsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	details, err := ic.invoiceService.UpdateBillingDetails(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update billing details", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Billing details updated successfully",
+		"billing_details": details,
+	})
+}
+
+// AdminGetInvoices gets invoices across users (admin)
+func (ic *InvoiceController) AdminGetInvoices(c *gin.Context) {
+	filters, ok := invoiceFiltersFromQuery(c)
+	if !ok {
+		return
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filters.UserID = uint(userID)
+	}
+
+	invoices, pagination, err := ic.invoiceService.GetInvoices(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invoices", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices":   invoices,
+		"pagination": pagination,
+	})
+}
+
+// AdminDownloadInvoice downloads any invoice as a PDF (admin)
+func (ic *InvoiceController) AdminDownloadInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoice, err := ic.invoiceService.GetInvoice(uint(invoiceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found", "details": err.Error()})
+		return
+	}
+
+	ic.sendPDF(c, invoice)
+}
+
+// AdminDownloadInvoiceRegister downloads the CSV register of a month's documents (admin)
+func (ic *InvoiceController) AdminDownloadInvoiceRegister(c *gin.Context) {
+	month := c.Query("month")
+	if month == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month is required", "details": "Use YYYY-MM format"})
+		return
+	}
+
+	file, filename, err := ic.invoiceService.GetInvoiceRegister(month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to build invoice register", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/csv", file)
+}
+
+// AdminGetTaxCodes gets the SAC codes and GST rates of categories (admin)
+func (ic *InvoiceController) AdminGetTaxCodes(c *gin.Context) {
+	taxCodes, err := ic.invoiceService.GetTaxCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tax codes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tax_codes": taxCodes,
+	})
+}
+
+// AdminSetTaxCode sets the SAC code and GST rate of a category (admin)
+func (ic *InvoiceController) AdminSetTaxCode(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("category_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req models.UpdateCategoryTaxCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	taxCode, err := ic.invoiceService.SetTaxCode(uint(categoryID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to set tax code", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Tax code updated successfully",
+		"tax_code": taxCode,
+	})
+}
+
+// paymentInvoice gets or issues the billing document of the payment in the path
+func (ic *InvoiceController) paymentInvoice(c *gin.Context) (*models.Invoice, bool) {
+	userID := ic.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	paymentID, err := strconv.ParseUint(c.Param("payment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return nil, false
+	}
+
+	invoice, err := ic.invoiceService.GetOrIssueForPayment(userID, uint(paymentID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get invoice", "details": err.Error()})
+		return nil, false
+	}
+	return invoice, true
+}
+
+func (ic *InvoiceController) sendPDF(c *gin.Context, invoice *models.Invoice) {
+	file, filename := ic.invoiceService.RenderPDF(invoice)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", file)
+}
+
+// invoiceFiltersFromQuery reads invoice list filters (?document_type=&from=&to= YYYY-MM-DD)
+func invoiceFiltersFromQuery(c *gin.Context) (*models.InvoiceFilters, bool) {
+	from, to, ok := statementPeriodFromQuery(c)
+	if !ok {
+		return nil, false
+	}
+
+	filters := &models.InvoiceFilters{
+		DocumentType: c.Query("document_type"),
+		From:         from,
+		To:           to,
+	}
+	filters.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	return filters, true
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"treesindia/models"
@@ -11,12 +13,14 @@ import (
 )
 
 type LedgerController struct {
-	ledgerService *services.LedgerService
+	ledgerService  *services.LedgerService
+	journalService *services.LedgerJournalService
 }
 
 func NewLedgerController() *LedgerController {
 	return &LedgerController{
-		ledgerService: services.NewLedgerService(),
+		ledgerService:  services.NewLedgerService(),
+		journalService: services.NewLedgerJournalService(),
 	}
 }
 
@@ -95,7 +99,7 @@ func (lc *LedgerController) UpdateEntry(c *gin.Context) {
 	entry, err := lc.ledgerService.UpdateEntry(uint(id), &req, adminID.(uint))
 	if err != nil {
 		logrus.Errorf("Failed to update ledger entry: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -118,7 +122,7 @@ func (lc *LedgerController) DeleteEntry(c *gin.Context) {
 	err = lc.ledgerService.DeleteEntry(uint(id))
 	if err != nil {
 		logrus.Errorf("Failed to delete ledger entry: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -229,7 +233,7 @@ func (lc *LedgerController) ProcessPayment(c *gin.Context) {
 	entry, err := lc.ledgerService.ProcessPayment(uint(id), &req, adminID.(uint))
 	if err != nil {
 		logrus.Errorf("Failed to process payment: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -265,7 +269,7 @@ func (lc *LedgerController) ProcessReceive(c *gin.Context) {
 	entry, err := lc.ledgerService.ProcessReceive(uint(id), &req, adminID.(uint))
 	if err != nil {
 		logrus.Errorf("Failed to process receive: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -334,3 +338,368 @@ func (lc *LedgerController) GetSummary(c *gin.Context) {
 		"data":    summary,
 	})
 }
+
+// GetAccounts gets the chart of accounts
+func (lc *LedgerController) GetAccounts(c *gin.Context) {
+	includeInactive := c.Query("include_inactive") == "true"
+
+	accounts, err := lc.journalService.GetAccounts(includeInactive)
+	if err != nil {
+		logrus.Errorf("Failed to get ledger accounts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    accounts,
+	})
+}
+
+// CreateAccount adds an account to the chart of accounts
+func (lc *LedgerController) CreateAccount(c *gin.Context) {
+	var req models.CreateLedgerAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := lc.journalService.CreateAccount(&req)
+	if err != nil {
+		logrus.Errorf("Failed to create ledger account: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Ledger account created successfully",
+		"data":    account,
+	})
+}
+
+// UpdateAccount renames or deactivates an account
+func (lc *LedgerController) UpdateAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+
+	var req models.UpdateLedgerAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := lc.journalService.UpdateAccount(uint(id), &req)
+	if err != nil {
+		logrus.Errorf("Failed to update ledger account: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Ledger account updated successfully",
+		"data":    account,
+	})
+}
+
+// GetJournals gets double-entry journals with pagination and filters
+func (lc *LedgerController) GetJournals(c *gin.Context) {
+	// Parse pagination parameters
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit > 100 {
+		limit = 100 // Max limit
+	}
+
+	filters := &models.LedgerJournalFilters{
+		SourceType:  c.Query("source_type"),
+		AccountCode: c.Query("account_code"),
+		Offset:      offset,
+		Limit:       limit,
+	}
+	if vendorID, err := strconv.ParseUint(c.Query("vendor_id"), 10, 32); err == nil {
+		filters.VendorID = uint(vendorID)
+	}
+
+	journals, total, err := lc.journalService.GetJournals(filters, c.Query("from"), c.Query("to"))
+	if err != nil {
+		logrus.Errorf("Failed to get ledger journals: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"journals": journals,
+			"total":    total,
+			"offset":   offset,
+			"limit":    limit,
+		},
+	})
+}
+
+// GetJournal gets a journal with its postings
+func (lc *LedgerController) GetJournal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid journal ID"})
+		return
+	}
+
+	journal, err := lc.journalService.GetJournal(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    journal,
+	})
+}
+
+// CreateJournal posts a manual journal
+func (lc *LedgerController) CreateJournal(c *gin.Context) {
+	var req models.CreateLedgerJournalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	journal, err := lc.journalService.CreateJournal(&req, adminID.(uint))
+	if err != nil {
+		logrus.Errorf("Failed to post ledger journal: %v", err)
+		c.JSON(ledgerErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Journal posted successfully",
+		"data":    journal,
+	})
+}
+
+// ReverseJournal posts a journal reversing another
+func (lc *LedgerController) ReverseJournal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid journal ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	journal, err := lc.journalService.ReverseJournal(uint(id), req.Reason, adminID.(uint))
+	if err != nil {
+		logrus.Errorf("Failed to reverse ledger journal: %v", err)
+		c.JSON(ledgerErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Journal reversed successfully",
+		"data":    journal,
+	})
+}
+
+// PostPendingEvents posts journals for platform events the posting job has not picked up yet
+func (lc *LedgerController) PostPendingEvents(c *gin.Context) {
+	result, err := lc.journalService.PostPendingEvents()
+	if err != nil {
+		logrus.Errorf("Failed to post platform events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Platform events posted successfully",
+		"data":    result,
+	})
+}
+
+// GetPeriods gets the closed accounting periods
+func (lc *LedgerController) GetPeriods(c *gin.Context) {
+	periods, err := lc.journalService.GetPeriods()
+	if err != nil {
+		logrus.Errorf("Failed to get accounting periods: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    periods,
+	})
+}
+
+// ClosePeriod closes a month so its entries and journals become immutable
+func (lc *LedgerController) ClosePeriod(c *gin.Context) {
+	var req models.CloseAccountingPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	period, err := lc.journalService.ClosePeriod(&req, adminID.(uint))
+	if err != nil {
+		logrus.Errorf("Failed to close accounting period: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Accounting period closed successfully",
+		"data":    period,
+	})
+}
+
+// GetTrialBalance gets the trial balance as of a day, as JSON or as CSV with format=csv
+func (lc *LedgerController) GetTrialBalance(c *gin.Context) {
+	report, err := lc.journalService.GetTrialBalance(c.Query("as_of"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		file, filename, err := lc.journalService.TrialBalanceCSV(report)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "text/csv", file)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetProfitAndLoss gets the profit and loss statement of a period, as JSON or as CSV with format=csv
+func (lc *LedgerController) GetProfitAndLoss(c *gin.Context) {
+	report, err := lc.journalService.GetProfitAndLoss(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		file, filename, err := lc.journalService.ProfitAndLossCSV(report)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "text/csv", file)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// UploadEntryAttachment attaches a receipt to a ledger entry
+func (lc *LedgerController) UploadEntryAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entry ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	attachment, err := lc.journalService.AddEntryAttachment(uint(id), file, adminID.(uint))
+	if err != nil {
+		logrus.Errorf("Failed to attach receipt to ledger entry: %v", err)
+		c.JSON(ledgerErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Receipt attached successfully",
+		"data":    attachment,
+	})
+}
+
+// DeleteEntryAttachment removes a receipt from a ledger entry
+func (lc *LedgerController) DeleteEntryAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entry ID"})
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment ID"})
+		return
+	}
+
+	if err := lc.journalService.DeleteEntryAttachment(uint(id), uint(attachmentID)); err != nil {
+		logrus.Errorf("Failed to delete ledger attachment: %v", err)
+		c.JSON(ledgerErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Receipt removed successfully",
+	})
+}
+
+// ledgerErrorStatus maps changes rejected for touching a closed accounting period to 409 and
+// other errors to the given status
+func ledgerErrorStatus(err error, status int) int {
+	if errors.Is(err, services.ErrPeriodClosed) {
+		return http.StatusConflict
+	}
+	return status
+}
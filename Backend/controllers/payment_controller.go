@@ -66,7 +66,7 @@ func (pc *PaymentController) CreateRazorpayOrder(c *gin.Context) {
 	}
 	req.UserID = userID
 
-	payment, razorpayOrder, err := pc.paymentService.CreateRazorpayOrder(&req)
+	payment, razorpayOrder, err := pc.paymentService.CreateGatewayOrder(&req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Razorpay order", "details": err.Error()})
 		return
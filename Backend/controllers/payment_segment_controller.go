@@ -11,12 +11,14 @@ import (
 )
 
 type PaymentSegmentController struct {
-	quoteService *services.QuoteService
+	quoteService      *services.QuoteService
+	collectionService *services.PaymentSegmentCollectionService
 }
 
 func NewPaymentSegmentController() *PaymentSegmentController {
 	return &PaymentSegmentController{
-		quoteService: services.NewQuoteService(),
+		quoteService:      services.NewQuoteService(),
+		collectionService: services.NewPaymentSegmentCollectionService(),
 	}
 }
 
@@ -260,3 +262,51 @@ func (psc *PaymentSegmentController) GetPaidSegments(c *gin.Context) {
 		"data":    segments,
 	})
 }
+
+// SetAutoDebit turns wallet auto-debit on or off for unpaid payment segments
+// @Summary Set payment segment auto-debit
+// @Description Opt in to paying unpaid payment segments from the wallet when they fall due, for one segment or all of them
+// @Tags Payment Segments
+// @Accept json
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param request body models.SegmentAutoDebitRequest true "Auto-debit settings"
+// @Success 200 {object} models.PaymentProgress
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /bookings/{id}/payment-segments/auto-debit [put]
+func (psc *PaymentSegmentController) SetAutoDebit(c *gin.Context) {
+	// Get booking ID from URL
+	bookingIDStr := c.Param("id")
+	bookingID, err := strconv.ParseUint(bookingIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Parse request body
+	var req models.SegmentAutoDebitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	progress, err := psc.collectionService.SetAutoDebit(uint(bookingID), userID.(uint), &req)
+	if err != nil {
+		logrus.Errorf("Failed to set auto-debit for booking %d: %v", bookingID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
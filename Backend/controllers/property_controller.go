@@ -73,6 +73,7 @@ func NewPropertyController() *PropertyController {
 // @Success 201 {object} views.SuccessResponse
 // @Failure 400 {object} views.ErrorResponse
 // @Failure 401 {object} views.ErrorResponse
+// @Failure 403 {object} views.ErrorResponse
 // @Router /api/v1/user/properties [post]
 func (pc *PropertyController) CreateProperty(c *gin.Context) {
 	logrus.Infof("PropertyController.CreateProperty called")
@@ -109,7 +110,11 @@ func (pc *PropertyController) CreateProperty(c *gin.Context) {
 	err = pc.propertyService.CreateProperty(&property, userID.(uint))
 	if err != nil {
 		logrus.Errorf("PropertyController.CreateProperty service error: %v", err)
-		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to create property", err.Error()))
+		status := http.StatusBadRequest
+		if services.IsEntitlementError(err) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, views.CreateErrorResponse("Failed to create property", err.Error()))
 		return
 	}
 	
@@ -775,6 +780,60 @@ func (pc *PropertyController) DeleteUserProperty(c *gin.Context) {
 	c.JSON(http.StatusOK, views.CreateSuccessResponse("Property deleted successfully", nil))
 }
 
+// SetPropertyFeatured features or unfeatures a user's own property
+// @Summary Feature user property
+// @Description Feature or unfeature a property owned by the authenticated user using the featured listing slots of their plan
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param featured body map[string]interface{} true "Featured flag"
+// @Success 200 {object} views.SuccessResponse
+// @Failure 400 {object} views.ErrorResponse
+// @Failure 401 {object} views.ErrorResponse
+// @Failure 403 {object} views.ErrorResponse
+// @Router /api/v1/user/properties/{id}/featured [put]
+// @Security ApiKeyAuth
+func (pc *PropertyController) SetPropertyFeatured(c *gin.Context) {
+	logrus.Infof("PropertyController.SetPropertyFeatured called")
+	
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logrus.Errorf("PropertyController.SetPropertyFeatured user_id not found in context")
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("Unauthorized", "User not authenticated"))
+		return
+	}
+	
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logrus.Errorf("PropertyController.SetPropertyFeatured invalid ID: %v", err)
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid property ID", "ID must be a valid number"))
+		return
+	}
+	
+	var req struct {
+		Featured *bool `json:"featured" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Errorf("PropertyController.SetPropertyFeatured invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request", err.Error()))
+		return
+	}
+	
+	property, err := pc.propertyService.SetPropertyFeatured(uint(id), userID.(uint), *req.Featured)
+	if err != nil {
+		logrus.Errorf("PropertyController.SetPropertyFeatured service error: %v", err)
+		status := http.StatusBadRequest
+		if services.IsEntitlementError(err) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, views.CreateErrorResponse("Failed to update featured listing", err.Error()))
+		return
+	}
+	
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Featured listing updated successfully", property))
+}
+
 // UpdatePropertyStatus updates a property's status (admin only)
 // @Summary Update property status
 // @Description Update a property's status (admin only)
@@ -66,7 +66,7 @@ func (qc *QuoteController) ProvideQuote(c *gin.Context) {
 	booking, err := qc.quoteService.ProvideQuote(uint(bookingID), adminID, &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.ProvideQuote service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to provide quote", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to provide quote", err.Error()))
 		return
 	}
 
@@ -166,7 +166,7 @@ func (qc *QuoteController) AcceptQuote(c *gin.Context) {
 	booking, err := qc.quoteService.AcceptQuote(uint(bookingID), userID, &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.AcceptQuote service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to accept quote", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to accept quote", err.Error()))
 		return
 	}
 
@@ -216,7 +216,7 @@ func (qc *QuoteController) RejectQuote(c *gin.Context) {
 	booking, err := qc.quoteService.RejectQuote(uint(bookingID), userID, &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.RejectQuote service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to reject quote", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to reject quote", err.Error()))
 		return
 	}
 
@@ -266,7 +266,7 @@ func (qc *QuoteController) ScheduleAfterQuote(c *gin.Context) {
 	booking, err := qc.quoteService.ScheduleAfterQuote(uint(bookingID), userID, &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.ScheduleAfterQuote service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to schedule after quote", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to schedule after quote", err.Error()))
 		return
 	}
 
@@ -442,7 +442,7 @@ func (qc *QuoteController) CreateQuotePayment(c *gin.Context) {
 	paymentOrder, err := qc.quoteService.CreateQuotePayment(uint(bookingID), userID.(uint), &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.CreateQuotePayment service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to create payment order", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to create payment order", err.Error()))
 		return
 	}
 
@@ -497,7 +497,7 @@ func (qc *QuoteController) VerifyQuotePayment(c *gin.Context) {
 	booking, err := qc.quoteService.VerifyQuotePayment(uint(bookingID), userID.(uint), &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.VerifyQuotePayment service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to verify payment", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to verify payment", err.Error()))
 		return
 	}
 
@@ -547,7 +547,7 @@ func (qc *QuoteController) WalletPayment(c *gin.Context) {
 	booking, err := qc.quoteService.WalletPayment(uint(bookingID), userID.(uint), &req)
 	if err != nil {
 		logrus.Errorf("QuoteController.WalletPayment service error: %v", err)
-		c.JSON(500, views.CreateErrorResponse("Failed to process wallet payment", err.Error()))
+		c.JSON(qc.ErrorStatus(err, 500), views.CreateErrorResponse("Failed to process wallet payment", err.Error()))
 		return
 	}
 
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"treesindia/models"
 	"treesindia/services"
 	"treesindia/views"
 
@@ -14,6 +17,8 @@ import (
 type RazorpayController struct {
 	razorpayService *services.RazorpayService
 	unifiedWalletService *services.UnifiedWalletService
+	webhookService *services.PaymentWebhookService
+	reconciliationService *services.PaymentReconciliationService
 }
 
 // NewRazorpayController creates a new Razorpay controller
@@ -24,6 +29,8 @@ func NewRazorpayController() *RazorpayController {
 	return &RazorpayController{
 		razorpayService: razorpayService,
 		unifiedWalletService: unifiedWalletService,
+		webhookService: services.NewPaymentWebhookService(),
+		reconciliationService: services.NewPaymentReconciliationServiceWithRazorpay(razorpayService),
 	}
 }
 
@@ -60,7 +67,7 @@ func (c *RazorpayController) CreatePaymentOrder(ctx *gin.Context) {
 	}
 
 	// Create pending wallet transaction
-	transaction, _, err := c.unifiedWalletService.RechargeWallet(userID, req.Amount, "razorpay")
+	transaction, _, err := c.unifiedWalletService.RechargeWallet(userID, req.Amount)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to create wallet transaction", err.Error()))
 		return
@@ -77,7 +84,7 @@ func (c *RazorpayController) CreatePaymentOrder(ctx *gin.Context) {
 
 // HandleWebhook handles Razorpay webhook notifications
 // @Summary Handle webhook
-// @Description Handle Razorpay webhook notifications for payment status updates
+// @Description Handle Razorpay webhook notifications. Events are stored by X-Razorpay-Event-Id so a redelivered event is only processed once.
 // @Tags Razorpay
 // @Accept json
 // @Produce json
@@ -86,6 +93,26 @@ func (c *RazorpayController) CreatePaymentOrder(ctx *gin.Context) {
 // @Failure 500 {object} views.Response
 // @Router /razorpay/webhook [post]
 func (c *RazorpayController) HandleWebhook(ctx *gin.Context) {
+	c.handleGatewayWebhook(ctx, services.PaymentGatewayRazorpay)
+}
+
+// HandleGatewayWebhook handles webhook notifications of any payment gateway
+// @Summary Handle payment gateway webhook
+// @Description Handle webhook notifications of a payment gateway (razorpay, cashfree). The signature is checked the way the provider signs its webhooks.
+// @Tags Razorpay
+// @Accept json
+// @Produce json
+// @Param provider path string true "Payment gateway provider"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /payment-gateways/{provider}/webhook [post]
+func (c *RazorpayController) HandleGatewayWebhook(ctx *gin.Context) {
+	c.handleGatewayWebhook(ctx, ctx.Param("provider"))
+}
+
+// handleGatewayWebhook stores and processes a webhook delivery of a payment gateway
+func (c *RazorpayController) handleGatewayWebhook(ctx *gin.Context, provider string) {
 	// Read the request body
 	body, err := io.ReadAll(ctx.Request.Body)
 	if err != nil {
@@ -93,98 +120,225 @@ func (c *RazorpayController) HandleWebhook(ctx *gin.Context) {
 		return
 	}
 
-	// Get the signature from headers
-	signature := ctx.GetHeader("X-Razorpay-Signature")
-	if signature == "" {
-		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Missing signature", "X-Razorpay-Signature header is required"))
+	event, err := c.webhookService.HandleWebhook(provider, body, ctx.Request.Header)
+	if errors.Is(err, services.ErrInvalidWebhookSignature) {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid signature", "Webhook signature verification failed"))
 		return
 	}
-
-	// Verify webhook signature
-	if !c.razorpayService.VerifyWebhookSignature(body, signature) {
-		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid signature", "Webhook signature verification failed"))
+	if event == nil && err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid webhook payload", err.Error()))
 		return
 	}
-
-	// Parse webhook payload
-	webhookData, err := c.razorpayService.ParseWebhookPayload(body)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid webhook payload", err.Error()))
+		// Gateways retry on non-2xx responses; the stored event can also be replayed by an admin
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to process webhook", err.Error()))
 		return
 	}
 
-	// Handle payment events
-	event := webhookData["event"].(string)
-	logrus.Infof("Received Razorpay webhook event: %s", event)
+	logrus.Infof("Received %s webhook event: %s (%s)", provider, event.EventType, event.Status)
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Webhook processed successfully", gin.H{
+		"event_id": event.EventID,
+		"status":   event.Status,
+	}))
+}
 
-	switch event {
-	case "payment.captured":
-		err = c.handlePaymentCaptured(webhookData)
-	case "payment.failed":
-		err = c.handlePaymentFailed(webhookData)
-	default:
-		logrus.Infof("Unhandled webhook event: %s", event)
+// GetWebhookEvents lists stored webhook events (admin only)
+// @Summary List webhook events
+// @Description Get stored payment webhook events with optional status and event type filters
+// @Tags Razorpay
+// @Produce json
+// @Param status query string false "Event status"
+// @Param event_type query string false "Event type"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/payment-webhooks [get]
+func (c *RazorpayController) GetWebhookEvents(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	filters := &models.WebhookEventFilters{
+		Provider:  ctx.Query("provider"),
+		EventType: ctx.Query("event_type"),
+		Status:    ctx.Query("status"),
+		Page:      page,
+		Limit:     limit,
 	}
 
+	events, pagination, err := c.webhookService.GetEvents(filters)
 	if err != nil {
-		logrus.Errorf("Error handling webhook event %s: %v", event, err)
-		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to process webhook", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to fetch webhook events", err.Error()))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Webhook processed successfully", nil))
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Webhook events retrieved successfully", gin.H{
+		"events":     events,
+		"pagination": pagination,
+	}))
 }
 
-// handlePaymentCaptured handles successful payment events
-func (c *RazorpayController) handlePaymentCaptured(webhookData map[string]interface{}) error {
-	payload := webhookData["payload"].(map[string]interface{})
-	entity := payload["payment"].(map[string]interface{})
+// GetWebhookEvent gets a stored webhook event (admin only)
+// @Summary Get webhook event
+// @Tags Razorpay
+// @Produce json
+// @Param id path int true "Webhook event ID"
+// @Success 200 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Router /admin/payment-webhooks/{id} [get]
+func (c *RazorpayController) GetWebhookEvent(ctx *gin.Context) {
+	eventID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid webhook event ID", err.Error()))
+		return
+	}
 
-	paymentID := entity["id"].(string)
-	orderID := entity["order_id"].(string)
+	event, err := c.webhookService.GetEventByID(uint(eventID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, views.CreateErrorResponse("Webhook event not found", err.Error()))
+		return
+	}
 
-	// Get payment details
-	paymentDetails, err := c.razorpayService.GetPaymentDetails(paymentID)
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Webhook event retrieved successfully", event))
+}
+
+// ReplayWebhookEvent processes a failed or stuck webhook event again (admin only)
+// @Summary Replay webhook event
+// @Description Process a failed payment webhook event, or one left processing for over 10 minutes, again
+// @Tags Razorpay
+// @Produce json
+// @Param id path int true "Webhook event ID"
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/payment-webhooks/{id}/replay [post]
+func (c *RazorpayController) ReplayWebhookEvent(ctx *gin.Context) {
+	eventID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		return err
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid webhook event ID", err.Error()))
+		return
 	}
 
-	// Verify payment was successful
-	if !c.razorpayService.IsPaymentSuccessful(paymentDetails) {
-		return nil // Payment not successful, ignore
+	event, err := c.webhookService.ReplayEvent(uint(eventID))
+	if event == nil && err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to replay webhook event", err.Error()))
+		return
 	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Webhook event failed again", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Webhook event replayed successfully", event))
+}
 
-	// Get amount from payment
-	amount, err := c.razorpayService.GetPaymentAmount(paymentDetails)
+// RunPaymentReconciliation reconciles payments with Razorpay now (admin only)
+// @Summary Run payment reconciliation
+// @Description Check pending, expired and abandoned payments against their Razorpay orders and completed payments against settlements
+// @Tags Razorpay
+// @Produce json
+// @Success 200 {object} views.Response{data=models.PaymentReconciliationResult}
+// @Failure 500 {object} views.Response
+// @Router /admin/payment-reconciliation/run [post]
+func (c *RazorpayController) RunPaymentReconciliation(ctx *gin.Context) {
+	result, err := c.reconciliationService.Reconcile()
 	if err != nil {
-		return err
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to reconcile payments", err.Error()))
+		return
 	}
 
-	// Find and complete the wallet transaction
-	// Note: In a real implementation, you'd need to store the mapping between order ID and transaction ID
-	// For now, we'll use the order ID as the reference ID
-	logrus.Infof("Payment captured: %s, Order: %s, Amount: ₹%.2f", paymentID, orderID, amount)
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Payment reconciliation completed", result))
+}
 
-	// TODO: Implement transaction completion logic
-	// This would involve finding the pending transaction by order ID and completing it
+// GetPaymentDiscrepancies lists payment discrepancies found by reconciliation (admin only)
+// @Summary List payment discrepancies
+// @Tags Razorpay
+// @Produce json
+// @Param status query string false "Discrepancy status (open, resolved)"
+// @Param discrepancy_type query string false "Discrepancy type"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/payment-reconciliation/discrepancies [get]
+func (c *RazorpayController) GetPaymentDiscrepancies(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	filters := &models.PaymentDiscrepancyFilters{
+		Status:          ctx.Query("status"),
+		DiscrepancyType: ctx.Query("discrepancy_type"),
+		Page:            page,
+		Limit:           limit,
+	}
+
+	discrepancies, pagination, err := c.reconciliationService.GetDiscrepancies(filters)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to fetch payment discrepancies", err.Error()))
+		return
+	}
 
-	return nil
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Payment discrepancies retrieved successfully", gin.H{
+		"discrepancies": discrepancies,
+		"pagination":    pagination,
+	}))
 }
 
-// handlePaymentFailed handles failed payment events
-func (c *RazorpayController) handlePaymentFailed(webhookData map[string]interface{}) error {
-	payload := webhookData["payload"].(map[string]interface{})
-	entity := payload["payment"].(map[string]interface{})
+// ExportPaymentDiscrepancies downloads the reconciliation report as CSV (admin only)
+// @Summary Export payment reconciliation report
+// @Tags Razorpay
+// @Produce text/csv
+// @Param status query string false "Discrepancy status (open, resolved)"
+// @Param discrepancy_type query string false "Discrepancy type"
+// @Success 200 {file} file
+// @Failure 500 {object} views.Response
+// @Router /admin/payment-reconciliation/discrepancies/export [get]
+func (c *RazorpayController) ExportPaymentDiscrepancies(ctx *gin.Context) {
+	filters := &models.PaymentDiscrepancyFilters{
+		Status:          ctx.Query("status"),
+		DiscrepancyType: ctx.Query("discrepancy_type"),
+	}
 
-	paymentID := entity["id"].(string)
-	orderID := entity["order_id"].(string)
+	file, filename, err := c.reconciliationService.ExportDiscrepancies(filters)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to export payment discrepancies", err.Error()))
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "text/csv", file)
+}
+
+// ResolvePaymentDiscrepancy marks a payment discrepancy resolved (admin only)
+// @Summary Resolve payment discrepancy
+// @Tags Razorpay
+// @Accept json
+// @Produce json
+// @Param id path int true "Discrepancy ID"
+// @Param request body models.ResolvePaymentDiscrepancyRequest true "Resolution notes"
+// @Success 200 {object} views.Response{data=models.PaymentDiscrepancy}
+// @Failure 400 {object} views.Response
+// @Router /admin/payment-reconciliation/discrepancies/{id}/resolve [put]
+func (c *RazorpayController) ResolvePaymentDiscrepancy(ctx *gin.Context) {
+	discrepancyID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid discrepancy ID", err.Error()))
+		return
+	}
 
-	logrus.Infof("Payment failed: %s, Order: %s", paymentID, orderID)
+	var req models.ResolvePaymentDiscrepancyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request data", err.Error()))
+		return
+	}
 
-	// TODO: Implement failed payment handling
-	// This would involve updating the transaction status to failed
+	discrepancy, err := c.reconciliationService.ResolveDiscrepancy(uint(discrepancyID), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to resolve payment discrepancy", err.Error()))
+		return
+	}
 
-	return nil
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Payment discrepancy resolved successfully", discrepancy))
 }
 
 // VerifyPayment verifies a payment signature
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"treesindia/middleware"
+	"treesindia/models"
+	"treesindia/services"
+	"treesindia/views"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// RealtimeController handles the realtime gateway WebSocket endpoint
+type RealtimeController struct {
+	gateway *services.RealtimeGateway
+}
+
+// NewRealtimeController creates a new realtime controller
+func NewRealtimeController() *RealtimeController {
+	return &RealtimeController{
+		gateway: services.GetRealtimeGateway(),
+	}
+}
+
+// realtimeUpgrader upgrades realtime gateway connections
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		// Allow connections from any origin, like the other WebSocket endpoints
+		return true
+	},
+}
+
+// HandleWebSocket opens a realtime gateway connection
+// @Summary Open realtime connection
+// @Description Open the realtime WebSocket connection of a device. Authenticate with an access token in the Authorization header or the token query parameter. A device_id replaces an older connection of the same device. Messages are versioned envelopes; clients subscribe to booking:<id>, chat_room:<id>, conversation:<id>, notifications:<user id> and, for admins, admin topics, and get an ack or error for every request with an id. Chat room and conversation topics accept sync, delivered, read and typing publishes.
+// @Tags Realtime
+// @Param token query string false "Access token, when the Authorization header cannot be set"
+// @Param device_id query string false "Device the connection belongs to"
+// @Success 101 "Switching Protocols"
+// @Failure 401 {object} views.Response
+// @Router /realtime/ws [get]
+func (rc *RealtimeController) HandleWebSocket(c *gin.Context) {
+	user, err := rc.authenticate(c)
+	if err != nil {
+		logrus.Warnf("Realtime connection rejected: %v", err)
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("Unauthorized", err.Error()))
+		return
+	}
+
+	conn, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Errorf("Failed to upgrade realtime connection for user %d: %v", user.ID, err)
+		return
+	}
+
+	rc.gateway.Serve(conn, user, c.Query("device_id"))
+}
+
+// GetStats returns realtime gateway connection statistics
+// @Summary Get realtime statistics
+// @Description Get the number of open realtime connections, connected users and topics on this replica, and the broker fanning events out between replicas (admin only)
+// @Tags Realtime
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} views.Response
+// @Router /admin/realtime/stats [get]
+func (rc *RealtimeController) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Realtime statistics retrieved successfully", rc.gateway.Stats()))
+}
+
+// authenticate reads the access token of a connection request, from the Authorization header or the
+// token query parameter, and loads its active user
+func (rc *RealtimeController) authenticate(c *gin.Context) (*models.User, error) {
+	token := c.Query("token")
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		tokenParts := strings.Split(authHeader, " ")
+		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+			return nil, errors.New("invalid authorization header format")
+		}
+		token = tokenParts[1]
+	}
+	if token == "" {
+		return nil, errors.New("authentication token required")
+	}
+
+	return middleware.AuthenticateAccessToken(token)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"treesindia/models"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralController handles referral HTTP requests
+type ReferralController struct {
+	BaseController
+	referralService *services.ReferralService
+}
+
+// NewReferralController creates a new instance of ReferralController
+func NewReferralController() *ReferralController {
+	return &ReferralController{
+		BaseController:  *NewBaseController(),
+		referralService: services.NewReferralService(),
+	}
+}
+
+// GetMyReferrals gets the user's referral code, their referrals and what they earned
+func (rc *ReferralController) GetMyReferrals(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	summary, err := rc.referralService.GetSummary(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get referrals", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"referral": summary,
+	})
+}
+
+// ApplyReferralCode applies the referral code of the user who referred them
+func (rc *ReferralController) ApplyReferralCode(c *gin.Context) {
+	userID := rc.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ApplyReferralCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	referral, err := rc.referralService.ApplyCode(userID, req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to apply referral code", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Referral code applied successfully",
+		"referral": referral,
+	})
+}
+
+// AdminGetReferrals gets referrals (admin)
+func (rc *ReferralController) AdminGetReferrals(c *gin.Context) {
+	filters := &models.ReferralFilters{
+		Status: c.Query("status"),
+	}
+	filters.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	referrals, pagination, err := rc.referralService.GetReferrals(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get referrals", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"referrals":  referrals,
+		"pagination": pagination,
+	})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 	"treesindia/models"
 	"treesindia/services"
 	"treesindia/views"
@@ -13,6 +14,8 @@ import (
 type UserSubscriptionController struct {
 	*BaseController
 	subscriptionService *services.UserSubscriptionService
+	renewalService      *services.SubscriptionRenewalService
+	entitlementService  *services.EntitlementService
 }
 
 // NewUserSubscriptionController creates a new user subscription controller
@@ -20,6 +23,8 @@ func NewUserSubscriptionController() *UserSubscriptionController {
 	return &UserSubscriptionController{
 		BaseController:     NewBaseController(),
 		subscriptionService: services.NewUserSubscriptionService(),
+		renewalService:      services.NewSubscriptionRenewalService(),
+		entitlementService:  services.NewEntitlementService(),
 	}
 }
 
@@ -28,12 +33,14 @@ type PurchaseSubscriptionRequest struct {
 	PlanID        uint   `json:"plan_id" binding:"required"`
 	PaymentMethod string `json:"payment_method" binding:"required"`
 	DurationType  string `json:"duration_type" binding:"required"`
+	CouponCode    string `json:"coupon_code"` // Optional promo code
 }
 
 // CreateSubscriptionPaymentOrderRequest represents subscription payment order request
 type CreateSubscriptionPaymentOrderRequest struct {
 	PlanID       uint   `json:"plan_id" binding:"required"`
 	DurationType string `json:"duration_type" binding:"required"`
+	CouponCode   string `json:"coupon_code"` // Optional promo code
 }
 
 // CompleteSubscriptionPurchaseRequest represents subscription purchase completion request
@@ -43,6 +50,23 @@ type CompleteSubscriptionPurchaseRequest struct {
 	RazorpaySignature string `json:"razorpay_signature" binding:"required"`
 }
 
+// SetAutoRenewRequest represents auto-renew toggle request
+type SetAutoRenewRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// RenewSubscriptionRequest represents subscription renewal request
+type RenewSubscriptionRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required"`
+}
+
+// ChangePlanRequest represents subscription plan change request
+type ChangePlanRequest struct {
+	PlanID        uint   `json:"plan_id" binding:"required"`
+	DurationType  string `json:"duration_type" binding:"required"`
+	PaymentMethod string `json:"payment_method"` // Required when an amount is due
+}
+
 // ExtendSubscriptionRequest represents subscription extension request
 type ExtendSubscriptionRequest struct {
 	Days int `json:"days" binding:"required,min=1"`
@@ -79,7 +103,7 @@ func (usc *UserSubscriptionController) PurchaseSubscription(c *gin.Context) {
 		return
 	}
 
-	subscription, err := usc.subscriptionService.PurchaseSubscription(userID.(uint), req.PlanID, req.PaymentMethod, req.DurationType)
+	subscription, err := usc.subscriptionService.PurchaseSubscription(userID.(uint), req.PlanID, req.PaymentMethod, req.DurationType, req.CouponCode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to purchase subscription", err.Error()))
 		return
@@ -113,7 +137,7 @@ func (usc *UserSubscriptionController) CreateSubscriptionPaymentOrder(c *gin.Con
 		return
 	}
 
-	payment, razorpayOrder, err := usc.subscriptionService.CreateSubscriptionPaymentOrder(userID.(uint), req.PlanID, req.DurationType)
+	payment, razorpayOrder, err := usc.subscriptionService.CreateSubscriptionPaymentOrder(userID.(uint), req.PlanID, req.DurationType, req.CouponCode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to create payment order", err.Error()))
 		return
@@ -220,6 +244,189 @@ func (usc *UserSubscriptionController) GetUserSubscriptionHistory(c *gin.Context
 	c.JSON(http.StatusOK, views.CreateSuccessResponse("Subscription history retrieved successfully", subscriptions))
 }
 
+// SetAutoRenew godoc
+// @Summary Set subscription auto-renew
+// @Description Turn auto-renewal of the current subscription on or off. Renewals are paid from the wallet, with a Razorpay order offered when the wallet cannot pay.
+// @Tags User Subscriptions
+// @Accept json
+// @Produce json
+// @Param request body SetAutoRenewRequest true "Auto-renew request"
+// @Success 200 {object} models.Response "Auto-renew updated successfully"
+// @Failure 400 {object} models.Response "Invalid request data"
+// @Failure 404 {object} models.Response "No active subscription"
+// @Router /subscriptions/auto-renew [put]
+func (usc *UserSubscriptionController) SetAutoRenew(c *gin.Context) {
+	var req SetAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request data", err.Error()))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("User not authenticated", "Please login to continue"))
+		return
+	}
+
+	subscription, err := usc.subscriptionService.SetAutoRenew(userID.(uint), *req.Enabled)
+	if err != nil {
+		c.JSON(http.StatusNotFound, views.CreateErrorResponse("Failed to update auto-renew", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Auto-renew updated successfully", subscription))
+}
+
+// RenewSubscription godoc
+// @Summary Renew subscription
+// @Description Renew the current subscription with the same plan. Wallet renewals take effect immediately; razorpay returns an order to complete through /subscriptions/complete-purchase.
+// @Tags User Subscriptions
+// @Accept json
+// @Produce json
+// @Param request body RenewSubscriptionRequest true "Renewal request"
+// @Success 200 {object} models.Response "Subscription renewed successfully"
+// @Failure 400 {object} models.Response "Invalid request data"
+// @Router /subscriptions/renew [post]
+func (usc *UserSubscriptionController) RenewSubscription(c *gin.Context) {
+	var req RenewSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request data", err.Error()))
+		return
+	}
+
+	// Validate payment method
+	if req.PaymentMethod != models.PaymentMethodWallet && req.PaymentMethod != models.PaymentMethodRazorpay {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid payment method", "Payment method must be wallet or razorpay"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("User not authenticated", "Please login to continue"))
+		return
+	}
+
+	result, err := usc.renewalService.RenewNow(userID.(uint), req.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to renew subscription", err.Error()))
+		return
+	}
+
+	if result.Subscription == nil {
+		c.JSON(http.StatusOK, views.CreateSuccessResponse("Renewal payment order created successfully", result))
+		return
+	}
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Subscription renewed successfully", result))
+}
+
+// GetPlanChangeQuote godoc
+// @Summary Preview a plan change
+// @Description Price moving the current subscription to another plan, with credit for the unused part of the current term
+// @Tags User Subscriptions
+// @Produce json
+// @Param plan_id query int true "Plan ID"
+// @Param duration_type query string true "Duration type"
+// @Success 200 {object} models.Response "Plan change quote retrieved successfully"
+// @Failure 400 {object} models.Response "Invalid request data"
+// @Router /subscriptions/change-plan/quote [get]
+func (usc *UserSubscriptionController) GetPlanChangeQuote(c *gin.Context) {
+	planID, err := strconv.ParseUint(c.Query("plan_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid plan ID", err.Error()))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("User not authenticated", "Please login to continue"))
+		return
+	}
+
+	quote, err := usc.subscriptionService.QuotePlanChange(userID.(uint), uint(planID), c.Query("duration_type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to price plan change", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Plan change quote retrieved successfully", quote))
+}
+
+// ChangePlan godoc
+// @Summary Change subscription plan
+// @Description Move the current subscription to another plan or duration. The unused part of the current term is credited; the rest is paid from the wallet or through a Razorpay order completed via /subscriptions/complete-purchase.
+// @Tags User Subscriptions
+// @Accept json
+// @Produce json
+// @Param request body ChangePlanRequest true "Plan change request"
+// @Success 200 {object} models.Response "Subscription plan changed successfully"
+// @Failure 400 {object} models.Response "Invalid request data"
+// @Router /subscriptions/change-plan [post]
+func (usc *UserSubscriptionController) ChangePlan(c *gin.Context) {
+	var req ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid request data", err.Error()))
+		return
+	}
+
+	// Validate payment method
+	if req.PaymentMethod != "" && req.PaymentMethod != models.PaymentMethodWallet && req.PaymentMethod != models.PaymentMethodRazorpay {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid payment method", "Payment method must be wallet or razorpay"))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("User not authenticated", "Please login to continue"))
+		return
+	}
+
+	result, err := usc.subscriptionService.ChangePlan(userID.(uint), req.PlanID, req.DurationType, req.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to change subscription plan", err.Error()))
+		return
+	}
+
+	if result.Subscription == nil {
+		c.JSON(http.StatusOK, views.CreateSuccessResponse("Plan change payment order created successfully", result))
+		return
+	}
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Subscription plan changed successfully", result))
+}
+
+// GetEntitlements godoc
+// @Summary Get plan entitlements
+// @Description Get the quotas and features of the user's plan, or of the free tier, with current usage and metered usage per month
+// @Tags User Subscriptions
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Response "Entitlements retrieved successfully"
+// @Failure 500 {object} models.Response "Internal server error"
+// @Router /subscriptions/entitlements [get]
+func (usc *UserSubscriptionController) GetEntitlements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, views.CreateErrorResponse("User not authenticated", "Please login to continue"))
+		return
+	}
+
+	entitlements, err := usc.entitlementService.GetAll(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to retrieve entitlements", err.Error()))
+		return
+	}
+
+	usage, err := usc.entitlementService.GetUsageHistory(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to retrieve entitlement usage", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, views.CreateSuccessResponse("Entitlements retrieved successfully", gin.H{
+		"entitlements": entitlements,
+		"usage":        usage,
+	}))
+}
+
 // ExtendSubscription godoc
 // @Summary Extend user subscription
 // @Description Extend user subscription by specified days (Admin only)
@@ -12,13 +12,15 @@ import (
 
 // WalletController handles HTTP requests for wallet operations
 type WalletController struct {
-	service *services.UnifiedWalletService
+	service               *services.UnifiedWalletService
+	reconciliationService *services.WalletReconciliationService
 }
 
 // NewWalletController creates a new wallet controller
 func NewWalletController() *WalletController {
 	return &WalletController{
-		service: services.NewUnifiedWalletService(),
+		service:               services.NewUnifiedWalletService(),
+		reconciliationService: services.NewWalletReconciliationService(),
 	}
 }
 
@@ -71,7 +73,7 @@ func (c *WalletController) RechargeWallet(ctx *gin.Context) {
 	}
 
 	// Create recharge transaction
-	payment, razorpayOrder, err := c.service.RechargeWallet(userID, req.Amount, req.PaymentMethod)
+	payment, razorpayOrder, err := c.service.RechargeWallet(userID, req.Amount)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to recharge wallet", err.Error()))
 		return
@@ -404,6 +406,148 @@ func (c *WalletController) AdminAdjustWallet(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Wallet adjusted successfully", transaction))
 }
 
+// GetWalletJournal gets the journal entries of the user's wallet
+// @Summary Get wallet journal
+// @Description Get the credits and debits posted to the authenticated user's wallet
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} views.Response
+// @Failure 401 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /wallet/journal [get]
+func (c *WalletController) GetWalletJournal(ctx *gin.Context) {
+	// Get user ID from context
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, views.CreateErrorResponse("Unauthorized", "User not authenticated"))
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if limit > 100 {
+		limit = 10
+	}
+
+	entries, pagination, err := c.service.GetWalletJournal(userID, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get wallet journal", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Wallet journal retrieved successfully", gin.H{
+		"entries":    entries,
+		"pagination": pagination,
+	}))
+}
+
+// AdminGetUserWalletJournal gets a user's wallet journal with the cached and derived balances
+// @Summary Admin get user wallet journal
+// @Description Get a user's wallet journal entries together with their cached and journal balances
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} views.Response
+// @Failure 400 {object} views.Response
+// @Failure 404 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/wallet/users/{id}/journal [get]
+func (c *WalletController) AdminGetUserWalletJournal(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Invalid user ID", err.Error()))
+		return
+	}
+
+	summary, err := c.service.GetUserWalletSummary(uint(userID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, views.CreateErrorResponse("User not found", err.Error()))
+		return
+	}
+
+	journalBalance, err := c.reconciliationService.GetJournalBalance(uint(userID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get journal balance", err.Error()))
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	entries, pagination, err := c.service.GetWalletJournal(uint(userID), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get wallet journal", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Wallet journal retrieved successfully", gin.H{
+		"cached_balance":  summary["current_balance"],
+		"journal_balance": journalBalance,
+		"entries":         entries,
+		"pagination":      pagination,
+	}))
+}
+
+// AdminGetWalletDiscrepancies gets users whose cached wallet balance disagrees with their journal
+// @Summary Admin get wallet discrepancies
+// @Description Get wallet balance discrepancies flagged by the reconciliation job
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param status query string false "Discrepancy status (open, resolved)"
+// @Param user_id query int false "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} views.Response
+// @Failure 500 {object} views.Response
+// @Router /admin/wallet/discrepancies [get]
+func (c *WalletController) AdminGetWalletDiscrepancies(ctx *gin.Context) {
+	filters := &models.WalletDiscrepancyFilters{
+		Status: ctx.Query("status"),
+	}
+	if userID, err := strconv.ParseUint(ctx.Query("user_id"), 10, 32); err == nil {
+		filters.UserID = uint(userID)
+	}
+	filters.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	discrepancies, pagination, err := c.reconciliationService.GetDiscrepancies(filters)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to get wallet discrepancies", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Wallet discrepancies retrieved successfully", gin.H{
+		"discrepancies": discrepancies,
+		"pagination":    pagination,
+	}))
+}
+
+// AdminReconcileWallets runs the wallet reconciliation immediately
+// @Summary Admin reconcile wallets
+// @Description Compare every cached wallet balance with the wallet journal and flag mismatches
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Success 200 {object} views.Response{data=models.WalletReconciliationResult}
+// @Failure 500 {object} views.Response
+// @Router /admin/wallet/reconcile [post]
+func (c *WalletController) AdminReconcileWallets(ctx *gin.Context) {
+	result, err := c.reconciliationService.ReconcileBalances()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, views.CreateErrorResponse("Failed to reconcile wallets", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Wallet reconciliation completed", result))
+}
+
 // GetTransactionByReference gets a transaction by reference ID
 // @Summary Get transaction by reference
 // @Description Get a wallet transaction by its reference ID
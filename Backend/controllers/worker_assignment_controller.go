@@ -139,7 +139,7 @@ func (wac *WorkerAssignmentController) AcceptAssignment(c *gin.Context) {
 	assignment, err := wac.workerAssignmentService.AcceptAssignment(uint(assignmentID), workerID, req.Notes)
 	if err != nil {
 		logrus.Errorf("Failed to accept assignment: %v", err)
-		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to accept assignment", err.Error()))
+		c.JSON(wac.ErrorStatus(err, http.StatusBadRequest), views.CreateErrorResponse("Failed to accept assignment", err.Error()))
 		return
 	}
 
@@ -181,7 +181,7 @@ func (wac *WorkerAssignmentController) RejectAssignment(c *gin.Context) {
 	assignment, err := wac.workerAssignmentService.RejectAssignment(uint(assignmentID), workerID, req.Reason, req.Notes)
 	if err != nil {
 		logrus.Errorf("Failed to reject assignment: %v", err)
-		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to reject assignment", err.Error()))
+		c.JSON(wac.ErrorStatus(err, http.StatusBadRequest), views.CreateErrorResponse("Failed to reject assignment", err.Error()))
 		return
 	}
 
@@ -223,7 +223,7 @@ func (wac *WorkerAssignmentController) StartAssignment(c *gin.Context) {
 	assignment, err := wac.workerAssignmentService.StartAssignment(uint(assignmentID), workerID, req.Notes)
 	if err != nil {
 		logrus.Errorf("Failed to start assignment: %v", err)
-		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to start assignment", err.Error()))
+		c.JSON(wac.ErrorStatus(err, http.StatusBadRequest), views.CreateErrorResponse("Failed to start assignment", err.Error()))
 		return
 	}
 
@@ -265,7 +265,7 @@ func (wac *WorkerAssignmentController) CompleteAssignment(c *gin.Context) {
 	assignment, err := wac.workerAssignmentService.CompleteAssignment(uint(assignmentID), workerID, req.Notes, req.MaterialsUsed, req.Photos)
 	if err != nil {
 		logrus.Errorf("Failed to complete assignment: %v", err)
-		c.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to complete assignment", err.Error()))
+		c.JSON(wac.ErrorStatus(err, http.StatusBadRequest), views.CreateErrorResponse("Failed to complete assignment", err.Error()))
 		return
 	}
 
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkerAvailabilityController handles worker schedule, leave and blocked slot HTTP requests
+type WorkerAvailabilityController struct {
+	BaseController
+	availabilityService *services.WorkerAvailabilityService
+}
+
+// NewWorkerAvailabilityController creates a new instance of WorkerAvailabilityController
+func NewWorkerAvailabilityController() *WorkerAvailabilityController {
+	return &WorkerAvailabilityController{
+		BaseController:      *NewBaseController(),
+		availabilityService: services.NewWorkerAvailabilityService(),
+	}
+}
+
+// GetSchedule gets the worker's weekly schedule
+func (wac *WorkerAvailabilityController) GetSchedule(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	schedule, err := wac.availabilityService.GetSchedule(workerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedule": schedule,
+	})
+}
+
+// UpdateSchedule replaces the worker's weekly schedule
+func (wac *WorkerAvailabilityController) UpdateSchedule(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdateWorkerScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	schedule, err := wac.availabilityService.UpdateSchedule(workerID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Schedule updated successfully",
+		"schedule": schedule,
+	})
+}
+
+// RequestLeave submits a leave request for admin approval
+func (wac *WorkerAvailabilityController) RequestLeave(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	leave, err := wac.availabilityService.RequestLeave(workerID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to request leave", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Leave requested successfully",
+		"leave":   leave,
+	})
+}
+
+// GetLeaves gets the worker's leave requests
+func (wac *WorkerAvailabilityController) GetLeaves(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	leaves, err := wac.availabilityService.GetWorkerLeaves(workerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leave", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leaves": leaves,
+	})
+}
+
+// CancelLeave withdraws one of the worker's leave requests
+func (wac *WorkerAvailabilityController) CancelLeave(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	leaveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return
+	}
+
+	leave, err := wac.availabilityService.CancelLeave(workerID, uint(leaveID))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "unauthorized" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Failed to cancel leave", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Leave cancelled successfully",
+		"leave":   leave,
+	})
+}
+
+// GetBlockedSlots gets the worker's blocked slots, by default for the next 30 days
+func (wac *WorkerAvailabilityController) GetBlockedSlots(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	from := time.Now()
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from time", "details": "Use RFC3339 format"})
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, 30)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to time", "details": "Use RFC3339 format"})
+			return
+		}
+		to = parsed
+	}
+
+	slots, err := wac.availabilityService.GetBlockedSlots(workerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked slots", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocked_slots": slots,
+	})
+}
+
+// AddBlockedSlot blocks a period in the worker's calendar
+func (wac *WorkerAvailabilityController) AddBlockedSlot(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateBlockedSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	slot, err := wac.availabilityService.AddBlockedSlot(workerID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to block slot", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Slot blocked successfully",
+		"blocked_slot": slot,
+	})
+}
+
+// RemoveBlockedSlot removes one of the worker's blocked slots
+func (wac *WorkerAvailabilityController) RemoveBlockedSlot(c *gin.Context) {
+	workerID := wac.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	slotID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocked slot ID"})
+		return
+	}
+
+	if err := wac.availabilityService.RemoveBlockedSlot(workerID, uint(slotID)); err != nil {
+		status := http.StatusNotFound
+		if err.Error() == "unauthorized" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Failed to remove blocked slot", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Blocked slot removed successfully",
+	})
+}
+
+// AdminGetLeaves gets leave requests with filters (admin only)
+func (wac *WorkerAvailabilityController) AdminGetLeaves(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filters := &repositories.WorkerLeaveFilters{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	if workerID, err := strconv.ParseUint(c.Query("worker_id"), 10, 32); err == nil {
+		id := uint(workerID)
+		filters.WorkerID = &id
+	}
+	if from, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		filters.From = &from
+	}
+	if to, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		filters.To = &to
+	}
+
+	leaves, pagination, err := wac.availabilityService.GetLeaves(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leave requests", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leaves":     leaves,
+		"pagination": pagination,
+	})
+}
+
+// AdminReviewLeave approves or rejects a leave request (admin only)
+func (wac *WorkerAvailabilityController) AdminReviewLeave(c *gin.Context) {
+	adminID := wac.GetUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	leaveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return
+	}
+
+	var req models.ReviewLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	leave, err := wac.availabilityService.ReviewLeave(adminID, uint(leaveID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to review leave", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Leave " + string(leave.Status) + " successfully",
+		"leave":   leave,
+	})
+}
+
+// AdminGetWorkerAvailability gets a worker's schedule, leave and blocked slots (admin only)
+func (wac *WorkerAvailabilityController) AdminGetWorkerAvailability(c *gin.Context) {
+	workerID, err := strconv.ParseUint(c.Param("worker_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid worker ID"})
+		return
+	}
+
+	availability, err := wac.availabilityService.GetWorkerAvailability(uint(workerID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch worker availability", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"availability": availability,
+	})
+}
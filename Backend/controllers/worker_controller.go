@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"treesindia/models"
 	"treesindia/repositories"
+	"treesindia/services"
 	"treesindia/views"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +14,15 @@ import (
 
 type WorkerController struct {
 	BaseController
-	workerRepo *repositories.WorkerRepository
+	workerRepo    *repositories.WorkerRepository
+	reviewService *services.BookingReviewService
 }
 
 func NewWorkerController() *WorkerController {
 	return &WorkerController{
 		BaseController: *NewBaseController(),
 		workerRepo:     repositories.NewWorkerRepository(),
+		reviewService:  services.NewBookingReviewService(),
 	}
 }
 
@@ -238,8 +241,17 @@ func (wc *WorkerController) GetWorkerByID(ctx *gin.Context) {
 		return
 	}
 
+	// Include the latest published reviews and rating summary
+	reviews, ratingSummary, _, err := wc.reviewService.GetWorkerReviews(worker.ID, 1, 5)
+	if err != nil {
+		logrus.Warnf("Failed to load reviews for worker %d: %v", id, err)
+		reviews = []models.PublicReview{}
+	}
+
 	logrus.Infof("Worker retrieved successfully with ID: %d", id)
 	ctx.JSON(http.StatusOK, views.CreateSuccessResponse("Worker retrieved successfully", gin.H{
-		"worker": worker,
+		"worker":         worker,
+		"recent_reviews": reviews,
+		"rating_summary": ratingSummary,
 	}))
 }
@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"treesindia/models"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkerEarningController handles worker earning, commission and payout HTTP requests
+type WorkerEarningController struct {
+	BaseController
+	earningService *services.WorkerEarningService
+}
+
+// NewWorkerEarningController creates a new instance of WorkerEarningController
+func NewWorkerEarningController() *WorkerEarningController {
+	return &WorkerEarningController{
+		BaseController: *NewBaseController(),
+		earningService: services.NewWorkerEarningService(),
+	}
+}
+
+// GetMyEarnings gets the worker's earning lines
+func (ec *WorkerEarningController) GetMyEarnings(c *gin.Context) {
+	workerID := ec.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filters, ok := earningFiltersFromQuery(c)
+	if !ok {
+		return
+	}
+	filters.WorkerID = workerID
+
+	earnings, pagination, err := ec.earningService.GetEarnings(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get earnings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"earnings":   earnings,
+		"pagination": pagination,
+	})
+}
+
+// GetMyStatement gets the worker's earnings and payout statement for a period
+func (ec *WorkerEarningController) GetMyStatement(c *gin.Context) {
+	workerID := ec.GetUserID(c)
+	if workerID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	from, to, ok := statementPeriodFromQuery(c)
+	if !ok {
+		return
+	}
+
+	statement, err := ec.earningService.GetWorkerStatement(workerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get earnings statement", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statement": statement,
+	})
+}
+
+// AdminGetWorkerStatement gets a worker's earnings and payout statement (admin)
+func (ec *WorkerEarningController) AdminGetWorkerStatement(c *gin.Context) {
+	workerID, err := strconv.ParseUint(c.Param("worker_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid worker ID"})
+		return
+	}
+
+	from, to, ok := statementPeriodFromQuery(c)
+	if !ok {
+		return
+	}
+
+	statement, err := ec.earningService.GetWorkerStatement(uint(workerID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get earnings statement", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statement": statement,
+	})
+}
+
+// AdminGetEarnings gets earning lines across workers (admin)
+func (ec *WorkerEarningController) AdminGetEarnings(c *gin.Context) {
+	filters, ok := earningFiltersFromQuery(c)
+	if !ok {
+		return
+	}
+	if workerID, err := strconv.ParseUint(c.Query("worker_id"), 10, 32); err == nil {
+		filters.WorkerID = uint(workerID)
+	}
+
+	earnings, pagination, err := ec.earningService.GetEarnings(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get earnings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"earnings":   earnings,
+		"pagination": pagination,
+	})
+}
+
+// GetCommissionRules gets all commission rules (admin)
+func (ec *WorkerEarningController) GetCommissionRules(c *gin.Context) {
+	rules, err := ec.earningService.GetCommissionRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get commission rules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+// CreateCommissionRule creates a commission rule (admin)
+func (ec *WorkerEarningController) CreateCommissionRule(c *gin.Context) {
+	adminID := ec.GetUserID(c)
+
+	var req models.CommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	rule, err := ec.earningService.CreateCommissionRule(adminID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create commission rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Commission rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// UpdateCommissionRule updates a commission rule (admin)
+func (ec *WorkerEarningController) UpdateCommissionRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commission rule ID"})
+		return
+	}
+
+	var req models.CommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	rule, err := ec.earningService.UpdateCommissionRule(uint(ruleID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update commission rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Commission rule updated successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteCommissionRule deletes a commission rule (admin)
+func (ec *WorkerEarningController) DeleteCommissionRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commission rule ID"})
+		return
+	}
+
+	if err := ec.earningService.DeleteCommissionRule(uint(ruleID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete commission rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Commission rule deleted successfully",
+	})
+}
+
+// CreatePayoutBatch creates a payout batch from pending earnings (admin)
+func (ec *WorkerEarningController) CreatePayoutBatch(c *gin.Context) {
+	adminID := ec.GetUserID(c)
+
+	var req models.CreatePayoutBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	batch, err := ec.earningService.CreatePayoutBatch(adminID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create payout batch", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Payout batch created successfully",
+		"batch":   batch,
+	})
+}
+
+// GetPayoutBatches gets payout batches (admin)
+func (ec *WorkerEarningController) GetPayoutBatches(c *gin.Context) {
+	filters := &models.PayoutBatchFilters{
+		Status: c.Query("status"),
+	}
+	filters.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	batches, pagination, err := ec.earningService.GetPayoutBatches(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payout batches", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batches":    batches,
+		"pagination": pagination,
+	})
+}
+
+// GetPayoutBatch gets a payout batch with its payouts (admin)
+func (ec *WorkerEarningController) GetPayoutBatch(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout batch ID"})
+		return
+	}
+
+	batch, err := ec.earningService.GetPayoutBatch(uint(batchID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payout batch not found", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batch": batch,
+	})
+}
+
+// ExportPayoutBatch downloads the CSV bank file of a payout batch (admin)
+func (ec *WorkerEarningController) ExportPayoutBatch(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout batch ID"})
+		return
+	}
+
+	file, filename, err := ec.earningService.ExportPayoutBatch(uint(batchID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to export payout batch", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/csv", file)
+}
+
+// RecordPayoutResults records the bank outcome of payouts in a batch (admin)
+func (ec *WorkerEarningController) RecordPayoutResults(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout batch ID"})
+		return
+	}
+
+	var req models.RecordPayoutResultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	batch, err := ec.earningService.RecordPayoutResults(uint(batchID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to record payout results", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Payout results recorded successfully",
+		"batch":   batch,
+	})
+}
+
+// CancelPayoutBatch cancels a payout batch and releases its earnings (admin)
+func (ec *WorkerEarningController) CancelPayoutBatch(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout batch ID"})
+		return
+	}
+
+	batch, err := ec.earningService.CancelPayoutBatch(uint(batchID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to cancel payout batch", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Payout batch cancelled successfully",
+		"batch":   batch,
+	})
+}
+
+// earningFiltersFromQuery reads status, date range and pagination query parameters
+func earningFiltersFromQuery(c *gin.Context) (*models.WorkerEarningFilters, bool) {
+	from, to, ok := statementPeriodFromQuery(c)
+	if !ok {
+		return nil, false
+	}
+
+	filters := &models.WorkerEarningFilters{
+		Status: c.Query("status"),
+		From:   from,
+		To:     to,
+	}
+	filters.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	return filters, true
+}
+
+// statementPeriodFromQuery reads the inclusive from and to dates (YYYY-MM-DD, IST) of a statement
+func statementPeriodFromQuery(c *gin.Context) (*time.Time, *time.Time, bool) {
+	location, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		location = time.FixedZone("IST", 5*60*60+30*60)
+	}
+
+	var from, to *time.Time
+	if value := strings.TrimSpace(c.Query("from")); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date", "details": "Use YYYY-MM-DD format"})
+			return nil, nil, false
+		}
+		from = &parsed
+	}
+	if value := strings.TrimSpace(c.Query("to")); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date", "details": "Use YYYY-MM-DD format"})
+			return nil, nil, false
+		}
+		end := parsed.AddDate(0, 0, 1)
+		to = &end
+	}
+	return from, to, true
+}
@@ -35,6 +35,7 @@ func NewWorkerInquiryController() *WorkerInquiryController {
 // @Success 201 {object} views.Response{data=models.WorkerInquiry}
 // @Failure 400 {object} views.Response
 // @Failure 401 {object} views.Response
+// @Failure 403 {object} views.Response
 // @Failure 404 {object} views.Response
 // @Router /workers/{worker_id}/inquiry [post]
 func (wic *WorkerInquiryController) CreateInquiry(ctx *gin.Context) {
@@ -64,7 +65,7 @@ func (wic *WorkerInquiryController) CreateInquiry(ctx *gin.Context) {
 	inquiry, err := wic.inquiryService.CreateInquiry(userID, uint(workerID), &req)
 	if err != nil {
 		logrus.Errorf("Failed to create inquiry: %v", err)
-		ctx.JSON(http.StatusBadRequest, views.CreateErrorResponse("Failed to create inquiry", err.Error()))
+		ctx.JSON(wic.ErrorStatus(err, http.StatusBadRequest), views.CreateErrorResponse("Failed to create inquiry", err.Error()))
 		return
 	}
 
@@ -160,6 +160,14 @@ func main() {
 		log.Fatal("Failed to seed initial data:", err)
 	}
 
+	// Initialize the realtime broker before any realtime service is created
+	realtimeBroker, err := services.NewRealtimeBroker(appConfig.RealtimeBroker, appConfig.GetDatabaseURL())
+	if err != nil {
+		log.Fatal("Failed to initialize realtime broker:", err)
+	}
+	services.SetRealtimeBroker(realtimeBroker)
+	logrus.Infof("Realtime broker: %s", realtimeBroker.Name())
+
 	// Set Gin mode based on environment
 	if appConfig.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -235,6 +243,40 @@ func main() {
 	cleanupService := services.NewCleanupService()
 	cleanupService.StartPeriodicCleanup()
 
+	// Start recurring booking job
+	bookingSeriesService := services.NewBookingSeriesService()
+	bookingSeriesService.StartRecurringBookingJob()
+
+	// Start wallet reconciliation job
+	walletReconciliationService := services.NewWalletReconciliationService()
+	walletReconciliationService.StartReconciliationJob()
+
+	// Start payment reconciliation job
+	paymentReconciliationService := services.NewPaymentReconciliationService()
+	paymentReconciliationService.StartReconciliationJob()
+
+	// Start refund retry job
+	refundRetryService := services.NewRefundRetryService()
+	refundRetryService.StartRetryJob()
+
+	// Start invoice job
+	invoiceService := services.NewInvoiceService()
+	invoiceService.StartInvoiceJob()
+
+	// Start ledger posting job
+	ledgerJournalService := services.NewLedgerJournalService()
+	ledgerJournalService.StartPostingJob()
+
+	// Start payment segment collection job
+	paymentSegmentCollectionService := services.NewPaymentSegmentCollectionService()
+	paymentSegmentCollectionService.StartCollectionJob()
+
+	// Start subscription renewal and expiry warning jobs
+	subscriptionRenewalService := services.NewSubscriptionRenewalService()
+	subscriptionRenewalService.StartRenewalJob()
+	subscriptionWarningService := services.NewSubscriptionWarningService()
+	subscriptionWarningService.StartWarningJob()
+
 	// Start token cleanup service
 	tokenCleanupService := services.NewTokenCleanupService(deviceManagementService)
 	tokenCleanupService.Start()
@@ -252,6 +294,16 @@ func main() {
 	routes.SetupSimpleConversationRoutes(r.Group("/api/v1"), simpleConversationService)
 	routes.SetupSimpleConversationWebSocketRoutes(r.Group("/api/v1"), simpleConversationWsService)
 
+	// Setup chat sync routes (message sync, receipts and typing for chat rooms and conversations)
+	chatSyncService := services.NewChatSyncService()
+	routes.SetupChatSyncRoutes(r.Group("/api/v1"), chatSyncService)
+
+	// Setup chat attachment routes (uploads, location pins and access checked media for chat rooms and conversations)
+	routes.SetupChatAttachmentRoutes(r.Group("/api/v1"))
+
+	// Setup chat moderation routes (message reports, admin review queue and mutes)
+	routes.SetupChatModerationRoutes(r.Group("/api/v1"))
+
 	// Setup worker assignment routes with chat service
 	bookingMiddleware := middleware.NewDynamicConfigMiddleware()
 	bookingGroup := r.Group("/api/v1")
@@ -276,9 +328,20 @@ func main() {
 	// Setup in-app notification routes
 	routes.SetupInAppNotificationRoutes(r.Group("/api/v1"), notificationWsService, inAppNotificationService)
 	
+	// Register realtime gateway topics with the services that own them
+	realtimeGateway := services.GetRealtimeGateway()
+	realtimeGateway.AuthorizeTopic(services.RealtimeTopicBooking, locationTrackingService.AuthorizeRealtimeTopic)
+	realtimeGateway.HandleTopic(services.RealtimeTopicBooking, locationTrackingService.HandleRealtimeMessage)
+	realtimeGateway.AuthorizeTopic(services.RealtimeTopicChatRoom, chatService.AuthorizeRealtimeTopic)
+	realtimeGateway.HandleTopic(services.RealtimeTopicChatRoom, chatSyncService.HandleRoomRealtimeMessage)
+	realtimeGateway.AuthorizeTopic(services.RealtimeTopicConversation, simpleConversationService.AuthorizeRealtimeTopic)
+	realtimeGateway.HandleTopic(services.RealtimeTopicConversation, chatSyncService.HandleConversationRealtimeMessage)
+	realtimeGateway.HandleTopic(services.RealtimeTopicNotifications, inAppNotificationService.HandleRealtimeMessage)
+	
 	// Store notification integration service globally for use in other services
 	// This will be used by other services to send notifications
 	services.SetGlobalNotificationIntegrationService(notificationIntegrationService)
+	services.SetGlobalPushNotificationService(enhancedNotificationService)
 
 	// Setup call masking routes
 	routes.SetupCallMaskingRoutes(r.Group("/api/v1"))
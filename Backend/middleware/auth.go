@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -37,98 +38,68 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token := tokenParts[1]
-
-		// Parse and validate JWT token
-		appConfig := config.LoadConfig()
-		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(appConfig.JWTSecret), nil
-		})
-
+		user, err := AuthenticateAccessToken(tokenParts[1])
 		if err != nil {
+			message := err.Error()
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"message": "Invalid token",
+				"message": strings.ToUpper(message[:1]) + message[1:],
 			})
 			c.Abort()
 			return
 		}
 
-		// Check if token is valid
-		if !parsedToken.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Invalid token",
-			})
-			c.Abort()
-			return
-		}
+		// Set user information in context
+		c.Set("user_id", user.ID)
+		c.Set("user_type", string(user.UserType)) // Convert to string explicitly
+		c.Set("user", *user)
 
-		// Extract claims
-		claims, ok := parsedToken.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Invalid token claims",
-			})
-			c.Abort()
-			return
-		}
+		c.Next()
+	}
+}
 
-		// Check token type (should be access token)
-		tokenType, ok := claims["type"].(string)
-		if !ok || tokenType != "access" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Invalid token type",
-			})
-			c.Abort()
-			return
+// AuthenticateAccessToken validates a JWT access token and loads its active user. It is shared by
+// AuthMiddleware and the WebSocket endpoints that take the token from the query string.
+func AuthenticateAccessToken(token string) (*models.User, error) {
+	// Parse and validate JWT token
+	appConfig := config.LoadConfig()
+	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		// Validate signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return []byte(appConfig.JWTSecret), nil
+	})
+	if err != nil || !parsedToken.Valid {
+		return nil, errors.New("invalid token")
+	}
 
-		// Extract user ID
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Invalid user ID in token",
-			})
-			c.Abort()
-			return
-		}
-		userID := uint(userIDFloat)
-
-		// Verify user exists and is active
-		var user models.User
-		if err := database.GetDB().First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			c.Abort()
-			return
-		}
+	// Extract claims
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
 
-		if !user.IsActive {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Account disabled",
-			})
-			c.Abort()
-			return
-		}
+	// Check token type (should be access token)
+	if tokenType, _ := claims["type"].(string); tokenType != "access" {
+		return nil, errors.New("invalid token type")
+	}
 
-		// Set user information in context
-		c.Set("user_id", user.ID)
-		c.Set("user_type", string(user.UserType)) // Convert to string explicitly
-		c.Set("user", user)
+	// Extract user ID
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, errors.New("invalid user ID in token")
+	}
 
-		c.Next()
+	// Verify user exists and is active
+	var user models.User
+	if err := database.GetDB().First(&user, uint(userIDFloat)).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive {
+		return nil, errors.New("account disabled")
 	}
+	return &user, nil
 }
 
 // AdminMiddleware ensures only admin users can access
@@ -0,0 +1,49 @@
+-- +goose Up
+-- Create booking_reviews table for customer reviews of completed bookings
+CREATE TABLE IF NOT EXISTS booking_reviews (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    worker_assignment_id BIGINT REFERENCES worker_assignments(id) ON DELETE SET NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    worker_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    rating INTEGER NOT NULL CHECK (rating >= 1 AND rating <= 5),
+    review TEXT,
+    categories JSONB DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'published',
+    editable_until TIMESTAMPTZ NOT NULL,
+    edited_at TIMESTAMPTZ,
+    moderated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMPTZ,
+    moderation_notes TEXT
+);
+
+-- Only one review per booking
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_reviews_booking_id ON booking_reviews(booking_id) WHERE deleted_at IS NULL;
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_booking_reviews_user_id ON booking_reviews(user_id);
+CREATE INDEX IF NOT EXISTS idx_booking_reviews_worker_id ON booking_reviews(worker_id);
+CREATE INDEX IF NOT EXISTS idx_booking_reviews_status ON booking_reviews(status);
+CREATE INDEX IF NOT EXISTS idx_booking_reviews_deleted_at ON booking_reviews(deleted_at);
+
+-- Track the number of published reviews behind workers.rating
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS total_reviews INTEGER DEFAULT 0;
+
+-- Add comments
+COMMENT ON TABLE booking_reviews IS 'Customer reviews for completed bookings';
+COMMENT ON COLUMN booking_reviews.worker_id IS 'User ID of the worker who completed the booking';
+COMMENT ON COLUMN booking_reviews.categories IS 'Per-category scores, e.g. {"punctuality": 5, "quality": 4}';
+COMMENT ON COLUMN booking_reviews.status IS 'Moderation status (published, hidden, flagged)';
+COMMENT ON COLUMN booking_reviews.editable_until IS 'Deadline after which the customer can no longer edit the review';
+
+-- +goose Down
+ALTER TABLE workers DROP COLUMN IF EXISTS total_reviews;
+DROP INDEX IF EXISTS idx_booking_reviews_deleted_at;
+DROP INDEX IF EXISTS idx_booking_reviews_status;
+DROP INDEX IF EXISTS idx_booking_reviews_worker_id;
+DROP INDEX IF EXISTS idx_booking_reviews_user_id;
+DROP INDEX IF EXISTS idx_booking_reviews_booking_id;
+DROP TABLE IF EXISTS booking_reviews CASCADE;
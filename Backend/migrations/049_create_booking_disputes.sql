@@ -0,0 +1,91 @@
+-- +goose Up
+-- Create booking_disputes table for customer and worker complaints about a booking
+CREATE TABLE IF NOT EXISTS booking_disputes (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    raised_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    raised_by_role VARCHAR(20) NOT NULL CHECK (raised_by_role IN ('user', 'worker')),
+    reason VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL,
+    evidence JSONB DEFAULT '[]'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'under_review', 'resolved')),
+    resolution VARCHAR(20) CHECK (resolution IS NULL OR resolution IN ('refund', 'partial_refund', 'rework', 'rejected')),
+    resolution_notes TEXT,
+    refund_amount DECIMAL(10,2),
+    resolved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ
+);
+
+-- Create dispute_comments table for the admin/party conversation on a dispute
+CREATE TABLE IF NOT EXISTS dispute_comments (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    dispute_id BIGINT NOT NULL REFERENCES booking_disputes(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_role VARCHAR(20) NOT NULL,
+    comment TEXT NOT NULL,
+    is_internal BOOLEAN DEFAULT FALSE
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_booking_disputes_booking_id ON booking_disputes(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_disputes_raised_by ON booking_disputes(raised_by);
+CREATE INDEX IF NOT EXISTS idx_booking_disputes_status ON booking_disputes(status);
+CREATE INDEX IF NOT EXISTS idx_booking_disputes_deleted_at ON booking_disputes(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_dispute_comments_dispute_id ON dispute_comments(dispute_id);
+CREATE INDEX IF NOT EXISTS idx_dispute_comments_deleted_at ON dispute_comments(deleted_at);
+
+-- Allow dispute notifications (and the quote notifications missing from the original list)
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved'
+));
+
+-- Add comments
+COMMENT ON TABLE booking_disputes IS 'Disputes raised by customers or workers about a booking';
+COMMENT ON COLUMN booking_disputes.evidence IS 'Cloudinary URLs of photo evidence';
+COMMENT ON COLUMN booking_disputes.resolution IS 'Admin resolution (refund, partial_refund, rework, rejected)';
+COMMENT ON COLUMN dispute_comments.is_internal IS 'Internal admin notes are not shown to the dispute parties';
+
+-- +goose Down
+DELETE FROM in_app_notifications WHERE type IN ('quote_accepted', 'quote_rejected', 'quote_expired', 'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved');
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'payment_confirmation',
+    'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed'
+));
+DROP INDEX IF EXISTS idx_dispute_comments_deleted_at;
+DROP INDEX IF EXISTS idx_dispute_comments_dispute_id;
+DROP INDEX IF EXISTS idx_booking_disputes_deleted_at;
+DROP INDEX IF EXISTS idx_booking_disputes_status;
+DROP INDEX IF EXISTS idx_booking_disputes_raised_by;
+DROP INDEX IF EXISTS idx_booking_disputes_booking_id;
+DROP TABLE IF EXISTS dispute_comments CASCADE;
+DROP TABLE IF EXISTS booking_disputes CASCADE;
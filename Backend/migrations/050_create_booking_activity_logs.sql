@@ -0,0 +1,36 @@
+-- +goose Up
+-- Create booking_activity_logs table as the audit trail of everything that happens to a booking
+CREATE TABLE IF NOT EXISTS booking_activity_logs (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    action VARCHAR(50) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL CHECK (actor_type IN ('user', 'worker', 'admin', 'system')),
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    old_value JSONB,
+    new_value JSONB,
+    description TEXT
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_booking_activity_logs_booking_id ON booking_activity_logs(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_activity_logs_action ON booking_activity_logs(action);
+CREATE INDEX IF NOT EXISTS idx_booking_activity_logs_actor ON booking_activity_logs(actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS idx_booking_activity_logs_created_at ON booking_activity_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_booking_activity_logs_deleted_at ON booking_activity_logs(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE booking_activity_logs IS 'Audit trail of status, quote, assignment and payment changes on bookings';
+COMMENT ON COLUMN booking_activity_logs.actor_type IS 'Who made the change (user, worker, admin, system)';
+COMMENT ON COLUMN booking_activity_logs.old_value IS 'Value before the change';
+COMMENT ON COLUMN booking_activity_logs.new_value IS 'Value after the change';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_booking_activity_logs_deleted_at;
+DROP INDEX IF EXISTS idx_booking_activity_logs_created_at;
+DROP INDEX IF EXISTS idx_booking_activity_logs_actor;
+DROP INDEX IF EXISTS idx_booking_activity_logs_action;
+DROP INDEX IF EXISTS idx_booking_activity_logs_booking_id;
+DROP TABLE IF EXISTS booking_activity_logs CASCADE;
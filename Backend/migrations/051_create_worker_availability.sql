@@ -0,0 +1,77 @@
+-- +goose Up
+-- Create worker_schedules table for each worker's weekly working hours
+CREATE TABLE IF NOT EXISTS worker_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    worker_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time VARCHAR(5) NOT NULL DEFAULT '09:00',
+    end_time VARCHAR(5) NOT NULL DEFAULT '22:00',
+    is_day_off BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- Create worker_leaves table for leave requests approved by admins
+CREATE TABLE IF NOT EXISTS worker_leaves (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    worker_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    admin_notes TEXT,
+    CHECK (end_date >= start_date)
+);
+
+-- Create worker_blocked_slots table for one-off periods a worker cannot take jobs
+CREATE TABLE IF NOT EXISTS worker_blocked_slots (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    worker_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    reason TEXT,
+    CHECK (end_time > start_time)
+);
+
+-- Create indexes for better query performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_worker_schedules_worker_day ON worker_schedules(worker_id, day_of_week) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_worker_schedules_deleted_at ON worker_schedules(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_worker_leaves_worker_id ON worker_leaves(worker_id);
+CREATE INDEX IF NOT EXISTS idx_worker_leaves_status ON worker_leaves(status);
+CREATE INDEX IF NOT EXISTS idx_worker_leaves_dates ON worker_leaves(start_date, end_date);
+CREATE INDEX IF NOT EXISTS idx_worker_leaves_deleted_at ON worker_leaves(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_worker_blocked_slots_worker_id ON worker_blocked_slots(worker_id);
+CREATE INDEX IF NOT EXISTS idx_worker_blocked_slots_times ON worker_blocked_slots(start_time, end_time);
+CREATE INDEX IF NOT EXISTS idx_worker_blocked_slots_deleted_at ON worker_blocked_slots(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE worker_schedules IS 'Weekly working hours of workers; workers without rows work the global working hours';
+COMMENT ON COLUMN worker_schedules.day_of_week IS 'Day of the week (0 = Sunday ... 6 = Saturday)';
+COMMENT ON COLUMN worker_schedules.start_time IS 'Shift start in IST (HH:MM)';
+COMMENT ON COLUMN worker_schedules.end_time IS 'Shift end in IST (HH:MM)';
+COMMENT ON TABLE worker_leaves IS 'Worker leave requests; only approved leave blocks assignments';
+COMMENT ON COLUMN worker_leaves.status IS 'Leave status (pending, approved, rejected, cancelled)';
+COMMENT ON TABLE worker_blocked_slots IS 'One-off periods in which a worker cannot be assigned';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_worker_blocked_slots_deleted_at;
+DROP INDEX IF EXISTS idx_worker_blocked_slots_times;
+DROP INDEX IF EXISTS idx_worker_blocked_slots_worker_id;
+DROP INDEX IF EXISTS idx_worker_leaves_deleted_at;
+DROP INDEX IF EXISTS idx_worker_leaves_dates;
+DROP INDEX IF EXISTS idx_worker_leaves_status;
+DROP INDEX IF EXISTS idx_worker_leaves_worker_id;
+DROP INDEX IF EXISTS idx_worker_schedules_deleted_at;
+DROP INDEX IF EXISTS idx_worker_schedules_worker_day;
+DROP TABLE IF EXISTS worker_blocked_slots CASCADE;
+DROP TABLE IF EXISTS worker_leaves CASCADE;
+DROP TABLE IF EXISTS worker_schedules CASCADE;
@@ -0,0 +1,108 @@
+-- +goose Up
+-- Create booking_series table for recurring bookings of the same service
+CREATE TABLE IF NOT EXISTS booking_series (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id BIGINT NOT NULL REFERENCES services(id) ON DELETE RESTRICT,
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('weekly', 'biweekly', 'monthly')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    occurrence_count INTEGER,
+    preferred_time VARCHAR(5) NOT NULL,
+    address JSONB NOT NULL,
+    description TEXT,
+    contact_person VARCHAR(255),
+    contact_phone VARCHAR(20),
+    special_instructions TEXT,
+    preferred_worker_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled')),
+    cancelled_at TIMESTAMPTZ,
+    cancellation_reason TEXT,
+    CHECK (end_date IS NOT NULL OR occurrence_count IS NOT NULL)
+);
+
+-- Create booking_series_occurrences table for each planned visit of a series
+CREATE TABLE IF NOT EXISTS booking_series_occurrences (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    series_id BIGINT NOT NULL REFERENCES booking_series(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'booked', 'skipped', 'cancelled', 'failed')),
+    booking_id BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    failure_reason TEXT,
+    processed_at TIMESTAMPTZ
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_booking_series_user_id ON booking_series(user_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_service_id ON booking_series(service_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_status ON booking_series(status);
+CREATE INDEX IF NOT EXISTS idx_booking_series_deleted_at ON booking_series(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_series_occurrences_series_sequence ON booking_series_occurrences(series_id, sequence) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_booking_series_occurrences_status_scheduled_at ON booking_series_occurrences(status, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_booking_series_occurrences_booking_id ON booking_series_occurrences(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_occurrences_deleted_at ON booking_series_occurrences(deleted_at);
+
+-- Allow recurring booking notifications
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed'
+));
+
+-- Add comments
+COMMENT ON TABLE booking_series IS 'Recurring bookings; each occurrence becomes a normal booking shortly before it is due';
+COMMENT ON COLUMN booking_series.frequency IS 'How often the service repeats (weekly, biweekly, monthly)';
+COMMENT ON COLUMN booking_series.preferred_time IS 'Preferred start time in IST (HH:MM)';
+COMMENT ON COLUMN booking_series.preferred_worker_id IS 'Worker who served the series last and is assigned again when free';
+COMMENT ON COLUMN booking_series.status IS 'Series status (active, completed, cancelled)';
+COMMENT ON TABLE booking_series_occurrences IS 'Planned visits of a booking series';
+COMMENT ON COLUMN booking_series_occurrences.status IS 'Occurrence status (scheduled, booked, skipped, cancelled, failed)';
+COMMENT ON COLUMN booking_series_occurrences.booking_id IS 'Booking created for the occurrence once it is booked';
+
+-- +goose Down
+DELETE FROM in_app_notifications WHERE type IN ('recurring_booking_created', 'recurring_booking_failed');
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved'
+));
+DROP INDEX IF EXISTS idx_booking_series_occurrences_deleted_at;
+DROP INDEX IF EXISTS idx_booking_series_occurrences_booking_id;
+DROP INDEX IF EXISTS idx_booking_series_occurrences_status_scheduled_at;
+DROP INDEX IF EXISTS idx_booking_series_occurrences_series_sequence;
+DROP INDEX IF EXISTS idx_booking_series_deleted_at;
+DROP INDEX IF EXISTS idx_booking_series_status;
+DROP INDEX IF EXISTS idx_booking_series_service_id;
+DROP INDEX IF EXISTS idx_booking_series_user_id;
+DROP TABLE IF EXISTS booking_series_occurrences CASCADE;
+DROP TABLE IF EXISTS booking_series CASCADE;
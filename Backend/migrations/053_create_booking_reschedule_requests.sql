@@ -0,0 +1,79 @@
+-- +goose Up
+-- Create booking_reschedule_requests table for moving bookings to another time
+CREATE TABLE IF NOT EXISTS booking_reschedule_requests (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    requested_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requester_role VARCHAR(20) NOT NULL CHECK (requester_role IN ('user', 'admin')),
+    worker_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    old_scheduled_time TIMESTAMPTZ NOT NULL,
+    old_scheduled_end_time TIMESTAMPTZ NOT NULL,
+    new_scheduled_time TIMESTAMPTZ NOT NULL,
+    new_scheduled_end_time TIMESTAMPTZ NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    responded_at TIMESTAMPTZ,
+    response_notes TEXT
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_booking_reschedule_requests_booking_id ON booking_reschedule_requests(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_reschedule_requests_worker_id ON booking_reschedule_requests(worker_id);
+CREATE INDEX IF NOT EXISTS idx_booking_reschedule_requests_status ON booking_reschedule_requests(status);
+CREATE INDEX IF NOT EXISTS idx_booking_reschedule_requests_deleted_at ON booking_reschedule_requests(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_reschedule_requests_one_pending ON booking_reschedule_requests(booking_id) WHERE status = 'pending' AND deleted_at IS NULL;
+
+-- Allow reschedule notifications
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed',
+    'reschedule_requested', 'booking_rescheduled', 'reschedule_declined'
+));
+
+-- Add comments
+COMMENT ON TABLE booking_reschedule_requests IS 'Requests by customers or admins to move a booking; the assigned worker has to accept';
+COMMENT ON COLUMN booking_reschedule_requests.requester_role IS 'Who asked for the new time (user, admin)';
+COMMENT ON COLUMN booking_reschedule_requests.worker_id IS 'Assigned worker who has to accept, NULL when no worker was assigned';
+COMMENT ON COLUMN booking_reschedule_requests.status IS 'Request status (pending, accepted, declined, cancelled, expired)';
+
+-- +goose Down
+DELETE FROM in_app_notifications WHERE type IN ('reschedule_requested', 'booking_rescheduled', 'reschedule_declined');
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed'
+));
+DROP INDEX IF EXISTS idx_booking_reschedule_requests_one_pending;
+DROP INDEX IF EXISTS idx_booking_reschedule_requests_deleted_at;
+DROP INDEX IF EXISTS idx_booking_reschedule_requests_status;
+DROP INDEX IF EXISTS idx_booking_reschedule_requests_worker_id;
+DROP INDEX IF EXISTS idx_booking_reschedule_requests_booking_id;
+DROP TABLE IF EXISTS booking_reschedule_requests CASCADE;
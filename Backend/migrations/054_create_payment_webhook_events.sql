@@ -0,0 +1,47 @@
+-- +goose Up
+-- Create payment_webhook_events table to store payment gateway webhooks once per event
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    provider VARCHAR(50) NOT NULL DEFAULT 'razorpay',
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'processing', 'processed', 'failed', 'ignored')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    processed_at TIMESTAMPTZ,
+    gateway_order_id VARCHAR(255),
+    gateway_payment_id VARCHAR(255),
+    gateway_refund_id VARCHAR(255),
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL
+);
+
+-- Create indexes for better query performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_webhook_events_provider_event_id ON payment_webhook_events(provider, event_id);
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_event_type ON payment_webhook_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_status ON payment_webhook_events(status);
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_gateway_payment_id ON payment_webhook_events(gateway_payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_payment_id ON payment_webhook_events(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_deleted_at ON payment_webhook_events(deleted_at);
+
+-- Look up refund records by their gateway refund ID
+CREATE INDEX IF NOT EXISTS idx_payments_razorpay_refund_id ON payments((metadata->>'razorpay_refund_id')) WHERE type = 'refund';
+
+-- Add comments
+COMMENT ON TABLE payment_webhook_events IS 'Payment gateway webhook events, stored once per event ID so replays are not processed twice';
+COMMENT ON COLUMN payment_webhook_events.event_id IS 'Gateway event ID (X-Razorpay-Event-Id), or a hash of the body when the header is missing';
+COMMENT ON COLUMN payment_webhook_events.status IS 'Processing status (received, processing, processed, failed, ignored)';
+COMMENT ON COLUMN payment_webhook_events.payment_id IS 'Local payment the event was reconciled against';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_razorpay_refund_id;
+DROP INDEX IF EXISTS idx_payment_webhook_events_deleted_at;
+DROP INDEX IF EXISTS idx_payment_webhook_events_payment_id;
+DROP INDEX IF EXISTS idx_payment_webhook_events_gateway_payment_id;
+DROP INDEX IF EXISTS idx_payment_webhook_events_status;
+DROP INDEX IF EXISTS idx_payment_webhook_events_event_type;
+DROP INDEX IF EXISTS idx_payment_webhook_events_provider_event_id;
+DROP TABLE IF EXISTS payment_webhook_events CASCADE;
@@ -0,0 +1,93 @@
+-- +goose Up
+-- Create wallet_journal_entries table: an append-only double-entry journal of wallet credits and debits
+CREATE TABLE IF NOT EXISTS wallet_journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    transaction_ref VARCHAR(100) NOT NULL,
+    account VARCHAR(50) NOT NULL CHECK (account IN ('user_wallet', 'gateway_clearing', 'booking_revenue', 'service_revenue', 'subscription_revenue', 'refunds', 'admin_adjustments', 'opening_balance')),
+    user_id BIGINT REFERENCES users(id),
+    entry_type VARCHAR(50) NOT NULL CHECK (entry_type IN ('recharge', 'booking_payment', 'service_payment', 'subscription_payment', 'refund', 'admin_adjustment', 'opening_balance')),
+    debit DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    balance_after DECIMAL(12,2),
+    payment_id BIGINT REFERENCES payments(id),
+    description TEXT,
+    created_by BIGINT REFERENCES users(id),
+    CHECK ((debit = 0) <> (credit = 0)),
+    CHECK (account <> 'user_wallet' OR user_id IS NOT NULL)
+);
+
+-- Journal entries are immutable; corrections are posted as new transactions
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_wallet_journal_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet journal entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER wallet_journal_entries_immutable
+    BEFORE UPDATE OR DELETE ON wallet_journal_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_wallet_journal_changes();
+
+-- Create wallet_balance_discrepancies table for users whose cached balance disagrees with the journal
+CREATE TABLE IF NOT EXISTS wallet_balance_discrepancies (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cached_balance DECIMAL(12,2) NOT NULL,
+    journal_balance DECIMAL(12,2) NOT NULL,
+    difference DECIMAL(12,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_wallet_journal_entries_transaction_ref ON wallet_journal_entries(transaction_ref);
+CREATE INDEX IF NOT EXISTS idx_wallet_journal_entries_user_id ON wallet_journal_entries(user_id, account);
+CREATE INDEX IF NOT EXISTS idx_wallet_journal_entries_payment_id ON wallet_journal_entries(payment_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_journal_entries_created_at ON wallet_journal_entries(created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_balance_discrepancies_open_user ON wallet_balance_discrepancies(user_id) WHERE status = 'open' AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_wallet_balance_discrepancies_status ON wallet_balance_discrepancies(status);
+CREATE INDEX IF NOT EXISTS idx_wallet_balance_discrepancies_deleted_at ON wallet_balance_discrepancies(deleted_at);
+
+-- Carry existing wallet balances into the journal as opening balances
+INSERT INTO wallet_journal_entries (transaction_ref, account, user_id, entry_type, debit, credit, balance_after, description)
+SELECT 'OPEN' || id, 'user_wallet', id, 'opening_balance',
+       CASE WHEN wallet_balance < 0 THEN -wallet_balance ELSE 0 END,
+       CASE WHEN wallet_balance > 0 THEN wallet_balance ELSE 0 END,
+       wallet_balance, 'Opening wallet balance'
+FROM users
+WHERE wallet_balance IS NOT NULL AND wallet_balance <> 0;
+
+INSERT INTO wallet_journal_entries (transaction_ref, account, entry_type, debit, credit, description)
+SELECT 'OPEN' || id, 'opening_balance', 'opening_balance',
+       CASE WHEN wallet_balance > 0 THEN wallet_balance ELSE 0 END,
+       CASE WHEN wallet_balance < 0 THEN -wallet_balance ELSE 0 END,
+       'Opening wallet balance'
+FROM users
+WHERE wallet_balance IS NOT NULL AND wallet_balance <> 0;
+
+-- Add comments
+COMMENT ON TABLE wallet_journal_entries IS 'Append-only double-entry journal of wallet credits and debits; each transaction_ref balances to zero';
+COMMENT ON COLUMN wallet_journal_entries.account IS 'Account the leg is posted to; user_wallet legs carry the user_id';
+COMMENT ON COLUMN wallet_journal_entries.balance_after IS 'User wallet balance after a user_wallet leg';
+COMMENT ON TABLE wallet_balance_discrepancies IS 'Users whose cached users.wallet_balance disagrees with their wallet journal';
+COMMENT ON COLUMN wallet_balance_discrepancies.difference IS 'Cached balance minus journal balance';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_wallet_balance_discrepancies_deleted_at;
+DROP INDEX IF EXISTS idx_wallet_balance_discrepancies_status;
+DROP INDEX IF EXISTS idx_wallet_balance_discrepancies_open_user;
+DROP INDEX IF EXISTS idx_wallet_journal_entries_created_at;
+DROP INDEX IF EXISTS idx_wallet_journal_entries_payment_id;
+DROP INDEX IF EXISTS idx_wallet_journal_entries_user_id;
+DROP INDEX IF EXISTS idx_wallet_journal_entries_transaction_ref;
+DROP TABLE IF EXISTS wallet_balance_discrepancies CASCADE;
+DROP TRIGGER IF EXISTS wallet_journal_entries_immutable ON wallet_journal_entries;
+DROP FUNCTION IF EXISTS prevent_wallet_journal_changes();
+DROP TABLE IF EXISTS wallet_journal_entries CASCADE;
@@ -0,0 +1,120 @@
+-- +goose Up
+-- Create commission_rules table for the platform commission per category or service
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    name VARCHAR(255) NOT NULL,
+    category_id BIGINT REFERENCES categories(id) ON DELETE CASCADE,
+    service_id BIGINT REFERENCES services(id) ON DELETE CASCADE,
+    commission_type VARCHAR(20) NOT NULL DEFAULT 'percentage' CHECK (commission_type IN ('percentage', 'fixed')),
+    value DECIMAL(10,2) NOT NULL CHECK (value >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT REFERENCES users(id),
+    CHECK (category_id IS NOT NULL OR service_id IS NOT NULL)
+);
+
+-- Create payout_batches table
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    batch_reference VARCHAR(50) NOT NULL,
+    period_start TIMESTAMPTZ,
+    period_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'exported', 'completed', 'cancelled')),
+    total_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    payout_count INTEGER NOT NULL DEFAULT 0,
+    skipped_workers INTEGER NOT NULL DEFAULT 0,
+    exported_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    notes TEXT,
+    created_by BIGINT REFERENCES users(id)
+);
+
+-- Create worker_payouts table for one worker's transfer in a batch
+CREATE TABLE IF NOT EXISTS worker_payouts (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    batch_id BIGINT NOT NULL REFERENCES payout_batches(id) ON DELETE CASCADE,
+    worker_id BIGINT NOT NULL REFERENCES users(id),
+    amount DECIMAL(12,2) NOT NULL,
+    earnings_count INTEGER NOT NULL DEFAULT 0,
+    account_holder_name VARCHAR(255),
+    account_number VARCHAR(50),
+    ifsc_code VARCHAR(20),
+    bank_name VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'failed', 'cancelled')),
+    transaction_ref VARCHAR(100),
+    failure_reason TEXT,
+    paid_at TIMESTAMPTZ
+);
+
+-- Create worker_earnings table for the earning line of each completed booking
+CREATE TABLE IF NOT EXISTS worker_earnings (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    worker_id BIGINT NOT NULL REFERENCES users(id),
+    booking_id BIGINT NOT NULL REFERENCES bookings(id),
+    assignment_id BIGINT REFERENCES worker_assignments(id) ON DELETE SET NULL,
+    commission_rule_id BIGINT REFERENCES commission_rules(id) ON DELETE SET NULL,
+    gross_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    commission_type VARCHAR(20) CHECK (commission_type IN ('percentage', 'fixed')),
+    commission_rate DECIMAL(10,2) NOT NULL DEFAULT 0,
+    commission_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    net_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'in_payout', 'paid')),
+    payout_id BIGINT REFERENCES worker_payouts(id) ON DELETE SET NULL,
+    earned_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_commission_rules_category_id ON commission_rules(category_id);
+CREATE INDEX IF NOT EXISTS idx_commission_rules_service_id ON commission_rules(service_id);
+CREATE INDEX IF NOT EXISTS idx_commission_rules_deleted_at ON commission_rules(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_batches_batch_reference ON payout_batches(batch_reference);
+CREATE INDEX IF NOT EXISTS idx_payout_batches_status ON payout_batches(status);
+CREATE INDEX IF NOT EXISTS idx_payout_batches_deleted_at ON payout_batches(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_worker_payouts_batch_id ON worker_payouts(batch_id);
+CREATE INDEX IF NOT EXISTS idx_worker_payouts_worker_id ON worker_payouts(worker_id);
+CREATE INDEX IF NOT EXISTS idx_worker_payouts_deleted_at ON worker_payouts(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_worker_earnings_booking_id ON worker_earnings(booking_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_worker_earnings_worker_id ON worker_earnings(worker_id, status);
+CREATE INDEX IF NOT EXISTS idx_worker_earnings_payout_id ON worker_earnings(payout_id);
+CREATE INDEX IF NOT EXISTS idx_worker_earnings_earned_at ON worker_earnings(earned_at);
+CREATE INDEX IF NOT EXISTS idx_worker_earnings_deleted_at ON worker_earnings(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE commission_rules IS 'Platform commission per category or service; service rules win over category rules';
+COMMENT ON COLUMN commission_rules.value IS 'Percentage of the job amount, or a fixed amount in INR';
+COMMENT ON TABLE worker_earnings IS 'What a worker earned for each completed booking after platform commission';
+COMMENT ON COLUMN worker_earnings.worker_id IS 'Worker user ID';
+COMMENT ON TABLE payout_batches IS 'Periodic settlement batches exported to the bank as a CSV file';
+COMMENT ON TABLE worker_payouts IS 'One worker transfer in a payout batch, with bank details copied from the worker';
+COMMENT ON COLUMN worker_payouts.transaction_ref IS 'Bank UTR of the transfer';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_worker_earnings_deleted_at;
+DROP INDEX IF EXISTS idx_worker_earnings_earned_at;
+DROP INDEX IF EXISTS idx_worker_earnings_payout_id;
+DROP INDEX IF EXISTS idx_worker_earnings_worker_id;
+DROP INDEX IF EXISTS idx_worker_earnings_booking_id;
+DROP INDEX IF EXISTS idx_worker_payouts_deleted_at;
+DROP INDEX IF EXISTS idx_worker_payouts_worker_id;
+DROP INDEX IF EXISTS idx_worker_payouts_batch_id;
+DROP INDEX IF EXISTS idx_payout_batches_deleted_at;
+DROP INDEX IF EXISTS idx_payout_batches_status;
+DROP INDEX IF EXISTS idx_payout_batches_batch_reference;
+DROP INDEX IF EXISTS idx_commission_rules_deleted_at;
+DROP INDEX IF EXISTS idx_commission_rules_service_id;
+DROP INDEX IF EXISTS idx_commission_rules_category_id;
+DROP TABLE IF EXISTS worker_earnings CASCADE;
+DROP TABLE IF EXISTS worker_payouts CASCADE;
+DROP TABLE IF EXISTS payout_batches CASCADE;
+DROP TABLE IF EXISTS commission_rules CASCADE;
@@ -0,0 +1,109 @@
+-- +goose Up
+-- Create invoice_sequences table for gapless document numbering per financial year
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    financial_year VARCHAR(10) NOT NULL,
+    document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('tax_invoice', 'credit_note', 'receipt')),
+    last_number INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (financial_year, document_type)
+);
+
+-- Create invoices table for tax invoices, credit notes and receipts
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    invoice_number VARCHAR(20) NOT NULL,
+    document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('tax_invoice', 'credit_note', 'receipt')),
+    financial_year VARCHAR(10) NOT NULL,
+    sequence_number INTEGER NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    payment_id BIGINT NOT NULL REFERENCES payments(id),
+    payment_reference VARCHAR(255),
+    payment_method VARCHAR(50),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    booking_id BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    original_invoice_id BIGINT REFERENCES invoices(id),
+    description TEXT,
+    sac_code VARCHAR(10),
+    seller_name VARCHAR(255),
+    seller_gstin VARCHAR(15),
+    seller_address TEXT,
+    seller_state VARCHAR(100),
+    customer_name VARCHAR(255),
+    customer_phone VARCHAR(20),
+    customer_email VARCHAR(255),
+    customer_gstin VARCHAR(15),
+    customer_address TEXT,
+    customer_state VARCHAR(100),
+    place_of_supply VARCHAR(100),
+    is_inter_state BOOLEAN NOT NULL DEFAULT false,
+    gst_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    taxable_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    cgst_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    sgst_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    igst_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(12,2) NOT NULL DEFAULT 0
+);
+
+-- Create category_tax_codes table for the SAC code and GST rate of each category
+CREATE TABLE IF NOT EXISTS category_tax_codes (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    sac_code VARCHAR(10) NOT NULL,
+    gst_rate DECIMAL(5,2) NOT NULL DEFAULT 18 CHECK (gst_rate >= 0 AND gst_rate <= 28)
+);
+
+-- Create billing_details table for customer business names and GSTINs
+CREATE TABLE IF NOT EXISTS billing_details (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    business_name VARCHAR(255),
+    gstin VARCHAR(15),
+    billing_address TEXT,
+    state VARCHAR(100)
+);
+
+-- Create indexes for better query performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_invoice_number ON invoices(invoice_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_payment_id ON invoices(payment_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id ON invoices(user_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_issued_at ON invoices(issued_at);
+CREATE INDEX IF NOT EXISTS idx_invoices_original_invoice_id ON invoices(original_invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_deleted_at ON invoices(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_tax_codes_category_id ON category_tax_codes(category_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_category_tax_codes_deleted_at ON category_tax_codes(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_billing_details_user_id ON billing_details(user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_billing_details_deleted_at ON billing_details(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE invoice_sequences IS 'Last document number used per financial year and document type';
+COMMENT ON TABLE invoices IS 'Tax invoices, credit notes and receipts issued for completed payments; one document per payment';
+COMMENT ON COLUMN invoices.financial_year IS 'Indian financial year of issue, April to March, e.g. 2026-27';
+COMMENT ON COLUMN invoices.original_invoice_id IS 'Tax invoice a credit note is issued against';
+COMMENT ON COLUMN invoices.taxable_amount IS 'Total amount less GST; payment amounts are GST inclusive';
+COMMENT ON TABLE category_tax_codes IS 'SAC code and GST rate printed on invoices for services of a category';
+COMMENT ON TABLE billing_details IS 'Business name and GSTIN a customer wants on their tax invoices';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_billing_details_deleted_at;
+DROP INDEX IF EXISTS idx_billing_details_user_id;
+DROP INDEX IF EXISTS idx_category_tax_codes_deleted_at;
+DROP INDEX IF EXISTS idx_category_tax_codes_category_id;
+DROP INDEX IF EXISTS idx_invoices_deleted_at;
+DROP INDEX IF EXISTS idx_invoices_original_invoice_id;
+DROP INDEX IF EXISTS idx_invoices_issued_at;
+DROP INDEX IF EXISTS idx_invoices_user_id;
+DROP INDEX IF EXISTS idx_invoices_payment_id;
+DROP INDEX IF EXISTS idx_invoices_invoice_number;
+DROP TABLE IF EXISTS billing_details CASCADE;
+DROP TABLE IF EXISTS category_tax_codes CASCADE;
+DROP TABLE IF EXISTS invoices CASCADE;
+DROP TABLE IF EXISTS invoice_sequences CASCADE;
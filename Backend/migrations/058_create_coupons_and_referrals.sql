@@ -0,0 +1,146 @@
+-- +goose Up
+-- Create coupons table for promo codes
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    code VARCHAR(30) NOT NULL,
+    description TEXT,
+    reward_type VARCHAR(20) NOT NULL DEFAULT 'discount' CHECK (reward_type IN ('discount', 'cashback')),
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('flat', 'percentage')),
+    value DECIMAL(10,2) NOT NULL CHECK (value > 0),
+    max_discount DECIMAL(10,2),
+    min_order_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    usage_limit INTEGER NOT NULL DEFAULT 0,
+    per_user_limit INTEGER NOT NULL DEFAULT 1,
+    scope VARCHAR(20) NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'booking', 'quote', 'subscription')),
+    category_ids JSONB NOT NULL DEFAULT '[]',
+    service_ids JSONB NOT NULL DEFAULT '[]',
+    first_booking_only BOOLEAN NOT NULL DEFAULT false,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT REFERENCES users(id)
+);
+
+-- Create coupon_redemptions table for each use of a coupon
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('booking', 'quote', 'subscription')),
+    booking_id BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    order_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    cashback_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'reserved' CHECK (status IN ('reserved', 'redeemed', 'released')),
+    reserved_until TIMESTAMPTZ,
+    redeemed_at TIMESTAMPTZ,
+    cashback_status VARCHAR(20) NOT NULL DEFAULT 'none' CHECK (cashback_status IN ('none', 'pending', 'credited')),
+    cashback_payment_id BIGINT REFERENCES payments(id)
+);
+
+-- Create referral_codes table for the code each user shares
+CREATE TABLE IF NOT EXISTS referral_codes (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(20) NOT NULL
+);
+
+-- Create referrals table linking referees to their referrers
+CREATE TABLE IF NOT EXISTS referrals (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    referrer_id BIGINT NOT NULL REFERENCES users(id),
+    referee_id BIGINT NOT NULL REFERENCES users(id),
+    code VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'rewarded')),
+    booking_id BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    referrer_reward DECIMAL(10,2) NOT NULL DEFAULT 0,
+    referee_reward DECIMAL(10,2) NOT NULL DEFAULT 0,
+    referrer_payment_id BIGINT REFERENCES payments(id),
+    referee_payment_id BIGINT REFERENCES payments(id),
+    rewarded_at TIMESTAMPTZ,
+    CHECK (referrer_id <> referee_id)
+);
+
+-- Allow promotional wallet credits in payments and the wallet journal
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method
+    CHECK (method IN ('razorpay', 'wallet', 'cash', 'admin', 'promotion'));
+ALTER TABLE wallet_journal_entries DROP CONSTRAINT IF EXISTS wallet_journal_entries_account_check;
+ALTER TABLE wallet_journal_entries ADD CONSTRAINT wallet_journal_entries_account_check
+    CHECK (account IN ('user_wallet', 'gateway_clearing', 'booking_revenue', 'service_revenue', 'subscription_revenue', 'refunds', 'admin_adjustments', 'opening_balance', 'promotions'));
+ALTER TABLE wallet_journal_entries DROP CONSTRAINT IF EXISTS wallet_journal_entries_entry_type_check;
+ALTER TABLE wallet_journal_entries ADD CONSTRAINT wallet_journal_entries_entry_type_check
+    CHECK (entry_type IN ('recharge', 'booking_payment', 'service_payment', 'subscription_payment', 'refund', 'admin_adjustment', 'opening_balance', 'cashback', 'referral_reward'));
+
+-- Create indexes for better query performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupons_code ON coupons(code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_coupons_is_active ON coupons(is_active);
+CREATE INDEX IF NOT EXISTS idx_coupons_deleted_at ON coupons(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_id ON coupon_redemptions(coupon_id, status);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_user_id ON coupon_redemptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_booking_id ON coupon_redemptions(booking_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_payment_id ON coupon_redemptions(payment_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_deleted_at ON coupon_redemptions(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_codes_user_id ON referral_codes(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_codes_code ON referral_codes(code);
+CREATE INDEX IF NOT EXISTS idx_referral_codes_deleted_at ON referral_codes(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referrals_referee_id ON referrals(referee_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals(referrer_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_status ON referrals(status);
+CREATE INDEX IF NOT EXISTS idx_referrals_deleted_at ON referrals(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE coupons IS 'Promo codes for bookings, quote payments and subscriptions';
+COMMENT ON COLUMN coupons.value IS 'Flat amount in INR or percentage of the order amount';
+COMMENT ON COLUMN coupons.max_discount IS 'Cap on the discount or cashback of percentage coupons';
+COMMENT ON COLUMN coupons.usage_limit IS 'Total redemptions allowed; 0 for unlimited';
+COMMENT ON COLUMN coupons.per_user_limit IS 'Redemptions allowed per user; 0 for unlimited';
+COMMENT ON TABLE coupon_redemptions IS 'Each use of a coupon; reserved redemptions count towards limits until reserved_until';
+COMMENT ON COLUMN coupon_redemptions.cashback_status IS 'Cashback is credited to the wallet when the order is fulfilled';
+COMMENT ON TABLE referral_codes IS 'Referral code each user shares';
+COMMENT ON TABLE referrals IS 'Referee to referrer links; both wallets are credited on the referee''s first completed booking';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_referrals_deleted_at;
+DROP INDEX IF EXISTS idx_referrals_status;
+DROP INDEX IF EXISTS idx_referrals_referrer_id;
+DROP INDEX IF EXISTS idx_referrals_referee_id;
+DROP INDEX IF EXISTS idx_referral_codes_deleted_at;
+DROP INDEX IF EXISTS idx_referral_codes_code;
+DROP INDEX IF EXISTS idx_referral_codes_user_id;
+DROP INDEX IF EXISTS idx_coupon_redemptions_deleted_at;
+DROP INDEX IF EXISTS idx_coupon_redemptions_payment_id;
+DROP INDEX IF EXISTS idx_coupon_redemptions_booking_id;
+DROP INDEX IF EXISTS idx_coupon_redemptions_user_id;
+DROP INDEX IF EXISTS idx_coupon_redemptions_coupon_id;
+DROP INDEX IF EXISTS idx_coupons_deleted_at;
+DROP INDEX IF EXISTS idx_coupons_is_active;
+DROP INDEX IF EXISTS idx_coupons_code;
+ALTER TABLE wallet_journal_entries DROP CONSTRAINT IF EXISTS wallet_journal_entries_entry_type_check;
+ALTER TABLE wallet_journal_entries ADD CONSTRAINT wallet_journal_entries_entry_type_check
+    CHECK (entry_type IN ('recharge', 'booking_payment', 'service_payment', 'subscription_payment', 'refund', 'admin_adjustment', 'opening_balance'));
+ALTER TABLE wallet_journal_entries DROP CONSTRAINT IF EXISTS wallet_journal_entries_account_check;
+ALTER TABLE wallet_journal_entries ADD CONSTRAINT wallet_journal_entries_account_check
+    CHECK (account IN ('user_wallet', 'gateway_clearing', 'booking_revenue', 'service_revenue', 'subscription_revenue', 'refunds', 'admin_adjustments', 'opening_balance'));
+-- Promotion credits already made have no earlier method, so existing rows are not checked
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method
+    CHECK (method IN ('razorpay', 'wallet', 'cash', 'admin'))
+    NOT VALID;
+DROP TABLE IF EXISTS referrals CASCADE;
+DROP TABLE IF EXISTS referral_codes CASCADE;
+DROP TABLE IF EXISTS coupon_redemptions CASCADE;
+DROP TABLE IF EXISTS coupons CASCADE;
@@ -0,0 +1,75 @@
+-- +goose Up
+-- Track reminders, overdue escalation and wallet auto-debit on payment segments
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS reminders_sent INT NOT NULL DEFAULT 0;
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS overdue_at TIMESTAMPTZ;
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMPTZ;
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS auto_debit BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS auto_debit_attempted_at TIMESTAMPTZ;
+ALTER TABLE payment_segments ADD COLUMN IF NOT EXISTS auto_debit_failure TEXT;
+
+-- Let escalation pause the worker assignment of a booking with overdue segments
+ALTER TABLE worker_assignments ADD COLUMN IF NOT EXISTS paused_at TIMESTAMPTZ;
+ALTER TABLE worker_assignments ADD COLUMN IF NOT EXISTS pause_reason TEXT;
+
+-- Create index for the auto-debit sweep
+CREATE INDEX IF NOT EXISTS idx_payment_segments_auto_debit ON payment_segments(auto_debit) WHERE auto_debit = TRUE;
+
+-- Allow payment segment notifications
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed',
+    'reschedule_requested', 'booking_rescheduled', 'reschedule_declined',
+    'segment_payment_reminder', 'segment_payment_overdue', 'segment_payment_escalated',
+    'segment_auto_debit', 'assignment_paused', 'assignment_resumed'
+));
+
+-- Add comments
+COMMENT ON COLUMN payment_segments.reminders_sent IS 'Number of configured reminder days already passed and notified';
+COMMENT ON COLUMN payment_segments.overdue_at IS 'When the collection job marked the segment overdue';
+COMMENT ON COLUMN payment_segments.escalated_at IS 'When the configured escalation action ran for the overdue segment';
+COMMENT ON COLUMN payment_segments.auto_debit IS 'Customer opted in to paying the segment from their wallet when it falls due';
+COMMENT ON COLUMN payment_segments.auto_debit_failure IS 'Why the last wallet auto-debit attempt failed';
+COMMENT ON COLUMN worker_assignments.paused_at IS 'Set while the assignment is paused for overdue payment; the worker cannot start or complete it';
+
+-- +goose Down
+DELETE FROM in_app_notifications WHERE type IN ('segment_payment_reminder', 'segment_payment_overdue', 'segment_payment_escalated', 'segment_auto_debit', 'assignment_paused', 'assignment_resumed');
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed',
+    'reschedule_requested', 'booking_rescheduled', 'reschedule_declined'
+));
+DROP INDEX IF EXISTS idx_payment_segments_auto_debit;
+ALTER TABLE worker_assignments DROP COLUMN IF EXISTS pause_reason;
+ALTER TABLE worker_assignments DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS auto_debit_failure;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS auto_debit_attempted_at;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS auto_debit;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS escalated_at;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS overdue_at;
+ALTER TABLE payment_segments DROP COLUMN IF EXISTS reminders_sent;
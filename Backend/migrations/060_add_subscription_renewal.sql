@@ -0,0 +1,91 @@
+-- +goose Up
+-- Track auto-renewal, grace periods and plan changes on user subscriptions
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS duration_type TEXT;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS grace_ends_at TIMESTAMPTZ;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS renewal_attempted_at TIMESTAMPTZ;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS renewal_failure TEXT;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS renewal_payment_id BIGINT REFERENCES payments(id);
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS previous_subscription_id BIGINT REFERENCES user_subscriptions(id);
+
+-- Persist which expiry warnings were sent for each subscription
+ALTER TABLE subscription_warnings ADD COLUMN IF NOT EXISTS subscription_id BIGINT REFERENCES user_subscriptions(id);
+ALTER TABLE subscription_warnings ADD COLUMN IF NOT EXISTS days_left INT;
+ALTER TABLE subscription_warnings ADD COLUMN IF NOT EXISTS warning_date TIMESTAMPTZ;
+ALTER TABLE subscription_warnings ADD COLUMN IF NOT EXISTS sent_via TEXT;
+ALTER TABLE subscription_warnings ALTER COLUMN warning_type DROP NOT NULL;
+ALTER TABLE subscription_warnings ALTER COLUMN message DROP NOT NULL;
+
+-- Create index so each warning is recorded once per subscription
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_warnings_subscription_id ON subscription_warnings(subscription_id, days_left) WHERE deleted_at IS NULL;
+
+-- Allow subscription renewal notifications
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed',
+    'reschedule_requested', 'booking_rescheduled', 'reschedule_declined',
+    'segment_payment_reminder', 'segment_payment_overdue', 'segment_payment_escalated',
+    'segment_auto_debit', 'assignment_paused', 'assignment_resumed',
+    'subscription_renewed', 'subscription_renewal_failed', 'subscription_grace_period',
+    'subscription_plan_changed'
+));
+
+-- Add comments
+COMMENT ON COLUMN user_subscriptions.duration_type IS 'Pricing option of the plan the term was bought with; renewals buy the same one';
+COMMENT ON COLUMN user_subscriptions.auto_renew IS 'Renew from the wallet when the term ends, falling back to a Razorpay order';
+COMMENT ON COLUMN user_subscriptions.grace_ends_at IS 'Access is kept until then after a failed auto-renewal; the subscription expires if it is not renewed';
+COMMENT ON COLUMN user_subscriptions.renewal_failure IS 'Why the last auto-renewal attempt failed';
+COMMENT ON COLUMN user_subscriptions.renewal_payment_id IS 'Razorpay order the customer can pay to renew after the wallet debit failed';
+COMMENT ON COLUMN user_subscriptions.previous_subscription_id IS 'Term this one renewed or replaced through a plan change';
+COMMENT ON COLUMN subscription_warnings.days_left IS 'Warning threshold in days before expiry; each one is sent once per subscription';
+
+-- +goose Down
+DELETE FROM in_app_notifications WHERE type IN ('subscription_renewed', 'subscription_renewal_failed', 'subscription_grace_period', 'subscription_plan_changed');
+ALTER TABLE in_app_notifications DROP CONSTRAINT IF EXISTS in_app_notifications_type_check;
+ALTER TABLE in_app_notifications ADD CONSTRAINT in_app_notifications_type_check CHECK (type IN (
+    'user_registered', 'worker_application', 'broker_application',
+    'booking_created', 'service_added', 'service_updated', 'service_deactivated',
+    'property_created', 'project_created', 'vendor_profile_created',
+    'payment_received', 'subscription_purchase', 'wallet_transaction',
+    'booking_cancelled', 'worker_assigned', 'worker_started', 'worker_completed',
+    'booking_confirmed', 'quote_provided', 'quote_accepted', 'quote_rejected', 'quote_expired',
+    'payment_confirmation', 'subscription_expiry_warning', 'subscription_expired', 'conversation_started',
+    'application_accepted', 'application_rejected', 'new_assignment',
+    'assignment_accepted', 'assignment_rejected', 'work_started', 'work_completed',
+    'worker_payment_received', 'broker_application_status', 'property_approval',
+    'property_expiry_warning', 'new_service_available', 'system_maintenance',
+    'feature_update', 'otp_requested', 'otp_verified', 'login_success', 'login_failed',
+    'worker_assigned_to_work', 'dispute_opened', 'dispute_updated', 'dispute_resolved',
+    'recurring_booking_created', 'recurring_booking_failed',
+    'reschedule_requested', 'booking_rescheduled', 'reschedule_declined',
+    'segment_payment_reminder', 'segment_payment_overdue', 'segment_payment_escalated',
+    'segment_auto_debit', 'assignment_paused', 'assignment_resumed'
+));
+DROP INDEX IF EXISTS idx_subscription_warnings_subscription_id;
+DELETE FROM subscription_warnings WHERE warning_type IS NULL OR message IS NULL;
+ALTER TABLE subscription_warnings ALTER COLUMN message SET NOT NULL;
+ALTER TABLE subscription_warnings ALTER COLUMN warning_type SET NOT NULL;
+ALTER TABLE subscription_warnings DROP COLUMN IF EXISTS sent_via;
+ALTER TABLE subscription_warnings DROP COLUMN IF EXISTS warning_date;
+ALTER TABLE subscription_warnings DROP COLUMN IF EXISTS days_left;
+ALTER TABLE subscription_warnings DROP COLUMN IF EXISTS subscription_id;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS previous_subscription_id;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS renewal_payment_id;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS renewal_failure;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS renewal_attempted_at;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS grace_ends_at;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS auto_renew;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS duration_type;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Add typed entitlements to subscription plans
+ALTER TABLE subscription_plans ADD COLUMN IF NOT EXISTS entitlements JSONB;
+
+-- Let subscribers feature listings with their featured listing slots
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS is_featured BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create entitlement_usages table to meter consumption of plan entitlements
+CREATE TABLE IF NOT EXISTS entitlement_usages (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entitlement_key VARCHAR(50) NOT NULL CHECK (entitlement_key IN ('max_active_listings', 'featured_listing_slots', 'priority_score_boost', 'worker_inquiry_contacts', 'chatbot_lead_access')),
+    period_start TIMESTAMPTZ NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0 CHECK (used >= 0)
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_entitlement_usages_user_id ON entitlement_usages(user_id, entitlement_key, period_start);
+CREATE INDEX IF NOT EXISTS idx_properties_is_featured ON properties(user_id) WHERE is_featured = TRUE;
+
+-- Add comments
+COMMENT ON COLUMN subscription_plans.entitlements IS 'Typed quotas and feature access the plan grants; -1 means unlimited';
+COMMENT ON COLUMN properties.is_featured IS 'Listing is shown ahead of others; uses one of the owner''s featured listing slots';
+COMMENT ON TABLE entitlement_usages IS 'Consumption of plan entitlements per user and calendar month';
+COMMENT ON COLUMN entitlement_usages.period_start IS 'Start of the calendar month (IST) the usage counts towards';
+
+-- +goose Down
+DROP TABLE IF EXISTS entitlement_usages;
+DROP INDEX IF EXISTS idx_properties_is_featured;
+ALTER TABLE properties DROP COLUMN IF EXISTS is_featured;
+ALTER TABLE subscription_plans DROP COLUMN IF EXISTS entitlements;
@@ -0,0 +1,191 @@
+-- +goose Up
+-- Create ledger_accounts table: the chart of accounts double-entry postings are made to
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('asset', 'liability', 'equity', 'income', 'expense')),
+    description TEXT,
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Create accounting_periods table for calendar months that were closed
+CREATE TABLE IF NOT EXISTS accounting_periods (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    closed_at TIMESTAMPTZ,
+    closed_by BIGINT REFERENCES users(id),
+    notes TEXT,
+    CHECK (period_end > period_start)
+);
+
+-- Create ledger_journals table: one balanced double-entry transaction each
+CREATE TABLE IF NOT EXISTS ledger_journals (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    reference VARCHAR(100) NOT NULL,
+    entry_date TIMESTAMPTZ NOT NULL,
+    description TEXT,
+    source_type VARCHAR(30) NOT NULL CHECK (source_type IN ('manual', 'ledger_entry', 'payment', 'worker_earning', 'worker_payout', 'reversal')),
+    source_id BIGINT,
+    payment_id BIGINT REFERENCES payments(id),
+    worker_payout_id BIGINT REFERENCES worker_payouts(id),
+    vendor_id BIGINT REFERENCES vendors(id),
+    ledger_entry_id BIGINT REFERENCES ledger_entries(id),
+    reversal_of_id BIGINT REFERENCES ledger_journals(id),
+    total_amount DECIMAL(12,2) NOT NULL,
+    created_by BIGINT REFERENCES users(id)
+);
+
+-- Create ledger_postings table: the debit and credit lines of journals
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    journal_id BIGINT NOT NULL REFERENCES ledger_journals(id),
+    account_id BIGINT NOT NULL REFERENCES ledger_accounts(id),
+    debit DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    description TEXT,
+    CHECK ((debit = 0) <> (credit = 0))
+);
+
+-- Create ledger_attachments table for receipts attached to ledger entries and journals
+CREATE TABLE IF NOT EXISTS ledger_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    ledger_entry_id BIGINT REFERENCES ledger_entries(id),
+    journal_id BIGINT REFERENCES ledger_journals(id),
+    file_url TEXT NOT NULL,
+    file_name VARCHAR(255),
+    content_type VARCHAR(100),
+    uploaded_by BIGINT NOT NULL REFERENCES users(id),
+    CHECK ((ledger_entry_id IS NULL) <> (journal_id IS NULL))
+);
+
+-- Link ledger entries to an account and to platform payments, worker payouts and vendors
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS account_id BIGINT REFERENCES ledger_accounts(id);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS payment_id BIGINT REFERENCES payments(id);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS worker_payout_id BIGINT REFERENCES worker_payouts(id);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS vendor_id BIGINT REFERENCES vendors(id);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code ON ledger_accounts(code) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounting_periods_period_start ON accounting_periods(period_start) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_journals_reference ON ledger_journals(reference);
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_entry_date ON ledger_journals(entry_date);
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_source ON ledger_journals(source_type, source_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_payment_id ON ledger_journals(payment_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_vendor_id ON ledger_journals(vendor_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_journal_id ON ledger_postings(journal_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings(account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_attachments_ledger_entry_id ON ledger_attachments(ledger_entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_attachments_journal_id ON ledger_attachments(journal_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_payment_id ON ledger_entries(payment_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_vendor_id ON ledger_entries(vendor_id);
+
+-- Journals and postings are immutable, and none can be dated in a closed period;
+-- corrections are posted as reversing journals
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_ledger_journal_changes() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        RAISE EXCEPTION 'ledger journals are immutable';
+    END IF;
+    IF TG_TABLE_NAME = 'ledger_journals' AND EXISTS (
+        SELECT 1 FROM accounting_periods
+        WHERE status = 'closed' AND deleted_at IS NULL
+          AND NEW.entry_date >= period_start AND NEW.entry_date < period_end
+    ) THEN
+        RAISE EXCEPTION 'accounting period of % is closed', NEW.entry_date;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER ledger_journals_immutable
+    BEFORE INSERT OR UPDATE OR DELETE ON ledger_journals
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_journal_changes();
+
+CREATE TRIGGER ledger_postings_immutable
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_journal_changes();
+
+-- Ledger entries created in a closed period cannot be changed or deleted
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_closed_ledger_entry_changes() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM accounting_periods
+        WHERE status = 'closed' AND deleted_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        RAISE EXCEPTION 'ledger entry % is in a closed accounting period', OLD.id;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER ledger_entries_closed_period
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_closed_ledger_entry_changes();
+
+-- Seed the chart of accounts the platform posts to
+INSERT INTO ledger_accounts (code, name, account_type, description, is_system) VALUES
+    ('1000', 'Cash in hand', 'asset', 'Cash held by the business', TRUE),
+    ('1010', 'Bank account', 'asset', 'Business bank account; worker payouts are paid from it', TRUE),
+    ('1100', 'Razorpay clearing', 'asset', 'Payments captured by Razorpay and not yet settled to the bank', TRUE),
+    ('2000', 'Customer wallet balances', 'liability', 'Money held in customer wallets', TRUE),
+    ('2100', 'Worker payables', 'liability', 'Earnings owed to workers and not yet paid out', TRUE),
+    ('2200', 'Vendor payables', 'liability', 'Amounts owed to vendors', TRUE),
+    ('3000', 'Owner''s equity', 'equity', 'Capital and retained earnings', TRUE),
+    ('4000', 'Service revenue', 'income', 'Bookings, quotes and payment segments', TRUE),
+    ('4100', 'Subscription revenue', 'income', 'Subscription plan purchases and renewals', TRUE),
+    ('4800', 'Other income', 'income', 'Income recorded through ledger receive entries', TRUE),
+    ('4900', 'Sales refunds', 'income', 'Refunds issued to customers; reduces income', TRUE),
+    ('5000', 'Worker payouts', 'expense', 'Worker earnings for completed bookings', TRUE),
+    ('5100', 'Promotions and cashback', 'expense', 'Coupon cashback and referral rewards credited to wallets', TRUE),
+    ('5200', 'Wallet adjustments', 'expense', 'Manual wallet adjustments by admins', TRUE),
+    ('5900', 'Other expenses', 'expense', 'Expenses recorded through ledger pay entries', TRUE)
+ON CONFLICT DO NOTHING;
+
+-- Add comments
+COMMENT ON TABLE ledger_accounts IS 'Chart of accounts; system accounts are posted to automatically and cannot be removed';
+COMMENT ON TABLE accounting_periods IS 'Closed calendar months (IST); journals and ledger entries in them are immutable';
+COMMENT ON TABLE ledger_journals IS 'Balanced double-entry transactions posted for ledger entries, platform events and manual journals';
+COMMENT ON COLUMN ledger_journals.reference IS 'Unique per journal; platform events use the event, e.g. PAY-12, so each is posted once';
+COMMENT ON COLUMN ledger_journals.reversal_of_id IS 'Journal this one reverses';
+COMMENT ON TABLE ledger_postings IS 'Debit and credit lines of a journal; debits equal credits per journal';
+COMMENT ON TABLE ledger_attachments IS 'Receipts attached to ledger entries and journals';
+COMMENT ON COLUMN ledger_entries.account_id IS 'Account the entry is posted against when paid or received';
+
+-- +goose Down
+DROP TRIGGER IF EXISTS ledger_entries_closed_period ON ledger_entries;
+DROP FUNCTION IF EXISTS prevent_closed_ledger_entry_changes();
+DROP INDEX IF EXISTS idx_ledger_entries_vendor_id;
+DROP INDEX IF EXISTS idx_ledger_entries_payment_id;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS vendor_id;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS worker_payout_id;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS payment_id;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS account_id;
+DROP TABLE IF EXISTS ledger_attachments;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_journals;
+DROP FUNCTION IF EXISTS prevent_ledger_journal_changes();
+DROP TABLE IF EXISTS accounting_periods;
+DROP TABLE IF EXISTS ledger_accounts;
@@ -0,0 +1,56 @@
+-- +goose Up
+-- Record Razorpay settlements against payments
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS razorpay_settlement_id VARCHAR(255);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settled_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS gateway_fee DECIMAL(12,2);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS gateway_tax DECIMAL(12,2);
+
+-- Create payment_discrepancies table for mismatches between payments and Razorpay found by reconciliation
+CREATE TABLE IF NOT EXISTS payment_discrepancies (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    gateway_payment_id VARCHAR(255),
+    gateway_order_id VARCHAR(255),
+    discrepancy_type VARCHAR(50) NOT NULL CHECK (discrepancy_type IN ('amount_mismatch', 'captured_after_expiry', 'not_settled', 'settlement_amount_mismatch', 'unknown_settlement')),
+    local_status VARCHAR(20),
+    gateway_status VARCHAR(50),
+    local_amount DECIMAL(12,2),
+    gateway_amount DECIMAL(12,2),
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ,
+    resolved_by BIGINT REFERENCES users(id),
+    resolution_notes TEXT
+);
+
+-- Create indexes for better query performance
+CREATE INDEX IF NOT EXISTS idx_payments_razorpay_settlement_id ON payments(razorpay_settlement_id);
+CREATE INDEX IF NOT EXISTS idx_payments_unsettled ON payments(completed_at) WHERE method = 'razorpay' AND razorpay_settlement_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_payment_id ON payment_discrepancies(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_gateway_payment_id ON payment_discrepancies(gateway_payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_status ON payment_discrepancies(status, discrepancy_type);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_deleted_at ON payment_discrepancies(deleted_at);
+
+-- Add comments
+COMMENT ON COLUMN payments.razorpay_settlement_id IS 'Razorpay settlement the captured payment was paid out in';
+COMMENT ON COLUMN payments.gateway_fee IS 'Razorpay fee deducted at settlement, including tax';
+COMMENT ON TABLE payment_discrepancies IS 'Mismatches between local payments and Razorpay payments or settlements found by reconciliation';
+COMMENT ON COLUMN payment_discrepancies.discrepancy_type IS 'Type of mismatch (amount_mismatch, captured_after_expiry, not_settled, settlement_amount_mismatch, unknown_settlement)';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_payment_discrepancies_deleted_at;
+DROP INDEX IF EXISTS idx_payment_discrepancies_status;
+DROP INDEX IF EXISTS idx_payment_discrepancies_gateway_payment_id;
+DROP INDEX IF EXISTS idx_payment_discrepancies_payment_id;
+DROP INDEX IF EXISTS idx_payments_unsettled;
+DROP INDEX IF EXISTS idx_payments_razorpay_settlement_id;
+DROP TABLE IF EXISTS payment_discrepancies CASCADE;
+ALTER TABLE payments DROP COLUMN IF EXISTS gateway_tax;
+ALTER TABLE payments DROP COLUMN IF EXISTS gateway_fee;
+ALTER TABLE payments DROP COLUMN IF EXISTS settled_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS razorpay_settlement_id;
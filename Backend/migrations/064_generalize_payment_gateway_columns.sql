@@ -0,0 +1,55 @@
+-- +goose Up
+-- Store gateway references in provider-neutral columns so payments can go through any gateway
+ALTER TABLE payments RENAME COLUMN razorpay_order_id TO gateway_order_id;
+ALTER TABLE payments RENAME COLUMN razorpay_payment_id TO gateway_payment_id;
+ALTER TABLE payments RENAME COLUMN razorpay_signature TO gateway_signature;
+ALTER TABLE payments RENAME COLUMN razorpay_settlement_id TO gateway_settlement_id;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS gateway_provider VARCHAR(20);
+
+-- Every gateway payment so far went through Razorpay
+UPDATE payments SET gateway_provider = 'razorpay'
+WHERE gateway_order_id IS NOT NULL OR gateway_payment_id IS NOT NULL;
+
+-- Refund records keep their gateway refund ID under a provider-neutral metadata key
+UPDATE payments
+SET metadata = (metadata - 'razorpay_refund_id' - 'razorpay_refund_status')
+    || jsonb_build_object('gateway_refund_id', metadata->'razorpay_refund_id', 'gateway_refund_status', metadata->'razorpay_refund_status')
+WHERE metadata ? 'razorpay_refund_id';
+
+ALTER INDEX IF EXISTS idx_payments_razorpay_order_id RENAME TO idx_payments_gateway_order_id;
+ALTER INDEX IF EXISTS idx_payments_razorpay_payment_id RENAME TO idx_payments_gateway_payment_id;
+ALTER INDEX IF EXISTS idx_payments_razorpay_settlement_id RENAME TO idx_payments_gateway_settlement_id;
+DROP INDEX IF EXISTS idx_payments_razorpay_refund_id;
+CREATE INDEX IF NOT EXISTS idx_payments_gateway_refund_id ON payments((metadata->>'gateway_refund_id')) WHERE type = 'refund';
+CREATE INDEX IF NOT EXISTS idx_payments_gateway_provider ON payments(gateway_provider);
+
+-- Payments captured through Cashfree clear through their own account
+INSERT INTO ledger_accounts (code, name, account_type, description, is_system) VALUES
+    ('1110', 'Cashfree clearing', 'asset', 'Payments captured by Cashfree and not yet settled to the bank', TRUE)
+ON CONFLICT DO NOTHING;
+
+-- Add comments
+COMMENT ON COLUMN payments.gateway_provider IS 'Payment gateway the payment went through (razorpay, cashfree); NULL for wallet, cash and admin payments';
+COMMENT ON COLUMN payments.gateway_order_id IS 'Order ID at the payment gateway';
+COMMENT ON COLUMN payments.gateway_payment_id IS 'Payment ID at the payment gateway';
+COMMENT ON COLUMN payments.gateway_settlement_id IS 'Gateway settlement the captured payment was paid out in';
+
+-- +goose Down
+DELETE FROM ledger_accounts WHERE code = '1110' AND NOT EXISTS (SELECT 1 FROM ledger_postings WHERE ledger_postings.account_id = ledger_accounts.id);
+DROP INDEX IF EXISTS idx_payments_gateway_provider;
+DROP INDEX IF EXISTS idx_payments_gateway_refund_id;
+CREATE INDEX IF NOT EXISTS idx_payments_razorpay_refund_id ON payments((metadata->>'razorpay_refund_id')) WHERE type = 'refund';
+ALTER INDEX IF EXISTS idx_payments_gateway_settlement_id RENAME TO idx_payments_razorpay_settlement_id;
+ALTER INDEX IF EXISTS idx_payments_gateway_payment_id RENAME TO idx_payments_razorpay_payment_id;
+ALTER INDEX IF EXISTS idx_payments_gateway_order_id RENAME TO idx_payments_razorpay_order_id;
+
+UPDATE payments
+SET metadata = (metadata - 'gateway_refund_id' - 'gateway_refund_status')
+    || jsonb_build_object('razorpay_refund_id', metadata->'gateway_refund_id', 'razorpay_refund_status', metadata->'gateway_refund_status')
+WHERE metadata ? 'gateway_refund_id';
+
+ALTER TABLE payments DROP COLUMN IF EXISTS gateway_provider;
+ALTER TABLE payments RENAME COLUMN gateway_settlement_id TO razorpay_settlement_id;
+ALTER TABLE payments RENAME COLUMN gateway_signature TO razorpay_signature;
+ALTER TABLE payments RENAME COLUMN gateway_payment_id TO razorpay_payment_id;
+ALTER TABLE payments RENAME COLUMN gateway_order_id TO razorpay_order_id;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Realtime messages too large for a Postgres NOTIFY payload, read by the listeners of every replica
+CREATE TABLE IF NOT EXISTS realtime_broker_messages (
+    id BIGSERIAL PRIMARY KEY,
+    channel VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_realtime_broker_messages_created_at ON realtime_broker_messages(created_at);
+
+-- Add comments
+COMMENT ON TABLE realtime_broker_messages IS 'Realtime broker payloads too large to send inline through LISTEN/NOTIFY; deleted after an hour';
+COMMENT ON COLUMN realtime_broker_messages.channel IS 'Broker channel the payload was published on';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_realtime_broker_messages_created_at;
+DROP TABLE IF EXISTS realtime_broker_messages;
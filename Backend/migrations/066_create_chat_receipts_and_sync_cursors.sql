@@ -0,0 +1,49 @@
+-- +goose Up
+-- Delivered and read receipts of chat messages per participant
+CREATE TABLE IF NOT EXISTS chat_message_receipts (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    thread_type VARCHAR(20) NOT NULL,
+    thread_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delivered_at TIMESTAMPTZ,
+    read_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_message_receipts_message_user ON chat_message_receipts(thread_type, message_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_chat_message_receipts_thread ON chat_message_receipts(thread_type, thread_id);
+
+-- Last message each device of a user has synced in a thread
+CREATE TABLE IF NOT EXISTS chat_sync_cursors (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) NOT NULL,
+    thread_type VARCHAR(20) NOT NULL,
+    thread_id BIGINT NOT NULL,
+    last_message_id BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_sync_cursors_device_thread ON chat_sync_cursors(user_id, device_id, thread_type, thread_id);
+
+-- Sync reads the messages of a thread after a message ID
+CREATE INDEX IF NOT EXISTS idx_chat_messages_room_id_id ON chat_messages(room_id, id);
+CREATE INDEX IF NOT EXISTS idx_simple_conversation_messages_conversation_id_id ON simple_conversation_messages(conversation_id, id);
+
+-- Add comments
+COMMENT ON TABLE chat_message_receipts IS 'When each participant other than the sender received and read a chat message';
+COMMENT ON COLUMN chat_message_receipts.thread_type IS 'Kind of chat the message belongs to: chat_room (chat_messages) or conversation (simple_conversation_messages)';
+COMMENT ON COLUMN chat_message_receipts.thread_id IS 'Chat room or conversation ID';
+COMMENT ON TABLE chat_sync_cursors IS 'Last message a device has synced in a chat room or conversation, so a reconnecting client gets everything after it';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_simple_conversation_messages_conversation_id_id;
+DROP INDEX IF EXISTS idx_chat_messages_room_id_id;
+DROP INDEX IF EXISTS idx_chat_sync_cursors_device_thread;
+DROP TABLE IF EXISTS chat_sync_cursors;
+DROP INDEX IF EXISTS idx_chat_message_receipts_thread;
+DROP INDEX IF EXISTS idx_chat_message_receipts_message_user;
+DROP TABLE IF EXISTS chat_message_receipts;
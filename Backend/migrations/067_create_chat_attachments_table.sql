@@ -0,0 +1,53 @@
+-- +goose Up
+-- Files, images and location pins shared in chat rooms and conversations
+CREATE TABLE IF NOT EXISTS chat_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    thread_type VARCHAR(20) NOT NULL,
+    thread_id BIGINT NOT NULL,
+    uploader_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id BIGINT,
+    kind VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255),
+    mime_type VARCHAR(100),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    location_name VARCHAR(255),
+    address TEXT,
+    url TEXT,
+    thumbnail_url TEXT,
+    public_id VARCHAR(255),
+    resource_type VARCHAR(20)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_deleted_at ON chat_attachments(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_thread ON chat_attachments(thread_type, thread_id);
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_message_id ON chat_attachments(message_id);
+
+-- Messages keep the metadata of their attachments
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS attachment_details JSONB DEFAULT '[]';
+ALTER TABLE simple_conversation_messages ADD COLUMN IF NOT EXISTS message_type VARCHAR(20) DEFAULT 'text';
+ALTER TABLE simple_conversation_messages ADD COLUMN IF NOT EXISTS attachment_details JSONB DEFAULT '[]';
+
+-- Add comments
+COMMENT ON TABLE chat_attachments IS 'Files, images and location pins shared in chat rooms and conversations, served only to participants';
+COMMENT ON COLUMN chat_attachments.thread_type IS 'Kind of chat the attachment belongs to: chat_room or conversation';
+COMMENT ON COLUMN chat_attachments.message_id IS 'Message carrying the attachment, NULL until it is sent';
+COMMENT ON COLUMN chat_attachments.kind IS 'Attachment kind: image, file or location';
+COMMENT ON COLUMN chat_attachments.url IS 'Cloudinary URL of the media, never returned to clients';
+COMMENT ON COLUMN chat_messages.attachment_details IS 'Metadata of the attachments the message carries';
+COMMENT ON COLUMN simple_conversation_messages.attachment_details IS 'Metadata of the attachments the message carries';
+
+-- +goose Down
+ALTER TABLE simple_conversation_messages DROP COLUMN IF EXISTS attachment_details;
+ALTER TABLE simple_conversation_messages DROP COLUMN IF EXISTS message_type;
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS attachment_details;
+DROP INDEX IF EXISTS idx_chat_attachments_message_id;
+DROP INDEX IF EXISTS idx_chat_attachments_thread;
+DROP INDEX IF EXISTS idx_chat_attachments_deleted_at;
+DROP TABLE IF EXISTS chat_attachments;
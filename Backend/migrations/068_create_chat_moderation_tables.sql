@@ -0,0 +1,60 @@
+-- +goose Up
+-- Chat messages flagged for admin review, by participants or automatically by moderation
+CREATE TABLE IF NOT EXISTS chat_message_reports (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    thread_type VARCHAR(20) NOT NULL,
+    thread_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    sender_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reporter_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    source VARCHAR(20) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    details TEXT,
+    original_message TEXT,
+    detected JSONB DEFAULT '[]',
+    status VARCHAR(20) DEFAULT 'pending',
+    reviewed_by BIGINT REFERENCES users(id),
+    reviewed_at TIMESTAMPTZ,
+    review_notes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_reports_status ON chat_message_reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_chat_message_reports_sender_id ON chat_message_reports(sender_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_message_reports_message_reporter ON chat_message_reports(thread_type, message_id, reporter_id) WHERE reporter_id IS NOT NULL;
+
+-- Users muted from sending chat messages by admins
+CREATE TABLE IF NOT EXISTS chat_mutes (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_type VARCHAR(20),
+    thread_id BIGINT,
+    report_id BIGINT REFERENCES chat_message_reports(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    muted_by BIGINT NOT NULL REFERENCES users(id),
+    muted_until TIMESTAMPTZ,
+    lifted_by BIGINT REFERENCES users(id),
+    lifted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_mutes_user_id ON chat_mutes(user_id);
+
+-- Add comments
+COMMENT ON TABLE chat_message_reports IS 'Chat messages flagged for admin review, reported by participants or flagged when moderation masked contact details or blocked words';
+COMMENT ON COLUMN chat_message_reports.thread_type IS 'Kind of chat the message belongs to: chat_room (chat_messages) or conversation (simple_conversation_messages)';
+COMMENT ON COLUMN chat_message_reports.reporter_id IS 'Participant who reported the message, NULL when flagged automatically';
+COMMENT ON COLUMN chat_message_reports.original_message IS 'Message as the sender wrote it, before masking';
+COMMENT ON COLUMN chat_message_reports.detected IS 'What moderation found in the message: phone, email, payment_handle or profanity';
+COMMENT ON TABLE chat_mutes IS 'Users muted from sending messages in one chat, or in every chat when thread_type is NULL';
+COMMENT ON COLUMN chat_mutes.muted_until IS 'When the mute expires, NULL until an admin lifts it';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chat_mutes_user_id;
+DROP TABLE IF EXISTS chat_mutes;
+DROP INDEX IF EXISTS idx_chat_message_reports_message_reporter;
+DROP INDEX IF EXISTS idx_chat_message_reports_sender_id;
+DROP INDEX IF EXISTS idx_chat_message_reports_status;
+DROP TABLE IF EXISTS chat_message_reports;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Let recurring booking runs claim an occurrence before booking it
+ALTER TABLE booking_series_occurrences DROP CONSTRAINT IF EXISTS booking_series_occurrences_status_check;
+ALTER TABLE booking_series_occurrences ADD CONSTRAINT booking_series_occurrences_status_check
+    CHECK (status IN ('scheduled', 'processing', 'booked', 'skipped', 'cancelled', 'failed'));
+
+COMMENT ON COLUMN booking_series_occurrences.status IS 'Occurrence status (scheduled, processing, booked, skipped, cancelled, failed)';
+
+-- +goose Down
+UPDATE booking_series_occurrences SET status = 'scheduled' WHERE status = 'processing';
+
+ALTER TABLE booking_series_occurrences DROP CONSTRAINT IF EXISTS booking_series_occurrences_status_check;
+ALTER TABLE booking_series_occurrences ADD CONSTRAINT booking_series_occurrences_status_check
+    CHECK (status IN ('scheduled', 'booked', 'skipped', 'cancelled', 'failed'));
+
+COMMENT ON COLUMN booking_series_occurrences.status IS 'Occurrence status (scheduled, booked, skipped, cancelled, failed)';
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Let a payment be refunded in parts: refund_amount holds the total refunded so far
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_status
+    CHECK (status IN ('pending', 'completed', 'failed', 'refunded', 'partially_refunded', 'cancelled', 'abandoned', 'expired', 'hold'));
+
+COMMENT ON COLUMN payments.refund_amount IS 'Total refunded so far; the payment is partially_refunded until it reaches amount';
+
+-- +goose Down
+UPDATE payments SET status = 'refunded' WHERE status = 'partially_refunded';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_status
+    CHECK (status IN ('pending', 'completed', 'failed', 'refunded', 'cancelled', 'abandoned', 'expired', 'hold'));
+
+COMMENT ON COLUMN payments.refund_amount IS NULL;
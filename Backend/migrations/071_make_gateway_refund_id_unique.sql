@@ -0,0 +1,20 @@
+-- +goose Up
+-- A gateway refund has one refund record. Refunds recorded twice, by the refund call and by a webhook
+-- that arrived before it, keep their first record.
+UPDATE payments
+SET deleted_at = NOW()
+WHERE type = 'refund' AND deleted_at IS NULL AND metadata->>'gateway_refund_id' IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM payments first
+      WHERE first.type = 'refund' AND first.deleted_at IS NULL
+        AND first.metadata->>'gateway_refund_id' = payments.metadata->>'gateway_refund_id'
+        AND first.id < payments.id
+  );
+
+DROP INDEX IF EXISTS idx_payments_gateway_refund_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_gateway_refund_id ON payments((metadata->>'gateway_refund_id'))
+    WHERE type = 'refund' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_gateway_refund_id;
+CREATE INDEX IF NOT EXISTS idx_payments_gateway_refund_id ON payments((metadata->>'gateway_refund_id')) WHERE type = 'refund';
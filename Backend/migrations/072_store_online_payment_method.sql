@@ -0,0 +1,32 @@
+-- +goose Up
+-- Payments made through a payment gateway are stored with method 'online'; gateway_provider records
+-- which gateway took them. Promotional wallet credits are stored with method 'promotion'.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+
+UPDATE payments SET method = 'online' WHERE method = 'razorpay';
+UPDATE payments SET method = 'online' WHERE type = 'wallet_recharge' AND method = 'wallet' AND gateway_provider IS NOT NULL;
+UPDATE payments SET refund_method = 'online' WHERE refund_method = 'razorpay';
+
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method
+    CHECK (method IN ('online', 'wallet', 'cash', 'admin', 'promotion'));
+
+DROP INDEX IF EXISTS idx_payments_unsettled;
+CREATE INDEX IF NOT EXISTS idx_payments_unsettled ON payments(completed_at) WHERE method = 'online' AND gateway_settlement_id IS NULL;
+
+COMMENT ON COLUMN payments.method IS 'How the payment was made: online (through gateway_provider), wallet, cash, admin or promotion';
+COMMENT ON COLUMN payments.refund_method IS 'Where the refund went: online (back through the gateway) or wallet';
+
+-- +goose Down
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+
+UPDATE payments SET method = 'razorpay' WHERE method = 'online';
+UPDATE payments SET refund_method = 'razorpay' WHERE refund_method = 'online';
+
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method
+    CHECK (method IN ('razorpay', 'wallet', 'cash', 'admin', 'promotion'));
+
+DROP INDEX IF EXISTS idx_payments_unsettled;
+CREATE INDEX IF NOT EXISTS idx_payments_unsettled ON payments(completed_at) WHERE method = 'razorpay' AND gateway_settlement_id IS NULL;
+
+COMMENT ON COLUMN payments.method IS NULL;
+COMMENT ON COLUMN payments.refund_method IS NULL;
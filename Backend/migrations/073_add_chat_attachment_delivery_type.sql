@@ -0,0 +1,8 @@
+-- +goose Up
+-- Chat attachments are uploaded as authenticated and fetched through signed URLs; earlier ones stay public
+ALTER TABLE chat_attachments ADD COLUMN IF NOT EXISTS delivery_type VARCHAR(20) NOT NULL DEFAULT 'upload';
+
+COMMENT ON COLUMN chat_attachments.delivery_type IS 'Cloudinary delivery type: upload (public URL) or authenticated (signed URLs only)';
+
+-- +goose Down
+ALTER TABLE chat_attachments DROP COLUMN IF EXISTS delivery_type;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Refunds owed on cancelled bookings that could not be issued at cancellation, retried until issued
+CREATE TABLE IF NOT EXISTS pending_refunds (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount DECIMAL(12,2) NOT NULL,
+    refund_method VARCHAR(20),
+    reason TEXT,
+    notes TEXT,
+    settled_payment_status VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'issued')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    issued_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_refunds_booking_id ON pending_refunds(booking_id);
+CREATE INDEX IF NOT EXISTS idx_pending_refunds_due ON pending_refunds(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_pending_refunds_deleted_at ON pending_refunds(deleted_at);
+
+-- Add comments
+COMMENT ON TABLE pending_refunds IS 'Cancellation refunds that could not be issued, retried by the refund retry job and listed for admins';
+COMMENT ON COLUMN pending_refunds.settled_payment_status IS 'Payment status the booking moves to once all of its pending refunds are issued';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pending_refunds_deleted_at;
+DROP INDEX IF EXISTS idx_pending_refunds_due;
+DROP INDEX IF EXISTS idx_pending_refunds_booking_id;
+DROP TABLE IF EXISTS pending_refunds CASCADE;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Let dispute refunds take the refunded amount off the worker's unpaid earning for the booking
+ALTER TABLE worker_earnings ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE worker_earnings DROP CONSTRAINT IF EXISTS worker_earnings_status_check;
+ALTER TABLE worker_earnings ADD CONSTRAINT worker_earnings_status_check
+    CHECK (status IN ('pending', 'in_payout', 'paid', 'reversed'));
+
+COMMENT ON COLUMN worker_earnings.refunded_amount IS 'Amount taken off the gross amount by dispute refunds before the earning was paid out';
+COMMENT ON COLUMN worker_earnings.status IS 'Earning status (pending, in_payout, paid, reversed)';
+
+-- +goose Down
+-- Reversed earnings have nothing left to pay
+UPDATE worker_earnings SET status = 'paid' WHERE status = 'reversed';
+
+ALTER TABLE worker_earnings DROP CONSTRAINT IF EXISTS worker_earnings_status_check;
+ALTER TABLE worker_earnings ADD CONSTRAINT worker_earnings_status_check
+    CHECK (status IN ('pending', 'in_payout', 'paid'));
+
+COMMENT ON COLUMN worker_earnings.status IS NULL;
+
+ALTER TABLE worker_earnings DROP COLUMN IF EXISTS refunded_amount;
@@ -40,6 +40,8 @@ const (
 	PaymentStatusCompleted PaymentStatus = "completed" // Payment completed
 	PaymentStatusFailed    PaymentStatus = "failed"    // Payment failed
 	PaymentStatusRefunded  PaymentStatus = "refunded"  // Payment refunded
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded" // Part of the payment refunded; the rest can still be refunded
+	PaymentStatusRefundPending PaymentStatus = "refund_pending" // Booking cancelled but a refund owed on it could not be issued
 	PaymentStatusCancelled PaymentStatus = "cancelled" // Payment cancelled
 	PaymentStatusAbandoned PaymentStatus = "abandoned" // Payment abandoned
 	PaymentStatusExpired   PaymentStatus = "expired"   // Payment expired
@@ -137,6 +139,7 @@ type CreateBookingRequest struct {
 	ContactPerson        string          `json:"contact_person"`
 	ContactPhone         string          `json:"contact_phone"`
 	SpecialInstructions  string          `json:"special_instructions"`
+	CouponCode           string          `json:"coupon_code"` // Optional promo code
 }
 
 // CreateBookingWithPaymentRequest represents the request structure for creating a booking with payment
@@ -428,12 +431,14 @@ type BookingStatistics struct {
 
 // ActivityLog represents activity log entry
 type ActivityLog struct {
-	ID            uint      `json:"id"`
-	Action        string    `json:"action"`
-	Description   string    `json:"description"`
-	PerformedBy   string    `json:"performed_by"`
-	PerformedByID *uint     `json:"performed_by_id"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            uint        `json:"id"`
+	Action        string      `json:"action"`
+	Description   string      `json:"description"`
+	PerformedBy   string      `json:"performed_by"`
+	PerformedByID *uint       `json:"performed_by_id"`
+	OldValue      interface{} `json:"old_value"`
+	NewValue      interface{} `json:"new_value"`
+	CreatedAt     time.Time   `json:"created_at"`
 }
 
 // ChatMessageInfo represents chat message information for booking details
@@ -503,6 +508,8 @@ type CreateQuotePaymentRequest struct {
 	
 	// For segmented payments
 	SegmentNumber *int    `json:"segment_number,omitempty"` // Specific segment to pay (optional)
+	
+	CouponCode    string  `json:"coupon_code,omitempty"` // Optional promo code (single payments only)
 }
 
 // VerifyQuotePaymentRequest represents the request to verify payment for quote acceptance
@@ -517,6 +524,7 @@ type WalletPaymentRequest struct {
 	ScheduledDate string  `json:"scheduled_date" binding:"required"` // YYYY-MM-DD format
 	ScheduledTime string  `json:"scheduled_time" binding:"required"` // HH:MM format
 	Amount        float64 `json:"amount" binding:"required,min=0"`   // Quote amount to pay
+	CouponCode    string  `json:"coupon_code,omitempty"`             // Optional promo code
 }
 
 // Quote represents a quote for notification purposes
@@ -561,7 +569,8 @@ func (b *Booking) GetPaymentProgress() *PaymentProgress {
 			PaidAt:        segment.PaidAt,
 			Notes:         segment.Notes,
 			PaymentID:     segment.PaymentID,
-			IsOverdue:     false,
+			IsOverdue:     segment.Status == PaymentSegmentStatusOverdue,
+			AutoDebit:     segment.AutoDebit,
 		}
 
 		// Calculate if overdue
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// BookingActivityAction represents the kind of change recorded on a booking
+type BookingActivityAction string
+
+const (
+	BookingActivityCreated                 BookingActivityAction = "booking_created"
+	BookingActivityStatusChanged           BookingActivityAction = "status_changed"
+	BookingActivityQuoteProvided           BookingActivityAction = "quote_provided"
+	BookingActivityQuoteUpdated            BookingActivityAction = "quote_updated"
+	BookingActivityQuoteAccepted           BookingActivityAction = "quote_accepted"
+	BookingActivityQuoteRejected           BookingActivityAction = "quote_rejected"
+	BookingActivityQuoteExpired            BookingActivityAction = "quote_expired"
+	BookingActivityScheduled               BookingActivityAction = "scheduled"
+	BookingActivityWorkerAssigned          BookingActivityAction = "worker_assigned"
+	BookingActivityAssignmentStatusChanged BookingActivityAction = "assignment_status_changed"
+	BookingActivityBufferRequested         BookingActivityAction = "buffer_requested"
+	BookingActivityBufferResponded         BookingActivityAction = "buffer_responded"
+	BookingActivityPaymentReceived         BookingActivityAction = "payment_received"
+	BookingActivityPaymentRefunded         BookingActivityAction = "payment_refunded"
+	BookingActivityDisputeOpened           BookingActivityAction = "dispute_opened"
+	BookingActivityDisputeResolved         BookingActivityAction = "dispute_resolved"
+	BookingActivityRescheduleRequested     BookingActivityAction = "reschedule_requested"
+	BookingActivityRescheduled             BookingActivityAction = "rescheduled"
+	BookingActivityRescheduleDeclined      BookingActivityAction = "reschedule_declined"
+	BookingActivitySegmentOverdue          BookingActivityAction = "segment_overdue"
+	BookingActivityAssignmentPaused        BookingActivityAction = "assignment_paused"
+	BookingActivityAssignmentResumed       BookingActivityAction = "assignment_resumed"
+)
+
+// ActivityActorType represents who performed an activity
+type ActivityActorType string
+
+const (
+	ActivityActorUser   ActivityActorType = "user"   // Customer who owns the booking
+	ActivityActorWorker ActivityActorType = "worker" // Assigned worker
+	ActivityActorAdmin  ActivityActorType = "admin"  // Admin user
+	ActivityActorSystem ActivityActorType = "system" // Background jobs and webhooks
+)
+
+// ActivityActor identifies who performed an activity
+type ActivityActor struct {
+	Type ActivityActorType
+	ID   *uint
+}
+
+// UserActor returns the actor for a customer action
+func UserActor(userID uint) ActivityActor {
+	return ActivityActor{Type: ActivityActorUser, ID: &userID}
+}
+
+// WorkerActor returns the actor for a worker action
+func WorkerActor(workerID uint) ActivityActor {
+	return ActivityActor{Type: ActivityActorWorker, ID: &workerID}
+}
+
+// AdminActor returns the actor for an admin action
+func AdminActor(adminID uint) ActivityActor {
+	return ActivityActor{Type: ActivityActorAdmin, ID: &adminID}
+}
+
+// SystemActor returns the actor for automated changes
+func SystemActor() ActivityActor {
+	return ActivityActor{Type: ActivityActorSystem}
+}
+
+// BookingActivityLog represents one entry in a booking's audit trail
+type BookingActivityLog struct {
+	gorm.Model
+	BookingID   uint                  `json:"booking_id" gorm:"not null;index"`
+	Action      BookingActivityAction `json:"action" gorm:"not null"`
+	ActorType   ActivityActorType     `json:"actor_type" gorm:"not null"`
+	ActorID     *uint                 `json:"actor_id"`
+	OldValue    interface{}           `json:"old_value" gorm:"type:jsonb;serializer:json"`
+	NewValue    interface{}           `json:"new_value" gorm:"type:jsonb;serializer:json"`
+	Description string                `json:"description"`
+
+	// Relationships
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// TableName returns the table name for BookingActivityLog
+func (BookingActivityLog) TableName() string {
+	return "booking_activity_logs"
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DisputeStatus represents the status of a booking dispute
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "open"         // Raised, waiting for admin
+	DisputeStatusUnderReview DisputeStatus = "under_review" // Admin is investigating
+	DisputeStatusResolved    DisputeStatus = "resolved"     // Admin has decided the outcome
+)
+
+// DisputeResolution represents how an admin resolved a dispute
+type DisputeResolution string
+
+const (
+	DisputeResolutionRefund        DisputeResolution = "refund"         // Full refund of booking payments
+	DisputeResolutionPartialRefund DisputeResolution = "partial_refund" // Refund of a specific amount
+	DisputeResolutionRework        DisputeResolution = "rework"         // Worker redoes the job, no refund
+	DisputeResolutionRejected      DisputeResolution = "rejected"       // Complaint not upheld
+)
+
+// DisputeReason represents the reason a dispute was raised
+type DisputeReason string
+
+const (
+	DisputeReasonWorkerNoShow       DisputeReason = "worker_no_show"
+	DisputeReasonPoorQuality        DisputeReason = "poor_quality"
+	DisputeReasonIncompleteWork     DisputeReason = "incomplete_work"
+	DisputeReasonPropertyDamage     DisputeReason = "property_damage"
+	DisputeReasonOvercharged        DisputeReason = "overcharged"
+	DisputeReasonMisconduct         DisputeReason = "misconduct"
+	DisputeReasonCustomerNoShow     DisputeReason = "customer_no_show"
+	DisputeReasonCustomerNonPayment DisputeReason = "customer_non_payment"
+	DisputeReasonOther              DisputeReason = "other"
+)
+
+// BookingDispute represents a complaint raised by a customer or worker about a booking
+type BookingDispute struct {
+	gorm.Model
+	// Basic Information
+	BookingID    uint   `json:"booking_id" gorm:"not null"`
+	RaisedBy     uint   `json:"raised_by" gorm:"not null"`      // User ID of the customer or worker
+	RaisedByRole string `json:"raised_by_role" gorm:"not null"` // "user" or "worker"
+
+	// Complaint Details
+	Reason      DisputeReason `json:"reason" gorm:"not null"`
+	Description string        `json:"description" gorm:"not null"`
+	Evidence    []string      `json:"evidence" gorm:"type:jsonb;default:'[]';serializer:json"` // Cloudinary URLs
+	Status      DisputeStatus `json:"status" gorm:"default:'open'"`
+
+	// Resolution
+	Resolution      *DisputeResolution `json:"resolution"`
+	ResolutionNotes string             `json:"resolution_notes"`
+	RefundAmount    *float64           `json:"refund_amount"`
+	ResolvedBy      *uint              `json:"resolved_by"` // Admin ID
+	ResolvedAt      *time.Time         `json:"resolved_at"`
+
+	// Relationships
+	Booking        *Booking         `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	RaisedByUser   *User            `json:"raised_by_user,omitempty" gorm:"foreignKey:RaisedBy"`
+	ResolvedByUser *User            `json:"resolved_by_user,omitempty" gorm:"foreignKey:ResolvedBy"`
+	Comments       []DisputeComment `json:"comments,omitempty" gorm:"foreignKey:DisputeID"`
+}
+
+// TableName returns the table name for BookingDispute
+func (BookingDispute) TableName() string {
+	return "booking_disputes"
+}
+
+// DisputeComment represents a comment on a dispute
+type DisputeComment struct {
+	gorm.Model
+	DisputeID  uint   `json:"dispute_id" gorm:"not null"`
+	UserID     uint   `json:"user_id" gorm:"not null"`
+	AuthorRole string `json:"author_role" gorm:"not null"` // "admin", "user" or "worker"
+	Comment    string `json:"comment" gorm:"not null"`
+	IsInternal bool   `json:"is_internal" gorm:"default:false"` // Admin-only note
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for DisputeComment
+func (DisputeComment) TableName() string {
+	return "dispute_comments"
+}
+
+// CreateDisputeRequest represents the request structure for opening a dispute
+type CreateDisputeRequest struct {
+	Reason      DisputeReason `json:"reason" form:"reason" binding:"required,oneof=worker_no_show poor_quality incomplete_work property_damage overcharged misconduct customer_no_show customer_non_payment other"`
+	Description string        `json:"description" form:"description" binding:"required,min=10"`
+}
+
+// AddDisputeCommentRequest represents the request structure for commenting on a dispute
+type AddDisputeCommentRequest struct {
+	Comment    string `json:"comment" binding:"required"`
+	IsInternal bool   `json:"is_internal"`
+}
+
+// ResolveDisputeRequest represents the request structure for resolving a dispute
+type ResolveDisputeRequest struct {
+	Resolution   DisputeResolution `json:"resolution" binding:"required,oneof=refund partial_refund rework rejected"`
+	Notes        string            `json:"notes" binding:"required"`
+	RefundAmount *float64          `json:"refund_amount"` // Required for partial_refund
+	RefundMethod string            `json:"refund_method"` // "wallet" or "online"; defaults to the original payment method
+}
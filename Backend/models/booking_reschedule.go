@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RescheduleStatus represents the status of a booking reschedule request
+type RescheduleStatus string
+
+const (
+	RescheduleStatusPending   RescheduleStatus = "pending"   // Waiting for the assigned worker to respond
+	RescheduleStatusAccepted  RescheduleStatus = "accepted"  // New time applied to the booking
+	RescheduleStatusDeclined  RescheduleStatus = "declined"  // Worker declined, booking keeps its time
+	RescheduleStatusCancelled RescheduleStatus = "cancelled" // Withdrawn by whoever asked for it
+	RescheduleStatusExpired   RescheduleStatus = "expired"   // Worker did not respond in time
+)
+
+// BookingRescheduleRequest represents a request to move a booking to another time
+type BookingRescheduleRequest struct {
+	gorm.Model
+	BookingID           uint              `json:"booking_id" gorm:"not null"`
+	RequestedBy         uint              `json:"requested_by" gorm:"not null"`
+	RequesterRole       ActivityActorType `json:"requester_role" gorm:"not null"` // user or admin
+	WorkerID            *uint             `json:"worker_id"`                      // Assigned worker who has to agree, if any
+	OldScheduledTime    time.Time         `json:"old_scheduled_time" gorm:"not null"`
+	OldScheduledEndTime time.Time         `json:"old_scheduled_end_time" gorm:"not null"`
+	NewScheduledTime    time.Time         `json:"new_scheduled_time" gorm:"not null"`
+	NewScheduledEndTime time.Time         `json:"new_scheduled_end_time" gorm:"not null"`
+	Reason              string            `json:"reason"`
+	Status              RescheduleStatus  `json:"status" gorm:"default:'pending'"`
+	RespondedAt         *time.Time        `json:"responded_at"`
+	ResponseNotes       string            `json:"response_notes"`
+
+	// Relationships
+	Booking *Booking `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// TableName returns the table name for BookingRescheduleRequest
+func (BookingRescheduleRequest) TableName() string {
+	return "booking_reschedule_requests"
+}
+
+// CreateRescheduleRequest represents the request structure for rescheduling a booking
+type CreateRescheduleRequest struct {
+	ScheduledDate string `json:"scheduled_date" binding:"required"` // YYYY-MM-DD
+	ScheduledTime string `json:"scheduled_time" binding:"required"` // HH:MM in IST
+	Reason        string `json:"reason"`
+	// Admin only: apply the new time without waiting for the assigned worker
+	Force bool `json:"force"`
+}
+
+// RespondRescheduleRequest represents the request structure for a worker's answer to a reschedule
+type RespondRescheduleRequest struct {
+	Notes string `json:"notes"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewStatus represents the moderation status of a review
+type ReviewStatus string
+
+const (
+	ReviewStatusPublished ReviewStatus = "published" // Visible to everyone and counted in worker rating
+	ReviewStatusHidden    ReviewStatus = "hidden"    // Hidden by admin, excluded from worker rating
+	ReviewStatusFlagged   ReviewStatus = "flagged"   // Flagged for admin attention, still visible
+)
+
+// BookingReview represents a customer's review of a completed booking
+type BookingReview struct {
+	gorm.Model
+	// Basic Information
+	BookingID          uint  `json:"booking_id" gorm:"not null;uniqueIndex"`
+	WorkerAssignmentID *uint `json:"worker_assignment_id"`
+	UserID             uint  `json:"user_id" gorm:"not null"`
+	WorkerID           *uint `json:"worker_id"` // Worker's user ID
+
+	// Review Details
+	Rating     int            `json:"rating" gorm:"not null"`
+	Review     string         `json:"review"`
+	Categories map[string]int `json:"categories" gorm:"type:jsonb;default:'{}';serializer:json"`
+	Status     ReviewStatus   `json:"status" gorm:"default:'published'"`
+
+	// Edit Window
+	EditableUntil time.Time  `json:"editable_until" gorm:"not null"`
+	EditedAt      *time.Time `json:"edited_at"`
+
+	// Moderation
+	ModeratedBy     *uint      `json:"moderated_by"`
+	ModeratedAt     *time.Time `json:"moderated_at"`
+	ModerationNotes string     `json:"moderation_notes"`
+
+	// Relationships
+	Booking          *Booking          `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	WorkerAssignment *WorkerAssignment `json:"worker_assignment,omitempty" gorm:"foreignKey:WorkerAssignmentID"`
+	User             *User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Worker           *User             `json:"worker,omitempty" gorm:"foreignKey:WorkerID"`
+}
+
+// TableName returns the table name for BookingReview
+func (BookingReview) TableName() string {
+	return "booking_reviews"
+}
+
+// IsEditable reports whether the review can still be edited by the customer
+func (br *BookingReview) IsEditable() bool {
+	return time.Now().Before(br.EditableUntil)
+}
+
+// ModerateReviewRequest represents the request structure for moderating a review
+type ModerateReviewRequest struct {
+	Status ReviewStatus `json:"status" binding:"required,oneof=published hidden flagged"`
+	Notes  string       `json:"notes"`
+}
+
+// PublicReview represents a review as shown on public worker profiles
+type PublicReview struct {
+	ID           uint           `json:"id"`
+	BookingID    uint           `json:"booking_id"`
+	Rating       int            `json:"rating"`
+	Review       string         `json:"review"`
+	Categories   map[string]int `json:"categories"`
+	ReviewerName string         `json:"reviewer_name"`
+	ServiceName  string         `json:"service_name"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// WorkerRatingSummary represents the aggregate rating of a worker
+type WorkerRatingSummary struct {
+	AverageRating      float64            `json:"average_rating"`
+	TotalReviews       int                `json:"total_reviews"`
+	RatingDistribution map[int]int        `json:"rating_distribution"`
+	CategoryAverages   map[string]float64 `json:"category_averages"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecurrenceFrequency represents how often a booking series repeats
+type RecurrenceFrequency string
+
+const (
+	RecurrenceWeekly   RecurrenceFrequency = "weekly"   // Every 7 days
+	RecurrenceBiweekly RecurrenceFrequency = "biweekly" // Every 14 days
+	RecurrenceMonthly  RecurrenceFrequency = "monthly"  // Same day every month
+)
+
+// BookingSeriesStatus represents the status of a booking series
+type BookingSeriesStatus string
+
+const (
+	BookingSeriesStatusActive    BookingSeriesStatus = "active"    // Occurrences are still being booked
+	BookingSeriesStatusCompleted BookingSeriesStatus = "completed" // Every occurrence has been handled
+	BookingSeriesStatusCancelled BookingSeriesStatus = "cancelled" // Customer cancelled the series
+)
+
+// OccurrenceStatus represents the status of one occurrence of a booking series
+type OccurrenceStatus string
+
+const (
+	OccurrenceStatusScheduled  OccurrenceStatus = "scheduled"  // Waiting to be booked
+	OccurrenceStatusProcessing OccurrenceStatus = "processing" // Claimed by a run that is booking it
+	OccurrenceStatusBooked     OccurrenceStatus = "booked"     // Booking created and paid
+	OccurrenceStatusSkipped    OccurrenceStatus = "skipped"    // Customer skipped this visit
+	OccurrenceStatusCancelled  OccurrenceStatus = "cancelled"  // Cancelled on its own or with the series
+	OccurrenceStatusFailed     OccurrenceStatus = "failed"     // Could not be booked (no slot, low wallet balance)
+)
+
+// BookingSeries represents a recurring booking of the same service
+type BookingSeries struct {
+	gorm.Model
+	UserID              uint                `json:"user_id" gorm:"not null"`
+	ServiceID           uint                `json:"service_id" gorm:"not null"`
+	Frequency           RecurrenceFrequency `json:"frequency" gorm:"not null"`
+	StartDate           time.Time           `json:"start_date" gorm:"type:date;not null"`
+	EndDate             *time.Time          `json:"end_date" gorm:"type:date"`
+	OccurrenceCount     *int                `json:"occurrence_count"`
+	PreferredTime       string              `json:"preferred_time" gorm:"not null"` // HH:MM in IST
+	Address             string              `json:"address" gorm:"type:jsonb;not null"`
+	Description         string              `json:"description"`
+	ContactPerson       string              `json:"contact_person"`
+	ContactPhone        string              `json:"contact_phone"`
+	SpecialInstructions string              `json:"special_instructions"`
+	PreferredWorkerID   *uint               `json:"preferred_worker_id"`
+	Status              BookingSeriesStatus `json:"status" gorm:"default:'active'"`
+	CancelledAt         *time.Time          `json:"cancelled_at"`
+	CancellationReason  string              `json:"cancellation_reason"`
+
+	// Relationships
+	User        User                      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Service     Service                   `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	Occurrences []BookingSeriesOccurrence `json:"occurrences,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// TableName returns the table name for BookingSeries
+func (BookingSeries) TableName() string {
+	return "booking_series"
+}
+
+// BookingSeriesOccurrence represents one planned visit of a booking series
+type BookingSeriesOccurrence struct {
+	gorm.Model
+	SeriesID      uint             `json:"series_id" gorm:"not null"`
+	Sequence      int              `json:"sequence" gorm:"not null"`
+	ScheduledAt   time.Time        `json:"scheduled_at" gorm:"not null"`
+	Status        OccurrenceStatus `json:"status" gorm:"default:'scheduled'"`
+	BookingID     *uint            `json:"booking_id"`
+	FailureReason string           `json:"failure_reason"`
+	ProcessedAt   *time.Time       `json:"processed_at"`
+
+	// Relationships
+	Series  *BookingSeries `json:"series,omitempty" gorm:"foreignKey:SeriesID"`
+	Booking *Booking       `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// TableName returns the table name for BookingSeriesOccurrence
+func (BookingSeriesOccurrence) TableName() string {
+	return "booking_series_occurrences"
+}
+
+// CreateBookingSeriesRequest represents the request structure for creating a recurring booking.
+// Either EndDate or OccurrenceCount must be set.
+type CreateBookingSeriesRequest struct {
+	ServiceID           uint                `json:"service_id" binding:"required"`
+	Frequency           RecurrenceFrequency `json:"frequency" binding:"required,oneof=weekly biweekly monthly"`
+	StartDate           string              `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate             string              `json:"end_date"`                      // YYYY-MM-DD, inclusive
+	OccurrenceCount     int                 `json:"occurrence_count" binding:"omitempty,min=1"`
+	PreferredTime       string              `json:"preferred_time" binding:"required"` // HH:MM in IST
+	Address             BookingAddress      `json:"address" binding:"required"`
+	Description         string              `json:"description"`
+	ContactPerson       string              `json:"contact_person"`
+	ContactPhone        string              `json:"contact_phone"`
+	SpecialInstructions string              `json:"special_instructions"`
+}
+
+// RescheduleOccurrenceRequest represents the request structure for moving one occurrence
+type RescheduleOccurrenceRequest struct {
+	ScheduledDate string `json:"scheduled_date" binding:"required"` // YYYY-MM-DD
+	ScheduledTime string `json:"scheduled_time" binding:"required"` // HH:MM in IST
+}
+
+// CancelBookingSeriesRequest represents the request structure for cancelling a whole series
+type CancelBookingSeriesRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Also cancel occurrences that are already booked (cancellation fees apply)
+	CancelBookedOccurrences bool `json:"cancel_booked_occurrences"`
+}
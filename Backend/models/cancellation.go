@@ -0,0 +1,41 @@
+package models
+
+// CancellationPaymentKind identifies which cancellation rule applies to a payment
+type CancellationPaymentKind string
+
+const (
+	CancellationPaymentKindBooking    CancellationPaymentKind = "booking"     // Fixed price or quote payment
+	CancellationPaymentKindInquiryFee CancellationPaymentKind = "inquiry_fee" // Inquiry booking fee
+	CancellationPaymentKindSegment    CancellationPaymentKind = "segment"     // Paid payment segment
+)
+
+// CancellationFeeTier charges FeePercentage when at least MinHoursBefore hours remain before the scheduled time
+type CancellationFeeTier struct {
+	MinHoursBefore float64 `json:"min_hours_before"`
+	FeePercentage  float64 `json:"fee_percentage"`
+}
+
+// CancellationRefundItem represents the refund calculated for one completed payment
+type CancellationRefundItem struct {
+	PaymentID     uint                    `json:"payment_id"`
+	Kind          CancellationPaymentKind `json:"kind"`
+	SegmentNumber *int                    `json:"segment_number,omitempty"`
+	Method        string                  `json:"method"`
+	PaidAmount    float64                 `json:"paid_amount"`
+	FeePercentage float64                 `json:"fee_percentage"`
+	FeeAmount     float64                 `json:"fee_amount"`
+	RefundAmount  float64                 `json:"refund_amount"`
+	RefundMethod  string                  `json:"refund_method"`
+	RefundStatus  string                  `json:"refund_status,omitempty"` // Set once the refund has been attempted
+	RefundError   string                  `json:"refund_error,omitempty"`
+}
+
+// CancellationSummary represents the outcome of applying the cancellation policy to a booking
+type CancellationSummary struct {
+	BookingID          uint                     `json:"booking_id"`
+	HoursBeforeService *float64                 `json:"hours_before_service"` // Nil when the booking is not scheduled yet
+	TotalPaid          float64                  `json:"total_paid"`
+	CancellationFee    float64                  `json:"cancellation_fee"`
+	RefundAmount       float64                  `json:"refund_amount"`
+	Items              []CancellationRefundItem `json:"items"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChatAttachment is a file, image or location pin shared in a chat room or conversation. Uploads are
+// stored before the message that carries them is sent; MessageID is set once it is. The stored
+// Cloudinary URLs are never returned to clients, media is fetched through the access checked endpoints.
+// Media uploaded as authenticated is fetched through signed URLs instead of the stored ones.
+type ChatAttachment struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	ThreadType ChatThreadType `json:"thread_type" gorm:"not null"`
+	ThreadID   uint           `json:"thread_id" gorm:"not null"`
+	UploaderID uint           `json:"uploader_id" gorm:"not null"`
+	MessageID  *uint          `json:"message_id"`
+	Kind       MessageType    `json:"kind" gorm:"not null"` // image, file or location
+
+	// File metadata
+	FileName  string `json:"file_name"`
+	MimeType  string `json:"mime_type"`
+	SizeBytes int64  `json:"size_bytes"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+
+	// Location pin
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	LocationName string   `json:"location_name"`
+	Address      string   `json:"address"`
+
+	// Storage, never sent to clients
+	URL          string `json:"-" gorm:"column:url"`
+	ThumbnailURL string `json:"-" gorm:"column:thumbnail_url"`
+	PublicID     string `json:"-"`
+	ResourceType string `json:"-"`
+	DeliveryType string `json:"-" gorm:"not null;default:'upload'"` // upload (public) or authenticated
+}
+
+// IsAuthenticated reports whether the media can only be fetched through signed URLs
+func (a *ChatAttachment) IsAuthenticated() bool {
+	return a.DeliveryType == "authenticated"
+}
+
+// TableName returns the table name for ChatAttachment
+func (ChatAttachment) TableName() string {
+	return "chat_attachments"
+}
+
+// Info returns the metadata of the attachment that messages carry
+func (a *ChatAttachment) Info() ChatAttachmentInfo {
+	info := ChatAttachmentInfo{
+		ID:           a.ID,
+		Kind:         a.Kind,
+		FileName:     a.FileName,
+		MimeType:     a.MimeType,
+		SizeBytes:    a.SizeBytes,
+		Width:        a.Width,
+		Height:       a.Height,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
+		LocationName: a.LocationName,
+		Address:      a.Address,
+	}
+	if a.URL != "" {
+		info.URL = fmt.Sprintf("/api/v1/chat/attachments/%d", a.ID)
+	}
+	if a.ThumbnailURL != "" {
+		info.ThumbnailURL = fmt.Sprintf("/api/v1/chat/attachments/%d/thumbnail", a.ID)
+	}
+	return info
+}
+
+// ChatAttachmentInfo is the metadata of an attachment stored on the message that carries it. URLs point
+// at the API, which checks the requester takes part in the chat before serving the media.
+type ChatAttachmentInfo struct {
+	ID           uint        `json:"id"`
+	Kind         MessageType `json:"kind"`
+	FileName     string      `json:"file_name,omitempty"`
+	MimeType     string      `json:"mime_type,omitempty"`
+	SizeBytes    int64       `json:"size_bytes,omitempty"`
+	Width        int         `json:"width,omitempty"`
+	Height       int         `json:"height,omitempty"`
+	Latitude     *float64    `json:"latitude,omitempty"`
+	Longitude    *float64    `json:"longitude,omitempty"`
+	LocationName string      `json:"location_name,omitempty"`
+	Address      string      `json:"address,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	ThumbnailURL string      `json:"thumbnail_url,omitempty"`
+}
+
+// ShareLocationRequest represents the request structure for sharing a location pin
+type ShareLocationRequest struct {
+	Latitude     *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	LocationName string   `json:"location_name" binding:"max=255"`
+	Address      string   `json:"address" binding:"max=500"`
+}
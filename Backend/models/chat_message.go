@@ -46,6 +46,7 @@ type ChatMessage struct {
 	
 	// Message attachments
 	Attachments []string `json:"attachments" gorm:"type:jsonb;default:'[]';serializer:json"`
+	AttachmentDetails []ChatAttachmentInfo `json:"attachment_details" gorm:"type:jsonb;default:'[]';serializer:json"`
 	
 	// Message status
 	Status MessageStatus `json:"status" gorm:"default:'sent'"`
@@ -70,9 +71,10 @@ func (ChatMessage) TableName() string {
 // SendMessageRequest represents the request structure for sending a message
 type SendMessageRequest struct {
 	RoomID         uint        `json:"room_id"` // Set from URL path, not required in body
-	Message        string      `json:"message" binding:"required"`
+	Message        string      `json:"message" binding:"required_without=AttachmentIDs"`
 	MessageType    MessageType `json:"message_type" binding:"required"`
 	Attachments    []string    `json:"attachments"`
+	AttachmentIDs  []uint      `json:"attachment_ids" binding:"max=10"` // Uploaded chat attachments to send with the message
 	ReplyToMessageID *uint     `json:"reply_to_message_id"`
 }
 
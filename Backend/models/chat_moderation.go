@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// ChatReportStatus represents where a reported chat message is in admin review
+type ChatReportStatus string
+
+const (
+	ChatReportStatusPending   ChatReportStatus = "pending"   // Waiting for an admin
+	ChatReportStatusDismissed ChatReportStatus = "dismissed" // Nothing wrong with the message
+	ChatReportStatusActioned  ChatReportStatus = "actioned"  // Admin acted on the message, such as muting its sender
+)
+
+// ChatReportSource represents who flagged a chat message
+type ChatReportSource string
+
+const (
+	ChatReportSourceUser ChatReportSource = "user" // A participant reported the message
+	ChatReportSourceAuto ChatReportSource = "auto" // Moderation masked contact details or blocked words in it
+)
+
+// ChatReportReason represents why a chat message was flagged
+type ChatReportReason string
+
+const (
+	ChatReportReasonAbuse              ChatReportReason = "abuse"
+	ChatReportReasonSpam               ChatReportReason = "spam"
+	ChatReportReasonContactSharing     ChatReportReason = "contact_sharing"
+	ChatReportReasonOffPlatformPayment ChatReportReason = "off_platform_payment"
+	ChatReportReasonOther              ChatReportReason = "other"
+)
+
+// ChatMessageReport is a chat message flagged for admin review, either reported by a participant or flagged
+// automatically when moderation changed it
+type ChatMessageReport struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ThreadType ChatThreadType   `json:"thread_type" gorm:"not null"`
+	ThreadID   uint             `json:"thread_id" gorm:"not null"`
+	MessageID  uint             `json:"message_id" gorm:"not null"`
+	SenderID   uint             `json:"sender_id" gorm:"not null"` // Author of the message
+	ReporterID *uint            `json:"reporter_id"`               // Nil when flagged automatically
+	Source     ChatReportSource `json:"source" gorm:"not null"`
+	Reason     ChatReportReason `json:"reason" gorm:"not null"`
+	Details    string           `json:"details"`
+
+	// Message as the sender wrote it, before masking, and what moderation found in it
+	OriginalMessage string   `json:"original_message"`
+	Detected        []string `json:"detected" gorm:"type:jsonb;default:'[]';serializer:json"`
+
+	// Review
+	Status      ChatReportStatus `json:"status" gorm:"default:'pending'"`
+	ReviewedBy  *uint            `json:"reviewed_by"` // Admin ID
+	ReviewedAt  *time.Time       `json:"reviewed_at"`
+	ReviewNotes string           `json:"review_notes"`
+
+	// Relationships
+	Sender   *User `json:"sender,omitempty" gorm:"foreignKey:SenderID"`
+	Reporter *User `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+}
+
+// TableName returns the table name for ChatMessageReport
+func (ChatMessageReport) TableName() string {
+	return "chat_message_reports"
+}
+
+// ChatMute stops a user from sending messages in one chat room or conversation, or in every chat when
+// ThreadType is nil, until it expires or an admin lifts it
+type ChatMute struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID     uint            `json:"user_id" gorm:"not null"`
+	ThreadType *ChatThreadType `json:"thread_type"`
+	ThreadID   *uint           `json:"thread_id"`
+	ReportID   *uint           `json:"report_id"` // Report the mute was applied from
+	Reason     string          `json:"reason" gorm:"not null"`
+	MutedBy    uint            `json:"muted_by" gorm:"not null"` // Admin ID
+	MutedUntil *time.Time      `json:"muted_until"`              // Nil until lifted
+	LiftedBy   *uint           `json:"lifted_by"`
+	LiftedAt   *time.Time      `json:"lifted_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for ChatMute
+func (ChatMute) TableName() string {
+	return "chat_mutes"
+}
+
+// ReportChatMessageRequest represents the request structure for reporting a chat message
+type ReportChatMessageRequest struct {
+	Reason  ChatReportReason `json:"reason" binding:"required,oneof=abuse spam contact_sharing off_platform_payment other"`
+	Details string           `json:"details" binding:"max=1000"`
+}
+
+// ReviewChatReportRequest represents the request structure for reviewing a flagged chat message
+type ReviewChatReportRequest struct {
+	Status         ChatReportStatus `json:"status" binding:"required,oneof=dismissed actioned"`
+	Notes          string           `json:"notes" binding:"max=1000"`
+	MuteSender     bool             `json:"mute_sender"`                         // Mute the message's sender, only when actioned
+	MuteHours      int              `json:"mute_hours" binding:"min=0,max=8760"` // Zero mutes until lifted
+	MuteEverywhere bool             `json:"mute_everywhere"`                     // Mute in every chat instead of the message's
+}
+
+// MuteChatUserRequest represents the request structure for muting a chat participant
+type MuteChatUserRequest struct {
+	UserID     uint           `json:"user_id" binding:"required"`
+	ThreadType ChatThreadType `json:"thread_type" binding:"omitempty,oneof=chat_room conversation"` // Empty mutes in every chat
+	ThreadID   uint           `json:"thread_id" binding:"required_with=ThreadType"`
+	Hours      int            `json:"hours" binding:"min=0,max=8760"` // Zero mutes until lifted
+	Reason     string         `json:"reason" binding:"required,max=500"`
+}
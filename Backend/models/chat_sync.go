@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// ChatThreadType identifies the kind of chat a message belongs to
+type ChatThreadType string
+
+const (
+	ChatThreadRoom         ChatThreadType = "chat_room"    // Booking chat rooms, messages in chat_messages
+	ChatThreadConversation ChatThreadType = "conversation" // Simple conversations, messages in simple_conversation_messages
+)
+
+// ReceiptStatus represents how far a message got to a participant
+type ReceiptStatus string
+
+const (
+	ReceiptStatusDelivered ReceiptStatus = "delivered"
+	ReceiptStatusRead      ReceiptStatus = "read"
+)
+
+// ChatMessageReceipt records when a participant received and read a message
+type ChatMessageReceipt struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	ThreadType  ChatThreadType `json:"thread_type" gorm:"not null"`
+	ThreadID    uint           `json:"thread_id" gorm:"not null"`
+	MessageID   uint           `json:"message_id" gorm:"not null"`
+	UserID      uint           `json:"user_id" gorm:"not null"`
+	DeliveredAt *time.Time     `json:"delivered_at"`
+	ReadAt      *time.Time     `json:"read_at"`
+}
+
+// TableName returns the table name for ChatMessageReceipt
+func (ChatMessageReceipt) TableName() string {
+	return "chat_message_receipts"
+}
+
+// ChatSyncCursor is the last message a device of a user has synced in a thread
+type ChatSyncCursor struct {
+	ID            uint           `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	UserID        uint           `json:"user_id" gorm:"not null"`
+	DeviceID      string         `json:"device_id" gorm:"not null"`
+	ThreadType    ChatThreadType `json:"thread_type" gorm:"not null"`
+	ThreadID      uint           `json:"thread_id" gorm:"not null"`
+	LastMessageID uint           `json:"last_message_id" gorm:"not null;default:0"`
+}
+
+// TableName returns the table name for ChatSyncCursor
+func (ChatSyncCursor) TableName() string {
+	return "chat_sync_cursors"
+}
+
+// ChatSyncMessage is a message of either thread type in a sync response
+type ChatSyncMessage struct {
+	ID                uint                 `json:"id"`
+	ThreadType        ChatThreadType       `json:"thread_type"`
+	ThreadID          uint                 `json:"thread_id"`
+	SenderID          uint                 `json:"sender_id"`
+	Message           string               `json:"message"`
+	MessageType       MessageType          `json:"message_type"`
+	Attachments       []string             `json:"attachments,omitempty"`
+	AttachmentDetails []ChatAttachmentInfo `json:"attachment_details,omitempty"`
+	ReplyToMessageID  *uint                `json:"reply_to_message_id,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	Receipts          []ChatMessageReceipt `json:"receipts,omitempty"` // Receipts of the other participants, on the requester's own messages
+}
+
+// ChatSyncRequest represents the request structure for syncing the messages of a thread
+type ChatSyncRequest struct {
+	SinceMessageID uint   `form:"since_message_id"` // Messages after this one; the device's cursor when zero
+	DeviceID       string `form:"device_id"`        // Device whose cursor is read and advanced
+	Limit          int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// ChatSyncResponse represents the messages of a thread after a cursor
+type ChatSyncResponse struct {
+	ThreadType     ChatThreadType    `json:"thread_type"`
+	ThreadID       uint              `json:"thread_id"`
+	SinceMessageID uint              `json:"since_message_id"`
+	Cursor         uint              `json:"cursor"` // Last message returned; pass as since_message_id for the next page
+	HasMore        bool              `json:"has_more"`
+	Messages       []ChatSyncMessage `json:"messages"`
+}
+
+// UpdateChatReceiptsRequest represents the request structure for acknowledging messages up to one of them
+type UpdateChatReceiptsRequest struct {
+	Status        ReceiptStatus `json:"status" binding:"required,oneof=delivered read"`
+	UpToMessageID uint          `json:"up_to_message_id" binding:"required"`
+}
+
+// ChatTypingRequest represents the request structure for a typing indicator
+type ChatTypingRequest struct {
+	Typing bool `json:"typing"`
+}
@@ -120,6 +120,17 @@ type ChatbotSessionResponse struct {
 	Messages      []ChatbotMessage       `json:"messages"`
 }
 
+// ChatbotLead is a logged-in user who searched for property through the chatbot
+type ChatbotLead struct {
+	SessionID     string                 `json:"session_id"`
+	UserID        uint                   `json:"user_id"`
+	UserName      string                 `json:"user_name"`
+	UserPhone     string                 `json:"user_phone"`
+	Location      string                 `json:"location"`
+	Context       map[string]interface{} `json:"context"`
+	LastMessageAt time.Time              `json:"last_message_at"`
+}
+
 // ChatbotMessageResponse represents the response for a chatbot message
 type ChatbotMessageResponse struct {
 	ID            uint                   `json:"id"`
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CouponRewardType represents how a coupon rewards the customer
+type CouponRewardType string
+
+const (
+	CouponRewardDiscount CouponRewardType = "discount" // Reduces the amount to pay
+	CouponRewardCashback CouponRewardType = "cashback" // Credited to the wallet once the order is fulfilled
+)
+
+// CouponDiscountType represents how a coupon's value is calculated
+type CouponDiscountType string
+
+const (
+	CouponDiscountFlat       CouponDiscountType = "flat"       // Fixed amount in INR
+	CouponDiscountPercentage CouponDiscountType = "percentage" // Percentage of the order amount
+)
+
+// CouponScope represents what a coupon can be applied to
+type CouponScope string
+
+const (
+	CouponScopeAll          CouponScope = "all"          // Bookings, quotes and subscriptions
+	CouponScopeBooking      CouponScope = "booking"      // Fixed price service bookings
+	CouponScopeQuote        CouponScope = "quote"        // Quote payments of inquiry bookings
+	CouponScopeSubscription CouponScope = "subscription" // Subscription purchases
+)
+
+// Coupon is a promo code customers can apply to bookings, quote payments and subscriptions
+type Coupon struct {
+	gorm.Model
+	Code             string             `json:"code" gorm:"uniqueIndex;not null"` // Stored upper case
+	Description      string             `json:"description"`
+	RewardType       CouponRewardType   `json:"reward_type" gorm:"not null;default:'discount'"`
+	DiscountType     CouponDiscountType `json:"discount_type" gorm:"not null"`
+	Value            float64            `json:"value" gorm:"not null"` // Flat amount in INR or percentage
+	MaxDiscount      *float64           `json:"max_discount"`          // Cap for percentage coupons
+	MinOrderAmount   float64            `json:"min_order_amount"`      // Minimum order amount in INR
+	ValidFrom        *time.Time         `json:"valid_from"`
+	ValidUntil       *time.Time         `json:"valid_until"`
+	UsageLimit       int                `json:"usage_limit"`    // Total redemptions allowed, 0 for unlimited
+	PerUserLimit     int                `json:"per_user_limit"` // Redemptions allowed per user, 0 for unlimited
+	Scope            CouponScope        `json:"scope" gorm:"not null;default:'all'"`
+	CategoryIDs      []uint             `json:"category_ids" gorm:"type:jsonb;default:'[]';serializer:json"` // Empty for any category
+	ServiceIDs       []uint             `json:"service_ids" gorm:"type:jsonb;default:'[]';serializer:json"`  // Empty for any service
+	FirstBookingOnly bool               `json:"first_booking_only"`
+	IsActive         bool               `json:"is_active" gorm:"default:true"`
+	CreatedBy        *uint              `json:"created_by"`
+
+	// Computed
+	RedemptionCount int64 `json:"redemption_count" gorm:"-"`
+}
+
+// TableName returns the table name for Coupon
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// CouponRedemptionStatus represents the status of a coupon redemption
+type CouponRedemptionStatus string
+
+const (
+	CouponRedemptionReserved CouponRedemptionStatus = "reserved" // Order created, waiting for payment
+	CouponRedemptionRedeemed CouponRedemptionStatus = "redeemed" // Order paid
+	CouponRedemptionReleased CouponRedemptionStatus = "released" // Payment failed or was abandoned
+)
+
+// CashbackStatus represents whether a redemption's cashback has been credited
+type CashbackStatus string
+
+const (
+	CashbackStatusNone     CashbackStatus = "none"     // Discount coupon, no cashback
+	CashbackStatusPending  CashbackStatus = "pending"  // Credited when the order is fulfilled
+	CashbackStatusCredited CashbackStatus = "credited" // Credited to the wallet
+)
+
+// CouponRedemption is one use of a coupon on an order. Reserved redemptions count towards
+// usage limits until ReservedUntil.
+type CouponRedemption struct {
+	gorm.Model
+	CouponID          uint                   `json:"coupon_id" gorm:"not null"`
+	UserID            uint                   `json:"user_id" gorm:"not null"`
+	Scope             CouponScope            `json:"scope" gorm:"not null"` // What the coupon was applied to
+	BookingID         *uint                  `json:"booking_id"`
+	PaymentID         *uint                  `json:"payment_id"`
+	OrderAmount       float64                `json:"order_amount"`
+	DiscountAmount    float64                `json:"discount_amount"`
+	CashbackAmount    float64                `json:"cashback_amount"`
+	Status            CouponRedemptionStatus `json:"status" gorm:"default:'reserved'"`
+	ReservedUntil     *time.Time             `json:"reserved_until"`
+	RedeemedAt        *time.Time             `json:"redeemed_at"`
+	CashbackStatus    CashbackStatus         `json:"cashback_status" gorm:"default:'none'"`
+	CashbackPaymentID *uint                  `json:"cashback_payment_id"`
+
+	// Relationships
+	Coupon *Coupon `json:"coupon,omitempty" gorm:"foreignKey:CouponID"`
+	User   *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for CouponRedemption
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// CouponTarget describes the order a coupon is applied to
+type CouponTarget struct {
+	Scope      CouponScope
+	Amount     float64
+	ServiceID  uint
+	CategoryID uint
+}
+
+// CouponApplication is the outcome of applying a coupon to an order
+type CouponApplication struct {
+	Coupon         *Coupon     `json:"coupon"`
+	Scope          CouponScope `json:"scope"`
+	OrderAmount    float64     `json:"order_amount"`
+	DiscountAmount float64     `json:"discount_amount"`
+	CashbackAmount float64     `json:"cashback_amount"`
+	PayableAmount  float64     `json:"payable_amount"`
+}
+
+// CouponRequest represents the request for creating or updating a coupon
+type CouponRequest struct {
+	Code             string             `json:"code" binding:"required,min=3,max=30"`
+	Description      string             `json:"description"`
+	RewardType       CouponRewardType   `json:"reward_type" binding:"omitempty,oneof=discount cashback"`
+	DiscountType     CouponDiscountType `json:"discount_type" binding:"required,oneof=flat percentage"`
+	Value            float64            `json:"value" binding:"required,gt=0"`
+	MaxDiscount      *float64           `json:"max_discount"`
+	MinOrderAmount   float64            `json:"min_order_amount" binding:"min=0"`
+	ValidFrom        *time.Time         `json:"valid_from"`
+	ValidUntil       *time.Time         `json:"valid_until"`
+	UsageLimit       int                `json:"usage_limit" binding:"min=0"`
+	PerUserLimit     *int               `json:"per_user_limit"` // Defaults to 1
+	Scope            CouponScope        `json:"scope" binding:"omitempty,oneof=all booking quote subscription"`
+	CategoryIDs      []uint             `json:"category_ids"`
+	ServiceIDs       []uint             `json:"service_ids"`
+	FirstBookingOnly bool               `json:"first_booking_only"`
+	IsActive         *bool              `json:"is_active"`
+}
+
+// ValidateCouponRequest represents the request for previewing a coupon on an order
+type ValidateCouponRequest struct {
+	Code         string      `json:"code" binding:"required"`
+	Scope        CouponScope `json:"scope" binding:"required,oneof=booking quote subscription"`
+	ServiceID    uint        `json:"service_id"`    // For bookings
+	BookingID    uint        `json:"booking_id"`    // For quote payments
+	PlanID       uint        `json:"plan_id"`       // For subscriptions
+	DurationType string      `json:"duration_type"` // For subscriptions
+}
+
+// CouponFilters represents filters for coupon queries
+type CouponFilters struct {
+	Search   string `json:"search"`
+	IsActive *bool  `json:"is_active"`
+	Page     int    `json:"page"`
+	Limit    int    `json:"limit"`
+}
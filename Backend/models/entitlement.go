@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EntitlementKey identifies a quota or feature a subscription plan grants
+type EntitlementKey string
+
+const (
+	EntitlementMaxActiveListings     EntitlementKey = "max_active_listings"     // Property listings live at the same time
+	EntitlementFeaturedListingSlots  EntitlementKey = "featured_listing_slots"  // Listings featured at the same time
+	EntitlementPriorityScoreBoost    EntitlementKey = "priority_score_boost"    // Added to the priority score of new listings
+	EntitlementWorkerInquiryContacts EntitlementKey = "worker_inquiry_contacts" // Worker inquiries per calendar month
+	EntitlementChatbotLeadAccess     EntitlementKey = "chatbot_lead_access"     // Leads from chatbot property searches
+)
+
+// EntitlementKeys lists every entitlement in display order
+var EntitlementKeys = []EntitlementKey{
+	EntitlementMaxActiveListings,
+	EntitlementFeaturedListingSlots,
+	EntitlementPriorityScoreBoost,
+	EntitlementWorkerInquiryContacts,
+	EntitlementChatbotLeadAccess,
+}
+
+// UnlimitedQuota is the quota of an entitlement without a limit
+const UnlimitedQuota = -1
+
+// PlanEntitlements are the typed entitlements of a subscription plan
+type PlanEntitlements struct {
+	MaxActiveListings             int  `json:"max_active_listings"`               // UnlimitedQuota for no limit
+	FeaturedListingSlots          int  `json:"featured_listing_slots"`            // UnlimitedQuota for no limit
+	PriorityScoreBoost            int  `json:"priority_score_boost"`              // Added to the listing's base priority score
+	WorkerInquiryContactsPerMonth int  `json:"worker_inquiry_contacts_per_month"` // UnlimitedQuota for no limit
+	ChatbotLeadAccess             bool `json:"chatbot_lead_access"`
+}
+
+// DefaultPlanEntitlements are used for plans created before entitlements were configured, so their
+// subscribers keep what they had: unlimited listings and inquiries and chatbot leads
+func DefaultPlanEntitlements() *PlanEntitlements {
+	return &PlanEntitlements{
+		MaxActiveListings:             UnlimitedQuota,
+		FeaturedListingSlots:          0,
+		PriorityScoreBoost:            0,
+		WorkerInquiryContactsPerMonth: UnlimitedQuota,
+		ChatbotLeadAccess:             true,
+	}
+}
+
+// Quota returns the limit an entitlement grants. Feature access is 1 when granted and 0 when not.
+func (e *PlanEntitlements) Quota(key EntitlementKey) int {
+	switch key {
+	case EntitlementMaxActiveListings:
+		return e.MaxActiveListings
+	case EntitlementFeaturedListingSlots:
+		return e.FeaturedListingSlots
+	case EntitlementPriorityScoreBoost:
+		return e.PriorityScoreBoost
+	case EntitlementWorkerInquiryContacts:
+		return e.WorkerInquiryContactsPerMonth
+	case EntitlementChatbotLeadAccess:
+		if e.ChatbotLeadAccess {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Validate checks that every quota is UnlimitedQuota or not negative
+func (e *PlanEntitlements) Validate() error {
+	for _, quota := range []int{e.MaxActiveListings, e.FeaturedListingSlots, e.WorkerInquiryContactsPerMonth} {
+		if quota < UnlimitedQuota {
+			return errors.New("entitlement quotas must be -1 (unlimited) or at least 0")
+		}
+	}
+	if e.PriorityScoreBoost < 0 {
+		return errors.New("priority score boost cannot be negative")
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (e PlanEntitlements) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+// Scan implements the sql.Scanner interface
+func (e *PlanEntitlements) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into PlanEntitlements")
+	}
+
+	return json.Unmarshal(bytes, e)
+}
+
+// EntitlementUsage meters how much of an entitlement a user consumed in a calendar month
+type EntitlementUsage struct {
+	gorm.Model
+	UserID         uint           `json:"user_id" gorm:"not null"`
+	EntitlementKey EntitlementKey `json:"entitlement_key" gorm:"not null"`
+	PeriodStart    time.Time      `json:"period_start" gorm:"not null"`
+	Used           int            `json:"used" gorm:"not null;default:0"`
+}
+
+// TableName returns the table name for EntitlementUsage
+func (EntitlementUsage) TableName() string {
+	return "entitlement_usages"
+}
+
+// EntitlementStatus is a user's allowance and usage of one entitlement
+type EntitlementStatus struct {
+	Key         EntitlementKey `json:"key"`
+	Limit       int            `json:"limit"`     // UnlimitedQuota for no limit
+	Used        int            `json:"used"`      // Live listings for listing quotas, this month's use for monthly ones
+	Remaining   int            `json:"remaining"` // UnlimitedQuota for no limit
+	Allowed     bool           `json:"allowed"`   // Whether one more can be used now
+	PeriodStart *time.Time     `json:"period_start,omitempty"`
+	PlanID      *uint          `json:"plan_id"` // Nil on the free tier
+	PlanName    string         `json:"plan_name"`
+}
@@ -42,7 +42,35 @@ const (
 	InAppNotificationTypeQuoteAccepted      InAppNotificationType = "quote_accepted"
 	InAppNotificationTypeQuoteRejected      InAppNotificationType = "quote_rejected"
 	InAppNotificationTypeQuoteExpired       InAppNotificationType = "quote_expired"
-	
+
+	// Disputes
+	InAppNotificationTypeDisputeOpened      InAppNotificationType = "dispute_opened"
+	InAppNotificationTypeDisputeUpdated     InAppNotificationType = "dispute_updated"
+	InAppNotificationTypeDisputeResolved    InAppNotificationType = "dispute_resolved"
+
+	// Recurring bookings
+	InAppNotificationTypeRecurringBookingCreated InAppNotificationType = "recurring_booking_created"
+	InAppNotificationTypeRecurringBookingFailed  InAppNotificationType = "recurring_booking_failed"
+
+	// Rescheduling
+	InAppNotificationTypeRescheduleRequested InAppNotificationType = "reschedule_requested"
+	InAppNotificationTypeBookingRescheduled  InAppNotificationType = "booking_rescheduled"
+	InAppNotificationTypeRescheduleDeclined  InAppNotificationType = "reschedule_declined"
+
+	// Payment segment collection
+	InAppNotificationTypeSegmentPaymentReminder  InAppNotificationType = "segment_payment_reminder"
+	InAppNotificationTypeSegmentPaymentOverdue   InAppNotificationType = "segment_payment_overdue"
+	InAppNotificationTypeSegmentPaymentEscalated InAppNotificationType = "segment_payment_escalated"
+	InAppNotificationTypeSegmentAutoDebit        InAppNotificationType = "segment_auto_debit"
+	InAppNotificationTypeAssignmentPaused        InAppNotificationType = "assignment_paused"
+	InAppNotificationTypeAssignmentResumed       InAppNotificationType = "assignment_resumed"
+
+	// Subscription renewal
+	InAppNotificationTypeSubscriptionRenewed       InAppNotificationType = "subscription_renewed"
+	InAppNotificationTypeSubscriptionRenewalFailed InAppNotificationType = "subscription_renewal_failed"
+	InAppNotificationTypeSubscriptionGracePeriod   InAppNotificationType = "subscription_grace_period"
+	InAppNotificationTypeSubscriptionPlanChanged   InAppNotificationType = "subscription_plan_changed"
+
 	// Payment & Subscription for Users
 	InAppNotificationTypePaymentConfirmation InAppNotificationType = "payment_confirmation"
 	InAppNotificationTypeSubscriptionExpiryWarning InAppNotificationType = "subscription_expiry_warning"
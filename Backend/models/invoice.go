@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InvoiceDocumentType represents the kind of billing document issued for a payment
+type InvoiceDocumentType string
+
+const (
+	InvoiceDocumentTaxInvoice InvoiceDocumentType = "tax_invoice" // GST tax invoice for a service or subscription
+	InvoiceDocumentCreditNote InvoiceDocumentType = "credit_note" // Credit note for a refund against a tax invoice
+	InvoiceDocumentReceipt    InvoiceDocumentType = "receipt"     // Receipt for a wallet recharge (advance, no GST)
+)
+
+// Invoice is a billing document issued for one completed payment. Seller, customer and tax
+// details are copied at issue time so the document never changes afterwards.
+type Invoice struct {
+	gorm.Model
+	InvoiceNumber     string              `json:"invoice_number" gorm:"uniqueIndex;not null"`
+	DocumentType      InvoiceDocumentType `json:"document_type" gorm:"not null"`
+	FinancialYear     string              `json:"financial_year" gorm:"not null"` // e.g. "2026-27"
+	SequenceNumber    int                 `json:"sequence_number"`
+	IssuedAt          time.Time           `json:"issued_at"`
+	PaymentID         uint                `json:"payment_id" gorm:"not null;uniqueIndex"`
+	PaymentReference  string              `json:"payment_reference"`
+	PaymentMethod     string              `json:"payment_method"`
+	UserID            uint                `json:"user_id" gorm:"not null"`
+	BookingID         *uint               `json:"booking_id"`
+	OriginalInvoiceID *uint               `json:"original_invoice_id"` // Invoice a credit note is issued against
+	Description       string              `json:"description"`
+	SacCode           string              `json:"sac_code"`
+
+	// Seller
+	SellerName    string `json:"seller_name"`
+	SellerGSTIN   string `json:"seller_gstin" gorm:"column:seller_gstin"`
+	SellerAddress string `json:"seller_address"`
+	SellerState   string `json:"seller_state"`
+
+	// Customer
+	CustomerName    string `json:"customer_name"`
+	CustomerPhone   string `json:"customer_phone"`
+	CustomerEmail   string `json:"customer_email"`
+	CustomerGSTIN   string `json:"customer_gstin" gorm:"column:customer_gstin"`
+	CustomerAddress string `json:"customer_address"`
+	CustomerState   string `json:"customer_state"`
+	PlaceOfSupply   string `json:"place_of_supply"`
+
+	// Tax breakdown (amounts are GST inclusive)
+	IsInterState  bool    `json:"is_inter_state"`
+	GSTRate       float64 `json:"gst_rate" gorm:"column:gst_rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	CGSTAmount    float64 `json:"cgst_amount" gorm:"column:cgst_amount"`
+	SGSTAmount    float64 `json:"sgst_amount" gorm:"column:sgst_amount"`
+	IGSTAmount    float64 `json:"igst_amount" gorm:"column:igst_amount"`
+	TotalAmount   float64 `json:"total_amount"`
+
+	// Relationships
+	OriginalInvoice *Invoice `json:"original_invoice,omitempty" gorm:"foreignKey:OriginalInvoiceID"`
+}
+
+// TableName returns the table name for Invoice
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceSequence holds the last number used for a document type in a financial year
+type InvoiceSequence struct {
+	FinancialYear string              `json:"financial_year" gorm:"primaryKey"`
+	DocumentType  InvoiceDocumentType `json:"document_type" gorm:"primaryKey"`
+	LastNumber    int                 `json:"last_number"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// TableName returns the table name for InvoiceSequence
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
+
+// CategoryTaxCode is the SAC code and GST rate used on invoices for a service category
+type CategoryTaxCode struct {
+	gorm.Model
+	CategoryID uint      `json:"category_id" gorm:"not null;uniqueIndex"`
+	SacCode    string    `json:"sac_code" gorm:"not null"`
+	GSTRate    float64   `json:"gst_rate" gorm:"column:gst_rate"`
+	Category   *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+// TableName returns the table name for CategoryTaxCode
+func (CategoryTaxCode) TableName() string {
+	return "category_tax_codes"
+}
+
+// BillingDetails holds the business name and GSTIN a customer wants on their tax invoices
+type BillingDetails struct {
+	gorm.Model
+	UserID         uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	BusinessName   string `json:"business_name"`
+	GSTIN          string `json:"gstin" gorm:"column:gstin"`
+	BillingAddress string `json:"billing_address"`
+	State          string `json:"state"`
+}
+
+// TableName returns the table name for BillingDetails
+func (BillingDetails) TableName() string {
+	return "billing_details"
+}
+
+// UpdateBillingDetailsRequest represents the request for updating billing details
+type UpdateBillingDetailsRequest struct {
+	BusinessName   string `json:"business_name"`
+	GSTIN          string `json:"gstin"`
+	BillingAddress string `json:"billing_address"`
+	State          string `json:"state"`
+}
+
+// UpdateCategoryTaxCodeRequest represents the request for setting a category's SAC code and GST rate
+type UpdateCategoryTaxCodeRequest struct {
+	SacCode string  `json:"sac_code" binding:"required"`
+	GSTRate float64 `json:"gst_rate" binding:"min=0,max=28"`
+}
+
+// InvoiceFilters represents filters for invoice queries
+type InvoiceFilters struct {
+	UserID       uint       `json:"user_id"`
+	DocumentType string     `json:"document_type"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+	Page         int        `json:"page"`
+	Limit        int        `json:"limit"`
+}
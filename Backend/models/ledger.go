@@ -57,6 +57,12 @@ type LedgerEntry struct {
 	// Payment Source (for pay entries)
 	PaymentSource *PaymentSource `json:"payment_source"` // "cash" or "bank"
 	
+	// Account and Links
+	AccountID      *uint `json:"account_id"`       // Account posted against when paid or received
+	PaymentID      *uint `json:"payment_id"`       // Platform payment the entry is for
+	WorkerPayoutID *uint `json:"worker_payout_id"` // Worker payout the entry is for
+	VendorID       *uint `json:"vendor_id"`        // Vendor paid or paying
+	
 	// Status
 	Status LedgerStatus `json:"status" gorm:"default:'pending'"`
 	
@@ -66,8 +72,11 @@ type LedgerEntry struct {
 	UpdatedBy *uint  `json:"updated_by"`
 	
 	// Relationships
-	CreatedByUser User  `json:"created_by_user" gorm:"foreignKey:CreatedBy"`
-	UpdatedByUser *User `json:"updated_by_user,omitempty" gorm:"foreignKey:UpdatedBy"`
+	CreatedByUser User               `json:"created_by_user" gorm:"foreignKey:CreatedBy"`
+	UpdatedByUser *User              `json:"updated_by_user,omitempty" gorm:"foreignKey:UpdatedBy"`
+	Account       *LedgerAccount     `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+	Vendor        *Vendor            `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	Attachments   []LedgerAttachment `json:"attachments,omitempty" gorm:"foreignKey:LedgerEntryID"`
 }
 
 // TableName returns the table name for LedgerEntry
@@ -172,6 +181,10 @@ type CreateLedgerEntryRequest struct {
 	AmountReceived   *float64        `json:"amount_received"`
 	PaymentSource    *PaymentSource  `json:"payment_source"`
 	Notes            string          `json:"notes"`
+	AccountCode      string          `json:"account_code"`
+	PaymentID        *uint           `json:"payment_id"`
+	WorkerPayoutID   *uint           `json:"worker_payout_id"`
+	VendorID         *uint           `json:"vendor_id"`
 }
 
 type UpdateLedgerEntryRequest struct {
@@ -183,6 +196,10 @@ type UpdateLedgerEntryRequest struct {
 	AmountReceived   *float64        `json:"amount_received"`
 	PaymentSource    *PaymentSource  `json:"payment_source"`
 	Notes            *string         `json:"notes"`
+	AccountCode      *string         `json:"account_code"`
+	PaymentID        *uint           `json:"payment_id"`
+	WorkerPayoutID   *uint           `json:"worker_payout_id"`
+	VendorID         *uint           `json:"vendor_id"`
 }
 
 type ProcessPaymentRequest struct {
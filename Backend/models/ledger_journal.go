@@ -0,0 +1,260 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LedgerAccountType represents the type of a ledger account
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeAsset     LedgerAccountType = "asset"
+	LedgerAccountTypeLiability LedgerAccountType = "liability"
+	LedgerAccountTypeEquity    LedgerAccountType = "equity"
+	LedgerAccountTypeIncome    LedgerAccountType = "income"
+	LedgerAccountTypeExpense   LedgerAccountType = "expense"
+)
+
+// Codes of the system accounts the platform posts to
+const (
+	LedgerAccountCash                = "1000"
+	LedgerAccountBank                = "1010"
+	LedgerAccountRazorpayClearing    = "1100"
+	LedgerAccountCashfreeClearing    = "1110"
+	LedgerAccountCustomerWallets     = "2000"
+	LedgerAccountWorkerPayables      = "2100"
+	LedgerAccountVendorPayables      = "2200"
+	LedgerAccountServiceRevenue      = "4000"
+	LedgerAccountSubscriptionRevenue = "4100"
+	LedgerAccountOtherIncome         = "4800"
+	LedgerAccountSalesRefunds        = "4900"
+	LedgerAccountWorkerPayouts       = "5000"
+	LedgerAccountPromotions          = "5100"
+	LedgerAccountWalletAdjustments   = "5200"
+	LedgerAccountOtherExpenses       = "5900"
+)
+
+// LedgerAccount is an account in the chart of accounts
+type LedgerAccount struct {
+	gorm.Model
+	Code        string            `json:"code" gorm:"not null"`
+	Name        string            `json:"name" gorm:"not null"`
+	AccountType LedgerAccountType `json:"account_type" gorm:"not null"`
+	Description string            `json:"description"`
+	IsSystem    bool              `json:"is_system" gorm:"default:false"` // Posted to automatically; cannot be deactivated
+	IsActive    bool              `json:"is_active" gorm:"default:true"`
+}
+
+// TableName returns the table name for LedgerAccount
+func (LedgerAccount) TableName() string {
+	return "ledger_accounts"
+}
+
+// IsDebitNormal reports whether the account's balance grows with debits
+func (a *LedgerAccount) IsDebitNormal() bool {
+	return a.AccountType == LedgerAccountTypeAsset || a.AccountType == LedgerAccountTypeExpense
+}
+
+// LedgerJournalSource represents what a ledger journal was posted for
+type LedgerJournalSource string
+
+const (
+	LedgerJournalSourceManual        LedgerJournalSource = "manual"         // Journal posted by an admin
+	LedgerJournalSourceLedgerEntry   LedgerJournalSource = "ledger_entry"   // Payment or receipt against a ledger entry
+	LedgerJournalSourcePayment       LedgerJournalSource = "payment"        // Platform payment, refund or wallet credit
+	LedgerJournalSourceWorkerEarning LedgerJournalSource = "worker_earning" // Worker earning accrued for a booking
+	LedgerJournalSourceWorkerPayout  LedgerJournalSource = "worker_payout"  // Worker payout paid from the bank
+	LedgerJournalSourceReversal      LedgerJournalSource = "reversal"       // Reversal of another journal
+)
+
+// LedgerJournal is one balanced double-entry transaction. Journals are immutable: corrections are
+// posted as reversing journals, and none can be dated in a closed accounting period.
+type LedgerJournal struct {
+	ID             uint                `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Reference      string              `json:"reference" gorm:"not null"`
+	EntryDate      time.Time           `json:"entry_date" gorm:"not null"`
+	Description    string              `json:"description"`
+	SourceType     LedgerJournalSource `json:"source_type" gorm:"not null"`
+	SourceID       *uint               `json:"source_id"`
+	PaymentID      *uint               `json:"payment_id"`
+	WorkerPayoutID *uint               `json:"worker_payout_id"`
+	VendorID       *uint               `json:"vendor_id"`
+	LedgerEntryID  *uint               `json:"ledger_entry_id"`
+	ReversalOfID   *uint               `json:"reversal_of_id"`
+	TotalAmount    float64             `json:"total_amount"` // Sum of the debits, equal to the sum of the credits
+	CreatedBy      *uint               `json:"created_by"`
+
+	// Relationships
+	Postings    []LedgerPosting    `json:"postings,omitempty" gorm:"foreignKey:JournalID"`
+	Attachments []LedgerAttachment `json:"attachments,omitempty" gorm:"foreignKey:JournalID"`
+	Vendor      *Vendor            `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+}
+
+// TableName returns the table name for LedgerJournal
+func (LedgerJournal) TableName() string {
+	return "ledger_journals"
+}
+
+// LedgerPosting is a debit or credit line of a journal
+type LedgerPosting struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	JournalID   uint      `json:"journal_id" gorm:"not null"`
+	AccountID   uint      `json:"account_id" gorm:"not null"`
+	Debit       float64   `json:"debit" gorm:"default:0"`
+	Credit      float64   `json:"credit" gorm:"default:0"`
+	Description string    `json:"description"`
+
+	// Relationships
+	Account *LedgerAccount `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+}
+
+// TableName returns the table name for LedgerPosting
+func (LedgerPosting) TableName() string {
+	return "ledger_postings"
+}
+
+// LedgerAttachment is a receipt attached to a ledger entry or a journal
+type LedgerAttachment struct {
+	gorm.Model
+	LedgerEntryID *uint  `json:"ledger_entry_id"`
+	JournalID     *uint  `json:"journal_id"`
+	FileURL       string `json:"file_url" gorm:"not null"`
+	FileName      string `json:"file_name"`
+	ContentType   string `json:"content_type"`
+	UploadedBy    uint   `json:"uploaded_by" gorm:"not null"`
+}
+
+// TableName returns the table name for LedgerAttachment
+func (LedgerAttachment) TableName() string {
+	return "ledger_attachments"
+}
+
+// AccountingPeriodStatus represents the status of an accounting period
+type AccountingPeriodStatus string
+
+const (
+	AccountingPeriodStatusOpen   AccountingPeriodStatus = "open"
+	AccountingPeriodStatusClosed AccountingPeriodStatus = "closed" // Journals and ledger entries in it are immutable
+)
+
+// AccountingPeriod is a calendar month (IST) of the ledger. Months without a row are open.
+type AccountingPeriod struct {
+	gorm.Model
+	PeriodStart time.Time              `json:"period_start" gorm:"not null"`
+	PeriodEnd   time.Time              `json:"period_end" gorm:"not null"` // Start of the next month
+	Status      AccountingPeriodStatus `json:"status" gorm:"default:'open'"`
+	ClosedAt    *time.Time             `json:"closed_at"`
+	ClosedBy    *uint                  `json:"closed_by"`
+	Notes       string                 `json:"notes"`
+}
+
+// TableName returns the table name for AccountingPeriod
+func (AccountingPeriod) TableName() string {
+	return "accounting_periods"
+}
+
+// LedgerPostingLine is a line of a journal to post, by account code
+type LedgerPostingLine struct {
+	AccountCode string  `json:"account_code" binding:"required"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+	Description string  `json:"description"`
+}
+
+// CreateLedgerJournalRequest represents the request for posting a manual journal
+type CreateLedgerJournalRequest struct {
+	EntryDate   string              `json:"entry_date"` // YYYY-MM-DD (IST), today when empty
+	Description string              `json:"description" binding:"required"`
+	VendorID    *uint               `json:"vendor_id"`
+	Lines       []LedgerPostingLine `json:"lines" binding:"required,min=2,dive"`
+}
+
+// CreateLedgerAccountRequest represents the request for adding an account to the chart of accounts
+type CreateLedgerAccountRequest struct {
+	Code        string            `json:"code" binding:"required"`
+	Name        string            `json:"name" binding:"required"`
+	AccountType LedgerAccountType `json:"account_type" binding:"required,oneof=asset liability equity income expense"`
+	Description string            `json:"description"`
+}
+
+// UpdateLedgerAccountRequest represents the request for updating an account
+type UpdateLedgerAccountRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// CloseAccountingPeriodRequest represents the request for closing a month
+type CloseAccountingPeriodRequest struct {
+	Month string `json:"month" binding:"required"` // YYYY-MM
+	Notes string `json:"notes"`
+}
+
+// LedgerJournalFilters represents filters for ledger journal queries
+type LedgerJournalFilters struct {
+	SourceType  string     `json:"source_type"`
+	AccountCode string     `json:"account_code"`
+	VendorID    uint       `json:"vendor_id"`
+	From        *time.Time `json:"from"`
+	To          *time.Time `json:"to"`
+	Offset      int        `json:"offset"`
+	Limit       int        `json:"limit"`
+}
+
+// LedgerAccountTotal is the sum of debits and credits posted to an account
+type LedgerAccountTotal struct {
+	AccountID   uint              `json:"account_id"`
+	Code        string            `json:"code"`
+	Name        string            `json:"name"`
+	AccountType LedgerAccountType `json:"account_type"`
+	Debit       float64           `json:"debit"`
+	Credit      float64           `json:"credit"`
+}
+
+// TrialBalanceRow is an account's debit or credit balance in the trial balance
+type TrialBalanceRow struct {
+	Code        string            `json:"code"`
+	Name        string            `json:"name"`
+	AccountType LedgerAccountType `json:"account_type"`
+	Debit       float64           `json:"debit"`
+	Credit      float64           `json:"credit"`
+}
+
+// TrialBalance lists the balance of every account with postings up to a date
+type TrialBalance struct {
+	AsOf        time.Time         `json:"as_of"`
+	Rows        []TrialBalanceRow `json:"rows"`
+	TotalDebit  float64           `json:"total_debit"`
+	TotalCredit float64           `json:"total_credit"`
+	IsBalanced  bool              `json:"is_balanced"`
+}
+
+// ProfitAndLossRow is an income or expense account's net amount for a period
+type ProfitAndLossRow struct {
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// ProfitAndLoss is the income and expenses of a period
+type ProfitAndLoss struct {
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"` // Exclusive
+	Income        []ProfitAndLossRow `json:"income"`
+	Expenses      []ProfitAndLossRow `json:"expenses"`
+	TotalIncome   float64            `json:"total_income"`
+	TotalExpenses float64            `json:"total_expenses"`
+	NetProfit     float64            `json:"net_profit"`
+}
+
+// LedgerPostingResult summarises a run of the platform event posting job
+type LedgerPostingResult struct {
+	Payments int `json:"payments"`
+	Earnings int `json:"earnings"`
+	Payouts  int `json:"payouts"`
+	Skipped  int `json:"skipped"`
+}
@@ -22,6 +22,10 @@ const (
 	PaymentTypeManual      PaymentType = "manual"
 )
 
+// PaymentMethodOnline is the method of payments made through a payment gateway; GatewayProvider
+// records which gateway took the payment
+const PaymentMethodOnline = "online"
+
 
 
 
@@ -73,10 +77,17 @@ type Payment struct {
 	RelatedEntityType string       `json:"related_entity_type"` // "booking", "subscription", etc.
 	RelatedEntityID   uint         `json:"related_entity_id"`   // ID of the related entity
 	
-	// Razorpay Details
-	RazorpayOrderID   *string      `json:"razorpay_order_id"`
-	RazorpayPaymentID *string      `json:"razorpay_payment_id"`
-	RazorpaySignature *string      `json:"razorpay_signature"`
+	// Gateway Details (JSON names are kept for existing app clients)
+	GatewayProvider   string       `json:"gateway_provider"` // "razorpay", "cashfree"
+	GatewayOrderID    *string      `json:"razorpay_order_id"`
+	GatewayPaymentID  *string      `json:"razorpay_payment_id"`
+	GatewaySignature  *string      `json:"razorpay_signature"`
+	
+	// Settlement Details (filled in by reconciliation from Razorpay settlement reports)
+	GatewaySettlementID *string    `json:"razorpay_settlement_id"`
+	SettledAt           *time.Time `json:"settled_at"`
+	GatewayFee           *float64   `json:"gateway_fee"` // Including tax
+	GatewayTax           *float64   `json:"gateway_tax"`
 	
 	// Payment Timing
 	InitiatedAt      time.Time     `json:"initiated_at" gorm:"not null"`
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentDiscrepancyType represents the kind of mismatch reconciliation found
+type PaymentDiscrepancyType string
+
+const (
+	PaymentDiscrepancyAmountMismatch           PaymentDiscrepancyType = "amount_mismatch"            // Razorpay captured a different amount than the payment
+	PaymentDiscrepancyCapturedAfterExpiry      PaymentDiscrepancyType = "captured_after_expiry"      // Captured after the payment expired or was abandoned; the order may need a refund
+	PaymentDiscrepancyNotSettled               PaymentDiscrepancyType = "not_settled"                // Completed payment missing from settlements after the settlement window
+	PaymentDiscrepancySettlementAmountMismatch PaymentDiscrepancyType = "settlement_amount_mismatch" // Settled amount differs from the payment
+	PaymentDiscrepancyUnknownSettlement        PaymentDiscrepancyType = "unknown_settlement"         // Settled Razorpay payment with no local payment
+)
+
+// PaymentDiscrepancyStatus represents the status of a payment discrepancy
+type PaymentDiscrepancyStatus string
+
+const (
+	PaymentDiscrepancyStatusOpen     PaymentDiscrepancyStatus = "open"     // Needs attention
+	PaymentDiscrepancyStatusResolved PaymentDiscrepancyStatus = "resolved" // Cleared by a later run or by an admin
+)
+
+// PaymentDiscrepancy flags a payment that disagrees with Razorpay's payments or settlements
+type PaymentDiscrepancy struct {
+	gorm.Model
+	PaymentID        *uint                    `json:"payment_id"`
+	Payment          *Payment                 `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
+	GatewayPaymentID string                   `json:"gateway_payment_id"`
+	GatewayOrderID   string                   `json:"gateway_order_id"`
+	DiscrepancyType  PaymentDiscrepancyType   `json:"discrepancy_type" gorm:"not null"`
+	LocalStatus      string                   `json:"local_status"`
+	GatewayStatus    string                   `json:"gateway_status"`
+	LocalAmount      float64                  `json:"local_amount"`
+	GatewayAmount    float64                  `json:"gateway_amount"`
+	Details          string                   `json:"details"`
+	Status           PaymentDiscrepancyStatus `json:"status" gorm:"default:'open'"`
+	DetectedAt       time.Time                `json:"detected_at"`
+	LastCheckedAt    time.Time                `json:"last_checked_at"`
+	ResolvedAt       *time.Time               `json:"resolved_at"`
+	ResolvedBy       *uint                    `json:"resolved_by"`
+	ResolutionNotes  string                   `json:"resolution_notes"`
+}
+
+// TableName returns the table name for PaymentDiscrepancy
+func (PaymentDiscrepancy) TableName() string {
+	return "payment_discrepancies"
+}
+
+// PaymentReconciliationResult summarises a payment reconciliation run
+type PaymentReconciliationResult struct {
+	PaymentsChecked    int `json:"payments_checked"`
+	PaymentsCompleted  int `json:"payments_completed"`
+	PaymentsFailed     int `json:"payments_failed"`
+	PaymentsAbandoned  int `json:"payments_abandoned"`
+	SettlementsChecked int `json:"settlements_checked"`
+	PaymentsSettled    int `json:"payments_settled"`
+	Discrepancies      int `json:"discrepancies"` // New discrepancies flagged by this run
+	Resolved           int `json:"resolved"`
+	Errors             int `json:"errors"` // Payments or days Razorpay could not be queried for
+}
+
+// PaymentDiscrepancyFilters represents filters for payment discrepancy queries
+type PaymentDiscrepancyFilters struct {
+	Status          string `json:"status"`
+	DiscrepancyType string `json:"discrepancy_type"`
+	Page            int    `json:"page"`
+	Limit           int    `json:"limit"`
+}
+
+// ResolvePaymentDiscrepancyRequest represents the request for resolving a payment discrepancy
+type ResolvePaymentDiscrepancyRequest struct {
+	Notes string `json:"notes" binding:"required"`
+}
@@ -16,6 +16,15 @@ const (
 	PaymentSegmentStatusCancelled PaymentSegmentStatus = "cancelled"
 )
 
+// SegmentEscalationAction is what the collection job does once a segment has stayed overdue
+type SegmentEscalationAction string
+
+const (
+	SegmentEscalationNone            SegmentEscalationAction = "none"             // Only the overdue notice is sent
+	SegmentEscalationNotifyAdmin     SegmentEscalationAction = "notify_admin"     // Admins are told about the overdue segment
+	SegmentEscalationPauseAssignment SegmentEscalationAction = "pause_assignment" // The worker assignment is paused until the booking is paid up
+)
+
 // PaymentSegment represents the payment segment model
 type PaymentSegment struct {
 	gorm.Model
@@ -35,6 +44,14 @@ type PaymentSegment struct {
 	// Additional Information
 	Notes          string                `json:"notes"`
 	
+	// Collection
+	RemindersSent        int        `json:"reminders_sent" gorm:"default:0"`
+	OverdueAt            *time.Time `json:"overdue_at"`
+	EscalatedAt          *time.Time `json:"escalated_at"`
+	AutoDebit            bool       `json:"auto_debit" gorm:"default:false"` // Pay from the wallet when due
+	AutoDebitAttemptedAt *time.Time `json:"auto_debit_attempted_at"`
+	AutoDebitFailure     string     `json:"auto_debit_failure"`
+	
 	// Relationships
 	Booking        Booking               `json:"booking" gorm:"foreignKey:BookingID"`
 	Payment        *Payment              `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
@@ -59,6 +76,12 @@ type CreateSegmentPaymentRequest struct {
 	PaymentMethod  string  `json:"payment_method" binding:"required,oneof=razorpay wallet"`
 }
 
+// SegmentAutoDebitRequest turns wallet auto-debit on or off for one unpaid segment, or all of them
+type SegmentAutoDebitRequest struct {
+	Enabled       *bool `json:"enabled" binding:"required"`
+	SegmentNumber *int  `json:"segment_number,omitempty" binding:"omitempty,min=1"`
+}
+
 // VerifySegmentPaymentRequest represents the request to verify segment payment
 type VerifySegmentPaymentRequest struct {
 	RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
@@ -78,6 +101,7 @@ type PaymentSegmentInfo struct {
 	PaymentID     *uint                 `json:"payment_id"`
 	IsOverdue     bool                  `json:"is_overdue"`
 	DaysUntilDue  *int                  `json:"days_until_due"`
+	AutoDebit     bool                  `json:"auto_debit"`
 }
 
 // PaymentProgress represents the overall payment progress for a booking
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEventStatus represents the processing status of a payment webhook event
+type WebhookEventStatus string
+
+const (
+	WebhookEventStatusReceived   WebhookEventStatus = "received"   // Stored, not processed yet
+	WebhookEventStatusProcessing WebhookEventStatus = "processing" // Being processed right now
+	WebhookEventStatusProcessed  WebhookEventStatus = "processed"  // Reconciled successfully
+	WebhookEventStatusFailed     WebhookEventStatus = "failed"     // Processing failed, can be replayed
+	WebhookEventStatusIgnored    WebhookEventStatus = "ignored"    // Event type we do not act on
+)
+
+// PaymentWebhookEvent represents a webhook received from a payment gateway
+type PaymentWebhookEvent struct {
+	gorm.Model
+	Provider         string             `json:"provider" gorm:"not null;default:'razorpay'"`
+	EventID          string             `json:"event_id" gorm:"not null"`
+	EventType        string             `json:"event_type" gorm:"not null"`
+	Payload          JSONMap            `json:"payload" gorm:"type:jsonb;not null"`
+	Status           WebhookEventStatus `json:"status" gorm:"default:'received'"`
+	Attempts         int                `json:"attempts" gorm:"default:0"`
+	LastError        string             `json:"last_error"`
+	ProcessedAt      *time.Time         `json:"processed_at"`
+	GatewayOrderID   *string            `json:"gateway_order_id"`
+	GatewayPaymentID *string            `json:"gateway_payment_id"`
+	GatewayRefundID  *string            `json:"gateway_refund_id"`
+	PaymentID        *uint              `json:"payment_id"` // Local payment the event was reconciled against
+}
+
+// TableName returns the table name for PaymentWebhookEvent
+func (PaymentWebhookEvent) TableName() string {
+	return "payment_webhook_events"
+}
+
+// WebhookEventFilters represents filters for webhook event queries
+type WebhookEventFilters struct {
+	Provider  string `json:"provider"`
+	EventType string `json:"event_type"`
+	Status    string `json:"status"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PendingRefundStatus represents the status of a refund waiting to be retried
+type PendingRefundStatus string
+
+const (
+	PendingRefundStatusPending PendingRefundStatus = "pending" // Not issued yet, retried by the refund retry job
+	PendingRefundStatusIssued  PendingRefundStatus = "issued"  // Issued by a retry
+)
+
+// PendingRefund is a refund owed on a cancelled booking that could not be issued when the booking was
+// cancelled. It is retried until it is issued; once every pending refund of the booking has been issued,
+// the booking's payment status moves to SettledPaymentStatus.
+type PendingRefund struct {
+	gorm.Model
+	BookingID            uint                `json:"booking_id" gorm:"not null;index"`
+	Booking              *Booking            `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	PaymentID            uint                `json:"payment_id" gorm:"not null"`
+	Amount               float64             `json:"amount" gorm:"not null"`
+	RefundMethod         string              `json:"refund_method"`
+	Reason               string              `json:"reason"`
+	Notes                string              `json:"notes"`
+	SettledPaymentStatus PaymentStatus       `json:"settled_payment_status" gorm:"not null"`
+	Status               PendingRefundStatus `json:"status" gorm:"default:'pending'"`
+	Attempts             int                 `json:"attempts"`
+	LastError            string              `json:"last_error"`
+	NextAttemptAt        time.Time           `json:"next_attempt_at"`
+	IssuedAt             *time.Time          `json:"issued_at"`
+}
+
+// TableName returns the table name for PendingRefund
+func (PendingRefund) TableName() string {
+	return "pending_refunds"
+}
+
+// PendingRefundFilters represents filters for pending refund queries
+type PendingRefundFilters struct {
+	Status string `json:"status"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
+
+// RefundRetryResult summarises a refund retry run
+type RefundRetryResult struct {
+	Retried int `json:"retried"`
+	Issued  int `json:"issued"`
+	Failed  int `json:"failed"`
+}
@@ -119,6 +119,8 @@ type Property struct {
 	// Priority and Subscription
 	PriorityScore        int  `json:"priority_score" gorm:"default:0"`           // Priority for listing order
 	SubscriptionRequired bool `json:"subscription_required" gorm:"default:false"` // If broker needed subscription to post
+	IsFeatured           bool `json:"is_featured" gorm:"default:false"`           // Uses one of the owner's featured listing slots
+	PriorityBoost        int  `json:"-" gorm:"-"`                                 // Added to the priority score on create, from the owner's plan
 	
 	// TreesIndia Assured Tag
 	TreesIndiaAssured    bool `json:"treesindia_assured" gorm:"column:treesindia_assured;default:false"`   // TreesIndia Assured tag for admin-created properties
@@ -175,6 +177,9 @@ func (p *Property) BeforeCreate(tx *gorm.DB) error {
 		p.PriorityScore = 0 // Normal user properties without subscription get low priority
 	}
 	
+	// Add the boost from the owner's subscription plan
+	p.PriorityScore += p.PriorityBoost
+	
 	return nil
 }
 
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReferralCode is the code a user shares to refer others
+type ReferralCode struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	Code   string `json:"code" gorm:"not null;uniqueIndex"`
+}
+
+// TableName returns the table name for ReferralCode
+func (ReferralCode) TableName() string {
+	return "referral_codes"
+}
+
+// ReferralStatus represents the status of a referral
+type ReferralStatus string
+
+const (
+	ReferralStatusPending  ReferralStatus = "pending"  // Waiting for the referee's first completed booking
+	ReferralStatusRewarded ReferralStatus = "rewarded" // Both wallets credited
+)
+
+// Referral links a referee to the user who referred them. Both are credited once the referee
+// completes their first booking.
+type Referral struct {
+	gorm.Model
+	ReferrerID        uint           `json:"referrer_id" gorm:"not null"`
+	RefereeID         uint           `json:"referee_id" gorm:"not null;uniqueIndex"`
+	Code              string         `json:"code" gorm:"not null"`
+	Status            ReferralStatus `json:"status" gorm:"default:'pending'"`
+	BookingID         *uint          `json:"booking_id"` // Booking that earned the reward
+	ReferrerReward    float64        `json:"referrer_reward"`
+	RefereeReward     float64        `json:"referee_reward"`
+	ReferrerPaymentID *uint          `json:"referrer_payment_id"`
+	RefereePaymentID  *uint          `json:"referee_payment_id"`
+	RewardedAt        *time.Time     `json:"rewarded_at"`
+
+	// Relationships
+	Referrer *User `json:"referrer,omitempty" gorm:"foreignKey:ReferrerID"`
+	Referee  *User `json:"referee,omitempty" gorm:"foreignKey:RefereeID"`
+}
+
+// TableName returns the table name for Referral
+func (Referral) TableName() string {
+	return "referrals"
+}
+
+// ApplyReferralCodeRequest represents the request for applying a referral code
+type ApplyReferralCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ReferralSummary is a user's referral code and what their referrals earned
+type ReferralSummary struct {
+	Code           string     `json:"code"`
+	ReferrerReward float64    `json:"referrer_reward"` // Current reward per referral
+	RefereeReward  float64    `json:"referee_reward"`  // Current reward for the referred user
+	PendingCount   int64      `json:"pending_count"`
+	RewardedCount  int64      `json:"rewarded_count"`
+	TotalEarned    float64    `json:"total_earned"`
+	ReferredBy     *uint      `json:"referred_by"` // Referrer of this user, if any
+	Referrals      []Referral `json:"referrals"`
+}
+
+// ReferralFilters represents filters for referral queries
+type ReferralFilters struct {
+	Status string `json:"status"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
@@ -50,6 +50,10 @@ type SimpleConversationMessage struct {
 	IsRead         bool   `json:"is_read" gorm:"column:is_read;default:false"`
 	ReadAt         *time.Time `json:"read_at" gorm:"column:read_at"`
 
+	// Message attachments
+	MessageType       MessageType          `json:"message_type" gorm:"column:message_type;default:'text'"`
+	AttachmentDetails []ChatAttachmentInfo `json:"attachment_details" gorm:"column:attachment_details;type:jsonb;default:'[]';serializer:json"`
+
 	// Relationships
 	Conversation SimpleConversation `json:"conversation" gorm:"foreignKey:ConversationID;references:ID"`
 	Sender       User               `json:"sender" gorm:"foreignKey:SenderID;references:ID"`
@@ -68,7 +72,8 @@ type CreateSimpleConversationRequest struct {
 
 // SendSimpleConversationMessageRequest represents the request structure for sending a message
 type SendSimpleConversationMessageRequest struct {
-	Message string `json:"message" binding:"required"`
+	Message       string `json:"message" binding:"required_without=AttachmentIDs"`
+	AttachmentIDs []uint `json:"attachment_ids" binding:"max=10"` // Uploaded chat attachments to send with the message
 }
 
 // GetSimpleConversationsRequest represents the request structure for getting conversations
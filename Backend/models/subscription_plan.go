@@ -75,6 +75,7 @@ type SubscriptionPlan struct {
 	Description string               `json:"description"`                                    // Plan description
 	Features    JSONB                `json:"features" gorm:"type:jsonb"`                     // Plan features as JSON
 	Pricing     PricingOptionsJSONB  `json:"pricing" gorm:"type:jsonb"`                      // Pricing options as JSON array
+	Entitlements *PlanEntitlements   `json:"entitlements" gorm:"type:jsonb"`                 // Typed quotas and feature access; nil uses DefaultPlanEntitlements
 	
 	// Relationships
 	UserSubscriptions []UserSubscription `json:"user_subscriptions,omitempty" gorm:"foreignKey:PlanID"`
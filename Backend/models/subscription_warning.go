@@ -9,11 +9,12 @@ import (
 // SubscriptionWarning represents subscription warning notifications
 type SubscriptionWarning struct {
 	gorm.Model
-	UserID      uint      `json:"user_id" gorm:"not null"`
-	User        User      `json:"user" gorm:"foreignKey:UserID"`
-	DaysLeft    int       `json:"days_left" gorm:"not null"` // 1, 7 days before expiry
-	WarningDate time.Time `json:"warning_date" gorm:"not null"`
-	SentVia     string    `json:"sent_via" gorm:"not null"` // "email", "sms", "both"
+	UserID         uint      `json:"user_id" gorm:"not null"`
+	User           User      `json:"user" gorm:"foreignKey:UserID"`
+	SubscriptionID uint      `json:"subscription_id" gorm:"not null"`
+	DaysLeft       int       `json:"days_left" gorm:"not null"` // 1, 7 days before expiry
+	WarningDate    time.Time `json:"warning_date" gorm:"not null"`
+	SentVia        string    `json:"sent_via" gorm:"not null"` // "email", "sms", "both"
 }
 
 // TableName returns the table name for SubscriptionWarning
@@ -26,4 +27,5 @@ const (
 	WarningTypeEmail = "email"
 	WarningTypeSMS   = "sms"
 	WarningTypeBoth  = "both"
+	WarningTypeInApp = "in_app"
 )
@@ -39,7 +39,7 @@ type User struct {
 	ApprovalDate          *time.Time `json:"approval_date"`
 	
 	// Wallet System
-	WalletBalance    float64 `json:"wallet_balance" gorm:"default:0"`    // Wallet balance
+	WalletBalance    float64 `json:"wallet_balance" gorm:"<-:create;default:0"`    // Wallet balance, only changed through the wallet journal
 	
 	// Subscription fields
 	SubscriptionID      *uint             `json:"subscription_id"`
@@ -15,10 +15,19 @@ type UserSubscription struct {
 	Plan            SubscriptionPlan `json:"plan" gorm:"foreignKey:PlanID"`
 	StartDate       time.Time `json:"start_date" gorm:"not null"`
 	EndDate         time.Time `json:"end_date" gorm:"not null"`
-	Status          string    `json:"status" gorm:"default:'active'"` // "active", "expired"
+	Status          string    `json:"status" gorm:"default:'active'"` // "active", "grace", "expired", "renewed", "changed"
 	PaymentMethod   string    `json:"payment_method" gorm:"not null"` // "wallet", "razorpay"
 	PaymentID       string    `json:"payment_id"` // Razorpay payment ID
 	Amount          float64   `json:"amount" gorm:"not null"`
+	DurationType    string    `json:"duration_type"`
+
+	// Auto-renewal
+	AutoRenew              bool       `json:"auto_renew" gorm:"default:false"`
+	GraceEndsAt            *time.Time `json:"grace_ends_at"`
+	RenewalAttemptedAt     *time.Time `json:"renewal_attempted_at"`
+	RenewalFailure         string     `json:"renewal_failure,omitempty"`
+	RenewalPaymentID       *uint      `json:"renewal_payment_id"` // Razorpay order to pay when the wallet debit failed
+	PreviousSubscriptionID *uint      `json:"previous_subscription_id"`
 }
 
 // TableName returns the table name for UserSubscription
@@ -29,11 +38,35 @@ func (UserSubscription) TableName() string {
 // Status constants
 const (
 	SubscriptionStatusActive  = "active"
+	SubscriptionStatusGrace   = "grace" // Term ended and auto-renewal failed; access is kept until GraceEndsAt
 	SubscriptionStatusExpired = "expired"
+	SubscriptionStatusRenewed = "renewed" // Replaced by its renewal
+	SubscriptionStatusChanged = "changed" // Replaced by a plan change
 )
 
 // Payment method constants
 const (
 	PaymentMethodWallet  = "wallet"
 	PaymentMethodRazorpay = "razorpay"
+	PaymentMethodProration = "proration" // Paid entirely with credit from the previous plan
 )
+
+// PlanChangeQuote is the prorated price of moving to another plan
+type PlanChangeQuote struct {
+	CurrentSubscriptionID uint      `json:"current_subscription_id"`
+	PlanID                uint      `json:"plan_id"`
+	DurationType          string    `json:"duration_type"`
+	Price                 float64   `json:"price"`
+	ProrationCredit       float64   `json:"proration_credit"` // Value of the unused part of the current term
+	AmountDue             float64   `json:"amount_due"`
+	StartDate             time.Time `json:"start_date"`
+	EndDate               time.Time `json:"end_date"` // Credit beyond the price lengthens the new term
+}
+
+// SubscriptionPaymentResult is a renewal or plan change that either took effect or waits for a Razorpay payment
+type SubscriptionPaymentResult struct {
+	Subscription *UserSubscription      `json:"subscription,omitempty"`
+	Quote        *PlanChangeQuote       `json:"quote,omitempty"`
+	Payment      *Payment               `json:"payment,omitempty"`
+	Order        map[string]interface{} `json:"order,omitempty"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletAccount represents an account wallet journal entries are posted to
+type WalletAccount string
+
+const (
+	WalletAccountUserWallet          WalletAccount = "user_wallet"          // Money held for the user (one account per user)
+	WalletAccountGatewayClearing     WalletAccount = "gateway_clearing"     // Money received through the payment gateway
+	WalletAccountBookingRevenue      WalletAccount = "booking_revenue"      // Bookings and quotes paid from the wallet
+	WalletAccountServiceRevenue      WalletAccount = "service_revenue"      // Other services paid from the wallet
+	WalletAccountSubscriptionRevenue WalletAccount = "subscription_revenue" // Subscriptions paid from the wallet
+	WalletAccountRefunds             WalletAccount = "refunds"              // Refunds credited to the wallet
+	WalletAccountAdminAdjustments    WalletAccount = "admin_adjustments"    // Manual adjustments by admins
+	WalletAccountOpeningBalance      WalletAccount = "opening_balance"      // Balances that existed before the journal
+	WalletAccountPromotions          WalletAccount = "promotions"           // Cashback and referral rewards funded by the platform
+)
+
+// WalletEntryType represents the business event behind a wallet journal entry
+type WalletEntryType string
+
+const (
+	WalletEntryTypeRecharge            WalletEntryType = "recharge"             // Wallet recharge through the gateway
+	WalletEntryTypeBookingPayment      WalletEntryType = "booking_payment"      // Booking or quote paid from the wallet
+	WalletEntryTypeServicePayment      WalletEntryType = "service_payment"      // Service paid from the wallet
+	WalletEntryTypeSubscriptionPayment WalletEntryType = "subscription_payment" // Subscription paid from the wallet
+	WalletEntryTypeRefund              WalletEntryType = "refund"               // Refund credited to the wallet
+	WalletEntryTypeAdminAdjustment     WalletEntryType = "admin_adjustment"     // Admin adjustment
+	WalletEntryTypeOpeningBalance      WalletEntryType = "opening_balance"      // Balance carried over when the journal was introduced
+	WalletEntryTypeCashback            WalletEntryType = "cashback"             // Coupon cashback
+	WalletEntryTypeReferralReward      WalletEntryType = "referral_reward"      // Referral reward
+)
+
+// WalletJournalEntry is one immutable leg of a wallet transaction. Every transaction has a
+// user wallet leg and a balancing leg on another account, so debits equal credits per
+// transaction and a user's balance is the sum of credits minus debits on their wallet account.
+type WalletJournalEntry struct {
+	ID             uint            `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time       `json:"created_at"`
+	TransactionRef string          `json:"transaction_ref" gorm:"not null"`
+	Account        WalletAccount   `json:"account" gorm:"not null"`
+	UserID         *uint           `json:"user_id"` // Set on user wallet legs
+	EntryType      WalletEntryType `json:"entry_type" gorm:"not null"`
+	Debit          float64         `json:"debit" gorm:"default:0"`
+	Credit         float64         `json:"credit" gorm:"default:0"`
+	BalanceAfter   *float64        `json:"balance_after"` // User wallet balance after this leg
+	PaymentID      *uint           `json:"payment_id"`
+	Description    string          `json:"description"`
+	CreatedBy      *uint           `json:"created_by"`
+}
+
+// TableName returns the table name for WalletJournalEntry
+func (WalletJournalEntry) TableName() string {
+	return "wallet_journal_entries"
+}
+
+// WalletDiscrepancyStatus represents the status of a wallet balance discrepancy
+type WalletDiscrepancyStatus string
+
+const (
+	WalletDiscrepancyStatusOpen     WalletDiscrepancyStatus = "open"     // Cached balance disagrees with the journal
+	WalletDiscrepancyStatusResolved WalletDiscrepancyStatus = "resolved" // Balances agree again
+)
+
+// WalletBalanceDiscrepancy flags a user whose cached wallet balance disagrees with their journal
+type WalletBalanceDiscrepancy struct {
+	gorm.Model
+	UserID         uint                    `json:"user_id" gorm:"not null"`
+	User           *User                   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CachedBalance  float64                 `json:"cached_balance"`
+	JournalBalance float64                 `json:"journal_balance"`
+	Difference     float64                 `json:"difference"` // Cached balance minus journal balance
+	Status         WalletDiscrepancyStatus `json:"status" gorm:"default:'open'"`
+	DetectedAt     time.Time               `json:"detected_at"`
+	LastCheckedAt  time.Time               `json:"last_checked_at"`
+	ResolvedAt     *time.Time              `json:"resolved_at"`
+}
+
+// TableName returns the table name for WalletBalanceDiscrepancy
+func (WalletBalanceDiscrepancy) TableName() string {
+	return "wallet_balance_discrepancies"
+}
+
+// WalletBalanceCheck is a user's cached wallet balance next to the balance derived from the journal
+type WalletBalanceCheck struct {
+	UserID         uint    `json:"user_id"`
+	CachedBalance  float64 `json:"cached_balance"`
+	JournalBalance float64 `json:"journal_balance"`
+}
+
+// WalletReconciliationResult summarises a wallet reconciliation run
+type WalletReconciliationResult struct {
+	UsersChecked           int64    `json:"users_checked"`
+	Discrepancies          int      `json:"discrepancies"`
+	Resolved               int      `json:"resolved"`
+	UnbalancedTransactions []string `json:"unbalanced_transactions"`
+}
+
+// WalletDiscrepancyFilters represents filters for wallet discrepancy queries
+type WalletDiscrepancyFilters struct {
+	Status string `json:"status"`
+	UserID uint   `json:"user_id"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
@@ -34,6 +34,7 @@ type Worker struct {
 	// Operational Data
 	IsAvailable        bool       `json:"is_available" gorm:"default:false"`
 	Rating             float64    `json:"rating" gorm:"default:0"`
+	TotalReviews       int        `json:"total_reviews" gorm:"default:0"`
 	TotalBookings      int        `json:"total_bookings" gorm:"default:0"`
 	Earnings           float64    `json:"earnings" gorm:"default:0"`
 	TotalJobs          int        `json:"total_jobs" gorm:"default:0"`
@@ -35,6 +35,8 @@ type WorkerAssignment struct {
 	RejectedAt   *time.Time       `json:"rejected_at"`
 	StartedAt    *time.Time       `json:"started_at"`
 	CompletedAt  *time.Time       `json:"completed_at"`
+	PausedAt     *time.Time       `json:"paused_at"`    // Set while paused for overdue payment
+	PauseReason  string           `json:"pause_reason"`
 	
 	// Notes
 	AssignmentNotes string        `json:"assignment_notes" gorm:"column:notes"`
@@ -101,6 +103,8 @@ type WorkerAssignmentResponse struct {
 	RejectedAt   *time.Time       `json:"rejected_at"`
 	StartedAt    *time.Time       `json:"started_at"`
 	CompletedAt  *time.Time       `json:"completed_at"`
+	PausedAt     *time.Time       `json:"paused_at"`    // Set while paused for overdue payment
+	PauseReason  string           `json:"pause_reason"`
 	
 	// Notes
 	AssignmentNotes string        `json:"assignment_notes" gorm:"column:notes"`
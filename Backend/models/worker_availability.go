@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeaveStatus represents the status of a worker leave request
+type LeaveStatus string
+
+const (
+	LeaveStatusPending   LeaveStatus = "pending"   // Waiting for admin review
+	LeaveStatusApproved  LeaveStatus = "approved"  // Worker is off for the whole date range
+	LeaveStatusRejected  LeaveStatus = "rejected"  // Admin turned the request down
+	LeaveStatusCancelled LeaveStatus = "cancelled" // Worker withdrew the request
+)
+
+// WorkerSchedule represents a worker's working hours on one day of the week
+type WorkerSchedule struct {
+	gorm.Model
+	WorkerID  uint   `json:"worker_id" gorm:"not null"`   // User ID of the worker
+	DayOfWeek int    `json:"day_of_week" gorm:"not null"` // 0 = Sunday ... 6 = Saturday
+	StartTime string `json:"start_time" gorm:"not null"`  // HH:MM in IST
+	EndTime   string `json:"end_time" gorm:"not null"`    // HH:MM in IST
+	IsDayOff  bool   `json:"is_day_off" gorm:"default:false"`
+}
+
+// TableName returns the table name for WorkerSchedule
+func (WorkerSchedule) TableName() string {
+	return "worker_schedules"
+}
+
+// WorkerLeave represents a leave request of a worker
+type WorkerLeave struct {
+	gorm.Model
+	WorkerID   uint        `json:"worker_id" gorm:"not null"`
+	StartDate  time.Time   `json:"start_date" gorm:"type:date;not null"`
+	EndDate    time.Time   `json:"end_date" gorm:"type:date;not null"` // Inclusive
+	Reason     string      `json:"reason"`
+	Status     LeaveStatus `json:"status" gorm:"default:'pending'"`
+	ReviewedBy *uint       `json:"reviewed_by"`
+	ReviewedAt *time.Time  `json:"reviewed_at"`
+	AdminNotes string      `json:"admin_notes"`
+
+	// Relationships
+	Worker User `json:"worker,omitempty" gorm:"foreignKey:WorkerID"`
+}
+
+// TableName returns the table name for WorkerLeave
+func (WorkerLeave) TableName() string {
+	return "worker_leaves"
+}
+
+// WorkerBlockedSlot represents a one-off period in which a worker cannot take jobs
+type WorkerBlockedSlot struct {
+	gorm.Model
+	WorkerID  uint      `json:"worker_id" gorm:"not null"`
+	StartTime time.Time `json:"start_time" gorm:"not null"`
+	EndTime   time.Time `json:"end_time" gorm:"not null"`
+	Reason    string    `json:"reason"`
+}
+
+// TableName returns the table name for WorkerBlockedSlot
+func (WorkerBlockedSlot) TableName() string {
+	return "worker_blocked_slots"
+}
+
+// WorkerScheduleDay represents the working hours of one day in a schedule request
+type WorkerScheduleDay struct {
+	DayOfWeek int    `json:"day_of_week" binding:"min=0,max=6"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	IsDayOff  bool   `json:"is_day_off"`
+}
+
+// UpdateWorkerScheduleRequest represents the request structure for setting a weekly schedule.
+// Days left out of the request are treated as days off.
+type UpdateWorkerScheduleRequest struct {
+	Days []WorkerScheduleDay `json:"days" binding:"required,min=1,max=7,dive"`
+}
+
+// CreateLeaveRequest represents the request structure for requesting leave
+type CreateLeaveRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// ReviewLeaveRequest represents the request structure for approving or rejecting leave
+type ReviewLeaveRequest struct {
+	Status LeaveStatus `json:"status" binding:"required,oneof=approved rejected"`
+	Notes  string      `json:"notes"`
+}
+
+// CreateBlockedSlotRequest represents the request structure for blocking a period
+type CreateBlockedSlotRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason"`
+}
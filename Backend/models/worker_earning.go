@@ -0,0 +1,211 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommissionType represents how a commission rule calculates the platform's share
+type CommissionType string
+
+const (
+	CommissionTypePercentage CommissionType = "percentage" // Percentage of the job amount
+	CommissionTypeFixed      CommissionType = "fixed"      // Fixed amount per job
+)
+
+// CommissionRule is the platform commission taken from jobs of a category or service.
+// A service rule wins over a category rule; jobs without a rule use the default commission.
+type CommissionRule struct {
+	gorm.Model
+	Name           string         `json:"name" gorm:"not null"`
+	CategoryID     *uint          `json:"category_id"`
+	ServiceID      *uint          `json:"service_id"`
+	CommissionType CommissionType `json:"commission_type" gorm:"not null;default:'percentage'"`
+	Value          float64        `json:"value" gorm:"not null"` // Percentage or fixed amount in INR
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy      *uint          `json:"created_by"`
+
+	// Relationships
+	Category *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Service  *Service  `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+}
+
+// TableName returns the table name for CommissionRule
+func (CommissionRule) TableName() string {
+	return "commission_rules"
+}
+
+// EarningStatus represents the settlement status of a worker earning
+type EarningStatus string
+
+const (
+	EarningStatusPending  EarningStatus = "pending"   // Owed to the worker, not in a payout yet
+	EarningStatusInPayout EarningStatus = "in_payout" // Included in a payout batch
+	EarningStatusPaid     EarningStatus = "paid"      // Paid out to the worker
+	EarningStatusReversed EarningStatus = "reversed"  // Refunded to the customer in full, not owed
+)
+
+// WorkerEarning is the amount a worker earned for one completed booking after commission
+type WorkerEarning struct {
+	gorm.Model
+	WorkerID         uint           `json:"worker_id" gorm:"not null"` // Worker's user ID
+	BookingID        uint           `json:"booking_id" gorm:"not null;uniqueIndex"`
+	AssignmentID     uint           `json:"assignment_id"`
+	CommissionRuleID *uint          `json:"commission_rule_id"`
+	GrossAmount      float64        `json:"gross_amount"`
+	CommissionType   CommissionType `json:"commission_type"`
+	CommissionRate   float64        `json:"commission_rate"` // Percentage or fixed amount that was applied
+	CommissionAmount float64        `json:"commission_amount"`
+	NetAmount        float64        `json:"net_amount"`
+	RefundedAmount   float64        `json:"refunded_amount"` // Taken off the gross amount by dispute refunds
+	Status           EarningStatus  `json:"status" gorm:"default:'pending'"`
+	PayoutID         *uint          `json:"payout_id"`
+	EarnedAt         time.Time      `json:"earned_at"`
+
+	// Relationships
+	Booking *Booking `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// TableName returns the table name for WorkerEarning
+func (WorkerEarning) TableName() string {
+	return "worker_earnings"
+}
+
+// PayoutBatchStatus represents the status of a payout batch
+type PayoutBatchStatus string
+
+const (
+	PayoutBatchStatusDraft     PayoutBatchStatus = "draft"     // Created, bank file not exported yet
+	PayoutBatchStatusExported  PayoutBatchStatus = "exported"  // Bank file exported, waiting for the bank
+	PayoutBatchStatusCompleted PayoutBatchStatus = "completed" // Every payout is paid or failed
+	PayoutBatchStatusCancelled PayoutBatchStatus = "cancelled" // Cancelled, earnings released
+)
+
+// PayoutBatch groups the payouts of one settlement period
+type PayoutBatch struct {
+	gorm.Model
+	BatchReference string            `json:"batch_reference" gorm:"uniqueIndex;not null"`
+	PeriodStart    *time.Time        `json:"period_start"`
+	PeriodEnd      time.Time         `json:"period_end"`
+	Status         PayoutBatchStatus `json:"status" gorm:"default:'draft'"`
+	TotalAmount    float64           `json:"total_amount"`
+	PayoutCount    int               `json:"payout_count"`
+	SkippedWorkers int               `json:"skipped_workers"` // Workers left out because their banking info is incomplete
+	ExportedAt     *time.Time        `json:"exported_at"`
+	CompletedAt    *time.Time        `json:"completed_at"`
+	Notes          string            `json:"notes"`
+	CreatedBy      uint              `json:"created_by"`
+
+	// Relationships
+	Payouts []WorkerPayout `json:"payouts,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+// TableName returns the table name for PayoutBatch
+func (PayoutBatch) TableName() string {
+	return "payout_batches"
+}
+
+// PayoutStatus represents the status of a worker payout
+type PayoutStatus string
+
+const (
+	PayoutStatusPending   PayoutStatus = "pending"   // Waiting for the bank transfer
+	PayoutStatusPaid      PayoutStatus = "paid"      // Transferred to the worker
+	PayoutStatusFailed    PayoutStatus = "failed"    // Transfer failed, earnings go back to pending
+	PayoutStatusCancelled PayoutStatus = "cancelled" // Batch cancelled
+)
+
+// WorkerPayout is one worker's transfer in a payout batch. The bank details are copied from the
+// worker's banking info when the batch is created.
+type WorkerPayout struct {
+	gorm.Model
+	BatchID           uint         `json:"batch_id" gorm:"not null"`
+	WorkerID          uint         `json:"worker_id" gorm:"not null"` // Worker's user ID
+	Amount            float64      `json:"amount"`
+	EarningsCount     int          `json:"earnings_count"`
+	AccountHolderName string       `json:"account_holder_name"`
+	AccountNumber     string       `json:"account_number"`
+	IfscCode          string       `json:"ifsc_code"`
+	BankName          string       `json:"bank_name"`
+	Status            PayoutStatus `json:"status" gorm:"default:'pending'"`
+	TransactionRef    string       `json:"transaction_ref"` // Bank UTR
+	FailureReason     string       `json:"failure_reason"`
+	PaidAt            *time.Time   `json:"paid_at"`
+
+	// Relationships
+	Batch  *PayoutBatch `json:"batch,omitempty" gorm:"foreignKey:BatchID"`
+	Worker *User        `json:"worker,omitempty" gorm:"foreignKey:WorkerID"`
+}
+
+// TableName returns the table name for WorkerPayout
+func (WorkerPayout) TableName() string {
+	return "worker_payouts"
+}
+
+// CommissionRuleRequest represents the request for creating or updating a commission rule
+type CommissionRuleRequest struct {
+	Name           string         `json:"name" binding:"required"`
+	CategoryID     *uint          `json:"category_id"`
+	ServiceID      *uint          `json:"service_id"`
+	CommissionType CommissionType `json:"commission_type" binding:"required,oneof=percentage fixed"`
+	Value          float64        `json:"value" binding:"min=0"`
+	IsActive       *bool          `json:"is_active"`
+}
+
+// CreatePayoutBatchRequest represents the request for creating a payout batch
+type CreatePayoutBatchRequest struct {
+	PeriodEnd string `json:"period_end"` // YYYY-MM-DD, inclusive; defaults to yesterday
+	Notes     string `json:"notes"`
+}
+
+// PayoutResult is the bank outcome of one payout
+type PayoutResult struct {
+	PayoutID       uint   `json:"payout_id" binding:"required"`
+	Paid           bool   `json:"paid"`
+	TransactionRef string `json:"transaction_ref"`
+	FailureReason  string `json:"failure_reason"`
+}
+
+// RecordPayoutResultsRequest represents the request for recording bank results of a payout batch
+type RecordPayoutResultsRequest struct {
+	Results []PayoutResult `json:"results" binding:"required,min=1,dive"`
+}
+
+// EarningsSummary totals a worker's earnings
+type EarningsSummary struct {
+	Jobs             int64   `json:"jobs"`
+	GrossAmount      float64 `json:"gross_amount"`
+	CommissionAmount float64 `json:"commission_amount"`
+	NetAmount        float64 `json:"net_amount"`
+	PendingAmount    float64 `json:"pending_amount"`   // Owed, not in a payout yet
+	InPayoutAmount   float64 `json:"in_payout_amount"` // In a payout that is not paid yet
+	PaidAmount       float64 `json:"paid_amount"`
+}
+
+// WorkerEarningsStatement is a worker's earnings and payouts for a period
+type WorkerEarningsStatement struct {
+	From              *time.Time      `json:"from"`
+	To                *time.Time      `json:"to"`
+	Period            EarningsSummary `json:"period"`
+	Lifetime          EarningsSummary `json:"lifetime"`
+	OutstandingAmount float64         `json:"outstanding_amount"` // Pending plus in payout, across all time
+	Payouts           []WorkerPayout  `json:"payouts"`
+}
+
+// WorkerEarningFilters represents filters for worker earning queries
+type WorkerEarningFilters struct {
+	WorkerID uint       `json:"worker_id"`
+	Status   string     `json:"status"`
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+	Page     int        `json:"page"`
+	Limit    int        `json:"limit"`
+}
+
+// PayoutBatchFilters represents filters for payout batch queries
+type PayoutBatchFilters struct {
+	Status string `json:"status"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type BookingActivityRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingActivityRepository() *BookingActivityRepository {
+	return &BookingActivityRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create creates a new activity log entry
+func (ar *BookingActivityRepository) Create(activity *models.BookingActivityLog) error {
+	return ar.db.Create(activity).Error
+}
+
+// GetByBookingID gets the full activity history of a booking, oldest first
+func (ar *BookingActivityRepository) GetByBookingID(bookingID uint) ([]models.BookingActivityLog, error) {
+	var activities []models.BookingActivityLog
+	err := ar.db.Where("booking_id = ?", bookingID).
+		Preload("Actor").
+		Order("created_at ASC, id ASC").
+		Find(&activities).Error
+	return activities, err
+}
+
+// GetActivities gets activity entries across bookings with filters, newest first
+func (ar *BookingActivityRepository) GetActivities(filters *BookingActivityFilters) ([]models.BookingActivityLog, *Pagination, error) {
+	var activities []models.BookingActivityLog
+	var total int64
+
+	query := ar.db.Model(&models.BookingActivityLog{})
+
+	// Apply filters
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+	if filters.ActorType != "" {
+		query = query.Where("actor_type = ?", filters.ActorType)
+	}
+	if filters.ActorID != nil {
+		query = query.Where("actor_id = ?", *filters.ActorID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+	query = query.Offset(offset).Limit(filters.Limit)
+
+	// Execute query
+	err = query.Preload("Actor").Order("created_at DESC, id DESC").Find(&activities).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return activities, pagination, nil
+}
+
+// BookingActivityFilters represents filters for activity log queries
+type BookingActivityFilters struct {
+	BookingID *uint      `json:"booking_id"`
+	Action    string     `json:"action"`
+	ActorType string     `json:"actor_type"`
+	ActorID   *uint      `json:"actor_id"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookingDisputeRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingDisputeRepository() *BookingDisputeRepository {
+	return &BookingDisputeRepository{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a dispute repository that works inside the transaction tx
+func (dr *BookingDisputeRepository) WithTx(tx *gorm.DB) *BookingDisputeRepository {
+	return &BookingDisputeRepository{db: tx}
+}
+
+// LockForUpdate locks a dispute's row until the transaction ends
+func (dr *BookingDisputeRepository) LockForUpdate(id uint) error {
+	var dispute models.BookingDispute
+	return dr.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&dispute, id).Error
+}
+
+// Create creates a new dispute
+func (dr *BookingDisputeRepository) Create(dispute *models.BookingDispute) error {
+	return dr.db.Create(dispute).Error
+}
+
+// Update updates a dispute
+func (dr *BookingDisputeRepository) Update(dispute *models.BookingDispute) error {
+	return dr.db.Omit("Comments", "Booking", "RaisedByUser", "ResolvedByUser").Save(dispute).Error
+}
+
+// GetByID gets a dispute by ID with its comments
+func (dr *BookingDisputeRepository) GetByID(id uint) (*models.BookingDispute, error) {
+	var dispute models.BookingDispute
+	err := dr.db.Preload("Booking.Service").
+		Preload("Booking.WorkerAssignment").
+		Preload("RaisedByUser").
+		Preload("ResolvedByUser").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Comments.User").
+		First(&dispute, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// GetByBookingID gets all disputes for a booking with their comments
+func (dr *BookingDisputeRepository) GetByBookingID(bookingID uint) ([]models.BookingDispute, error) {
+	var disputes []models.BookingDispute
+	err := dr.db.Where("booking_id = ?", bookingID).
+		Preload("RaisedByUser").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Comments.User").
+		Order("created_at DESC").
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// HasActiveDispute checks whether a user already has an unresolved dispute on a booking
+func (dr *BookingDisputeRepository) HasActiveDispute(bookingID uint, userID uint) (bool, error) {
+	var count int64
+	err := dr.db.Model(&models.BookingDispute{}).
+		Where("booking_id = ? AND raised_by = ? AND status != ?", bookingID, userID, models.DisputeStatusResolved).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetDisputes gets disputes with filters
+func (dr *BookingDisputeRepository) GetDisputes(filters *DisputeFilters) ([]models.BookingDispute, *Pagination, error) {
+	var disputes []models.BookingDispute
+	var total int64
+
+	query := dr.db.Model(&models.BookingDispute{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Reason != "" {
+		query = query.Where("reason = ?", filters.Reason)
+	}
+	if filters.RaisedByRole != "" {
+		query = query.Where("raised_by_role = ?", filters.RaisedByRole)
+	}
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 10
+	}
+	offset := (filters.Page - 1) * filters.Limit
+	query = query.Offset(offset).Limit(filters.Limit)
+
+	// Preload relationships
+	query = query.Preload("RaisedByUser").Preload("Booking.Service")
+
+	// Execute query
+	err = query.Order("created_at DESC").Find(&disputes).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return disputes, pagination, nil
+}
+
+// CreateComment adds a comment to a dispute
+func (dr *BookingDisputeRepository) CreateComment(comment *models.DisputeComment) error {
+	return dr.db.Create(comment).Error
+}
+
+// DisputeFilters represents filters for dispute queries
+type DisputeFilters struct {
+	Status       string `json:"status"`
+	Reason       string `json:"reason"`
+	RaisedByRole string `json:"raised_by_role"`
+	BookingID    *uint  `json:"booking_id"`
+	Page         int    `json:"page"`
+	Limit        int    `json:"limit"`
+}
@@ -26,6 +26,11 @@ func (br *BookingRepository) GetDB() *gorm.DB {
 	return br.db
 }
 
+// WithTx returns a booking repository that works inside the transaction tx
+func (br *BookingRepository) WithTx(tx *gorm.DB) *BookingRepository {
+	return &BookingRepository{db: tx}
+}
+
 // Create creates a new booking
 func (br *BookingRepository) Create(booking *models.Booking) (*models.Booking, error) {
 	err := br.db.Create(booking).Error
@@ -64,6 +69,13 @@ func (br *BookingRepository) Update(booking *models.Booking) error {
 	return br.db.Save(booking).Error
 }
 
+// SettleRefundPending moves a booking whose refund was pending to the given payment status
+func (br *BookingRepository) SettleRefundPending(bookingID uint, status models.PaymentStatus) error {
+	return br.db.Model(&models.Booking{}).
+		Where("id = ? AND payment_status = ?", bookingID, models.PaymentStatusRefundPending).
+		Update("payment_status", status).Error
+}
+
 // GetUserBookings gets bookings for a user with filters
 func (br *BookingRepository) GetUserBookings(userID uint, filters *UserBookingFilters) ([]models.Booking, *Pagination, error) {
 	var bookings []models.Booking
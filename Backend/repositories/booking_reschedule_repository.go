@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type BookingRescheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingRescheduleRepository() *BookingRescheduleRepository {
+	return &BookingRescheduleRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create creates a reschedule request
+func (rr *BookingRescheduleRepository) Create(request *models.BookingRescheduleRequest) error {
+	return rr.db.Omit("Booking").Create(request).Error
+}
+
+// Update updates a reschedule request
+func (rr *BookingRescheduleRepository) Update(request *models.BookingRescheduleRequest) error {
+	return rr.db.Omit("Booking").Save(request).Error
+}
+
+// GetByID gets a reschedule request with its booking
+func (rr *BookingRescheduleRepository) GetByID(id uint) (*models.BookingRescheduleRequest, error) {
+	var request models.BookingRescheduleRequest
+	err := rr.db.Preload("Booking").Preload("Booking.Service").First(&request, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetPendingByBookingID gets the open reschedule request of a booking
+func (rr *BookingRescheduleRepository) GetPendingByBookingID(bookingID uint) (*models.BookingRescheduleRequest, error) {
+	var request models.BookingRescheduleRequest
+	err := rr.db.Where("booking_id = ? AND status = ?", bookingID, models.RescheduleStatusPending).First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetByBookingID gets the reschedule history of a booking, newest first
+func (rr *BookingRescheduleRepository) GetByBookingID(bookingID uint) ([]models.BookingRescheduleRequest, error) {
+	var requests []models.BookingRescheduleRequest
+	err := rr.db.Where("booking_id = ?", bookingID).Order("created_at DESC").Find(&requests).Error
+	return requests, err
+}
+
+// GetPendingForWorker gets the reschedule requests waiting for a worker's answer
+func (rr *BookingRescheduleRepository) GetPendingForWorker(workerID uint) ([]models.BookingRescheduleRequest, error) {
+	var requests []models.BookingRescheduleRequest
+	err := rr.db.Preload("Booking").Preload("Booking.Service").
+		Where("worker_id = ? AND status = ?", workerID, models.RescheduleStatusPending).
+		Order("old_scheduled_time ASC").
+		Find(&requests).Error
+	return requests, err
+}
+
+// CountAccepted counts the reschedules applied to a booking at the request of the given role
+func (rr *BookingRescheduleRepository) CountAccepted(bookingID uint, role models.ActivityActorType) (int64, error) {
+	var count int64
+	err := rr.db.Model(&models.BookingRescheduleRequest{}).
+		Where("booking_id = ? AND requester_role = ? AND status = ?", bookingID, role, models.RescheduleStatusAccepted).
+		Count(&count).Error
+	return count, err
+}
+
+// GetRequests gets reschedule requests with filters
+func (rr *BookingRescheduleRepository) GetRequests(filters *RescheduleFilters) ([]models.BookingRescheduleRequest, *Pagination, error) {
+	var requests []models.BookingRescheduleRequest
+	var total int64
+
+	query := rr.db.Model(&models.BookingRescheduleRequest{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+	if filters.WorkerID != nil {
+		query = query.Where("worker_id = ?", *filters.WorkerID)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 10
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Booking").Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&requests).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return requests, pagination, nil
+}
+
+// GetStalePending gets pending requests whose old or new time has already passed
+func (rr *BookingRescheduleRepository) GetStalePending(now time.Time) ([]models.BookingRescheduleRequest, error) {
+	var requests []models.BookingRescheduleRequest
+	err := rr.db.Where("status = ?", models.RescheduleStatusPending).
+		Where("old_scheduled_time <= ? OR new_scheduled_time <= ?", now, now).
+		Find(&requests).Error
+	return requests, err
+}
+
+// ApplyReschedule moves the booking and its worker assignment to the new time and closes the request in one transaction
+func (rr *BookingRescheduleRepository) ApplyReschedule(request *models.BookingRescheduleRequest, assignment *models.WorkerAssignment) error {
+	return rr.db.Transaction(func(tx *gorm.DB) error {
+		scheduledDate := time.Date(request.NewScheduledTime.Year(), request.NewScheduledTime.Month(), request.NewScheduledTime.Day(), 0, 0, 0, 0, time.UTC)
+		err := tx.Model(&models.Booking{}).Where("id = ?", request.BookingID).Updates(map[string]interface{}{
+			"scheduled_date":     scheduledDate,
+			"scheduled_time":     request.NewScheduledTime,
+			"scheduled_end_time": request.NewScheduledEndTime,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if assignment != nil {
+			if err := tx.Model(&models.WorkerAssignment{}).Where("id = ?", assignment.ID).Updates(map[string]interface{}{
+				"accepted_at":      assignment.AcceptedAt,
+				"acceptance_notes": assignment.AcceptanceNotes,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Omit("Booking").Save(request).Error
+	})
+}
+
+// RescheduleFilters represents filters for reschedule request queries
+type RescheduleFilters struct {
+	Status    string `json:"status"`
+	BookingID *uint  `json:"booking_id"`
+	WorkerID  *uint  `json:"worker_id"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
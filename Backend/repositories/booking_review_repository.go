@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type BookingReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingReviewRepository() *BookingReviewRepository {
+	return &BookingReviewRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create creates a new review
+func (rr *BookingReviewRepository) Create(review *models.BookingReview) error {
+	return rr.db.Create(review).Error
+}
+
+// Update updates a review
+func (rr *BookingReviewRepository) Update(review *models.BookingReview) error {
+	return rr.db.Save(review).Error
+}
+
+// GetByID gets a review by ID
+func (rr *BookingReviewRepository) GetByID(id uint) (*models.BookingReview, error) {
+	var review models.BookingReview
+	err := rr.db.Preload("User").Preload("Worker").Preload("Booking.Service").First(&review, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByBookingID gets the review for a booking
+func (rr *BookingReviewRepository) GetByBookingID(bookingID uint) (*models.BookingReview, error) {
+	var review models.BookingReview
+	err := rr.db.Where("booking_id = ?", bookingID).First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetPublishedByWorker gets published reviews for a worker (by worker user ID)
+func (rr *BookingReviewRepository) GetPublishedByWorker(workerUserID uint, page, limit int) ([]models.BookingReview, *Pagination, error) {
+	return rr.GetReviews(&ReviewFilters{
+		WorkerID: &workerUserID,
+		Status:   string(models.ReviewStatusPublished),
+		Page:     page,
+		Limit:    limit,
+	})
+}
+
+// GetReviews gets reviews with filters
+func (rr *BookingReviewRepository) GetReviews(filters *ReviewFilters) ([]models.BookingReview, *Pagination, error) {
+	var reviews []models.BookingReview
+	var total int64
+
+	query := rr.db.Model(&models.BookingReview{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.WorkerID != nil {
+		query = query.Where("worker_id = ?", *filters.WorkerID)
+	}
+	if filters.UserID != nil {
+		query = query.Where("user_id = ?", *filters.UserID)
+	}
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+	if filters.MinRating > 0 {
+		query = query.Where("rating >= ?", filters.MinRating)
+	}
+	if filters.MaxRating > 0 {
+		query = query.Where("rating <= ?", filters.MaxRating)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 10
+	}
+	offset := (filters.Page - 1) * filters.Limit
+	query = query.Offset(offset).Limit(filters.Limit)
+
+	// Preload relationships
+	query = query.Preload("User").Preload("Worker").Preload("Booking.Service")
+
+	// Execute query
+	err = query.Order("created_at DESC").Find(&reviews).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return reviews, pagination, nil
+}
+
+// GetWorkerRatingAggregate returns the average rating and count of published reviews for a worker
+func (rr *BookingReviewRepository) GetWorkerRatingAggregate(workerUserID uint) (float64, int, error) {
+	var result struct {
+		AverageRating float64
+		TotalReviews  int
+	}
+	err := rr.db.Model(&models.BookingReview{}).
+		Select("COALESCE(AVG(rating), 0) AS average_rating, COUNT(*) AS total_reviews").
+		Where("worker_id = ? AND status = ?", workerUserID, models.ReviewStatusPublished).
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.AverageRating, result.TotalReviews, nil
+}
+
+// GetWorkerRatingDistribution returns the number of published reviews per star rating for a worker
+func (rr *BookingReviewRepository) GetWorkerRatingDistribution(workerUserID uint) (map[int]int, error) {
+	var rows []struct {
+		Rating int
+		Count  int
+	}
+	err := rr.db.Model(&models.BookingReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("worker_id = ? AND status = ?", workerUserID, models.ReviewStatusPublished).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range rows {
+		distribution[row.Rating] = row.Count
+	}
+	return distribution, nil
+}
+
+// GetWorkerCategoryAverages returns the average score per review category for a worker
+func (rr *BookingReviewRepository) GetWorkerCategoryAverages(workerUserID uint) (map[string]float64, error) {
+	var rows []struct {
+		Category string
+		Average  float64
+	}
+	err := rr.db.Raw(`
+		SELECT c.key AS category, AVG(c.value::int) AS average
+		FROM booking_reviews r, jsonb_each_text(r.categories) c
+		WHERE r.worker_id = ? AND r.status = ? AND r.deleted_at IS NULL
+		GROUP BY c.key
+	`, workerUserID, models.ReviewStatusPublished).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	averages := make(map[string]float64)
+	for _, row := range rows {
+		averages[row.Category] = row.Average
+	}
+	return averages, nil
+}
+
+// ReviewFilters represents filters for reviews
+type ReviewFilters struct {
+	Status    string `json:"status"`
+	WorkerID  *uint  `json:"worker_id"`
+	UserID    *uint  `json:"user_id"`
+	BookingID *uint  `json:"booking_id"`
+	MinRating int    `json:"min_rating"`
+	MaxRating int    `json:"max_rating"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
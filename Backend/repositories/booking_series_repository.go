@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type BookingSeriesRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingSeriesRepository() *BookingSeriesRepository {
+	return &BookingSeriesRepository{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a booking series repository that works inside the transaction tx
+func (sr *BookingSeriesRepository) WithTx(tx *gorm.DB) *BookingSeriesRepository {
+	return &BookingSeriesRepository{db: tx}
+}
+
+// Create creates a booking series together with its occurrences
+func (sr *BookingSeriesRepository) Create(series *models.BookingSeries) error {
+	return sr.db.Omit("User", "Service").Create(series).Error
+}
+
+// Update updates a booking series
+func (sr *BookingSeriesRepository) Update(series *models.BookingSeries) error {
+	return sr.db.Omit("User", "Service", "Occurrences").Save(series).Error
+}
+
+// GetByID gets a booking series with its service and occurrences
+func (sr *BookingSeriesRepository) GetByID(id uint) (*models.BookingSeries, error) {
+	var series models.BookingSeries
+	err := sr.db.Preload("Service").
+		Preload("Occurrences", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence ASC")
+		}).
+		Preload("Occurrences.Booking").
+		First(&series, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// GetSeries gets booking series with filters
+func (sr *BookingSeriesRepository) GetSeries(filters *BookingSeriesFilters) ([]models.BookingSeries, *Pagination, error) {
+	var series []models.BookingSeries
+	var total int64
+
+	query := sr.db.Model(&models.BookingSeries{})
+
+	// Apply filters
+	if filters.UserID != nil {
+		query = query.Where("user_id = ?", *filters.UserID)
+	}
+	if filters.ServiceID != nil {
+		query = query.Where("service_id = ?", *filters.ServiceID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 10
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("User").Preload("Service").
+		Preload("Occurrences", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence ASC")
+		}).
+		Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&series).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return series, pagination, nil
+}
+
+// GetOccurrenceByID gets an occurrence by ID
+func (sr *BookingSeriesRepository) GetOccurrenceByID(id uint) (*models.BookingSeriesOccurrence, error) {
+	var occurrence models.BookingSeriesOccurrence
+	err := sr.db.First(&occurrence, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &occurrence, nil
+}
+
+// UpdateOccurrence updates an occurrence
+func (sr *BookingSeriesRepository) UpdateOccurrence(occurrence *models.BookingSeriesOccurrence) error {
+	return sr.db.Omit("Series", "Booking").Save(occurrence).Error
+}
+
+// ClaimOccurrence moves a scheduled occurrence to processing, stamping updated_at with the claim time.
+// Occurrences processing since before staleBefore were left by a run that stopped and are claimed again.
+// It reports false when another run claimed the occurrence first.
+func (sr *BookingSeriesRepository) ClaimOccurrence(id uint, staleBefore time.Time) (bool, error) {
+	result := sr.db.Model(&models.BookingSeriesOccurrence{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, models.OccurrenceStatusScheduled, models.OccurrenceStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     models.OccurrenceStatusProcessing,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseOccurrence moves a claimed occurrence back to scheduled so a later run can book it
+func (sr *BookingSeriesRepository) ReleaseOccurrence(id uint) error {
+	return sr.db.Model(&models.BookingSeriesOccurrence{}).
+		Where("id = ? AND status = ?", id, models.OccurrenceStatusProcessing).
+		Update("status", models.OccurrenceStatusScheduled).Error
+}
+
+// GetDueOccurrences gets scheduled occurrences of active series starting before the cutoff, and those
+// processing since before staleBefore
+func (sr *BookingSeriesRepository) GetDueOccurrences(cutoff time.Time, staleBefore time.Time) ([]models.BookingSeriesOccurrence, error) {
+	var occurrences []models.BookingSeriesOccurrence
+	err := sr.db.Joins("JOIN booking_series ON booking_series.id = booking_series_occurrences.series_id AND booking_series.deleted_at IS NULL").
+		Where("booking_series.status = ?", models.BookingSeriesStatusActive).
+		Where("booking_series_occurrences.scheduled_at <= ?", cutoff).
+		Where("(booking_series_occurrences.status = ? OR (booking_series_occurrences.status = ? AND booking_series_occurrences.updated_at < ?))",
+			models.OccurrenceStatusScheduled, models.OccurrenceStatusProcessing, staleBefore).
+		Order("booking_series_occurrences.scheduled_at ASC").
+		Find(&occurrences).Error
+	return occurrences, err
+}
+
+// CountScheduledOccurrences counts the occurrences of a series still waiting to be booked or being booked
+func (sr *BookingSeriesRepository) CountScheduledOccurrences(seriesID uint) (int64, error) {
+	var count int64
+	err := sr.db.Model(&models.BookingSeriesOccurrence{}).
+		Where("series_id = ? AND status IN ?", seriesID, []models.OccurrenceStatus{models.OccurrenceStatusScheduled, models.OccurrenceStatusProcessing}).
+		Count(&count).Error
+	return count, err
+}
+
+// GetLastServingWorkerID gets the worker who most recently took an occurrence of the series
+func (sr *BookingSeriesRepository) GetLastServingWorkerID(seriesID uint) (uint, error) {
+	var assignment models.WorkerAssignment
+	err := sr.db.Joins("JOIN booking_series_occurrences ON booking_series_occurrences.booking_id = worker_assignments.booking_id AND booking_series_occurrences.deleted_at IS NULL").
+		Where("booking_series_occurrences.series_id = ?", seriesID).
+		Where("worker_assignments.status IN ?", []models.AssignmentStatus{models.AssignmentStatusAccepted, models.AssignmentStatusInProgress, models.AssignmentStatusCompleted}).
+		Order("booking_series_occurrences.scheduled_at DESC").
+		First(&assignment).Error
+	if err != nil {
+		return 0, err
+	}
+	return assignment.WorkerID, nil
+}
+
+// BookingSeriesFilters represents filters for booking series queries
+type BookingSeriesFilters struct {
+	UserID    *uint  `json:"user_id"`
+	ServiceID *uint  `json:"service_id"`
+	Status    string `json:"status"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
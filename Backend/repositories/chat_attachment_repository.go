@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+// ChatAttachmentRepository handles chat attachment database operations
+type ChatAttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewChatAttachmentRepository creates a new chat attachment repository
+func NewChatAttachmentRepository() *ChatAttachmentRepository {
+	return &ChatAttachmentRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create creates a new chat attachment
+func (r *ChatAttachmentRepository) Create(attachment *models.ChatAttachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// GetByID gets a chat attachment by ID
+func (r *ChatAttachmentRepository) GetByID(id uint) (*models.ChatAttachment, error) {
+	var attachment models.ChatAttachment
+	err := r.db.First(&attachment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetUnsent gets the attachments a user uploaded to a thread that no message carries yet
+func (r *ChatAttachmentRepository) GetUnsent(ids []uint, uploaderID uint, threadType models.ChatThreadType, threadID uint) ([]models.ChatAttachment, error) {
+	var attachments []models.ChatAttachment
+	err := r.db.Where("id IN ? AND uploader_id = ? AND thread_type = ? AND thread_id = ? AND message_id IS NULL", ids, uploaderID, threadType, threadID).
+		Order("id ASC").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// AssignToMessage links unsent attachments to the message carrying them and returns how many were linked
+func (r *ChatAttachmentRepository) AssignToMessage(ids []uint, messageID uint) (int64, error) {
+	result := r.db.Model(&models.ChatAttachment{}).
+		Where("id IN ? AND message_id IS NULL", ids).
+		Update("message_id", messageID)
+	return result.RowsAffected, result.Error
+}
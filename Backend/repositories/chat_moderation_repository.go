@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+// ChatModerationRepository handles flagged chat messages and chat mutes
+type ChatModerationRepository struct {
+	db *gorm.DB
+}
+
+// NewChatModerationRepository creates a new chat moderation repository
+func NewChatModerationRepository() *ChatModerationRepository {
+	return &ChatModerationRepository{
+		db: database.GetDB(),
+	}
+}
+
+// CreateReport creates a new chat message report
+func (r *ChatModerationRepository) CreateReport(report *models.ChatMessageReport) error {
+	return r.db.Create(report).Error
+}
+
+// UpdateReport updates a chat message report
+func (r *ChatModerationRepository) UpdateReport(report *models.ChatMessageReport) error {
+	return r.db.Save(report).Error
+}
+
+// GetReportByID gets a chat message report by ID with its sender and reporter
+func (r *ChatModerationRepository) GetReportByID(id uint) (*models.ChatMessageReport, error) {
+	var report models.ChatMessageReport
+	err := r.db.Preload("Sender").Preload("Reporter").First(&report, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// HasReported reports whether a user has already reported a message
+func (r *ChatModerationRepository) HasReported(threadType models.ChatThreadType, messageID, reporterID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ChatMessageReport{}).
+		Where("thread_type = ? AND message_id = ? AND reporter_id = ?", threadType, messageID, reporterID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetReports gets chat message reports with filters, newest first
+func (r *ChatModerationRepository) GetReports(filters *ChatReportFilters) ([]models.ChatMessageReport, *Pagination, error) {
+	var reports []models.ChatMessageReport
+	var total int64
+
+	query := r.db.Model(&models.ChatMessageReport{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Source != "" {
+		query = query.Where("source = ?", filters.Source)
+	}
+	if filters.Reason != "" {
+		query = query.Where("reason = ?", filters.Reason)
+	}
+	if filters.ThreadType != "" {
+		query = query.Where("thread_type = ?", filters.ThreadType)
+	}
+	if filters.SenderID != nil {
+		query = query.Where("sender_id = ?", *filters.SenderID)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 10
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Sender").Preload("Reporter").
+		Order("created_at DESC").
+		Offset(offset).Limit(filters.Limit).
+		Find(&reports).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return reports, pagination, nil
+}
+
+// CreateMute creates a new chat mute
+func (r *ChatModerationRepository) CreateMute(mute *models.ChatMute) error {
+	return r.db.Create(mute).Error
+}
+
+// UpdateMute updates a chat mute
+func (r *ChatModerationRepository) UpdateMute(mute *models.ChatMute) error {
+	return r.db.Save(mute).Error
+}
+
+// GetMuteByID gets a chat mute by ID
+func (r *ChatModerationRepository) GetMuteByID(id uint) (*models.ChatMute, error) {
+	var mute models.ChatMute
+	err := r.db.Preload("User").First(&mute, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &mute, nil
+}
+
+// GetActiveMute gets the mute that lasts longest among those stopping a user from sending in a thread, nil when
+// the user is not muted there
+func (r *ChatModerationRepository) GetActiveMute(userID uint, threadType models.ChatThreadType, threadID uint) (*models.ChatMute, error) {
+	var mute models.ChatMute
+	err := r.db.Where("user_id = ? AND lifted_at IS NULL AND (muted_until IS NULL OR muted_until > ?)", userID, time.Now()).
+		Where("thread_type IS NULL OR (thread_type = ? AND thread_id = ?)", threadType, threadID).
+		Order("muted_until DESC NULLS FIRST").
+		First(&mute).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mute, nil
+}
+
+// GetActiveMutes gets the mutes in force, optionally of one user, newest first
+func (r *ChatModerationRepository) GetActiveMutes(userID *uint, page, limit int) ([]models.ChatMute, *Pagination, error) {
+	var mutes []models.ChatMute
+	var total int64
+
+	query := r.db.Model(&models.ChatMute{}).
+		Where("lifted_at IS NULL AND (muted_until IS NULL OR muted_until > ?)", time.Now())
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	err = query.Preload("User").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&mutes).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	pagination := &Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return mutes, pagination, nil
+}
+
+// ChatReportFilters represents filters for the chat moderation review queue
+type ChatReportFilters struct {
+	Status     string `json:"status"`
+	Source     string `json:"source"`
+	Reason     string `json:"reason"`
+	ThreadType string `json:"thread_type"`
+	SenderID   *uint  `json:"sender_id"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+}
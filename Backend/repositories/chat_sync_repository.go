@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChatSyncRepository handles chat receipts, sync cursors and reading messages after a cursor
+type ChatSyncRepository struct {
+	db *gorm.DB
+}
+
+// NewChatSyncRepository creates a new chat sync repository
+func NewChatSyncRepository() *ChatSyncRepository {
+	return &ChatSyncRepository{
+		db: database.GetDB(),
+	}
+}
+
+// chatThreadMessages returns the message table of a thread type and its thread column
+func chatThreadMessages(threadType models.ChatThreadType) (string, string, error) {
+	switch threadType {
+	case models.ChatThreadRoom:
+		return "chat_messages", "room_id", nil
+	case models.ChatThreadConversation:
+		return "simple_conversation_messages", "conversation_id", nil
+	default:
+		return "", "", fmt.Errorf("unknown chat thread type %q", threadType)
+	}
+}
+
+// GetRoomMessagesSince gets up to limit messages of a chat room after a message ID, oldest first
+func (r *ChatSyncRepository) GetRoomMessagesSince(roomID, sinceMessageID uint, limit int) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.db.Where("room_id = ? AND id > ?", roomID, sinceMessageID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// GetConversationMessagesSince gets up to limit messages of a conversation after a message ID, oldest first
+func (r *ChatSyncRepository) GetConversationMessagesSince(conversationID, sinceMessageID uint, limit int) ([]models.SimpleConversationMessage, error) {
+	var messages []models.SimpleConversationMessage
+	err := r.db.Where("conversation_id = ? AND id > ?", conversationID, sinceMessageID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// GetCursor gets the sync cursor of a user's device in a thread, nil when the device has not synced it
+func (r *ChatSyncRepository) GetCursor(userID uint, deviceID string, threadType models.ChatThreadType, threadID uint) (*models.ChatSyncCursor, error) {
+	var cursor models.ChatSyncCursor
+	err := r.db.Where("user_id = ? AND device_id = ? AND thread_type = ? AND thread_id = ?", userID, deviceID, threadType, threadID).
+		First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// AdvanceCursor moves the sync cursor of a user's device in a thread forward to a message. A cursor never moves back.
+func (r *ChatSyncRepository) AdvanceCursor(userID uint, deviceID string, threadType models.ChatThreadType, threadID, messageID uint) error {
+	cursor := &models.ChatSyncCursor{
+		UserID:        userID,
+		DeviceID:      deviceID,
+		ThreadType:    threadType,
+		ThreadID:      threadID,
+		LastMessageID: messageID,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "device_id"}, {Name: "thread_type"}, {Name: "thread_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_message_id": gorm.Expr("GREATEST(chat_sync_cursors.last_message_id, EXCLUDED.last_message_id)"),
+			"updated_at":      time.Now(),
+		}),
+	}).Create(cursor).Error
+}
+
+// MarkReceipts records that a user received, or read, the messages of a thread up to a message that others
+// sent. It returns the IDs of the messages whose receipt changed.
+func (r *ChatSyncRepository) MarkReceipts(threadType models.ChatThreadType, threadID, userID, upToMessageID uint, status models.ReceiptStatus, at time.Time) ([]uint, error) {
+	table, threadColumn, err := chatThreadMessages(threadType)
+	if err != nil {
+		return nil, err
+	}
+	var readAt *time.Time
+	if status == models.ReceiptStatusRead {
+		readAt = &at
+	}
+
+	var messageIDs []uint
+	err = r.db.Raw(fmt.Sprintf(`
+		INSERT INTO chat_message_receipts (thread_type, thread_id, message_id, user_id, delivered_at, read_at, created_at, updated_at)
+		SELECT ?, ?, m.id, ?, ?, ?, ?, ?
+		FROM %s m
+		WHERE m.%s = ? AND m.id <= ? AND m.sender_id != ? AND m.deleted_at IS NULL
+		ON CONFLICT (thread_type, message_id, user_id) DO UPDATE SET
+			delivered_at = COALESCE(chat_message_receipts.delivered_at, EXCLUDED.delivered_at),
+			read_at = COALESCE(chat_message_receipts.read_at, EXCLUDED.read_at),
+			updated_at = EXCLUDED.updated_at
+		WHERE chat_message_receipts.read_at IS NULL AND (EXCLUDED.read_at IS NOT NULL OR chat_message_receipts.delivered_at IS NULL)
+		RETURNING message_id`, table, threadColumn),
+		threadType, threadID, userID, at, readAt, at, at,
+		threadID, upToMessageID, userID,
+	).Scan(&messageIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return messageIDs, nil
+}
+
+// MarkRoomMessagesRead sets the read flags chat rooms keep on their messages
+func (r *ChatSyncRepository) MarkRoomMessagesRead(messageIDs []uint, userID uint) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	return r.db.Exec(`
+		UPDATE chat_messages
+		SET is_read = TRUE, read_by = COALESCE(read_by, '[]'::jsonb) || jsonb_build_array(?::bigint), updated_at = ?
+		WHERE id IN ? AND NOT COALESCE(read_by, '[]'::jsonb) @> jsonb_build_array(?::bigint)`,
+		userID, time.Now(), messageIDs, userID,
+	).Error
+}
+
+// MarkConversationMessagesRead sets the read flags conversations keep on their messages
+func (r *ChatSyncRepository) MarkConversationMessagesRead(messageIDs []uint, at time.Time) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.SimpleConversationMessage{}).
+		Where("id IN ? AND is_read = ?", messageIDs, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": at}).Error
+}
+
+// GetReceipts gets the receipts of messages in a thread
+func (r *ChatSyncRepository) GetReceipts(threadType models.ChatThreadType, threadID uint, messageIDs []uint) ([]models.ChatMessageReceipt, error) {
+	var receipts []models.ChatMessageReceipt
+	if len(messageIDs) == 0 {
+		return receipts, nil
+	}
+	err := r.db.Where("thread_type = ? AND thread_id = ? AND message_id IN ?", threadType, threadID, messageIDs).
+		Order("message_id ASC, user_id ASC").
+		Find(&receipts).Error
+	return receipts, err
+}
+
+// IsDelivered reports whether a user has received a message
+func (r *ChatSyncRepository) IsDelivered(threadType models.ChatThreadType, messageID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ChatMessageReceipt{}).
+		Where("thread_type = ? AND message_id = ? AND user_id = ? AND delivered_at IS NOT NULL", threadType, messageID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -50,6 +50,20 @@ func (r *ChatbotRepository) GetSessionByUserID(userID uint) ([]models.ChatbotSes
 	return sessions, nil
 }
 
+// GetPropertyLeadSessions retrieves the latest property search sessions of logged-in users other than the given one
+func (r *ChatbotRepository) GetPropertyLeadSessions(excludeUserID uint, limit int) ([]models.ChatbotSession, error) {
+	var sessions []models.ChatbotSession
+	err := r.db.Preload("User").
+		Where("query_type = ? AND user_id IS NOT NULL AND user_id <> ? AND deleted_at IS NULL", "property", excludeUserID).
+		Order("last_message_at DESC").
+		Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 // UpdateSession updates a chatbot session
 func (r *ChatbotRepository) UpdateSession(session *models.ChatbotSession) error {
 	return r.db.Save(session).Error
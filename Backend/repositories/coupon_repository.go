@@ -0,0 +1,349 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository() *CouponRepository {
+	return &CouponRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create creates a coupon
+func (cr *CouponRepository) Create(coupon *models.Coupon) error {
+	return cr.db.Create(coupon).Error
+}
+
+// Update updates a coupon
+func (cr *CouponRepository) Update(coupon *models.Coupon) error {
+	return cr.db.Save(coupon).Error
+}
+
+// Delete deletes a coupon
+func (cr *CouponRepository) Delete(id uint) error {
+	return cr.db.Delete(&models.Coupon{}, id).Error
+}
+
+// GetByID gets a coupon by ID
+func (cr *CouponRepository) GetByID(id uint) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := cr.db.First(&coupon, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetByCode gets a coupon by its code, ignoring case
+func (cr *CouponRepository) GetByCode(code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := cr.db.Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetCoupons gets coupons with filters, with the number of redemptions of each
+func (cr *CouponRepository) GetCoupons(filters *models.CouponFilters) ([]models.Coupon, *Pagination, error) {
+	var coupons []models.Coupon
+	var total int64
+
+	query := cr.db.Model(&models.Coupon{})
+	if filters.Search != "" {
+		search := "%" + filters.Search + "%"
+		query = query.Where("code ILIKE ? OR description ILIKE ?", search, search)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&coupons).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range coupons {
+		cr.db.Model(&models.CouponRedemption{}).
+			Where("coupon_id = ? AND status = ?", coupons[i].ID, models.CouponRedemptionRedeemed).
+			Count(&coupons[i].RedemptionCount)
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return coupons, pagination, nil
+}
+
+// activeRedemptions scopes a query to redemptions that count towards usage limits:
+// redeemed ones and reservations that have not expired
+func activeRedemptions(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("(status = ? OR (status = ? AND reserved_until > ?))",
+		models.CouponRedemptionRedeemed, models.CouponRedemptionReserved, now)
+}
+
+// Reserve stores a reserved redemption after checking the coupon's usage limits with the coupon
+// row locked, so concurrent orders cannot go over the limits. A reservation the user already
+// holds for the same order is released first, since the new order replaces it.
+func (cr *CouponRepository) Reserve(redemption *models.CouponRedemption) error {
+	return cr.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, redemption.CouponID).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		replaced := tx.Model(&models.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ? AND scope = ? AND status = ?",
+				coupon.ID, redemption.UserID, redemption.Scope, models.CouponRedemptionReserved)
+		if redemption.BookingID != nil {
+			replaced = replaced.Where("booking_id = ?", *redemption.BookingID)
+		} else {
+			replaced = replaced.Where("booking_id IS NULL")
+		}
+		err = replaced.Update("status", models.CouponRedemptionReleased).Error
+		if err != nil {
+			return err
+		}
+
+		if coupon.UsageLimit > 0 {
+			var used int64
+			err = activeRedemptions(tx.Model(&models.CouponRedemption{}).Where("coupon_id = ?", coupon.ID), now).Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(coupon.UsageLimit) {
+				return errors.New("coupon usage limit reached")
+			}
+		}
+
+		if coupon.PerUserLimit > 0 {
+			var used int64
+			err = activeRedemptions(tx.Model(&models.CouponRedemption{}).Where("coupon_id = ? AND user_id = ?", coupon.ID, redemption.UserID), now).Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(coupon.PerUserLimit) {
+				return errors.New("you have already used this coupon")
+			}
+		}
+
+		return tx.Create(redemption).Error
+	})
+}
+
+// CountActiveRedemptions counts the redemptions of a coupon that count towards its limits,
+// in total and for the given user
+func (cr *CouponRepository) CountActiveRedemptions(couponID uint, userID uint) (int64, int64, error) {
+	now := time.Now()
+	var total, byUser int64
+	err := activeRedemptions(cr.db.Model(&models.CouponRedemption{}).Where("coupon_id = ?", couponID), now).Count(&total).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	err = activeRedemptions(cr.db.Model(&models.CouponRedemption{}).Where("coupon_id = ? AND user_id = ?", couponID, userID), now).Count(&byUser).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, byUser, nil
+}
+
+// GetRedemptionByID gets a redemption by ID
+func (cr *CouponRepository) GetRedemptionByID(id uint) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	err := cr.db.Preload("Coupon").First(&redemption, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// GetRedemptionByPaymentID gets the redemption applied to a payment
+func (cr *CouponRepository) GetRedemptionByPaymentID(paymentID uint) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	err := cr.db.Preload("Coupon").Where("payment_id = ?", paymentID).Order("id DESC").First(&redemption).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// GetRedeemedByBookingID gets the redeemed redemption of a booking
+func (cr *CouponRepository) GetRedeemedByBookingID(bookingID uint) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	err := cr.db.Preload("Coupon").
+		Where("booking_id = ? AND status = ?", bookingID, models.CouponRedemptionRedeemed).
+		Order("id DESC").
+		First(&redemption).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// GetRedemptions gets the redemptions of a coupon
+func (cr *CouponRepository) GetRedemptions(couponID uint, page, limit int) ([]models.CouponRedemption, *Pagination, error) {
+	var redemptions []models.CouponRedemption
+	var total int64
+
+	query := cr.db.Model(&models.CouponRedemption{}).Where("coupon_id = ?", couponID)
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	err = query.Preload("User").Order("created_at DESC").Offset(offset).Limit(limit).Find(&redemptions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	pagination := &Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return redemptions, pagination, nil
+}
+
+// AttachPayment links a reserved redemption to the payment order created for it
+func (cr *CouponRepository) AttachPayment(redemptionID uint, paymentID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).Where("id = ?", redemptionID).
+		Update("payment_id", paymentID).Error
+}
+
+// MarkRedeemed marks a redemption redeemed unless it already is. It returns whether this call redeemed it.
+func (cr *CouponRepository) MarkRedeemed(redemption *models.CouponRedemption, paymentID *uint) (bool, error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.CouponRedemptionRedeemed,
+		"redeemed_at": now,
+	}
+	if paymentID != nil {
+		updates["payment_id"] = *paymentID
+	}
+	result := cr.db.Model(&models.CouponRedemption{}).
+		Where("id = ? AND status <> ?", redemption.ID, models.CouponRedemptionRedeemed).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		redemption.Status = models.CouponRedemptionRedeemed
+		redemption.RedeemedAt = &now
+		redemption.PaymentID = paymentID
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Release releases a reserved redemption
+func (cr *CouponRepository) Release(redemptionID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).
+		Where("id = ? AND status = ?", redemptionID, models.CouponRedemptionReserved).
+		Update("status", models.CouponRedemptionReleased).Error
+}
+
+// ReleaseByPaymentID releases the reserved redemption of a payment
+func (cr *CouponRepository) ReleaseByPaymentID(paymentID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).
+		Where("payment_id = ? AND status = ?", paymentID, models.CouponRedemptionReserved).
+		Update("status", models.CouponRedemptionReleased).Error
+}
+
+// ReleaseByBookingID releases the reserved redemptions of a booking
+func (cr *CouponRepository) ReleaseByBookingID(bookingID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).
+		Where("booking_id = ? AND status = ?", bookingID, models.CouponRedemptionReserved).
+		Update("status", models.CouponRedemptionReleased).Error
+}
+
+// ClaimCashback moves a redemption's cashback from pending to credited. It returns false when the
+// cashback is not pending, so the caller that gets true is the only one that credits it.
+func (cr *CouponRepository) ClaimCashback(redemptionID uint) (bool, error) {
+	result := cr.db.Model(&models.CouponRedemption{}).
+		Where("id = ? AND status = ? AND cashback_status = ?", redemptionID, models.CouponRedemptionRedeemed, models.CashbackStatusPending).
+		Update("cashback_status", models.CashbackStatusCredited)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetCashbackPayment records the wallet payment of a credited cashback
+func (cr *CouponRepository) SetCashbackPayment(redemptionID uint, paymentID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).Where("id = ?", redemptionID).
+		Update("cashback_payment_id", paymentID).Error
+}
+
+// UnclaimCashback puts a claimed cashback back to pending after crediting it failed
+func (cr *CouponRepository) UnclaimCashback(redemptionID uint) error {
+	return cr.db.Model(&models.CouponRedemption{}).
+		Where("id = ? AND cashback_status = ? AND cashback_payment_id IS NULL", redemptionID, models.CashbackStatusCredited).
+		Update("cashback_status", models.CashbackStatusPending).Error
+}
+
+// CountUserBookings counts the user's bookings that went ahead, other than the given booking,
+// for first booking coupons
+func (cr *CouponRepository) CountUserBookings(userID uint, excludeBookingID uint) (int64, error) {
+	var count int64
+	err := cr.db.Model(&models.Booking{}).
+		Where("user_id = ? AND id <> ? AND status IN ?", userID, excludeBookingID, []models.BookingStatus{
+			models.BookingStatusConfirmed,
+			models.BookingStatusScheduled,
+			models.BookingStatusPartiallyPaid,
+			models.BookingStatusAssigned,
+			models.BookingStatusInProgress,
+			models.BookingStatusCompleted,
+		}).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EntitlementUsageRepository handles entitlement usage metering
+type EntitlementUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewEntitlementUsageRepository creates a new entitlement usage repository
+func NewEntitlementUsageRepository() *EntitlementUsageRepository {
+	return &EntitlementUsageRepository{
+		db: database.GetDB(),
+	}
+}
+
+// GetUsed gets how much of an entitlement a user consumed in a period
+func (ur *EntitlementUsageRepository) GetUsed(userID uint, key models.EntitlementKey, periodStart time.Time) (int, error) {
+	var usage models.EntitlementUsage
+	err := ur.db.Where("user_id = ? AND entitlement_key = ? AND period_start = ?", userID, key, periodStart).
+		Limit(1).Find(&usage).Error
+	return usage.Used, err
+}
+
+// Consume uses one of an entitlement in a period unless that would go over limit.
+// A negative limit is unlimited. The check and the increment are one statement, so
+// concurrent requests cannot go over the limit together.
+func (ur *EntitlementUsageRepository) Consume(userID uint, key models.EntitlementKey, periodStart time.Time, limit int) (bool, error) {
+	consumed := false
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		usage := &models.EntitlementUsage{
+			UserID:         userID,
+			EntitlementKey: key,
+			PeriodStart:    periodStart,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(usage).Error; err != nil {
+			return err
+		}
+
+		query := tx.Model(&models.EntitlementUsage{}).
+			Where("user_id = ? AND entitlement_key = ? AND period_start = ?", userID, key, periodStart)
+		if limit >= 0 {
+			query = query.Where("used < ?", limit)
+		}
+		result := query.Update("used", gorm.Expr("used + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		consumed = result.RowsAffected == 1
+		return nil
+	})
+	return consumed, err
+}
+
+// Release gives back one use of an entitlement in a period
+func (ur *EntitlementUsageRepository) Release(userID uint, key models.EntitlementKey, periodStart time.Time) error {
+	return ur.db.Model(&models.EntitlementUsage{}).
+		Where("user_id = ? AND entitlement_key = ? AND period_start = ? AND used > 0", userID, key, periodStart).
+		Update("used", gorm.Expr("used - 1")).Error
+}
+
+// GetUsageByUser gets a user's metered usage, most recent period first
+func (ur *EntitlementUsageRepository) GetUsageByUser(userID uint) ([]models.EntitlementUsage, error) {
+	var usages []models.EntitlementUsage
+	err := ur.db.Where("user_id = ?", userID).
+		Order("period_start DESC, entitlement_key ASC").
+		Find(&usages).Error
+	return usages, err
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository() *InvoiceRepository {
+	return &InvoiceRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create takes the next number of the invoice's document type and financial year and stores the
+// invoice in the same transaction, so a failed insert never leaves a gap in the numbering.
+// format turns the sequence number into the printed invoice number.
+func (ir *InvoiceRepository) Create(invoice *models.Invoice, format func(sequence int) string) error {
+	return ir.db.Transaction(func(tx *gorm.DB) error {
+		var sequence int
+		err := tx.Raw(`INSERT INTO invoice_sequences (financial_year, document_type, last_number, updated_at)
+			VALUES (?, ?, 1, NOW())
+			ON CONFLICT (financial_year, document_type)
+			DO UPDATE SET last_number = invoice_sequences.last_number + 1, updated_at = NOW()
+			RETURNING last_number`, invoice.FinancialYear, invoice.DocumentType).Scan(&sequence).Error
+		if err != nil {
+			return err
+		}
+
+		invoice.SequenceNumber = sequence
+		invoice.InvoiceNumber = format(sequence)
+		return tx.Create(invoice).Error
+	})
+}
+
+// GetByID gets an invoice by ID
+func (ir *InvoiceRepository) GetByID(id uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := ir.db.Preload("OriginalInvoice").First(&invoice, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetByPaymentID gets the invoice issued for a payment
+func (ir *InvoiceRepository) GetByPaymentID(paymentID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := ir.db.Preload("OriginalInvoice").Where("payment_id = ?", paymentID).First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetInvoices gets invoices with filters
+func (ir *InvoiceRepository) GetInvoices(filters *models.InvoiceFilters) ([]models.Invoice, *Pagination, error) {
+	var invoices []models.Invoice
+	var total int64
+
+	query := ir.db.Model(&models.Invoice{})
+	if filters.UserID != 0 {
+		query = query.Where("user_id = ?", filters.UserID)
+	}
+	if filters.DocumentType != "" {
+		query = query.Where("document_type = ?", filters.DocumentType)
+	}
+	if filters.From != nil {
+		query = query.Where("issued_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("issued_at < ?", *filters.To)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Order("issued_at DESC, id DESC").Offset(offset).Limit(filters.Limit).Find(&invoices).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return invoices, pagination, nil
+}
+
+// GetIssuedBetween gets every invoice issued in [from, to), grouped by document type in number order
+func (ir *InvoiceRepository) GetIssuedBetween(from, to time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := ir.db.Preload("OriginalInvoice").
+		Where("issued_at >= ? AND issued_at < ?", from, to).
+		Order("document_type, financial_year, sequence_number").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// GetPaymentsWithoutInvoice gets payments of the given types, not made by the excluded methods,
+// completed since the given time that have no invoice yet, oldest first
+func (ir *InvoiceRepository) GetPaymentsWithoutInvoice(types []models.PaymentType, excludedMethods []string, since time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := ir.db.Preload("User").
+		Where("type IN ? AND status IN ? AND completed_at >= ?", types,
+			[]models.PaymentStatus{models.PaymentStatusCompleted, models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded}, since).
+		Where("method NOT IN ?", excludedMethods).
+		Where("NOT EXISTS (SELECT 1 FROM invoices WHERE invoices.payment_id = payments.id)").
+		Order("completed_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// GetTaxCodeByCategoryID gets the tax code of a category
+func (ir *InvoiceRepository) GetTaxCodeByCategoryID(categoryID uint) (*models.CategoryTaxCode, error) {
+	var taxCode models.CategoryTaxCode
+	err := ir.db.Where("category_id = ?", categoryID).First(&taxCode).Error
+	if err != nil {
+		return nil, err
+	}
+	return &taxCode, nil
+}
+
+// GetTaxCodes gets the tax codes of all categories
+func (ir *InvoiceRepository) GetTaxCodes() ([]models.CategoryTaxCode, error) {
+	var taxCodes []models.CategoryTaxCode
+	err := ir.db.Preload("Category").Order("category_id").Find(&taxCodes).Error
+	return taxCodes, err
+}
+
+// SaveTaxCode creates or updates a category tax code
+func (ir *InvoiceRepository) SaveTaxCode(taxCode *models.CategoryTaxCode) error {
+	return ir.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "category_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"sac_code", "gst_rate", "updated_at"}),
+	}).Create(taxCode).Error
+}
+
+// GetBillingDetails gets a user's billing details
+func (ir *InvoiceRepository) GetBillingDetails(userID uint) (*models.BillingDetails, error) {
+	var details models.BillingDetails
+	err := ir.db.Where("user_id = ?", userID).First(&details).Error
+	if err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// SaveBillingDetails creates or updates a user's billing details
+func (ir *InvoiceRepository) SaveBillingDetails(details *models.BillingDetails) error {
+	return ir.db.Save(details).Error
+}
@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LedgerJournalRepository handles the chart of accounts, double-entry journals and accounting periods
+type LedgerJournalRepository struct {
+	*BaseRepository
+}
+
+// NewLedgerJournalRepository creates a new ledger journal repository
+func NewLedgerJournalRepository() *LedgerJournalRepository {
+	return &LedgerJournalRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Account Operations
+
+// GetAccounts gets the chart of accounts ordered by code
+func (jr *LedgerJournalRepository) GetAccounts(includeInactive bool) ([]models.LedgerAccount, error) {
+	var accounts []models.LedgerAccount
+	query := jr.db.Order("code")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&accounts).Error
+	return accounts, err
+}
+
+// GetAccountByID gets an account by ID
+func (jr *LedgerJournalRepository) GetAccountByID(id uint) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	if err := jr.db.First(&account, id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetAccountByCode gets an account by code
+func (jr *LedgerJournalRepository) GetAccountByCode(code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	if err := jr.db.Where("code = ?", code).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// CreateAccount adds an account to the chart of accounts
+func (jr *LedgerJournalRepository) CreateAccount(account *models.LedgerAccount) error {
+	return jr.db.Create(account).Error
+}
+
+// UpdateAccount updates an account
+func (jr *LedgerJournalRepository) UpdateAccount(account *models.LedgerAccount) error {
+	return jr.db.Save(account).Error
+}
+
+// Journal Operations
+
+// PostJournal creates a journal with its postings in one transaction. A journal whose reference
+// was already posted is skipped and false is returned, so platform events are posted once.
+func (jr *LedgerJournalRepository) PostJournal(journal *models.LedgerJournal) (bool, error) {
+	posted := false
+	err := jr.db.Transaction(func(tx *gorm.DB) error {
+		postings := journal.Postings
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(journal)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		for i := range postings {
+			postings[i].JournalID = journal.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&postings).Error; err != nil {
+			return err
+		}
+		journal.Postings = postings
+		posted = true
+		return nil
+	})
+	return posted, err
+}
+
+// GetJournalByID gets a journal with its postings and attachments
+func (jr *LedgerJournalRepository) GetJournalByID(id uint) (*models.LedgerJournal, error) {
+	var journal models.LedgerJournal
+	err := jr.db.Preload("Postings.Account").Preload("Attachments").Preload("Vendor").First(&journal, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// GetReversal gets the journal that reverses the given one
+func (jr *LedgerJournalRepository) GetReversal(journalID uint) (*models.LedgerJournal, error) {
+	var journal models.LedgerJournal
+	if err := jr.db.Where("reversal_of_id = ?", journalID).First(&journal).Error; err != nil {
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// GetJournals gets journals with filters, newest first
+func (jr *LedgerJournalRepository) GetJournals(filters *models.LedgerJournalFilters) ([]models.LedgerJournal, int64, error) {
+	var journals []models.LedgerJournal
+	var total int64
+
+	query := jr.db.Model(&models.LedgerJournal{})
+	if filters.SourceType != "" {
+		query = query.Where("source_type = ?", filters.SourceType)
+	}
+	if filters.VendorID != 0 {
+		query = query.Where("vendor_id = ?", filters.VendorID)
+	}
+	if filters.AccountCode != "" {
+		query = query.Where("id IN (SELECT ledger_postings.journal_id FROM ledger_postings JOIN ledger_accounts ON ledger_accounts.id = ledger_postings.account_id WHERE ledger_accounts.code = ?)", filters.AccountCode)
+	}
+	if filters.From != nil {
+		query = query.Where("entry_date >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("entry_date < ?", *filters.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Postings.Account").
+		Order("entry_date DESC, id DESC").
+		Offset(filters.Offset).Limit(filters.Limit).
+		Find(&journals).Error
+	return journals, total, err
+}
+
+// GetAccountTotals sums the debits and credits of every account over journals dated in [from, to).
+// A nil from sums from the beginning.
+func (jr *LedgerJournalRepository) GetAccountTotals(from *time.Time, to time.Time) ([]models.LedgerAccountTotal, error) {
+	var totals []models.LedgerAccountTotal
+	query := jr.db.Table("ledger_postings").
+		Select("ledger_accounts.id AS account_id, ledger_accounts.code, ledger_accounts.name, ledger_accounts.account_type, COALESCE(SUM(ledger_postings.debit), 0) AS debit, COALESCE(SUM(ledger_postings.credit), 0) AS credit").
+		Joins("JOIN ledger_journals ON ledger_journals.id = ledger_postings.journal_id").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = ledger_postings.account_id").
+		Where("ledger_journals.entry_date < ?", to)
+	if from != nil {
+		query = query.Where("ledger_journals.entry_date >= ?", *from)
+	}
+	err := query.Group("ledger_accounts.id, ledger_accounts.code, ledger_accounts.name, ledger_accounts.account_type").
+		Order("ledger_accounts.code").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// Platform Events
+
+// GetUnpostedPayments gets completed payments and refunds since the given time that have no journal yet, oldest first
+func (jr *LedgerJournalRepository) GetUnpostedPayments(since time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := jr.db.Where("status IN ? AND completed_at >= ?",
+		[]models.PaymentStatus{models.PaymentStatusCompleted, models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded}, since).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_journals WHERE ledger_journals.source_type = ? AND ledger_journals.source_id = payments.id)",
+			models.LedgerJournalSourcePayment).
+		Order("completed_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// GetUnpostedEarnings gets worker earnings accrued since the given time that have no journal yet, oldest first
+func (jr *LedgerJournalRepository) GetUnpostedEarnings(since time.Time, limit int) ([]models.WorkerEarning, error) {
+	var earnings []models.WorkerEarning
+	err := jr.db.Where("earned_at >= ?", since).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_journals WHERE ledger_journals.source_type = ? AND ledger_journals.source_id = worker_earnings.id)",
+			models.LedgerJournalSourceWorkerEarning).
+		Order("earned_at").
+		Limit(limit).
+		Find(&earnings).Error
+	return earnings, err
+}
+
+// GetUnpostedPayouts gets worker payouts paid since the given time that have no journal yet, oldest first
+func (jr *LedgerJournalRepository) GetUnpostedPayouts(since time.Time, limit int) ([]models.WorkerPayout, error) {
+	var payouts []models.WorkerPayout
+	err := jr.db.Where("status = ? AND paid_at >= ?", models.PayoutStatusPaid, since).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_journals WHERE ledger_journals.source_type = ? AND ledger_journals.source_id = worker_payouts.id)",
+			models.LedgerJournalSourceWorkerPayout).
+		Order("paid_at").
+		Limit(limit).
+		Find(&payouts).Error
+	return payouts, err
+}
+
+// Accounting Period Operations
+
+// GetPeriods gets the accounting periods that have a record, newest first
+func (jr *LedgerJournalRepository) GetPeriods() ([]models.AccountingPeriod, error) {
+	var periods []models.AccountingPeriod
+	err := jr.db.Order("period_start DESC").Find(&periods).Error
+	return periods, err
+}
+
+// GetPeriodByStart gets the accounting period starting at the given time
+func (jr *LedgerJournalRepository) GetPeriodByStart(periodStart time.Time) (*models.AccountingPeriod, error) {
+	var period models.AccountingPeriod
+	if err := jr.db.Where("period_start = ?", periodStart).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// SavePeriod creates or updates an accounting period
+func (jr *LedgerJournalRepository) SavePeriod(period *models.AccountingPeriod) error {
+	return jr.db.Save(period).Error
+}
+
+// IsClosed reports whether the given time falls in a closed accounting period
+func (jr *LedgerJournalRepository) IsClosed(at time.Time) (bool, error) {
+	var count int64
+	err := jr.db.Model(&models.AccountingPeriod{}).
+		Where("status = ? AND period_start <= ? AND period_end > ?", models.AccountingPeriodStatusClosed, at, at).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Attachment Operations
+
+// CreateAttachment creates a ledger attachment
+func (jr *LedgerJournalRepository) CreateAttachment(attachment *models.LedgerAttachment) error {
+	return jr.db.Create(attachment).Error
+}
+
+// GetAttachmentByID gets a ledger attachment by ID
+func (jr *LedgerJournalRepository) GetAttachmentByID(id uint) (*models.LedgerAttachment, error) {
+	var attachment models.LedgerAttachment
+	if err := jr.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DeleteAttachment soft deletes a ledger attachment
+func (jr *LedgerJournalRepository) DeleteAttachment(id uint) error {
+	return jr.db.Delete(&models.LedgerAttachment{}, id).Error
+}
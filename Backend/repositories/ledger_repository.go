@@ -3,6 +3,8 @@ package repositories
 import (
 	"fmt"
 	"treesindia/models"
+
+	"gorm.io/gorm/clause"
 )
 
 type LedgerRepository struct {
@@ -25,7 +27,7 @@ func (lr *LedgerRepository) Create(entry *models.LedgerEntry) error {
 // GetByID gets a ledger entry by ID
 func (lr *LedgerRepository) GetByID(id uint) (*models.LedgerEntry, error) {
 	var entry models.LedgerEntry
-	err := lr.db.Preload("CreatedByUser").Preload("UpdatedByUser").First(&entry, id).Error
+	err := lr.db.Preload("CreatedByUser").Preload("UpdatedByUser").Preload("Account").Preload("Vendor").Preload("Attachments").First(&entry, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +36,7 @@ func (lr *LedgerRepository) GetByID(id uint) (*models.LedgerEntry, error) {
 
 // Update updates a ledger entry
 func (lr *LedgerRepository) Update(entry *models.LedgerEntry) error {
-	return lr.db.Save(entry).Error
+	return lr.db.Omit(clause.Associations).Save(entry).Error
 }
 
 // Delete soft deletes a ledger entry
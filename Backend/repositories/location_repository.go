@@ -82,6 +82,14 @@ func (lr *LocationRepository) FindLocationsWithinRadius(locations *[]models.Loca
 	return lr.db.Raw(query, lat, lng, lat, radiusKm).Scan(locations).Error
 }
 
+// FindLocationsByUserIDs finds the locations of the given users
+func (lr *LocationRepository) FindLocationsByUserIDs(locations *[]models.Location, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return lr.db.Where("user_id IN ?", userIDs).Find(locations).Error
+}
+
 // ExistsByUserID checks if a location exists for a user
 func (lr *LocationRepository) ExistsByUserID(userID uint) (bool, error) {
 	return lr.Exists(&models.Location{}, "user_id", userID)
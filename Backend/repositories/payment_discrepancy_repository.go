@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+// PaymentDiscrepancyRepository handles payment discrepancies found by reconciliation
+type PaymentDiscrepancyRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentDiscrepancyRepository creates a new payment discrepancy repository
+func NewPaymentDiscrepancyRepository() *PaymentDiscrepancyRepository {
+	return &PaymentDiscrepancyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Flag records a discrepancy, updating the open discrepancy of the same type for the payment if
+// there is one. It reports whether a new discrepancy was created.
+func (dr *PaymentDiscrepancyRepository) Flag(discrepancy *models.PaymentDiscrepancy, now time.Time) (bool, error) {
+	query := dr.db.Where("discrepancy_type = ? AND status = ?", discrepancy.DiscrepancyType, models.PaymentDiscrepancyStatusOpen)
+	if discrepancy.PaymentID != nil {
+		query = query.Where("payment_id = ?", *discrepancy.PaymentID)
+	} else {
+		query = query.Where("payment_id IS NULL AND gateway_payment_id = ?", discrepancy.GatewayPaymentID)
+	}
+
+	var existing models.PaymentDiscrepancy
+	err := query.First(&existing).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		discrepancy.Status = models.PaymentDiscrepancyStatusOpen
+		discrepancy.DetectedAt = now
+		discrepancy.LastCheckedAt = now
+		return true, dr.db.Create(discrepancy).Error
+	}
+
+	existing.GatewayPaymentID = discrepancy.GatewayPaymentID
+	existing.GatewayOrderID = discrepancy.GatewayOrderID
+	existing.LocalStatus = discrepancy.LocalStatus
+	existing.GatewayStatus = discrepancy.GatewayStatus
+	existing.LocalAmount = discrepancy.LocalAmount
+	existing.GatewayAmount = discrepancy.GatewayAmount
+	existing.Details = discrepancy.Details
+	existing.LastCheckedAt = now
+	return false, dr.db.Save(&existing).Error
+}
+
+// ResolveSettled resolves open not-settled discrepancies of payments whose settlement has since been recorded
+func (dr *PaymentDiscrepancyRepository) ResolveSettled(now time.Time) (int64, error) {
+	result := dr.db.Model(&models.PaymentDiscrepancy{}).
+		Where("status = ? AND discrepancy_type = ?", models.PaymentDiscrepancyStatusOpen, models.PaymentDiscrepancyNotSettled).
+		Where("payment_id IN (SELECT id FROM payments WHERE gateway_settlement_id IS NOT NULL)").
+		Updates(map[string]interface{}{
+			"status":           models.PaymentDiscrepancyStatusResolved,
+			"resolved_at":      now,
+			"last_checked_at":  now,
+			"resolution_notes": "Settlement received",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Resolve marks an open discrepancy resolved by an admin. It reports whether the discrepancy was open.
+func (dr *PaymentDiscrepancyRepository) Resolve(id uint, adminID uint, notes string, now time.Time) (bool, error) {
+	result := dr.db.Model(&models.PaymentDiscrepancy{}).
+		Where("id = ? AND status = ?", id, models.PaymentDiscrepancyStatusOpen).
+		Updates(map[string]interface{}{
+			"status":           models.PaymentDiscrepancyStatusResolved,
+			"resolved_at":      now,
+			"resolved_by":      adminID,
+			"resolution_notes": notes,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetByID gets a payment discrepancy with its payment
+func (dr *PaymentDiscrepancyRepository) GetByID(id uint) (*models.PaymentDiscrepancy, error) {
+	var discrepancy models.PaymentDiscrepancy
+	if err := dr.db.Preload("Payment").First(&discrepancy, id).Error; err != nil {
+		return nil, err
+	}
+	return &discrepancy, nil
+}
+
+// GetDiscrepancies gets payment discrepancies with filters
+func (dr *PaymentDiscrepancyRepository) GetDiscrepancies(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, *Pagination, error) {
+	var discrepancies []models.PaymentDiscrepancy
+	var total int64
+
+	query := dr.filteredQuery(filters)
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Payment").Order("detected_at DESC").Offset(offset).Limit(filters.Limit).Find(&discrepancies).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return discrepancies, pagination, nil
+}
+
+// GetDiscrepanciesForExport gets every payment discrepancy matching the filters, without pagination
+func (dr *PaymentDiscrepancyRepository) GetDiscrepanciesForExport(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, error) {
+	var discrepancies []models.PaymentDiscrepancy
+	err := dr.filteredQuery(filters).Preload("Payment").Order("detected_at DESC").Find(&discrepancies).Error
+	return discrepancies, err
+}
+
+// filteredQuery applies discrepancy filters
+func (dr *PaymentDiscrepancyRepository) filteredQuery(filters *models.PaymentDiscrepancyFilters) *gorm.DB {
+	query := dr.db.Model(&models.PaymentDiscrepancy{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.DiscrepancyType != "" {
+		query = query.Where("discrepancy_type = ?", filters.DiscrepancyType)
+	}
+	return query
+}
@@ -1,12 +1,14 @@
 package repositories
 
 import (
+	"strconv"
 	"strings"
 	"time"
 	"treesindia/database"
 	"treesindia/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository struct {
@@ -19,6 +21,11 @@ func NewPaymentRepository() *PaymentRepository {
 	}
 }
 
+// WithTx returns a payment repository that works inside the transaction tx
+func (pr *PaymentRepository) WithTx(tx *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{db: tx}
+}
+
 // Create creates a new payment
 func (pr *PaymentRepository) Create(payment *models.Payment) error {
 	return pr.db.Create(payment).Error
@@ -34,6 +41,25 @@ func (pr *PaymentRepository) GetByID(id uint) (*models.Payment, error) {
 	return &payment, nil
 }
 
+// GetByIDForUpdate gets a payment and locks its row until the transaction ends
+func (pr *PaymentRepository) GetByIDForUpdate(id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := pr.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CountRefundRecords counts the refund records of a payment, failed ones included
+func (pr *PaymentRepository) CountRefundRecords(paymentID uint) (int64, error) {
+	var count int64
+	err := pr.db.Model(&models.Payment{}).
+		Where("type = ? AND metadata->>'original_payment_id' = ?", models.PaymentTypeRefund, strconv.FormatUint(uint64(paymentID), 10)).
+		Count(&count).Error
+	return count, err
+}
+
 // GetByReference gets a payment by reference
 func (pr *PaymentRepository) GetByReference(reference string) (*models.Payment, error) {
 	var payment models.Payment
@@ -44,20 +70,51 @@ func (pr *PaymentRepository) GetByReference(reference string) (*models.Payment,
 	return &payment, nil
 }
 
-// GetByRazorpayOrderID gets a payment by Razorpay order ID
-func (pr *PaymentRepository) GetByRazorpayOrderID(orderID string) (*models.Payment, error) {
+// GetByGatewayOrderID gets a payment by its payment gateway order ID
+func (pr *PaymentRepository) GetByGatewayOrderID(orderID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := pr.db.Preload("User").Where("gateway_order_id = ?", orderID).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetByGatewayPaymentID gets a payment by its payment gateway payment ID
+func (pr *PaymentRepository) GetByGatewayPaymentID(paymentID string) (*models.Payment, error) {
 	var payment models.Payment
-	err := pr.db.Preload("User").Where("razorpay_order_id = ?", orderID).First(&payment).Error
+	err := pr.db.Preload("User").Where("gateway_payment_id = ?", paymentID).First(&payment).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-// GetByRazorpayPaymentID gets a payment by Razorpay payment ID
-func (pr *PaymentRepository) GetByRazorpayPaymentID(paymentID string) (*models.Payment, error) {
+// CreateRefundRecord stores the refund record of a gateway refund unless the refund already has one,
+// in which case the existing record is loaded into refund. It returns whether the record was created by this call.
+func (pr *PaymentRepository) CreateRefundRecord(refund *models.Payment) (bool, error) {
+	result := pr.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "(metadata->>'gateway_refund_id')", Raw: true}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "type = 'refund' AND deleted_at IS NULL"}}},
+		DoNothing:   true,
+	}).Create(refund)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	refundID := (*refund.Metadata)["gateway_refund_id"]
+	*refund = models.Payment{}
+	err := pr.db.Where("type = ? AND metadata->>'gateway_refund_id' = ?", models.PaymentTypeRefund, refundID).First(refund).Error
+	return false, err
+}
+
+// GetByGatewayRefundID gets the refund record of a payment gateway refund
+func (pr *PaymentRepository) GetByGatewayRefundID(refundID string) (*models.Payment, error) {
 	var payment models.Payment
-	err := pr.db.Preload("User").Where("razorpay_payment_id = ?", paymentID).First(&payment).Error
+	err := pr.db.Preload("User").Where("type = ? AND metadata->>'gateway_refund_id' = ?", models.PaymentTypeRefund, refundID).First(&payment).Error
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +131,16 @@ func (pr *PaymentRepository) GetByRelatedEntity(entityType string, entityID uint
 	return &payment, nil
 }
 
+// GetCompletedByBooking gets all completed, non-refund payments made for a booking, including partially refunded ones
+func (pr *PaymentRepository) GetCompletedByBooking(bookingID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := pr.db.Where("related_entity_type = ? AND related_entity_id = ? AND status IN ? AND type <> ?",
+		"booking", bookingID, []models.PaymentStatus{models.PaymentStatusCompleted, models.PaymentStatusPartiallyRefunded}, models.PaymentTypeRefund).
+		Order("created_at ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
 // GetPayments gets payments with filters and pagination
 func (pr *PaymentRepository) GetPayments(filters *models.PaymentFilters) ([]models.Payment, *Pagination, error) {
 	var payments []models.Payment
@@ -173,6 +240,30 @@ func (pr *PaymentRepository) UpdateStatus(paymentID uint, status models.PaymentS
 		}).Error
 }
 
+// CompleteIfUnsettled marks a payment completed unless it already is, using the gateway fields
+// already set on it. It reports whether this call completed the payment, so that when the
+// client callback and the gateway webhook race only one of them runs the completion logic.
+func (pr *PaymentRepository) CompleteIfUnsettled(payment *models.Payment) (bool, error) {
+	result := pr.db.Model(&models.Payment{}).
+		Where("id = ? AND status IN ?", payment.ID, []models.PaymentStatus{
+			models.PaymentStatusPending,
+			models.PaymentStatusFailed,
+			models.PaymentStatusAbandoned,
+			models.PaymentStatusExpired,
+		}).
+		Updates(map[string]interface{}{
+			"status":             models.PaymentStatusCompleted,
+			"gateway_payment_id": payment.GatewayPaymentID,
+			"gateway_signature":  payment.GatewaySignature,
+			"completed_at":       payment.CompletedAt,
+			"notes":              payment.Notes,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // GetPaymentStats gets payment statistics
 func (pr *PaymentRepository) GetPaymentStats(userID *uint) (map[string]interface{}, error) {
 	query := pr.db.Model(&models.Payment{})
@@ -213,14 +304,14 @@ func (pr *PaymentRepository) GetPaymentStats(userID *uint) (map[string]interface
 
 	// Refunded payments
 	var refundedPayments int64
-	err = query.Where("status = ?", models.PaymentStatusRefunded).Count(&refundedPayments).Error
+	err = query.Where("status IN ?", []models.PaymentStatus{models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded}).Count(&refundedPayments).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// Total refunded amount
 	var totalRefundedAmount float64
-	err = query.Where("status = ?", models.PaymentStatusRefunded).Select("COALESCE(SUM(refund_amount), 0)").Scan(&totalRefundedAmount).Error
+	err = query.Where("status IN ?", []models.PaymentStatus{models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded}).Select("COALESCE(SUM(refund_amount), 0)").Scan(&totalRefundedAmount).Error
 	if err != nil {
 		return nil, err
 	}
@@ -291,6 +382,53 @@ func (pr *PaymentRepository) GetAbandonedWalletPayments(cutoffTime time.Time) ([
 	return payments, err
 }
 
+// GetReconcilablePayments gets gateway payments created in [from, to) that are not settled locally:
+// pending ones and those that failed, expired or were abandoned, which the gateway may still have captured
+func (pr *PaymentRepository) GetReconcilablePayments(from, to time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := pr.db.Where("gateway_provider IS NOT NULL AND gateway_order_id IS NOT NULL AND type <> ?", models.PaymentTypeRefund).
+		Where("status IN ?", []models.PaymentStatus{
+			models.PaymentStatusPending,
+			models.PaymentStatusFailed,
+			models.PaymentStatusAbandoned,
+			models.PaymentStatusExpired,
+		}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// GetUnsettledPayments gets captured Razorpay payments completed in [from, to) with no settlement recorded
+func (pr *PaymentRepository) GetUnsettledPayments(from, to time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := pr.db.Where("gateway_provider = ? AND gateway_payment_id IS NOT NULL AND gateway_settlement_id IS NULL", "razorpay").
+		Where("status IN ?", []models.PaymentStatus{models.PaymentStatusCompleted, models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded}).
+		Where("completed_at >= ? AND completed_at < ?", from, to).
+		Order("completed_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// RecordSettlement stores the Razorpay settlement of a payment unless one is already recorded.
+// It reports whether the settlement was recorded.
+func (pr *PaymentRepository) RecordSettlement(paymentID uint, settlementID string, settledAt time.Time, fee, tax float64) (bool, error) {
+	result := pr.db.Model(&models.Payment{}).
+		Where("id = ? AND gateway_settlement_id IS NULL", paymentID).
+		Updates(map[string]interface{}{
+			"gateway_settlement_id": settlementID,
+			"settled_at":            settledAt,
+			"gateway_fee":           fee,
+			"gateway_tax":           tax,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // GetByUserIDAndTypesAndStatus gets payments for a user by type(s) and status
 func (pr *PaymentRepository) GetByUserIDAndTypesAndStatus(userID uint, paymentTypes []models.PaymentType, status models.PaymentStatus, limit, offset int) ([]models.Payment, error) {
 	var payments []models.Payment
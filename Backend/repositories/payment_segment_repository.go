@@ -1,12 +1,16 @@
 package repositories
 
 import (
+	"time"
 	"treesindia/database"
 	"treesindia/models"
 
 	"gorm.io/gorm"
 )
 
+// unpaidSegmentStatuses are the statuses of segments the customer still has to pay
+var unpaidSegmentStatuses = []models.PaymentSegmentStatus{models.PaymentSegmentStatusPending, models.PaymentSegmentStatusOverdue}
+
 type PaymentSegmentRepository struct {
 	db *gorm.DB
 }
@@ -62,10 +66,10 @@ func (psr *PaymentSegmentRepository) GetByBookingIDAndSegmentNumber(bookingID ui
 	return &segment, nil
 }
 
-// GetPendingSegments gets all pending segments for a booking
+// GetPendingSegments gets all unpaid (pending or overdue) segments for a booking
 func (psr *PaymentSegmentRepository) GetPendingSegments(bookingID uint) ([]models.PaymentSegment, error) {
 	var segments []models.PaymentSegment
-	err := psr.db.Where("booking_id = ? AND status = ?", bookingID, models.PaymentSegmentStatusPending).
+	err := psr.db.Where("booking_id = ? AND status IN ?", bookingID, unpaidSegmentStatuses).
 		Order("segment_number ASC").
 		Find(&segments).Error
 	return segments, err
@@ -141,6 +145,8 @@ func (psr *PaymentSegmentRepository) GetPaymentProgress(bookingID uint) (*models
 			PaidAt:        segment.PaidAt,
 			Notes:         segment.Notes,
 			PaymentID:     segment.PaymentID,
+			IsOverdue:     segment.Status == models.PaymentSegmentStatusOverdue,
+			AutoDebit:     segment.AutoDebit,
 		}
 
 		if segment.Status == models.PaymentSegmentStatusPaid {
@@ -181,9 +187,122 @@ func (psr *PaymentSegmentRepository) CountSegmentsByStatus(bookingID uint, statu
 
 // IsAllSegmentsPaid checks if all segments for a booking are paid
 func (psr *PaymentSegmentRepository) IsAllSegmentsPaid(bookingID uint) (bool, error) {
-	pendingCount, err := psr.CountSegmentsByStatus(bookingID, models.PaymentSegmentStatusPending)
+	var unpaidCount int64
+	err := psr.db.Model(&models.PaymentSegment{}).
+		Where("booking_id = ? AND status IN ?", bookingID, unpaidSegmentStatuses).
+		Count(&unpaidCount).Error
 	if err != nil {
 		return false, err
 	}
-	return pendingCount == 0, nil
+	return unpaidCount == 0, nil
+}
+
+// GetSegmentsDueBefore gets pending segments that fall due between now and the given time
+func (psr *PaymentSegmentRepository) GetSegmentsDueBefore(now time.Time, before time.Time) ([]models.PaymentSegment, error) {
+	var segments []models.PaymentSegment
+	err := psr.db.Where("status = ? AND due_date > ? AND due_date <= ?", models.PaymentSegmentStatusPending, now, before).
+		Preload("Booking").
+		Order("due_date ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// GetPastDueSegments gets pending segments whose due date has passed
+func (psr *PaymentSegmentRepository) GetPastDueSegments(now time.Time) ([]models.PaymentSegment, error) {
+	var segments []models.PaymentSegment
+	err := psr.db.Where("status = ? AND due_date <= ?", models.PaymentSegmentStatusPending, now).
+		Preload("Booking").
+		Order("due_date ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// GetSegmentsToEscalate gets overdue segments not yet escalated that became overdue before the given time
+func (psr *PaymentSegmentRepository) GetSegmentsToEscalate(overdueBefore time.Time) ([]models.PaymentSegment, error) {
+	var segments []models.PaymentSegment
+	err := psr.db.Where("status = ? AND escalated_at IS NULL AND overdue_at <= ?", models.PaymentSegmentStatusOverdue, overdueBefore).
+		Preload("Booking").
+		Order("overdue_at ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// GetSegmentsForAutoDebit gets unpaid auto-debit segments that are due and were not attempted since retryBefore
+func (psr *PaymentSegmentRepository) GetSegmentsForAutoDebit(now time.Time, retryBefore time.Time) ([]models.PaymentSegment, error) {
+	var segments []models.PaymentSegment
+	err := psr.db.Where("auto_debit = ? AND status IN ? AND due_date <= ?", true, unpaidSegmentStatuses, now).
+		Where("auto_debit_attempted_at IS NULL OR auto_debit_attempted_at <= ?", retryBefore).
+		Preload("Booking").
+		Order("due_date ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// ClaimAutoDebit records an auto-debit attempt; it returns false when another run attempted it since retryBefore
+func (psr *PaymentSegmentRepository) ClaimAutoDebit(segmentID uint, retryBefore time.Time) (bool, error) {
+	result := psr.db.Model(&models.PaymentSegment{}).
+		Where("id = ? AND status IN ?", segmentID, unpaidSegmentStatuses).
+		Where("auto_debit_attempted_at IS NULL OR auto_debit_attempted_at <= ?", retryBefore).
+		Update("auto_debit_attempted_at", psr.db.NowFunc())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetAutoDebitFailure records why the last auto-debit attempt failed
+func (psr *PaymentSegmentRepository) SetAutoDebitFailure(segmentID uint, failure string) error {
+	return psr.db.Model(&models.PaymentSegment{}).
+		Where("id = ?", segmentID).
+		Update("auto_debit_failure", failure).Error
+}
+
+// SetAutoDebit turns auto-debit on or off for the unpaid segments of a booking, or only the given segment
+func (psr *PaymentSegmentRepository) SetAutoDebit(bookingID uint, segmentNumber *int, enabled bool) (int64, error) {
+	query := psr.db.Model(&models.PaymentSegment{}).
+		Where("booking_id = ? AND status IN ?", bookingID, unpaidSegmentStatuses)
+	if segmentNumber != nil {
+		query = query.Where("segment_number = ?", *segmentNumber)
+	}
+
+	updates := map[string]interface{}{"auto_debit": enabled}
+	if enabled {
+		// Opting in again allows an immediate retry
+		updates["auto_debit_attempted_at"] = nil
+		updates["auto_debit_failure"] = ""
+	}
+	result := query.Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+// MarkOverdue moves a pending segment to overdue; it returns false when the segment is no longer pending
+func (psr *PaymentSegmentRepository) MarkOverdue(segmentID uint) (bool, error) {
+	result := psr.db.Model(&models.PaymentSegment{}).
+		Where("id = ? AND status = ?", segmentID, models.PaymentSegmentStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.PaymentSegmentStatusOverdue,
+			"overdue_at": psr.db.NowFunc(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkEscalated records that an overdue segment was escalated; it returns false when it already was
+func (psr *PaymentSegmentRepository) MarkEscalated(segmentID uint) (bool, error) {
+	result := psr.db.Model(&models.PaymentSegment{}).
+		Where("id = ? AND status = ? AND escalated_at IS NULL", segmentID, models.PaymentSegmentStatusOverdue).
+		Update("escalated_at", psr.db.NowFunc())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetRemindersSent records how many reminders have been sent for a segment
+func (psr *PaymentSegmentRepository) SetRemindersSent(segmentID uint, remindersSent int) error {
+	return psr.db.Model(&models.PaymentSegment{}).
+		Where("id = ?", segmentID).
+		Update("reminders_sent", remindersSent).Error
 }
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentWebhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentWebhookEventRepository() *PaymentWebhookEventRepository {
+	return &PaymentWebhookEventRepository{
+		db: database.GetDB(),
+	}
+}
+
+// CreateIfNotExists stores an event unless one with the same provider and event ID exists.
+// It returns the stored event and whether it was created by this call.
+func (wr *PaymentWebhookEventRepository) CreateIfNotExists(event *models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error) {
+	result := wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return event, true, nil
+	}
+
+	existing, err := wr.GetByEventID(event.Provider, event.EventID)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// GetByID gets a webhook event by ID
+func (wr *PaymentWebhookEventRepository) GetByID(id uint) (*models.PaymentWebhookEvent, error) {
+	var event models.PaymentWebhookEvent
+	err := wr.db.First(&event, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetByEventID gets a webhook event by provider and gateway event ID
+func (wr *PaymentWebhookEventRepository) GetByEventID(provider string, eventID string) (*models.PaymentWebhookEvent, error) {
+	var event models.PaymentWebhookEvent
+	err := wr.db.Where("provider = ? AND event_id = ?", provider, eventID).First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Claim marks an event as processing when it is in one of the given statuses, or has been processing
+// since before staleBefore because the server processing it stopped. Only one caller can claim an event,
+// so concurrent deliveries are not processed twice.
+func (wr *PaymentWebhookEventRepository) Claim(id uint, from []models.WebhookEventStatus, staleBefore time.Time) (bool, error) {
+	result := wr.db.Model(&models.PaymentWebhookEvent{}).
+		Where("id = ? AND (status IN ? OR (status = ? AND updated_at < ?))", id, from, models.WebhookEventStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":   models.WebhookEventStatusProcessing,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Update updates a webhook event
+func (wr *PaymentWebhookEventRepository) Update(event *models.PaymentWebhookEvent) error {
+	return wr.db.Save(event).Error
+}
+
+// GetEvents gets webhook events with filters
+func (wr *PaymentWebhookEventRepository) GetEvents(filters *models.WebhookEventFilters) ([]models.PaymentWebhookEvent, *Pagination, error) {
+	var events []models.PaymentWebhookEvent
+	var total int64
+
+	query := wr.db.Model(&models.PaymentWebhookEvent{})
+
+	// Apply filters
+	if filters.Provider != "" {
+		query = query.Where("provider = ?", filters.Provider)
+	}
+	if filters.EventType != "" {
+		query = query.Where("event_type = ?", filters.EventType)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&events).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return events, pagination, nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+// PendingRefundRepository handles cancellation refunds waiting to be retried
+type PendingRefundRepository struct {
+	db *gorm.DB
+}
+
+// NewPendingRefundRepository creates a new pending refund repository
+func NewPendingRefundRepository() *PendingRefundRepository {
+	return &PendingRefundRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create stores a pending refund
+func (rr *PendingRefundRepository) Create(refund *models.PendingRefund) error {
+	return rr.db.Create(refund).Error
+}
+
+// Update saves a pending refund
+func (rr *PendingRefundRepository) Update(refund *models.PendingRefund) error {
+	return rr.db.Save(refund).Error
+}
+
+// GetDue gets pending refunds whose next attempt is due, oldest first
+func (rr *PendingRefundRepository) GetDue(now time.Time, limit int) ([]models.PendingRefund, error) {
+	var refunds []models.PendingRefund
+	err := rr.db.Where("status = ? AND next_attempt_at <= ?", models.PendingRefundStatusPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// CountPending counts the refunds of a booking that have not been issued yet
+func (rr *PendingRefundRepository) CountPending(bookingID uint) (int64, error) {
+	var count int64
+	err := rr.db.Model(&models.PendingRefund{}).
+		Where("booking_id = ? AND status = ?", bookingID, models.PendingRefundStatusPending).
+		Count(&count).Error
+	return count, err
+}
+
+// GetPendingRefunds gets pending refunds with their bookings
+func (rr *PendingRefundRepository) GetPendingRefunds(filters *models.PendingRefundFilters) ([]models.PendingRefund, *Pagination, error) {
+	var refunds []models.PendingRefund
+	var total int64
+
+	query := rr.db.Model(&models.PendingRefund{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Booking").Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&refunds).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return refunds, pagination, nil
+}
@@ -413,7 +413,10 @@ func (pr *PropertyRepository) applyFilters(query *gorm.DB, filters map[string]in
 			query = query.Order(sortByStr + " " + sortOrder)
 		}
 	} else {
-		// Default sorting by created_at desc
+		// Featured listings first on public listings, then by priority score and created_at desc
+		if !isAdmin {
+			query = query.Order("is_featured DESC").Order("priority_score DESC")
+		}
 		query = query.Order("created_at DESC")
 	}
 	
@@ -654,3 +657,31 @@ func (pr *PropertyRepository) GetPropertyCountByUserID(userID uint) (int, error)
 	logrus.Infof("PropertyRepository.GetPropertyCountByUserID found %d properties for user %d", count, userID)
 	return int(count), nil
 }
+
+// activeListingCondition matches listings that are available and not expired
+const activeListingCondition = "status = ? AND (expires_at IS NULL OR expires_at > ?)"
+
+// CountActiveByUserID counts a user's available, unexpired listings
+func (pr *PropertyRepository) CountActiveByUserID(userID uint) (int, error) {
+	var count int64
+	err := pr.GetDB().Model(&models.Property{}).
+		Where("user_id = ?", userID).
+		Where(activeListingCondition, models.PropertyStatusAvailable, time.Now()).
+		Count(&count).Error
+	return int(count), err
+}
+
+// CountFeaturedByUserID counts a user's featured listings that are still active
+func (pr *PropertyRepository) CountFeaturedByUserID(userID uint) (int, error) {
+	var count int64
+	err := pr.GetDB().Model(&models.Property{}).
+		Where("user_id = ? AND is_featured = ?", userID, true).
+		Where(activeListingCondition, models.PropertyStatusAvailable, time.Now()).
+		Count(&count).Error
+	return int(count), err
+}
+
+// SetFeatured features or unfeatures a listing
+func (pr *PropertyRepository) SetFeatured(id uint, featured bool) error {
+	return pr.GetDB().Model(&models.Property{}).Where("id = ?", id).Update("is_featured", featured).Error
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type ReferralRepository struct {
+	db *gorm.DB
+}
+
+func NewReferralRepository() *ReferralRepository {
+	return &ReferralRepository{
+		db: database.GetDB(),
+	}
+}
+
+// GetCodeByUserID gets the referral code of a user
+func (rr *ReferralRepository) GetCodeByUserID(userID uint) (*models.ReferralCode, error) {
+	var code models.ReferralCode
+	err := rr.db.Where("user_id = ?", userID).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// GetCodeByCode gets a referral code by its code
+func (rr *ReferralRepository) GetCodeByCode(code string) (*models.ReferralCode, error) {
+	var referralCode models.ReferralCode
+	err := rr.db.Where("code = ?", code).First(&referralCode).Error
+	if err != nil {
+		return nil, err
+	}
+	return &referralCode, nil
+}
+
+// CreateCode creates a referral code
+func (rr *ReferralRepository) CreateCode(code *models.ReferralCode) error {
+	return rr.db.Create(code).Error
+}
+
+// Create creates a referral
+func (rr *ReferralRepository) Create(referral *models.Referral) error {
+	return rr.db.Create(referral).Error
+}
+
+// GetByRefereeID gets the referral of a referee
+func (rr *ReferralRepository) GetByRefereeID(refereeID uint) (*models.Referral, error) {
+	var referral models.Referral
+	err := rr.db.Where("referee_id = ?", refereeID).First(&referral).Error
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// GetByReferrerID gets the referrals made by a user, newest first
+func (rr *ReferralRepository) GetByReferrerID(referrerID uint) ([]models.Referral, error) {
+	var referrals []models.Referral
+	err := rr.db.Preload("Referee").Where("referrer_id = ?", referrerID).Order("created_at DESC").Find(&referrals).Error
+	return referrals, err
+}
+
+// GetReferrals gets referrals with filters
+func (rr *ReferralRepository) GetReferrals(filters *models.ReferralFilters) ([]models.Referral, *Pagination, error) {
+	var referrals []models.Referral
+	var total int64
+
+	query := rr.db.Model(&models.Referral{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Referrer").Preload("Referee").Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&referrals).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return referrals, pagination, nil
+}
+
+// ClaimReward moves a pending referral to rewarded for the given booking and rewards. It returns
+// false when the referral is no longer pending, so only one caller credits the wallets.
+func (rr *ReferralRepository) ClaimReward(referralID uint, bookingID uint, referrerReward, refereeReward float64) (bool, error) {
+	result := rr.db.Model(&models.Referral{}).
+		Where("id = ? AND status = ?", referralID, models.ReferralStatusPending).
+		Updates(map[string]interface{}{
+			"status":          models.ReferralStatusRewarded,
+			"booking_id":      bookingID,
+			"referrer_reward": referrerReward,
+			"referee_reward":  refereeReward,
+			"rewarded_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UnclaimReward puts a claimed referral back to pending after crediting the wallets failed
+func (rr *ReferralRepository) UnclaimReward(referralID uint) error {
+	return rr.db.Model(&models.Referral{}).
+		Where("id = ? AND status = ?", referralID, models.ReferralStatusRewarded).
+		Updates(map[string]interface{}{
+			"status":      models.ReferralStatusPending,
+			"booking_id":  nil,
+			"rewarded_at": nil,
+		}).Error
+}
+
+// SetRewardPayments records the wallet payments of a rewarded referral
+func (rr *ReferralRepository) SetRewardPayments(referralID uint, referrerPaymentID, refereePaymentID *uint) error {
+	return rr.db.Model(&models.Referral{}).Where("id = ?", referralID).
+		Updates(map[string]interface{}{
+			"referrer_payment_id": referrerPaymentID,
+			"referee_payment_id":  refereePaymentID,
+		}).Error
+}
+
+// CountCompletedBookings counts a user's completed bookings
+func (rr *ReferralRepository) CountCompletedBookings(userID uint) (int64, error) {
+	var count int64
+	err := rr.db.Model(&models.Booking{}).
+		Where("user_id = ? AND status = ?", userID, models.BookingStatusCompleted).
+		Count(&count).Error
+	return count, err
+}
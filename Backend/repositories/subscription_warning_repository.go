@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscriptionWarningRepository handles subscription warning database operations
+type SubscriptionWarningRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriptionWarningRepository creates a new subscription warning repository
+func NewSubscriptionWarningRepository() *SubscriptionWarningRepository {
+	return &SubscriptionWarningRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Exists checks if the warning for the given days before expiry was already sent for a subscription
+func (swr *SubscriptionWarningRepository) Exists(subscriptionID uint, daysLeft int) (bool, error) {
+	var count int64
+	err := swr.db.Model(&models.SubscriptionWarning{}).
+		Where("subscription_id = ? AND days_left = ?", subscriptionID, daysLeft).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Create records a sent warning; recording the same warning twice is a no-op
+func (swr *SubscriptionWarningRepository) Create(warning *models.SubscriptionWarning) error {
+	return swr.db.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(warning).Error
+}
@@ -35,11 +35,18 @@ func (usr *UserSubscriptionRepository) GetByID(id uint) (*models.UserSubscriptio
 	return &subscription, nil
 }
 
+// renewableSubscriptionStatuses are the statuses of a term that can still be renewed or changed
+var renewableSubscriptionStatuses = []string{models.SubscriptionStatusActive, models.SubscriptionStatusGrace}
+
 // GetActiveByUserID retrieves active subscription for a user
 func (usr *UserSubscriptionRepository) GetActiveByUserID(userID uint) (*models.UserSubscription, error) {
 	var subscription models.UserSubscription
+	now := time.Now()
 	err := usr.db.Preload("Plan").
-		Where("user_id = ? AND status = ? AND end_date > ?", userID, models.SubscriptionStatusActive, time.Now()).
+		Where("user_id = ?", userID).
+		Where("((status = ? AND end_date > ?) OR (status = ? AND grace_ends_at > ?))",
+			models.SubscriptionStatusActive, now, models.SubscriptionStatusGrace, now).
+		Order("end_date DESC").
 		First(&subscription).Error
 	if err != nil {
 		return nil, err
@@ -47,6 +54,16 @@ func (usr *UserSubscriptionRepository) GetActiveByUserID(userID uint) (*models.U
 	return &subscription, nil
 }
 
+// GetByPaymentID retrieves the subscription bought with the given gateway payment ID
+func (usr *UserSubscriptionRepository) GetByPaymentID(paymentID string) (*models.UserSubscription, error) {
+	var subscription models.UserSubscription
+	err := usr.db.Preload("Plan").Where("payment_id = ?", paymentID).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
 // GetAllByUserID retrieves all subscriptions for a user
 func (usr *UserSubscriptionRepository) GetAllByUserID(userID uint) ([]models.UserSubscription, error) {
 	var subscriptions []models.UserSubscription
@@ -115,8 +132,11 @@ func (usr *UserSubscriptionRepository) GetByStatus(status string) ([]models.User
 // HasActiveSubscription checks if a user has an active subscription
 func (usr *UserSubscriptionRepository) HasActiveSubscription(userID uint) (bool, error) {
 	var count int64
+	now := time.Now()
 	err := usr.db.Model(&models.UserSubscription{}).
-		Where("user_id = ? AND status = ? AND end_date > ?", userID, models.SubscriptionStatusActive, time.Now()).
+		Where("user_id = ?", userID).
+		Where("((status = ? AND end_date > ?) OR (status = ? AND grace_ends_at > ?))",
+			models.SubscriptionStatusActive, now, models.SubscriptionStatusGrace, now).
 		Count(&count).Error
 	
 	if err != nil {
@@ -125,3 +145,137 @@ func (usr *UserSubscriptionRepository) HasActiveSubscription(userID uint) (bool,
 	
 	return count > 0, nil
 }
+
+// GetDueForRenewal gets auto-renewing subscriptions ending before renewBefore that were not attempted since retryBefore
+func (usr *UserSubscriptionRepository) GetDueForRenewal(renewBefore, retryBefore time.Time) ([]models.UserSubscription, error) {
+	var subscriptions []models.UserSubscription
+	err := usr.db.Preload("User").Preload("Plan").
+		Where("auto_renew = ? AND status IN ? AND end_date <= ?", true, renewableSubscriptionStatuses, renewBefore).
+		Where("(renewal_attempted_at IS NULL OR renewal_attempted_at <= ?)", retryBefore).
+		Order("end_date ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ClaimRenewal records a renewal attempt unless another one was made since retryBefore
+func (usr *UserSubscriptionRepository) ClaimRenewal(subscriptionID uint, retryBefore time.Time) (bool, error) {
+	result := usr.db.Model(&models.UserSubscription{}).
+		Where("id = ? AND status IN ?", subscriptionID, renewableSubscriptionStatuses).
+		Where("(renewal_attempted_at IS NULL OR renewal_attempted_at <= ?)", retryBefore).
+		Update("renewal_attempted_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetRenewalFailure records why an auto-renewal failed and the Razorpay order the customer can pay instead
+func (usr *UserSubscriptionRepository) SetRenewalFailure(subscriptionID uint, failure string, renewalPaymentID *uint) error {
+	return usr.db.Model(&models.UserSubscription{}).Where("id = ?", subscriptionID).Updates(map[string]interface{}{
+		"renewal_failure":    failure,
+		"renewal_payment_id": renewalPaymentID,
+	}).Error
+}
+
+// SetAutoRenew turns auto-renewal of a subscription on or off
+func (usr *UserSubscriptionRepository) SetAutoRenew(subscriptionID uint, autoRenew bool) error {
+	return usr.db.Model(&models.UserSubscription{}).Where("id = ?", subscriptionID).Update("auto_renew", autoRenew).Error
+}
+
+// GetGraceEnded gets subscriptions whose grace period is over
+func (usr *UserSubscriptionRepository) GetGraceEnded(now time.Time) ([]models.UserSubscription, error) {
+	var subscriptions []models.UserSubscription
+	err := usr.db.Preload("User").Preload("Plan").
+		Where("status = ? AND grace_ends_at <= ?", models.SubscriptionStatusGrace, now).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// StartGrace moves an ended subscription into its grace period and keeps the user's access until it ends
+func (usr *UserSubscriptionRepository) StartGrace(subscription *models.UserSubscription, graceEndsAt time.Time) (bool, error) {
+	updated := false
+	err := usr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserSubscription{}).
+			Where("id = ? AND status = ?", subscription.ID, models.SubscriptionStatusActive).
+			Updates(map[string]interface{}{
+				"status":        models.SubscriptionStatusGrace,
+				"grace_ends_at": graceEndsAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+
+		// The user's access may already have lapsed when the term ended before the job ran
+		return tx.Model(&models.User{}).
+			Where("id = ? AND (subscription_id = ? OR subscription_id IS NULL)", subscription.UserID, subscription.ID).
+			Updates(map[string]interface{}{
+				"has_active_subscription":  true,
+				"subscription_expiry_date": graceEndsAt,
+				"subscription_id":          subscription.ID,
+			}).Error
+	})
+	return updated, err
+}
+
+// Expire expires a subscription that is still in the given status and clears the user's access if it was their current one
+func (usr *UserSubscriptionRepository) Expire(subscription *models.UserSubscription, fromStatus string) (bool, error) {
+	updated := false
+	err := usr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserSubscription{}).
+			Where("id = ? AND status = ?", subscription.ID, fromStatus).
+			Update("status", models.SubscriptionStatusExpired)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+
+		return tx.Model(&models.User{}).Where("id = ? AND subscription_id = ?", subscription.UserID, subscription.ID).Updates(map[string]interface{}{
+			"has_active_subscription":  false,
+			"subscription_expiry_date": nil,
+			"subscription_id":          nil,
+		}).Error
+	})
+	return updated, err
+}
+
+// StartTerm creates a subscription term and makes it the user's current one. When previous is set it is
+// closed with previousStatus in the same transaction, and false is returned if it was already renewed or changed.
+func (usr *UserSubscriptionRepository) StartTerm(subscription *models.UserSubscription, previous *models.UserSubscription, previousStatus string) (bool, error) {
+	started := false
+	err := usr.db.Transaction(func(tx *gorm.DB) error {
+		if previous != nil {
+			result := tx.Model(&models.UserSubscription{}).
+				Where("id = ? AND status IN ?", previous.ID, renewableSubscriptionStatuses).
+				Update("status", previousStatus)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return nil
+			}
+			subscription.PreviousSubscriptionID = &previous.ID
+		}
+
+		if err := tx.Omit("User", "Plan").Create(subscription).Error; err != nil {
+			return err
+		}
+		started = true
+
+		return tx.Model(&models.User{}).Where("id = ?", subscription.UserID).Updates(map[string]interface{}{
+			"has_active_subscription":  true,
+			"subscription_expiry_date": subscription.EndDate,
+			"subscription_id":          subscription.ID,
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return started, nil
+}
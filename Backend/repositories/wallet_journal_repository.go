@@ -0,0 +1,302 @@
+package repositories
+
+import (
+	"math"
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// walletBalanceTolerance is the largest difference treated as equal when comparing balances
+const walletBalanceTolerance = 0.005
+
+type WalletJournalRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletJournalRepository() *WalletJournalRepository {
+	return &WalletJournalRepository{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a wallet journal repository that posts inside the transaction tx
+func (wr *WalletJournalRepository) WithTx(tx *gorm.DB) *WalletJournalRepository {
+	return &WalletJournalRepository{db: tx}
+}
+
+// Post applies a wallet credit or debit. In one transaction it locks the user's row, lets check
+// validate the new balance, updates the cached balance, saves the payment and writes the user
+// wallet leg and its balancing leg. It returns the new balance.
+func (wr *WalletJournalRepository) Post(posting *WalletPosting, check func(current, next float64) error) (float64, error) {
+	var newBalance float64
+
+	err := wr.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "wallet_balance").First(&user, posting.UserID).Error; err != nil {
+			return err
+		}
+
+		newBalance = roundRupees(user.WalletBalance + posting.Amount)
+		if check != nil {
+			if err := check(user.WalletBalance, newBalance); err != nil {
+				return err
+			}
+		}
+
+		// wallet_balance is create-only on the model so it cannot be overwritten by a stale Save
+		if err := tx.Exec("UPDATE users SET wallet_balance = ?, updated_at = ? WHERE id = ?", newBalance, time.Now(), posting.UserID).Error; err != nil {
+			return err
+		}
+
+		payment := posting.Payment
+		if payment != nil {
+			payment.BalanceAfter = &newBalance
+			if payment.ID == 0 {
+				if err := tx.Create(payment).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Save(payment).Error; err != nil {
+				return err
+			}
+		}
+
+		amount := roundRupees(math.Abs(posting.Amount))
+		userID := posting.UserID
+		walletLeg := models.WalletJournalEntry{
+			TransactionRef: posting.TransactionRef,
+			Account:        models.WalletAccountUserWallet,
+			UserID:         &userID,
+			EntryType:      posting.EntryType,
+			BalanceAfter:   &newBalance,
+			Description:    posting.Description,
+			CreatedBy:      posting.CreatedBy,
+		}
+		counterLeg := models.WalletJournalEntry{
+			TransactionRef: posting.TransactionRef,
+			Account:        posting.CounterAccount,
+			EntryType:      posting.EntryType,
+			Description:    posting.Description,
+			CreatedBy:      posting.CreatedBy,
+		}
+		if payment != nil {
+			walletLeg.PaymentID = &payment.ID
+			counterLeg.PaymentID = &payment.ID
+			if walletLeg.TransactionRef == "" {
+				walletLeg.TransactionRef = payment.PaymentReference
+				counterLeg.TransactionRef = payment.PaymentReference
+			}
+		}
+		// The user wallet is a liability: credits increase the balance, debits reduce it
+		if posting.Amount >= 0 {
+			walletLeg.Credit = amount
+			counterLeg.Debit = amount
+		} else {
+			walletLeg.Debit = amount
+			counterLeg.Credit = amount
+		}
+
+		return tx.Create([]*models.WalletJournalEntry{&walletLeg, &counterLeg}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newBalance, nil
+}
+
+// GetJournalBalance derives a user's wallet balance from their journal
+func (wr *WalletJournalRepository) GetJournalBalance(userID uint) (float64, error) {
+	var balance float64
+	err := wr.db.Model(&models.WalletJournalEntry{}).
+		Where("user_id = ? AND account = ?", userID, models.WalletAccountUserWallet).
+		Select("COALESCE(SUM(credit - debit), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// GetUserEntries gets a user's wallet journal entries, newest first
+func (wr *WalletJournalRepository) GetUserEntries(userID uint, page, limit int) ([]models.WalletJournalEntry, *Pagination, error) {
+	var entries []models.WalletJournalEntry
+	var total int64
+
+	query := wr.db.Model(&models.WalletJournalEntry{}).
+		Where("user_id = ? AND account = ?", userID, models.WalletAccountUserWallet)
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	err = query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	pagination := &Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return entries, pagination, nil
+}
+
+// CountUsers counts the users whose wallets are reconciled
+func (wr *WalletJournalRepository) CountUsers() (int64, error) {
+	var count int64
+	err := wr.db.Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
+// GetBalanceMismatches gets users whose cached wallet balance differs from their journal balance
+func (wr *WalletJournalRepository) GetBalanceMismatches() ([]models.WalletBalanceCheck, error) {
+	var checks []models.WalletBalanceCheck
+	err := wr.db.Raw(`
+		SELECT u.id AS user_id,
+		       COALESCE(u.wallet_balance, 0) AS cached_balance,
+		       COALESCE(j.balance, 0) AS journal_balance
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, SUM(credit - debit) AS balance
+			FROM wallet_journal_entries
+			WHERE account = ?
+			GROUP BY user_id
+		) j ON j.user_id = u.id
+		WHERE u.deleted_at IS NULL
+		  AND ABS(COALESCE(u.wallet_balance, 0) - COALESCE(j.balance, 0)) > ?
+		ORDER BY u.id`, models.WalletAccountUserWallet, walletBalanceTolerance).
+		Scan(&checks).Error
+	return checks, err
+}
+
+// GetUnbalancedTransactions gets journal transactions whose debits and credits do not match
+func (wr *WalletJournalRepository) GetUnbalancedTransactions() ([]string, error) {
+	var refs []string
+	err := wr.db.Model(&models.WalletJournalEntry{}).
+		Group("transaction_ref").
+		Having("ABS(SUM(debit) - SUM(credit)) > ?", walletBalanceTolerance).
+		Pluck("transaction_ref", &refs).Error
+	return refs, err
+}
+
+// FlagDiscrepancy records a mismatch for a user, updating the user's open discrepancy if there is one
+func (wr *WalletJournalRepository) FlagDiscrepancy(check models.WalletBalanceCheck, now time.Time) (bool, error) {
+	var existing models.WalletBalanceDiscrepancy
+	err := wr.db.Where("user_id = ? AND status = ?", check.UserID, models.WalletDiscrepancyStatusOpen).First(&existing).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	difference := roundRupees(check.CachedBalance - check.JournalBalance)
+	if err == gorm.ErrRecordNotFound {
+		discrepancy := &models.WalletBalanceDiscrepancy{
+			UserID:         check.UserID,
+			CachedBalance:  check.CachedBalance,
+			JournalBalance: check.JournalBalance,
+			Difference:     difference,
+			Status:         models.WalletDiscrepancyStatusOpen,
+			DetectedAt:     now,
+			LastCheckedAt:  now,
+		}
+		return true, wr.db.Create(discrepancy).Error
+	}
+
+	existing.CachedBalance = check.CachedBalance
+	existing.JournalBalance = check.JournalBalance
+	existing.Difference = difference
+	existing.LastCheckedAt = now
+	return false, wr.db.Save(&existing).Error
+}
+
+// ResolveDiscrepancies resolves open discrepancies for users that are not in stillOpen
+func (wr *WalletJournalRepository) ResolveDiscrepancies(stillOpen []uint, now time.Time) (int64, error) {
+	query := wr.db.Model(&models.WalletBalanceDiscrepancy{}).Where("status = ?", models.WalletDiscrepancyStatusOpen)
+	if len(stillOpen) > 0 {
+		query = query.Where("user_id NOT IN ?", stillOpen)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":          models.WalletDiscrepancyStatusResolved,
+		"resolved_at":     now,
+		"last_checked_at": now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// GetDiscrepancies gets wallet balance discrepancies with filters
+func (wr *WalletJournalRepository) GetDiscrepancies(filters *models.WalletDiscrepancyFilters) ([]models.WalletBalanceDiscrepancy, *Pagination, error) {
+	var discrepancies []models.WalletBalanceDiscrepancy
+	var total int64
+
+	query := wr.db.Model(&models.WalletBalanceDiscrepancy{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.UserID != 0 {
+		query = query.Where("user_id = ?", filters.UserID)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("User").Order("detected_at DESC").Offset(offset).Limit(filters.Limit).Find(&discrepancies).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return discrepancies, pagination, nil
+}
+
+// roundRupees rounds an amount to paise
+func roundRupees(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// WalletPosting is a wallet credit or debit applied by WalletJournalRepository.Post
+type WalletPosting struct {
+	UserID         uint
+	Amount         float64 // Positive credits the wallet, negative debits it
+	CounterAccount models.WalletAccount
+	EntryType      models.WalletEntryType
+	TransactionRef string // Defaults to the payment reference
+	Description    string
+	CreatedBy      *uint
+	Payment        *models.Payment // Created, or saved when it already exists, in the same transaction
+}
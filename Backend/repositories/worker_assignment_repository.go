@@ -1,12 +1,21 @@
 package repositories
 
 import (
+	"time"
 	"treesindia/database"
 	"treesindia/models"
 
 	"gorm.io/gorm"
 )
 
+// activeAssignmentStatuses are the assignment statuses that keep a worker busy
+var activeAssignmentStatuses = []models.AssignmentStatus{
+	models.AssignmentStatusReserved,
+	models.AssignmentStatusAssigned,
+	models.AssignmentStatusAccepted,
+	models.AssignmentStatusInProgress,
+}
+
 type WorkerAssignmentRepository struct {
 	db *gorm.DB
 }
@@ -68,6 +77,57 @@ func (war *WorkerAssignmentRepository) Update(assignment *models.WorkerAssignmen
 	return war.db.Save(assignment).Error
 }
 
+// SetPaused pauses an assignment with the given reason, or resumes it when pausedAt is nil
+func (war *WorkerAssignmentRepository) SetPaused(assignmentID uint, pausedAt *time.Time, reason string) error {
+	return war.db.Model(&models.WorkerAssignment{}).
+		Where("id = ?", assignmentID).
+		Updates(map[string]interface{}{
+			"paused_at":    pausedAt,
+			"pause_reason": reason,
+		}).Error
+}
+
+// GetActiveAssignmentCounts counts the open assignments (not yet completed or rejected) of each worker
+func (war *WorkerAssignmentRepository) GetActiveAssignmentCounts(workerIDs []uint) (map[uint]int, error) {
+	counts := make(map[uint]int)
+	if len(workerIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		WorkerID uint
+		Count    int
+	}
+	err := war.db.Model(&models.WorkerAssignment{}).
+		Select("worker_id, COUNT(*) AS count").
+		Where("worker_id IN ? AND status IN ?", workerIDs, activeAssignmentStatuses).
+		Group("worker_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.WorkerID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetBusyWorkerIDs gets the workers with an open assignment whose booking overlaps the given period.
+// Bookings without an end time are treated as lasting defaultDurationMinutes.
+func (war *WorkerAssignmentRepository) GetBusyWorkerIDs(startTime, endTime time.Time, defaultDurationMinutes int) ([]uint, error) {
+	var workerIDs []uint
+	err := war.db.Model(&models.WorkerAssignment{}).
+		Joins("JOIN bookings ON bookings.id = worker_assignments.booking_id").
+		Where("worker_assignments.status IN ?", activeAssignmentStatuses).
+		Where("bookings.deleted_at IS NULL AND bookings.scheduled_time IS NOT NULL").
+		Where("bookings.scheduled_time < ?", endTime).
+		Where("COALESCE(bookings.scheduled_end_time, bookings.scheduled_time + make_interval(mins => ?)) > ?", defaultDurationMinutes, startTime).
+		Distinct("worker_assignments.worker_id").
+		Pluck("worker_assignments.worker_id", &workerIDs).Error
+	return workerIDs, err
+}
+
 // Delete deletes a worker assignment by ID
 func (war *WorkerAssignmentRepository) Delete(id uint) error {
 	return war.db.Delete(&models.WorkerAssignment{}, id).Error
@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+)
+
+type WorkerAvailabilityRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkerAvailabilityRepository() *WorkerAvailabilityRepository {
+	return &WorkerAvailabilityRepository{
+		db: database.GetDB(),
+	}
+}
+
+// GetSchedule gets a worker's weekly schedule ordered by day
+func (ar *WorkerAvailabilityRepository) GetSchedule(workerID uint) ([]models.WorkerSchedule, error) {
+	var schedules []models.WorkerSchedule
+	err := ar.db.Where("worker_id = ?", workerID).Order("day_of_week ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetSchedulesForWorkers gets the weekly schedules of several workers
+func (ar *WorkerAvailabilityRepository) GetSchedulesForWorkers(workerIDs []uint) ([]models.WorkerSchedule, error) {
+	var schedules []models.WorkerSchedule
+	if len(workerIDs) == 0 {
+		return schedules, nil
+	}
+	err := ar.db.Where("worker_id IN ?", workerIDs).Find(&schedules).Error
+	return schedules, err
+}
+
+// ReplaceSchedule replaces a worker's weekly schedule
+func (ar *WorkerAvailabilityRepository) ReplaceSchedule(workerID uint, schedules []models.WorkerSchedule) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("worker_id = ?", workerID).Delete(&models.WorkerSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+}
+
+// CreateLeave creates a leave request
+func (ar *WorkerAvailabilityRepository) CreateLeave(leave *models.WorkerLeave) error {
+	return ar.db.Create(leave).Error
+}
+
+// UpdateLeave updates a leave request
+func (ar *WorkerAvailabilityRepository) UpdateLeave(leave *models.WorkerLeave) error {
+	return ar.db.Omit("Worker").Save(leave).Error
+}
+
+// GetLeaveByID gets a leave request by ID
+func (ar *WorkerAvailabilityRepository) GetLeaveByID(id uint) (*models.WorkerLeave, error) {
+	var leave models.WorkerLeave
+	err := ar.db.Preload("Worker").First(&leave, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &leave, nil
+}
+
+// GetWorkerLeaves gets a worker's leave requests, newest first
+func (ar *WorkerAvailabilityRepository) GetWorkerLeaves(workerID uint) ([]models.WorkerLeave, error) {
+	var leaves []models.WorkerLeave
+	err := ar.db.Where("worker_id = ?", workerID).Order("start_date DESC").Find(&leaves).Error
+	return leaves, err
+}
+
+// HasOverlappingLeave checks whether a worker already has pending or approved leave overlapping the dates
+func (ar *WorkerAvailabilityRepository) HasOverlappingLeave(workerID uint, startDate, endDate time.Time) (bool, error) {
+	var count int64
+	err := ar.db.Model(&models.WorkerLeave{}).
+		Where("worker_id = ? AND status IN ?", workerID, []models.LeaveStatus{models.LeaveStatusPending, models.LeaveStatusApproved}).
+		Where("start_date <= ? AND end_date >= ?", endDate, startDate).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetApprovedLeavesInRange gets the approved leave of the workers that overlaps the dates
+func (ar *WorkerAvailabilityRepository) GetApprovedLeavesInRange(workerIDs []uint, startDate, endDate time.Time) ([]models.WorkerLeave, error) {
+	var leaves []models.WorkerLeave
+	if len(workerIDs) == 0 {
+		return leaves, nil
+	}
+	err := ar.db.Where("worker_id IN ? AND status = ?", workerIDs, models.LeaveStatusApproved).
+		Where("start_date <= ? AND end_date >= ?", endDate, startDate).
+		Find(&leaves).Error
+	return leaves, err
+}
+
+// GetLeaves gets leave requests with filters
+func (ar *WorkerAvailabilityRepository) GetLeaves(filters *WorkerLeaveFilters) ([]models.WorkerLeave, *Pagination, error) {
+	var leaves []models.WorkerLeave
+	var total int64
+
+	query := ar.db.Model(&models.WorkerLeave{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.WorkerID != nil {
+		query = query.Where("worker_id = ?", *filters.WorkerID)
+	}
+	if filters.From != nil {
+		query = query.Where("end_date >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("start_date <= ?", *filters.To)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Worker").Order("start_date ASC").Offset(offset).Limit(filters.Limit).Find(&leaves).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return leaves, pagination, nil
+}
+
+// CreateBlockedSlot creates a blocked slot
+func (ar *WorkerAvailabilityRepository) CreateBlockedSlot(slot *models.WorkerBlockedSlot) error {
+	return ar.db.Create(slot).Error
+}
+
+// GetBlockedSlotByID gets a blocked slot by ID
+func (ar *WorkerAvailabilityRepository) GetBlockedSlotByID(id uint) (*models.WorkerBlockedSlot, error) {
+	var slot models.WorkerBlockedSlot
+	err := ar.db.First(&slot, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// DeleteBlockedSlot deletes a blocked slot
+func (ar *WorkerAvailabilityRepository) DeleteBlockedSlot(id uint) error {
+	return ar.db.Delete(&models.WorkerBlockedSlot{}, id).Error
+}
+
+// GetBlockedSlotsInRange gets the blocked slots of the workers that overlap the period
+func (ar *WorkerAvailabilityRepository) GetBlockedSlotsInRange(workerIDs []uint, startTime, endTime time.Time) ([]models.WorkerBlockedSlot, error) {
+	var slots []models.WorkerBlockedSlot
+	if len(workerIDs) == 0 {
+		return slots, nil
+	}
+	err := ar.db.Where("worker_id IN ?", workerIDs).
+		Where("start_time < ? AND end_time > ?", endTime, startTime).
+		Order("start_time ASC").
+		Find(&slots).Error
+	return slots, err
+}
+
+// WorkerLeaveFilters represents filters for leave queries
+type WorkerLeaveFilters struct {
+	Status   string     `json:"status"`
+	WorkerID *uint      `json:"worker_id"`
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+	Page     int        `json:"page"`
+	Limit    int        `json:"limit"`
+}
@@ -0,0 +1,374 @@
+package repositories
+
+import (
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkerEarningRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkerEarningRepository() *WorkerEarningRepository {
+	return &WorkerEarningRepository{
+		db: database.GetDB(),
+	}
+}
+
+// CreateRule creates a commission rule
+func (er *WorkerEarningRepository) CreateRule(rule *models.CommissionRule) error {
+	return er.db.Create(rule).Error
+}
+
+// UpdateRule updates a commission rule
+func (er *WorkerEarningRepository) UpdateRule(rule *models.CommissionRule) error {
+	return er.db.Save(rule).Error
+}
+
+// DeleteRule deletes a commission rule
+func (er *WorkerEarningRepository) DeleteRule(id uint) error {
+	return er.db.Delete(&models.CommissionRule{}, id).Error
+}
+
+// GetRuleByID gets a commission rule by ID
+func (er *WorkerEarningRepository) GetRuleByID(id uint) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	err := er.db.Preload("Category").Preload("Service").First(&rule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetRules gets all commission rules
+func (er *WorkerEarningRepository) GetRules() ([]models.CommissionRule, error) {
+	var rules []models.CommissionRule
+	err := er.db.Preload("Category").Preload("Service").Order("created_at DESC").Find(&rules).Error
+	return rules, err
+}
+
+// FindRule finds the active rule for a service, preferring a service rule over a category rule
+func (er *WorkerEarningRepository) FindRule(serviceID uint, categoryID uint) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	err := er.db.Where("is_active = ? AND (service_id = ? OR (service_id IS NULL AND category_id = ?))", true, serviceID, categoryID).
+		Order("service_id IS NULL, updated_at DESC").
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateEarning stores an earning unless the booking already has one.
+// It returns whether the earning was created by this call.
+func (er *WorkerEarningRepository) CreateEarning(earning *models.WorkerEarning) (bool, error) {
+	result := er.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "booking_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoNothing:   true,
+	}).Create(earning)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetEarningByBookingID gets the earning of a booking
+func (er *WorkerEarningRepository) GetEarningByBookingID(bookingID uint) (*models.WorkerEarning, error) {
+	var earning models.WorkerEarning
+	err := er.db.Where("booking_id = ?", bookingID).First(&earning).Error
+	if err != nil {
+		return nil, err
+	}
+	return &earning, nil
+}
+
+// DeductEarning stores the amounts of an earning after a refund, as long as the earning is still
+// pending and has not been deducted since it was read. It returns whether the earning was updated.
+func (er *WorkerEarningRepository) DeductEarning(earning *models.WorkerEarning, previouslyRefunded float64) (bool, error) {
+	result := er.db.Model(&models.WorkerEarning{}).
+		Where("id = ? AND status = ? AND refunded_amount = ?", earning.ID, models.EarningStatusPending, previouslyRefunded).
+		Updates(map[string]interface{}{
+			"refunded_amount":   earning.RefundedAmount,
+			"commission_amount": earning.CommissionAmount,
+			"net_amount":        earning.NetAmount,
+			"status":            earning.Status,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetEarnings gets worker earnings with filters
+func (er *WorkerEarningRepository) GetEarnings(filters *models.WorkerEarningFilters) ([]models.WorkerEarning, *Pagination, error) {
+	var earnings []models.WorkerEarning
+	var total int64
+
+	query := er.earningsQuery(filters.WorkerID, filters.From, filters.To)
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Preload("Booking").Order("earned_at DESC").Offset(offset).Limit(filters.Limit).Find(&earnings).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return earnings, pagination, nil
+}
+
+// GetSummary totals a worker's earnings earned in the given period (nil bounds are open), net of refunds
+func (er *WorkerEarningRepository) GetSummary(workerID uint, from, to *time.Time) (*models.EarningsSummary, error) {
+	var summary models.EarningsSummary
+	err := er.earningsQuery(workerID, from, to).
+		Select(`COUNT(*) AS jobs,
+			COALESCE(SUM(gross_amount - refunded_amount), 0) AS gross_amount,
+			COALESCE(SUM(commission_amount), 0) AS commission_amount,
+			COALESCE(SUM(net_amount), 0) AS net_amount,
+			COALESCE(SUM(CASE WHEN status = ? THEN net_amount ELSE 0 END), 0) AS pending_amount,
+			COALESCE(SUM(CASE WHEN status = ? THEN net_amount ELSE 0 END), 0) AS in_payout_amount,
+			COALESCE(SUM(CASE WHEN status = ? THEN net_amount ELSE 0 END), 0) AS paid_amount`,
+			models.EarningStatusPending, models.EarningStatusInPayout, models.EarningStatusPaid).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetPendingEarnings gets pending earnings earned up to periodEnd, oldest first
+func (er *WorkerEarningRepository) GetPendingEarnings(periodEnd time.Time) ([]models.WorkerEarning, error) {
+	var earnings []models.WorkerEarning
+	err := er.db.Where("status = ? AND earned_at <= ?", models.EarningStatusPending, periodEnd).
+		Order("worker_id, earned_at").
+		Find(&earnings).Error
+	return earnings, err
+}
+
+// earningsQuery scopes earnings to a worker and an earned_at period
+func (er *WorkerEarningRepository) earningsQuery(workerID uint, from, to *time.Time) *gorm.DB {
+	query := er.db.Model(&models.WorkerEarning{})
+	if workerID != 0 {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	if from != nil {
+		query = query.Where("earned_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("earned_at < ?", *to)
+	}
+	return query
+}
+
+// CreateBatch creates a payout batch with its payouts and moves the earnings of each payout
+// into it. earningIDs holds the earning IDs of each payout, by payout index.
+func (er *WorkerEarningRepository) CreateBatch(batch *models.PayoutBatch, payouts []models.WorkerPayout, earningIDs [][]uint) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+
+		for i := range payouts {
+			payouts[i].BatchID = batch.ID
+			if err := tx.Create(&payouts[i]).Error; err != nil {
+				return err
+			}
+
+			result := tx.Model(&models.WorkerEarning{}).
+				Where("id IN ? AND status = ?", earningIDs[i], models.EarningStatusPending).
+				Updates(map[string]interface{}{
+					"status":    models.EarningStatusInPayout,
+					"payout_id": payouts[i].ID,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if int(result.RowsAffected) != len(earningIDs[i]) {
+				// Another batch picked up some of these earnings first
+				return gorm.ErrInvalidData
+			}
+		}
+
+		batch.Payouts = payouts
+		return nil
+	})
+}
+
+// GetBatchByID gets a payout batch with its payouts
+func (er *WorkerEarningRepository) GetBatchByID(id uint) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := er.db.Preload("Payouts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Payouts.Worker").First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetLatestBatch gets the most recent payout batch that was not cancelled
+func (er *WorkerEarningRepository) GetLatestBatch() (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := er.db.Where("status <> ?", models.PayoutBatchStatusCancelled).Order("period_end DESC").First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// UpdateBatch updates a payout batch
+func (er *WorkerEarningRepository) UpdateBatch(batch *models.PayoutBatch) error {
+	return er.db.Omit("Payouts").Save(batch).Error
+}
+
+// GetBatches gets payout batches with filters
+func (er *WorkerEarningRepository) GetBatches(filters *models.PayoutBatchFilters) ([]models.PayoutBatch, *Pagination, error) {
+	var batches []models.PayoutBatch
+	var total int64
+
+	query := er.db.Model(&models.PayoutBatch{})
+
+	// Apply filters
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	// Count total
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply pagination
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 {
+		filters.Limit = 20
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	err = query.Order("created_at DESC").Offset(offset).Limit(filters.Limit).Find(&batches).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate pagination
+	totalPages := int((total + int64(filters.Limit) - 1) / int64(filters.Limit))
+	pagination := &Pagination{
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+
+	return batches, pagination, nil
+}
+
+// SettlePayout records the bank outcome of a pending payout. Paid payouts mark their earnings
+// paid; failed payouts release their earnings so the next batch picks them up.
+func (er *WorkerEarningRepository) SettlePayout(payout *models.WorkerPayout) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WorkerPayout{}).
+			Where("id = ? AND status = ?", payout.ID, models.PayoutStatusPending).
+			Updates(map[string]interface{}{
+				"status":          payout.Status,
+				"transaction_ref": payout.TransactionRef,
+				"failure_reason":  payout.FailureReason,
+				"paid_at":         payout.PaidAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		earnings := tx.Model(&models.WorkerEarning{}).Where("payout_id = ?", payout.ID)
+		if payout.Status == models.PayoutStatusPaid {
+			return earnings.Update("status", models.EarningStatusPaid).Error
+		}
+		return earnings.Updates(map[string]interface{}{
+			"status":    models.EarningStatusPending,
+			"payout_id": nil,
+		}).Error
+	})
+}
+
+// CancelBatch cancels a batch and its pending payouts and releases their earnings
+func (er *WorkerEarningRepository) CancelBatch(batch *models.PayoutBatch) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		var payoutIDs []uint
+		if err := tx.Model(&models.WorkerPayout{}).Where("batch_id = ? AND status = ?", batch.ID, models.PayoutStatusPending).Pluck("id", &payoutIDs).Error; err != nil {
+			return err
+		}
+
+		if len(payoutIDs) > 0 {
+			if err := tx.Model(&models.WorkerEarning{}).Where("payout_id IN ?", payoutIDs).Updates(map[string]interface{}{
+				"status":    models.EarningStatusPending,
+				"payout_id": nil,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.WorkerPayout{}).Where("id IN ?", payoutIDs).Update("status", models.PayoutStatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+
+		batch.Status = models.PayoutBatchStatusCancelled
+		return tx.Omit("Payouts").Save(batch).Error
+	})
+}
+
+// CountPendingPayouts counts the payouts of a batch still waiting for the bank
+func (er *WorkerEarningRepository) CountPendingPayouts(batchID uint) (int64, error) {
+	var count int64
+	err := er.db.Model(&models.WorkerPayout{}).Where("batch_id = ? AND status = ?", batchID, models.PayoutStatusPending).Count(&count).Error
+	return count, err
+}
+
+// GetWorkerPayouts gets a worker's payouts created in the given period (nil bounds are open)
+func (er *WorkerEarningRepository) GetWorkerPayouts(workerID uint, from, to *time.Time) ([]models.WorkerPayout, error) {
+	var payouts []models.WorkerPayout
+	query := er.db.Preload("Batch").
+		Joins("JOIN payout_batches ON payout_batches.id = worker_payouts.batch_id").
+		Where("worker_payouts.worker_id = ? AND payout_batches.status <> ?", workerID, models.PayoutBatchStatusCancelled)
+	if from != nil {
+		query = query.Where("worker_payouts.created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("worker_payouts.created_at < ?", *to)
+	}
+	err := query.Order("worker_payouts.created_at DESC").Find(&payouts).Error
+	return payouts, err
+}
@@ -54,6 +54,13 @@ func (wr *WorkerRepository) IncrementCompletedJob(workerID uint, earnings float6
 		}).Error
 }
 
+// DeductEarnings takes an amount off the worker's total earnings
+func (wr *WorkerRepository) DeductEarnings(workerID uint, amount float64) error {
+	return wr.db.Model(&models.Worker{}).
+		Where("id = ?", workerID).
+		Update("earnings", gorm.Expr("earnings - ?", amount)).Error
+}
+
 // UpdateRating updates the worker's rating
 func (wr *WorkerRepository) UpdateRating(workerID uint, newRating float64) error {
 	return wr.db.Model(&models.Worker{}).
@@ -61,6 +68,26 @@ func (wr *WorkerRepository) UpdateRating(workerID uint, newRating float64) error
 		Update("rating", newRating).Error
 }
 
+// UpdateRatingSummaryByUserID updates the worker's rating and review count by user ID
+func (wr *WorkerRepository) UpdateRatingSummaryByUserID(userID uint, rating float64, totalReviews int) error {
+	return wr.db.Model(&models.Worker{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"rating":        rating,
+			"total_reviews": totalReviews,
+		}).Error
+}
+
+// GetActiveWorkers gets the workers whose user account is an active worker account
+func (wr *WorkerRepository) GetActiveWorkers() ([]models.Worker, error) {
+	var workers []models.Worker
+	err := wr.db.Preload("User").
+		Joins("JOIN users ON users.id = workers.user_id").
+		Where("users.user_type = ? AND users.is_active = ? AND users.deleted_at IS NULL", models.UserTypeWorker, true).
+		Find(&workers).Error
+	return workers, err
+}
+
 // UpdateAvailability updates the worker's availability status
 func (wr *WorkerRepository) UpdateAvailability(workerID uint, isAvailable bool) error {
 	return wr.db.Model(&models.Worker{}).
@@ -32,6 +32,12 @@ func SetupAdminPaymentRoutes(group *gin.RouterGroup) {
 		// POST /api/v1/admin/transactions/export - Export transactions to CSV
 		adminTransactions.POST("/export", adminPaymentController.ExportTransactions)
 		
+		// GET /api/v1/admin/transactions/pending-refunds - List cancellation refunds waiting to be retried
+		adminTransactions.GET("/pending-refunds", adminPaymentController.GetPendingRefunds)
+		
+		// POST /api/v1/admin/transactions/pending-refunds/retry - Retry due pending refunds now
+		adminTransactions.POST("/pending-refunds/retry", adminPaymentController.RetryPendingRefunds)
+		
 		// GET /api/v1/admin/transactions/:id - Get specific transaction by ID
 		adminTransactions.GET("/:id", adminPaymentController.GetTransactionByID)
 		
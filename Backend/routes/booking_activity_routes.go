@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookingActivityRoutes sets up booking activity log routes
+func SetupBookingActivityRoutes(router *gin.RouterGroup) {
+	activityController := controllers.NewBookingActivityController()
+
+	// Admin activity routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/bookings/:id/activity - Get the activity history of a booking
+		admin.GET("/bookings/:id/activity", activityController.GetBookingActivity)
+
+		// GET /api/v1/admin/booking-activity - Search activity across bookings
+		admin.GET("/booking-activity", activityController.GetActivities)
+	}
+}
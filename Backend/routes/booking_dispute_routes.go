@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookingDisputeRoutes sets up booking dispute routes
+func SetupBookingDisputeRoutes(router *gin.RouterGroup) {
+	disputeController := controllers.NewBookingDisputeController()
+
+	// Customer and worker dispute routes (authentication required)
+	bookingDisputes := router.Group("/bookings")
+	bookingDisputes.Use(middleware.AuthMiddleware())
+	{
+		// POST /api/v1/bookings/:id/disputes - Raise a dispute (multipart with "evidence" photos, or JSON)
+		bookingDisputes.POST("/:id/disputes", disputeController.OpenDispute)
+
+		// GET /api/v1/bookings/:id/disputes - Get disputes for a booking
+		bookingDisputes.GET("/:id/disputes", disputeController.GetBookingDisputes)
+	}
+
+	// Admin dispute routes (admin authentication required)
+	adminDisputes := router.Group("/admin/disputes")
+	adminDisputes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/disputes - Get all disputes
+		adminDisputes.GET("", disputeController.AdminGetDisputes)
+
+		// GET /api/v1/admin/disputes/:id - Get a dispute with comments
+		adminDisputes.GET("/:id", disputeController.AdminGetDispute)
+
+		// POST /api/v1/admin/disputes/:id/comments - Comment on a dispute
+		adminDisputes.POST("/:id/comments", disputeController.AdminAddComment)
+
+		// PUT /api/v1/admin/disputes/:id/resolve - Resolve a dispute
+		adminDisputes.PUT("/:id/resolve", disputeController.AdminResolveDispute)
+	}
+}
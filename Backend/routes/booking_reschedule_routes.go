@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookingRescheduleRoutes sets up booking reschedule routes
+func SetupBookingRescheduleRoutes(router *gin.RouterGroup) {
+	rescheduleController := controllers.NewBookingRescheduleController()
+
+	// Customer reschedule routes (authentication required)
+	bookings := router.Group("/bookings")
+	bookings.Use(middleware.AuthMiddleware())
+	{
+		// POST /api/v1/bookings/:id/reschedule - Ask to move a booking to a new time
+		bookings.POST("/:id/reschedule", rescheduleController.RequestReschedule)
+
+		// GET /api/v1/bookings/:id/reschedule-requests - Get the reschedule history of a booking
+		bookings.GET("/:id/reschedule-requests", rescheduleController.GetBookingRequests)
+
+		// PUT /api/v1/bookings/:id/reschedule-requests/:request_id/cancel - Withdraw a pending reschedule request
+		bookings.PUT("/:id/reschedule-requests/:request_id/cancel", rescheduleController.CancelRequest)
+	}
+
+	// Worker reschedule routes (worker authentication required)
+	workerReschedules := router.Group("/worker/reschedule-requests")
+	workerReschedules.Use(middleware.AuthMiddleware(), middleware.WorkerMiddleware())
+	{
+		// GET /api/v1/worker/reschedule-requests - Get reschedule requests waiting for an answer
+		workerReschedules.GET("", rescheduleController.GetWorkerPendingRequests)
+
+		// POST /api/v1/worker/reschedule-requests/:id/accept - Accept the new time
+		workerReschedules.POST("/:id/accept", rescheduleController.AcceptReschedule)
+
+		// POST /api/v1/worker/reschedule-requests/:id/decline - Decline the new time
+		workerReschedules.POST("/:id/decline", rescheduleController.DeclineReschedule)
+	}
+
+	// Admin reschedule routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// POST /api/v1/admin/bookings/:id/reschedule - Move a booking, optionally without waiting for the worker
+		admin.POST("/bookings/:id/reschedule", rescheduleController.AdminRequestReschedule)
+
+		// GET /api/v1/admin/reschedule-requests - Get all reschedule requests
+		admin.GET("/reschedule-requests", rescheduleController.AdminGetRequests)
+	}
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookingReviewRoutes sets up booking review routes
+func SetupBookingReviewRoutes(router *gin.RouterGroup) {
+	reviewController := controllers.NewBookingReviewController()
+
+	// Public review routes (no authentication required)
+	publicReviews := router.Group("/public/workers")
+	{
+		// GET /api/v1/public/workers/:id/reviews - Get published reviews for a worker
+		publicReviews.GET("/:id/reviews", reviewController.GetWorkerReviews)
+	}
+
+	// User review routes (authentication required)
+	userReviews := router.Group("/bookings")
+	userReviews.Use(middleware.AuthMiddleware())
+	{
+		// POST /api/v1/bookings/:id/review - Submit review for a completed booking
+		userReviews.POST("/:id/review", reviewController.SubmitReview)
+
+		// PUT /api/v1/bookings/:id/review - Update review within the edit window
+		userReviews.PUT("/:id/review", reviewController.UpdateReview)
+
+		// GET /api/v1/bookings/:id/review - Get review for a booking
+		userReviews.GET("/:id/review", reviewController.GetBookingReview)
+	}
+
+	// Admin review routes (admin authentication required)
+	adminReviews := router.Group("/admin/reviews")
+	adminReviews.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/reviews - Get all reviews
+		adminReviews.GET("", reviewController.AdminGetReviews)
+
+		// PUT /api/v1/admin/reviews/:id/moderate - Moderate a review
+		adminReviews.PUT("/:id/moderate", reviewController.AdminModerateReview)
+	}
+}
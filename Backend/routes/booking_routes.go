@@ -39,6 +39,9 @@ func SetupBookingRoutes(router *gin.RouterGroup) {
 		
 		// PUT /api/v1/bookings/:id/cancel - Cancel booking
 		userBookings.PUT("/:id/cancel", bookingController.CancelUserBooking)
+		
+		// GET /api/v1/bookings/:id/cancellation-preview - Preview cancellation fee and refund
+		userBookings.GET("/:id/cancellation-preview", bookingController.GetCancellationPreview)
 	}
 
 	// Inquiry-based booking routes
@@ -90,9 +93,12 @@ func SetupBookingRoutes(router *gin.RouterGroup) {
 		// PUT /api/v1/admin/bookings/:id/status - Update booking status
 		adminBookings.PUT("/:id/status", bookingController.AdminUpdateBookingStatus)
 		
-		// POST /api/v1/admin/bookings/:id/assign-worker - Assign worker to booking
+		// POST /api/v1/admin/bookings/:id/assign-worker - Assign worker to booking (or best match with auto_assign)
 		adminBookings.POST("/:id/assign-worker", bookingController.AdminAssignWorker)
 		
+		// GET /api/v1/admin/bookings/:id/suggested-workers - Rank workers who could take the booking
+		adminBookings.GET("/:id/suggested-workers", bookingController.AdminGetSuggestedWorkers)
+		
 		// GET /api/v1/admin/bookings/stats - Get booking statistics
 		adminBookings.GET("/stats", bookingController.GetBookingStats)
 		
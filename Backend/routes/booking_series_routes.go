@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBookingSeriesRoutes sets up recurring booking routes
+func SetupBookingSeriesRoutes(router *gin.RouterGroup) {
+	seriesController := controllers.NewBookingSeriesController()
+
+	// Customer recurring booking routes (authentication required)
+	bookingSeries := router.Group("/booking-series")
+	bookingSeries.Use(middleware.AuthMiddleware())
+	{
+		// POST /api/v1/booking-series - Create a recurring booking
+		bookingSeries.POST("", seriesController.CreateSeries)
+
+		// GET /api/v1/booking-series - Get user's recurring bookings
+		bookingSeries.GET("", seriesController.GetUserSeries)
+
+		// GET /api/v1/booking-series/:id - Get a recurring booking with its visits
+		bookingSeries.GET("/:id", seriesController.GetSeries)
+
+		// PUT /api/v1/booking-series/:id/cancel - Cancel the whole series
+		bookingSeries.PUT("/:id/cancel", seriesController.CancelSeries)
+
+		// PUT /api/v1/booking-series/:id/occurrences/:occurrence_id/skip - Skip one visit
+		bookingSeries.PUT("/:id/occurrences/:occurrence_id/skip", seriesController.SkipOccurrence)
+
+		// PUT /api/v1/booking-series/:id/occurrences/:occurrence_id/reschedule - Move one visit
+		bookingSeries.PUT("/:id/occurrences/:occurrence_id/reschedule", seriesController.RescheduleOccurrence)
+
+		// PUT /api/v1/booking-series/:id/occurrences/:occurrence_id/cancel - Cancel one visit
+		bookingSeries.PUT("/:id/occurrences/:occurrence_id/cancel", seriesController.CancelOccurrence)
+	}
+
+	// Admin recurring booking routes (admin authentication required)
+	adminBookingSeries := router.Group("/admin/booking-series")
+	adminBookingSeries.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/booking-series - Get all recurring bookings
+		adminBookingSeries.GET("", seriesController.AdminGetSeries)
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupChatAttachmentRoutes sets up attachment upload, location sharing and media routes for chat rooms and conversations
+func SetupChatAttachmentRoutes(router *gin.RouterGroup) {
+	chatAttachmentController := controllers.NewChatAttachmentController()
+
+	// Chat room attachment routes (authenticated users only)
+	chat := router.Group("/chat")
+	chat.Use(middleware.AuthMiddleware())
+	{
+		chat.POST("/rooms/:room_id/attachments", chatAttachmentController.UploadRoomAttachment)       // Upload an image or document
+		chat.POST("/rooms/:room_id/attachments/location", chatAttachmentController.ShareRoomLocation) // Share a location pin
+		chat.GET("/attachments/:id", chatAttachmentController.GetAttachmentMedia)                     // Get an attachment's media
+		chat.GET("/attachments/:id/thumbnail", chatAttachmentController.GetAttachmentThumbnail)       // Get an attachment's thumbnail
+	}
+
+	// Conversation attachment routes (authenticated users only)
+	conversations := router.Group("/conversations")
+	conversations.Use(middleware.AuthMiddleware())
+	{
+		conversations.POST("/:id/attachments", chatAttachmentController.UploadConversationAttachment)       // Upload an image or document
+		conversations.POST("/:id/attachments/location", chatAttachmentController.ShareConversationLocation) // Share a location pin
+	}
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupChatModerationRoutes sets up message report routes and the admin chat moderation routes
+func SetupChatModerationRoutes(router *gin.RouterGroup) {
+	chatModerationController := controllers.NewChatModerationController()
+
+	// Message report routes (authenticated users only)
+	chat := router.Group("/chat")
+	chat.Use(middleware.AuthMiddleware())
+	{
+		chat.POST("/messages/:message_id/report", chatModerationController.ReportRoomMessage) // Report a chat room message
+	}
+
+	conversations := router.Group("/conversations")
+	conversations.Use(middleware.AuthMiddleware())
+	{
+		conversations.POST("/messages/:message_id/report", chatModerationController.ReportConversationMessage) // Report a conversation message
+	}
+
+	// Admin chat moderation routes (admin authentication required)
+	adminModeration := router.Group("/admin/chat-moderation")
+	adminModeration.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/chat-moderation/reports - Get the review queue of flagged messages
+		adminModeration.GET("/reports", chatModerationController.GetReports)
+
+		// GET /api/v1/admin/chat-moderation/reports/:id - Get a flagged message
+		adminModeration.GET("/reports/:id", chatModerationController.GetReport)
+
+		// PUT /api/v1/admin/chat-moderation/reports/:id/review - Dismiss or action a flagged message
+		adminModeration.PUT("/reports/:id/review", chatModerationController.ReviewReport)
+
+		// GET /api/v1/admin/chat-moderation/mutes - Get the mutes in force
+		adminModeration.GET("/mutes", chatModerationController.GetMutes)
+
+		// POST /api/v1/admin/chat-moderation/mutes - Mute a participant
+		adminModeration.POST("/mutes", chatModerationController.MuteUser)
+
+		// PUT /api/v1/admin/chat-moderation/mutes/:id/lift - Lift a mute
+		adminModeration.PUT("/mutes/:id/lift", chatModerationController.LiftMute)
+	}
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+	"treesindia/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupChatSyncRoutes sets up message sync, receipt and typing routes for chat rooms and conversations
+func SetupChatSyncRoutes(router *gin.RouterGroup, syncService *services.ChatSyncService) {
+	chatSyncController := controllers.NewChatSyncController(syncService)
+
+	// Chat room sync routes (authenticated users only)
+	chat := router.Group("/chat")
+	chat.Use(middleware.AuthMiddleware())
+	{
+		chat.GET("/rooms/:room_id/sync", chatSyncController.SyncRoomMessages)        // Get messages after a message or the device's cursor
+		chat.POST("/rooms/:room_id/receipts", chatSyncController.UpdateRoomReceipts) // Mark messages delivered or read
+		chat.GET("/rooms/:room_id/receipts", chatSyncController.GetRoomReceipts)     // Get receipts of messages
+		chat.POST("/rooms/:room_id/typing", chatSyncController.SetRoomTyping)        // Send a typing indicator
+	}
+
+	// Conversation sync routes (authenticated users only)
+	conversations := router.Group("/conversations")
+	conversations.Use(middleware.AuthMiddleware())
+	{
+		conversations.GET("/:id/sync", chatSyncController.SyncConversationMessages)        // Get messages after a message or the device's cursor
+		conversations.POST("/:id/receipts", chatSyncController.UpdateConversationReceipts) // Mark messages delivered or read
+		conversations.GET("/:id/receipts", chatSyncController.GetConversationReceipts)     // Get receipts of messages
+		conversations.POST("/:id/typing", chatSyncController.SetConversationTyping)        // Send a typing indicator
+	}
+}
@@ -33,5 +33,6 @@ func SetupChatbotRoutes(router *gin.RouterGroup) {
 	{
 		// User-specific routes
 		authChatbot.GET("/sessions", chatbotController.GetUserSessions)             // Get user's sessions
+		authChatbot.GET("/leads", chatbotController.GetLeads)                       // Get property leads (plan feature)
 	}
 }
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCouponRoutes sets up coupon routes
+func SetupCouponRoutes(router *gin.RouterGroup) {
+	couponController := controllers.NewCouponController()
+
+	// User coupon routes (authentication required)
+	coupons := router.Group("/coupons")
+	coupons.Use(middleware.AuthMiddleware())
+	{
+		// POST /api/v1/coupons/validate - Preview a coupon on a booking, quote payment or subscription
+		coupons.POST("/validate", couponController.ValidateCoupon)
+	}
+
+	// Admin coupon routes (admin authentication required)
+	admin := router.Group("/admin/coupons")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/coupons - Get coupons (?search=&is_active=)
+		admin.GET("", couponController.GetCoupons)
+
+		// POST /api/v1/admin/coupons - Create a coupon
+		admin.POST("", couponController.CreateCoupon)
+
+		// GET /api/v1/admin/coupons/:id - Get a coupon
+		admin.GET("/:id", couponController.GetCoupon)
+
+		// PUT /api/v1/admin/coupons/:id - Update a coupon
+		admin.PUT("/:id", couponController.UpdateCoupon)
+
+		// DELETE /api/v1/admin/coupons/:id - Delete a coupon
+		admin.DELETE("/:id", couponController.DeleteCoupon)
+
+		// GET /api/v1/admin/coupons/:id/redemptions - Get the redemptions of a coupon
+		admin.GET("/:id/redemptions", couponController.GetCouponRedemptions)
+	}
+}
@@ -34,7 +34,7 @@ func SetupInAppNotificationRoutes(router *gin.RouterGroup, wsService *services.N
 	// User WebSocket route (outside auth middleware to handle token via query parameter)
 	userWS := router.Group("/in-app-notifications")
 	{
-		// WS /api/v1/in-app-notifications/ws - WebSocket for real-time updates
+		// WS /api/v1/in-app-notifications/ws - WebSocket for real-time updates (legacy; the realtime gateway's notifications topic carries the same events)
 		userWS.GET("/ws", wsController.HandleWebSocket)
 	}
 
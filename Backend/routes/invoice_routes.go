@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupInvoiceRoutes sets up invoice, credit note, billing detail and tax code routes
+func SetupInvoiceRoutes(router *gin.RouterGroup) {
+	invoiceController := controllers.NewInvoiceController()
+
+	// User invoice routes (authentication required)
+	invoices := router.Group("/invoices")
+	invoices.Use(middleware.AuthMiddleware())
+	{
+		// GET /api/v1/invoices - Get invoices, credit notes and receipts (?document_type=&from=&to= YYYY-MM-DD)
+		invoices.GET("", invoiceController.GetMyInvoices)
+
+		// GET /api/v1/invoices/billing-details - Get the business name and GSTIN for invoices
+		invoices.GET("/billing-details", invoiceController.GetBillingDetails)
+
+		// PUT /api/v1/invoices/billing-details - Update the business name and GSTIN for future invoices
+		invoices.PUT("/billing-details", invoiceController.UpdateBillingDetails)
+
+		// GET /api/v1/invoices/payments/:payment_id - Get the document of a payment, issuing it if needed
+		invoices.GET("/payments/:payment_id", invoiceController.GetPaymentInvoice)
+
+		// GET /api/v1/invoices/payments/:payment_id/pdf - Download the document of a payment as a PDF
+		invoices.GET("/payments/:payment_id/pdf", invoiceController.DownloadPaymentInvoice)
+
+		// GET /api/v1/invoices/:id - Get an invoice
+		invoices.GET("/:id", invoiceController.GetMyInvoice)
+
+		// GET /api/v1/invoices/:id/pdf - Download an invoice as a PDF
+		invoices.GET("/:id/pdf", invoiceController.DownloadMyInvoice)
+	}
+
+	// Admin invoice routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/invoices - Get invoices (?user_id=&document_type=&from=&to=)
+		admin.GET("/invoices", invoiceController.AdminGetInvoices)
+
+		// GET /api/v1/admin/invoices/register - Download the monthly invoice register (?month=YYYY-MM)
+		admin.GET("/invoices/register", invoiceController.AdminDownloadInvoiceRegister)
+
+		// GET /api/v1/admin/invoices/:id/pdf - Download an invoice as a PDF
+		admin.GET("/invoices/:id/pdf", invoiceController.AdminDownloadInvoice)
+
+		// GET /api/v1/admin/tax-codes - Get category SAC codes and GST rates
+		admin.GET("/tax-codes", invoiceController.AdminGetTaxCodes)
+
+		// PUT /api/v1/admin/tax-codes/:category_id - Set a category's SAC code and GST rate
+		admin.PUT("/tax-codes/:category_id", invoiceController.AdminSetTaxCode)
+	}
+}
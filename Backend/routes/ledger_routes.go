@@ -31,11 +31,33 @@ func SetupLedgerRoutes(router *gin.RouterGroup) {
 		admin.POST("/entries/:id/pay", ledgerController.ProcessPayment)
 		admin.POST("/entries/:id/receive", ledgerController.ProcessReceive)
 
+		// Receipts attached to entries
+		admin.POST("/entries/:id/attachments", ledgerController.UploadEntryAttachment)
+		admin.DELETE("/entries/:id/attachments/:attachment_id", ledgerController.DeleteEntryAttachment)
+
 		// Balance management
 		admin.GET("/balance", ledgerController.GetCurrentBalance)
 		admin.PUT("/balance", ledgerController.UpdateBalance)
 
 		// Summary and reports
 		admin.GET("/summary", ledgerController.GetSummary)
+		admin.GET("/reports/trial-balance", ledgerController.GetTrialBalance)
+		admin.GET("/reports/profit-and-loss", ledgerController.GetProfitAndLoss)
+
+		// Chart of accounts
+		admin.GET("/accounts", ledgerController.GetAccounts)
+		admin.POST("/accounts", ledgerController.CreateAccount)
+		admin.PUT("/accounts/:id", ledgerController.UpdateAccount)
+
+		// Double-entry journals
+		admin.GET("/journals", ledgerController.GetJournals)
+		admin.POST("/journals", ledgerController.CreateJournal)
+		admin.POST("/journals/post-pending", ledgerController.PostPendingEvents)
+		admin.GET("/journals/:id", ledgerController.GetJournal)
+		admin.POST("/journals/:id/reverse", ledgerController.ReverseJournal)
+
+		// Accounting periods
+		admin.GET("/periods", ledgerController.GetPeriods)
+		admin.POST("/periods/close", ledgerController.ClosePeriod)
 	}
 }
@@ -28,5 +28,8 @@ func SetupPaymentSegmentRoutes(router *gin.RouterGroup) {
 		
 		// Verify segment payment
 		paymentSegmentRoutes.POST("/verify", paymentSegmentController.VerifySegmentPayment)
+		
+		// Turn wallet auto-debit on or off for unpaid segments
+		paymentSegmentRoutes.PUT("/auto-debit", paymentSegmentController.SetAutoDebit)
 	}
 }
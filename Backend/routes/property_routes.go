@@ -26,6 +26,7 @@ func SetupPropertyRoutes(router *gin.RouterGroup) {
 		userProperties.POST("", propertyController.CreateProperty)                // Create property listing (users and brokers)
 		userProperties.GET("", propertyController.GetUserProperties)              // Get user's properties (works for both users and brokers)
 		userProperties.DELETE("/:id", propertyController.DeleteUserProperty)      // Delete user's property
+		userProperties.PUT("/:id/featured", propertyController.SetPropertyFeatured) // Feature user's property with a plan slot
 	}
 	
 	// Admin routes (admin authentication required)
@@ -27,5 +27,39 @@ func SetupRazorpayRoutes(group *gin.RouterGroup) {
 	{
 		// Handle webhook notifications
 		group.POST("/razorpay/webhook", razorpayController.HandleWebhook)
+
+		// Handle webhook notifications of any payment gateway
+		group.POST("/payment-gateways/:provider/webhook", razorpayController.HandleGatewayWebhook)
+	}
+
+	// Admin webhook event routes (admin authentication required)
+	adminWebhooks := group.Group("/admin/payment-webhooks")
+	adminWebhooks.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/payment-webhooks - List stored webhook events
+		adminWebhooks.GET("", razorpayController.GetWebhookEvents)
+
+		// GET /api/v1/admin/payment-webhooks/:id - Get a webhook event with its payload
+		adminWebhooks.GET("/:id", razorpayController.GetWebhookEvent)
+
+		// POST /api/v1/admin/payment-webhooks/:id/replay - Process a failed event again
+		adminWebhooks.POST("/:id/replay", razorpayController.ReplayWebhookEvent)
+	}
+
+	// Admin payment reconciliation routes (admin authentication required)
+	adminReconciliation := group.Group("/admin/payment-reconciliation")
+	adminReconciliation.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// POST /api/v1/admin/payment-reconciliation/run - Reconcile payments with Razorpay now
+		adminReconciliation.POST("/run", razorpayController.RunPaymentReconciliation)
+
+		// GET /api/v1/admin/payment-reconciliation/discrepancies - List discrepancies
+		adminReconciliation.GET("/discrepancies", razorpayController.GetPaymentDiscrepancies)
+
+		// GET /api/v1/admin/payment-reconciliation/discrepancies/export - Download the reconciliation report as CSV
+		adminReconciliation.GET("/discrepancies/export", razorpayController.ExportPaymentDiscrepancies)
+
+		// PUT /api/v1/admin/payment-reconciliation/discrepancies/:id/resolve - Resolve a discrepancy
+		adminReconciliation.PUT("/discrepancies/:id/resolve", razorpayController.ResolvePaymentDiscrepancy)
 	}
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRealtimeRoutes sets up the realtime gateway routes
+func SetupRealtimeRoutes(group *gin.RouterGroup) {
+	realtimeController := controllers.NewRealtimeController()
+
+	// Realtime connection (authentication handled in the controller, as browsers cannot set headers on WebSocket requests)
+	realtime := group.Group("/realtime")
+	{
+		// GET /api/v1/realtime/ws - Open the realtime WebSocket connection of a device
+		realtime.GET("/ws", realtimeController.HandleWebSocket)
+	}
+
+	// Admin realtime routes (admin authentication required)
+	adminRealtime := group.Group("/admin/realtime")
+	adminRealtime.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/realtime/stats - Get realtime connection statistics
+		adminRealtime.GET("/stats", realtimeController.GetStats)
+	}
+}
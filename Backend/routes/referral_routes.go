@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReferralRoutes sets up referral routes
+func SetupReferralRoutes(router *gin.RouterGroup) {
+	referralController := controllers.NewReferralController()
+
+	// User referral routes (authentication required)
+	referrals := router.Group("/referrals")
+	referrals.Use(middleware.AuthMiddleware())
+	{
+		// GET /api/v1/referrals/me - Get the user's referral code and referrals
+		referrals.GET("/me", referralController.GetMyReferrals)
+
+		// POST /api/v1/referrals/apply - Apply the referral code of the user who referred them
+		referrals.POST("/apply", referralController.ApplyReferralCode)
+	}
+
+	// Admin referral routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/referrals - Get referrals (?status=)
+		admin.GET("/referrals", referralController.AdminGetReferrals)
+	}
+}
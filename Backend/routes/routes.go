@@ -54,6 +54,11 @@ func SetupRoutes(r *gin.Engine) {
 		SetupHomepageCategoryIconRoutes(v1)
 		SetupVendorRoutes(v1)
 		SetupWorkerRoutes(v1)
+		SetupWorkerAvailabilityRoutes(v1)
+		SetupWorkerEarningRoutes(v1)
+		SetupInvoiceRoutes(v1)
+		SetupCouponRoutes(v1)
+		SetupReferralRoutes(v1)
 		SetupChatbotRoutes(v1)
 		
 		// Booking routes with booking system middleware
@@ -62,6 +67,11 @@ func SetupRoutes(r *gin.Engine) {
 		bookingGroup.Use(bookingMiddleware.BookingSystem())
 		SetupBookingRoutes(bookingGroup)
 		SetupWorkerInquiryRoutes(bookingGroup)
+		SetupBookingReviewRoutes(bookingGroup)
+		SetupBookingDisputeRoutes(bookingGroup)
+		SetupBookingActivityRoutes(bookingGroup)
+		SetupBookingSeriesRoutes(bookingGroup)
+		SetupBookingRescheduleRoutes(bookingGroup)
 		// Worker assignment routes will be set up in main.go with chat service
 		
 		// Payment routes
@@ -71,6 +81,9 @@ func SetupRoutes(r *gin.Engine) {
 		
 		// Ledger routes
 		SetupLedgerRoutes(v1)
+		
+		// Realtime gateway routes
+		SetupRealtimeRoutes(v1)
 	}
 }
 
@@ -7,7 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupSimpleConversationWebSocketRoutes sets up WebSocket routes for simple conversations
+// SetupSimpleConversationWebSocketRoutes sets up the legacy WebSocket routes for simple conversations.
+// New clients use the realtime gateway (/api/v1/realtime/ws) and subscribe to conversation topics instead.
 func SetupSimpleConversationWebSocketRoutes(router *gin.RouterGroup, wsService *services.SimpleConversationWebSocketService) {
 	wsController := controllers.NewSimpleConversationWebSocketController(wsService)
 
@@ -26,6 +26,11 @@ func SetupSubscriptionRoutes(router *gin.RouterGroup) {
 		subscriptionRoutes.POST("/complete-purchase", userSubscriptionController.CompleteSubscriptionPurchase)
 		subscriptionRoutes.GET("/my-subscription", userSubscriptionController.GetUserSubscription)
 		subscriptionRoutes.GET("/history", userSubscriptionController.GetUserSubscriptionHistory)
+		subscriptionRoutes.PUT("/auto-renew", userSubscriptionController.SetAutoRenew)
+		subscriptionRoutes.POST("/renew", userSubscriptionController.RenewSubscription)
+		subscriptionRoutes.GET("/change-plan/quote", userSubscriptionController.GetPlanChangeQuote)
+		subscriptionRoutes.POST("/change-plan", userSubscriptionController.ChangePlan)
+		subscriptionRoutes.GET("/entitlements", userSubscriptionController.GetEntitlements)
 	}
 }
 
@@ -31,6 +31,9 @@ func SetupWalletRoutes(group *gin.RouterGroup) {
 
 		// Wallet summary
 		walletGroup.GET("/summary", walletController.GetUserWalletSummary)
+
+		// Wallet journal
+		walletGroup.GET("/journal", walletController.GetWalletJournal)
 	}
 
 	// Admin wallet routes (admin only)
@@ -41,5 +44,10 @@ func SetupWalletRoutes(group *gin.RouterGroup) {
 	{
 		// Admin wallet adjustment
 		adminWalletGroup.POST("/adjust", walletController.AdminAdjustWallet)
+
+		// Wallet journal and reconciliation
+		adminWalletGroup.GET("/users/:id/journal", walletController.AdminGetUserWalletJournal)
+		adminWalletGroup.GET("/discrepancies", walletController.AdminGetWalletDiscrepancies)
+		adminWalletGroup.POST("/reconcile", walletController.AdminReconcileWallets)
 	}
 }
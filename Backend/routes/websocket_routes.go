@@ -7,7 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupWebSocketRoutes sets up WebSocket routes
+// SetupWebSocketRoutes sets up the legacy chat and location WebSocket routes. New clients use the
+// realtime gateway (/api/v1/realtime/ws) and subscribe to chat_room and booking topics instead.
 func SetupWebSocketRoutes(router *gin.Engine, wsController *controllers.WebSocketController) {
 	// WebSocket routes (no authentication middleware for WebSocket upgrade)
 	websocket := router.Group("/ws")
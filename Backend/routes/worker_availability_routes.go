@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWorkerAvailabilityRoutes sets up worker schedule, leave and blocked slot routes
+func SetupWorkerAvailabilityRoutes(router *gin.RouterGroup) {
+	availabilityController := controllers.NewWorkerAvailabilityController()
+
+	// Worker availability routes (authenticated workers only)
+	workerAvailability := router.Group("/worker/availability")
+	workerAvailability.Use(middleware.AuthMiddleware(), middleware.WorkerMiddleware())
+	{
+		// GET /api/v1/worker/availability/schedule - Get weekly schedule
+		workerAvailability.GET("/schedule", availabilityController.GetSchedule)
+
+		// PUT /api/v1/worker/availability/schedule - Replace weekly schedule
+		workerAvailability.PUT("/schedule", availabilityController.UpdateSchedule)
+
+		// GET /api/v1/worker/availability/leaves - Get leave requests
+		workerAvailability.GET("/leaves", availabilityController.GetLeaves)
+
+		// POST /api/v1/worker/availability/leaves - Request leave
+		workerAvailability.POST("/leaves", availabilityController.RequestLeave)
+
+		// PUT /api/v1/worker/availability/leaves/:id/cancel - Cancel a leave request
+		workerAvailability.PUT("/leaves/:id/cancel", availabilityController.CancelLeave)
+
+		// GET /api/v1/worker/availability/blocked-slots - Get blocked slots (?from=&to= RFC3339)
+		workerAvailability.GET("/blocked-slots", availabilityController.GetBlockedSlots)
+
+		// POST /api/v1/worker/availability/blocked-slots - Block a period
+		workerAvailability.POST("/blocked-slots", availabilityController.AddBlockedSlot)
+
+		// DELETE /api/v1/worker/availability/blocked-slots/:id - Remove a blocked slot
+		workerAvailability.DELETE("/blocked-slots/:id", availabilityController.RemoveBlockedSlot)
+	}
+
+	// Admin availability routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/worker-leaves - Get leave requests (?status=&worker_id=&from=&to=)
+		admin.GET("/worker-leaves", availabilityController.AdminGetLeaves)
+
+		// PUT /api/v1/admin/worker-leaves/:id/review - Approve or reject a leave request
+		admin.PUT("/worker-leaves/:id/review", availabilityController.AdminReviewLeave)
+
+		// GET /api/v1/admin/workers/:worker_id/availability - Get a worker's schedule, leave and blocked slots
+		admin.GET("/workers/:worker_id/availability", availabilityController.AdminGetWorkerAvailability)
+	}
+}
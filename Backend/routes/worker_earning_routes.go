@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"treesindia/controllers"
+	"treesindia/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWorkerEarningRoutes sets up worker earning, commission rule and payout routes
+func SetupWorkerEarningRoutes(router *gin.RouterGroup) {
+	earningController := controllers.NewWorkerEarningController()
+
+	// Worker earning routes (authenticated workers only)
+	workerEarnings := router.Group("/worker/earnings")
+	workerEarnings.Use(middleware.AuthMiddleware(), middleware.WorkerMiddleware())
+	{
+		// GET /api/v1/worker/earnings - Get earning lines (?status=&from=&to= YYYY-MM-DD)
+		workerEarnings.GET("", earningController.GetMyEarnings)
+
+		// GET /api/v1/worker/earnings/statement - Get earnings and payout statement (?from=&to= YYYY-MM-DD)
+		workerEarnings.GET("/statement", earningController.GetMyStatement)
+	}
+
+	// Admin earning and payout routes (admin authentication required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// GET /api/v1/admin/commission-rules - Get commission rules
+		admin.GET("/commission-rules", earningController.GetCommissionRules)
+
+		// POST /api/v1/admin/commission-rules - Create a commission rule
+		admin.POST("/commission-rules", earningController.CreateCommissionRule)
+
+		// PUT /api/v1/admin/commission-rules/:id - Update a commission rule
+		admin.PUT("/commission-rules/:id", earningController.UpdateCommissionRule)
+
+		// DELETE /api/v1/admin/commission-rules/:id - Delete a commission rule
+		admin.DELETE("/commission-rules/:id", earningController.DeleteCommissionRule)
+
+		// GET /api/v1/admin/worker-earnings - Get earning lines (?worker_id=&status=&from=&to=)
+		admin.GET("/worker-earnings", earningController.AdminGetEarnings)
+
+		// GET /api/v1/admin/workers/:worker_id/earnings/statement - Get a worker's statement
+		admin.GET("/workers/:worker_id/earnings/statement", earningController.AdminGetWorkerStatement)
+
+		// POST /api/v1/admin/payout-batches - Create a payout batch from pending earnings
+		admin.POST("/payout-batches", earningController.CreatePayoutBatch)
+
+		// GET /api/v1/admin/payout-batches - Get payout batches
+		admin.GET("/payout-batches", earningController.GetPayoutBatches)
+
+		// GET /api/v1/admin/payout-batches/:id - Get a payout batch with its payouts
+		admin.GET("/payout-batches/:id", earningController.GetPayoutBatch)
+
+		// GET /api/v1/admin/payout-batches/:id/export - Download the CSV bank file
+		admin.GET("/payout-batches/:id/export", earningController.ExportPayoutBatch)
+
+		// POST /api/v1/admin/payout-batches/:id/results - Record paid and failed transfers
+		admin.POST("/payout-batches/:id/results", earningController.RecordPayoutResults)
+
+		// POST /api/v1/admin/payout-batches/:id/cancel - Cancel a batch and release its earnings
+		admin.POST("/payout-batches/:id/cancel", earningController.CancelPayoutBatch)
+	}
+}
@@ -240,6 +240,403 @@ func (s *AdminConfigService) GetRequirePropertyApproval() bool {
 	return require
 }
 
+// GetReviewEditWindowHours retrieves how long a customer can edit a booking review
+func (s *AdminConfigService) GetReviewEditWindowHours() int {
+	hours, err := s.GetIntValue("review_edit_window_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get review edit window hours, using 48: %v", err)
+		return 48
+	}
+	return hours
+}
+
+// GetCancellationFeeTiers retrieves the cancellation fee tiers for booking payments
+func (s *AdminConfigService) GetCancellationFeeTiers() string {
+	tiers, err := s.repo.GetValueByKey("cancellation_fee_tiers")
+	if err != nil {
+		logrus.Warnf("Failed to get cancellation fee tiers, using 24:0,6:20,0:50: %v", err)
+		return "24:0,6:20,0:50"
+	}
+	return tiers
+}
+
+// GetSegmentCancellationFeeTiers retrieves the cancellation fee tiers for paid payment segments
+func (s *AdminConfigService) GetSegmentCancellationFeeTiers() string {
+	tiers, err := s.repo.GetValueByKey("segment_cancellation_fee_tiers")
+	if err != nil {
+		logrus.Warnf("Failed to get segment cancellation fee tiers, using 24:0,0:10: %v", err)
+		return "24:0,0:10"
+	}
+	return tiers
+}
+
+// GetInquiryFeeRefundPercentage retrieves the share of the inquiry fee refunded on cancellation
+func (s *AdminConfigService) GetInquiryFeeRefundPercentage(quoteAccepted bool) float64 {
+	key, defaultValue := "inquiry_fee_refund_percentage_before_quote", 100.0
+	if quoteAccepted {
+		key, defaultValue = "inquiry_fee_refund_percentage_after_quote", 0.0
+	}
+	percentage, err := s.GetFloatValue(key)
+	if err != nil {
+		logrus.Warnf("Failed to get %s, using %.0f: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return percentage
+}
+
+// GetWorkerMatchingMaxDistanceKm retrieves how far a worker can be from a booking to be matched to it
+func (s *AdminConfigService) GetWorkerMatchingMaxDistanceKm() float64 {
+	distance, err := s.GetFloatValue("worker_matching_max_distance_km")
+	if err != nil {
+		logrus.Warnf("Failed to get worker matching max distance, using 25: %v", err)
+		return 25
+	}
+	return distance
+}
+
+// GetRecurringBookingLeadHours retrieves how long before a recurring visit its booking is created
+func (s *AdminConfigService) GetRecurringBookingLeadHours() int {
+	hours, err := s.GetIntValue("recurring_booking_lead_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get recurring booking lead hours, using 48: %v", err)
+		return 48
+	}
+	return hours
+}
+
+// GetBookingRescheduleCutoffHours retrieves how close to the start customers can still reschedule a booking
+func (s *AdminConfigService) GetBookingRescheduleCutoffHours() int {
+	hours, err := s.GetIntValue("booking_reschedule_cutoff_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get booking reschedule cutoff hours, using 4: %v", err)
+		return 4
+	}
+	return hours
+}
+
+// GetMaxReschedulesPerBooking retrieves how many times a customer can reschedule one booking
+func (s *AdminConfigService) GetMaxReschedulesPerBooking() int {
+	limit, err := s.GetIntValue("max_reschedules_per_booking")
+	if err != nil {
+		logrus.Warnf("Failed to get max reschedules per booking, using 2: %v", err)
+		return 2
+	}
+	return limit
+}
+
+// GetDefaultCommissionPercentage retrieves the platform commission for jobs without a commission rule
+func (s *AdminConfigService) GetDefaultCommissionPercentage() float64 {
+	percentage, err := s.GetFloatValue("default_commission_percentage")
+	if err != nil {
+		logrus.Warnf("Failed to get default commission percentage, using 20: %v", err)
+		return 20
+	}
+	return percentage
+}
+
+// GetCompanyLegalName retrieves the company's legal name for invoices
+func (s *AdminConfigService) GetCompanyLegalName() string {
+	value, err := s.repo.GetValueByKey("company_legal_name")
+	if err != nil {
+		logrus.Warnf("Failed to get company legal name, using TreesIndia Services Private Limited: %v", err)
+		return "TreesIndia Services Private Limited"
+	}
+	return value
+}
+
+// GetCompanyGSTIN retrieves the company's GSTIN for invoices
+func (s *AdminConfigService) GetCompanyGSTIN() string {
+	value, err := s.repo.GetValueByKey("company_gstin")
+	if err != nil {
+		logrus.Warnf("Failed to get company GSTIN: %v", err)
+		return ""
+	}
+	return value
+}
+
+// GetCompanyState retrieves the state the company is registered in for GST
+func (s *AdminConfigService) GetCompanyState() string {
+	value, err := s.repo.GetValueByKey("company_state")
+	if err != nil {
+		logrus.Warnf("Failed to get company state, using West Bengal: %v", err)
+		return "West Bengal"
+	}
+	return value
+}
+
+// GetCompanyAddress retrieves the company's registered address for invoices
+func (s *AdminConfigService) GetCompanyAddress() string {
+	value, err := s.repo.GetValueByKey("company_address")
+	if err != nil {
+		logrus.Warnf("Failed to get company address: %v", err)
+		return ""
+	}
+	return value
+}
+
+// GetSupportEmail retrieves the support email address printed on invoices
+func (s *AdminConfigService) GetSupportEmail() string {
+	value, err := s.repo.GetValueByKey("support_email")
+	if err != nil {
+		logrus.Warnf("Failed to get support email: %v", err)
+		return ""
+	}
+	return value
+}
+
+// GetDefaultGSTRate retrieves the GST rate for services without a category tax code
+func (s *AdminConfigService) GetDefaultGSTRate() float64 {
+	rate, err := s.GetFloatValue("default_gst_rate")
+	if err != nil {
+		logrus.Warnf("Failed to get default GST rate, using 18: %v", err)
+		return 18
+	}
+	return rate
+}
+
+// GetDefaultSACCode retrieves the SAC code for services without a category tax code
+func (s *AdminConfigService) GetDefaultSACCode() string {
+	value, err := s.repo.GetValueByKey("default_sac_code")
+	if err != nil {
+		logrus.Warnf("Failed to get default SAC code, using 9987: %v", err)
+		return "9987"
+	}
+	return value
+}
+
+// GetSubscriptionSACCode retrieves the SAC code for subscription invoices
+func (s *AdminConfigService) GetSubscriptionSACCode() string {
+	value, err := s.repo.GetValueByKey("subscription_sac_code")
+	if err != nil {
+		logrus.Warnf("Failed to get subscription SAC code, using 997221: %v", err)
+		return "997221"
+	}
+	return value
+}
+
+// GetReferralReferrerReward retrieves the wallet credit for a referrer
+func (s *AdminConfigService) GetReferralReferrerReward() float64 {
+	reward, err := s.GetFloatValue("referral_referrer_reward")
+	if err != nil {
+		logrus.Warnf("Failed to get referral referrer reward, using 100: %v", err)
+		return 100
+	}
+	return reward
+}
+
+// GetReferralRefereeReward retrieves the wallet credit for a referred user
+func (s *AdminConfigService) GetReferralRefereeReward() float64 {
+	reward, err := s.GetFloatValue("referral_referee_reward")
+	if err != nil {
+		logrus.Warnf("Failed to get referral referee reward, using 50: %v", err)
+		return 50
+	}
+	return reward
+}
+
+// GetSegmentReminderDays retrieves the days before a payment segment is due to remind the customer
+func (s *AdminConfigService) GetSegmentReminderDays() string {
+	days, err := s.repo.GetValueByKey("segment_reminder_days")
+	if err != nil {
+		logrus.Warnf("Failed to get segment reminder days, using 3,1: %v", err)
+		return "3,1"
+	}
+	return days
+}
+
+// GetSegmentEscalationDays retrieves how long a payment segment stays overdue before it is escalated
+func (s *AdminConfigService) GetSegmentEscalationDays() int {
+	days, err := s.GetIntValue("segment_escalation_days")
+	if err != nil {
+		logrus.Warnf("Failed to get segment escalation days, using 3: %v", err)
+		return 3
+	}
+	return days
+}
+
+// GetSegmentEscalationAction retrieves what happens when an overdue payment segment is escalated
+func (s *AdminConfigService) GetSegmentEscalationAction() models.SegmentEscalationAction {
+	value, err := s.repo.GetValueByKey("segment_escalation_action")
+	if err != nil {
+		logrus.Warnf("Failed to get segment escalation action, using notify_admin: %v", err)
+		return models.SegmentEscalationNotifyAdmin
+	}
+
+	action := models.SegmentEscalationAction(value)
+	switch action {
+	case models.SegmentEscalationNone, models.SegmentEscalationNotifyAdmin, models.SegmentEscalationPauseAssignment:
+		return action
+	}
+	logrus.Warnf("Unknown segment escalation action %q, using notify_admin", value)
+	return models.SegmentEscalationNotifyAdmin
+}
+
+// GetSegmentAutoDebitRetryHours retrieves the hours between wallet auto-debit attempts for a due segment
+func (s *AdminConfigService) GetSegmentAutoDebitRetryHours() int {
+	hours, err := s.GetIntValue("segment_auto_debit_retry_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get segment auto-debit retry hours, using 24: %v", err)
+		return 24
+	}
+	return hours
+}
+
+// GetSubscriptionGraceDays retrieves the days a subscription keeps access after a failed auto-renewal
+func (s *AdminConfigService) GetSubscriptionGraceDays() int {
+	days, err := s.GetIntValue("subscription_grace_days")
+	if err != nil {
+		logrus.Warnf("Failed to get subscription grace days, using 3: %v", err)
+		return 3
+	}
+	return days
+}
+
+// GetSubscriptionRenewalLeadHours retrieves how many hours before the end of a term auto-renewal starts
+func (s *AdminConfigService) GetSubscriptionRenewalLeadHours() int {
+	hours, err := s.GetIntValue("subscription_renewal_lead_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get subscription renewal lead hours, using 24: %v", err)
+		return 24
+	}
+	return hours
+}
+
+// GetSubscriptionRenewalRetryHours retrieves the hours between auto-renewal attempts
+func (s *AdminConfigService) GetSubscriptionRenewalRetryHours() int {
+	hours, err := s.GetIntValue("subscription_renewal_retry_hours")
+	if err != nil {
+		logrus.Warnf("Failed to get subscription renewal retry hours, using 24: %v", err)
+		return 24
+	}
+	return hours
+}
+
+// GetFreeMaxActiveListings retrieves the active listings allowed without a subscription
+func (s *AdminConfigService) GetFreeMaxActiveListings() int {
+	listings, err := s.GetIntValue("free_max_active_listings")
+	if err != nil {
+		logrus.Warnf("Failed to get free max active listings, using 1: %v", err)
+		return 1
+	}
+	return listings
+}
+
+// GetFreeWorkerInquiryContactsPerMonth retrieves the monthly worker inquiries allowed without a subscription
+func (s *AdminConfigService) GetFreeWorkerInquiryContactsPerMonth() int {
+	contacts, err := s.GetIntValue("free_worker_inquiry_contacts_per_month")
+	if err != nil {
+		logrus.Warnf("Failed to get free worker inquiry contacts per month, using 3: %v", err)
+		return 3
+	}
+	return contacts
+}
+
+// GetPaymentAbandonAfterMinutes retrieves the minutes after which an unattempted Razorpay order is abandoned
+func (s *AdminConfigService) GetPaymentAbandonAfterMinutes() int {
+	minutes, err := s.GetIntValue("payment_abandon_after_minutes")
+	if err != nil {
+		logrus.Warnf("Failed to get payment abandon after minutes, using 60: %v", err)
+		return 60
+	}
+	return minutes
+}
+
+// GetPaymentSettlementWindowDays retrieves the days Razorpay takes to settle a captured payment
+func (s *AdminConfigService) GetPaymentSettlementWindowDays() int {
+	days, err := s.GetIntValue("payment_settlement_window_days")
+	if err != nil {
+		logrus.Warnf("Failed to get payment settlement window days, using 3: %v", err)
+		return 3
+	}
+	return days
+}
+
+// GetActivePaymentGateway retrieves the payment gateway new online payments are made through
+func (s *AdminConfigService) GetActivePaymentGateway() string {
+	value, err := s.repo.GetValueByKey("payment_gateway")
+	if err != nil {
+		logrus.Warnf("Failed to get payment gateway, using razorpay: %v", err)
+		return PaymentGatewayRazorpay
+	}
+
+	switch value {
+	case PaymentGatewayRazorpay, PaymentGatewayCashfree:
+		return value
+	}
+	logrus.Warnf("Unknown payment gateway %q, using razorpay", value)
+	return PaymentGatewayRazorpay
+}
+
+// GetRealtimeMessagesPerMinute retrieves the messages a client may send per minute on a realtime connection
+func (s *AdminConfigService) GetRealtimeMessagesPerMinute() int {
+	messages, err := s.GetIntValue("realtime_messages_per_minute")
+	if err != nil {
+		logrus.Warnf("Failed to get realtime messages per minute, using 120: %v", err)
+		return 120
+	}
+	return messages
+}
+
+// GetRealtimeMaxSubscriptions retrieves the topics a realtime connection may subscribe to at once
+func (s *AdminConfigService) GetRealtimeMaxSubscriptions() int {
+	subscriptions, err := s.GetIntValue("realtime_max_subscriptions")
+	if err != nil {
+		logrus.Warnf("Failed to get realtime max subscriptions, using 50: %v", err)
+		return 50
+	}
+	return subscriptions
+}
+
+// GetChatPushFallbackDelaySeconds returns the seconds a chat message may stay undelivered before a push notification is sent
+func (s *AdminConfigService) GetChatPushFallbackDelaySeconds() int {
+	seconds, err := s.GetIntValue("chat_push_fallback_delay_seconds")
+	if err != nil {
+		logrus.Warnf("Failed to get chat push fallback delay, using 15 seconds: %v", err)
+		return 15
+	}
+	return seconds
+}
+
+// GetChatImageMaxSizeMB returns the maximum size in MB of an image shared in chat
+func (s *AdminConfigService) GetChatImageMaxSizeMB() int {
+	size, err := s.GetIntValue("chat_image_max_size_mb")
+	if err != nil {
+		logrus.Warnf("Failed to get chat image max size, using 10 MB: %v", err)
+		return 10
+	}
+	return size
+}
+
+// GetChatFileMaxSizeMB returns the maximum size in MB of a document shared in chat
+func (s *AdminConfigService) GetChatFileMaxSizeMB() int {
+	size, err := s.GetIntValue("chat_file_max_size_mb")
+	if err != nil {
+		logrus.Warnf("Failed to get chat file max size, using 20 MB: %v", err)
+		return 20
+	}
+	return size
+}
+
+// GetChatModerationEnabled returns whether chat messages are moderated
+func (s *AdminConfigService) GetChatModerationEnabled() bool {
+	enabled, err := s.GetBoolValue("chat_moderation_enabled")
+	if err != nil {
+		logrus.Warnf("Failed to get chat moderation setting, using true: %v", err)
+		return true
+	}
+	return enabled
+}
+
+// GetChatBlockedWords returns the comma separated words filtered out of chat messages
+func (s *AdminConfigService) GetChatBlockedWords() string {
+	words, err := s.repo.GetValueByKey("chat_blocked_words")
+	if err != nil {
+		logrus.Warnf("Failed to get chat blocked words, using the default list: %v", err)
+		return "fuck,fucking,shit,bitch,bastard,asshole,chutiya,madarchod,behenchod,bhenchod,bhosdike,gandu,harami"
+	}
+	return words
+}
+
 // DynamicConfigChecker provides dynamic configuration checking capabilities
 type DynamicConfigChecker struct {
 	service *AdminConfigService
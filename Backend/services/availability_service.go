@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 	"treesindia/models"
 	"treesindia/repositories"
@@ -17,8 +18,13 @@ type AvailabilityService struct {
 	bookingRepo     *repositories.BookingRepository
 	workerAssignmentRepo *repositories.WorkerAssignmentRepository
 	userRepo        *repositories.UserRepository
+	matchingService *WorkerMatchingService
+	workerAvailabilityService *WorkerAvailabilityService
 }
 
+// virtualWorkerIDOffset marks pool reservations of confirmed bookings in the busy workers map
+const virtualWorkerIDOffset = 1000000
+
 func NewAvailabilityService() *AvailabilityService {
 	return &AvailabilityService{
 		adminConfigRepo: repositories.NewAdminConfigRepository(),
@@ -26,6 +32,8 @@ func NewAvailabilityService() *AvailabilityService {
 		bookingRepo:     repositories.NewBookingRepository(),
 		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
 		userRepo:        repositories.NewUserRepository(),
+		matchingService: NewWorkerMatchingService(),
+		workerAvailabilityService: NewWorkerAvailabilityService(),
 	}
 }
 
@@ -106,10 +114,20 @@ func (as *AvailabilityService) GetAvailableSlotsWithDuration(serviceID uint, dat
 		return nil, fmt.Errorf("failed to get worker assignments: %v", err)
 	}
 
-	// 6. Get total active workers
-	totalWorkers, err := as.getTotalActiveWorkers()
+	// 6. Get the workers qualified for the service and their calendars for the day
+	qualifiedWorkers, err := as.getQualifiedWorkerIDs(service, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get qualified workers: %v", err)
+	}
+
+	istLocation := workerCalendarLocation()
+	parsedDate, err := time.ParseInLocation("2006-01-02", date, istLocation)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total workers: %v", err)
+		return nil, fmt.Errorf("invalid date: %v", err)
+	}
+	calendars, err := as.workerAvailabilityService.LoadCalendars(qualifiedWorkers, parsedDate, parsedDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker calendars: %v", err)
 	}
 
 	// 7. Calculate available slots
@@ -120,7 +138,8 @@ func (as *AvailabilityService) GetAvailableSlotsWithDuration(serviceID uint, dat
 		serviceDurationMinutes,
 		bufferTimeMinutes,
 		workerAssignments,
-		totalWorkers,
+		qualifiedWorkers,
+		calendars,
 		serviceID,
 		location,
 	)
@@ -152,22 +171,29 @@ func (as *AvailabilityService) getWorkerAssignmentsForDate(date string) ([]model
 	return assignments, err
 }
 
-// getTotalActiveWorkers gets the total number of active workers
-func (as *AvailabilityService) getTotalActiveWorkers() (int, error) {
-	var workers []models.User
-	err := as.userRepo.FindByUserType(&workers, models.UserTypeWorker)
+// getQualifiedWorkerIDs gets the workers whose skills and service area match the service
+func (as *AvailabilityService) getQualifiedWorkerIDs(service *models.Service, location string) ([]uint, error) {
+	criteria := &MatchCriteria{Service: service}
+	if location != "" {
+		parts := strings.SplitN(location, ",", 2)
+		criteria.City = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			criteria.State = strings.TrimSpace(parts[1])
+		}
+	}
+
+	matches, err := as.matchingService.MatchWorkers(criteria)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	activeCount := 0
-	for _, worker := range workers {
-		if worker.IsActive {
-			activeCount++
+	workerIDs := []uint{}
+	for _, match := range matches {
+		if match.Eligible {
+			workerIDs = append(workerIDs, match.WorkerID)
 		}
 	}
-
-	return activeCount, nil
+	return workerIDs, nil
 }
 
 // calculateAvailableSlots calculates available slots based on worker assignments
@@ -175,7 +201,8 @@ func (as *AvailabilityService) calculateAvailableSlots(
 	date, startTimeStr, endTimeStr string,
 	serviceDurationMinutes, bufferTimeMinutes int,
 	workerAssignments []models.WorkerAssignment,
-	totalWorkers int,
+	qualifiedWorkers []uint,
+	calendars *WorkerCalendars,
 	serviceID uint,
 	location string,
 ) []AvailableSlot {
@@ -219,8 +246,25 @@ func (as *AvailabilityService) calculateAvailableSlots(
 
 	for currentTime.Before(slotEndTime) {
 		slotKey := currentTime.Format("15:04")
-		busyWorkers := len(busyWorkersMap[slotKey])
-		availableWorkers := totalWorkers - busyWorkers
+		jobEndTime := currentTime.Add(time.Duration(serviceDurationMinutes) * time.Minute)
+
+		// Qualified workers who are on shift and not on another job, less the pool reservations
+		busy := make(map[uint]bool)
+		reservations := 0
+		for _, workerID := range busyWorkersMap[slotKey] {
+			if workerID >= virtualWorkerIDOffset {
+				reservations++
+			} else {
+				busy[workerID] = true
+			}
+		}
+
+		availableWorkers := -reservations
+		for _, workerID := range qualifiedWorkers {
+			if !busy[workerID] && calendars.IsAvailable(workerID, currentTime, jobEndTime) {
+				availableWorkers++
+			}
+		}
 		
 		// Ensure we don't go below 0
 		if availableWorkers < 0 {
@@ -347,7 +391,7 @@ func (as *AvailabilityService) buildBusyWorkersMap(assignments []models.WorkerAs
 			
 			// Add a virtual worker ID (using booking ID as a unique identifier)
 			// This represents one worker slot being occupied
-			virtualWorkerID := booking.ID + 1000000 // Use a large offset to avoid conflicts
+			virtualWorkerID := booking.ID + virtualWorkerIDOffset // Use a large offset to avoid conflicts
 			
 			// Check if this virtual worker is already in the list
 			workerExists := false
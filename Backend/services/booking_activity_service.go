@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BookingActivityService records and queries the audit trail of bookings
+type BookingActivityService struct {
+	activityRepo *repositories.BookingActivityRepository
+}
+
+// NewBookingActivityService creates a new booking activity service
+func NewBookingActivityService() *BookingActivityService {
+	return &BookingActivityService{
+		activityRepo: repositories.NewBookingActivityRepository(),
+	}
+}
+
+// Record adds an entry to a booking's activity log.
+// Failures are logged but never returned, so auditing cannot break the action being audited.
+func (as *BookingActivityService) Record(bookingID uint, action models.BookingActivityAction, actor models.ActivityActor, oldValue, newValue interface{}, description string) {
+	activity := &models.BookingActivityLog{
+		BookingID:   bookingID,
+		Action:      action,
+		ActorType:   actor.Type,
+		ActorID:     actor.ID,
+		OldValue:    oldValue,
+		NewValue:    newValue,
+		Description: description,
+	}
+
+	if err := as.activityRepo.Create(activity); err != nil {
+		logrus.Errorf("Failed to record %s activity for booking %d: %v", action, bookingID, err)
+	}
+}
+
+// RecordStatusChange records a booking status transition; unchanged statuses are ignored
+func (as *BookingActivityService) RecordStatusChange(bookingID uint, oldStatus, newStatus models.BookingStatus, actor models.ActivityActor, description string) {
+	if oldStatus == newStatus {
+		return
+	}
+	if description == "" {
+		description = fmt.Sprintf("Status changed from %s to %s", oldStatus, newStatus)
+	}
+	as.Record(bookingID, models.BookingActivityStatusChanged, actor, oldStatus, newStatus, description)
+}
+
+// RecordAssignmentStatusChange records a worker assignment status transition
+func (as *BookingActivityService) RecordAssignmentStatusChange(bookingID uint, oldStatus, newStatus models.AssignmentStatus, actor models.ActivityActor, description string) {
+	if oldStatus == newStatus {
+		return
+	}
+	if description == "" {
+		description = fmt.Sprintf("Assignment status changed from %s to %s", oldStatus, newStatus)
+	}
+	as.Record(bookingID, models.BookingActivityAssignmentStatusChanged, actor, oldStatus, newStatus, description)
+}
+
+// RecordPayment records a payment or refund event on a booking
+func (as *BookingActivityService) RecordPayment(bookingID uint, action models.BookingActivityAction, payment *models.Payment, actor models.ActivityActor, description string) {
+	value := map[string]interface{}{
+		"payment_id": payment.ID,
+		"amount":     payment.Amount,
+		"method":     payment.Method,
+		"status":     payment.Status,
+	}
+	if payment.RefundAmount != nil {
+		value["refund_amount"] = *payment.RefundAmount
+	}
+	as.Record(bookingID, action, actor, nil, value, description)
+}
+
+// GetBookingActivity gets the full activity history of a booking
+func (as *BookingActivityService) GetBookingActivity(bookingID uint) ([]models.BookingActivityLog, error) {
+	return as.activityRepo.GetByBookingID(bookingID)
+}
+
+// GetActivities gets activity entries across bookings (admin)
+func (as *BookingActivityService) GetActivities(filters *repositories.BookingActivityFilters) ([]models.BookingActivityLog, *repositories.Pagination, error) {
+	return as.activityRepo.GetActivities(filters)
+}
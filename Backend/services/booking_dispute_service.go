@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxDisputeEvidenceFiles is the maximum number of photos attached to a dispute
+const maxDisputeEvidenceFiles = 5
+
+// ErrDisputeRefundIncomplete is returned when only part of a dispute's refund could be issued. The dispute
+// stays under review with the amount refunded so far, so resolving it again refunds only the rest.
+var ErrDisputeRefundIncomplete = errors.New("dispute refund was only partly issued")
+
+// BookingDisputeService handles business logic for booking disputes
+type BookingDisputeService struct {
+	disputeRepo       *repositories.BookingDisputeRepository
+	bookingRepo       *repositories.BookingRepository
+	paymentService    *PaymentService
+	cloudinaryService *CloudinaryService
+	activityService   *BookingActivityService
+	earningService    *WorkerEarningService
+}
+
+// NewBookingDisputeService creates a new booking dispute service
+func NewBookingDisputeService(cloudinaryService *CloudinaryService) *BookingDisputeService {
+	return &BookingDisputeService{
+		disputeRepo:       repositories.NewBookingDisputeRepository(),
+		bookingRepo:       repositories.NewBookingRepository(),
+		paymentService:    NewPaymentService(),
+		cloudinaryService: cloudinaryService,
+		activityService:   NewBookingActivityService(),
+		earningService:    NewWorkerEarningService(),
+	}
+}
+
+// OpenDispute raises a dispute on a booking for the customer or the assigned worker
+func (ds *BookingDisputeService) OpenDispute(userID uint, bookingID uint, req *models.CreateDisputeRequest, evidence []*multipart.FileHeader) (*models.BookingDispute, error) {
+	booking, err := ds.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	role := disputeRole(booking, userID)
+	if role == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	switch booking.Status {
+	case models.BookingStatusAssigned, models.BookingStatusInProgress, models.BookingStatusCompleted:
+	default:
+		return nil, fmt.Errorf("disputes cannot be raised for %s bookings", booking.Status)
+	}
+
+	hasActive, err := ds.disputeRepo.HasActiveDispute(bookingID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing disputes: %v", err)
+	}
+	if hasActive {
+		return nil, errors.New("you already have an open dispute for this booking")
+	}
+
+	if len(evidence) > maxDisputeEvidenceFiles {
+		return nil, fmt.Errorf("a maximum of %d evidence photos is allowed", maxDisputeEvidenceFiles)
+	}
+
+	evidenceURLs := []string{}
+	if len(evidence) > 0 {
+		if ds.cloudinaryService == nil {
+			return nil, errors.New("image upload service not available")
+		}
+		for _, file := range evidence {
+			url, err := ds.cloudinaryService.UploadImage(file, "disputes/evidence")
+			if err != nil {
+				return nil, fmt.Errorf("failed to upload evidence: %v", err)
+			}
+			evidenceURLs = append(evidenceURLs, url)
+		}
+	}
+
+	dispute := &models.BookingDispute{
+		BookingID:    booking.ID,
+		RaisedBy:     userID,
+		RaisedByRole: role,
+		Reason:       req.Reason,
+		Description:  req.Description,
+		Evidence:     evidenceURLs,
+		Status:       models.DisputeStatusOpen,
+	}
+
+	if err := ds.disputeRepo.Create(dispute); err != nil {
+		return nil, fmt.Errorf("failed to create dispute: %v", err)
+	}
+
+	actor := models.UserActor(userID)
+	if role == string(models.UserTypeWorker) {
+		actor = models.WorkerActor(userID)
+	}
+	ds.activityService.Record(booking.ID, models.BookingActivityDisputeOpened, actor, nil, dispute.Reason, fmt.Sprintf("Dispute %d raised: %s", dispute.ID, dispute.Reason))
+
+	logrus.Infof("Dispute %d opened by %s %d for booking %d", dispute.ID, role, userID, booking.ID)
+
+	go NotifyDisputeOpened(dispute, booking)
+
+	return dispute, nil
+}
+
+// GetBookingDisputes gets the disputes for a booking visible to the given user.
+// Internal admin comments are only returned to admins.
+func (ds *BookingDisputeService) GetBookingDisputes(userID uint, userType string, bookingID uint) ([]models.BookingDispute, error) {
+	booking, err := ds.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	isAdmin := userType == string(models.UserTypeAdmin)
+	if !isAdmin && disputeRole(booking, userID) == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	disputes, err := ds.disputeRepo.GetByBookingID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disputes: %v", err)
+	}
+
+	if !isAdmin {
+		for i := range disputes {
+			disputes[i].Comments = publicDisputeComments(disputes[i].Comments)
+		}
+	}
+
+	return disputes, nil
+}
+
+// GetDispute gets a dispute by ID (admin)
+func (ds *BookingDisputeService) GetDispute(disputeID uint) (*models.BookingDispute, error) {
+	dispute, err := ds.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+	return dispute, nil
+}
+
+// GetDisputes gets disputes with filters (admin)
+func (ds *BookingDisputeService) GetDisputes(filters *repositories.DisputeFilters) ([]models.BookingDispute, *repositories.Pagination, error) {
+	return ds.disputeRepo.GetDisputes(filters)
+}
+
+// AddComment adds an admin comment to a dispute and moves it under review
+func (ds *BookingDisputeService) AddComment(adminID uint, disputeID uint, req *models.AddDisputeCommentRequest) (*models.DisputeComment, error) {
+	dispute, err := ds.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+
+	if dispute.Status == models.DisputeStatusResolved {
+		return nil, errors.New("dispute is already resolved")
+	}
+
+	comment := &models.DisputeComment{
+		DisputeID:  dispute.ID,
+		UserID:     adminID,
+		AuthorRole: string(models.UserTypeAdmin),
+		Comment:    req.Comment,
+		IsInternal: req.IsInternal,
+	}
+
+	if err := ds.disputeRepo.CreateComment(comment); err != nil {
+		return nil, fmt.Errorf("failed to add comment: %v", err)
+	}
+
+	if dispute.Status == models.DisputeStatusOpen {
+		dispute.Status = models.DisputeStatusUnderReview
+		if err := ds.disputeRepo.Update(dispute); err != nil {
+			logrus.Errorf("Failed to move dispute %d under review: %v", dispute.ID, err)
+		}
+	}
+
+	if !comment.IsInternal {
+		booking, err := ds.bookingRepo.GetByID(dispute.BookingID)
+		if err == nil {
+			go NotifyDisputeUpdated(dispute, booking, comment.Comment)
+		}
+	}
+
+	return comment, nil
+}
+
+// ResolveDispute records the admin decision on a dispute and issues any refund it calls for.
+// The dispute stays locked while it is resolved, so an admin resolving it at the same time waits
+// and then finds it resolved, or refunds only what this attempt could not.
+func (ds *BookingDisputeService) ResolveDispute(adminID uint, disputeID uint, req *models.ResolveDisputeRequest) (*models.BookingDispute, error) {
+	var dispute *models.BookingDispute
+	var booking *models.Booking
+	var refundErr error
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		disputeRepo := ds.disputeRepo.WithTx(tx)
+		if err := disputeRepo.LockForUpdate(disputeID); err != nil {
+			return errors.New("dispute not found")
+		}
+
+		var err error
+		dispute, err = disputeRepo.GetByID(disputeID)
+		if err != nil {
+			return errors.New("dispute not found")
+		}
+
+		if dispute.Status == models.DisputeStatusResolved {
+			return errors.New("dispute is already resolved")
+		}
+
+		booking, err = ds.bookingRepo.GetByID(dispute.BookingID)
+		if err != nil {
+			return errors.New("booking not found")
+		}
+
+		switch req.Resolution {
+		case models.DisputeResolutionRefund, models.DisputeResolutionPartialRefund:
+			refunded, err := ds.refundBooking(booking, dispute, req)
+			if refunded > 0 {
+				total := roundCurrency(disputeRefunded(dispute) + refunded)
+				dispute.RefundAmount = &total
+				ds.deductWorkerEarning(booking, dispute, refunded)
+			}
+			if errors.Is(err, ErrDisputeRefundIncomplete) {
+				// Keep the dispute open with what was refunded so far
+				dispute.Status = models.DisputeStatusUnderReview
+				if updateErr := disputeRepo.Update(dispute); updateErr != nil {
+					logrus.Errorf("Failed to record partial refund of dispute %d: %v", dispute.ID, updateErr)
+				}
+				refundErr = err
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		resolution := req.Resolution
+		dispute.Status = models.DisputeStatusResolved
+		dispute.Resolution = &resolution
+		dispute.ResolutionNotes = req.Notes
+		dispute.ResolvedBy = &adminID
+		dispute.ResolvedAt = &now
+
+		if err := disputeRepo.Update(dispute); err != nil {
+			return fmt.Errorf("failed to update dispute: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refundErr != nil {
+		return dispute, refundErr
+	}
+
+	ds.activityService.Record(booking.ID, models.BookingActivityDisputeResolved, models.AdminActor(adminID), nil, req.Resolution, fmt.Sprintf("Dispute %d resolved: %s", dispute.ID, req.Notes))
+
+	logrus.Infof("Dispute %d for booking %d resolved as %s by admin %d", dispute.ID, booking.ID, req.Resolution, adminID)
+
+	go NotifyDisputeResolved(dispute, booking)
+
+	return dispute, nil
+}
+
+// refundBooking refunds the booking's completed payments for a dispute and returns the amount refunded.
+// A full refund returns every payment; a partial refund is taken from the payments in order until covered,
+// less what an earlier, incomplete attempt already refunded. When some payments could not be refunded
+// the amount that was refunded is returned with ErrDisputeRefundIncomplete.
+func (ds *BookingDisputeService) refundBooking(booking *models.Booking, dispute *models.BookingDispute, req *models.ResolveDisputeRequest) (float64, error) {
+	payments, err := ds.paymentService.GetCompletedBookingPayments(booking.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get booking payments: %v", err)
+	}
+
+	var totalRefundable float64
+	for i := range payments {
+		totalRefundable += refundableAmount(&payments[i])
+	}
+	totalRefundable = roundCurrency(totalRefundable)
+	if totalRefundable <= 0 {
+		if disputeRefunded(dispute) > 0 {
+			// An earlier attempt's refunds have since covered the payments
+			return 0, nil
+		}
+		return 0, errors.New("booking has no completed payments to refund")
+	}
+
+	remaining := totalRefundable
+	if req.Resolution == models.DisputeResolutionPartialRefund {
+		if req.RefundAmount == nil || *req.RefundAmount <= 0 {
+			return 0, errors.New("refund amount is required for a partial refund")
+		}
+		remaining = roundCurrency(*req.RefundAmount - disputeRefunded(dispute))
+		if remaining <= 0 {
+			return 0, nil
+		}
+		if remaining > totalRefundable {
+			return 0, fmt.Errorf("refund amount cannot exceed the amount not yet refunded (₹%.2f)", totalRefundable)
+		}
+	}
+
+	var refunded float64
+	var failed []string
+	for _, payment := range payments {
+		if remaining <= 0 {
+			break
+		}
+		amount := roundCurrency(remaining)
+		if refundable := refundableAmount(&payment); amount > refundable {
+			amount = refundable
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		_, err := ds.paymentService.RefundPayment(payment.ID, &models.RefundPaymentRequest{
+			RefundAmount: amount,
+			RefundReason: fmt.Sprintf("Dispute resolution: %s", req.Resolution),
+			RefundMethod: req.RefundMethod,
+			Notes:        fmt.Sprintf("Refund for dispute %d on booking %d", dispute.ID, booking.ID),
+		})
+		if err != nil {
+			logrus.Errorf("Failed to refund payment %d for dispute %d: %v", payment.ID, dispute.ID, err)
+			failed = append(failed, fmt.Sprintf("payment %d: %v", payment.ID, err))
+			continue
+		}
+
+		refunded += amount
+		remaining -= amount
+	}
+
+	refunded = roundCurrency(refunded)
+	if len(failed) > 0 {
+		if refunded == 0 && disputeRefunded(dispute) == 0 {
+			return 0, fmt.Errorf("failed to refund payment: %s", strings.Join(failed, "; "))
+		}
+		return refunded, fmt.Errorf("%w: ₹%.2f still to refund (%s)", ErrDisputeRefundIncomplete, roundCurrency(remaining), strings.Join(failed, "; "))
+	}
+
+	return refunded, nil
+}
+
+// deductWorkerEarning takes a dispute refund off the worker's unpaid earning for the booking, so the
+// platform does not pay both the customer and the worker. Earnings already paid out are left for the
+// admin to recover from the worker.
+func (ds *BookingDisputeService) deductWorkerEarning(booking *models.Booking, dispute *models.BookingDispute, refunded float64) {
+	if _, err := ds.earningService.DeductRefund(booking.ID, refunded); err != nil {
+		logrus.Errorf("Failed to deduct refund of dispute %d from the worker earning for booking %d: %v", dispute.ID, booking.ID, err)
+	}
+}
+
+// disputeRefunded returns what has been refunded for a dispute so far
+func disputeRefunded(dispute *models.BookingDispute) float64 {
+	if dispute.RefundAmount == nil {
+		return 0
+	}
+	return *dispute.RefundAmount
+}
+
+// disputeRole returns "user" for the booking's customer, "worker" for its assigned worker, or "" otherwise
+func disputeRole(booking *models.Booking, userID uint) string {
+	if booking.UserID == userID {
+		return "user"
+	}
+	if booking.WorkerAssignment != nil && booking.WorkerAssignment.WorkerID == userID {
+		return string(models.UserTypeWorker)
+	}
+	return ""
+}
+
+// publicDisputeComments filters out internal admin notes
+func publicDisputeComments(comments []models.DisputeComment) []models.DisputeComment {
+	public := []models.DisputeComment{}
+	for _, comment := range comments {
+		if !comment.IsInternal {
+			public = append(public, comment)
+		}
+	}
+	return public
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BookingRescheduleService moves confirmed bookings to another time, with the assigned worker's agreement
+type BookingRescheduleService struct {
+	rescheduleRepo            *repositories.BookingRescheduleRepository
+	bookingRepo               *repositories.BookingRepository
+	workerAssignmentRepo      *repositories.WorkerAssignmentRepository
+	bookingService            *BookingService
+	availabilityService       *AvailabilityService
+	workerAvailabilityService *WorkerAvailabilityService
+	activityService           *BookingActivityService
+	adminConfigService        *AdminConfigService
+}
+
+// NewBookingRescheduleService creates a new booking reschedule service
+func NewBookingRescheduleService() *BookingRescheduleService {
+	return &BookingRescheduleService{
+		rescheduleRepo:            repositories.NewBookingRescheduleRepository(),
+		bookingRepo:               repositories.NewBookingRepository(),
+		workerAssignmentRepo:      repositories.NewWorkerAssignmentRepository(),
+		bookingService:            NewBookingService(),
+		availabilityService:       NewAvailabilityService(),
+		workerAvailabilityService: NewWorkerAvailabilityService(),
+		activityService:           NewBookingActivityService(),
+		adminConfigService:        NewAdminConfigService(),
+	}
+}
+
+// RequestReschedule asks for a booking to move to a new time. Bookings without a worker, and
+// admin requests with Force set, move right away; otherwise the assigned worker has to accept.
+func (brs *BookingRescheduleService) RequestReschedule(actorID uint, role models.ActivityActorType, bookingID uint, req *models.CreateRescheduleRequest) (*models.BookingRescheduleRequest, error) {
+	booking, err := brs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	// 1. Check who may move the booking and whether it can still be moved
+	if role == models.ActivityActorUser && booking.UserID != actorID {
+		return nil, errors.New("unauthorized")
+	}
+	if booking.Status != models.BookingStatusConfirmed && booking.Status != models.BookingStatusAssigned {
+		return nil, fmt.Errorf("%s booking cannot be rescheduled", booking.Status)
+	}
+	if booking.ScheduledTime == nil || booking.ScheduledEndTime == nil {
+		return nil, errors.New("booking has no scheduled time")
+	}
+	if _, err := brs.rescheduleRepo.GetPendingByBookingID(booking.ID); err == nil {
+		return nil, errors.New("booking already has a pending reschedule request")
+	}
+
+	if role == models.ActivityActorUser {
+		cutoffHours := brs.adminConfigService.GetBookingRescheduleCutoffHours()
+		if time.Until(*booking.ScheduledTime) < time.Duration(cutoffHours)*time.Hour {
+			return nil, fmt.Errorf("bookings cannot be rescheduled less than %d hours before they start", cutoffHours)
+		}
+
+		maxReschedules := brs.adminConfigService.GetMaxReschedulesPerBooking()
+		count, err := brs.rescheduleRepo.CountAccepted(booking.ID, models.ActivityActorUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count reschedules: %v", err)
+		}
+		if int(count) >= maxReschedules {
+			return nil, fmt.Errorf("booking can be rescheduled at most %d times", maxReschedules)
+		}
+	}
+
+	// 2. Work out the new time, keeping the booking's duration
+	newStart, err := parseRescheduleTime(req.ScheduledDate, req.ScheduledTime)
+	if err != nil {
+		return nil, err
+	}
+	if !newStart.After(time.Now()) {
+		return nil, errors.New("new time must be in the future")
+	}
+	if newStart.Equal(*booking.ScheduledTime) {
+		return nil, errors.New("booking is already scheduled at this time")
+	}
+	newEnd := newStart.Add(booking.ScheduledEndTime.Sub(*booking.ScheduledTime))
+
+	// 3. Validate the new slot
+	assignment := activeAssignment(booking)
+	if err := brs.validateSlot(booking, assignment, newStart, newEnd); err != nil {
+		return nil, err
+	}
+
+	request := &models.BookingRescheduleRequest{
+		BookingID:           booking.ID,
+		RequestedBy:         actorID,
+		RequesterRole:       role,
+		OldScheduledTime:    *booking.ScheduledTime,
+		OldScheduledEndTime: *booking.ScheduledEndTime,
+		NewScheduledTime:    newStart,
+		NewScheduledEndTime: newEnd,
+		Reason:              req.Reason,
+		Status:              models.RescheduleStatusPending,
+	}
+	if assignment != nil {
+		request.WorkerID = &assignment.WorkerID
+	}
+	actor := models.ActivityActor{Type: role, ID: &actorID}
+
+	// 4. Move the booking now when nobody else has to agree
+	if assignment == nil || (role == models.ActivityActorAdmin && req.Force) {
+		notes := "Rescheduled before a worker was assigned"
+		if assignment != nil {
+			notes = "Rescheduled by admin"
+		}
+		if err := brs.applyReschedule(request, booking, assignment, notes); err != nil {
+			return nil, err
+		}
+		brs.activityService.Record(booking.ID, models.BookingActivityRescheduled, actor, request.OldScheduledTime, request.NewScheduledTime, rescheduleDescription(request))
+		go NotifyBookingRescheduled(request, booking)
+		return request, nil
+	}
+
+	if err := brs.rescheduleRepo.Create(request); err != nil {
+		return nil, fmt.Errorf("failed to create reschedule request: %v", err)
+	}
+
+	logrus.Infof("Reschedule request %d created for booking %d by %s %d", request.ID, booking.ID, role, actorID)
+	brs.activityService.Record(booking.ID, models.BookingActivityRescheduleRequested, actor, request.OldScheduledTime, request.NewScheduledTime, rescheduleDescription(request))
+	go NotifyRescheduleRequested(request, booking)
+
+	return request, nil
+}
+
+// CancelRequest withdraws a pending reschedule request
+func (brs *BookingRescheduleService) CancelRequest(actorID uint, role models.ActivityActorType, bookingID uint, requestID uint) (*models.BookingRescheduleRequest, error) {
+	request, err := brs.rescheduleRepo.GetByID(requestID)
+	if err != nil || request.BookingID != bookingID {
+		return nil, errors.New("reschedule request not found")
+	}
+	if role == models.ActivityActorUser && (request.Booking == nil || request.Booking.UserID != actorID) {
+		return nil, errors.New("unauthorized")
+	}
+	if request.Status != models.RescheduleStatusPending {
+		return nil, fmt.Errorf("reschedule request is already %s", request.Status)
+	}
+
+	now := time.Now()
+	request.Status = models.RescheduleStatusCancelled
+	request.RespondedAt = &now
+	if err := brs.rescheduleRepo.Update(request); err != nil {
+		return nil, fmt.Errorf("failed to cancel reschedule request: %v", err)
+	}
+
+	return request, nil
+}
+
+// AcceptReschedule lets the assigned worker accept the new time, moving the booking and assignment together
+func (brs *BookingRescheduleService) AcceptReschedule(workerID uint, requestID uint, notes string) (*models.BookingRescheduleRequest, error) {
+	request, booking, assignment, err := brs.getWorkerRequest(workerID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The worker's calendar may have changed since the request was made
+	if err := brs.validateSlot(booking, assignment, request.NewScheduledTime, request.NewScheduledEndTime); err != nil {
+		return nil, err
+	}
+
+	request.ResponseNotes = notes
+	if err := brs.applyReschedule(request, booking, assignment, "Rescheduled with the worker's agreement"); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Worker %d accepted reschedule request %d of booking %d", workerID, request.ID, booking.ID)
+	brs.activityService.Record(booking.ID, models.BookingActivityRescheduled, models.WorkerActor(workerID), request.OldScheduledTime, request.NewScheduledTime, rescheduleDescription(request))
+	go NotifyBookingRescheduled(request, booking)
+
+	return request, nil
+}
+
+// DeclineReschedule lets the assigned worker decline the new time; the booking keeps its current time
+func (brs *BookingRescheduleService) DeclineReschedule(workerID uint, requestID uint, notes string) (*models.BookingRescheduleRequest, error) {
+	request, booking, _, err := brs.getWorkerRequest(workerID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = models.RescheduleStatusDeclined
+	request.RespondedAt = &now
+	request.ResponseNotes = notes
+	if err := brs.rescheduleRepo.Update(request); err != nil {
+		return nil, fmt.Errorf("failed to decline reschedule request: %v", err)
+	}
+
+	logrus.Infof("Worker %d declined reschedule request %d of booking %d", workerID, request.ID, booking.ID)
+	brs.activityService.Record(booking.ID, models.BookingActivityRescheduleDeclined, models.WorkerActor(workerID), nil, request.NewScheduledTime, notes)
+	go NotifyRescheduleDeclined(request, booking)
+
+	return request, nil
+}
+
+// GetBookingRequests gets the reschedule history of a booking
+func (brs *BookingRescheduleService) GetBookingRequests(actorID uint, role models.ActivityActorType, bookingID uint) ([]models.BookingRescheduleRequest, error) {
+	booking, err := brs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+	if role == models.ActivityActorUser && booking.UserID != actorID {
+		return nil, errors.New("unauthorized")
+	}
+	return brs.rescheduleRepo.GetByBookingID(bookingID)
+}
+
+// GetWorkerPendingRequests gets the reschedule requests waiting for a worker's answer
+func (brs *BookingRescheduleService) GetWorkerPendingRequests(workerID uint) ([]models.BookingRescheduleRequest, error) {
+	return brs.rescheduleRepo.GetPendingForWorker(workerID)
+}
+
+// GetRequests gets reschedule requests with filters (admin)
+func (brs *BookingRescheduleService) GetRequests(filters *repositories.RescheduleFilters) ([]models.BookingRescheduleRequest, *repositories.Pagination, error) {
+	return brs.rescheduleRepo.GetRequests(filters)
+}
+
+// ExpireStaleRequests expires pending requests that can no longer be applied
+func (brs *BookingRescheduleService) ExpireStaleRequests() error {
+	requests, err := brs.rescheduleRepo.GetStalePending(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get stale reschedule requests: %v", err)
+	}
+
+	for i := range requests {
+		request := &requests[i]
+		request.Status = models.RescheduleStatusExpired
+		if err := brs.rescheduleRepo.Update(request); err != nil {
+			logrus.Errorf("Failed to expire reschedule request %d: %v", request.ID, err)
+			continue
+		}
+		brs.activityService.Record(request.BookingID, models.BookingActivityRescheduleDeclined, models.SystemActor(), nil, request.NewScheduledTime, "Reschedule request expired without an answer")
+	}
+
+	if len(requests) > 0 {
+		logrus.Infof("Expired %d stale reschedule requests", len(requests))
+	}
+	return nil
+}
+
+// validateSlot checks the new time against service availability and, when a worker is assigned, their calendar
+func (brs *BookingRescheduleService) validateSlot(booking *models.Booking, assignment *models.WorkerAssignment, newStart, newEnd time.Time) error {
+	var address models.BookingAddress
+	if booking.Address == nil {
+		return errors.New("booking has no address")
+	}
+	if err := json.Unmarshal([]byte(*booking.Address), &address); err != nil {
+		return fmt.Errorf("invalid booking address: %v", err)
+	}
+
+	location := workerCalendarLocation()
+	date := newStart.In(location).Format("2006-01-02")
+	slotTime := newStart.In(location).Format("15:04")
+	availability, err := brs.availabilityService.GetAvailableSlotsWithDuration(booking.ServiceID, date, address.City+", "+address.State, booking.QuoteDuration)
+	if err != nil {
+		return fmt.Errorf("failed to check availability: %v", err)
+	}
+
+	var slot *AvailableSlot
+	for i := range availability.AvailableSlots {
+		if availability.AvailableSlots[i].Time == slotTime {
+			slot = &availability.AvailableSlots[i]
+			break
+		}
+	}
+	if slot == nil {
+		return fmt.Errorf("%s on %s is not a bookable time slot", slotTime, date)
+	}
+
+	// Without a worker any qualified worker has to be free; with one, only they matter
+	if assignment == nil {
+		if !slot.IsAvailable {
+			return fmt.Errorf("no worker is available at %s on %s", slotTime, date)
+		}
+		return nil
+	}
+
+	hasConflict, err := brs.bookingService.checkWorkerBookingConflict(assignment.WorkerID, newStart, newEnd, booking.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check worker conflicts: %v", err)
+	}
+	if hasConflict {
+		return errors.New("assigned worker has another booking at the new time")
+	}
+	if err := brs.workerAvailabilityService.CheckWorkerAvailable(assignment.WorkerID, newStart, newEnd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyReschedule moves the booking and its assignment to the request's new time in one transaction
+func (brs *BookingRescheduleService) applyReschedule(request *models.BookingRescheduleRequest, booking *models.Booking, assignment *models.WorkerAssignment, notes string) error {
+	now := time.Now()
+	request.Status = models.RescheduleStatusAccepted
+	request.RespondedAt = &now
+
+	if assignment != nil {
+		if assignment.Status == models.AssignmentStatusAccepted {
+			assignment.AcceptedAt = &now
+		}
+		assignment.AcceptanceNotes = notes
+	}
+
+	if err := brs.rescheduleRepo.ApplyReschedule(request, assignment); err != nil {
+		return fmt.Errorf("failed to reschedule booking: %v", err)
+	}
+
+	booking.ScheduledTime = &request.NewScheduledTime
+	booking.ScheduledEndTime = &request.NewScheduledEndTime
+	return nil
+}
+
+// getWorkerRequest loads a pending request addressed to the worker together with its booking and assignment
+func (brs *BookingRescheduleService) getWorkerRequest(workerID uint, requestID uint) (*models.BookingRescheduleRequest, *models.Booking, *models.WorkerAssignment, error) {
+	request, err := brs.rescheduleRepo.GetByID(requestID)
+	if err != nil {
+		return nil, nil, nil, errors.New("reschedule request not found")
+	}
+	if request.WorkerID == nil || *request.WorkerID != workerID {
+		return nil, nil, nil, errors.New("unauthorized")
+	}
+	if request.Status != models.RescheduleStatusPending {
+		return nil, nil, nil, fmt.Errorf("reschedule request is already %s", request.Status)
+	}
+
+	booking, err := brs.bookingRepo.GetByID(request.BookingID)
+	if err != nil {
+		return nil, nil, nil, errors.New("booking not found")
+	}
+	assignment := activeAssignment(booking)
+	if assignment == nil || assignment.WorkerID != workerID {
+		return nil, nil, nil, errors.New("worker is no longer assigned to this booking")
+	}
+
+	return request, booking, assignment, nil
+}
+
+// activeAssignment returns the booking's assignment while the worker still holds it
+func activeAssignment(booking *models.Booking) *models.WorkerAssignment {
+	if booking.WorkerAssignment == nil {
+		return nil
+	}
+	switch booking.WorkerAssignment.Status {
+	case models.AssignmentStatusAssigned, models.AssignmentStatusAccepted:
+		return booking.WorkerAssignment
+	}
+	return nil
+}
+
+// parseRescheduleTime parses a date and HH:MM time given in IST
+func parseRescheduleTime(date string, clock string) (time.Time, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, errors.New("invalid scheduled date, expected YYYY-MM-DD")
+	}
+	hm, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, errors.New("invalid scheduled time, expected HH:MM")
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hm.Hour(), hm.Minute(), 0, 0, workerCalendarLocation()), nil
+}
+
+// rescheduleDescription describes a reschedule for the activity log
+func rescheduleDescription(request *models.BookingRescheduleRequest) string {
+	location := workerCalendarLocation()
+	description := fmt.Sprintf("Moved from %s to %s", request.OldScheduledTime.In(location).Format("02 Jan 2006 15:04"), request.NewScheduledTime.In(location).Format("02 Jan 2006 15:04"))
+	if request.Reason != "" {
+		description += ": " + request.Reason
+	}
+	return description
+}
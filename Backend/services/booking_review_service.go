@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// BookingReviewService handles business logic for booking reviews
+type BookingReviewService struct {
+	reviewRepo         *repositories.BookingReviewRepository
+	bookingRepo        *repositories.BookingRepository
+	workerRepo         *repositories.WorkerRepository
+	adminConfigService *AdminConfigService
+}
+
+// NewBookingReviewService creates a new booking review service
+func NewBookingReviewService() *BookingReviewService {
+	return &BookingReviewService{
+		reviewRepo:         repositories.NewBookingReviewRepository(),
+		bookingRepo:        repositories.NewBookingRepository(),
+		workerRepo:         repositories.NewWorkerRepository(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// SubmitReview creates the review for a completed booking
+func (rs *BookingReviewService) SubmitReview(userID uint, bookingID uint, req *models.ReviewBookingRequest) (*models.BookingReview, error) {
+	booking, err := rs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	if booking.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if booking.Status != models.BookingStatusCompleted {
+		return nil, errors.New("only completed bookings can be reviewed")
+	}
+
+	if err := validateReviewCategories(req.Categories); err != nil {
+		return nil, err
+	}
+
+	existing, err := rs.reviewRepo.GetByBookingID(bookingID)
+	if err == nil && existing != nil {
+		return nil, errors.New("booking has already been reviewed")
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing review: %v", err)
+	}
+
+	editWindow := time.Duration(rs.adminConfigService.GetReviewEditWindowHours()) * time.Hour
+
+	review := &models.BookingReview{
+		BookingID:     booking.ID,
+		UserID:        userID,
+		Rating:        req.Rating,
+		Review:        req.Review,
+		Categories:    req.Categories,
+		Status:        models.ReviewStatusPublished,
+		EditableUntil: time.Now().Add(editWindow),
+	}
+
+	if booking.WorkerAssignment != nil {
+		assignmentID := booking.WorkerAssignment.ID
+		workerID := booking.WorkerAssignment.WorkerID
+		review.WorkerAssignmentID = &assignmentID
+		review.WorkerID = &workerID
+	}
+
+	if err := rs.reviewRepo.Create(review); err != nil {
+		return nil, fmt.Errorf("failed to create review: %v", err)
+	}
+
+	if review.WorkerID != nil {
+		rs.refreshWorkerRating(*review.WorkerID)
+	}
+
+	logrus.Infof("Review %d submitted for booking %d by user %d", review.ID, bookingID, userID)
+	return review, nil
+}
+
+// UpdateReview updates an existing review while it is still within the edit window
+func (rs *BookingReviewService) UpdateReview(userID uint, bookingID uint, req *models.ReviewBookingRequest) (*models.BookingReview, error) {
+	review, err := rs.reviewRepo.GetByBookingID(bookingID)
+	if err != nil {
+		return nil, errors.New("review not found")
+	}
+
+	if review.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if !review.IsEditable() {
+		return nil, errors.New("review can no longer be edited")
+	}
+
+	if review.Status == models.ReviewStatusHidden {
+		return nil, errors.New("review has been hidden by moderation and cannot be edited")
+	}
+
+	if err := validateReviewCategories(req.Categories); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	review.Rating = req.Rating
+	review.Review = req.Review
+	review.Categories = req.Categories
+	review.EditedAt = &now
+
+	if err := rs.reviewRepo.Update(review); err != nil {
+		return nil, fmt.Errorf("failed to update review: %v", err)
+	}
+
+	if review.WorkerID != nil {
+		rs.refreshWorkerRating(*review.WorkerID)
+	}
+
+	return review, nil
+}
+
+// GetBookingReview gets the review for a booking
+func (rs *BookingReviewService) GetBookingReview(bookingID uint) (*models.BookingReview, error) {
+	return rs.reviewRepo.GetByBookingID(bookingID)
+}
+
+// GetWorkerReviews gets published reviews for a worker along with the rating summary.
+// workerID is the ID of the worker record, as used by the public worker endpoints.
+func (rs *BookingReviewService) GetWorkerReviews(workerID uint, page, limit int) ([]models.PublicReview, *models.WorkerRatingSummary, *repositories.Pagination, error) {
+	worker, err := rs.workerRepo.GetByID(workerID)
+	if err != nil {
+		return nil, nil, nil, errors.New("worker not found")
+	}
+
+	reviews, pagination, err := rs.reviewRepo.GetPublishedByWorker(worker.UserID, page, limit)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get reviews: %v", err)
+	}
+
+	summary, err := rs.GetWorkerRatingSummary(worker.UserID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	publicReviews := make([]models.PublicReview, len(reviews))
+	for i, review := range reviews {
+		publicReviews[i] = toPublicReview(&review)
+	}
+
+	return publicReviews, summary, pagination, nil
+}
+
+// GetWorkerRatingSummary calculates the rating summary for a worker (by worker user ID)
+func (rs *BookingReviewService) GetWorkerRatingSummary(workerUserID uint) (*models.WorkerRatingSummary, error) {
+	average, total, err := rs.reviewRepo.GetWorkerRatingAggregate(workerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating aggregate: %v", err)
+	}
+
+	distribution, err := rs.reviewRepo.GetWorkerRatingDistribution(workerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating distribution: %v", err)
+	}
+
+	categoryAverages, err := rs.reviewRepo.GetWorkerCategoryAverages(workerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category averages: %v", err)
+	}
+
+	return &models.WorkerRatingSummary{
+		AverageRating:      roundRating(average),
+		TotalReviews:       total,
+		RatingDistribution: distribution,
+		CategoryAverages:   categoryAverages,
+	}, nil
+}
+
+// GetReviews gets reviews with filters (admin)
+func (rs *BookingReviewService) GetReviews(filters *repositories.ReviewFilters) ([]models.BookingReview, *repositories.Pagination, error) {
+	return rs.reviewRepo.GetReviews(filters)
+}
+
+// ModerateReview changes the moderation status of a review (admin)
+func (rs *BookingReviewService) ModerateReview(reviewID uint, adminID uint, req *models.ModerateReviewRequest) (*models.BookingReview, error) {
+	review, err := rs.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("review not found")
+	}
+
+	now := time.Now()
+	review.Status = req.Status
+	review.ModeratedBy = &adminID
+	review.ModeratedAt = &now
+	review.ModerationNotes = req.Notes
+
+	if err := rs.reviewRepo.Update(review); err != nil {
+		return nil, fmt.Errorf("failed to moderate review: %v", err)
+	}
+
+	// Hidden reviews are excluded from the aggregate, so recalculate it
+	if review.WorkerID != nil {
+		rs.refreshWorkerRating(*review.WorkerID)
+	}
+
+	logrus.Infof("Review %d moderated to %s by admin %d", reviewID, req.Status, adminID)
+	return review, nil
+}
+
+// refreshWorkerRating recalculates the worker's aggregate rating from published reviews
+func (rs *BookingReviewService) refreshWorkerRating(workerUserID uint) {
+	average, total, err := rs.reviewRepo.GetWorkerRatingAggregate(workerUserID)
+	if err != nil {
+		logrus.Errorf("Failed to calculate rating for worker user %d: %v", workerUserID, err)
+		return
+	}
+
+	if err := rs.workerRepo.UpdateRatingSummaryByUserID(workerUserID, roundRating(average), total); err != nil {
+		logrus.Errorf("Failed to update rating for worker user %d: %v", workerUserID, err)
+	}
+}
+
+// validateReviewCategories ensures every category score is within the 1-5 range
+func validateReviewCategories(categories map[string]int) error {
+	for category, score := range categories {
+		if score < 1 || score > 5 {
+			return fmt.Errorf("category score for %s must be between 1 and 5", category)
+		}
+	}
+	return nil
+}
+
+// roundRating rounds a rating to two decimal places
+func roundRating(rating float64) float64 {
+	return math.Round(rating*100) / 100
+}
+
+// toPublicReview converts a review to its public representation
+func toPublicReview(review *models.BookingReview) models.PublicReview {
+	publicReview := models.PublicReview{
+		ID:         review.ID,
+		BookingID:  review.BookingID,
+		Rating:     review.Rating,
+		Review:     review.Review,
+		Categories: review.Categories,
+		CreatedAt:  review.CreatedAt,
+	}
+	if review.User != nil {
+		publicReview.ReviewerName = review.User.Name
+	}
+	if review.Booking != nil {
+		publicReview.ServiceName = review.Booking.Service.Name
+	}
+	return publicReview
+}
@@ -0,0 +1,710 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"treesindia/database"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxSeriesOccurrences caps how many visits a single booking series can plan
+const maxSeriesOccurrences = 52
+
+// occurrenceProcessingTimeout is how long an occurrence can stay processing before it is taken to be
+// abandoned by a run that stopped, and a later run may claim it again
+const occurrenceProcessingTimeout = 10 * time.Minute
+
+// BookingSeriesService manages recurring bookings and books their occurrences as they come due
+type BookingSeriesService struct {
+	seriesRepo                *repositories.BookingSeriesRepository
+	serviceRepo               *repositories.ServiceRepository
+	serviceAreaRepo           *repositories.ServiceAreaRepository
+	bookingRepo               *repositories.BookingRepository
+	userRepo                  *repositories.UserRepository
+	workerAssignmentRepo      *repositories.WorkerAssignmentRepository
+	adminConfigRepo           *repositories.AdminConfigRepository
+	adminConfigService        *AdminConfigService
+	bookingService            *BookingService
+	availabilityService       *AvailabilityService
+	workerAvailabilityService *WorkerAvailabilityService
+	walletService             *UnifiedWalletService
+	activityService           *BookingActivityService
+	stateMachine              *BookingStateMachine
+	notificationService       *NotificationService
+}
+
+// NewBookingSeriesService creates a new booking series service
+func NewBookingSeriesService() *BookingSeriesService {
+	return &BookingSeriesService{
+		seriesRepo:                repositories.NewBookingSeriesRepository(),
+		serviceRepo:               repositories.NewServiceRepository(),
+		serviceAreaRepo:           repositories.NewServiceAreaRepository(),
+		bookingRepo:               repositories.NewBookingRepository(),
+		userRepo:                  repositories.NewUserRepository(),
+		workerAssignmentRepo:      repositories.NewWorkerAssignmentRepository(),
+		adminConfigRepo:           repositories.NewAdminConfigRepository(),
+		adminConfigService:        NewAdminConfigService(),
+		bookingService:            NewBookingService(),
+		availabilityService:       NewAvailabilityService(),
+		workerAvailabilityService: NewWorkerAvailabilityService(),
+		walletService:             NewUnifiedWalletService(),
+		activityService:           NewBookingActivityService(),
+		stateMachine:              NewBookingStateMachine(),
+		notificationService:       NewNotificationService(),
+	}
+}
+
+// CreateSeries creates a recurring booking and plans its occurrences
+func (bss *BookingSeriesService) CreateSeries(userID uint, req *models.CreateBookingSeriesRequest) (*models.BookingSeries, error) {
+	// 1. Validate service; occurrences are paid from the wallet so the price must be fixed
+	service, err := bss.serviceRepo.GetByID(req.ServiceID)
+	if err != nil {
+		return nil, errors.New("service not found")
+	}
+	if !service.IsActive {
+		return nil, errors.New("service is not active")
+	}
+	if service.PriceType != "fixed" || service.Price == nil {
+		return nil, errors.New("recurring bookings are only available for fixed price services")
+	}
+
+	available, err := bss.serviceAreaRepo.CheckServiceAvailability(req.ServiceID, req.Address.City, req.Address.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check service availability: %v", err)
+	}
+	if !available {
+		return nil, fmt.Errorf("service is not available in your selected address location (%s, %s)", req.Address.City, req.Address.State)
+	}
+
+	// 2. Validate the recurrence
+	location := workerCalendarLocation()
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date, expected YYYY-MM-DD")
+	}
+	preferredTime, err := time.Parse("15:04", req.PreferredTime)
+	if err != nil {
+		return nil, errors.New("invalid preferred time, expected HH:MM")
+	}
+	if preferredTime.Minute()%30 != 0 {
+		return nil, errors.New("preferred time must be on the hour or half hour")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid end date, expected YYYY-MM-DD")
+		}
+		if parsed.Before(startDate) {
+			return nil, errors.New("end date cannot be before start date")
+		}
+		endDate = &parsed
+	}
+	if endDate == nil && req.OccurrenceCount == 0 {
+		return nil, errors.New("either end date or occurrence count is required")
+	}
+	if req.OccurrenceCount > maxSeriesOccurrences {
+		return nil, fmt.Errorf("a series can have at most %d occurrences", maxSeriesOccurrences)
+	}
+
+	firstVisit := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), preferredTime.Hour(), preferredTime.Minute(), 0, 0, location)
+	if firstVisit.Before(time.Now()) {
+		return nil, errors.New("first visit must be in the future")
+	}
+
+	// 3. Plan occurrences
+	visits := planSeriesVisits(firstVisit, req.Frequency, endDate, req.OccurrenceCount)
+	if len(visits) == 0 {
+		return nil, errors.New("the series has no visits between the start and end date")
+	}
+
+	addressJSON, err := json.Marshal(req.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal address: %v", err)
+	}
+
+	series := &models.BookingSeries{
+		UserID:              userID,
+		ServiceID:           req.ServiceID,
+		Frequency:           req.Frequency,
+		StartDate:           startDate,
+		EndDate:             endDate,
+		PreferredTime:       req.PreferredTime,
+		Address:             string(addressJSON),
+		Description:         req.Description,
+		ContactPerson:       req.ContactPerson,
+		ContactPhone:        req.ContactPhone,
+		SpecialInstructions: req.SpecialInstructions,
+		Status:              models.BookingSeriesStatusActive,
+	}
+	if req.OccurrenceCount > 0 {
+		count := req.OccurrenceCount
+		series.OccurrenceCount = &count
+	}
+	for i, visit := range visits {
+		series.Occurrences = append(series.Occurrences, models.BookingSeriesOccurrence{
+			Sequence:    i + 1,
+			ScheduledAt: visit,
+			Status:      models.OccurrenceStatusScheduled,
+		})
+	}
+
+	if err := bss.seriesRepo.Create(series); err != nil {
+		return nil, fmt.Errorf("failed to create booking series: %v", err)
+	}
+
+	logrus.Infof("Booking series %d created for user %d: %d %s visits of service %d", series.ID, userID, len(visits), req.Frequency, req.ServiceID)
+
+	// Book visits that are already inside the lead window right away
+	if err := bss.processSeries(series.ID); err != nil {
+		logrus.Errorf("Failed to book due occurrences of series %d: %v", series.ID, err)
+	}
+
+	return bss.seriesRepo.GetByID(series.ID)
+}
+
+// GetUserSeries gets a customer's booking series
+func (bss *BookingSeriesService) GetUserSeries(userID uint, filters *repositories.BookingSeriesFilters) ([]models.BookingSeries, *repositories.Pagination, error) {
+	filters.UserID = &userID
+	return bss.seriesRepo.GetSeries(filters)
+}
+
+// GetSeries gets booking series with filters (admin)
+func (bss *BookingSeriesService) GetSeries(filters *repositories.BookingSeriesFilters) ([]models.BookingSeries, *repositories.Pagination, error) {
+	return bss.seriesRepo.GetSeries(filters)
+}
+
+// GetSeriesByID gets one of a customer's booking series
+func (bss *BookingSeriesService) GetSeriesByID(userID uint, seriesID uint) (*models.BookingSeries, error) {
+	series, err := bss.seriesRepo.GetByID(seriesID)
+	if err != nil {
+		return nil, errors.New("booking series not found")
+	}
+	if series.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return series, nil
+}
+
+// SkipOccurrence skips a visit that has not been booked yet
+func (bss *BookingSeriesService) SkipOccurrence(userID uint, seriesID uint, occurrenceID uint) (*models.BookingSeriesOccurrence, error) {
+	series, occurrence, err := bss.getOwnedOccurrence(userID, seriesID, occurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	if occurrence.Status == models.OccurrenceStatusBooked {
+		return nil, errors.New("occurrence is already booked, cancel it instead")
+	}
+	if occurrence.Status != models.OccurrenceStatusScheduled {
+		return nil, fmt.Errorf("%s occurrence cannot be skipped", occurrence.Status)
+	}
+
+	now := time.Now()
+	occurrence.Status = models.OccurrenceStatusSkipped
+	occurrence.ProcessedAt = &now
+	if err := bss.seriesRepo.UpdateOccurrence(occurrence); err != nil {
+		return nil, fmt.Errorf("failed to skip occurrence: %v", err)
+	}
+
+	bss.completeSeriesIfDone(series)
+	return occurrence, nil
+}
+
+// RescheduleOccurrence moves a visit that has not been booked yet
+func (bss *BookingSeriesService) RescheduleOccurrence(userID uint, seriesID uint, occurrenceID uint, req *models.RescheduleOccurrenceRequest) (*models.BookingSeriesOccurrence, error) {
+	_, occurrence, err := bss.getOwnedOccurrence(userID, seriesID, occurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	if occurrence.Status == models.OccurrenceStatusBooked {
+		return nil, errors.New("occurrence is already booked, reschedule the booking instead")
+	}
+	if occurrence.Status != models.OccurrenceStatusScheduled && occurrence.Status != models.OccurrenceStatusFailed {
+		return nil, fmt.Errorf("%s occurrence cannot be rescheduled", occurrence.Status)
+	}
+
+	date, err := time.Parse("2006-01-02", req.ScheduledDate)
+	if err != nil {
+		return nil, errors.New("invalid scheduled date, expected YYYY-MM-DD")
+	}
+	clock, err := time.Parse("15:04", req.ScheduledTime)
+	if err != nil {
+		return nil, errors.New("invalid scheduled time, expected HH:MM")
+	}
+	scheduledAt := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, workerCalendarLocation())
+	if scheduledAt.Before(time.Now()) {
+		return nil, errors.New("scheduled time must be in the future")
+	}
+
+	occurrence.ScheduledAt = scheduledAt
+	occurrence.Status = models.OccurrenceStatusScheduled
+	occurrence.FailureReason = ""
+	occurrence.ProcessedAt = nil
+	if err := bss.seriesRepo.UpdateOccurrence(occurrence); err != nil {
+		return nil, fmt.Errorf("failed to reschedule occurrence: %v", err)
+	}
+
+	// The new time may already be inside the lead window
+	if err := bss.processSeries(seriesID); err != nil {
+		logrus.Errorf("Failed to book due occurrences of series %d: %v", seriesID, err)
+	}
+
+	return bss.seriesRepo.GetOccurrenceByID(occurrence.ID)
+}
+
+// CancelOccurrence cancels a single visit; booked visits are cancelled like any other booking
+func (bss *BookingSeriesService) CancelOccurrence(userID uint, seriesID uint, occurrenceID uint, reason string) (*models.BookingSeriesOccurrence, map[string]interface{}, error) {
+	series, occurrence, err := bss.getOwnedOccurrence(userID, seriesID, occurrenceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cancellation map[string]interface{}
+	var refundErr error
+	switch occurrence.Status {
+	case models.OccurrenceStatusScheduled, models.OccurrenceStatusFailed:
+	case models.OccurrenceStatusBooked:
+		if occurrence.BookingID != nil {
+			cancellation, err = bss.bookingService.CancelUserBooking(userID, *occurrence.BookingID, &models.CancelBookingRequest{Reason: reason})
+			if errors.Is(err, ErrRefundPending) {
+				// The booking is cancelled; only its refund is outstanding
+				refundErr = err
+			} else if err != nil {
+				return nil, nil, err
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("%s occurrence cannot be cancelled", occurrence.Status)
+	}
+
+	now := time.Now()
+	occurrence.Status = models.OccurrenceStatusCancelled
+	occurrence.ProcessedAt = &now
+	if err := bss.seriesRepo.UpdateOccurrence(occurrence); err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel occurrence: %v", err)
+	}
+
+	bss.completeSeriesIfDone(series)
+	return occurrence, cancellation, refundErr
+}
+
+// CancelSeries cancels all upcoming visits of a series
+func (bss *BookingSeriesService) CancelSeries(userID uint, seriesID uint, req *models.CancelBookingSeriesRequest) (*models.BookingSeries, error) {
+	series, err := bss.GetSeriesByID(userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != models.BookingSeriesStatusActive {
+		return nil, fmt.Errorf("booking series is already %s", series.Status)
+	}
+
+	now := time.Now()
+	for i := range series.Occurrences {
+		occurrence := &series.Occurrences[i]
+		switch occurrence.Status {
+		case models.OccurrenceStatusScheduled:
+		case models.OccurrenceStatusBooked:
+			if !req.CancelBookedOccurrences || occurrence.BookingID == nil || occurrence.ScheduledAt.Before(now) {
+				continue
+			}
+			if _, err := bss.bookingService.CancelUserBooking(userID, *occurrence.BookingID, &models.CancelBookingRequest{Reason: req.Reason}); errors.Is(err, ErrRefundPending) {
+				logrus.Errorf("Booking %d of series %d cancelled with its refund pending: %v", *occurrence.BookingID, series.ID, err)
+			} else if err != nil {
+				logrus.Errorf("Failed to cancel booking %d of series %d: %v", *occurrence.BookingID, series.ID, err)
+				continue
+			}
+		default:
+			continue
+		}
+
+		occurrence.Status = models.OccurrenceStatusCancelled
+		occurrence.ProcessedAt = &now
+		if err := bss.seriesRepo.UpdateOccurrence(occurrence); err != nil {
+			return nil, fmt.Errorf("failed to cancel occurrence: %v", err)
+		}
+	}
+
+	series.Status = models.BookingSeriesStatusCancelled
+	series.CancelledAt = &now
+	series.CancellationReason = req.Reason
+	if err := bss.seriesRepo.Update(series); err != nil {
+		return nil, fmt.Errorf("failed to cancel booking series: %v", err)
+	}
+
+	logrus.Infof("Booking series %d cancelled by user %d", series.ID, userID)
+	return bss.seriesRepo.GetByID(series.ID)
+}
+
+// ProcessDueOccurrences books every occurrence that has entered the lead window
+func (bss *BookingSeriesService) ProcessDueOccurrences() error {
+	leadHours := bss.adminConfigService.GetRecurringBookingLeadHours()
+	now := time.Now()
+	occurrences, err := bss.seriesRepo.GetDueOccurrences(now.Add(time.Duration(leadHours)*time.Hour), now.Add(-occurrenceProcessingTimeout))
+	if err != nil {
+		return fmt.Errorf("failed to get due occurrences: %v", err)
+	}
+
+	seriesCache := make(map[uint]*models.BookingSeries)
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		series, ok := seriesCache[occurrence.SeriesID]
+		if !ok {
+			series, err = bss.seriesRepo.GetByID(occurrence.SeriesID)
+			if err != nil {
+				logrus.Errorf("Failed to load booking series %d: %v", occurrence.SeriesID, err)
+				continue
+			}
+			seriesCache[occurrence.SeriesID] = series
+		}
+		bss.bookOccurrence(series, occurrence)
+	}
+
+	for _, series := range seriesCache {
+		bss.completeSeriesIfDone(series)
+	}
+
+	if len(occurrences) > 0 {
+		logrus.Infof("Processed %d due recurring booking occurrences", len(occurrences))
+	}
+	return nil
+}
+
+// StartRecurringBookingJob starts a periodic job that books due occurrences
+func (bss *BookingSeriesService) StartRecurringBookingJob() {
+	ticker := time.NewTicker(15 * time.Minute) // Run every 15 minutes
+	go func() {
+		for range ticker.C {
+			if err := bss.ProcessDueOccurrences(); err != nil {
+				logrus.Errorf("Recurring booking job failed: %v", err)
+			}
+		}
+	}()
+	logrus.Info("Recurring booking job started")
+}
+
+// processSeries books the due occurrences of one series
+func (bss *BookingSeriesService) processSeries(seriesID uint) error {
+	series, err := bss.seriesRepo.GetByID(seriesID)
+	if err != nil {
+		return err
+	}
+	if series.Status != models.BookingSeriesStatusActive {
+		return nil
+	}
+
+	now := time.Now()
+	cutoff := now.Add(time.Duration(bss.adminConfigService.GetRecurringBookingLeadHours()) * time.Hour)
+	for i := range series.Occurrences {
+		occurrence := &series.Occurrences[i]
+		if isClaimableOccurrence(occurrence, now) && !occurrence.ScheduledAt.After(cutoff) {
+			bss.bookOccurrence(series, occurrence)
+		}
+	}
+
+	bss.completeSeriesIfDone(series)
+	return nil
+}
+
+// isClaimableOccurrence reports whether an occurrence is waiting to be booked, or has been processing for
+// longer than booking it can take
+func isClaimableOccurrence(occurrence *models.BookingSeriesOccurrence, now time.Time) bool {
+	if occurrence.Status == models.OccurrenceStatusScheduled {
+		return true
+	}
+	return occurrence.Status == models.OccurrenceStatusProcessing && now.Sub(occurrence.UpdatedAt) > occurrenceProcessingTimeout
+}
+
+// bookOccurrence claims an occurrence, then creates and pays its booking, recording why when it cannot.
+// Occurrences another run has already claimed are left to that run.
+func (bss *BookingSeriesService) bookOccurrence(series *models.BookingSeries, occurrence *models.BookingSeriesOccurrence) {
+	claimed, err := bss.seriesRepo.ClaimOccurrence(occurrence.ID, time.Now().Add(-occurrenceProcessingTimeout))
+	if err != nil {
+		logrus.Errorf("Failed to claim occurrence %d of series %d: %v", occurrence.ID, series.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	occurrence.Status = models.OccurrenceStatusProcessing
+
+	booking, amount, err := bss.createOccurrenceBooking(series, occurrence)
+	if err != nil {
+		now := time.Now()
+		occurrence.Status = models.OccurrenceStatusFailed
+		occurrence.FailureReason = err.Error()
+		occurrence.ProcessedAt = &now
+		logrus.Warnf("Failed to book occurrence %d of series %d: %v", occurrence.ID, series.ID, err)
+
+		if err := bss.seriesRepo.UpdateOccurrence(occurrence); err != nil {
+			logrus.Errorf("Failed to update occurrence %d of series %d: %v", occurrence.ID, series.ID, err)
+			// The claim is released so a later run can try again
+			if err := bss.seriesRepo.ReleaseOccurrence(occurrence.ID); err != nil {
+				logrus.Errorf("Failed to release occurrence %d of series %d: %v", occurrence.ID, series.ID, err)
+			}
+			occurrence.Status = models.OccurrenceStatusScheduled
+			return
+		}
+
+		go NotifyRecurringBookingFailed(series, occurrence)
+		return
+	}
+
+	bss.assignRegularWorker(series, booking)
+
+	go bss.notificationService.SendBookingConfirmation(booking)
+	go bss.bookingService.sendBookingNotificationToAdmin(booking, &series.Service)
+	go NotifyRecurringBookingCreated(series, booking, amount)
+}
+
+// createOccurrenceBooking checks availability and balance, then creates a confirmed booking paid from the wallet
+// and marks the occurrence booked in the same transaction
+func (bss *BookingSeriesService) createOccurrenceBooking(series *models.BookingSeries, occurrence *models.BookingSeriesOccurrence) (*models.Booking, float64, error) {
+	location := workerCalendarLocation()
+	scheduledTime := occurrence.ScheduledAt.In(location)
+	if scheduledTime.Before(time.Now()) {
+		return nil, 0, errors.New("visit time passed before it could be booked")
+	}
+
+	// 1. Service must still be bookable at a fixed price
+	service, err := bss.serviceRepo.GetByID(series.ServiceID)
+	if err != nil || !service.IsActive {
+		return nil, 0, errors.New("service is no longer available")
+	}
+	if service.Price == nil {
+		return nil, 0, errors.New("service price is not set")
+	}
+	amount := *service.Price
+
+	var address models.BookingAddress
+	if err := json.Unmarshal([]byte(series.Address), &address); err != nil {
+		return nil, 0, fmt.Errorf("invalid series address: %v", err)
+	}
+
+	// 2. A qualified worker must be free at the visit time
+	date := scheduledTime.Format("2006-01-02")
+	slotTime := scheduledTime.Format("15:04")
+	availability, err := bss.availabilityService.GetAvailableSlots(series.ServiceID, date, address.City+", "+address.State)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to check availability: %v", err)
+	}
+	slotAvailable := false
+	for _, slot := range availability.AvailableSlots {
+		if slot.Time == slotTime && slot.IsAvailable {
+			slotAvailable = true
+			break
+		}
+	}
+	if !slotAvailable {
+		return nil, 0, fmt.Errorf("no worker is available at %s on %s", slotTime, date)
+	}
+
+	// 3. Wallet must cover the visit
+	var user models.User
+	if err := bss.userRepo.FindByID(&user, series.UserID); err != nil {
+		return nil, 0, errors.New("user not found")
+	}
+	if user.WalletBalance < amount {
+		return nil, 0, fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", amount, user.WalletBalance)
+	}
+
+	// 4. Create the booking
+	bufferTimeMinutes := 30
+	if config, err := bss.adminConfigRepo.GetByKey("booking_buffer_time_minutes"); err == nil {
+		if value, err := strconv.Atoi(config.Value); err == nil {
+			bufferTimeMinutes = value
+		}
+	}
+	serviceDurationMinutes := 120
+	if service.Duration != nil && *service.Duration != "" {
+		if duration, err := utils.ParseDuration(*service.Duration); err == nil {
+			serviceDurationMinutes = duration.ToMinutes()
+		}
+	}
+
+	scheduledDate := time.Date(scheduledTime.Year(), scheduledTime.Month(), scheduledTime.Day(), 0, 0, 0, 0, time.UTC)
+	scheduledEndTime := scheduledTime.Add(time.Duration(serviceDurationMinutes+bufferTimeMinutes) * time.Minute)
+	addressStr := series.Address
+
+	booking := &models.Booking{
+		UserID:              series.UserID,
+		ServiceID:           series.ServiceID,
+		BookingReference:    bss.bookingService.generateBookingReference(),
+		Status:              models.BookingStatusConfirmed,
+		PaymentStatus:       models.PaymentStatusCompleted,
+		BookingType:         models.BookingTypeRegular,
+		ScheduledDate:       &scheduledDate,
+		ScheduledTime:       &scheduledTime,
+		ScheduledEndTime:    &scheduledEndTime,
+		Address:             &addressStr,
+		Description:         series.Description,
+		ContactPerson:       series.ContactPerson,
+		ContactPhone:        series.ContactPhone,
+		SpecialInstructions: series.SpecialInstructions,
+	}
+
+	// 5. Create the booking, charge the wallet and mark the occurrence booked together, so a visit is
+	// never booked without its payment or paid for without being booked
+	description := fmt.Sprintf("Recurring booking payment (series #%d, visit %d)", series.ID, occurrence.Sequence)
+	var payment *models.Payment
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if _, err := bss.bookingRepo.WithTx(tx).Create(booking); err != nil {
+			return fmt.Errorf("failed to save booking: %v", err)
+		}
+
+		var err error
+		payment, err = bss.walletService.debitWalletForBooking(repositories.NewWalletJournalRepository().WithTx(tx), series.UserID, amount, booking.ID, description)
+		if err != nil {
+			return fmt.Errorf("failed to process wallet payment: %v", err)
+		}
+
+		now := time.Now()
+		occurrence.Status = models.OccurrenceStatusBooked
+		occurrence.BookingID = &booking.ID
+		occurrence.FailureReason = ""
+		occurrence.ProcessedAt = &now
+		if err := bss.seriesRepo.WithTx(tx).UpdateOccurrence(occurrence); err != nil {
+			return fmt.Errorf("failed to update occurrence: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		occurrence.Status = models.OccurrenceStatusProcessing
+		occurrence.BookingID = nil
+		return nil, 0, err
+	}
+
+	bss.activityService.Record(booking.ID, models.BookingActivityCreated, models.SystemActor(), nil, booking.Status, fmt.Sprintf("Visit %d of booking series #%d booked", occurrence.Sequence, series.ID))
+	bss.activityService.RecordPayment(booking.ID, models.BookingActivityPaymentReceived, payment, models.UserActor(series.UserID), description)
+
+	return booking, amount, nil
+}
+
+// assignRegularWorker assigns the worker who served the series last when they are free for the new visit
+func (bss *BookingSeriesService) assignRegularWorker(series *models.BookingSeries, booking *models.Booking) {
+	if workerID, err := bss.seriesRepo.GetLastServingWorkerID(series.ID); err == nil && (series.PreferredWorkerID == nil || *series.PreferredWorkerID != workerID) {
+		series.PreferredWorkerID = &workerID
+		if err := bss.seriesRepo.Update(series); err != nil {
+			logrus.Errorf("Failed to update preferred worker of series %d: %v", series.ID, err)
+		}
+	}
+	if series.PreferredWorkerID == nil || booking.ScheduledTime == nil || booking.ScheduledEndTime == nil {
+		return
+	}
+	workerID := *series.PreferredWorkerID
+
+	if err := bss.workerAvailabilityService.CheckWorkerAvailable(workerID, *booking.ScheduledTime, *booking.ScheduledEndTime); err != nil {
+		logrus.Infof("Regular worker %d of series %d is not available for booking %d: %v", workerID, series.ID, booking.ID, err)
+		return
+	}
+	busyIDs, err := bss.workerAssignmentRepo.GetBusyWorkerIDs(*booking.ScheduledTime, *booking.ScheduledEndTime, defaultMatchDurationMinutes)
+	if err != nil {
+		logrus.Errorf("Failed to check whether worker %d is busy: %v", workerID, err)
+		return
+	}
+	for _, id := range busyIDs {
+		if id == workerID {
+			logrus.Infof("Regular worker %d of series %d is busy for booking %d", workerID, series.ID, booking.ID)
+			return
+		}
+	}
+
+	// The customer asked for the series, so the assignment is recorded as theirs
+	assignment := &models.WorkerAssignment{
+		BookingID:       booking.ID,
+		WorkerID:        workerID,
+		AssignedBy:      series.UserID,
+		Status:          models.AssignmentStatusAssigned,
+		AssignedAt:      time.Now(),
+		AssignmentNotes: fmt.Sprintf("Regular worker of booking series #%d", series.ID),
+	}
+	if err := bss.workerAssignmentRepo.Create(assignment); err != nil {
+		logrus.Errorf("Failed to assign regular worker %d to booking %d: %v", workerID, booking.ID, err)
+		return
+	}
+
+	bss.activityService.Record(booking.ID, models.BookingActivityWorkerAssigned, models.SystemActor(), nil, workerID, "Regular worker of the booking series assigned")
+	if err := bss.stateMachine.TransitionBooking(booking, models.BookingStatusAssigned, TransitionContext{Actor: models.SystemActor()}); err != nil {
+		logrus.Errorf("Failed to mark booking %d as assigned: %v", booking.ID, err)
+		return
+	}
+
+	go bss.bookingService.sendWorkerAssignmentNotification(assignment)
+}
+
+// completeSeriesIfDone marks an active series completed once no occurrence is left to book
+func (bss *BookingSeriesService) completeSeriesIfDone(series *models.BookingSeries) {
+	if series.Status != models.BookingSeriesStatusActive {
+		return
+	}
+	remaining, err := bss.seriesRepo.CountScheduledOccurrences(series.ID)
+	if err != nil || remaining > 0 {
+		return
+	}
+
+	series.Status = models.BookingSeriesStatusCompleted
+	if err := bss.seriesRepo.Update(series); err != nil {
+		logrus.Errorf("Failed to complete booking series %d: %v", series.ID, err)
+	}
+}
+
+// getOwnedOccurrence loads an occurrence of an active series owned by the user
+func (bss *BookingSeriesService) getOwnedOccurrence(userID uint, seriesID uint, occurrenceID uint) (*models.BookingSeries, *models.BookingSeriesOccurrence, error) {
+	series, err := bss.GetSeriesByID(userID, seriesID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if series.Status == models.BookingSeriesStatusCancelled {
+		return nil, nil, errors.New("booking series is cancelled")
+	}
+
+	occurrence, err := bss.seriesRepo.GetOccurrenceByID(occurrenceID)
+	if err != nil || occurrence.SeriesID != series.ID {
+		return nil, nil, errors.New("occurrence not found")
+	}
+	return series, occurrence, nil
+}
+
+// planSeriesVisits lists the visit times of a series, stopping at the end date, the count or the cap
+func planSeriesVisits(first time.Time, frequency models.RecurrenceFrequency, endDate *time.Time, count int) []time.Time {
+	limit := maxSeriesOccurrences
+	if count > 0 && count < limit {
+		limit = count
+	}
+
+	var visits []time.Time
+	for i := 0; i < limit; i++ {
+		visit := seriesVisit(first, frequency, i)
+		if endDate != nil && visit.Format("2006-01-02") > endDate.Format("2006-01-02") {
+			break
+		}
+		visits = append(visits, visit)
+	}
+	return visits
+}
+
+// seriesVisit returns the nth visit of a series. Monthly visits keep the start day, or the last day of shorter months.
+func seriesVisit(first time.Time, frequency models.RecurrenceFrequency, n int) time.Time {
+	switch frequency {
+	case models.RecurrenceBiweekly:
+		return first.AddDate(0, 0, 14*n)
+	case models.RecurrenceMonthly:
+		month := time.Date(first.Year(), first.Month()+time.Month(n), 1, first.Hour(), first.Minute(), 0, 0, first.Location())
+		day := first.Day()
+		if lastDay := month.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		return time.Date(month.Year(), month.Month(), day, first.Hour(), first.Minute(), 0, 0, first.Location())
+	default:
+		return first.AddDate(0, 0, 7*n)
+	}
+}
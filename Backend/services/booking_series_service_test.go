@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"treesindia/models"
+)
+
+func TestIsClaimableOccurrenceReclaimsAbandonedRuns(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name      string
+		status    models.OccurrenceStatus
+		updatedAt time.Time
+		want      bool
+	}{
+		{"scheduled", models.OccurrenceStatusScheduled, now, true},
+		{"claimed by a running job", models.OccurrenceStatusProcessing, now.Add(-time.Minute), false},
+		{"left by a stopped job", models.OccurrenceStatusProcessing, now.Add(-occurrenceProcessingTimeout - time.Minute), true},
+		{"booked", models.OccurrenceStatusBooked, now.Add(-24 * time.Hour), false},
+		{"failed", models.OccurrenceStatusFailed, now.Add(-24 * time.Hour), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			occurrence := &models.BookingSeriesOccurrence{Status: tc.status}
+			occurrence.UpdatedAt = tc.updatedAt
+			if got := isClaimableOccurrence(occurrence, now); got != tc.want {
+				t.Errorf("isClaimableOccurrence() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -23,8 +23,15 @@ type BookingService struct {
 	serviceAreaRepo  *repositories.ServiceAreaRepository
 	locationRepo     *repositories.LocationRepository
 	paymentService   *PaymentService
-	razorpayService  *RazorpayService
 	notificationService *NotificationService
+	reviewRepo       *repositories.BookingReviewRepository
+	disputeRepo      *repositories.BookingDisputeRepository
+	activityService  *BookingActivityService
+	workerRepo       *repositories.WorkerRepository
+	stateMachine     *BookingStateMachine
+	matchingService  *WorkerMatchingService
+	availabilityService *WorkerAvailabilityService
+	couponService    *CouponService
 }
 
 func NewBookingService() *BookingService {
@@ -36,8 +43,15 @@ func NewBookingService() *BookingService {
 		serviceAreaRepo:  repositories.NewServiceAreaRepository(),
 		locationRepo:     repositories.NewLocationRepository(),
 		paymentService:   NewPaymentService(),
-		razorpayService:  NewRazorpayService(),
 		notificationService: NewNotificationService(),
+		reviewRepo:       repositories.NewBookingReviewRepository(),
+		disputeRepo:      repositories.NewBookingDisputeRepository(),
+		activityService:  NewBookingActivityService(),
+		workerRepo:       repositories.NewWorkerRepository(),
+		stateMachine:     NewBookingStateMachine(),
+		matchingService:  NewWorkerMatchingService(),
+		availabilityService: NewWorkerAvailabilityService(),
+		couponService:    NewCouponService(),
 	}
 }
 
@@ -138,15 +152,22 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 			return nil, nil, errors.New("service price is not set")
 		}
 		totalAmount = service.Price
-		
-		// Check if time slot is available using worker pool
-		// Extract location from address
-		location := ""
-		if req.Address.City != "" && req.Address.State != "" {
-			location = req.Address.City + ", " + req.Address.State
+
+		// Check the coupon before holding the slot
+		couponTarget := &models.CouponTarget{
+			Scope:      models.CouponScopeBooking,
+			Amount:     *service.Price,
+			ServiceID:  service.ID,
+			CategoryID: service.CategoryID,
+		}
+		if req.CouponCode != "" {
+			if _, err := bs.couponService.Apply(userID, req.CouponCode, couponTarget, 0); err != nil {
+				return nil, nil, err
+			}
 		}
 		
-		isSlotAvailable, err := bs.isTimeSlotAvailable(scheduledTime, serviceDurationMinutes, req.ServiceID, location)
+		// Check if a matching worker is free for the time slot
+		isSlotAvailable, err := bs.isTimeSlotAvailable(scheduledTime, serviceDurationMinutes, req.ServiceID, req.Address)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to check slot availability: %v", err)
 		}
@@ -194,6 +215,21 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to save booking: %v", err)
 		}
+		bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
+
+		// Reserve the coupon for this booking until it is paid or the hold expires
+		var redemption *models.CouponRedemption
+		if req.CouponCode != "" {
+			var application *models.CouponApplication
+			application, redemption, err = bs.couponService.ReserveForOrder(userID, req.CouponCode, couponTarget, &booking.ID)
+			if err != nil {
+				if cancelErr := bs.stateMachine.TransitionBooking(booking, models.BookingStatusCancelled, TransitionContext{Actor: models.SystemActor(), Reason: "Coupon could not be applied"}); cancelErr != nil {
+					logrus.Errorf("Failed to cancel booking %d after coupon error: %v", booking.ID, cancelErr)
+				}
+				return nil, nil, err
+			}
+			totalAmount = &application.PayableAmount
+		}
 
 		// 10. Create payment record
 		paymentReq := &models.CreatePaymentRequest{
@@ -201,16 +237,24 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 			Amount:            *totalAmount,
 			Currency:          "INR",
 			Type:              models.PaymentTypeBooking,
-			Method:            "razorpay",
+			Method:            models.PaymentMethodOnline,
 			RelatedEntityType: "booking",
 			RelatedEntityID:   booking.ID,
 			Description:       "Service booking payment",
 		}
 
-		_, razorpayOrder, err := bs.paymentService.CreateRazorpayOrder(paymentReq)
+		payment, razorpayOrder, err := bs.paymentService.CreateGatewayOrder(paymentReq)
 		if err != nil {
+			if redemption != nil {
+				bs.couponService.Release(redemption)
+			}
 			return nil, nil, fmt.Errorf("failed to create payment: %v", err)
 		}
+		if redemption != nil {
+			if err := bs.couponService.AttachPayment(redemption, payment.ID); err != nil {
+				logrus.Errorf("Failed to attach payment %d to coupon redemption %d: %v", payment.ID, redemption.ID, err)
+			}
+		}
 
 		// Calculate payment progress before returning
 		booking.GetPaymentProgress()
@@ -273,6 +317,7 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 			if err != nil {
 				return nil, nil, err
 			}
+			bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 			// Create payment record for inquiry fee
 			paymentReq := &models.CreatePaymentRequest{
@@ -280,13 +325,13 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 				Amount:            feeFloat,
 				Currency:          "INR",
 				Type:              models.PaymentTypeBooking,
-				Method:            "razorpay",
+				Method:            models.PaymentMethodOnline,
 				RelatedEntityType: "booking",
 				RelatedEntityID:   booking.ID,
 				Description:       "Inquiry booking fee",
 			}
 
-			_, razorpayOrder, err := bs.paymentService.CreateRazorpayOrder(paymentReq)
+			_, razorpayOrder, err := bs.paymentService.CreateGatewayOrder(paymentReq)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to create payment: %v", err)
 			}
@@ -333,6 +378,7 @@ func (bs *BookingService) CreateBooking(userID uint, req *models.CreateBookingRe
 			if err != nil {
 				return nil, nil, err
 			}
+			bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 		// Calculate payment progress before returning
 		booking.GetPaymentProgress()
@@ -393,13 +439,13 @@ func (bs *BookingService) CreateInquiryBooking(userID uint, req *models.CreateIn
 		feeAmount = 0 // Default to 0 if parsing fails
 	}
 
-	// 5. If fee is required, try to create Razorpay order
+	// 5. If fee is required, try to create a gateway order
 	if feeAmount > 0 {
 		logrus.Infof("Inquiry booking fee required: %d", feeAmount)
 		
-		// Check if razorpay service is available and configured
-		if bs.razorpayService == nil {
-			logrus.Warn("Razorpay service is nil, creating booking without payment")
+		// Check if payment service is available
+		if bs.paymentService == nil {
+			logrus.Warn("Payment service is nil, creating booking without payment")
 			// Fall through to create booking without payment
 		} else {
 			// Try to create Razorpay order using payment service (same as fixed price booking)
@@ -423,7 +469,7 @@ func (bs *BookingService) CreateInquiryBooking(userID uint, req *models.CreateIn
 				Amount:            feeFloat,
 				Currency:          "INR",
 				Type:              models.PaymentTypeBooking,
-				Method:            "razorpay",
+				Method:            models.PaymentMethodOnline,
 				RelatedEntityType: "inquiry_booking",
 				RelatedEntityID:   0, // Will be set after booking creation
 				Description:       "Inquiry booking fee",
@@ -431,7 +477,7 @@ func (bs *BookingService) CreateInquiryBooking(userID uint, req *models.CreateIn
 			}
 			
 			logrus.Infof("Creating Razorpay order for inquiry booking fee: %f", feeFloat)
-			_, razorpayOrder, err := bs.paymentService.CreateRazorpayOrder(paymentReq)
+			_, razorpayOrder, err := bs.paymentService.CreateGatewayOrder(paymentReq)
 			if err != nil {
 				logrus.Warnf("Failed to create Razorpay order: %v, creating booking without payment", err)
 				// Fall through to create booking without payment
@@ -482,6 +528,7 @@ func (bs *BookingService) CreateInquiryBooking(userID uint, req *models.CreateIn
 			logrus.Errorf("Failed to create inquiry booking: %v", err)
 			return nil, nil, err
 		}
+		bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 		// 10. Send notification (optional)
 		// bs.notificationService.SendInquiryBookingNotification(booking)
@@ -500,16 +547,20 @@ func (bs *BookingService) CreateInquiryBooking(userID uint, req *models.CreateIn
 
 
 // checkWorkerBookingConflict checks if a worker has any conflicting bookings
-func (bs *BookingService) checkWorkerBookingConflict(workerID uint, startTime time.Time, endTime time.Time) (bool, error) {
+// Bookings listed in excludeBookingIDs are ignored, e.g. the booking that is being moved
+func (bs *BookingService) checkWorkerBookingConflict(workerID uint, startTime time.Time, endTime time.Time, excludeBookingIDs ...uint) (bool, error) {
 	// Get all bookings for this worker that overlap with the requested time
 	var conflictingBookings []models.Booking
-	err := bs.bookingRepo.GetDB().Joins("JOIN worker_assignments ON bookings.id = worker_assignments.booking_id").
+	query := bs.bookingRepo.GetDB().Joins("JOIN worker_assignments ON bookings.id = worker_assignments.booking_id").
 		Where("worker_assignments.worker_id = ? AND worker_assignments.status IN (?)", workerID, []string{"reserved", "assigned", "accepted", "in_progress"}).
 		Where("(bookings.scheduled_time < ? AND bookings.scheduled_end_time > ?) OR "+
 			"(bookings.scheduled_time >= ? AND bookings.scheduled_time < ?) OR "+
 			"(bookings.scheduled_end_time > ? AND bookings.scheduled_end_time <= ?)",
-			endTime, startTime, startTime, endTime, startTime, endTime).
-		Find(&conflictingBookings).Error
+			endTime, startTime, startTime, endTime, startTime, endTime)
+	if len(excludeBookingIDs) > 0 {
+		query = query.Where("bookings.id NOT IN ?", excludeBookingIDs)
+	}
+	err := query.Find(&conflictingBookings).Error
 
 	if err != nil {
 		return false, err
@@ -523,8 +574,8 @@ func (bs *BookingService) checkWorkerBookingConflict(workerID uint, startTime ti
 	return len(conflictingBookings) > 0, nil
 }
 
-// isTimeSlotAvailable checks if a time slot is available for booking using Go-based calculation
-func (bs *BookingService) isTimeSlotAvailable(scheduledTime time.Time, serviceDurationMinutes int, serviceID uint, location string) (bool, error) {
+// isTimeSlotAvailable checks if a worker who can do the service at the given address is free for the time slot
+func (bs *BookingService) isTimeSlotAvailable(scheduledTime time.Time, serviceDurationMinutes int, serviceID uint, address models.BookingAddress) (bool, error) {
 	// Get buffer time configuration
 	adminConfigRepo := repositories.NewAdminConfigRepository()
 	bufferTimeConfig, err := adminConfigRepo.GetByKey("booking_buffer_time_minutes")
@@ -546,101 +597,38 @@ func (bs *BookingService) isTimeSlotAvailable(scheduledTime time.Time, serviceDu
 	// Calculate end time including buffer
 	endTime := scheduledTime.Add(time.Duration(serviceDurationMinutes+bufferTimeMinutes) * time.Minute)
 
-	// Get total active workers
-	var workers []models.User
-	err = bs.userRepo.FindByUserType(&workers, models.UserTypeWorker)
+	// Load the service with its category and service areas for matching
+	service, err := bs.serviceRepo.GetByID(serviceID)
 	if err != nil {
-		return false, fmt.Errorf("failed to get workers: %v", err)
-	}
-
-	totalWorkers := 0
-	for _, worker := range workers {
-		if worker.IsActive {
-			totalWorkers++
-		}
-	}
-
-	// If no workers available, return false
-	if totalWorkers == 0 {
-		return false, nil
+		return false, fmt.Errorf("service not found: %v", err)
 	}
 
-	// Get worker assignments for this time period
-	workerAssignmentRepo := repositories.NewWorkerAssignmentRepository()
-	assignments, _, err := workerAssignmentRepo.GetWorkerAssignments(0, &repositories.WorkerAssignmentFilters{
-		Page:  1,
-		Limit: 100, // Get up to 100 assignments
+	// Count workers with the right skills in range who have no overlapping assignment
+	availableWorkers, err := bs.matchingService.CountAvailableWorkers(&MatchCriteria{
+		Service:   service,
+		StartTime: &scheduledTime,
+		EndTime:   &endTime,
+		City:      address.City,
+		State:     address.State,
+		Latitude:  address.Latitude,
+		Longitude: address.Longitude,
 	})
 	if err != nil {
-		return false, fmt.Errorf("failed to get worker assignments: %v", err)
-	}
-
-	// Count busy workers for this time slot
-	busyWorkers := 0
-	for _, assignment := range assignments {
-		// Check if booking scheduled time is valid
-		if assignment.Booking.ScheduledTime == nil {
-			continue
-		}
-		
-		// Get the actual service duration for this assignment
-		assignmentServiceDuration := serviceDurationMinutes // Default to requested service duration
-		if assignment.Booking.Service.Duration != nil && *assignment.Booking.Service.Duration != "" {
-			duration, err := utils.ParseDuration(*assignment.Booking.Service.Duration)
-			if err == nil {
-				assignmentServiceDuration = duration.ToMinutes()
-			}
-		}
-		
-		// Ensure assignment service duration is valid
-		if assignmentServiceDuration <= 0 {
-			assignmentServiceDuration = 120 // Default to 2 hours if invalid
-		}
-		
-		// Calculate assignment end time using the actual service duration
-		assignmentEndTime := assignment.Booking.ScheduledTime.Add(time.Duration(assignmentServiceDuration+bufferTimeMinutes) * time.Minute)
-		
-		// Check if there's overlap
-		if scheduledTime.Before(assignmentEndTime) && endTime.After(*assignment.Booking.ScheduledTime) {
-			busyWorkers++
-		}
+		return false, fmt.Errorf("failed to match workers: %v", err)
 	}
 
-	// Check if there are available workers
-	availableWorkers := totalWorkers - busyWorkers
 	return availableWorkers > 0, nil
 }
 
-// assignAvailableWorker finds and assigns an available worker for the given time period
-func (bs *BookingService) assignAvailableWorker(startTime time.Time, serviceDurationMinutes int) (uint, error) {
-	// Get all active workers
-	var workers []models.User
-	err := bs.userRepo.FindByUserType(&workers, models.UserTypeWorker)
+// assignAvailableWorker finds the best matching available worker for a booking
+func (bs *BookingService) assignAvailableWorker(booking *models.Booking) (uint, error) {
+	match, err := bs.matchingService.FindBestWorkerForBooking(booking)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get workers: %v", err)
-	}
-
-	// Calculate service end time
-	serviceEndTime := startTime.Add(time.Duration(serviceDurationMinutes) * time.Minute)
-
-	// Find first available worker
-	for _, worker := range workers {
-		if !worker.IsActive {
-			continue
-		}
-
-		// Check if worker has any conflicting bookings during this time period
-		hasConflict, err := bs.checkWorkerBookingConflict(worker.ID, startTime, serviceEndTime)
-		if err != nil {
-			continue // Skip this worker if there's an error checking conflicts
-		}
-
-		if !hasConflict {
-			return worker.ID, nil
-		}
+		return 0, err
 	}
 
-	return 0, errors.New("no available workers found")
+	logrus.Infof("Matched worker %d to booking %d with score %.3f", match.WorkerID, booking.ID, match.Score)
+	return match.WorkerID, nil
 }
 
 // VerifyPaymentAndCreateBooking verifies payment and creates the booking
@@ -649,7 +637,7 @@ func (bs *BookingService) VerifyPaymentAndCreateBooking(userID uint, req *models
 	paymentService := NewPaymentService()
 
 	// 2. Find the payment record by Razorpay order ID
-	payment, err := paymentService.GetPaymentByRazorpayOrderID(req.RazorpayOrderID)
+	payment, err := paymentService.GetPaymentByGatewayOrderID(req.RazorpayOrderID)
 	if err != nil {
 		return nil, fmt.Errorf("payment not found: %v", err)
 	}
@@ -664,7 +652,7 @@ func (bs *BookingService) VerifyPaymentAndCreateBooking(userID uint, req *models
 	} else {
 		// If no signature provided, just mark payment as completed (signature will be verified via webhook)
 		payment.Status = models.PaymentStatusCompleted
-		payment.RazorpayPaymentID = &req.RazorpayPaymentID
+		payment.GatewayPaymentID = &req.RazorpayPaymentID
 		now := time.Now()
 		payment.CompletedAt = &now
 		payment.Notes = "Payment completed via frontend callback"
@@ -675,22 +663,24 @@ func (bs *BookingService) VerifyPaymentAndCreateBooking(userID uint, req *models
 		}
 	}
 
-	// 4. Create the booking with the verified payment
+	// 4. Create the booking with the verified payment; any coupon was applied when the order was created
+	req.CreateBookingRequest.CouponCode = ""
 	booking, _, err := bs.CreateBooking(userID, &req.CreateBookingRequest)
 	if err != nil {
 		return nil, err
 	}
 
 	// 5. Update booking status - only confirm for regular bookings, keep inquiry bookings pending
+	newStatus := booking.Status
 	if booking.BookingType == models.BookingTypeRegular {
-		booking.Status = models.BookingStatusConfirmed
+		newStatus = models.BookingStatusConfirmed
 	} else if booking.BookingType == models.BookingTypeInquiry {
 		// Keep inquiry bookings as pending - they need to go through quote workflow
-		booking.Status = models.BookingStatusPending
+		newStatus = models.BookingStatusPending
 	}
 
 	// 6. Save booking
-	err = bs.bookingRepo.Update(booking)
+	err = bs.stateMachine.TransitionBooking(booking, newStatus, TransitionContext{Actor: models.UserActor(userID), Reason: "Payment verified"})
 	if err != nil {
 		return nil, err
 	}
@@ -723,6 +713,14 @@ func (bs *BookingService) VerifyPayment(req *models.VerifyPaymentRequest) (*mode
 		return nil, errors.New("booking not found")
 	}
 
+	// The payment.captured webhook may already have confirmed the booking
+	if booking.Status == models.BookingStatusConfirmed {
+		if payment, err := bs.paymentService.GetPaymentByGatewayOrderID(req.RazorpayOrderID); err == nil &&
+			payment.Status == models.PaymentStatusCompleted && payment.GatewayPaymentID != nil && *payment.GatewayPaymentID == req.RazorpayPaymentID {
+			return booking, nil
+		}
+	}
+
 	// Check if booking is in temporary hold status
 	if booking.Status != models.BookingStatusTemporaryHold {
 		return nil, errors.New("booking is not in temporary hold status")
@@ -734,7 +732,7 @@ func (bs *BookingService) VerifyPayment(req *models.VerifyPaymentRequest) (*mode
 	}
 
 	// 2. Find and verify the associated payment
-	payment, err := bs.paymentService.GetPaymentByRazorpayOrderID(req.RazorpayOrderID)
+	payment, err := bs.paymentService.GetPaymentByGatewayOrderID(req.RazorpayOrderID)
 	if err != nil {
 		return nil, fmt.Errorf("payment not found for order ID %s: %v", req.RazorpayOrderID, err)
 	}
@@ -756,12 +754,14 @@ func (bs *BookingService) VerifyPayment(req *models.VerifyPaymentRequest) (*mode
 	// The availability is calculated in real-time based on existing bookings and worker assignments
 
 	// 5. Update booking status to confirmed and payment status to completed
-	booking.Status = models.BookingStatusConfirmed
+	if err := bs.stateMachine.CanTransitionBooking(booking, models.BookingStatusConfirmed); err != nil {
+		return nil, err
+	}
 	booking.PaymentStatus = models.PaymentStatusCompleted
 	booking.HoldExpiresAt = nil // Clear hold expiration
 
 	// 6. Save booking
-	err = bs.bookingRepo.Update(booking)
+	err = bs.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, TransitionContext{Actor: models.UserActor(booking.UserID), Reason: "Payment verified, booking confirmed"})
 	if err != nil {
 		return nil, err
 	}
@@ -784,18 +784,13 @@ func (bs *BookingService) CleanupExpiredTemporaryHolds() error {
 	}
 
 	for _, booking := range expiredHolds {
-		// Update booking status to cancelled
-		booking.Status = models.BookingStatusCancelled
-		
-		err := bs.bookingRepo.Update(&booking)
+		// Update booking status to cancelled; call masking is disabled by the state machine
+		err := bs.stateMachine.TransitionBooking(&booking, models.BookingStatusCancelled, TransitionContext{Actor: models.SystemActor(), Reason: "Temporary hold expired"})
 		if err != nil {
 			// Log error but continue with other bookings
+			logrus.Errorf("Failed to cancel expired hold for booking %d: %v", booking.ID, err)
 			continue
 		}
-
-		// Disable call masking for expired bookings
-		callMaskingService := NewCallMaskingService()
-		go callMaskingService.DisableCallMasking(booking.ID)
 	}
 
 	return nil
@@ -823,55 +818,117 @@ func (bs *BookingService) CancelUserBooking(userID uint, bookingID uint, req *mo
 		return nil, errors.New("unauthorized")
 	}
 
-	if booking.Status == models.BookingStatusCompleted || booking.Status == models.BookingStatusCancelled {
-		return nil, errors.New("booking cannot be cancelled")
+	if err := bs.stateMachine.CanTransitionBooking(booking, models.BookingStatusCancelled); err != nil {
+		return nil, err
 	}
 
-	// 2. Cancel booking
-	booking.Status = models.BookingStatusCancelled
-	err = bs.bookingRepo.Update(booking)
+	// 2. Apply cancellation policy to every completed payment
+	policyService := NewCancellationPolicyService()
+	summary, err := policyService.CalculateRefunds(booking)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Disable call masking if it exists
-	callMaskingService := NewCallMaskingService()
-	go callMaskingService.DisableCallMasking(bookingID)
+	reason := "Customer request"
+	if req.Reason != "" {
+		reason = req.Reason
+	}
 
-	// 4. Send cancellation notifications
-	go func() {
-		// Get user and service details for notification
-		var user models.User
-		err := bs.userRepo.FindByID(&user, booking.UserID)
-		if err == nil {
-			var service models.Service
-			err = bs.serviceRepo.FindByID(&service, booking.ServiceID)
-			if err == nil {
-				reason := "Customer request"
-				if req.Reason != "" {
-					reason = req.Reason
-				}
-				NotifyBookingCancelled(booking, &user, &service, reason)
+	// 3. Cancel booking; call masking is disabled and the customer notified by the state machine
+	err = bs.stateMachine.TransitionBooking(booking, models.BookingStatusCancelled, TransitionContext{Actor: models.UserActor(userID), Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Cancel payment segments that were never paid
+	segmentRepo := repositories.NewPaymentSegmentRepository()
+	for _, segment := range booking.PaymentSegments {
+		if segment.Status == models.PaymentSegmentStatusPending || segment.Status == models.PaymentSegmentStatusOverdue {
+			segment.Status = models.PaymentSegmentStatusCancelled
+			if err := segmentRepo.Update(&segment); err != nil {
+				logrus.Errorf("Failed to cancel payment segment %d for booking %d: %v", segment.ID, booking.ID, err)
 			}
 		}
-	}()
+	}
 
-	// 5. Process refund if payment was made
-	var refundAmount float64
-	var refundMethod string
-	// TODO: Get payment details from payment table and process refund
-	// For now, set default values
-	refundAmount = 0
-	refundMethod = "razorpay"
+	// 5. Process refunds; a refund that could not be issued leaves the booking's payment refund pending
+	// and is queued for the refund retry job
+	refundReason := fmt.Sprintf("Booking %s cancelled: %s", booking.BookingReference, reason)
+	refundErr := policyService.ProcessRefunds(summary, refundReason)
+	paymentStatus := booking.PaymentStatus
+	switch {
+	case refundErr != nil:
+		paymentStatus = models.PaymentStatusRefundPending
+		settledStatus := models.PaymentStatusRefunded
+		if summary.CancellationFee > 0 {
+			settledStatus = models.PaymentStatusPartiallyRefunded
+		}
+		if err := NewRefundRetryService().QueueFailedRefunds(summary, refundReason, settledStatus, time.Now()); err != nil {
+			logrus.Errorf("Failed to queue refunds of cancelled booking %d for retry: %v", booking.ID, err)
+		}
+	case summary.RefundAmount > 0 && summary.CancellationFee > 0:
+		paymentStatus = models.PaymentStatusPartiallyRefunded
+	case summary.RefundAmount > 0:
+		paymentStatus = models.PaymentStatusRefunded
+	}
+	if paymentStatus != booking.PaymentStatus {
+		booking.PaymentStatus = paymentStatus
+		if err := bs.bookingRepo.Update(booking); err != nil {
+			logrus.Errorf("Failed to update payment status for cancelled booking %d: %v", booking.ID, err)
+		}
+	}
 
+	message := "Booking cancelled successfully"
+	if refundErr != nil {
+		message = "Booking cancelled, but the refund could not be issued yet and will be retried"
+	}
 	return map[string]interface{}{
 		"booking_id":       booking.ID,
 		"status":           booking.Status,
-		"refund_amount":    refundAmount,
-		"refund_method":    refundMethod,
-		"cancellation_fee": 0,
-		"message":          "Booking cancelled successfully",
-	}, nil
+		"payment_status":   booking.PaymentStatus,
+		"refund_amount":    summary.RefundAmount,
+		"refund_method":    summaryRefundMethod(summary),
+		"cancellation_fee": summary.CancellationFee,
+		"refunds":          summary.Items,
+		"message":          message,
+	}, refundErr
+}
+
+// GetCancellationPreview shows the fee and refund a user would get if they cancelled now
+func (bs *BookingService) GetCancellationPreview(userID uint, bookingID uint) (*models.CancellationSummary, error) {
+	booking, err := bs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	if booking.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if booking.Status == models.BookingStatusCompleted || booking.Status == models.BookingStatusCancelled {
+		return nil, errors.New("booking cannot be cancelled")
+	}
+
+	return NewCancellationPolicyService().CalculateRefunds(booking)
+}
+
+// summaryRefundMethod describes where the refunds of a cancellation went
+func summaryRefundMethod(summary *models.CancellationSummary) string {
+	method := ""
+	for _, item := range summary.Items {
+		if item.RefundAmount <= 0 {
+			continue
+		}
+		if method == "" {
+			method = item.RefundMethod
+		} else if method != item.RefundMethod {
+			return "mixed"
+		}
+	}
+	if method == "" {
+		return "none"
+	}
+	return method
 }
 
 // AssignWorker assigns a worker to a booking
@@ -883,7 +940,7 @@ func (bs *BookingService) AssignWorker(bookingID uint, workerID uint, notes stri
 	}
 
 	if booking.Status != models.BookingStatusConfirmed {
-		return nil, errors.New("booking is not confirmed")
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusAssigned), Reason: "booking is not confirmed"}
 	}
 
 	// 2. Check if worker exists and is available
@@ -897,6 +954,16 @@ func (bs *BookingService) AssignWorker(bookingID uint, workerID uint, notes stri
 		return nil, errors.New("user is not a worker")
 	}
 
+	if booking.ScheduledTime != nil {
+		endTime := booking.ScheduledTime.Add(2 * time.Hour)
+		if booking.ScheduledEndTime != nil {
+			endTime = *booking.ScheduledEndTime
+		}
+		if err := bs.availabilityService.CheckWorkerAvailable(workerID, *booking.ScheduledTime, endTime); err != nil {
+			return nil, err
+		}
+	}
+
 	// 3. Create worker assignment
 	assignment := &models.WorkerAssignment{
 		BookingID:        booking.ID,
@@ -913,8 +980,9 @@ func (bs *BookingService) AssignWorker(bookingID uint, workerID uint, notes stri
 	}
 
 	// 4. Update booking status
-	booking.Status = models.BookingStatusAssigned
-	err = bs.bookingRepo.Update(booking)
+	actor := models.AdminActor(assignment.AssignedBy)
+	bs.activityService.Record(booking.ID, models.BookingActivityWorkerAssigned, actor, nil, workerID, fmt.Sprintf("Worker %s assigned", worker.Name))
+	err = bs.stateMachine.TransitionBooking(booking, models.BookingStatusAssigned, TransitionContext{Actor: actor})
 	if err != nil {
 		return nil, err
 	}
@@ -955,18 +1023,23 @@ func (bs *BookingService) GetAllBookings(filters *repositories.AdminBookingFilte
 }
 
 // UpdateBookingStatus updates booking status (admin only)
-func (bs *BookingService) UpdateBookingStatus(bookingID uint, status models.BookingStatus, reason string) (*models.Booking, error) {
+func (bs *BookingService) UpdateBookingStatus(bookingID uint, adminID uint, status models.BookingStatus, reason string) (*models.Booking, error) {
 	booking, err := bs.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return nil, errors.New("booking not found")
 	}
 
-	booking.Status = status
+	oldStatus := booking.Status
 	if reason != "" {
 		// TODO: Add cancellation reason field to booking model
 	}
 
-	err = bs.bookingRepo.Update(booking)
+	description := ""
+	if reason != "" {
+		description = fmt.Sprintf("Status changed from %s to %s by admin: %s", oldStatus, status, reason)
+	}
+
+	err = bs.stateMachine.TransitionBooking(booking, status, TransitionContext{Actor: models.AdminActor(adminID), Reason: description})
 	if err != nil {
 		return nil, err
 	}
@@ -1029,6 +1102,11 @@ func (bs *BookingService) AssignWorkerToBooking(bookingID uint, workerID uint, a
 		if hasConflict {
 			return nil, errors.New("worker is not available for this time slot")
 		}
+
+		// Check the worker's schedule, leave and blocked slots
+		if err := bs.availabilityService.CheckWorkerAvailable(workerID, *booking.ScheduledTime, booking.ScheduledTime.Add(time.Duration(serviceDurationMinutes)*time.Minute)); err != nil {
+			return nil, err
+		}
 	}
 
 	// 4. Check existing assignment status
@@ -1052,9 +1130,9 @@ func (bs *BookingService) AssignWorkerToBooking(bookingID uint, workerID uint, a
 		// Allow reassignment if status is: assigned (not accepted yet) or rejected
 		if existingAssignment.Status == models.AssignmentStatusAssigned || existingAssignment.Status == models.AssignmentStatusRejected {
 			// Update existing assignment
+			previousWorkerID := existingAssignment.WorkerID
 			existingAssignment.WorkerID = workerID
 			existingAssignment.AssignedBy = adminID
-			existingAssignment.Status = models.AssignmentStatusAssigned
 			existingAssignment.AssignedAt = time.Now()
 			existingAssignment.AssignmentNotes = "Worker reassigned by admin"
 			
@@ -1065,14 +1143,16 @@ func (bs *BookingService) AssignWorkerToBooking(bookingID uint, workerID uint, a
 			existingAssignment.RejectionNotes = ""
 			existingAssignment.RejectionReason = ""
 			
-			err = workerAssignmentRepo.Update(existingAssignment)
+			actor := models.AdminActor(adminID)
+			transition := TransitionContext{Actor: actor, Reason: "Worker reassigned by admin"}
+			err = bs.stateMachine.TransitionAssignment(existingAssignment, models.AssignmentStatusAssigned, transition)
 			if err != nil {
 				return nil, err
 			}
+			bs.activityService.Record(booking.ID, models.BookingActivityWorkerAssigned, actor, previousWorkerID, workerID, fmt.Sprintf("Worker reassigned to %s", worker.Name))
 			
 			// Update booking status
-			booking.Status = models.BookingStatusAssigned
-			err = bs.bookingRepo.Update(booking)
+			err = bs.stateMachine.TransitionBooking(booking, models.BookingStatusAssigned, transition)
 			if err != nil {
 				return nil, err
 			}
@@ -1100,8 +1180,9 @@ func (bs *BookingService) AssignWorkerToBooking(bookingID uint, workerID uint, a
 	}
 
 	// 6. Update booking status
-	booking.Status = models.BookingStatusAssigned
-	err = bs.bookingRepo.Update(booking)
+	actor := models.AdminActor(adminID)
+	bs.activityService.Record(booking.ID, models.BookingActivityWorkerAssigned, actor, nil, workerID, fmt.Sprintf("Worker %s assigned", worker.Name))
+	err = bs.stateMachine.TransitionBooking(booking, models.BookingStatusAssigned, TransitionContext{Actor: actor})
 	if err != nil {
 		return nil, err
 	}
@@ -1112,6 +1193,26 @@ func (bs *BookingService) AssignWorkerToBooking(bookingID uint, workerID uint, a
 	return assignment, nil
 }
 
+// AutoAssignWorker assigns the best matching available worker to a booking (admin only)
+func (bs *BookingService) AutoAssignWorker(bookingID uint, adminID uint) (*models.WorkerAssignment, error) {
+	booking, err := bs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	workerID, err := bs.assignAvailableWorker(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.AssignWorkerToBooking(bookingID, workerID, adminID)
+}
+
+// GetSuggestedWorkers ranks the workers who could take a booking (admin only)
+func (bs *BookingService) GetSuggestedWorkers(bookingID uint, limit int) ([]WorkerMatch, error) {
+	return bs.matchingService.SuggestWorkersForBooking(bookingID, limit)
+}
+
 // GetBookingStats gets booking statistics (admin only)
 func (bs *BookingService) GetBookingStats() (map[string]interface{}, error) {
 	return bs.bookingRepo.GetBookingStats()
@@ -1160,7 +1261,7 @@ func (bs *BookingService) VerifyInquiryPaymentAndCreateBooking(userID uint, req
 		Amount:             amount,
 		Currency:           "INR",
 		Type:               models.PaymentTypeBooking,
-		Method:             "razorpay",
+		Method:             models.PaymentMethodOnline,
 		RelatedEntityType:  "service", // Will be updated to booking after creation
 		RelatedEntityID:    serviceID,
 		Description:        "Inquiry booking fee",
@@ -1175,9 +1276,10 @@ func (bs *BookingService) VerifyInquiryPaymentAndCreateBooking(userID uint, req
 	// Update payment with Razorpay details and mark as completed
 	now := time.Now()
 	payment.Status = models.PaymentStatusCompleted
-	payment.RazorpayOrderID = &req.RazorpayOrderID
-	payment.RazorpayPaymentID = &req.RazorpayPaymentID
-	payment.RazorpaySignature = &req.RazorpaySignature
+	payment.GatewayProvider = PaymentGatewayRazorpay
+	payment.GatewayOrderID = &req.RazorpayOrderID
+	payment.GatewayPaymentID = &req.RazorpayPaymentID
+	payment.GatewaySignature = &req.RazorpaySignature
 	payment.CompletedAt = &now
 	
 	err = paymentService.UpdatePayment(payment)
@@ -1211,6 +1313,7 @@ func (bs *BookingService) VerifyInquiryPaymentAndCreateBooking(userID uint, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to create booking: %v", err)
 	}
+	bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 	// 6. Update payment to link to the actual booking
 	payment.RelatedEntityType = "booking"
@@ -1245,14 +1348,14 @@ func (bs *BookingService) VerifyInquiryPayment(userID uint, req *models.VerifyIn
 		return nil, errors.New("service is not inquiry-based")
 	}
 
-	// 3. Verify Razorpay payment
-	if bs.razorpayService == nil {
-		logrus.Error("Razorpay service is nil")
+	// 3. Verify gateway payment
+	if bs.paymentService == nil {
+		logrus.Error("Payment service is nil")
 		return nil, errors.New("payment service not available")
 	}
 	
-	logrus.Infof("Verifying Razorpay payment: payment_id=%s, order_id=%s", req.RazorpayPaymentID, req.RazorpayOrderID)
-	isValid, err := bs.razorpayService.VerifyPayment(req.RazorpayPaymentID, req.RazorpayOrderID, req.RazorpaySignature)
+	logrus.Infof("Verifying gateway payment: payment_id=%s, order_id=%s", req.RazorpayPaymentID, req.RazorpayOrderID)
+	isValid, err := bs.paymentService.VerifyGatewayPayment(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature)
 	if err != nil {
 		logrus.Errorf("Payment verification failed: %v", err)
 		return nil, fmt.Errorf("payment verification failed: %v", err)
@@ -1295,12 +1398,13 @@ func (bs *BookingService) VerifyInquiryPayment(userID uint, req *models.VerifyIn
 	if err != nil {
 		return nil, err
 	}
+	bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 	logrus.Infof("Booking created with ID: %d, status: %s, booking_type: %s", 
 		booking.ID, booking.Status, booking.BookingType)
 
 	// 7. Get the existing payment record to retrieve inquiry data
-	existingPayment, err := bs.paymentService.GetPaymentByRazorpayOrderID(req.RazorpayOrderID)
+	existingPayment, err := bs.paymentService.GetPaymentByGatewayOrderID(req.RazorpayOrderID)
 	if err != nil {
 		logrus.Errorf("Could not find existing payment record: %v", err)
 		return nil, fmt.Errorf("payment record not found: %v", err)
@@ -1357,8 +1461,8 @@ func (bs *BookingService) VerifyInquiryPayment(userID uint, req *models.VerifyIn
 	existingPayment.RelatedEntityType = "booking"
 	existingPayment.RelatedEntityID = booking.ID
 	existingPayment.Status = models.PaymentStatusCompleted
-	existingPayment.RazorpayPaymentID = &req.RazorpayPaymentID
-	existingPayment.RazorpaySignature = &req.RazorpaySignature
+	existingPayment.GatewayPaymentID = &req.RazorpayPaymentID
+	existingPayment.GatewaySignature = &req.RazorpaySignature
 	now := time.Now()
 	existingPayment.CompletedAt = &now
 	existingPayment.Notes = "Inquiry booking fee - payment completed"
@@ -1403,8 +1507,8 @@ func (bs *BookingService) ConvertToOptimizedBookingResponse(booking *models.Book
 			Amount:            booking.Payment.Amount,
 			Currency:          booking.Payment.Currency,
 			PaymentMethod:     &booking.Payment.Method,
-			RazorpayOrderID:   booking.Payment.RazorpayOrderID,
-			RazorpayPaymentID: booking.Payment.RazorpayPaymentID,
+			RazorpayOrderID:   booking.Payment.GatewayOrderID,
+			RazorpayPaymentID: booking.Payment.GatewayPaymentID,
 			CreatedAt:         &booking.Payment.CreatedAt,
 		}
 	} else {
@@ -1420,8 +1524,8 @@ func (bs *BookingService) ConvertToOptimizedBookingResponse(booking *models.Book
 				Amount:            paymentRecord.Amount,
 				Currency:          paymentRecord.Currency,
 				PaymentMethod:     &paymentRecord.Method,
-				RazorpayOrderID:   paymentRecord.RazorpayOrderID,
-				RazorpayPaymentID: paymentRecord.RazorpayPaymentID,
+				RazorpayOrderID:   paymentRecord.GatewayOrderID,
+				RazorpayPaymentID: paymentRecord.GatewayPaymentID,
 				CreatedAt:         &paymentRecord.CreatedAt,
 			}
 		} else {
@@ -1461,7 +1565,8 @@ func (bs *BookingService) ConvertToOptimizedBookingResponse(booking *models.Book
 				PaidAt:        segment.PaidAt,
 				Notes:         segment.Notes,
 				PaymentID:     segment.PaymentID,
-				IsOverdue:     false,
+				IsOverdue:     segment.Status == models.PaymentSegmentStatusOverdue,
+				AutoDebit:     segment.AutoDebit,
 			}
 
 			// Calculate if overdue
@@ -1550,8 +1655,8 @@ func (bs *BookingService) ConvertToUserOptimizedBookingResponse(booking *models.
 			Amount:            booking.Payment.Amount,
 			Currency:          booking.Payment.Currency,
 			PaymentMethod:     &booking.Payment.Method,
-			RazorpayOrderID:   booking.Payment.RazorpayOrderID,
-			RazorpayPaymentID: booking.Payment.RazorpayPaymentID,
+			RazorpayOrderID:   booking.Payment.GatewayOrderID,
+			RazorpayPaymentID: booking.Payment.GatewayPaymentID,
 			CreatedAt:         &booking.Payment.CreatedAt,
 		}
 	} else {
@@ -1567,8 +1672,8 @@ func (bs *BookingService) ConvertToUserOptimizedBookingResponse(booking *models.
 				Amount:            paymentRecord.Amount,
 				Currency:          paymentRecord.Currency,
 				PaymentMethod:     &paymentRecord.Method,
-				RazorpayOrderID:   paymentRecord.RazorpayOrderID,
-				RazorpayPaymentID: paymentRecord.RazorpayPaymentID,
+				RazorpayOrderID:   paymentRecord.GatewayOrderID,
+				RazorpayPaymentID: paymentRecord.GatewayPaymentID,
 				CreatedAt:         &paymentRecord.CreatedAt,
 			}
 		} else {
@@ -1608,7 +1713,8 @@ func (bs *BookingService) ConvertToUserOptimizedBookingResponse(booking *models.
 				PaidAt:        segment.PaidAt,
 				Notes:         segment.Notes,
 				PaymentID:     segment.PaymentID,
-				IsOverdue:     false,
+				IsOverdue:     segment.Status == models.PaymentSegmentStatusOverdue,
+				AutoDebit:     segment.AutoDebit,
 			}
 
 			// Calculate if overdue
@@ -1698,9 +1804,9 @@ func (bs *BookingService) ConvertToDetailedBookingResponse(booking *models.Booki
 			Amount:            booking.Payment.Amount,
 			Currency:          booking.Payment.Currency,
 			PaymentMethod:     &booking.Payment.Method,
-			RazorpayOrderID:   booking.Payment.RazorpayOrderID,
-			RazorpayPaymentID: booking.Payment.RazorpayPaymentID,
-			RazorpaySignature: booking.Payment.RazorpaySignature,
+			RazorpayOrderID:   booking.Payment.GatewayOrderID,
+			RazorpayPaymentID: booking.Payment.GatewayPaymentID,
+			RazorpaySignature: booking.Payment.GatewaySignature,
 			Metadata:          map[string]interface{}(*booking.Payment.Metadata),
 			CreatedAt:         booking.Payment.CreatedAt,
 			UpdatedAt:         booking.Payment.UpdatedAt,
@@ -1719,9 +1825,9 @@ func (bs *BookingService) ConvertToDetailedBookingResponse(booking *models.Booki
 				Amount:            paymentRecord.Amount,
 				Currency:          paymentRecord.Currency,
 				PaymentMethod:     &paymentRecord.Method,
-				RazorpayOrderID:   paymentRecord.RazorpayOrderID,
-				RazorpayPaymentID: paymentRecord.RazorpayPaymentID,
-				RazorpaySignature: paymentRecord.RazorpaySignature,
+				RazorpayOrderID:   paymentRecord.GatewayOrderID,
+				RazorpayPaymentID: paymentRecord.GatewayPaymentID,
+				RazorpaySignature: paymentRecord.GatewaySignature,
 				Metadata:          map[string]interface{}(*paymentRecord.Metadata),
 				CreatedAt:         paymentRecord.CreatedAt,
 				UpdatedAt:         paymentRecord.UpdatedAt,
@@ -1765,7 +1871,7 @@ func (bs *BookingService) ConvertToDetailedBookingResponse(booking *models.Booki
 	relatedBookings := bs.getRelatedBookings(booking.UserID, booking.ID)
 
 	// Get statistics
-	statistics := bs.getBookingStatistics(booking.ID)
+	statistics := bs.getBookingStatistics(booking)
 
 	// Get payment progress
 	paymentProgress := booking.GetPaymentProgress()
@@ -1884,20 +1990,52 @@ func (bs *BookingService) getRelatedBookings(userID uint, excludeBookingID uint)
 	return []models.RelatedBooking{}
 }
 
-func (bs *BookingService) getBookingStatistics(bookingID uint) *models.BookingStatistics {
-	// This would typically calculate statistics from the database
-	// For now, returning default values
-	return &models.BookingStatistics{
+func (bs *BookingService) getBookingStatistics(booking *models.Booking) *models.BookingStatistics {
+	statistics := &models.BookingStatistics{
 		TotalMessages: 0,
 		TotalReviews:  0,
 		AverageRating: 0.0,
 	}
+
+	// Published booking review (at most one per booking)
+	if review, err := bs.reviewRepo.GetByBookingID(booking.ID); err == nil && review.Status == models.ReviewStatusPublished {
+		statistics.TotalReviews = 1
+		statistics.AverageRating = float64(review.Rating)
+	}
+
+	// Completion time in minutes
+	if booking.ActualDurationMinutes != nil {
+		statistics.CompletionTime = booking.ActualDurationMinutes
+	} else if booking.ActualStartTime != nil && booking.ActualEndTime != nil {
+		minutes := int(booking.ActualEndTime.Sub(*booking.ActualStartTime).Minutes())
+		statistics.CompletionTime = &minutes
+	}
+
+	// Worker's aggregate rating
+	if booking.WorkerAssignment != nil {
+		if worker, err := bs.workerRepo.GetByUserID(booking.WorkerAssignment.WorkerID); err == nil {
+			statistics.WorkerRating = &worker.Rating
+		}
+	}
+
+	return statistics
 }
 
 func (bs *BookingService) getBookingReviews(bookingID uint) []models.Review {
-	// This would typically query the database for reviews
-	// For now, returning empty slice
-	return []models.Review{}
+	review, err := bs.reviewRepo.GetByBookingID(bookingID)
+	if err != nil || review.Status != models.ReviewStatusPublished {
+		return []models.Review{}
+	}
+
+	return []models.Review{
+		{
+			ID:         review.ID,
+			Rating:     review.Rating,
+			Review:     review.Review,
+			Categories: review.Categories,
+			CreatedAt:  review.CreatedAt,
+		},
+	}
 }
 
 func (bs *BookingService) getBookingChatMessages(bookingID uint) []models.ChatMessageInfo {
@@ -1907,15 +2045,51 @@ func (bs *BookingService) getBookingChatMessages(bookingID uint) []models.ChatMe
 }
 
 func (bs *BookingService) getBookingActivityLog(bookingID uint) []models.ActivityLog {
-	// This would typically query the database for activity log
-	// For now, returning empty slice
-	return []models.ActivityLog{}
+	activities, err := bs.activityService.GetBookingActivity(bookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get activity log for booking %d: %v", bookingID, err)
+		return []models.ActivityLog{}
+	}
+
+	result := make([]models.ActivityLog, 0, len(activities))
+	for _, activity := range activities {
+		result = append(result, models.ActivityLog{
+			ID:            activity.ID,
+			Action:        string(activity.Action),
+			Description:   activity.Description,
+			PerformedBy:   string(activity.ActorType),
+			PerformedByID: activity.ActorID,
+			OldValue:      activity.OldValue,
+			NewValue:      activity.NewValue,
+			CreatedAt:     activity.CreatedAt,
+		})
+	}
+	return result
 }
 
 func (bs *BookingService) getBookingDisputes(bookingID uint) []models.Dispute {
-	// This would typically query the database for disputes
-	// For now, returning empty slice
-	return []models.Dispute{}
+	disputes, err := bs.disputeRepo.GetByBookingID(bookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get disputes for booking %d: %v", bookingID, err)
+		return []models.Dispute{}
+	}
+
+	result := make([]models.Dispute, 0, len(disputes))
+	for _, dispute := range disputes {
+		var resolution *string
+		if dispute.Resolution != nil {
+			value := string(*dispute.Resolution)
+			resolution = &value
+		}
+		result = append(result, models.Dispute{
+			ID:         dispute.ID,
+			Reason:     string(dispute.Reason),
+			Status:     string(dispute.Status),
+			Resolution: resolution,
+			CreatedAt:  dispute.CreatedAt,
+		})
+	}
+	return result
 }
 
 // CreateBookingWithWallet creates a booking with wallet payment for fixed price services
@@ -1939,6 +2113,20 @@ func (bs *BookingService) CreateBookingWithWallet(userID uint, req *models.Creat
 		return nil, errors.New("service price is not set")
 	}
 
+	// Check the coupon before holding the slot
+	amount := *service.Price
+	couponTarget := &models.CouponTarget{
+		Scope:      models.CouponScopeBooking,
+		Amount:     amount,
+		ServiceID:  service.ID,
+		CategoryID: service.CategoryID,
+	}
+	if req.CouponCode != "" {
+		if _, err := bs.couponService.Apply(userID, req.CouponCode, couponTarget, 0); err != nil {
+			return nil, err
+		}
+	}
+
 	// 4. Parse scheduled date and time
 	scheduledDate, err := time.Parse("2006-01-02", req.ScheduledDate)
 	if err != nil {
@@ -1963,11 +2151,6 @@ func (bs *BookingService) CreateBookingWithWallet(userID uint, req *models.Creat
 	}
 
 	// 7. Check if time slot is available
-	location := ""
-	if req.Address.City != "" && req.Address.State != "" {
-		location = req.Address.City + ", " + req.Address.State
-	}
-	
 	serviceDurationMinutes := 60 // Default duration
 	if service.Duration != nil {
 		durationStr := *service.Duration
@@ -1976,7 +2159,7 @@ func (bs *BookingService) CreateBookingWithWallet(userID uint, req *models.Creat
 		}
 	}
 	
-	isSlotAvailable, err := bs.isTimeSlotAvailable(scheduledTime, serviceDurationMinutes, req.ServiceID, location)
+	isSlotAvailable, err := bs.isTimeSlotAvailable(scheduledTime, serviceDurationMinutes, req.ServiceID, req.Address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check slot availability: %v", err)
 	}
@@ -2022,17 +2205,39 @@ func (bs *BookingService) CreateBookingWithWallet(userID uint, req *models.Creat
 	if err != nil {
 		return nil, fmt.Errorf("failed to save booking: %v", err)
 	}
+	bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
+
+	// Reserve the coupon for this booking
+	var redemption *models.CouponRedemption
+	if req.CouponCode != "" {
+		var application *models.CouponApplication
+		application, redemption, err = bs.couponService.ReserveForOrder(userID, req.CouponCode, couponTarget, &booking.ID)
+		if err != nil {
+			booking.PaymentStatus = "failed"
+			if cancelErr := bs.stateMachine.TransitionBooking(booking, models.BookingStatusCancelled, TransitionContext{Actor: models.SystemActor(), Reason: "Coupon could not be applied"}); cancelErr != nil {
+				logrus.Errorf("Failed to cancel booking %d after coupon error: %v", booking.ID, cancelErr)
+			}
+			return nil, err
+		}
+		amount = application.PayableAmount
+	}
 
 	// 13. Process wallet payment after booking is created
 	walletService := NewUnifiedWalletService()
-	_, err = walletService.DeductFromWalletForBooking(userID, *service.Price, booking.ID, "Service booking payment")
+	walletPayment, err := walletService.DeductFromWalletForBooking(userID, amount, booking.ID, "Service booking payment")
 	if err != nil {
-		// If payment fails, update booking status to cancelled
-		booking.Status = models.BookingStatusCancelled
+		// If payment fails, update booking status to cancelled; this also frees the coupon
 		booking.PaymentStatus = "failed"
-		bs.bookingRepo.Update(booking)
+		if err := bs.stateMachine.TransitionBooking(booking, models.BookingStatusCancelled, TransitionContext{Actor: models.SystemActor(), Reason: "Wallet payment failed"}); err != nil {
+			logrus.Errorf("Failed to cancel booking %d after wallet payment failure: %v", booking.ID, err)
+		}
 		return nil, fmt.Errorf("failed to process wallet payment: %v", err)
 	}
+	if redemption != nil {
+		if err := bs.couponService.Confirm(redemption, &walletPayment.ID); err != nil {
+			logrus.Errorf("Failed to confirm coupon for booking %d: %v", booking.ID, err)
+		}
+	}
 
 	// 14. Send confirmation notification
 	go bs.notificationService.SendBookingConfirmation(booking)
@@ -2048,14 +2253,14 @@ func (bs *BookingService) CreateBookingWithWallet(userID uint, req *models.Creat
 		payment := &models.Payment{
 			Model:  gorm.Model{ID: 0}, // Wallet payments don't have a separate payment record
 			UserID: userID,
-			Amount: *service.Price,
+			Amount: amount,
 			Type:   models.PaymentTypeBooking,
 			Status: models.PaymentStatusCompleted,
 		}
 		go bs.sendWalletPaymentNotifications(payment, &user)
 	}
 
-	logrus.Infof("Wallet payment booking created successfully: booking_id=%d, amount=%.2f", booking.ID, *service.Price)
+	logrus.Infof("Wallet payment booking created successfully: booking_id=%d, amount=%.2f", booking.ID, amount)
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -2133,6 +2338,7 @@ func (bs *BookingService) CreateInquiryBookingWithWallet(userID uint, req *model
 	if err != nil {
 		return nil, fmt.Errorf("failed to save booking: %v", err)
 	}
+	bs.activityService.Record(booking.ID, models.BookingActivityCreated, models.UserActor(userID), nil, booking.Status, fmt.Sprintf("%s booking created", booking.BookingType))
 
 	// 9. Process wallet payment if fee is required (after booking is created)
 	if feeAmount > 0 {
@@ -2141,9 +2347,10 @@ func (bs *BookingService) CreateInquiryBookingWithWallet(userID uint, req *model
 		_, err = walletService.DeductFromWalletForBooking(userID, feeFloat, booking.ID, "Inquiry booking fee")
 		if err != nil {
 			// If payment fails, update booking status to cancelled
-			booking.Status = models.BookingStatusCancelled
 			booking.PaymentStatus = "failed"
-			bs.bookingRepo.Update(booking)
+			if err := bs.stateMachine.TransitionBooking(booking, models.BookingStatusCancelled, TransitionContext{Actor: models.SystemActor(), Reason: "Wallet payment failed"}); err != nil {
+				logrus.Errorf("Failed to cancel booking %d after wallet payment failure: %v", booking.ID, err)
+			}
 			return nil, fmt.Errorf("failed to process wallet payment: %v", err)
 		}
 	}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StateTransitionError is returned when a booking or assignment status change is not allowed,
+// either because the edge is not in the transition table or because its guard failed
+type StateTransitionError struct {
+	Entity string // "booking" or "assignment"
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *StateTransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("cannot move %s from %s to %s: %s", e.Entity, e.From, e.To, e.Reason)
+	}
+	return fmt.Sprintf("cannot move %s from %s to %s", e.Entity, e.From, e.To)
+}
+
+// IsStateTransitionError reports whether err is (or wraps) a StateTransitionError
+func IsStateTransitionError(err error) bool {
+	var transitionErr *StateTransitionError
+	return errors.As(err, &transitionErr)
+}
+
+// TransitionContext describes who is changing a status and why
+type TransitionContext struct {
+	Actor  models.ActivityActor
+	Reason string
+}
+
+// bookingGuard checks a condition that must hold before a booking enters a status
+type bookingGuard func(sm *BookingStateMachine, booking *models.Booking) error
+
+// bookingHook runs after a booking has entered a status
+type bookingHook func(sm *BookingStateMachine, booking *models.Booking, from models.BookingStatus, ctx TransitionContext)
+
+// assignmentHook runs after an assignment has entered a status
+type assignmentHook func(sm *BookingStateMachine, assignment *models.WorkerAssignment, from models.AssignmentStatus, ctx TransitionContext)
+
+// bookingTransitions lists the allowed booking status edges. Terminal statuses have no entry.
+var bookingTransitions = map[models.BookingStatus][]models.BookingStatus{
+	models.BookingStatusTemporaryHold: {models.BookingStatusConfirmed, models.BookingStatusCancelled},
+	models.BookingStatusPending:       {models.BookingStatusQuoteProvided, models.BookingStatusConfirmed, models.BookingStatusCancelled},
+	models.BookingStatusQuoteProvided: {models.BookingStatusQuoteAccepted, models.BookingStatusPending, models.BookingStatusRejected, models.BookingStatusCancelled},
+	models.BookingStatusQuoteAccepted: {models.BookingStatusConfirmed, models.BookingStatusPartiallyPaid, models.BookingStatusCancelled},
+	models.BookingStatusPartiallyPaid: {models.BookingStatusConfirmed, models.BookingStatusCancelled},
+	models.BookingStatusConfirmed:     {models.BookingStatusScheduled, models.BookingStatusAssigned, models.BookingStatusInProgress, models.BookingStatusCancelled},
+	models.BookingStatusScheduled:     {models.BookingStatusConfirmed, models.BookingStatusAssigned, models.BookingStatusCancelled},
+	models.BookingStatusAssigned:      {models.BookingStatusConfirmed, models.BookingStatusInProgress, models.BookingStatusCancelled},
+	models.BookingStatusInProgress:    {models.BookingStatusCompleted, models.BookingStatusCancelled},
+}
+
+// bookingGuards are checked before a booking enters the status
+var bookingGuards = map[models.BookingStatus]bookingGuard{
+	models.BookingStatusQuoteProvided: guardQuoteProvided,
+	models.BookingStatusScheduled:     guardScheduled,
+	models.BookingStatusAssigned:      guardAssignmentIn(models.AssignmentStatusAssigned),
+	models.BookingStatusInProgress:    guardAssignmentIn(models.AssignmentStatusInProgress),
+	models.BookingStatusCompleted:     guardAssignmentIn(models.AssignmentStatusCompleted),
+}
+
+// bookingHooks run after a booking enters the status
+var bookingHooks = map[models.BookingStatus][]bookingHook{
+	models.BookingStatusCancelled: {hookDisableCallMasking, hookNotifyCancelled, hookReleaseCoupons},
+	models.BookingStatusCompleted: {hookDisableCallMasking, hookCreditBookingRewards},
+}
+
+// assignmentTransitions lists the allowed worker assignment status edges
+var assignmentTransitions = map[models.AssignmentStatus][]models.AssignmentStatus{
+	models.AssignmentStatusReserved:   {models.AssignmentStatusAssigned, models.AssignmentStatusRejected},
+	models.AssignmentStatusAssigned:   {models.AssignmentStatusAccepted, models.AssignmentStatusRejected},
+	models.AssignmentStatusRejected:   {models.AssignmentStatusAssigned},
+	models.AssignmentStatusAccepted:   {models.AssignmentStatusInProgress},
+	models.AssignmentStatusInProgress: {models.AssignmentStatusCompleted},
+}
+
+// assignmentHooks run after an assignment enters the status
+var assignmentHooks = map[models.AssignmentStatus][]assignmentHook{
+	models.AssignmentStatusAccepted: {hookEnableAssignmentCallMasking},
+	models.AssignmentStatusRejected: {hookDisableAssignmentCallMasking},
+}
+
+// BookingStateMachine is the single place booking and assignment statuses are changed
+type BookingStateMachine struct {
+	bookingRepo          *repositories.BookingRepository
+	workerAssignmentRepo *repositories.WorkerAssignmentRepository
+	userRepo             *repositories.UserRepository
+	serviceRepo          *repositories.ServiceRepository
+	activityService      *BookingActivityService
+}
+
+// NewBookingStateMachine creates a new booking state machine
+func NewBookingStateMachine() *BookingStateMachine {
+	return &BookingStateMachine{
+		bookingRepo:          repositories.NewBookingRepository(),
+		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
+		userRepo:             repositories.NewUserRepository(),
+		serviceRepo:          repositories.NewServiceRepository(),
+		activityService:      NewBookingActivityService(),
+	}
+}
+
+// CanTransitionBooking checks whether a booking may move to the given status without changing it
+func (sm *BookingStateMachine) CanTransitionBooking(booking *models.Booking, to models.BookingStatus) error {
+	from := booking.Status
+	if from == to {
+		return nil
+	}
+
+	if !containsBookingStatus(bookingTransitions[from], to) {
+		return &StateTransitionError{Entity: "booking", From: string(from), To: string(to)}
+	}
+
+	if guard, ok := bookingGuards[to]; ok {
+		if err := guard(sm, booking); err != nil {
+			return &StateTransitionError{Entity: "booking", From: string(from), To: string(to), Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// TransitionBooking moves a booking to the given status and saves it together with any other
+// field changes already made on it. The change is recorded in the activity log and the hooks
+// for the new status are run. Moving to the current status just saves the booking.
+func (sm *BookingStateMachine) TransitionBooking(booking *models.Booking, to models.BookingStatus, ctx TransitionContext) error {
+	from := booking.Status
+	if err := sm.CanTransitionBooking(booking, to); err != nil {
+		return err
+	}
+
+	booking.Status = to
+	if err := sm.bookingRepo.Update(booking); err != nil {
+		booking.Status = from
+		return fmt.Errorf("failed to update booking status: %v", err)
+	}
+
+	if from == to {
+		return nil
+	}
+
+	sm.activityService.RecordStatusChange(booking.ID, from, to, ctx.Actor, ctx.Reason)
+	for _, hook := range bookingHooks[to] {
+		hook(sm, booking, from, ctx)
+	}
+
+	logrus.Infof("Booking %d moved from %s to %s by %s", booking.ID, from, to, ctx.Actor.Type)
+	return nil
+}
+
+// CanTransitionAssignment checks whether an assignment may move to the given status without changing it
+func (sm *BookingStateMachine) CanTransitionAssignment(assignment *models.WorkerAssignment, to models.AssignmentStatus) error {
+	from := assignment.Status
+	if from == to {
+		return nil
+	}
+
+	if !containsAssignmentStatus(assignmentTransitions[from], to) {
+		return &StateTransitionError{Entity: "assignment", From: string(from), To: string(to)}
+	}
+
+	// Work cannot start or finish while the assignment is paused for overdue payment
+	if assignment.PausedAt != nil && (to == models.AssignmentStatusInProgress || to == models.AssignmentStatusCompleted) {
+		return &StateTransitionError{Entity: "assignment", From: string(from), To: string(to), Reason: "assignment is paused: " + assignment.PauseReason}
+	}
+
+	return nil
+}
+
+// TransitionAssignment moves a worker assignment to the given status and saves it together with
+// any other field changes already made on it, then records the change and runs the status hooks
+func (sm *BookingStateMachine) TransitionAssignment(assignment *models.WorkerAssignment, to models.AssignmentStatus, ctx TransitionContext) error {
+	from := assignment.Status
+	if err := sm.CanTransitionAssignment(assignment, to); err != nil {
+		return err
+	}
+
+	assignment.Status = to
+	if err := sm.workerAssignmentRepo.Update(assignment); err != nil {
+		assignment.Status = from
+		return fmt.Errorf("failed to update assignment status: %v", err)
+	}
+
+	if from == to {
+		return nil
+	}
+
+	sm.activityService.RecordAssignmentStatusChange(assignment.BookingID, from, to, ctx.Actor, ctx.Reason)
+	for _, hook := range assignmentHooks[to] {
+		hook(sm, assignment, from, ctx)
+	}
+
+	return nil
+}
+
+// AllowedBookingTransitions returns the statuses a booking in the given status can move to
+func AllowedBookingTransitions(from models.BookingStatus) []models.BookingStatus {
+	return append([]models.BookingStatus{}, bookingTransitions[from]...)
+}
+
+// guardQuoteProvided requires a quote amount before the quote is offered to the customer
+func guardQuoteProvided(sm *BookingStateMachine, booking *models.Booking) error {
+	if booking.QuoteAmount == nil || *booking.QuoteAmount <= 0 {
+		return errors.New("booking has no quote amount")
+	}
+	return nil
+}
+
+// guardScheduled requires a scheduled time
+func guardScheduled(sm *BookingStateMachine, booking *models.Booking) error {
+	if booking.ScheduledTime == nil {
+		return errors.New("booking has no scheduled time")
+	}
+	return nil
+}
+
+// guardAssignmentIn requires the booking's worker assignment to already be in the given status
+func guardAssignmentIn(status models.AssignmentStatus) bookingGuard {
+	return func(sm *BookingStateMachine, booking *models.Booking) error {
+		assignment, err := sm.workerAssignmentRepo.GetByBookingID(booking.ID)
+		if err != nil || assignment == nil {
+			return errors.New("booking has no worker assignment")
+		}
+		if assignment.Status != status {
+			return fmt.Errorf("worker assignment is %s, expected %s", assignment.Status, status)
+		}
+		return nil
+	}
+}
+
+// hookDisableCallMasking tears down call masking once the booking is finished
+func hookDisableCallMasking(sm *BookingStateMachine, booking *models.Booking, from models.BookingStatus, ctx TransitionContext) {
+	go NewCallMaskingService().DisableCallMasking(booking.ID)
+}
+
+// hookNotifyCancelled tells the customer their booking was cancelled.
+// System cancellations (expired holds, failed wallet payments) are reported by the caller instead.
+func hookNotifyCancelled(sm *BookingStateMachine, booking *models.Booking, from models.BookingStatus, ctx TransitionContext) {
+	if ctx.Actor.Type == models.ActivityActorSystem {
+		return
+	}
+
+	reason := ctx.Reason
+	if reason == "" {
+		reason = "Booking cancelled"
+	}
+
+	go func() {
+		var user models.User
+		if err := sm.userRepo.FindByID(&user, booking.UserID); err != nil {
+			return
+		}
+		var service models.Service
+		if err := sm.serviceRepo.FindByID(&service, booking.ServiceID); err != nil {
+			return
+		}
+		NotifyBookingCancelled(booking, &user, &service, reason)
+	}()
+}
+
+// hookReleaseCoupons frees coupons still reserved for a booking that was cancelled before it was paid
+func hookReleaseCoupons(sm *BookingStateMachine, booking *models.Booking, from models.BookingStatus, ctx TransitionContext) {
+	if err := NewCouponService().ReleaseForBooking(booking.ID); err != nil {
+		logrus.Errorf("Failed to release coupons of booking %d: %v", booking.ID, err)
+	}
+}
+
+// hookCreditBookingRewards credits coupon cashback and the referral reward earned by a completed booking
+func hookCreditBookingRewards(sm *BookingStateMachine, booking *models.Booking, from models.BookingStatus, ctx TransitionContext) {
+	go func() {
+		NewCouponService().CreditBookingCashback(booking.ID)
+		NewReferralService().RewardForCompletedBooking(booking)
+	}()
+}
+
+// hookEnableAssignmentCallMasking sets up call masking once the worker accepts
+func hookEnableAssignmentCallMasking(sm *BookingStateMachine, assignment *models.WorkerAssignment, from models.AssignmentStatus, ctx TransitionContext) {
+	go NewCallMaskingService().EnableCallMasking(assignment.BookingID)
+}
+
+// hookDisableAssignmentCallMasking tears down call masking when the worker rejects
+func hookDisableAssignmentCallMasking(sm *BookingStateMachine, assignment *models.WorkerAssignment, from models.AssignmentStatus, ctx TransitionContext) {
+	go NewCallMaskingService().DisableCallMasking(assignment.BookingID)
+}
+
+func containsBookingStatus(statuses []models.BookingStatus, status models.BookingStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAssignmentStatus(statuses []models.AssignmentStatus, status models.AssignmentStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"treesindia/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrRefundPending is returned when a booking was cancelled but some of the refunds owed on it could not be issued
+var ErrRefundPending = errors.New("booking cancelled but its refund is pending")
+
+// CancellationPolicyService applies the admin-configured cancellation policy to bookings
+type CancellationPolicyService struct {
+	paymentService     *PaymentService
+	adminConfigService *AdminConfigService
+}
+
+// NewCancellationPolicyService creates a new cancellation policy service
+func NewCancellationPolicyService() *CancellationPolicyService {
+	return &CancellationPolicyService{
+		paymentService:     NewPaymentService(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// CalculateRefunds works out the cancellation fee and refund for every completed payment of a booking
+func (cps *CancellationPolicyService) CalculateRefunds(booking *models.Booking) (*models.CancellationSummary, error) {
+	payments, err := cps.paymentService.GetCompletedBookingPayments(booking.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking payments: %v", err)
+	}
+
+	summary := &models.CancellationSummary{
+		BookingID: booking.ID,
+		Items:     []models.CancellationRefundItem{},
+	}
+
+	if booking.ScheduledTime != nil {
+		hours := time.Until(*booking.ScheduledTime).Hours()
+		summary.HoursBeforeService = &hours
+	}
+
+	bookingTiers := ParseCancellationFeeTiers(cps.adminConfigService.GetCancellationFeeTiers())
+	segmentTiers := ParseCancellationFeeTiers(cps.adminConfigService.GetSegmentCancellationFeeTiers())
+
+	// Map payment IDs to the segments they paid for
+	segmentNumbers := make(map[uint]int)
+	for _, segment := range booking.PaymentSegments {
+		if segment.Status == models.PaymentSegmentStatusPaid && segment.PaymentID != nil {
+			segmentNumbers[*segment.PaymentID] = segment.SegmentNumber
+		}
+	}
+
+	for _, payment := range payments {
+		item := models.CancellationRefundItem{
+			PaymentID:  payment.ID,
+			Method:     payment.Method,
+			PaidAmount: payment.Amount,
+		}
+
+		if segmentNumber, ok := segmentNumbers[payment.ID]; ok {
+			item.Kind = models.CancellationPaymentKindSegment
+			item.SegmentNumber = &segmentNumber
+			item.FeePercentage = feePercentageForTiers(segmentTiers, summary.HoursBeforeService)
+		} else if isInquiryFee(booking, &payment) {
+			item.Kind = models.CancellationPaymentKindInquiryFee
+			refundPercentage := cps.adminConfigService.GetInquiryFeeRefundPercentage(booking.QuoteAcceptedAt != nil)
+			item.FeePercentage = 100 - clampPercentage(refundPercentage)
+		} else {
+			item.Kind = models.CancellationPaymentKindBooking
+			item.FeePercentage = feePercentageForTiers(bookingTiers, summary.HoursBeforeService)
+		}
+
+		// Only what has not been refunded already is refunded again
+		refundable := refundableAmount(&payment)
+		item.FeeAmount = roundCurrency(refundable * item.FeePercentage / 100)
+		item.RefundAmount = roundCurrency(refundable - item.FeeAmount)
+		item.RefundMethod = cps.paymentService.resolveRefundMethod(&payment, "")
+
+		summary.TotalPaid += payment.Amount
+		summary.CancellationFee += item.FeeAmount
+		summary.RefundAmount += item.RefundAmount
+		summary.Items = append(summary.Items, item)
+	}
+
+	summary.TotalPaid = roundCurrency(summary.TotalPaid)
+	summary.CancellationFee = roundCurrency(summary.CancellationFee)
+	summary.RefundAmount = roundCurrency(summary.RefundAmount)
+
+	return summary, nil
+}
+
+// ProcessRefunds issues the refunds calculated in the summary and records the outcome on each item.
+// A failed refund does not stop the others; the payment stays completed so it can be refunded manually,
+// and ErrRefundPending is returned naming the payments that were not refunded.
+func (cps *CancellationPolicyService) ProcessRefunds(summary *models.CancellationSummary, reason string) error {
+	var refunded float64
+	var failures []string
+	for i := range summary.Items {
+		item := &summary.Items[i]
+		if item.RefundAmount <= 0 {
+			item.RefundStatus = "not_applicable"
+			continue
+		}
+
+		_, err := cps.paymentService.RefundPayment(item.PaymentID, &models.RefundPaymentRequest{
+			RefundAmount: item.RefundAmount,
+			RefundReason: reason,
+			RefundMethod: item.RefundMethod,
+			Notes:        fmt.Sprintf("Cancellation refund for booking %d (fee %.0f%%)", summary.BookingID, item.FeePercentage),
+		})
+		if err != nil {
+			logrus.Errorf("Failed to refund payment %d for booking %d: %v", item.PaymentID, summary.BookingID, err)
+			item.RefundStatus = "failed"
+			item.RefundError = err.Error()
+			failures = append(failures, fmt.Sprintf("payment %d: %v", item.PaymentID, err))
+			continue
+		}
+
+		item.RefundStatus = "refunded"
+		refunded += item.RefundAmount
+	}
+	summary.RefundAmount = roundCurrency(refunded)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%w: %s", ErrRefundPending, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// isInquiryFee reports whether a payment of a booking is its inquiry fee: the payment of an inquiry booking
+// made before its quote was accepted. Quote and segment payments are only made once the quote is accepted.
+func isInquiryFee(booking *models.Booking, payment *models.Payment) bool {
+	if booking.BookingType != models.BookingTypeInquiry {
+		return false
+	}
+	return booking.QuoteAcceptedAt == nil || payment.CreatedAt.Before(*booking.QuoteAcceptedAt)
+}
+
+// ParseCancellationFeeTiers parses "hours:percent" pairs such as "24:0,6:20,0:50".
+// Invalid entries are skipped; tiers are returned sorted by hours, highest first.
+func ParseCancellationFeeTiers(value string) []models.CancellationFeeTier {
+	var tiers []models.CancellationFeeTier
+	for _, part := range strings.Split(value, ",") {
+		pair := strings.Split(strings.TrimSpace(part), ":")
+		if len(pair) != 2 {
+			continue
+		}
+		hours, err := strconv.ParseFloat(strings.TrimSpace(pair[0]), 64)
+		if err != nil {
+			logrus.Warnf("Invalid cancellation fee tier hours %q", pair[0])
+			continue
+		}
+		percentage, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64)
+		if err != nil {
+			logrus.Warnf("Invalid cancellation fee tier percentage %q", pair[1])
+			continue
+		}
+		tiers = append(tiers, models.CancellationFeeTier{
+			MinHoursBefore: hours,
+			FeePercentage:  clampPercentage(percentage),
+		})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinHoursBefore > tiers[j].MinHoursBefore
+	})
+	return tiers
+}
+
+// feePercentageForTiers returns the fee for the given hours before service.
+// Unscheduled bookings are free to cancel; past the last tier the last tier's fee applies.
+func feePercentageForTiers(tiers []models.CancellationFeeTier, hoursBefore *float64) float64 {
+	if hoursBefore == nil || len(tiers) == 0 {
+		return 0
+	}
+	for _, tier := range tiers {
+		if *hoursBefore >= tier.MinHoursBefore {
+			return tier.FeePercentage
+		}
+	}
+	return tiers[len(tiers)-1].FeePercentage
+}
+
+// clampPercentage limits a percentage to the 0-100 range
+func clampPercentage(percentage float64) float64 {
+	return math.Max(0, math.Min(100, percentage))
+}
+
+// roundCurrency rounds an amount to paise
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"treesindia/models"
+)
+
+func TestIsInquiryFeeDoesNotDependOnDescription(t *testing.T) {
+	acceptedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fee := &models.Payment{Description: "Booking fee for your enquiry"}
+	fee.CreatedAt = acceptedAt.Add(-48 * time.Hour)
+	quotePayment := &models.Payment{Description: "Inquiry booking fee"}
+	quotePayment.CreatedAt = acceptedAt.Add(time.Hour)
+
+	inquiry := &models.Booking{BookingType: models.BookingTypeInquiry}
+	if !isInquiryFee(inquiry, fee) {
+		t.Error("payment of an inquiry booking without an accepted quote is not its inquiry fee")
+	}
+
+	inquiry.QuoteAcceptedAt = &acceptedAt
+	if !isInquiryFee(inquiry, fee) {
+		t.Error("payment made before the quote was accepted is not the inquiry fee")
+	}
+	if isInquiryFee(inquiry, quotePayment) {
+		t.Error("payment made after the quote was accepted is taken for the inquiry fee")
+	}
+
+	regular := &models.Booking{BookingType: models.BookingTypeRegular}
+	if isInquiryFee(regular, quotePayment) {
+		t.Error("payment of a regular booking is taken for an inquiry fee")
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCashfreeBaseURL is the Cashfree Payment Gateway API base URL; the sandbox is https://sandbox.cashfree.com/pg
+	defaultCashfreeBaseURL = "https://api.cashfree.com/pg"
+	// cashfreeAPIVersion is the Cashfree API version requests are made against
+	cashfreeAPIVersion = "2023-08-01"
+)
+
+// CashfreeGateway is the Cashfree payment gateway. Cashfree checkouts return no signature, so payments
+// are verified by fetching them from Cashfree.
+type CashfreeGateway struct {
+	appID     string
+	secretKey string
+	baseURL   string
+}
+
+// NewCashfreeGateway creates the Cashfree gateway from the CASHFREE_* environment variables
+func NewCashfreeGateway() *CashfreeGateway {
+	baseURL := os.Getenv("CASHFREE_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultCashfreeBaseURL
+	}
+	return NewCashfreeGatewayWithConfig(os.Getenv("CASHFREE_APP_ID"), os.Getenv("CASHFREE_SECRET_KEY"), baseURL)
+}
+
+// NewCashfreeGatewayWithConfig creates a Cashfree gateway with explicit credentials and API base URL
+func NewCashfreeGatewayWithConfig(appID, secretKey, baseURL string) *CashfreeGateway {
+	return &CashfreeGateway{
+		appID:     appID,
+		secretKey: secretKey,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Name returns the provider name
+func (g *CashfreeGateway) Name() string {
+	return PaymentGatewayCashfree
+}
+
+// CreateOrder creates a Cashfree order. The checkout data carries the payment session the Cashfree
+// checkout is opened with.
+func (g *CashfreeGateway) CreateOrder(req *GatewayOrderRequest) (*GatewayOrder, error) {
+	if req.CustomerPhone == "" {
+		return nil, errors.New("cashfree orders need the customer's phone number")
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = "INR"
+	}
+
+	var order map[string]interface{}
+	err := g.request("POST", "/orders", map[string]interface{}{
+		"order_amount":   roundCurrency(req.Amount),
+		"order_currency": currency,
+		"order_note":     req.Description,
+		"order_tags":     map[string]string{"receipt": req.Receipt},
+		"customer_details": map[string]string{
+			"customer_id":    req.CustomerID,
+			"customer_name":  req.CustomerName,
+			"customer_phone": req.CustomerPhone,
+			"customer_email": req.CustomerEmail,
+		},
+	}, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID := stringField(order, "order_id")
+	if orderID == "" {
+		return nil, errors.New("invalid order response: missing order id")
+	}
+	return &GatewayOrder{
+		Provider: PaymentGatewayCashfree,
+		OrderID:  orderID,
+		Checkout: map[string]interface{}{
+			"id":                 orderID,
+			"amount":             float64(int64(req.Amount*100 + 0.5)), // In paise, like Razorpay orders
+			"currency":           currency,
+			"receipt":            req.Receipt,
+			"payment_session_id": stringField(order, "payment_session_id"),
+			"provider":           PaymentGatewayCashfree,
+		},
+	}, nil
+}
+
+// VerifyPayment checks with Cashfree that the payment was made against the order and succeeded.
+// The signature is ignored.
+func (g *CashfreeGateway) VerifyPayment(orderID, paymentID, signature string) (bool, error) {
+	payments, err := g.getOrderPayments(orderID)
+	if err != nil {
+		return false, err
+	}
+	for _, payment := range payments {
+		if cashfreeID(payment["cf_payment_id"]) == paymentID {
+			return stringField(payment, "payment_status") == "SUCCESS", nil
+		}
+	}
+	return false, nil
+}
+
+// CapturePayment captures a pre-authorized Cashfree payment
+func (g *CashfreeGateway) CapturePayment(orderID, paymentID string, amount float64) error {
+	return g.request("POST", fmt.Sprintf("/orders/%s/authorization", orderID), map[string]interface{}{
+		"action": "CAPTURE",
+		"amount": roundCurrency(amount),
+	}, nil)
+}
+
+// Refund issues a Cashfree refund against the order. Cashfree refunds are identified by our reference,
+// which is also sent as the idempotency key.
+func (g *CashfreeGateway) Refund(req *GatewayRefundRequest) (*GatewayRefund, error) {
+	var refund map[string]interface{}
+	err := g.requestWithHeaders("POST", fmt.Sprintf("/orders/%s/refunds", req.OrderID), map[string]string{
+		"x-idempotency-key": req.Reference,
+	}, map[string]interface{}{
+		"refund_amount": roundCurrency(req.Amount),
+		"refund_id":     req.Reference,
+		"refund_note":   req.Notes["reason"],
+	}, &refund)
+	if err != nil {
+		return nil, err
+	}
+	return &GatewayRefund{ID: req.Reference, Status: cashfreeRefundStatus(stringField(refund, "refund_status"))}, nil
+}
+
+// VerifyWebhook checks the x-webhook-signature of a webhook: the base64 HMAC-SHA256 of the
+// x-webhook-timestamp followed by the body, keyed with the secret key. Cashfree sends no event ID.
+func (g *CashfreeGateway) VerifyWebhook(body []byte, headers http.Header) (string, error) {
+	signature := headers.Get("x-webhook-signature")
+	if g.secretKey == "" || signature == "" {
+		return "", ErrInvalidWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.secretKey))
+	mac.Write([]byte(headers.Get("x-webhook-timestamp")))
+	mac.Write(body)
+	if !hmac.Equal([]byte(base64.StdEncoding.EncodeToString(mac.Sum(nil))), []byte(signature)) {
+		return "", ErrInvalidWebhookSignature
+	}
+	return "", nil
+}
+
+// ParseWebhook reads a Cashfree webhook body
+func (g *CashfreeGateway) ParseWebhook(body []byte) (*GatewayWebhookEvent, error) {
+	var webhookData map[string]interface{}
+	if err := json.Unmarshal(body, &webhookData); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	event := &GatewayWebhookEvent{
+		EventType: stringField(webhookData, "type"),
+		Payload:   webhookData,
+	}
+	if event.EventType == "" {
+		return nil, errors.New("webhook payload has no event type")
+	}
+
+	data, _ := webhookData["data"].(map[string]interface{})
+	if order, ok := data["order"].(map[string]interface{}); ok {
+		event.OrderID = stringField(order, "order_id")
+	}
+	if payment, ok := data["payment"].(map[string]interface{}); ok {
+		event.PaymentID = cashfreeID(payment["cf_payment_id"])
+		event.Amount, event.HasAmount = payment["payment_amount"].(float64)
+		event.FailureReason = stringField(payment, "payment_message")
+	}
+	if refund, ok := data["refund"].(map[string]interface{}); ok {
+		event.RefundID = stringField(refund, "refund_id")
+		event.OrderID = stringField(refund, "order_id")
+		event.PaymentID = cashfreeID(refund["cf_payment_id"])
+		event.Amount, event.HasAmount = refund["refund_amount"].(float64)
+		event.FailureReason = stringField(refund, "status_description")
+
+		switch cashfreeRefundStatus(stringField(refund, "refund_status")) {
+		case GatewayRefundProcessed:
+			event.Type = GatewayEventRefundProcessed
+		case GatewayRefundFailed:
+			event.Type = GatewayEventRefundFailed
+		}
+		return event, nil
+	}
+
+	switch event.EventType {
+	case "PAYMENT_SUCCESS_WEBHOOK":
+		event.Type = GatewayEventPaymentCaptured
+	case "PAYMENT_FAILED_WEBHOOK", "PAYMENT_USER_DROPPED_WEBHOOK":
+		event.Type = GatewayEventPaymentFailed
+	}
+	return event, nil
+}
+
+// GetOrderStatus gets a Cashfree order and its payments
+func (g *CashfreeGateway) GetOrderStatus(orderID string) (*GatewayOrderStatus, error) {
+	var order map[string]interface{}
+	if err := g.request("GET", fmt.Sprintf("/orders/%s", orderID), nil, &order); err != nil {
+		return nil, fmt.Errorf("failed to get Cashfree order %s: %v", orderID, err)
+	}
+	payments, err := g.getOrderPayments(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments of Cashfree order %s: %v", orderID, err)
+	}
+
+	status := &GatewayOrderStatus{OrderID: orderID}
+	for _, attempt := range payments {
+		payment := GatewayPayment{
+			ID:            cashfreeID(attempt["cf_payment_id"]),
+			FailureReason: stringField(attempt, "payment_message"),
+		}
+		payment.Amount, _ = attempt["payment_amount"].(float64)
+		switch stringField(attempt, "payment_status") {
+		case "SUCCESS":
+			payment.Status = GatewayPaymentCaptured
+		case "FAILED", "USER_DROPPED", "CANCELLED", "VOID":
+			payment.Status = GatewayPaymentFailed
+		default:
+			payment.Status = GatewayPaymentPending
+		}
+		status.Payments = append(status.Payments, payment)
+	}
+
+	switch {
+	case stringField(order, "order_status") == "PAID":
+		status.Status = GatewayOrderPaid
+	case len(status.Payments) == 0:
+		status.Status = GatewayOrderCreated
+	default:
+		status.Status = GatewayOrderAttempted
+	}
+	return status, nil
+}
+
+// getOrderPayments gets every payment attempt made against a Cashfree order
+func (g *CashfreeGateway) getOrderPayments(orderID string) ([]map[string]interface{}, error) {
+	var payments []map[string]interface{}
+	if err := g.request("GET", fmt.Sprintf("/orders/%s/payments", orderID), nil, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// request makes an authenticated request to the Cashfree API, sending the payload as JSON when there
+// is one, and parses the JSON response into result when it is not nil
+func (g *CashfreeGateway) request(method, path string, payload interface{}, result interface{}) error {
+	return g.requestWithHeaders(method, path, nil, payload, result)
+}
+
+// requestWithHeaders makes a request like request, with extra headers
+func (g *CashfreeGateway) requestWithHeaders(method, path string, headers map[string]string, payload interface{}, result interface{}) error {
+	if g.appID == "" || g.secretKey == "" {
+		return errors.New("cashfree is not configured - missing API keys")
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonPayload)
+	}
+
+	req, err := http.NewRequest(method, g.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-version", cashfreeAPIVersion)
+	req.Header.Set("x-client-id", g.appID)
+	req.Header.Set("x-client-secret", g.secretKey)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("cashfree API error: %s", string(body))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// cashfreeRefundStatus maps a Cashfree refund status to a gateway refund status
+func cashfreeRefundStatus(status string) string {
+	switch status {
+	case "SUCCESS":
+		return GatewayRefundProcessed
+	case "CANCELLED":
+		return GatewayRefundFailed
+	}
+	return GatewayRefundPending
+}
+
+// cashfreeID reads a Cashfree ID, which the API returns as a number or a string
+func cashfreeID(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return ""
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cashfreeWebhookFixtures = "../testdata/cashfree_webhooks"
+
+// loadCashfreeWebhook reads a Cashfree webhook body, shaped like the payloads Cashfree documents
+func loadCashfreeWebhook(t *testing.T, fixture string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join(cashfreeWebhookFixtures, fixture))
+	if err != nil {
+		t.Fatalf("failed to read webhook fixture: %v", err)
+	}
+	return body
+}
+
+// signCashfreeWebhook signs a webhook the way Cashfree does: the base64 HMAC-SHA256 of the timestamp
+// followed by the body, keyed with the secret key
+func signCashfreeWebhook(secretKey, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestCashfreeGatewayCreateOrderReturnsPaymentSession(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+
+	order, err := cashfree.gateway().CreateOrder(&GatewayOrderRequest{
+		Amount:        249.99,
+		Receipt:       "PAY_TEST",
+		Description:   "Wallet recharge",
+		CustomerID:    "42",
+		CustomerName:  "Test Customer",
+		CustomerPhone: "9999999999",
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if order.Provider != PaymentGatewayCashfree || order.OrderID != "order_TEST000000001" {
+		t.Errorf("CreateOrder() = %+v, want Cashfree order order_TEST000000001", order)
+	}
+	if order.Checkout["payment_session_id"] != "session_order_TEST000000001" || order.Checkout["amount"] != float64(24999) || order.Checkout["currency"] != "INR" {
+		t.Errorf("checkout = %v, want the payment session and 24999 paise in INR", order.Checkout)
+	}
+
+	requests := cashfree.createdOrders()
+	if len(requests) != 1 {
+		t.Fatalf("created %d orders, want 1", len(requests))
+	}
+	tags, _ := requests[0]["order_tags"].(map[string]interface{})
+	if requests[0]["order_amount"] != 249.99 || tags["receipt"] != "PAY_TEST" {
+		t.Errorf("order request = %v, want ₹249.99 tagged with the receipt", requests[0])
+	}
+}
+
+func TestCashfreeGatewayCreateOrderNeedsCustomerPhone(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+
+	if _, err := cashfree.gateway().CreateOrder(&GatewayOrderRequest{Amount: 100, CustomerID: "42"}); err == nil {
+		t.Fatal("CreateOrder() without a phone number succeeded, want an error")
+	}
+	if requests := cashfree.createdOrders(); len(requests) != 0 {
+		t.Errorf("sent %d order requests, want none", len(requests))
+	}
+}
+
+func TestCashfreeGatewayVerifyPaymentChecksPaymentStatus(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+	cashfree.addOrder("order_paid", "PAID", 500,
+		cashfreePayment(5114910000001, "FAILED", 500, "Transaction declined by the bank"),
+		cashfreePayment(5114910000002, "SUCCESS", 500, "00::Transaction success"))
+	gateway := cashfree.gateway()
+
+	cases := []struct {
+		paymentID string
+		want      bool
+	}{
+		{"5114910000002", true},
+		{"5114910000001", false},
+		{"5114910000003", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.paymentID, func(t *testing.T) {
+			verified, err := gateway.VerifyPayment("order_paid", tc.paymentID, "ignored")
+			if err != nil {
+				t.Fatalf("VerifyPayment() error = %v", err)
+			}
+			if verified != tc.want {
+				t.Errorf("VerifyPayment() = %v, want %v", verified, tc.want)
+			}
+		})
+	}
+
+	if _, err := gateway.VerifyPayment("order_unknown", "5114910000002", ""); err == nil {
+		t.Error("VerifyPayment() of an unknown order succeeded, want an error")
+	}
+}
+
+func TestCashfreeGatewayGetOrderStatus(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+	cashfree.addOrder("order_paid", "PAID", 500,
+		cashfreePayment(5114910000001, "USER_DROPPED", 500, "User dropped and did not complete the two factor authentication"),
+		cashfreePayment(5114910000002, "SUCCESS", 500, "00::Transaction success"))
+	cashfree.addOrder("order_attempted", "ACTIVE", 500,
+		cashfreePayment(5114910000003, "PENDING", 500, ""))
+	cashfree.addOrder("order_created", "ACTIVE", 500)
+	gateway := cashfree.gateway()
+
+	status, err := gateway.GetOrderStatus("order_paid")
+	if err != nil {
+		t.Fatalf("GetOrderStatus() error = %v", err)
+	}
+	if status.Status != GatewayOrderPaid || len(status.Payments) != 2 {
+		t.Fatalf("GetOrderStatus() = %+v, want a paid order with 2 payments", status)
+	}
+	dropped, captured := status.Payments[0], status.Payments[1]
+	if dropped.ID != "5114910000001" || dropped.Status != GatewayPaymentFailed || dropped.FailureReason == "" {
+		t.Errorf("dropped payment = %+v, want failed 5114910000001 with its message", dropped)
+	}
+	if captured.ID != "5114910000002" || captured.Status != GatewayPaymentCaptured || captured.Amount != 500 {
+		t.Errorf("successful payment = %+v, want captured 5114910000002 of ₹500", captured)
+	}
+
+	if status, err := gateway.GetOrderStatus("order_attempted"); err != nil || status.Status != GatewayOrderAttempted || status.Payments[0].Status != GatewayPaymentPending {
+		t.Errorf("GetOrderStatus() = %+v, %v, want an attempted order with a pending payment", status, err)
+	}
+	if status, err := gateway.GetOrderStatus("order_created"); err != nil || status.Status != GatewayOrderCreated || len(status.Payments) != 0 {
+		t.Errorf("GetOrderStatus() = %+v, %v, want a created order without payments", status, err)
+	}
+	if _, err := gateway.GetOrderStatus("order_unknown"); err == nil {
+		t.Error("GetOrderStatus() of an unknown order succeeded, want an error")
+	}
+}
+
+func TestCashfreeGatewayRetriedRefundIsNotIssuedTwice(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+	cashfree.addOrder("order_paid", "PAID", 800, cashfreePayment(5114910000001, "SUCCESS", 800, "00::Transaction success"))
+	gateway := cashfree.gateway()
+
+	// The first call's response was lost, so the refund is retried with the same reference
+	req := &GatewayRefundRequest{
+		OrderID:   "order_paid",
+		PaymentID: "5114910000001",
+		Amount:    300,
+		Reference: refundReference(7, 0),
+		Notes:     map[string]string{"reason": "Booking cancelled"},
+	}
+	first, err := gateway.Refund(req)
+	if err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if first.ID != "RFD7_1" || first.Status != GatewayRefundPending {
+		t.Errorf("Refund() = %+v, want pending refund RFD7_1", first)
+	}
+	retried, err := gateway.Refund(req)
+	if err != nil {
+		t.Fatalf("retried Refund() error = %v", err)
+	}
+	if retried.ID != first.ID {
+		t.Errorf("retried refund = %s, want the first refund %s", retried.ID, first.ID)
+	}
+
+	// The next refund of the payment gets a reference of its own
+	if _, err := gateway.Refund(&GatewayRefundRequest{OrderID: "order_paid", Amount: 300, Reference: refundReference(7, 1)}); err != nil {
+		t.Fatalf("second Refund() error = %v", err)
+	}
+	issued := cashfree.issuedRefunds()
+	if len(issued) != 2 || issued[0].IdempotencyKey != "RFD7_1" || issued[1].IdempotencyKey != "RFD7_2" {
+		t.Fatalf("issued refunds = %+v, want RFD7_1 and RFD7_2 issued once each", issued)
+	}
+	if issued[0].RefundID != "RFD7_1" || issued[0].Amount != 300 || issued[0].Note != "Booking cancelled" {
+		t.Errorf("refund request = %+v, want ₹300 as RFD7_1 with the reason as note", issued[0])
+	}
+
+	if _, err := gateway.Refund(&GatewayRefundRequest{OrderID: "order_paid", Amount: 200.01, Reference: refundReference(7, 2)}); err == nil {
+		t.Error("Refund() past the paid amount succeeded, want an error")
+	}
+}
+
+func TestCashfreeGatewayReturnsAPIErrors(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+	cashfree.addOrder("order_paid", "PAID", 500)
+
+	unauthorized := NewCashfreeGatewayWithConfig(testCashfreeAppID, "wrong_secret", cashfree.server.URL)
+	if _, err := unauthorized.GetOrderStatus("order_paid"); err == nil || !strings.Contains(err.Error(), "authentication Failed") {
+		t.Errorf("GetOrderStatus() with wrong credentials error = %v, want the Cashfree authentication error", err)
+	}
+
+	unconfigured := NewCashfreeGatewayWithConfig("", "", cashfree.server.URL)
+	if _, err := unconfigured.GetOrderStatus("order_paid"); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("GetOrderStatus() without API keys error = %v, want a configuration error", err)
+	}
+}
+
+func TestNewCashfreeGatewayUsesBaseURLFromEnvironment(t *testing.T) {
+	cashfree := newFakeCashfree(t)
+	cashfree.addOrder("order_created", "ACTIVE", 100)
+	t.Setenv("CASHFREE_APP_ID", testCashfreeAppID)
+	t.Setenv("CASHFREE_SECRET_KEY", testCashfreeSecretKey)
+	t.Setenv("CASHFREE_BASE_URL", cashfree.server.URL+"/")
+
+	if status, err := NewCashfreeGateway().GetOrderStatus("order_created"); err != nil || status.Status != GatewayOrderCreated {
+		t.Fatalf("GetOrderStatus() = %+v, %v, want the order from the local server", status, err)
+	}
+}
+
+func TestCashfreeGatewayVerifiesAndParsesWebhookFixtures(t *testing.T) {
+	gateway := NewCashfreeGatewayWithConfig(testCashfreeAppID, testCashfreeSecretKey, "http://127.0.0.1:0")
+
+	cases := []struct {
+		fixture       string
+		wantType      string
+		refundID      string
+		amount        float64
+		failureReason string
+	}{
+		{"payment_success.json", GatewayEventPaymentCaptured, "", 500, "00::Transaction success"},
+		{"payment_failed.json", GatewayEventPaymentFailed, "", 500, "Transaction declined by the bank"},
+		{"payment_user_dropped.json", GatewayEventPaymentFailed, "", 500, "User dropped and did not complete the two factor authentication"},
+		{"refund_success.json", GatewayEventRefundProcessed, "RFD1_1", 200, "Refund processed successfully"},
+		{"refund_cancelled.json", GatewayEventRefundFailed, "RFD1_1", 200, "Refund cancelled by the bank"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			body := loadCashfreeWebhook(t, tc.fixture)
+			headers := http.Header{}
+			headers.Set("x-webhook-timestamp", "1760600000000")
+			headers.Set("x-webhook-signature", signCashfreeWebhook(testCashfreeSecretKey, "1760600000000", body))
+
+			eventID, err := gateway.VerifyWebhook(body, headers)
+			if err != nil {
+				t.Fatalf("VerifyWebhook() error = %v", err)
+			}
+			if eventID != "" {
+				t.Errorf("event ID = %q, want none since Cashfree sends no event ID", eventID)
+			}
+
+			event, err := gateway.ParseWebhook(body)
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if event.Type != tc.wantType || event.OrderID != "order_TEST000000001" || event.PaymentID != "5114910000001" ||
+				event.RefundID != tc.refundID || !event.HasAmount || event.Amount != tc.amount || event.FailureReason != tc.failureReason {
+				t.Errorf("parsed %+v, want type %s, refund %q, amount %v, reason %q for order_TEST000000001/5114910000001",
+					event, tc.wantType, tc.refundID, tc.amount, tc.failureReason)
+			}
+		})
+	}
+}
+
+func TestCashfreeGatewayRejectsBadWebhookSignatures(t *testing.T) {
+	gateway := NewCashfreeGatewayWithConfig(testCashfreeAppID, testCashfreeSecretKey, "http://127.0.0.1:0")
+	body := loadCashfreeWebhook(t, "payment_success.json")
+	const timestamp = "1760600000000"
+	signature := signCashfreeWebhook(testCashfreeSecretKey, timestamp, body)
+
+	cases := []struct {
+		name      string
+		body      []byte
+		timestamp string
+		signature string
+	}{
+		{"missing signature", body, timestamp, ""},
+		{"signed with another secret", body, timestamp, signCashfreeWebhook("another_secret", timestamp, body)},
+		{"body changed after signing", append([]byte(" "), body...), timestamp, signature},
+		{"timestamp changed after signing", body, "1760600000001", signature},
+		{"signed without the timestamp", body, timestamp, signCashfreeWebhook(testCashfreeSecretKey, "", body)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set("x-webhook-timestamp", tc.timestamp)
+			headers.Set("x-webhook-signature", tc.signature)
+			if _, err := gateway.VerifyWebhook(tc.body, headers); !errors.Is(err, ErrInvalidWebhookSignature) {
+				t.Errorf("VerifyWebhook() error = %v, want ErrInvalidWebhookSignature", err)
+			}
+		})
+	}
+
+	unconfigured := NewCashfreeGatewayWithConfig("", "", "http://127.0.0.1:0")
+	headers := http.Header{}
+	headers.Set("x-webhook-timestamp", timestamp)
+	headers.Set("x-webhook-signature", signCashfreeWebhook("", timestamp, body))
+	if _, err := unconfigured.VerifyWebhook(body, headers); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("VerifyWebhook() without a secret key error = %v, want ErrInvalidWebhookSignature", err)
+	}
+}
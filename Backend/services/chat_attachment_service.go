@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// chatAttachmentFolder is the Cloudinary folder chat attachments are uploaded to
+const chatAttachmentFolder = "chat-attachments"
+
+// ChatAttachmentService handles images, documents and location pins shared in booking chat rooms and
+// simple conversations. Attachments are uploaded first and sent by passing their IDs with a message;
+// their media is only served to the thread's participants and admins.
+type ChatAttachmentService struct {
+	attachmentRepo    *repositories.ChatAttachmentRepository
+	chatRoomRepo      *repositories.ChatRoomRepository
+	syncService       *ChatSyncService
+	cloudinaryService *CloudinaryService
+	configService     *AdminConfigService
+}
+
+// NewChatAttachmentService creates a new chat attachment service
+func NewChatAttachmentService() *ChatAttachmentService {
+	cloudinaryService, err := NewCloudinaryService()
+	if err != nil {
+		logrus.Warnf("Failed to initialize Cloudinary service: %v", err)
+		cloudinaryService = nil
+	}
+
+	return &ChatAttachmentService{
+		attachmentRepo:    repositories.NewChatAttachmentRepository(),
+		chatRoomRepo:      repositories.NewChatRoomRepository(),
+		syncService:       NewChatSyncService(),
+		cloudinaryService: cloudinaryService,
+		configService:     NewAdminConfigService(),
+	}
+}
+
+// Upload validates an image or PDF and uploads it with a thumbnail, ready to be sent in a thread
+func (s *ChatAttachmentService) Upload(userID uint, userType string, threadType models.ChatThreadType, threadID uint, file *multipart.FileHeader) (*models.ChatAttachment, error) {
+	if err := s.authorizeShare(userID, userType, threadType, threadID); err != nil {
+		return nil, err
+	}
+	if s.cloudinaryService == nil {
+		return nil, errors.New("file uploads are not configured")
+	}
+
+	// Trust the file's content over the type the client declares
+	contentType, err := utils.DetectFileType(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var kind models.MessageType
+	var maxSizeMB int
+	switch {
+	case utils.IsValidImageType(contentType):
+		kind = models.MessageTypeImage
+		maxSizeMB = s.configService.GetChatImageMaxSizeMB()
+	case utils.IsValidDocumentType(contentType):
+		kind = models.MessageTypeFile
+		maxSizeMB = s.configService.GetChatFileMaxSizeMB()
+	default:
+		return nil, errors.New("attachments must be JPEG, PNG or WebP images or PDF documents")
+	}
+	if file.Size > int64(maxSizeMB)*1024*1024 {
+		return nil, fmt.Errorf("%s attachments cannot be larger than %d MB", kind, maxSizeMB)
+	}
+
+	upload, err := s.cloudinaryService.UploadWithThumbnail(file, chatAttachmentFolder, kind == models.MessageTypeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.ChatAttachment{
+		ThreadType:   threadType,
+		ThreadID:     threadID,
+		UploaderID:   userID,
+		Kind:         kind,
+		FileName:     filepath.Base(file.Filename),
+		MimeType:     contentType,
+		SizeBytes:    file.Size,
+		Width:        upload.Width,
+		Height:       upload.Height,
+		URL:          upload.URL,
+		ThumbnailURL: upload.ThumbnailURL,
+		PublicID:     upload.PublicID,
+		ResourceType: upload.ResourceType,
+		DeliveryType: upload.DeliveryType,
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		if deleteErr := s.cloudinaryService.DeleteAuthenticatedMedia(upload.PublicID, upload.ResourceType); deleteErr != nil {
+			logrus.Errorf("Failed to delete chat attachment %s after save failed: %v", upload.PublicID, deleteErr)
+		}
+		return nil, fmt.Errorf("failed to save attachment: %v", err)
+	}
+
+	logrus.Infof("User %d uploaded chat attachment %d to %s %d", userID, attachment.ID, threadType, threadID)
+	return attachment, nil
+}
+
+// ShareLocation stores a location pin, ready to be sent in a thread
+func (s *ChatAttachmentService) ShareLocation(userID uint, userType string, threadType models.ChatThreadType, threadID uint, req *models.ShareLocationRequest) (*models.ChatAttachment, error) {
+	if err := s.authorizeShare(userID, userType, threadType, threadID); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.ChatAttachment{
+		ThreadType:   threadType,
+		ThreadID:     threadID,
+		UploaderID:   userID,
+		Kind:         models.MessageTypeLocation,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		LocationName: req.LocationName,
+		Address:      req.Address,
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		return nil, fmt.Errorf("failed to save location: %v", err)
+	}
+	return attachment, nil
+}
+
+// GetMedia returns an attachment and the URL of its media, or of its thumbnail, to a user allowed to see it.
+// Attachments that have not been sent yet are only visible to their uploader. Authenticated media gets a
+// freshly signed URL.
+func (s *ChatAttachmentService) GetMedia(userID uint, userType string, attachmentID uint, thumbnail bool) (*models.ChatAttachment, string, error) {
+	attachment, err := s.attachmentRepo.GetByID(attachmentID)
+	if err != nil || (attachment.MessageID == nil && attachment.UploaderID != userID) {
+		return nil, "", errors.New("attachment not found")
+	}
+	if _, err := s.syncService.authorize(attachment.ThreadType, attachment.ThreadID, userID, userType); err != nil {
+		return nil, "", err
+	}
+
+	mediaURL := attachment.URL
+	if thumbnail {
+		mediaURL = attachment.ThumbnailURL
+	}
+	if mediaURL == "" {
+		return nil, "", errors.New("media not found")
+	}
+	if !attachment.IsAuthenticated() {
+		return attachment, mediaURL, nil
+	}
+
+	if s.cloudinaryService == nil {
+		return nil, "", errors.New("file uploads are not configured")
+	}
+	mediaURL, err = s.cloudinaryService.AuthenticatedURL(attachment.PublicID, attachment.Kind == models.MessageTypeFile, thumbnail)
+	if err != nil {
+		return nil, "", err
+	}
+	return attachment, mediaURL, nil
+}
+
+// PrepareMessage checks the attachments a sender is about to send in a thread and returns their metadata.
+// A text message carrying attachments takes the type of what it carries.
+func (s *ChatAttachmentService) PrepareMessage(senderID uint, threadType models.ChatThreadType, threadID uint, attachmentIDs []uint, messageType models.MessageType) ([]models.ChatAttachmentInfo, models.MessageType, error) {
+	if len(attachmentIDs) == 0 {
+		return nil, messageType, nil
+	}
+
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, id := range attachmentIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	attachments, err := s.attachmentRepo.GetUnsent(ids, senderID, threadType, threadID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get attachments: %v", err)
+	}
+	if len(attachments) != len(ids) {
+		return nil, "", errors.New("attachments not found or already sent")
+	}
+
+	infos := make([]models.ChatAttachmentInfo, 0, len(attachments))
+	for i := range attachments {
+		infos = append(infos, attachments[i].Info())
+	}
+	if messageType == "" || messageType == models.MessageTypeText {
+		messageType = attachmentsMessageType(attachments)
+	}
+	return infos, messageType, nil
+}
+
+// AttachToMessage links the attachments a message carries to it once it is saved
+func (s *ChatAttachmentService) AttachToMessage(attachments []models.ChatAttachmentInfo, messageID uint) {
+	if len(attachments) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(attachments))
+	for _, attachment := range attachments {
+		ids = append(ids, attachment.ID)
+	}
+
+	linked, err := s.attachmentRepo.AssignToMessage(ids, messageID)
+	if err != nil {
+		logrus.Errorf("Failed to link attachments %v to message %d: %v", ids, messageID, err)
+		return
+	}
+	if linked != int64(len(ids)) {
+		logrus.Warnf("Linked %d of %d attachments to message %d, the rest were sent with another message", linked, len(ids), messageID)
+	}
+}
+
+// authorizeShare checks that a user takes part in a thread they share an attachment in and that a chat
+// room is still open. Admins overseeing a thread cannot share in it.
+func (s *ChatAttachmentService) authorizeShare(userID uint, userType string, threadType models.ChatThreadType, threadID uint) error {
+	participant, err := s.syncService.authorize(threadType, threadID, userID, userType)
+	if err != nil {
+		return err
+	}
+	if !participant {
+		return errors.New("only participants can share attachments")
+	}
+
+	if threadType == models.ChatThreadRoom {
+		chatRoom, err := s.chatRoomRepo.GetByID(threadID)
+		if err != nil {
+			return errors.New("chat room not found")
+		}
+		if !chatRoom.IsActive {
+			return errors.New("chat room is closed")
+		}
+	}
+	return nil
+}
+
+// attachmentsMessageType returns the type of a message carrying attachments: location when it carries a pin,
+// image when it carries only images and file otherwise
+func attachmentsMessageType(attachments []models.ChatAttachment) models.MessageType {
+	messageType := models.MessageTypeImage
+	for _, attachment := range attachments {
+		switch attachment.Kind {
+		case models.MessageTypeLocation:
+			return models.MessageTypeLocation
+		case models.MessageTypeFile:
+			messageType = models.MessageTypeFile
+		}
+	}
+	return messageType
+}
+
+// messagePreview returns the text that stands for a message in notifications, describing what a message
+// without text carries
+func messagePreview(text string, messageType models.MessageType) string {
+	if text != "" {
+		return text
+	}
+	switch messageType {
+	case models.MessageTypeImage:
+		return "Sent an image"
+	case models.MessageTypeFile:
+		return "Sent a file"
+	case models.MessageTypeLocation:
+		return "Shared a location"
+	default:
+		return text
+	}
+}
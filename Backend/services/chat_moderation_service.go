@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// chatDetectedProfanity marks a message in which blocked words were filtered
+const chatDetectedProfanity = "profanity"
+
+// ChatModerationResult is a chat message after moderation
+type ChatModerationResult struct {
+	Text     string   // Text to store and deliver
+	Original string   // Text as the sender wrote it
+	Detected []string // What moderation found: phone, email, payment_handle or profanity
+}
+
+// ChatModerationService moderates booking chat rooms and simple conversations. Phone numbers, emails and
+// payment handles are masked so chats cannot be taken off the platform, blocked words are filtered, and
+// messages moderation changed are flagged for admin review next to those participants report. Admins work
+// through the review queue and can mute participants in one chat or every chat.
+type ChatModerationService struct {
+	moderationRepo          *repositories.ChatModerationRepository
+	userRepo                *repositories.UserRepository
+	chatMessageRepo         *repositories.ChatMessageRepository
+	conversationMessageRepo *repositories.SimpleConversationMessageRepository
+	syncService             *ChatSyncService
+	configService           *AdminConfigService
+}
+
+// NewChatModerationService creates a new chat moderation service
+func NewChatModerationService() *ChatModerationService {
+	return &ChatModerationService{
+		moderationRepo:          repositories.NewChatModerationRepository(),
+		userRepo:                repositories.NewUserRepository(),
+		chatMessageRepo:         repositories.NewChatMessageRepository(),
+		conversationMessageRepo: repositories.NewSimpleConversationMessageRepository(database.GetDB()),
+		syncService:             NewChatSyncService(),
+		configService:           NewAdminConfigService(),
+	}
+}
+
+// ModerateMessage refuses a message from a muted sender, and masks contact details and filters blocked words
+// in the text of anyone but admins
+func (s *ChatModerationService) ModerateMessage(senderID uint, threadType models.ChatThreadType, threadID uint, text string) (*ChatModerationResult, error) {
+	mute, err := s.moderationRepo.GetActiveMute(senderID, threadType, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check chat mute: %v", err)
+	}
+	if mute != nil {
+		if mute.MutedUntil != nil {
+			return nil, fmt.Errorf("you are muted in this chat until %s", mute.MutedUntil.Format(time.RFC1123))
+		}
+		return nil, errors.New("you are muted in this chat")
+	}
+
+	result := &ChatModerationResult{Text: text, Original: text}
+	if text == "" || !s.configService.GetChatModerationEnabled() {
+		return result, nil
+	}
+
+	var sender models.User
+	if err := s.userRepo.FindByID(&sender, senderID); err == nil && sender.UserType == models.UserTypeAdmin {
+		return result, nil
+	}
+
+	result.Text, result.Detected = utils.MaskContactDetailsInText(result.Text)
+	filtered, found := utils.FilterBlockedWords(result.Text, utils.ParseWordList(s.configService.GetChatBlockedWords()))
+	if found {
+		result.Text = filtered
+		result.Detected = append(result.Detected, chatDetectedProfanity)
+	}
+	return result, nil
+}
+
+// FlagMessage puts a saved message that moderation changed in the admin review queue
+func (s *ChatModerationService) FlagMessage(result *ChatModerationResult, threadType models.ChatThreadType, threadID, messageID, senderID uint) {
+	if result == nil || len(result.Detected) == 0 {
+		return
+	}
+
+	report := &models.ChatMessageReport{
+		ThreadType:      threadType,
+		ThreadID:        threadID,
+		MessageID:       messageID,
+		SenderID:        senderID,
+		Source:          models.ChatReportSourceAuto,
+		Reason:          detectedReportReason(result.Detected),
+		OriginalMessage: result.Original,
+		Detected:        result.Detected,
+		Status:          models.ChatReportStatusPending,
+	}
+	if err := s.moderationRepo.CreateReport(report); err != nil {
+		logrus.Errorf("ChatModerationService.FlagMessage failed to flag %s message %d: %v", threadType, messageID, err)
+	}
+}
+
+// ReportMessage lets a participant report a message someone else sent in their chat
+func (s *ChatModerationService) ReportMessage(reporterID uint, userType string, threadType models.ChatThreadType, messageID uint, req *models.ReportChatMessageRequest) (*models.ChatMessageReport, error) {
+	var threadID, senderID uint
+	var text string
+	switch threadType {
+	case models.ChatThreadRoom:
+		message, err := s.chatMessageRepo.GetByID(messageID)
+		if err != nil {
+			return nil, errors.New("message not found")
+		}
+		threadID, senderID, text = message.RoomID, message.SenderID, message.Message
+	case models.ChatThreadConversation:
+		message, err := s.conversationMessageRepo.GetByID(messageID)
+		if err != nil {
+			return nil, errors.New("message not found")
+		}
+		threadID, senderID, text = message.ConversationID, message.SenderID, message.Message
+	default:
+		return nil, fmt.Errorf("unknown chat thread type %q", threadType)
+	}
+
+	participant, err := s.syncService.authorize(threadType, threadID, reporterID, userType)
+	if err != nil {
+		return nil, err
+	}
+	if !participant {
+		return nil, errors.New("only participants can report messages")
+	}
+	if senderID == reporterID {
+		return nil, errors.New("you cannot report your own message")
+	}
+
+	reported, err := s.moderationRepo.HasReported(threadType, messageID, reporterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check reports: %v", err)
+	}
+	if reported {
+		return nil, errors.New("you have already reported this message")
+	}
+
+	report := &models.ChatMessageReport{
+		ThreadType:      threadType,
+		ThreadID:        threadID,
+		MessageID:       messageID,
+		SenderID:        senderID,
+		ReporterID:      &reporterID,
+		Source:          models.ChatReportSourceUser,
+		Reason:          req.Reason,
+		Details:         req.Details,
+		OriginalMessage: text,
+		Detected:        []string{},
+		Status:          models.ChatReportStatusPending,
+	}
+	if err := s.moderationRepo.CreateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to report message: %v", err)
+	}
+
+	logrus.Infof("User %d reported %s message %d for %s", reporterID, threadType, messageID, req.Reason)
+	return report, nil
+}
+
+// GetReports returns the review queue of flagged messages
+func (s *ChatModerationService) GetReports(filters *repositories.ChatReportFilters) ([]models.ChatMessageReport, *repositories.Pagination, error) {
+	return s.moderationRepo.GetReports(filters)
+}
+
+// GetReport returns a flagged message
+func (s *ChatModerationService) GetReport(reportID uint) (*models.ChatMessageReport, error) {
+	report, err := s.moderationRepo.GetReportByID(reportID)
+	if err != nil {
+		return nil, errors.New("report not found")
+	}
+	return report, nil
+}
+
+// ReviewReport dismisses a flagged message or marks it actioned, muting its sender when asked to
+func (s *ChatModerationService) ReviewReport(adminID, reportID uint, req *models.ReviewChatReportRequest) (*models.ChatMessageReport, error) {
+	report, err := s.moderationRepo.GetReportByID(reportID)
+	if err != nil {
+		return nil, errors.New("report not found")
+	}
+	if report.Status != models.ChatReportStatusPending {
+		return nil, errors.New("report has already been reviewed")
+	}
+	if req.MuteSender && req.Status != models.ChatReportStatusActioned {
+		return nil, errors.New("only actioned reports can mute the sender")
+	}
+
+	if req.MuteSender {
+		muteReq := &models.MuteChatUserRequest{
+			UserID: report.SenderID,
+			Hours:  req.MuteHours,
+			Reason: fmt.Sprintf("Message reported for %s", report.Reason),
+		}
+		if req.Notes != "" {
+			muteReq.Reason = req.Notes
+		}
+		if !req.MuteEverywhere {
+			muteReq.ThreadType = report.ThreadType
+			muteReq.ThreadID = report.ThreadID
+		}
+		if _, err := s.mute(adminID, muteReq, &report.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	report.Status = req.Status
+	report.ReviewNotes = req.Notes
+	report.ReviewedBy = &adminID
+	report.ReviewedAt = &now
+	if err := s.moderationRepo.UpdateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to review report: %v", err)
+	}
+
+	logrus.Infof("Admin %d marked chat report %d %s", adminID, reportID, req.Status)
+	return report, nil
+}
+
+// MuteUser stops a participant from sending messages in one chat, or in every chat
+func (s *ChatModerationService) MuteUser(adminID uint, req *models.MuteChatUserRequest) (*models.ChatMute, error) {
+	return s.mute(adminID, req, nil)
+}
+
+// LiftMute lets a muted participant send messages again
+func (s *ChatModerationService) LiftMute(adminID, muteID uint) (*models.ChatMute, error) {
+	mute, err := s.moderationRepo.GetMuteByID(muteID)
+	if err != nil {
+		return nil, errors.New("mute not found")
+	}
+	if mute.LiftedAt != nil || (mute.MutedUntil != nil && mute.MutedUntil.Before(time.Now())) {
+		return nil, errors.New("mute is no longer in force")
+	}
+
+	now := time.Now()
+	mute.LiftedBy = &adminID
+	mute.LiftedAt = &now
+	if err := s.moderationRepo.UpdateMute(mute); err != nil {
+		return nil, fmt.Errorf("failed to lift mute: %v", err)
+	}
+
+	SendPushNotification(mute.UserID, models.NotificationTypeChat, "Chat restored", "You can send chat messages again", map[string]interface{}{
+		"type":    "chat_unmuted",
+		"mute_id": mute.ID,
+	})
+
+	logrus.Infof("Admin %d lifted chat mute %d of user %d", adminID, muteID, mute.UserID)
+	return mute, nil
+}
+
+// GetActiveMutes returns the mutes in force, optionally of one user
+func (s *ChatModerationService) GetActiveMutes(userID *uint, page, limit int) ([]models.ChatMute, *repositories.Pagination, error) {
+	return s.moderationRepo.GetActiveMutes(userID, page, limit)
+}
+
+// mute creates a mute and tells the muted user about it
+func (s *ChatModerationService) mute(adminID uint, req *models.MuteChatUserRequest, reportID *uint) (*models.ChatMute, error) {
+	var user models.User
+	if err := s.userRepo.FindByID(&user, req.UserID); err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.UserType == models.UserTypeAdmin {
+		return nil, errors.New("admins cannot be muted")
+	}
+
+	mute := &models.ChatMute{
+		UserID:   req.UserID,
+		ReportID: reportID,
+		Reason:   req.Reason,
+		MutedBy:  adminID,
+	}
+	if req.ThreadType != "" {
+		if _, err := s.syncService.participants(req.ThreadType, req.ThreadID); err != nil {
+			return nil, err
+		}
+		threadType, threadID := req.ThreadType, req.ThreadID
+		mute.ThreadType = &threadType
+		mute.ThreadID = &threadID
+	}
+	if req.Hours > 0 {
+		until := time.Now().Add(time.Duration(req.Hours) * time.Hour)
+		mute.MutedUntil = &until
+	}
+	if err := s.moderationRepo.CreateMute(mute); err != nil {
+		return nil, fmt.Errorf("failed to mute user: %v", err)
+	}
+
+	body := "An admin has stopped you from sending chat messages"
+	if mute.MutedUntil != nil {
+		body = fmt.Sprintf("%s until %s", body, mute.MutedUntil.Format("02 Jan 2006 15:04"))
+	}
+	data := map[string]interface{}{
+		"type":    "chat_muted",
+		"mute_id": mute.ID,
+	}
+	if mute.ThreadType != nil {
+		data["thread_type"] = *mute.ThreadType
+		data["thread_id"] = *mute.ThreadID
+	}
+	SendPushNotification(mute.UserID, models.NotificationTypeChat, "You have been muted", body, data)
+
+	logrus.Infof("Admin %d muted user %d in chat (thread type %v, report %v)", adminID, req.UserID, req.ThreadType, reportID)
+	return mute, nil
+}
+
+// detectedReportReason returns the reason an automatically flagged message is queued under
+func detectedReportReason(detected []string) models.ChatReportReason {
+	reason := models.ChatReportReasonAbuse
+	for _, kind := range detected {
+		switch kind {
+		case utils.ContactDetailPaymentHandle:
+			return models.ChatReportReasonOffPlatformPayment
+		case utils.ContactDetailPhone, utils.ContactDetailEmail:
+			reason = models.ChatReportReasonContactSharing
+		}
+	}
+	return reason
+}
@@ -18,6 +18,9 @@ type ChatService struct {
 	bookingRepo            *repositories.BookingRepository
 	workerAssignmentRepo   *repositories.WorkerAssignmentRepository
 	wsService              *WebSocketService
+	syncService            *ChatSyncService
+	attachmentService      *ChatAttachmentService
+	moderationService      *ChatModerationService
 }
 
 // NewChatService creates a new chat service
@@ -29,6 +32,9 @@ func NewChatService(wsService *WebSocketService) *ChatService {
 		bookingRepo:          repositories.NewBookingRepository(),
 		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
 		wsService:            wsService,
+		syncService:          NewChatSyncService(),
+		attachmentService:    NewChatAttachmentService(),
+		moderationService:    NewChatModerationService(),
 	}
 }
 
@@ -105,13 +111,29 @@ func (cs *ChatService) SendMessage(senderID uint, req *models.SendMessageRequest
 		return nil, err
 	}
 
+	// Refuse muted senders and mask contact details and blocked words
+	moderation, err := cs.moderationService.ModerateMessage(senderID, models.ChatThreadRoom, req.RoomID, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the uploaded attachments sent with the message
+	attachmentDetails, messageType, err := cs.attachmentService.PrepareMessage(senderID, models.ChatThreadRoom, req.RoomID, req.AttachmentIDs, req.MessageType)
+	if err != nil {
+		return nil, err
+	}
+	if req.Message == "" && len(attachmentDetails) == 0 {
+		return nil, errors.New("message or attachments are required")
+	}
+
 	// Create message
 	message := &models.ChatMessage{
 		RoomID:          req.RoomID,
 		SenderID:        senderID,
-		Message:         req.Message,
-		MessageType:     req.MessageType,
+		Message:         moderation.Text,
+		MessageType:     messageType,
 		Attachments:     req.Attachments,
+		AttachmentDetails: attachmentDetails,
 		ReplyToMessageID: req.ReplyToMessageID,
 		Status:          models.MessageStatusSent,
 	}
@@ -122,6 +144,8 @@ func (cs *ChatService) SendMessage(senderID uint, req *models.SendMessageRequest
 		logrus.Errorf("ChatService.SendMessage failed to create message: %v", err)
 		return nil, err
 	}
+	cs.attachmentService.AttachToMessage(attachmentDetails, message.ID)
+	cs.moderationService.FlagMessage(moderation, models.ChatThreadRoom, req.RoomID, message.ID, senderID)
 
 	// Update room's last message timestamp
 	if err := cs.chatRoomRepo.UpdateLastMessageAt(req.RoomID, time.Now()); err != nil {
@@ -138,6 +162,7 @@ func (cs *ChatService) SendMessage(senderID uint, req *models.SendMessageRequest
 				"sender_id":    message.SenderID,
 				"message":      message.Message,
 				"message_type": message.MessageType,
+				"attachment_details": message.AttachmentDetails,
 				"status":       message.Status,
 				"created_at":   message.CreatedAt,
 				"sender": map[string]interface{}{
@@ -150,6 +175,9 @@ func (cs *ChatService) SendMessage(senderID uint, req *models.SendMessageRequest
 		}()
 	}
 
+	// Push the message to recipients who do not receive it live
+	go cs.syncService.MessageSent(models.ChatThreadRoom, req.RoomID, message.ID, senderID, user.Name, messagePreview(message.Message, message.MessageType))
+
 	logrus.Infof("ChatService.SendMessage successfully sent message ID: %d", message.ID)
 	return message, nil
 }
@@ -339,6 +367,11 @@ func (cs *ChatService) ValidateChatAccess(roomID uint, userID uint, userType str
 	return errors.New("access denied")
 }
 
+// AuthorizeRealtimeTopic lets users who can access a chat room follow its realtime topic
+func (cs *ChatService) AuthorizeRealtimeTopic(conn *RealtimeConnection, roomID uint) error {
+	return cs.ValidateChatAccess(roomID, conn.UserID, conn.UserType)
+}
+
 // GetUserChatHistory gets both active and closed chat rooms for a user
 func (cs *ChatService) GetUserChatHistory(userID uint, userType string, page, limit int) ([]models.ChatRoom, *repositories.Pagination, error) {
 	// Admin can see all chat rooms
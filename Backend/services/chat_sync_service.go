@@ -0,0 +1,404 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"treesindia/database"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultChatSyncLimit is the number of messages a sync returns when the client does not ask for a limit
+	defaultChatSyncLimit = 100
+	// maxChatSyncLimit is the most messages one sync returns
+	maxChatSyncLimit = 500
+	// chatPushPreviewLength is the length of the message preview in a chat push notification
+	chatPushPreviewLength = 100
+)
+
+// ChatSyncService keeps chat clients in sync across reconnects for booking chat rooms and simple
+// conversations alike. Each device has a cursor per thread so a reconnecting client gets everything
+// after the last message it saw, participants acknowledge messages as delivered and read, typing
+// indicators go out on the thread's realtime topic, and recipients who do not receive a message in
+// time get a push notification for it.
+type ChatSyncService struct {
+	syncRepo             *repositories.ChatSyncRepository
+	chatRoomRepo         *repositories.ChatRoomRepository
+	bookingRepo          *repositories.BookingRepository
+	workerAssignmentRepo *repositories.WorkerAssignmentRepository
+	conversationRepo     *repositories.SimpleConversationRepository
+	configService        *AdminConfigService
+}
+
+// NewChatSyncService creates a new chat sync service
+func NewChatSyncService() *ChatSyncService {
+	return &ChatSyncService{
+		syncRepo:             repositories.NewChatSyncRepository(),
+		chatRoomRepo:         repositories.NewChatRoomRepository(),
+		bookingRepo:          repositories.NewBookingRepository(),
+		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
+		conversationRepo:     repositories.NewSimpleConversationRepository(database.GetDB()),
+		configService:        NewAdminConfigService(),
+	}
+}
+
+// SyncMessages returns the messages of a thread after a message. Without since_message_id the device's
+// cursor is used. The returned messages from others are marked delivered and the cursor moves past them.
+func (s *ChatSyncService) SyncMessages(userID uint, userType string, threadType models.ChatThreadType, threadID uint, req *models.ChatSyncRequest) (*models.ChatSyncResponse, error) {
+	participant, err := s.authorize(threadType, threadID, userID, userType)
+	if err != nil {
+		return nil, err
+	}
+
+	since := req.SinceMessageID
+	if since == 0 && req.DeviceID != "" {
+		cursor, err := s.syncRepo.GetCursor(userID, req.DeviceID, threadType, threadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sync cursor: %v", err)
+		}
+		if cursor != nil {
+			since = cursor.LastMessageID
+		}
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultChatSyncLimit
+	}
+	if limit > maxChatSyncLimit {
+		limit = maxChatSyncLimit
+	}
+
+	// Read one message more than asked for to know whether there are more
+	messages, err := s.messagesSince(threadType, threadID, since, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %v", err)
+	}
+	response := &models.ChatSyncResponse{
+		ThreadType:     threadType,
+		ThreadID:       threadID,
+		SinceMessageID: since,
+		Cursor:         since,
+		Messages:       messages,
+	}
+	if len(messages) > limit {
+		response.Messages = messages[:limit]
+		response.HasMore = true
+	}
+	if len(response.Messages) == 0 {
+		return response, nil
+	}
+	response.Cursor = response.Messages[len(response.Messages)-1].ID
+
+	if err := s.attachReceipts(userID, response); err != nil {
+		logrus.Errorf("ChatSyncService.SyncMessages failed to load receipts of %s %d: %v", threadType, threadID, err)
+	}
+	if req.DeviceID != "" {
+		if err := s.syncRepo.AdvanceCursor(userID, req.DeviceID, threadType, threadID, response.Cursor); err != nil {
+			logrus.Errorf("ChatSyncService.SyncMessages failed to advance cursor of device %s: %v", req.DeviceID, err)
+		}
+	}
+	// Admins reading a thread they do not take part in leave no receipts
+	if participant {
+		if err := s.markReceipts(userID, threadType, threadID, response.Cursor, models.ReceiptStatusDelivered); err != nil {
+			logrus.Errorf("ChatSyncService.SyncMessages failed to mark messages delivered: %v", err)
+		}
+	}
+	return response, nil
+}
+
+// UpdateReceipts acknowledges the messages of a thread up to one of them as delivered or read
+func (s *ChatSyncService) UpdateReceipts(userID uint, userType string, threadType models.ChatThreadType, threadID uint, req *models.UpdateChatReceiptsRequest) error {
+	participant, err := s.authorize(threadType, threadID, userID, userType)
+	if err != nil {
+		return err
+	}
+	if !participant {
+		return errors.New("only participants can acknowledge messages")
+	}
+	if req.Status != models.ReceiptStatusDelivered && req.Status != models.ReceiptStatusRead {
+		return errors.New("status must be delivered or read")
+	}
+	return s.markReceipts(userID, threadType, threadID, req.UpToMessageID, req.Status)
+}
+
+// GetReceipts returns the receipts of messages in a thread
+func (s *ChatSyncService) GetReceipts(userID uint, userType string, threadType models.ChatThreadType, threadID uint, messageIDs []uint) ([]models.ChatMessageReceipt, error) {
+	if _, err := s.authorize(threadType, threadID, userID, userType); err != nil {
+		return nil, err
+	}
+	return s.syncRepo.GetReceipts(threadType, threadID, messageIDs)
+}
+
+// SetTyping tells the thread's other participants whether a user is typing
+func (s *ChatSyncService) SetTyping(userID uint, userType string, threadType models.ChatThreadType, threadID uint, typing bool) error {
+	if _, err := s.authorize(threadType, threadID, userID, userType); err != nil {
+		return err
+	}
+	GetRealtimeGateway().Publish(RealtimeTopic(string(threadType), threadID), "typing", map[string]interface{}{
+		"user_id": userID,
+		"typing":  typing,
+	})
+	return nil
+}
+
+// MessageSent sends a push notification for a new message to every recipient who has not received it
+// over a live connection once the push fallback delay has passed
+func (s *ChatSyncService) MessageSent(threadType models.ChatThreadType, threadID, messageID, senderID uint, senderName, text string) {
+	participants, err := s.participants(threadType, threadID)
+	if err != nil {
+		logrus.Errorf("ChatSyncService.MessageSent failed to get participants of %s %d: %v", threadType, threadID, err)
+		return
+	}
+	var recipients []uint
+	for _, participantID := range participants {
+		if participantID != senderID {
+			recipients = append(recipients, participantID)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	preview := []rune(text)
+	if len(preview) > chatPushPreviewLength {
+		preview = append(preview[:chatPushPreviewLength], '…')
+	}
+	data := map[string]interface{}{
+		"type":        "chat_message",
+		"thread_type": threadType,
+		"thread_id":   threadID,
+		"message_id":  messageID,
+		"sender_id":   senderID,
+	}
+
+	delay := time.Duration(s.configService.GetChatPushFallbackDelaySeconds()) * time.Second
+	time.AfterFunc(delay, func() {
+		for _, recipientID := range recipients {
+			delivered, err := s.syncRepo.IsDelivered(threadType, messageID, recipientID)
+			if err != nil {
+				logrus.Errorf("ChatSyncService.MessageSent failed to check delivery of message %d to user %d: %v", messageID, recipientID, err)
+				continue
+			}
+			if delivered {
+				continue
+			}
+			SendPushNotification(recipientID, models.NotificationTypeChat, senderName, string(preview), data)
+		}
+	})
+}
+
+// HandleRoomRealtimeMessage handles sync, receipt and typing messages clients send on chat room topics
+func (s *ChatSyncService) HandleRoomRealtimeMessage(conn *RealtimeConnection, roomID uint, envelope *RealtimeEnvelope) (map[string]interface{}, error) {
+	return s.handleRealtimeMessage(conn, models.ChatThreadRoom, roomID, envelope)
+}
+
+// HandleConversationRealtimeMessage handles sync, receipt and typing messages clients send on conversation topics
+func (s *ChatSyncService) HandleConversationRealtimeMessage(conn *RealtimeConnection, conversationID uint, envelope *RealtimeEnvelope) (map[string]interface{}, error) {
+	return s.handleRealtimeMessage(conn, models.ChatThreadConversation, conversationID, envelope)
+}
+
+// handleRealtimeMessage handles a message on a thread topic. Sync uses the connection's device cursor.
+func (s *ChatSyncService) handleRealtimeMessage(conn *RealtimeConnection, threadType models.ChatThreadType, threadID uint, envelope *RealtimeEnvelope) (map[string]interface{}, error) {
+	messageID := func(key string) uint {
+		value, _ := envelope.Data[key].(float64)
+		if value < 0 {
+			return 0
+		}
+		return uint(value)
+	}
+
+	switch envelope.Event {
+	case "sync":
+		limit, _ := envelope.Data["limit"].(float64)
+		response, err := s.SyncMessages(conn.UserID, conn.UserType, threadType, threadID, &models.ChatSyncRequest{
+			SinceMessageID: messageID("since_message_id"),
+			DeviceID:       conn.DeviceID,
+			Limit:          int(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"since_message_id": response.SinceMessageID,
+			"cursor":           response.Cursor,
+			"has_more":         response.HasMore,
+			"messages":         response.Messages,
+		}, nil
+
+	case "delivered", "read":
+		upTo := messageID("up_to_message_id")
+		if upTo == 0 {
+			return nil, errors.New("up_to_message_id is required")
+		}
+		err := s.UpdateReceipts(conn.UserID, conn.UserType, threadType, threadID, &models.UpdateChatReceiptsRequest{
+			Status:        models.ReceiptStatus(envelope.Event),
+			UpToMessageID: upTo,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"up_to_message_id": upTo}, nil
+
+	case "typing":
+		typing, _ := envelope.Data["typing"].(bool)
+		return nil, s.SetTyping(conn.UserID, conn.UserType, threadType, threadID, typing)
+
+	default:
+		return nil, fmt.Errorf("unknown %s event %q", threadType, envelope.Event)
+	}
+}
+
+// markReceipts records receipts and tells the thread which messages a user received or read
+func (s *ChatSyncService) markReceipts(userID uint, threadType models.ChatThreadType, threadID, upToMessageID uint, status models.ReceiptStatus) error {
+	now := time.Now()
+	messageIDs, err := s.syncRepo.MarkReceipts(threadType, threadID, userID, upToMessageID, status, now)
+	if err != nil {
+		return fmt.Errorf("failed to record receipts: %v", err)
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	// Keep the read flags the message lists show in step with the receipts
+	if status == models.ReceiptStatusRead {
+		if threadType == models.ChatThreadRoom {
+			err = s.syncRepo.MarkRoomMessagesRead(messageIDs, userID)
+		} else {
+			err = s.syncRepo.MarkConversationMessagesRead(messageIDs, now)
+		}
+		if err != nil {
+			logrus.Errorf("ChatSyncService.markReceipts failed to mark %s %d messages read: %v", threadType, threadID, err)
+		}
+	}
+
+	GetRealtimeGateway().Publish(RealtimeTopic(string(threadType), threadID), "receipt", map[string]interface{}{
+		"user_id":          userID,
+		"status":           status,
+		"up_to_message_id": upToMessageID,
+		"message_ids":      messageIDs,
+		"at":               now,
+	})
+	return nil
+}
+
+// messagesSince reads the messages of a thread after a message
+func (s *ChatSyncService) messagesSince(threadType models.ChatThreadType, threadID, since uint, limit int) ([]models.ChatSyncMessage, error) {
+	var messages []models.ChatSyncMessage
+	switch threadType {
+	case models.ChatThreadRoom:
+		roomMessages, err := s.syncRepo.GetRoomMessagesSince(threadID, since, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range roomMessages {
+			messages = append(messages, models.ChatSyncMessage{
+				ID:                message.ID,
+				ThreadType:        threadType,
+				ThreadID:          message.RoomID,
+				SenderID:          message.SenderID,
+				Message:           message.Message,
+				MessageType:       message.MessageType,
+				Attachments:       message.Attachments,
+				AttachmentDetails: message.AttachmentDetails,
+				ReplyToMessageID:  message.ReplyToMessageID,
+				CreatedAt:         message.CreatedAt,
+			})
+		}
+	case models.ChatThreadConversation:
+		conversationMessages, err := s.syncRepo.GetConversationMessagesSince(threadID, since, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range conversationMessages {
+			messages = append(messages, models.ChatSyncMessage{
+				ID:                message.ID,
+				ThreadType:        threadType,
+				ThreadID:          message.ConversationID,
+				SenderID:          message.SenderID,
+				Message:           message.Message,
+				MessageType:       message.MessageType,
+				AttachmentDetails: message.AttachmentDetails,
+				CreatedAt:         message.CreatedAt,
+			})
+		}
+	}
+	return messages, nil
+}
+
+// attachReceipts adds the other participants' receipts to the requester's own messages
+func (s *ChatSyncService) attachReceipts(userID uint, response *models.ChatSyncResponse) error {
+	var ownMessageIDs []uint
+	for _, message := range response.Messages {
+		if message.SenderID == userID {
+			ownMessageIDs = append(ownMessageIDs, message.ID)
+		}
+	}
+	receipts, err := s.syncRepo.GetReceipts(response.ThreadType, response.ThreadID, ownMessageIDs)
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint][]models.ChatMessageReceipt)
+	for _, receipt := range receipts {
+		byMessage[receipt.MessageID] = append(byMessage[receipt.MessageID], receipt)
+	}
+	for i := range response.Messages {
+		response.Messages[i].Receipts = byMessage[response.Messages[i].ID]
+	}
+	return nil
+}
+
+// authorize checks that a user takes part in a thread or is an admin, and reports whether they take part
+func (s *ChatSyncService) authorize(threadType models.ChatThreadType, threadID, userID uint, userType string) (bool, error) {
+	participants, err := s.participants(threadType, threadID)
+	if err != nil {
+		return false, err
+	}
+	for _, participantID := range participants {
+		if participantID == userID {
+			return true, nil
+		}
+	}
+	if userType == string(models.UserTypeAdmin) {
+		return false, nil
+	}
+	return false, errors.New("access denied")
+}
+
+// participants returns the users taking part in a thread: the booking's customer and assigned worker for
+// chat rooms, both users for conversations
+func (s *ChatSyncService) participants(threadType models.ChatThreadType, threadID uint) ([]uint, error) {
+	switch threadType {
+	case models.ChatThreadRoom:
+		chatRoom, err := s.chatRoomRepo.GetByID(threadID)
+		if err != nil {
+			return nil, errors.New("chat room not found")
+		}
+		if chatRoom.BookingID == nil {
+			return nil, errors.New("invalid chat room")
+		}
+		booking, err := s.bookingRepo.GetByID(*chatRoom.BookingID)
+		if err != nil {
+			return nil, errors.New("booking not found")
+		}
+		participants := []uint{booking.UserID}
+		if assignment, err := s.workerAssignmentRepo.GetByBookingID(*chatRoom.BookingID); err == nil {
+			participants = append(participants, assignment.WorkerID)
+		}
+		return participants, nil
+
+	case models.ChatThreadConversation:
+		conversation, err := s.conversationRepo.GetByID(threadID)
+		if err != nil {
+			return nil, errors.New("conversation not found")
+		}
+		return []uint{conversation.User1, conversation.User2}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown chat thread type %q", threadType)
+	}
+}
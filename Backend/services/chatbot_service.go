@@ -19,13 +19,14 @@ import (
 )
 
 type ChatbotService struct {
-	ChatbotRepo    *repositories.ChatbotRepository
-	propertyRepo   *repositories.PropertyRepository
-	serviceRepo    *repositories.ServiceRepository
-	projectRepo    *repositories.ProjectRepository
-	userRepo       *repositories.UserRepository
-	config         *config.AppConfig
-	prompts        *ChatbotPrompts
+	ChatbotRepo        *repositories.ChatbotRepository
+	propertyRepo       *repositories.PropertyRepository
+	serviceRepo        *repositories.ServiceRepository
+	projectRepo        *repositories.ProjectRepository
+	userRepo           *repositories.UserRepository
+	config             *config.AppConfig
+	prompts            *ChatbotPrompts
+	entitlementService *EntitlementService
 }
 
 type OpenAIMessage struct {
@@ -62,13 +63,14 @@ type OpenAIResponse struct {
 
 func NewChatbotService() *ChatbotService {
 	return &ChatbotService{
-		ChatbotRepo:  repositories.NewChatbotRepository(),
-		propertyRepo: repositories.NewPropertyRepository(),
-		serviceRepo:  repositories.NewServiceRepository(),
-		projectRepo:  repositories.NewProjectRepository(),
-		userRepo:     repositories.NewUserRepository(),
-		config:       config.LoadConfig(),
-		prompts:      NewChatbotPrompts(),
+		ChatbotRepo:        repositories.NewChatbotRepository(),
+		propertyRepo:       repositories.NewPropertyRepository(),
+		serviceRepo:        repositories.NewServiceRepository(),
+		projectRepo:        repositories.NewProjectRepository(),
+		userRepo:           repositories.NewUserRepository(),
+		config:             config.LoadConfig(),
+		prompts:            NewChatbotPrompts(),
+		entitlementService: NewEntitlementService(),
 	}
 }
 
@@ -1081,6 +1083,36 @@ func (s *ChatbotService) GetSuggestions(category string, limit int) ([]models.Ch
 	return s.ChatbotRepo.GetSuggestions(category, limit)
 }
 
+// GetLeads retrieves logged-in users who recently searched for property through the chatbot.
+// Leads are a plan feature, so users whose plan does not include them get an EntitlementError.
+func (s *ChatbotService) GetLeads(userID uint, limit int) ([]models.ChatbotLead, error) {
+	if _, err := s.entitlementService.Require(userID, models.EntitlementChatbotLeadAccess); err != nil {
+		return nil, err
+	}
+	
+	sessions, err := s.ChatbotRepo.GetPropertyLeadSessions(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	
+	leads := make([]models.ChatbotLead, 0, len(sessions))
+	for _, session := range sessions {
+		lead := models.ChatbotLead{
+			SessionID:     session.SessionID,
+			UserID:        *session.UserID,
+			Location:      session.Location,
+			Context:       session.CurrentContext,
+			LastMessageAt: session.LastMessageAt,
+		}
+		if session.User != nil {
+			lead.UserName = session.User.Name
+			lead.UserPhone = session.User.Phone
+		}
+		leads = append(leads, lead)
+	}
+	return leads, nil
+}
+
 // Helper function to create int pointer
 func intPtr(i int) *int {
 	return &i
@@ -15,6 +15,7 @@ type CleanupService struct {
 	bookingService     *BookingService
 	paymentService     *PaymentService
 	adminConfigService *AdminConfigService
+	rescheduleService  *BookingRescheduleService
 }
 
 // NewCleanupService creates a new cleanup service
@@ -23,6 +24,7 @@ func NewCleanupService() *CleanupService {
 		bookingService:     NewBookingService(),
 		paymentService:     NewPaymentService(),
 		adminConfigService: NewAdminConfigService(),
+		rescheduleService:  NewBookingRescheduleService(),
 	}
 }
 
@@ -40,6 +42,11 @@ func (cs *CleanupService) RunCleanupTasks() error {
 		logrus.Errorf("Failed to cleanup abandoned wallet payments: %v", err)
 	}
 
+	// Expire reschedule requests the worker never answered
+	if err := cs.rescheduleService.ExpireStaleRequests(); err != nil {
+		logrus.Errorf("Failed to expire stale reschedule requests: %v", err)
+	}
+
 	logrus.Info("Cleanup tasks completed successfully")
 	return nil
 }
@@ -10,7 +10,9 @@ import (
 	"treesindia/config"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -137,6 +139,146 @@ func (cs *CloudinaryService) UploadVideo(file *multipart.FileHeader, folder stri
 	return result.SecureURL, nil
 }
 
+// UploadFile uploads a document such as a PDF receipt to Cloudinary as is, without transformations
+func (cs *CloudinaryService) UploadFile(file *multipart.FileHeader, folder string) (string, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Open the file
+	src, err := file.Open()
+	if err != nil {
+		logrus.Errorf("Failed to open file %s: %v", file.Filename, err)
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer src.Close()
+
+	// Generate unique filename
+	ext := filepath.Ext(file.Filename)
+	timestamp := time.Now().Unix()
+	filename := fmt.Sprintf("%s_%d%s", strings.TrimSuffix(file.Filename, ext), timestamp, ext)
+
+	// Let Cloudinary detect the resource type so PDFs and images are both accepted
+	uploadParams := uploader.UploadParams{
+		PublicID:     fmt.Sprintf("%s/%s", folder, filename),
+		Folder:       folder,
+		ResourceType: "auto",
+	}
+
+	result, err := cs.cld.Upload.Upload(ctx, src, uploadParams)
+	if err != nil {
+		logrus.Errorf("Failed to upload file to Cloudinary: %v", err)
+		return "", fmt.Errorf("failed to upload file to Cloudinary: %v", err)
+	}
+
+	logrus.Infof("Successfully uploaded file %s to Cloudinary: %s", file.Filename, result.SecureURL)
+	return result.SecureURL, nil
+}
+
+// CloudinaryUpload holds what Cloudinary reports about an uploaded file
+type CloudinaryUpload struct {
+	URL          string
+	ThumbnailURL string
+	PublicID     string
+	ResourceType string
+	DeliveryType string
+	Width        int
+	Height       int
+	Bytes        int64
+}
+
+// thumbnailTransformation returns the transformation making a JPEG thumbnail of an image, or of the first
+// page of a PDF. Cloudinary treats PDFs as images, so both get one.
+func thumbnailTransformation(isDocument bool) string {
+	thumbnail := "c_fill,g_auto,w_320,h_320,q_auto,f_jpg"
+	if isDocument {
+		thumbnail = "pg_1," + thumbnail
+	}
+	return thumbnail
+}
+
+// UploadWithThumbnail uploads an image or PDF under an unguessable name and has Cloudinary generate a
+// thumbnail of it while uploading, from the first page for PDFs. The file is uploaded as authenticated,
+// so it can only be fetched through URLs signed by AuthenticatedURL.
+func (cs *CloudinaryService) UploadWithThumbnail(file *multipart.FileHeader, folder string, isDocument bool) (*CloudinaryUpload, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Open the file
+	src, err := file.Open()
+	if err != nil {
+		logrus.Errorf("Failed to open file %s: %v", file.Filename, err)
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer src.Close()
+
+	uploadParams := uploader.UploadParams{
+		PublicID:     uuid.New().String(),
+		Folder:       folder,
+		ResourceType: "image",
+		Type:         api.Authenticated,
+		Eager:        thumbnailTransformation(isDocument),
+	}
+
+	result, err := cs.cld.Upload.Upload(ctx, src, uploadParams)
+	if err != nil {
+		logrus.Errorf("Failed to upload file to Cloudinary: %v", err)
+		return nil, fmt.Errorf("failed to upload file to Cloudinary: %v", err)
+	}
+
+	upload := &CloudinaryUpload{
+		URL:          result.SecureURL,
+		PublicID:     result.PublicID,
+		ResourceType: result.ResourceType,
+		DeliveryType: api.Authenticated,
+		Width:        result.Width,
+		Height:       result.Height,
+		Bytes:        int64(result.Bytes),
+	}
+	if len(result.Eager) > 0 {
+		upload.ThumbnailURL = result.Eager[0].SecureURL
+	}
+
+	logrus.Infof("Successfully uploaded %s to Cloudinary with thumbnail: %s", file.Filename, result.PublicID)
+	return upload, nil
+}
+
+// AuthenticatedURL returns a signed URL of an authenticated upload, or of the thumbnail generated for it
+func (cs *CloudinaryService) AuthenticatedURL(publicID string, isDocument bool, thumbnail bool) (string, error) {
+	image, err := cs.cld.Image(publicID)
+	if err != nil {
+		return "", fmt.Errorf("failed to build media URL: %v", err)
+	}
+	image.DeliveryType = api.Authenticated
+	image.Config.URL.SignURL = true
+	if thumbnail {
+		image.Transformation = thumbnailTransformation(isDocument)
+	}
+
+	mediaURL, err := image.String()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign media URL: %v", err)
+	}
+	return mediaURL, nil
+}
+
+// DeleteAuthenticatedMedia deletes an authenticated upload from Cloudinary
+func (cs *CloudinaryService) DeleteAuthenticatedMedia(publicID string, resourceType string) error {
+	ctx := context.Background()
+	result, err := cs.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		Type:         api.Authenticated,
+		ResourceType: resourceType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete media: %v", err)
+	}
+
+	logrus.Infof("Authenticated media deleted successfully: %s", result.Result)
+	return nil
+}
+
 // UploadMedia uploads either an image or video to Cloudinary based on content type
 func (cs *CloudinaryService) UploadMedia(file *multipart.FileHeader, folder string, mediaType string) (string, error) {
 	if mediaType == "video" {
@@ -294,6 +294,390 @@ func (cr *ConfigRegistry) registerDefaultSchemas() {
 		MaxValue:    10000,
 		Unit:        "INR",
 	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "review_edit_window_hours",
+		Type:        "int",
+		Category:    "booking",
+		Description: "Hours after submission during which a customer can edit a booking review",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    720,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "cancellation_fee_tiers",
+		Type:        "string",
+		Category:    "booking",
+		Description: "Cancellation fee tiers as hours_before:fee_percent pairs",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "segment_cancellation_fee_tiers",
+		Type:        "string",
+		Category:    "booking",
+		Description: "Cancellation fee tiers applied to paid payment segments",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "inquiry_fee_refund_percentage_before_quote",
+		Type:        "float",
+		Category:    "booking",
+		Description: "Percentage of the inquiry fee refunded when cancelled before a quote is accepted",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    100,
+		Unit:        "percent",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "inquiry_fee_refund_percentage_after_quote",
+		Type:        "float",
+		Category:    "booking",
+		Description: "Percentage of the inquiry fee refunded when cancelled after a quote is accepted",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    100,
+		Unit:        "percent",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "worker_matching_max_distance_km",
+		Type:        "float",
+		Category:    "booking",
+		Description: "Maximum distance between a worker and the booking address for automatic matching",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    500,
+		Unit:        "km",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "recurring_booking_lead_hours",
+		Type:        "int",
+		Category:    "booking",
+		Description: "How long before a recurring visit its booking is created and paid",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    336,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "booking_reschedule_cutoff_hours",
+		Type:        "int",
+		Category:    "booking",
+		Description: "Hours before the start of a booking after which customers can no longer reschedule it",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    168,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "max_reschedules_per_booking",
+		Type:        "int",
+		Category:    "booking",
+		Description: "Maximum number of customer reschedules per booking",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    10,
+	})
+
+	// Worker Payouts
+	cr.registerSchema(ConfigSchema{
+		Key:         "default_commission_percentage",
+		Type:        "float",
+		Category:    "payout",
+		Description: "Platform commission on jobs without a commission rule",
+		Required:    false,
+		MinValue:    0.0,
+		MaxValue:    100.0,
+		Unit:        "percent",
+	})
+
+	// Invoicing
+	cr.registerSchema(ConfigSchema{
+		Key:         "company_legal_name",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "Legal name of the company printed on invoices",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "company_gstin",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "GSTIN of the company printed on invoices",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "company_state",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "State the company is registered in for GST",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "company_address",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "Registered address of the company printed on invoices",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "default_gst_rate",
+		Type:        "float",
+		Category:    "invoice",
+		Description: "GST rate for services without a category tax code",
+		Required:    false,
+		MinValue:    0.0,
+		MaxValue:    28.0,
+		Unit:        "percent",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "default_sac_code",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "SAC code for services without a category tax code",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "subscription_sac_code",
+		Type:        "string",
+		Category:    "invoice",
+		Description: "SAC code printed on subscription invoices",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "referral_referrer_reward",
+		Type:        "float",
+		Category:    "referral",
+		Description: "Wallet credit for a user whose referee completes their first booking",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    10000,
+		Unit:        "INR",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "referral_referee_reward",
+		Type:        "float",
+		Category:    "referral",
+		Description: "Wallet credit for a referred user on their first completed booking",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    10000,
+		Unit:        "INR",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "segment_reminder_days",
+		Type:        "string",
+		Category:    "payment",
+		Description: "Days before a payment segment is due to remind the customer (comma separated)",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "segment_escalation_days",
+		Type:        "int",
+		Category:    "payment",
+		Description: "Days a payment segment stays overdue before it is escalated",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    90,
+		Unit:        "days",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "segment_escalation_action",
+		Type:        "string",
+		Category:    "payment",
+		Description: "What happens when an overdue payment segment is escalated",
+		Required:    false,
+		Options:     []string{"none", "notify_admin", "pause_assignment"},
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "segment_auto_debit_retry_hours",
+		Type:        "int",
+		Category:    "payment",
+		Description: "Hours between wallet auto-debit attempts for a due payment segment",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    168,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "subscription_grace_days",
+		Type:        "int",
+		Category:    "subscription",
+		Description: "Days an auto-renewing subscription keeps access after a failed renewal before it expires",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    30,
+		Unit:        "days",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "subscription_renewal_lead_hours",
+		Type:        "int",
+		Category:    "subscription",
+		Description: "Hours before the end of a term that auto-renewal is first attempted",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    168,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "subscription_renewal_retry_hours",
+		Type:        "int",
+		Category:    "subscription",
+		Description: "Hours between auto-renewal attempts after a failed wallet debit",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    168,
+		Unit:        "hours",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "free_max_active_listings",
+		Type:        "int",
+		Category:    "subscription",
+		Description: "Active property listings a user without a subscription can have; -1 for unlimited",
+		Required:    false,
+		MinValue:    -1,
+		MaxValue:    1000,
+		Unit:        "listings",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "free_worker_inquiry_contacts_per_month",
+		Type:        "int",
+		Category:    "subscription",
+		Description: "Worker inquiries a user without a subscription can send per calendar month; -1 for unlimited",
+		Required:    false,
+		MinValue:    -1,
+		MaxValue:    1000,
+		Unit:        "inquiries",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "payment_abandon_after_minutes",
+		Type:        "int",
+		Category:    "payment",
+		Description: "Minutes after which a pending Razorpay order with no payment attempt is marked abandoned by reconciliation",
+		Required:    false,
+		MinValue:    15,
+		MaxValue:    10080,
+		Unit:        "minutes",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "payment_settlement_window_days",
+		Type:        "int",
+		Category:    "payment",
+		Description: "Days Razorpay takes to settle a captured payment before reconciliation flags it as not settled",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    30,
+		Unit:        "days",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "payment_gateway",
+		Type:        "string",
+		Category:    "payment",
+		Description: "Payment gateway new online payments are made through; existing payments stay with their gateway",
+		Required:    false,
+		Options:     []string{"razorpay", "cashfree"},
+	})
+
+	// Realtime
+	cr.registerSchema(ConfigSchema{
+		Key:         "realtime_messages_per_minute",
+		Type:        "int",
+		Category:    "system",
+		Description: "Messages a client may send per minute on a realtime connection before it is rate limited",
+		Required:    false,
+		MinValue:    10,
+		MaxValue:    6000,
+		Unit:        "messages",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "realtime_max_subscriptions",
+		Type:        "int",
+		Category:    "system",
+		Description: "Topics a realtime connection may subscribe to at once",
+		Required:    false,
+		MinValue:    5,
+		MaxValue:    500,
+		Unit:        "topics",
+	})
+
+	// Chat
+	cr.registerSchema(ConfigSchema{
+		Key:         "chat_push_fallback_delay_seconds",
+		Type:        "int",
+		Category:    "system",
+		Description: "Seconds a chat message may stay undelivered to a recipient before they get a push notification for it",
+		Required:    false,
+		MinValue:    0,
+		MaxValue:    600,
+		Unit:        "seconds",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "chat_image_max_size_mb",
+		Type:        "int",
+		Category:    "system",
+		Description: "Maximum file size for images shared in chat",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    50,
+		Unit:        "MB",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "chat_file_max_size_mb",
+		Type:        "int",
+		Category:    "system",
+		Description: "Maximum file size for documents shared in chat",
+		Required:    false,
+		MinValue:    1,
+		MaxValue:    100,
+		Unit:        "MB",
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "chat_moderation_enabled",
+		Type:        "bool",
+		Category:    "system",
+		Description: "Mask phone numbers, emails and payment handles and filter blocked words in chat messages",
+		Required:    false,
+	})
+
+	cr.registerSchema(ConfigSchema{
+		Key:         "chat_blocked_words",
+		Type:        "string",
+		Category:    "system",
+		Description: "Comma separated words replaced with asterisks in chat messages",
+		Required:    false,
+	})
 }
 
 // registerSchema registers a configuration schema
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// couponReservationWindow is how long a coupon stays reserved for an order waiting for payment
+const couponReservationWindow = 30 * time.Minute
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+
+type CouponService struct {
+	couponRepo  *repositories.CouponRepository
+	serviceRepo *repositories.ServiceRepository
+	bookingRepo *repositories.BookingRepository
+}
+
+func NewCouponService() *CouponService {
+	return &CouponService{
+		couponRepo:  repositories.NewCouponRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+		bookingRepo: repositories.NewBookingRepository(),
+	}
+}
+
+// Apply checks that a coupon can be used by the user on the order and works out its discount or cashback.
+// bookingID is the booking being paid for, if it already exists.
+func (cs *CouponService) Apply(userID uint, code string, target *models.CouponTarget, bookingID uint) (*models.CouponApplication, error) {
+	coupon, err := cs.couponRepo.GetByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid coupon code")
+		}
+		return nil, fmt.Errorf("failed to get coupon: %v", err)
+	}
+
+	now := time.Now()
+	if !coupon.IsActive {
+		return nil, errors.New("coupon is not active")
+	}
+	if coupon.ValidFrom != nil && now.Before(*coupon.ValidFrom) {
+		return nil, errors.New("coupon is not valid yet")
+	}
+	if coupon.ValidUntil != nil && now.After(*coupon.ValidUntil) {
+		return nil, errors.New("coupon has expired")
+	}
+
+	if coupon.Scope != models.CouponScopeAll && coupon.Scope != target.Scope {
+		return nil, fmt.Errorf("coupon cannot be applied to %s payments", target.Scope)
+	}
+	if len(coupon.ServiceIDs) > 0 && !containsUint(coupon.ServiceIDs, target.ServiceID) {
+		return nil, errors.New("coupon cannot be applied to this service")
+	}
+	if len(coupon.CategoryIDs) > 0 && !containsUint(coupon.CategoryIDs, target.CategoryID) {
+		return nil, errors.New("coupon cannot be applied to this category")
+	}
+	if target.Amount < coupon.MinOrderAmount {
+		return nil, fmt.Errorf("minimum order amount for this coupon is ₹%.2f", coupon.MinOrderAmount)
+	}
+
+	if coupon.FirstBookingOnly {
+		count, err := cs.couponRepo.CountUserBookings(userID, bookingID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check previous bookings: %v", err)
+		}
+		if count > 0 {
+			return nil, errors.New("coupon is only valid on your first booking")
+		}
+	}
+
+	// Limits are checked again when the coupon is reserved
+	total, byUser, err := cs.couponRepo.CountActiveRedemptions(coupon.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check coupon usage: %v", err)
+	}
+	if coupon.UsageLimit > 0 && total >= int64(coupon.UsageLimit) {
+		return nil, errors.New("coupon usage limit reached")
+	}
+	if coupon.PerUserLimit > 0 && byUser >= int64(coupon.PerUserLimit) {
+		return nil, errors.New("you have already used this coupon")
+	}
+
+	reward := couponReward(coupon, target.Amount)
+	application := &models.CouponApplication{
+		Coupon:        coupon,
+		Scope:         target.Scope,
+		OrderAmount:   target.Amount,
+		PayableAmount: target.Amount,
+	}
+	if coupon.RewardType == models.CouponRewardCashback {
+		application.CashbackAmount = reward
+	} else {
+		// At least ₹1 is always paid
+		if reward > target.Amount-1 {
+			reward = roundCurrency(math.Max(target.Amount-1, 0))
+		}
+		application.DiscountAmount = reward
+		application.PayableAmount = roundCurrency(target.Amount - reward)
+	}
+	if application.DiscountAmount <= 0 && application.CashbackAmount <= 0 {
+		return nil, errors.New("coupon does not reduce this order")
+	}
+
+	return application, nil
+}
+
+// couponReward works out the discount or cashback of a coupon on an order amount
+func couponReward(coupon *models.Coupon, amount float64) float64 {
+	reward := coupon.Value
+	if coupon.DiscountType == models.CouponDiscountPercentage {
+		reward = amount * coupon.Value / 100
+		if coupon.MaxDiscount != nil && reward > *coupon.MaxDiscount {
+			reward = *coupon.MaxDiscount
+		}
+	}
+	if reward > amount {
+		reward = amount
+	}
+	return roundCurrency(reward)
+}
+
+// Preview applies a coupon to an order the user is about to pay for, without reserving it
+func (cs *CouponService) Preview(userID uint, req *models.ValidateCouponRequest) (*models.CouponApplication, error) {
+	target := &models.CouponTarget{Scope: req.Scope}
+	var bookingID uint
+
+	switch req.Scope {
+	case models.CouponScopeBooking:
+		service, err := cs.serviceRepo.GetByID(req.ServiceID)
+		if err != nil {
+			return nil, errors.New("service not found")
+		}
+		if service.PriceType != "fixed" || service.Price == nil {
+			return nil, errors.New("coupons can only be applied to fixed price services")
+		}
+		target.Amount = *service.Price
+		target.ServiceID = service.ID
+		target.CategoryID = service.CategoryID
+
+	case models.CouponScopeQuote:
+		booking, err := cs.bookingRepo.GetByID(req.BookingID)
+		if err != nil || booking.UserID != userID {
+			return nil, errors.New("booking not found")
+		}
+		if booking.Status != models.BookingStatusQuoteAccepted || booking.QuoteAmount == nil {
+			return nil, errors.New("quote has not been accepted")
+		}
+		if len(booking.PaymentSegments) > 1 {
+			return nil, errors.New("coupons cannot be applied to quotes paid in segments")
+		}
+		target = QuoteCouponTarget(booking)
+		bookingID = booking.ID
+
+	case models.CouponScopeSubscription:
+		plan, err := NewSubscriptionPlanService().GetPlanByID(req.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		var selectedPricing *models.PricingOption
+		for _, pricing := range plan.Pricing {
+			if pricing.DurationType == req.DurationType {
+				selectedPricing = &pricing
+				break
+			}
+		}
+		if selectedPricing == nil {
+			return nil, errors.New("invalid duration type for this plan")
+		}
+		target.Amount = selectedPricing.Price
+
+	default:
+		return nil, errors.New("invalid coupon scope")
+	}
+
+	return cs.Apply(userID, req.Code, target, bookingID)
+}
+
+// QuoteCouponTarget describes the quote of an inquiry booking as a coupon target
+func QuoteCouponTarget(booking *models.Booking) *models.CouponTarget {
+	target := &models.CouponTarget{
+		Scope:     models.CouponScopeQuote,
+		ServiceID: booking.ServiceID,
+	}
+	if booking.QuoteAmount != nil {
+		target.Amount = *booking.QuoteAmount
+	}
+	if booking.Service.ID != 0 {
+		target.CategoryID = booking.Service.CategoryID
+	}
+	return target
+}
+
+// ReserveForOrder applies a coupon to an order and reserves it until the order is paid or abandoned
+func (cs *CouponService) ReserveForOrder(userID uint, code string, target *models.CouponTarget, bookingID *uint) (*models.CouponApplication, *models.CouponRedemption, error) {
+	var existingBookingID uint
+	if bookingID != nil {
+		existingBookingID = *bookingID
+	}
+	application, err := cs.Apply(userID, code, target, existingBookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservedUntil := time.Now().Add(couponReservationWindow)
+	redemption := &models.CouponRedemption{
+		CouponID:       application.Coupon.ID,
+		UserID:         userID,
+		Scope:          target.Scope,
+		BookingID:      bookingID,
+		OrderAmount:    application.OrderAmount,
+		DiscountAmount: application.DiscountAmount,
+		CashbackAmount: application.CashbackAmount,
+		Status:         models.CouponRedemptionReserved,
+		ReservedUntil:  &reservedUntil,
+		CashbackStatus: models.CashbackStatusNone,
+	}
+	if application.CashbackAmount > 0 {
+		redemption.CashbackStatus = models.CashbackStatusPending
+	}
+
+	if err := cs.couponRepo.Reserve(redemption); err != nil {
+		return nil, nil, err
+	}
+	redemption.Coupon = application.Coupon
+	return application, redemption, nil
+}
+
+// AttachPayment links a reserved coupon to the payment order created for it
+func (cs *CouponService) AttachPayment(redemption *models.CouponRedemption, paymentID uint) error {
+	redemption.PaymentID = &paymentID
+	return cs.couponRepo.AttachPayment(redemption.ID, paymentID)
+}
+
+// Confirm redeems a reserved coupon once its order is paid. Subscription cashback is credited
+// straight away, booking and quote cashback when the booking is completed.
+func (cs *CouponService) Confirm(redemption *models.CouponRedemption, paymentID *uint) error {
+	redeemed, err := cs.couponRepo.MarkRedeemed(redemption, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to redeem coupon: %v", err)
+	}
+	if redeemed && redemption.Scope == models.CouponScopeSubscription {
+		cs.creditCashback(redemption)
+	}
+	return nil
+}
+
+// ConfirmForPayment redeems the coupon applied to a payment that was completed, if any
+func (cs *CouponService) ConfirmForPayment(payment *models.Payment) error {
+	redemption, err := cs.couponRepo.GetRedemptionByPaymentID(payment.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return cs.Confirm(redemption, &payment.ID)
+}
+
+// Release releases a reserved coupon whose order was not paid
+func (cs *CouponService) Release(redemption *models.CouponRedemption) {
+	if err := cs.couponRepo.Release(redemption.ID); err != nil {
+		logrus.Errorf("Failed to release coupon redemption %d: %v", redemption.ID, err)
+	}
+}
+
+// ReleaseForPayment releases the coupon reserved for a payment that failed
+func (cs *CouponService) ReleaseForPayment(paymentID uint) error {
+	return cs.couponRepo.ReleaseByPaymentID(paymentID)
+}
+
+// ReleaseForBooking releases the coupons reserved for a booking that was abandoned
+func (cs *CouponService) ReleaseForBooking(bookingID uint) error {
+	return cs.couponRepo.ReleaseByBookingID(bookingID)
+}
+
+// CreditBookingCashback credits the pending cashback of the coupon used on a completed booking
+func (cs *CouponService) CreditBookingCashback(bookingID uint) {
+	redemption, err := cs.couponRepo.GetRedeemedByBookingID(bookingID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Errorf("Failed to get coupon redemption of booking %d: %v", bookingID, err)
+		}
+		return
+	}
+	cs.creditCashback(redemption)
+}
+
+// creditCashback credits a redemption's pending cashback to the user's wallet exactly once
+func (cs *CouponService) creditCashback(redemption *models.CouponRedemption) {
+	if redemption.CashbackAmount <= 0 || redemption.CashbackStatus != models.CashbackStatusPending {
+		return
+	}
+
+	claimed, err := cs.couponRepo.ClaimCashback(redemption.ID)
+	if err != nil || !claimed {
+		if err != nil {
+			logrus.Errorf("Failed to claim cashback of coupon redemption %d: %v", redemption.ID, err)
+		}
+		return
+	}
+
+	code := ""
+	if redemption.Coupon != nil {
+		code = redemption.Coupon.Code
+	}
+	payment, err := NewUnifiedWalletService().CreditPromotion(redemption.UserID, redemption.CashbackAmount, models.WalletEntryTypeCashback,
+		"coupon_redemption", redemption.ID, fmt.Sprintf("Cashback for coupon %s", code))
+	if err != nil {
+		logrus.Errorf("Failed to credit cashback of coupon redemption %d: %v", redemption.ID, err)
+		if err := cs.couponRepo.UnclaimCashback(redemption.ID); err != nil {
+			logrus.Errorf("Failed to reset cashback of coupon redemption %d: %v", redemption.ID, err)
+		}
+		return
+	}
+
+	redemption.CashbackStatus = models.CashbackStatusCredited
+	redemption.CashbackPaymentID = &payment.ID
+	if err := cs.couponRepo.SetCashbackPayment(redemption.ID, payment.ID); err != nil {
+		logrus.Errorf("Failed to record cashback payment of coupon redemption %d: %v", redemption.ID, err)
+	}
+}
+
+// CreateCoupon creates a coupon
+func (cs *CouponService) CreateCoupon(adminID uint, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon := &models.Coupon{CreatedBy: &adminID, PerUserLimit: 1, IsActive: true}
+	if err := applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.couponRepo.GetByCode(coupon.Code); err == nil {
+		return nil, errors.New("coupon code already exists")
+	}
+
+	if err := cs.couponRepo.Create(coupon); err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %v", err)
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon updates a coupon
+func (cs *CouponService) UpdateCoupon(id uint, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon, err := cs.couponRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	if err := applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+
+	if existing, err := cs.couponRepo.GetByCode(coupon.Code); err == nil && existing.ID != coupon.ID {
+		return nil, errors.New("coupon code already exists")
+	}
+
+	if err := cs.couponRepo.Update(coupon); err != nil {
+		return nil, fmt.Errorf("failed to update coupon: %v", err)
+	}
+	return coupon, nil
+}
+
+// applyCouponRequest validates a coupon request and copies it onto the coupon
+func applyCouponRequest(coupon *models.Coupon, req *models.CouponRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !couponCodePattern.MatchString(code) {
+		return errors.New("coupon code can only contain letters, digits, hyphens and underscores")
+	}
+	if req.DiscountType == models.CouponDiscountPercentage && req.Value > 100 {
+		return errors.New("percentage value cannot be more than 100")
+	}
+	if req.MaxDiscount != nil && *req.MaxDiscount <= 0 {
+		return errors.New("max discount must be positive")
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom) {
+		return errors.New("valid until must be after valid from")
+	}
+	if req.PerUserLimit != nil && *req.PerUserLimit < 0 {
+		return errors.New("per user limit cannot be negative")
+	}
+
+	coupon.Code = code
+	coupon.Description = req.Description
+	coupon.RewardType = req.RewardType
+	if coupon.RewardType == "" {
+		coupon.RewardType = models.CouponRewardDiscount
+	}
+	coupon.DiscountType = req.DiscountType
+	coupon.Value = req.Value
+	coupon.MaxDiscount = req.MaxDiscount
+	coupon.MinOrderAmount = req.MinOrderAmount
+	coupon.ValidFrom = req.ValidFrom
+	coupon.ValidUntil = req.ValidUntil
+	coupon.UsageLimit = req.UsageLimit
+	if req.PerUserLimit != nil {
+		coupon.PerUserLimit = *req.PerUserLimit
+	}
+	coupon.Scope = req.Scope
+	if coupon.Scope == "" {
+		coupon.Scope = models.CouponScopeAll
+	}
+	coupon.CategoryIDs = req.CategoryIDs
+	if coupon.CategoryIDs == nil {
+		coupon.CategoryIDs = []uint{}
+	}
+	coupon.ServiceIDs = req.ServiceIDs
+	if coupon.ServiceIDs == nil {
+		coupon.ServiceIDs = []uint{}
+	}
+	coupon.FirstBookingOnly = req.FirstBookingOnly
+	if req.IsActive != nil {
+		coupon.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// DeleteCoupon deletes a coupon
+func (cs *CouponService) DeleteCoupon(id uint) error {
+	if _, err := cs.couponRepo.GetByID(id); err != nil {
+		return errors.New("coupon not found")
+	}
+	return cs.couponRepo.Delete(id)
+}
+
+// GetCoupon gets a coupon by ID
+func (cs *CouponService) GetCoupon(id uint) (*models.Coupon, error) {
+	coupon, err := cs.couponRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	return coupon, nil
+}
+
+// GetCoupons gets coupons with filters
+func (cs *CouponService) GetCoupons(filters *models.CouponFilters) ([]models.Coupon, *repositories.Pagination, error) {
+	return cs.couponRepo.GetCoupons(filters)
+}
+
+// GetRedemptions gets the redemptions of a coupon
+func (cs *CouponService) GetRedemptions(couponID uint, page, limit int) ([]models.CouponRedemption, *repositories.Pagination, error) {
+	return cs.couponRepo.GetRedemptions(couponID, page, limit)
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// entitlementLabels are the names used for entitlements in error messages
+var entitlementLabels = map[models.EntitlementKey]string{
+	models.EntitlementMaxActiveListings:     "active listings",
+	models.EntitlementFeaturedListingSlots:  "featured listing slots",
+	models.EntitlementPriorityScoreBoost:    "priority listing boost",
+	models.EntitlementWorkerInquiryContacts: "worker inquiry contacts this month",
+	models.EntitlementChatbotLeadAccess:     "chatbot leads",
+}
+
+// EntitlementError is returned when a plan does not include a feature or its quota is used up
+type EntitlementError struct {
+	Key   models.EntitlementKey
+	Limit int
+	Used  int
+}
+
+func (e *EntitlementError) Error() string {
+	label := entitlementLabels[e.Key]
+	if e.Limit == 0 {
+		return fmt.Sprintf("%s are not included in your plan, please upgrade your subscription", label)
+	}
+	return fmt.Sprintf("quota exceeded: %d of %d %s used, please upgrade your subscription", e.Used, e.Limit, label)
+}
+
+// IsEntitlementError reports whether err is a quota exceeded or feature not included error
+func IsEntitlementError(err error) bool {
+	var entitlementErr *EntitlementError
+	return errors.As(err, &entitlementErr)
+}
+
+// EntitlementService checks and meters the entitlements of a user's subscription plan
+type EntitlementService struct {
+	userRepo           *repositories.UserRepository
+	subscriptionRepo   *repositories.UserSubscriptionRepository
+	usageRepo          *repositories.EntitlementUsageRepository
+	propertyRepo       *repositories.PropertyRepository
+	adminConfigService *AdminConfigService
+}
+
+// NewEntitlementService creates a new entitlement service
+func NewEntitlementService() *EntitlementService {
+	return &EntitlementService{
+		userRepo:           repositories.NewUserRepository(),
+		subscriptionRepo:   repositories.NewUserSubscriptionRepository(),
+		usageRepo:          repositories.NewEntitlementUsageRepository(),
+		propertyRepo:       repositories.NewPropertyRepository(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// GetEntitlements gets the entitlements of a user's active plan, or of the free tier when
+// the user has no subscription. The plan is nil on the free tier.
+func (es *EntitlementService) GetEntitlements(userID uint) (*models.PlanEntitlements, *models.SubscriptionPlan, error) {
+	subscription, err := es.subscriptionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return es.freeEntitlements(), nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get active subscription: %v", err)
+	}
+
+	plan := &subscription.Plan
+	if plan.Entitlements == nil {
+		return models.DefaultPlanEntitlements(), plan, nil
+	}
+	return plan.Entitlements, plan, nil
+}
+
+// Check gets a user's allowance and usage of an entitlement
+func (es *EntitlementService) Check(userID uint, key models.EntitlementKey) (*models.EntitlementStatus, error) {
+	var user models.User
+	if err := es.userRepo.FindByID(&user, userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Admins are not limited by plans
+	if user.UserType == models.UserTypeAdmin {
+		return &models.EntitlementStatus{
+			Key:       key,
+			Limit:     models.UnlimitedQuota,
+			Remaining: models.UnlimitedQuota,
+			Allowed:   true,
+		}, nil
+	}
+
+	entitlements, plan, err := es.GetEntitlements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.EntitlementStatus{
+		Key:   key,
+		Limit: entitlements.Quota(key),
+	}
+	if plan != nil {
+		status.PlanID = &plan.ID
+		status.PlanName = plan.Name
+	}
+
+	switch key {
+	case models.EntitlementMaxActiveListings:
+		status.Used, err = es.propertyRepo.CountActiveByUserID(userID)
+	case models.EntitlementFeaturedListingSlots:
+		status.Used, err = es.propertyRepo.CountFeaturedByUserID(userID)
+	case models.EntitlementWorkerInquiryContacts:
+		periodStart := entitlementPeriodStart(time.Now())
+		status.PeriodStart = &periodStart
+		status.Used, err = es.usageRepo.GetUsed(userID, key, periodStart)
+	case models.EntitlementPriorityScoreBoost, models.EntitlementChatbotLeadAccess:
+		// Features rather than quotas: allowed when the plan grants any
+		status.Remaining = status.Limit
+		status.Allowed = status.Limit > 0
+		return status, nil
+	default:
+		return nil, fmt.Errorf("unknown entitlement: %s", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s usage: %v", key, err)
+	}
+
+	if status.Limit < 0 {
+		status.Remaining = models.UnlimitedQuota
+		status.Allowed = true
+	} else {
+		status.Remaining = status.Limit - status.Used
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+		status.Allowed = status.Used < status.Limit
+	}
+	return status, nil
+}
+
+// Require checks that a user can use one more of an entitlement, returning an EntitlementError when not
+func (es *EntitlementService) Require(userID uint, key models.EntitlementKey) (*models.EntitlementStatus, error) {
+	status, err := es.Check(userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Allowed {
+		return status, &EntitlementError{Key: key, Limit: status.Limit, Used: status.Used}
+	}
+	return status, nil
+}
+
+// Consume uses one of a monthly entitlement, returning an EntitlementError when the month's
+// quota is used up. The returned period is what Release takes to give the use back.
+func (es *EntitlementService) Consume(userID uint, key models.EntitlementKey) (time.Time, error) {
+	status, err := es.Check(userID, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if status.PeriodStart == nil {
+		return time.Time{}, fmt.Errorf("%s is not metered monthly", key)
+	}
+
+	consumed, err := es.usageRepo.Consume(userID, key, *status.PeriodStart, status.Limit)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to record %s usage: %v", key, err)
+	}
+	if !consumed {
+		return time.Time{}, &EntitlementError{Key: key, Limit: status.Limit, Used: status.Limit}
+	}
+	return *status.PeriodStart, nil
+}
+
+// Release gives back a use taken by Consume, e.g. when the gated action failed
+func (es *EntitlementService) Release(userID uint, key models.EntitlementKey, periodStart time.Time) {
+	if err := es.usageRepo.Release(userID, key, periodStart); err != nil {
+		logrus.Errorf("Failed to release %s usage of user %d: %v", key, userID, err)
+	}
+}
+
+// RecordUsage meters a use of an entitlement that is enforced on live counts, such as listings
+func (es *EntitlementService) RecordUsage(userID uint, key models.EntitlementKey) {
+	if _, err := es.usageRepo.Consume(userID, key, entitlementPeriodStart(time.Now()), models.UnlimitedQuota); err != nil {
+		logrus.Errorf("Failed to record %s usage of user %d: %v", key, userID, err)
+	}
+}
+
+// GetAll gets a user's allowance and usage of every entitlement
+func (es *EntitlementService) GetAll(userID uint) ([]models.EntitlementStatus, error) {
+	statuses := make([]models.EntitlementStatus, 0, len(models.EntitlementKeys))
+	for _, key := range models.EntitlementKeys {
+		status, err := es.Check(userID, key)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// GetUsageHistory gets a user's metered usage per month
+func (es *EntitlementService) GetUsageHistory(userID uint) ([]models.EntitlementUsage, error) {
+	return es.usageRepo.GetUsageByUser(userID)
+}
+
+// freeEntitlements are the entitlements of users without a subscription
+func (es *EntitlementService) freeEntitlements() *models.PlanEntitlements {
+	return &models.PlanEntitlements{
+		MaxActiveListings:             es.adminConfigService.GetFreeMaxActiveListings(),
+		FeaturedListingSlots:          0,
+		PriorityScoreBoost:            0,
+		WorkerInquiryContactsPerMonth: es.adminConfigService.GetFreeWorkerInquiryContactsPerMonth(),
+		ChatbotLeadAccess:             false,
+	}
+}
+
+// entitlementPeriodStart returns the start of the calendar month (IST) monthly quotas count towards
+func entitlementPeriodStart(now time.Time) time.Time {
+	local := now.In(workerCalendarLocation())
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, local.Location())
+}
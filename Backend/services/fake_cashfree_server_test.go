@@ -0,0 +1,237 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testCashfreeAppID     = "cf_test_app"
+	testCashfreeSecretKey = "cf_test_secret"
+)
+
+// fakeCashfree is a local stand-in for the Cashfree Payment Gateway API serving the orders and payments
+// a test sets up, creating orders and issuing refunds against successful payments
+type fakeCashfree struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	orders        map[string]map[string]interface{}   // Order ID to order entity
+	orderPayments map[string][]map[string]interface{} // Order ID to payment entities
+	orderRequests []map[string]interface{}            // Create order requests received, in order
+	refunds       []fakeCashfreeRefund                // Refunds issued, in order
+}
+
+// fakeCashfreeRefund is a refund request the fake API accepted
+type fakeCashfreeRefund struct {
+	OrderID        string
+	RefundID       string
+	Amount         float64 // Rupees
+	Note           string
+	IdempotencyKey string
+}
+
+// newFakeCashfree starts a fake Cashfree API that is shut down when the test ends
+func newFakeCashfree(t *testing.T) *fakeCashfree {
+	t.Helper()
+	fake := &fakeCashfree{
+		orders:        map[string]map[string]interface{}{},
+		orderPayments: map[string][]map[string]interface{}{},
+	}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// gateway returns a Cashfree gateway pointed at the fake API
+func (f *fakeCashfree) gateway() *CashfreeGateway {
+	return NewCashfreeGatewayWithConfig(testCashfreeAppID, testCashfreeSecretKey, f.server.URL)
+}
+
+// addOrder sets up an order with an order_status of ACTIVE, PAID or EXPIRED and its payment attempts
+func (f *fakeCashfree) addOrder(orderID, status string, amount float64, payments ...map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[orderID] = map[string]interface{}{
+		"cf_order_id": 2149460581, "order_id": orderID, "entity": "order",
+		"order_amount": amount, "order_currency": "INR", "order_status": status,
+	}
+	f.orderPayments[orderID] = payments
+}
+
+// createdOrders returns the create order requests received so far
+func (f *fakeCashfree) createdOrders() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}(nil), f.orderRequests...)
+}
+
+// issuedRefunds returns the refunds issued so far
+func (f *fakeCashfree) issuedRefunds() []fakeCashfreeRefund {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeCashfreeRefund(nil), f.refunds...)
+}
+
+// cashfreePayment builds a payment entity as the order payments API returns it
+func cashfreePayment(cfPaymentID float64, status string, amount float64, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"cf_payment_id": cfPaymentID, "entity": "payment", "payment_status": status,
+		"payment_amount": amount, "payment_currency": "INR", "payment_message": message, "payment_group": "upi",
+	}
+}
+
+func (f *fakeCashfree) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("x-client-id") != testCashfreeAppID || r.Header.Get("x-client-secret") != testCashfreeSecretKey {
+		writeFakeCashfreeError(w, http.StatusUnauthorized, "authentication_error", "authentication Failed")
+		return
+	}
+	if r.Header.Get("x-api-version") != cashfreeAPIVersion {
+		writeFakeCashfreeError(w, http.StatusBadRequest, "invalid_request_error", "x-api-version is missing in the request")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "orders":
+		f.createOrder(w, r)
+
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "orders":
+		order, ok := f.orders[parts[1]]
+		if !ok {
+			writeFakeCashfreeError(w, http.StatusNotFound, "invalid_request_error", "order not found")
+			return
+		}
+		writeFakeCashfreeJSON(w, order)
+
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "orders" && parts[2] == "payments":
+		if _, ok := f.orders[parts[1]]; !ok {
+			writeFakeCashfreeError(w, http.StatusNotFound, "invalid_request_error", "order not found")
+			return
+		}
+		payments := f.orderPayments[parts[1]]
+		if payments == nil {
+			payments = []map[string]interface{}{}
+		}
+		writeFakeCashfreeJSON(w, payments)
+
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "orders" && parts[2] == "refunds":
+		f.refund(w, r, parts[1])
+
+	default:
+		writeFakeCashfreeError(w, http.StatusNotFound, "invalid_request_error", "requested URL was not found")
+	}
+}
+
+// createOrder creates an active order with a payment session, generating its order ID
+func (f *fakeCashfree) createOrder(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeCashfreeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body")
+		return
+	}
+	customer, _ := body["customer_details"].(map[string]interface{})
+	if phone, _ := customer["customer_phone"].(string); phone == "" {
+		writeFakeCashfreeError(w, http.StatusBadRequest, "invalid_request_error", "customer_details.customer_phone : is missing in the request")
+		return
+	}
+	f.orderRequests = append(f.orderRequests, body)
+
+	orderID := fmt.Sprintf("order_TEST%09d", len(f.orderRequests))
+	order := map[string]interface{}{
+		"cf_order_id": 2149460581 + len(f.orderRequests), "order_id": orderID, "entity": "order",
+		"order_amount": body["order_amount"], "order_currency": body["order_currency"], "order_status": "ACTIVE",
+		"order_note": body["order_note"], "order_tags": body["order_tags"], "customer_details": customer,
+		"payment_session_id": "session_" + orderID,
+	}
+	f.orders[orderID] = order
+	writeFakeCashfreeJSON(w, order)
+}
+
+// refund refunds part of an order's successful payments, refusing more than is left of them. A refund
+// repeating an earlier x-idempotency-key gets the earlier refund back; a refund ID cannot be reused.
+func (f *fakeCashfree) refund(w http.ResponseWriter, r *http.Request, orderID string) {
+	var body struct {
+		RefundAmount float64 `json:"refund_amount"`
+		RefundID     string  `json:"refund_id"`
+		RefundNote   string  `json:"refund_note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeCashfreeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body")
+		return
+	}
+
+	idempotencyKey := r.Header.Get("x-idempotency-key")
+	for _, refund := range f.refunds {
+		if idempotencyKey != "" && refund.IdempotencyKey == idempotencyKey {
+			writeFakeCashfreeRefund(w, refund)
+			return
+		}
+		if refund.RefundID == body.RefundID {
+			writeFakeCashfreeError(w, http.StatusConflict, "invalid_request_error", "refund_id : already exists")
+			return
+		}
+	}
+
+	if _, ok := f.orders[orderID]; !ok {
+		writeFakeCashfreeError(w, http.StatusNotFound, "invalid_request_error", "order not found")
+		return
+	}
+	var paid, alreadyRefunded float64
+	for _, payment := range f.orderPayments[orderID] {
+		if payment["payment_status"] == "SUCCESS" {
+			paid += payment["payment_amount"].(float64)
+		}
+	}
+	for _, refund := range f.refunds {
+		if refund.OrderID == orderID {
+			alreadyRefunded += refund.Amount
+		}
+	}
+	if body.RefundAmount <= 0 || body.RefundAmount > paid-alreadyRefunded {
+		writeFakeCashfreeError(w, http.StatusBadRequest, "invalid_request_error", "refund_amount : should be less than or equal to the refundable amount")
+		return
+	}
+
+	refund := fakeCashfreeRefund{
+		OrderID:        orderID,
+		RefundID:       body.RefundID,
+		Amount:         body.RefundAmount,
+		Note:           body.RefundNote,
+		IdempotencyKey: idempotencyKey,
+	}
+	f.refunds = append(f.refunds, refund)
+	writeFakeCashfreeRefund(w, refund)
+}
+
+// writeFakeCashfreeRefund writes a refund entity; Cashfree refunds stay pending until the bank processes them
+func writeFakeCashfreeRefund(w http.ResponseWriter, refund fakeCashfreeRefund) {
+	writeFakeCashfreeJSON(w, map[string]interface{}{
+		"cf_refund_id": 11325632, "refund_id": refund.RefundID, "order_id": refund.OrderID, "entity": "refund",
+		"refund_amount": refund.Amount, "refund_currency": "INR", "refund_note": refund.Note,
+		"refund_status": "PENDING", "refund_type": "MERCHANT_INITIATED", "refund_mode": "STANDARD",
+	})
+}
+
+// writeFakeCashfreeJSON writes a successful Cashfree API response
+func writeFakeCashfreeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeFakeCashfreeError writes a Cashfree API error response
+func writeFakeCashfreeError(w http.ResponseWriter, status int, errorType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message, "code": "request_failed", "type": errorType,
+	})
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// fakeGatewaySecret signs fake payments and webhooks
+const fakeGatewaySecret = "fake_gateway_secret"
+
+// FakePaymentGateway is an in-memory payment gateway for tests. Instead of a checkout, orders are paid
+// with PayOrder, authorized with AuthorizeOrder or failed with FailOrder. Registering it with
+// RegisterPaymentGateway makes it stand in for the provider it is named after.
+type FakePaymentGateway struct {
+	mu       sync.Mutex
+	name     string
+	sequence int
+	orders   map[string]*fakeGatewayOrder
+	refunds  []GatewayRefundRequest
+}
+
+// fakeGatewayOrder is an order held by the fake gateway
+type fakeGatewayOrder struct {
+	amount   float64
+	receipt  string
+	payments []GatewayPayment
+}
+
+// NewFakePaymentGateway creates a fake gateway with the given provider name
+func NewFakePaymentGateway(name string) *FakePaymentGateway {
+	return &FakePaymentGateway{
+		name:   name,
+		orders: make(map[string]*fakeGatewayOrder),
+	}
+}
+
+// Name returns the provider name the fake gateway was created with
+func (g *FakePaymentGateway) Name() string {
+	return g.name
+}
+
+// CreateOrder creates an order in memory
+func (g *FakePaymentGateway) CreateOrder(req *GatewayOrderRequest) (*GatewayOrder, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	orderID := g.nextID("order")
+	g.orders[orderID] = &fakeGatewayOrder{amount: req.Amount, receipt: req.Receipt}
+	return &GatewayOrder{
+		Provider: g.name,
+		OrderID:  orderID,
+		Checkout: map[string]interface{}{
+			"id":       orderID,
+			"amount":   float64(int64(req.Amount*100 + 0.5)),
+			"currency": "INR",
+			"receipt":  req.Receipt,
+			"provider": g.name,
+		},
+	}, nil
+}
+
+// VerifyPayment checks the signature PayOrder returned and that the payment was captured
+func (g *FakePaymentGateway) VerifyPayment(orderID, paymentID, signature string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !hmac.Equal([]byte(g.Signature(orderID, paymentID)), []byte(signature)) {
+		return false, nil
+	}
+	payment := g.findPayment(orderID, paymentID)
+	return payment != nil && payment.Status == GatewayPaymentCaptured, nil
+}
+
+// CapturePayment captures an authorized payment
+func (g *FakePaymentGateway) CapturePayment(orderID, paymentID string, amount float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment := g.findPayment(orderID, paymentID)
+	if payment == nil {
+		return fmt.Errorf("payment %s not found", paymentID)
+	}
+	if payment.Status != GatewayPaymentAuthorized {
+		return fmt.Errorf("payment %s is %s, not authorized", paymentID, payment.Status)
+	}
+	payment.Status = GatewayPaymentCaptured
+	return nil
+}
+
+// Refund records the refund and reports it processed
+func (g *FakePaymentGateway) Refund(req *GatewayRefundRequest) (*GatewayRefund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refunds = append(g.refunds, *req)
+	return &GatewayRefund{ID: g.nextID("rfnd"), Status: GatewayRefundProcessed}, nil
+}
+
+// VerifyWebhook checks the X-Fake-Signature of a webhook, as produced by SignWebhook
+func (g *FakePaymentGateway) VerifyWebhook(body []byte, headers http.Header) (string, error) {
+	if !hmac.Equal([]byte(g.SignWebhook(body)), []byte(headers.Get("X-Fake-Signature"))) {
+		return "", ErrInvalidWebhookSignature
+	}
+	return headers.Get("X-Fake-Event-Id"), nil
+}
+
+// ParseWebhook reads a fake webhook body, which is a JSON GatewayWebhookEvent using the gateway event
+// types as event names, e.g. {"event": "payment.captured", "order_id": "...", "payment_id": "...", "amount": 100}
+func (g *FakePaymentGateway) ParseWebhook(body []byte) (*GatewayWebhookEvent, error) {
+	var webhookData map[string]interface{}
+	if err := json.Unmarshal(body, &webhookData); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	event := &GatewayWebhookEvent{
+		EventType:     stringField(webhookData, "event"),
+		OrderID:       stringField(webhookData, "order_id"),
+		PaymentID:     stringField(webhookData, "payment_id"),
+		RefundID:      stringField(webhookData, "refund_id"),
+		FailureReason: stringField(webhookData, "reason"),
+		Payload:       webhookData,
+	}
+	if event.EventType == "" {
+		return nil, errors.New("webhook payload has no event type")
+	}
+	event.Type = event.EventType
+	event.Amount, event.HasAmount = webhookData["amount"].(float64)
+	return event, nil
+}
+
+// GetOrderStatus gets an order and its payments from memory
+func (g *FakePaymentGateway) GetOrderStatus(orderID string) (*GatewayOrderStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, ok := g.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("order %s not found", orderID)
+	}
+
+	status := &GatewayOrderStatus{OrderID: orderID, Status: GatewayOrderCreated}
+	status.Payments = append(status.Payments, order.payments...)
+	for _, payment := range order.payments {
+		status.Status = GatewayOrderAttempted
+		if payment.Status == GatewayPaymentCaptured {
+			status.Status = GatewayOrderPaid
+			break
+		}
+	}
+	return status, nil
+}
+
+// PayOrder makes a captured payment of the full amount against an order, returning the payment ID and
+// the signature a checkout would hand the client
+func (g *FakePaymentGateway) PayOrder(orderID string) (string, string, error) {
+	paymentID, err := g.addPayment(orderID, GatewayPaymentCaptured, "")
+	if err != nil {
+		return "", "", err
+	}
+	return paymentID, g.Signature(orderID, paymentID), nil
+}
+
+// AuthorizeOrder makes an authorized payment against an order that still has to be captured
+func (g *FakePaymentGateway) AuthorizeOrder(orderID string) (string, error) {
+	return g.addPayment(orderID, GatewayPaymentAuthorized, "")
+}
+
+// FailOrder makes a failed payment attempt against an order
+func (g *FakePaymentGateway) FailOrder(orderID string, reason string) (string, error) {
+	return g.addPayment(orderID, GatewayPaymentFailed, reason)
+}
+
+// Refunds returns the refunds issued so far
+func (g *FakePaymentGateway) Refunds() []GatewayRefundRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]GatewayRefundRequest(nil), g.refunds...)
+}
+
+// Signature returns the payment signature of a payment against an order
+func (g *FakePaymentGateway) Signature(orderID, paymentID string) string {
+	return g.SignWebhook([]byte(orderID + "|" + paymentID))
+}
+
+// SignWebhook returns the X-Fake-Signature value for a webhook body
+func (g *FakePaymentGateway) SignWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(fakeGatewaySecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// addPayment adds a payment attempt of the order's amount
+func (g *FakePaymentGateway) addPayment(orderID string, status string, reason string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, ok := g.orders[orderID]
+	if !ok {
+		return "", fmt.Errorf("order %s not found", orderID)
+	}
+	paymentID := g.nextID("pay")
+	order.payments = append(order.payments, GatewayPayment{ID: paymentID, Status: status, Amount: order.amount, FailureReason: reason})
+	return paymentID, nil
+}
+
+// findPayment finds a payment attempt of an order
+func (g *FakePaymentGateway) findPayment(orderID, paymentID string) *GatewayPayment {
+	order, ok := g.orders[orderID]
+	if !ok {
+		return nil
+	}
+	for i := range order.payments {
+		if order.payments[i].ID == paymentID {
+			return &order.payments[i]
+		}
+	}
+	return nil
+}
+
+// nextID returns a new ID with the prefix
+func (g *FakePaymentGateway) nextID(prefix string) string {
+	g.sequence++
+	return fmt.Sprintf("%s_fake_%d", prefix, g.sequence)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testRazorpayKeyID     = "rzp_test_key"
+	testRazorpayKeySecret = "rzp_test_secret"
+)
+
+// fakeRazorpay is a local stand-in for the Razorpay REST API serving the orders, payments and
+// settlement reports a test sets up, and issuing refunds against captured payments
+type fakeRazorpay struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	orders        map[string]string                   // Order ID to status
+	orderPayments map[string][]map[string]interface{} // Order ID to payment attempts
+	settlements   map[string][]map[string]interface{} // Day (2006-01-02) to settlement recon items
+	captured      map[string]float64                  // Captured payment ID to amount in paise
+	refunds       []fakeRazorpayRefund                // Refunds issued, in order
+}
+
+// fakeRazorpayRefund is a refund request the fake API accepted
+type fakeRazorpayRefund struct {
+	ID             string
+	PaymentID      string
+	Amount         float64 // Paise
+	Speed          string
+	Notes          map[string]string
+	IdempotencyKey string
+}
+
+// newFakeRazorpay starts a fake Razorpay API that is shut down when the test ends
+func newFakeRazorpay(t *testing.T) *fakeRazorpay {
+	t.Helper()
+	fake := &fakeRazorpay{
+		orders:        map[string]string{},
+		orderPayments: map[string][]map[string]interface{}{},
+		settlements:   map[string][]map[string]interface{}{},
+		captured:      map[string]float64{},
+	}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// service returns a Razorpay service pointed at the fake API
+func (f *fakeRazorpay) service() *RazorpayService {
+	return NewRazorpayServiceWithConfig(testRazorpayKeyID, testRazorpayKeySecret, f.server.URL)
+}
+
+// addOrder sets up an order and its payment attempts
+func (f *fakeRazorpay) addOrder(orderID, status string, payments ...map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[orderID] = status
+	f.orderPayments[orderID] = payments
+}
+
+// addCapturedPayment sets up a captured payment that can be refunded, amount in rupees
+func (f *fakeRazorpay) addCapturedPayment(paymentID string, amount float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.captured[paymentID] = amount * 100
+}
+
+// issuedRefunds returns the refunds issued so far
+func (f *fakeRazorpay) issuedRefunds() []fakeRazorpayRefund {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRazorpayRefund(nil), f.refunds...)
+}
+
+// addSettlement sets up an item of a day's settlement recon report
+func (f *fakeRazorpay) addSettlement(day string, item map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.settlements[day] = append(f.settlements[day], item)
+}
+
+func (f *fakeRazorpay) serve(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != testRazorpayKeyID || pass != testRazorpayKeySecret {
+		writeFakeRazorpayError(w, http.StatusUnauthorized, "Authentication failed")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case r.Method == http.MethodGet && path == "settlements/recon/combined":
+		query := r.URL.Query()
+		day := query.Get("year") + "-" + query.Get("month") + "-" + query.Get("day")
+		items := f.settlements[day]
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		writeFakeRazorpayJSON(w, map[string]interface{}{"entity": "collection", "count": len(items), "items": items})
+
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "orders":
+		status, ok := f.orders[parts[1]]
+		if !ok {
+			writeFakeRazorpayError(w, http.StatusBadRequest, "The id provided does not exist")
+			return
+		}
+		writeFakeRazorpayJSON(w, map[string]interface{}{"id": parts[1], "entity": "order", "status": status})
+
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "orders" && parts[2] == "payments":
+		payments := f.orderPayments[parts[1]]
+		if payments == nil {
+			payments = []map[string]interface{}{}
+		}
+		writeFakeRazorpayJSON(w, map[string]interface{}{"entity": "collection", "count": len(payments), "items": payments})
+
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "payments" && parts[2] == "refund":
+		f.refund(w, r, parts[1])
+
+	default:
+		writeFakeRazorpayError(w, http.StatusNotFound, "The requested URL was not found on the server")
+	}
+}
+
+// refund refunds part of a captured payment, refusing more than is left of it. A refund repeating an
+// earlier X-Refund-Idempotency key gets the earlier refund back.
+func (f *fakeRazorpay) refund(w http.ResponseWriter, r *http.Request, paymentID string) {
+	var body struct {
+		Amount float64           `json:"amount"`
+		Speed  string            `json:"speed"`
+		Notes  map[string]string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeRazorpayError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	idempotencyKey := r.Header.Get("X-Refund-Idempotency")
+	if idempotencyKey != "" {
+		for _, refund := range f.refunds {
+			if refund.IdempotencyKey == idempotencyKey {
+				writeFakeRazorpayRefund(w, refund)
+				return
+			}
+		}
+	}
+
+	captured, ok := f.captured[paymentID]
+	if !ok {
+		writeFakeRazorpayError(w, http.StatusBadRequest, "The payment has not been captured")
+		return
+	}
+	var alreadyRefunded float64
+	for _, refund := range f.refunds {
+		if refund.PaymentID == paymentID {
+			alreadyRefunded += refund.Amount
+		}
+	}
+	if body.Amount <= 0 || body.Amount > captured-alreadyRefunded {
+		writeFakeRazorpayError(w, http.StatusBadRequest, "The refund amount provided is greater than amount captured")
+		return
+	}
+
+	refund := fakeRazorpayRefund{
+		ID:             fmt.Sprintf("rfnd_test_%d", len(f.refunds)+1),
+		PaymentID:      paymentID,
+		Amount:         body.Amount,
+		Speed:          body.Speed,
+		Notes:          body.Notes,
+		IdempotencyKey: idempotencyKey,
+	}
+	f.refunds = append(f.refunds, refund)
+	writeFakeRazorpayRefund(w, refund)
+}
+
+// writeFakeRazorpayRefund writes a refund entity
+func writeFakeRazorpayRefund(w http.ResponseWriter, refund fakeRazorpayRefund) {
+	writeFakeRazorpayJSON(w, map[string]interface{}{
+		"id": refund.ID, "entity": "refund", "payment_id": refund.PaymentID,
+		"amount": refund.Amount, "currency": "INR", "notes": refund.Notes, "status": GatewayRefundProcessed,
+	})
+}
+
+// writeFakeRazorpayJSON writes a successful Razorpay API response
+func writeFakeRazorpayJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeFakeRazorpayError writes a Razorpay API error response
+func writeFakeRazorpayError(w http.ResponseWriter, status int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"code": "BAD_REQUEST_ERROR", "description": description},
+	})
+}
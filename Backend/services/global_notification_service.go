@@ -1,13 +1,17 @@
 package services
 
 import (
+	"fmt"
 	"sync"
 	"treesindia/models"
+
+	"github.com/sirupsen/logrus"
 )
 
 // Global notification integration service instance
 var (
 	globalNotificationIntegrationService *NotificationIntegrationService
+	globalPushNotificationService        *EnhancedNotificationService
 	globalNotificationMutex              sync.RWMutex
 )
 
@@ -25,6 +29,51 @@ func GetGlobalNotificationIntegrationService() *NotificationIntegrationService {
 	return globalNotificationIntegrationService
 }
 
+// SetGlobalPushNotificationService sets the global push notification service
+func SetGlobalPushNotificationService(service *EnhancedNotificationService) {
+	globalNotificationMutex.Lock()
+	defer globalNotificationMutex.Unlock()
+	globalPushNotificationService = service
+}
+
+// GetGlobalPushNotificationService returns the global push notification service
+func GetGlobalPushNotificationService() *EnhancedNotificationService {
+	globalNotificationMutex.RLock()
+	defer globalNotificationMutex.RUnlock()
+	return globalPushNotificationService
+}
+
+// SendPushNotification is a global helper function to send an FCM push notification to a user's devices
+func SendPushNotification(userID uint, notificationType models.NotificationType, title, body string, data map[string]interface{}) {
+	service := GetGlobalPushNotificationService()
+	if service == nil {
+		return
+	}
+
+	pushData := make(map[string]string, len(data))
+	for key, value := range data {
+		if value != nil {
+			pushData[key] = fmt.Sprint(value)
+		}
+	}
+
+	result, err := service.SendNotification(&NotificationRequest{
+		UserID:   userID,
+		Type:     notificationType,
+		Title:    title,
+		Body:     body,
+		Data:     pushData,
+		Priority: "high",
+	})
+	if err != nil {
+		logrus.Warnf("Failed to send push notification to user %d: %v", userID, err)
+		return
+	}
+	if result.PushError != "" {
+		logrus.Debugf("Push notification to user %d not delivered: %s", userID, result.PushError)
+	}
+}
+
 // NotifyUserRegistration is a global helper function to notify about user registration
 func NotifyUserRegistration(user *models.User) {
 	service := GetGlobalNotificationIntegrationService()
@@ -384,3 +433,143 @@ func NotifyProjectCreated(project *models.Project, user *models.User) {
 func NotifyConversationStarted(userID uint, otherUserName string, bookingID uint) {
 	// Conversation notifications not implemented
 }
+
+// NotifyDisputeOpened notifies admins and the other party about a new booking dispute
+func NotifyDisputeOpened(dispute *models.BookingDispute, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyDisputeOpened(dispute, booking)
+}
+
+// NotifyDisputeUpdated notifies both parties about an admin update on a dispute
+func NotifyDisputeUpdated(dispute *models.BookingDispute, booking *models.Booking, comment string) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyDisputeUpdated(dispute, booking, comment)
+}
+
+// NotifyDisputeResolved notifies both parties about the outcome of a dispute
+func NotifyDisputeResolved(dispute *models.BookingDispute, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyDisputeResolved(dispute, booking)
+}
+
+// NotifyRecurringBookingCreated tells the customer an occurrence of their booking series was booked
+func NotifyRecurringBookingCreated(series *models.BookingSeries, booking *models.Booking, amount float64) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyRecurringBookingCreated(series, booking, amount)
+}
+
+// NotifyRecurringBookingFailed tells the customer an occurrence of their booking series could not be booked
+func NotifyRecurringBookingFailed(series *models.BookingSeries, occurrence *models.BookingSeriesOccurrence) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyRecurringBookingFailed(series, occurrence)
+}
+
+// NotifyRescheduleRequested asks the assigned worker to accept a new booking time
+func NotifyRescheduleRequested(request *models.BookingRescheduleRequest, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyRescheduleRequested(request, booking)
+}
+
+// NotifyBookingRescheduled tells the customer and the assigned worker that a booking moved
+func NotifyBookingRescheduled(request *models.BookingRescheduleRequest, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyBookingRescheduled(request, booking)
+}
+
+// NotifyRescheduleDeclined tells whoever asked for a new time that the worker declined it
+func NotifyRescheduleDeclined(request *models.BookingRescheduleRequest, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyRescheduleDeclined(request, booking)
+}
+
+// NotifySegmentPaymentReminder reminds the customer that a payment segment falls due soon
+func NotifySegmentPaymentReminder(segment *models.PaymentSegment, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifySegmentPaymentReminder(segment, booking)
+}
+
+// NotifySegmentPaymentOverdue tells the customer a payment segment is past its due date
+func NotifySegmentPaymentOverdue(segment *models.PaymentSegment, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifySegmentPaymentOverdue(segment, booking)
+}
+
+// NotifySegmentPaymentEscalated tells admins a payment segment stayed overdue
+func NotifySegmentPaymentEscalated(segment *models.PaymentSegment, booking *models.Booking, action models.SegmentEscalationAction) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifySegmentPaymentEscalated(segment, booking, action)
+}
+
+// NotifySegmentAutoDebit tells the customer whether a due payment segment was paid from their wallet
+func NotifySegmentAutoDebit(segment *models.PaymentSegment, booking *models.Booking, failure string) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifySegmentAutoDebit(segment, booking, failure)
+}
+
+// NotifyAssignmentPaused tells the worker an assignment is on hold for overdue payment
+func NotifyAssignmentPaused(assignment *models.WorkerAssignment, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyAssignmentPaused(assignment, booking)
+}
+
+// NotifyAssignmentResumed tells the worker a paused assignment can continue
+func NotifyAssignmentResumed(assignment *models.WorkerAssignment, booking *models.Booking) {
+	notificationService := GetGlobalNotificationIntegrationService()
+	if notificationService == nil {
+		return
+	}
+	
+	notificationService.NotifyAssignmentResumed(assignment, booking)
+}
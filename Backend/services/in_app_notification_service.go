@@ -279,6 +279,29 @@ func (ns *InAppNotificationService) CleanupOldNotifications(days int) error {
 	return nil
 }
 
+// HandleRealtimeMessage handles the events a user publishes to their notifications realtime topic:
+// mark_read with a notification_id, and mark_all_read
+func (ns *InAppNotificationService) HandleRealtimeMessage(conn *RealtimeConnection, userID uint, envelope *RealtimeEnvelope) (map[string]interface{}, error) {
+	switch envelope.Event {
+	case "mark_read":
+		notificationID, ok := envelope.Data["notification_id"].(float64)
+		if !ok || notificationID <= 0 {
+			return nil, errors.New("notification_id is required")
+		}
+		if err := ns.MarkNotificationAsRead(uint(notificationID), userID); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"notification_id": uint(notificationID)}, nil
+
+	case "mark_all_read":
+		if err := ns.MarkAllNotificationsAsRead(userID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown notifications event %q", envelope.Event)
+}
+
 // sendRealTimeNotification sends notification via WebSocket
 func (ns *InAppNotificationService) sendRealTimeNotification(notification *models.InAppNotification) {
 	if ns.wsService == nil {
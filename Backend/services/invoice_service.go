@@ -0,0 +1,667 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errNotInvoiceable is returned for payments that get no billing document
+var errNotInvoiceable = errors.New("no invoice is issued for this payment")
+
+// invoiceablePaymentTypes are the payment types that get a billing document
+var invoiceablePaymentTypes = []models.PaymentType{
+	models.PaymentTypeBooking,
+	models.PaymentTypeQuote,
+	models.PaymentTypeSegmentPay,
+	models.PaymentTypeSubscription,
+	models.PaymentTypeWalletDebit,
+	models.PaymentTypeWalletRecharge,
+	models.PaymentTypeRefund,
+}
+
+// nonSalePaymentMethods are wallet credits that are not sales: admin adjustments and promotional credits
+var nonSalePaymentMethods = []string{"admin", "promotion"}
+
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+// InvoiceService issues GST tax invoices, credit notes and receipts for completed payments
+type InvoiceService struct {
+	invoiceRepo        *repositories.InvoiceRepository
+	paymentRepo        *repositories.PaymentRepository
+	bookingRepo        *repositories.BookingRepository
+	serviceRepo        *repositories.ServiceRepository
+	addressRepo        *repositories.AddressRepository
+	adminConfigService *AdminConfigService
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService() *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo:        repositories.NewInvoiceRepository(),
+		paymentRepo:        repositories.NewPaymentRepository(),
+		bookingRepo:        repositories.NewBookingRepository(),
+		serviceRepo:        repositories.NewServiceRepository(),
+		addressRepo:        repositories.NewAddressRepository(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// IssueForPayment issues the billing document of a completed payment: a tax invoice for services
+// and subscriptions, a receipt for wallet recharges and a credit note for refunds. A payment gets
+// one document; issuing again returns the existing one.
+func (is *InvoiceService) IssueForPayment(payment *models.Payment) (*models.Invoice, error) {
+	if existing, err := is.invoiceRepo.GetByPaymentID(payment.ID); err == nil {
+		return existing, nil
+	}
+
+	if payment.Status != models.PaymentStatusCompleted && payment.Status != models.PaymentStatusPartiallyRefunded && payment.Status != models.PaymentStatusRefunded {
+		return nil, errors.New("invoices are only issued for completed payments")
+	}
+	if payment.User.ID == 0 {
+		fullPayment, err := is.paymentRepo.GetByID(payment.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment: %v", err)
+		}
+		payment = fullPayment
+	}
+
+	issuedAt := time.Now()
+	if payment.CompletedAt != nil {
+		issuedAt = *payment.CompletedAt
+	}
+	financialYear, _ := invoiceFinancialYear(issuedAt)
+
+	invoice := &models.Invoice{
+		FinancialYear:    financialYear,
+		IssuedAt:         issuedAt,
+		PaymentID:        payment.ID,
+		PaymentReference: payment.PaymentReference,
+		PaymentMethod:    invoicePaymentMethod(payment),
+		UserID:           payment.UserID,
+		Description:      payment.Description,
+		SellerName:       is.adminConfigService.GetCompanyLegalName(),
+		SellerGSTIN:      is.adminConfigService.GetCompanyGSTIN(),
+		SellerAddress:    is.adminConfigService.GetCompanyAddress(),
+		SellerState:      is.adminConfigService.GetCompanyState(),
+		TotalAmount:      roundCurrency(payment.Amount),
+	}
+
+	var booking *models.Booking
+	if (payment.RelatedEntityType == "booking" || payment.RelatedEntityType == "inquiry_booking") && payment.RelatedEntityID != 0 {
+		if found, err := is.bookingRepo.GetByID(payment.RelatedEntityID); err == nil {
+			booking = found
+			invoice.BookingID = &booking.ID
+		}
+	}
+	is.setCustomer(invoice, payment, booking)
+
+	switch {
+	case payment.Type == models.PaymentTypeRefund:
+		original, err := is.originalInvoice(payment)
+		if err != nil {
+			return nil, err
+		}
+		invoice.DocumentType = models.InvoiceDocumentCreditNote
+		invoice.OriginalInvoiceID = &original.ID
+		invoice.Description = fmt.Sprintf("Refund against invoice %s", original.InvoiceNumber)
+		invoice.SacCode = original.SacCode
+		invoice.GSTRate = original.GSTRate
+		invoice.PlaceOfSupply = original.PlaceOfSupply
+		invoice.IsInterState = original.IsInterState
+		if invoice.TotalAmount > original.TotalAmount {
+			invoice.TotalAmount = original.TotalAmount
+		}
+
+	case containsString(nonSalePaymentMethods, payment.Method):
+		return nil, errNotInvoiceable
+
+	case payment.Type == models.PaymentTypeWalletRecharge:
+		invoice.DocumentType = models.InvoiceDocumentReceipt
+		if invoice.Description == "" {
+			invoice.Description = "Wallet recharge"
+		}
+
+	case containsPaymentType(invoiceablePaymentTypes, payment.Type):
+		invoice.DocumentType = models.InvoiceDocumentTaxInvoice
+		invoice.SacCode, invoice.GSTRate = is.taxCodeFor(payment, booking)
+		if booking != nil {
+			invoice.Description = fmt.Sprintf("%s (booking %s)", booking.Service.Name, booking.BookingReference)
+		}
+
+	default:
+		return nil, errNotInvoiceable
+	}
+
+	// Place of supply and the GST split (payment amounts are GST inclusive)
+	if invoice.DocumentType != models.InvoiceDocumentCreditNote {
+		invoice.PlaceOfSupply = invoice.CustomerState
+		if invoice.PlaceOfSupply == "" {
+			invoice.PlaceOfSupply = invoice.SellerState
+		}
+		invoice.IsInterState = !strings.EqualFold(strings.TrimSpace(invoice.PlaceOfSupply), strings.TrimSpace(invoice.SellerState))
+	}
+	invoice.TaxableAmount, invoice.CGSTAmount, invoice.SGSTAmount, invoice.IGSTAmount = splitGST(invoice.TotalAmount, invoice.GSTRate, invoice.IsInterState)
+
+	err := is.invoiceRepo.Create(invoice, func(sequence int) string {
+		return formatInvoiceNumber(invoice.DocumentType, issuedAt, sequence)
+	})
+	if err != nil {
+		// Another request may have issued the document for this payment first
+		if existing, getErr := is.invoiceRepo.GetByPaymentID(payment.ID); getErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to create invoice: %v", err)
+	}
+
+	logrus.Infof("Issued %s %s for payment %d", invoice.DocumentType, invoice.InvoiceNumber, payment.ID)
+	return invoice, nil
+}
+
+// GetOrIssueForPayment gets the billing document of one of the user's payments, issuing it if needed
+func (is *InvoiceService) GetOrIssueForPayment(userID uint, paymentID uint) (*models.Invoice, error) {
+	payment, err := is.paymentRepo.GetByID(paymentID)
+	if err != nil || payment.UserID != userID {
+		return nil, errors.New("payment not found")
+	}
+	return is.IssueForPayment(payment)
+}
+
+// GetInvoice gets an invoice by ID
+func (is *InvoiceService) GetInvoice(id uint) (*models.Invoice, error) {
+	invoice, err := is.invoiceRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invoice not found")
+		}
+		return nil, fmt.Errorf("failed to get invoice: %v", err)
+	}
+	return invoice, nil
+}
+
+// GetUserInvoice gets one of the user's invoices
+func (is *InvoiceService) GetUserInvoice(userID uint, id uint) (*models.Invoice, error) {
+	invoice, err := is.GetInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.UserID != userID {
+		return nil, errors.New("invoice not found")
+	}
+	return invoice, nil
+}
+
+// GetInvoices gets invoices with filters
+func (is *InvoiceService) GetInvoices(filters *models.InvoiceFilters) ([]models.Invoice, *repositories.Pagination, error) {
+	return is.invoiceRepo.GetInvoices(filters)
+}
+
+// RenderPDF renders an invoice as a PDF and returns it with its file name
+func (is *InvoiceService) RenderPDF(invoice *models.Invoice) ([]byte, string) {
+	filename := strings.ReplaceAll(invoice.InvoiceNumber, "/", "-") + ".pdf"
+	return renderInvoicePDF(invoice, is.adminConfigService.GetSupportEmail()), filename
+}
+
+// GetInvoiceRegister builds the CSV register of every document issued in a month (YYYY-MM, IST)
+func (is *InvoiceService) GetInvoiceRegister(month string) ([]byte, string, error) {
+	start, err := time.ParseInLocation("2006-01", month, workerCalendarLocation())
+	if err != nil {
+		return nil, "", errors.New("month must be in YYYY-MM format")
+	}
+
+	invoices, err := is.invoiceRepo.GetIssuedBetween(start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get invoices: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Document Type", "Document Number", "Date", "Customer Name", "Customer GSTIN", "Place of Supply",
+		"Original Invoice", "SAC Code", "GST Rate", "Taxable Value", "CGST", "SGST", "IGST", "Total", "Payment Reference"})
+	for _, invoice := range invoices {
+		originalNumber := ""
+		if invoice.OriginalInvoice != nil {
+			originalNumber = invoice.OriginalInvoice.InvoiceNumber
+		}
+		writer.Write([]string{
+			invoiceDocumentTitle(invoice.DocumentType),
+			invoice.InvoiceNumber,
+			invoice.IssuedAt.In(workerCalendarLocation()).Format("2006-01-02"),
+			invoice.CustomerName,
+			invoice.CustomerGSTIN,
+			invoice.PlaceOfSupply,
+			originalNumber,
+			invoice.SacCode,
+			strconv.FormatFloat(invoice.GSTRate, 'f', -1, 64),
+			fmt.Sprintf("%.2f", invoice.TaxableAmount),
+			fmt.Sprintf("%.2f", invoice.CGSTAmount),
+			fmt.Sprintf("%.2f", invoice.SGSTAmount),
+			fmt.Sprintf("%.2f", invoice.IGSTAmount),
+			fmt.Sprintf("%.2f", invoice.TotalAmount),
+			invoice.PaymentReference,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to write invoice register: %v", err)
+	}
+
+	return buf.Bytes(), fmt.Sprintf("invoice_register_%s.csv", month), nil
+}
+
+// GetBillingDetails gets the user's billing details; users without any get empty details
+func (is *InvoiceService) GetBillingDetails(userID uint) (*models.BillingDetails, error) {
+	details, err := is.invoiceRepo.GetBillingDetails(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.BillingDetails{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("failed to get billing details: %v", err)
+	}
+	return details, nil
+}
+
+// UpdateBillingDetails sets the business name and GSTIN printed on the user's future invoices
+func (is *InvoiceService) UpdateBillingDetails(userID uint, req *models.UpdateBillingDetailsRequest) (*models.BillingDetails, error) {
+	gstin := strings.ToUpper(strings.TrimSpace(req.GSTIN))
+	if gstin != "" && !gstinPattern.MatchString(gstin) {
+		return nil, errors.New("invalid GSTIN")
+	}
+
+	details, err := is.GetBillingDetails(userID)
+	if err != nil {
+		return nil, err
+	}
+	details.BusinessName = strings.TrimSpace(req.BusinessName)
+	details.GSTIN = gstin
+	details.BillingAddress = strings.TrimSpace(req.BillingAddress)
+	details.State = strings.TrimSpace(req.State)
+
+	if err := is.invoiceRepo.SaveBillingDetails(details); err != nil {
+		return nil, fmt.Errorf("failed to save billing details: %v", err)
+	}
+	return details, nil
+}
+
+// GetTaxCodes gets the SAC codes and GST rates of categories
+func (is *InvoiceService) GetTaxCodes() ([]models.CategoryTaxCode, error) {
+	return is.invoiceRepo.GetTaxCodes()
+}
+
+// SetTaxCode sets the SAC code and GST rate printed on invoices for services of a category
+func (is *InvoiceService) SetTaxCode(categoryID uint, req *models.UpdateCategoryTaxCodeRequest) (*models.CategoryTaxCode, error) {
+	var category models.Category
+	if err := repositories.NewCategoryRepository().FindByID(&category, categoryID); err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	taxCode := &models.CategoryTaxCode{
+		CategoryID: categoryID,
+		SacCode:    strings.TrimSpace(req.SacCode),
+		GSTRate:    req.GSTRate,
+	}
+	if err := is.invoiceRepo.SaveTaxCode(taxCode); err != nil {
+		return nil, fmt.Errorf("failed to save tax code: %v", err)
+	}
+	return is.invoiceRepo.GetTaxCodeByCategoryID(categoryID)
+}
+
+// IssuePendingInvoices issues documents for recently completed payments that have none yet
+func (is *InvoiceService) IssuePendingInvoices() (int, error) {
+	payments, err := is.invoiceRepo.GetPaymentsWithoutInvoice(invoiceablePaymentTypes, nonSalePaymentMethods, time.Now().AddDate(0, 0, -7), 200)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get payments without invoice: %v", err)
+	}
+
+	issued := 0
+	for i := range payments {
+		if _, err := is.IssueForPayment(&payments[i]); err != nil {
+			if !errors.Is(err, errNotInvoiceable) {
+				logrus.Errorf("Failed to issue invoice for payment %d: %v", payments[i].ID, err)
+			}
+			continue
+		}
+		issued++
+	}
+	return issued, nil
+}
+
+// StartInvoiceJob starts a periodic job that issues documents for completed payments
+func (is *InvoiceService) StartInvoiceJob() {
+	ticker := time.NewTicker(15 * time.Minute) // Run every 15 minutes
+	go func() {
+		for range ticker.C {
+			issued, err := is.IssuePendingInvoices()
+			if err != nil {
+				logrus.Errorf("Invoice job failed: %v", err)
+				continue
+			}
+			if issued > 0 {
+				logrus.Infof("Invoice job issued %d documents", issued)
+			}
+		}
+	}()
+	logrus.Info("Invoice job started")
+}
+
+// setCustomer copies the customer's name, contact, GSTIN and address onto the invoice. The
+// customer state is the booking address state for services at a property, then the billing
+// details state, then the state of the user's default address.
+func (is *InvoiceService) setCustomer(invoice *models.Invoice, payment *models.Payment, booking *models.Booking) {
+	invoice.CustomerName = payment.User.Name
+	invoice.CustomerPhone = payment.User.Phone
+	if payment.User.Email != nil {
+		invoice.CustomerEmail = *payment.User.Email
+	}
+
+	if booking != nil && booking.Address != nil {
+		var address models.BookingAddress
+		if err := json.Unmarshal([]byte(*booking.Address), &address); err == nil {
+			invoice.CustomerAddress = joinAddress(address.HouseNumber, address.Address, address.City, address.State, address.PostalCode)
+			invoice.CustomerState = address.State
+		}
+	}
+
+	if details, err := is.invoiceRepo.GetBillingDetails(payment.UserID); err == nil {
+		if details.BusinessName != "" {
+			invoice.CustomerName = details.BusinessName
+		}
+		invoice.CustomerGSTIN = details.GSTIN
+		if details.BillingAddress != "" {
+			invoice.CustomerAddress = details.BillingAddress
+		}
+		if invoice.CustomerState == "" {
+			invoice.CustomerState = details.State
+		}
+	}
+
+	if invoice.CustomerAddress == "" || invoice.CustomerState == "" {
+		var address models.Address
+		if err := is.addressRepo.FindDefaultAddressByUserID(&address, payment.UserID); err == nil {
+			if invoice.CustomerAddress == "" {
+				invoice.CustomerAddress = joinAddress(address.HouseNumber, address.Address, address.City, address.State, address.PostalCode)
+			}
+			if invoice.CustomerState == "" {
+				invoice.CustomerState = address.State
+			}
+		}
+	}
+}
+
+// taxCodeFor finds the SAC code and GST rate of a payment: the subscription SAC code for
+// subscriptions, the category tax code for services, or the defaults
+func (is *InvoiceService) taxCodeFor(payment *models.Payment, booking *models.Booking) (string, float64) {
+	defaultRate := is.adminConfigService.GetDefaultGSTRate()
+	if payment.RelatedEntityType == "subscription" || payment.Type == models.PaymentTypeSubscription {
+		return is.adminConfigService.GetSubscriptionSACCode(), defaultRate
+	}
+
+	var categoryID uint
+	if booking != nil {
+		categoryID = booking.Service.CategoryID
+	} else if payment.RelatedEntityType == "service" && payment.RelatedEntityID != 0 {
+		if service, err := is.serviceRepo.GetByID(payment.RelatedEntityID); err == nil {
+			categoryID = service.CategoryID
+		}
+	}
+	if categoryID != 0 {
+		if taxCode, err := is.invoiceRepo.GetTaxCodeByCategoryID(categoryID); err == nil {
+			return taxCode.SacCode, taxCode.GSTRate
+		}
+	}
+	return is.adminConfigService.GetDefaultSACCode(), defaultRate
+}
+
+// originalInvoice gets the tax invoice a refund is credited against, issuing it if needed
+func (is *InvoiceService) originalInvoice(refund *models.Payment) (*models.Invoice, error) {
+	if refund.Metadata == nil {
+		return nil, errNotInvoiceable
+	}
+
+	var originalPaymentID uint
+	switch value := (*refund.Metadata)["original_payment_id"].(type) {
+	case float64:
+		originalPaymentID = uint(value)
+	case uint:
+		originalPaymentID = value
+	}
+	if originalPaymentID == 0 {
+		return nil, errNotInvoiceable
+	}
+
+	originalPayment, err := is.paymentRepo.GetByID(originalPaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunded payment: %v", err)
+	}
+	original, err := is.IssueForPayment(originalPayment)
+	if err != nil {
+		return nil, err
+	}
+	if original.DocumentType != models.InvoiceDocumentTaxInvoice {
+		// Refunds of wallet recharges return an advance, not a sale
+		return nil, errNotInvoiceable
+	}
+	return original, nil
+}
+
+// splitGST splits a GST inclusive total into the taxable value and CGST/SGST or IGST
+func splitGST(total float64, rate float64, interState bool) (taxable, cgst, sgst, igst float64) {
+	taxable = roundCurrency(total * 100 / (100 + rate))
+	tax := roundCurrency(total - taxable)
+	if interState {
+		return taxable, 0, 0, tax
+	}
+	cgst = roundCurrency(tax / 2)
+	return taxable, cgst, roundCurrency(tax - cgst), 0
+}
+
+// invoiceFinancialYear returns the Indian financial year (April to March) of a date, as a label
+// like "2026-27" and a short code like "2627"
+func invoiceFinancialYear(date time.Time) (string, string) {
+	date = date.In(workerCalendarLocation())
+	startYear := date.Year()
+	if date.Month() < time.April {
+		startYear--
+	}
+	return fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100), fmt.Sprintf("%02d%02d", startYear%100, (startYear+1)%100)
+}
+
+// invoicePaymentMethod names how an invoiced payment was made, naming the gateway of online payments
+func invoicePaymentMethod(payment *models.Payment) string {
+	if payment.Method == models.PaymentMethodOnline && payment.GatewayProvider != "" {
+		return payment.GatewayProvider
+	}
+	return payment.Method
+}
+
+// formatInvoiceNumber formats a document number like "TI/2627/000001"; GST allows at most 16 characters
+func formatInvoiceNumber(documentType models.InvoiceDocumentType, issuedAt time.Time, sequence int) string {
+	prefix := "TI"
+	switch documentType {
+	case models.InvoiceDocumentCreditNote:
+		prefix = "CN"
+	case models.InvoiceDocumentReceipt:
+		prefix = "RV"
+	}
+	_, yearCode := invoiceFinancialYear(issuedAt)
+	return fmt.Sprintf("%s/%s/%06d", prefix, yearCode, sequence)
+}
+
+func invoiceDocumentTitle(documentType models.InvoiceDocumentType) string {
+	switch documentType {
+	case models.InvoiceDocumentCreditNote:
+		return "Credit Note"
+	case models.InvoiceDocumentReceipt:
+		return "Receipt"
+	default:
+		return "Tax Invoice"
+	}
+}
+
+func containsPaymentType(types []models.PaymentType, paymentType models.PaymentType) bool {
+	for _, t := range types {
+		if t == paymentType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinAddress(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
+
+// renderInvoicePDF lays out a billing document on one A4 page
+func renderInvoicePDF(invoice *models.Invoice, supportEmail string) []byte {
+	const left, right = 40.0, utils.PDFPageWidth - 40
+	doc := utils.NewPDFDocument()
+	amount := func(value float64) string { return fmt.Sprintf("Rs. %.2f", value) }
+
+	// Header
+	doc.Text(left, 60, 18, true, strings.ToUpper(invoiceDocumentTitle(invoice.DocumentType)))
+	doc.Text(left, 84, 11, true, invoice.SellerName)
+	y := 98.0
+	for _, line := range utils.WrapPDFText(invoice.SellerAddress, 9, false, 260) {
+		doc.Text(left, y, 9, false, line)
+		y += 12
+	}
+	if invoice.SellerGSTIN != "" {
+		doc.Text(left, y, 9, false, "GSTIN: "+invoice.SellerGSTIN)
+		y += 12
+	}
+	doc.Text(left, y, 9, false, "State: "+invoice.SellerState)
+
+	details := [][2]string{
+		{"Number", invoice.InvoiceNumber},
+		{"Date", invoice.IssuedAt.In(workerCalendarLocation()).Format("02 Jan 2006")},
+		{"Payment", invoice.PaymentReference},
+	}
+	if invoice.OriginalInvoice != nil {
+		details = append(details, [2]string{"Against invoice", invoice.OriginalInvoice.InvoiceNumber})
+	}
+	if invoice.DocumentType != models.InvoiceDocumentReceipt {
+		details = append(details, [2]string{"Place of supply", invoice.PlaceOfSupply})
+	}
+	detailY := 84.0
+	for _, detail := range details {
+		doc.Text(340, detailY, 9, true, detail[0])
+		doc.TextRight(right, detailY, 9, false, detail[1])
+		detailY += 14
+	}
+
+	// Customer
+	y = 190
+	doc.Line(left, y-14, right, y-14)
+	doc.Text(left, y, 9, true, "BILL TO")
+	y += 14
+	doc.Text(left, y, 10, true, invoice.CustomerName)
+	y += 13
+	for _, line := range utils.WrapPDFText(invoice.CustomerAddress, 9, false, 320) {
+		doc.Text(left, y, 9, false, line)
+		y += 12
+	}
+	if invoice.CustomerGSTIN != "" {
+		doc.Text(left, y, 9, false, "GSTIN: "+invoice.CustomerGSTIN)
+		y += 12
+	}
+	if invoice.CustomerPhone != "" {
+		doc.Text(left, y, 9, false, "Phone: "+invoice.CustomerPhone)
+		y += 12
+	}
+	if invoice.CustomerEmail != "" {
+		doc.Text(left, y, 9, false, "Email: "+invoice.CustomerEmail)
+		y += 12
+	}
+
+	// Line item
+	y += 16
+	doc.FillRect(left, y, right-left, 20, 0.9)
+	doc.Text(left+6, y+14, 9, true, "Description")
+	doc.Text(330, y+14, 9, true, "SAC")
+	doc.TextRight(440, y+14, 9, true, "Taxable value")
+	doc.TextRight(right-6, y+14, 9, true, "Amount")
+	y += 36
+	descriptionLines := utils.WrapPDFText(invoice.Description, 9, false, 270)
+	if len(descriptionLines) == 0 {
+		descriptionLines = []string{invoiceDocumentTitle(invoice.DocumentType)}
+	}
+	doc.Text(330, y, 9, false, invoice.SacCode)
+	doc.TextRight(440, y, 9, false, amount(invoice.TaxableAmount))
+	doc.TextRight(right-6, y, 9, false, amount(invoice.TotalAmount))
+	for _, line := range descriptionLines {
+		doc.Text(left+6, y, 9, false, line)
+		y += 12
+	}
+	y += 6
+	doc.Line(left, y, right, y)
+
+	// Totals
+	rate := strconv.FormatFloat(invoice.GSTRate, 'f', -1, 64)
+	halfRate := strconv.FormatFloat(invoice.GSTRate/2, 'f', -1, 64)
+	totals := [][2]string{{"Taxable value", amount(invoice.TaxableAmount)}}
+	switch {
+	case invoice.DocumentType == models.InvoiceDocumentReceipt:
+		totals = nil
+	case invoice.IsInterState:
+		totals = append(totals, [2]string{"IGST @ " + rate + "%", amount(invoice.IGSTAmount)})
+	default:
+		totals = append(totals,
+			[2]string{"CGST @ " + halfRate + "%", amount(invoice.CGSTAmount)},
+			[2]string{"SGST @ " + halfRate + "%", amount(invoice.SGSTAmount)})
+	}
+	y += 18
+	for _, total := range totals {
+		doc.Text(340, y, 9, false, total[0])
+		doc.TextRight(right-6, y, 9, false, total[1])
+		y += 14
+	}
+	doc.FillRect(330, y-4, right-330, 20, 0.9)
+	doc.Text(340, y+10, 10, true, "Total")
+	doc.TextRight(right-6, y+10, 10, true, amount(invoice.TotalAmount))
+	y += 40
+
+	// Notes
+	switch invoice.DocumentType {
+	case models.InvoiceDocumentReceipt:
+		doc.Text(left, y, 8, false, "Amount received as an advance to the wallet. GST is charged on the invoice for the service it is used for.")
+		y += 12
+	case models.InvoiceDocumentCreditNote:
+		doc.Text(left, y, 8, false, "Issued for a refund. The tax shown is reversed from the original invoice.")
+		y += 12
+	}
+	doc.Text(left, y, 8, false, "Amounts are inclusive of GST. Paid via "+invoice.PaymentMethod+".")
+	doc.Text(left, utils.PDFPageHeight-40, 8, false, "This is a computer generated document and does not require a signature.")
+	if supportEmail != "" {
+		doc.TextRight(right, utils.PDFPageHeight-40, 8, false, "Questions? "+supportEmail)
+	}
+
+	return doc.Bytes()
+}
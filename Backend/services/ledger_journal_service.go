@@ -0,0 +1,807 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"strings"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ledgerAttachmentContentTypes are the receipt formats that can be attached to ledger entries
+var ledgerAttachmentContentTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/webp"}
+
+// maxLedgerAttachmentSize is the largest receipt that can be attached (10 MB)
+const maxLedgerAttachmentSize = 10 << 20
+
+// ErrPeriodClosed is returned when a change would touch a closed accounting period
+var ErrPeriodClosed = errors.New("the accounting period is closed")
+
+// LedgerJournalService keeps the double-entry books: the chart of accounts, journals posted for
+// ledger entries and platform events, accounting periods and the financial reports
+type LedgerJournalService struct {
+	journalRepo       *repositories.LedgerJournalRepository
+	ledgerRepo        *repositories.LedgerRepository
+	cloudinaryService *CloudinaryService
+}
+
+// NewLedgerJournalService creates a new ledger journal service
+func NewLedgerJournalService() *LedgerJournalService {
+	cloudinaryService, err := NewCloudinaryService()
+	if err != nil {
+		logrus.Warnf("Failed to initialize Cloudinary service: %v", err)
+		cloudinaryService = nil
+	}
+
+	return &LedgerJournalService{
+		journalRepo:       repositories.NewLedgerJournalRepository(),
+		ledgerRepo:        repositories.NewLedgerRepository(),
+		cloudinaryService: cloudinaryService,
+	}
+}
+
+// Account Operations
+
+// GetAccounts gets the chart of accounts
+func (ljs *LedgerJournalService) GetAccounts(includeInactive bool) ([]models.LedgerAccount, error) {
+	return ljs.journalRepo.GetAccounts(includeInactive)
+}
+
+// CreateAccount adds an account to the chart of accounts
+func (ljs *LedgerJournalService) CreateAccount(req *models.CreateLedgerAccountRequest) (*models.LedgerAccount, error) {
+	code := strings.TrimSpace(req.Code)
+	if _, err := ljs.journalRepo.GetAccountByCode(code); err == nil {
+		return nil, fmt.Errorf("account %s already exists", code)
+	}
+
+	account := &models.LedgerAccount{
+		Code:        code,
+		Name:        strings.TrimSpace(req.Name),
+		AccountType: req.AccountType,
+		Description: req.Description,
+		IsActive:    true,
+	}
+	if err := ljs.journalRepo.CreateAccount(account); err != nil {
+		return nil, fmt.Errorf("failed to create account: %v", err)
+	}
+	return account, nil
+}
+
+// GetActiveAccount gets an active account by code
+func (ljs *LedgerJournalService) GetActiveAccount(code string) (*models.LedgerAccount, error) {
+	account, err := ljs.journalRepo.GetAccountByCode(strings.TrimSpace(code))
+	if err != nil {
+		return nil, fmt.Errorf("account %s not found", code)
+	}
+	if !account.IsActive {
+		return nil, fmt.Errorf("account %s is inactive", code)
+	}
+	return account, nil
+}
+
+// UpdateAccount renames or deactivates an account. Codes and types cannot change once
+// postings may exist, and system accounts cannot be deactivated.
+func (ljs *LedgerJournalService) UpdateAccount(id uint, req *models.UpdateLedgerAccountRequest) (*models.LedgerAccount, error) {
+	account, err := ljs.journalRepo.GetAccountByID(id)
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+
+	if req.Name != nil {
+		account.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		account.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		if !*req.IsActive && account.IsSystem {
+			return nil, errors.New("system accounts cannot be deactivated")
+		}
+		account.IsActive = *req.IsActive
+	}
+
+	if err := ljs.journalRepo.UpdateAccount(account); err != nil {
+		return nil, fmt.Errorf("failed to update account: %v", err)
+	}
+	return account, nil
+}
+
+// Journal Operations
+
+// CreateJournal posts a manual journal
+func (ljs *LedgerJournalService) CreateJournal(req *models.CreateLedgerJournalRequest, adminID uint) (*models.LedgerJournal, error) {
+	entryDate := time.Now()
+	if req.EntryDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.EntryDate, workerCalendarLocation())
+		if err != nil {
+			return nil, errors.New("entry_date must be in YYYY-MM-DD format")
+		}
+		entryDate = date
+	}
+
+	journal := &models.LedgerJournal{
+		Reference:   generateJournalReference("JV"),
+		EntryDate:   entryDate,
+		Description: req.Description,
+		SourceType:  models.LedgerJournalSourceManual,
+		VendorID:    req.VendorID,
+		CreatedBy:   &adminID,
+	}
+	posted, err := ljs.post(journal, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+	if !posted {
+		return nil, errors.New("journal reference already in use, please try again")
+	}
+
+	logrus.Infof("Posted manual ledger journal %s", journal.Reference)
+	return ljs.journalRepo.GetJournalByID(journal.ID)
+}
+
+// GetJournal gets a journal with its postings
+func (ljs *LedgerJournalService) GetJournal(id uint) (*models.LedgerJournal, error) {
+	journal, err := ljs.journalRepo.GetJournalByID(id)
+	if err != nil {
+		return nil, errors.New("journal not found")
+	}
+	return journal, nil
+}
+
+// GetJournals gets journals with filters, dated from and to the given days (YYYY-MM-DD, IST, both included)
+func (ljs *LedgerJournalService) GetJournals(filters *models.LedgerJournalFilters, from, to string) ([]models.LedgerJournal, int64, error) {
+	if from != "" {
+		start, err := parseReportDate(from, time.Time{})
+		if err != nil {
+			return nil, 0, err
+		}
+		filters.From = &start
+	}
+	if to != "" {
+		endDay, err := parseReportDate(to, time.Time{})
+		if err != nil {
+			return nil, 0, err
+		}
+		end := endDay.AddDate(0, 0, 1)
+		filters.To = &end
+	}
+	return ljs.journalRepo.GetJournals(filters)
+}
+
+// ReverseJournal posts a journal that swaps the debits and credits of another. A journal can be
+// reversed once, and reversals cannot themselves be reversed.
+func (ljs *LedgerJournalService) ReverseJournal(id uint, reason string, adminID uint) (*models.LedgerJournal, error) {
+	original, err := ljs.journalRepo.GetJournalByID(id)
+	if err != nil {
+		return nil, errors.New("journal not found")
+	}
+	if original.SourceType == models.LedgerJournalSourceReversal {
+		return nil, errors.New("reversal journals cannot be reversed")
+	}
+	if _, err := ljs.journalRepo.GetReversal(id); err == nil {
+		return nil, errors.New("journal has already been reversed")
+	}
+
+	lines := make([]models.LedgerPostingLine, 0, len(original.Postings))
+	for _, posting := range original.Postings {
+		lines = append(lines, models.LedgerPostingLine{
+			AccountCode: posting.Account.Code,
+			Debit:       posting.Credit,
+			Credit:      posting.Debit,
+			Description: posting.Description,
+		})
+	}
+
+	description := fmt.Sprintf("Reversal of %s", original.Reference)
+	if reason != "" {
+		description = fmt.Sprintf("%s: %s", description, reason)
+	}
+	journal := &models.LedgerJournal{
+		Reference:      fmt.Sprintf("REV-%s", original.Reference),
+		EntryDate:      time.Now(),
+		Description:    description,
+		SourceType:     models.LedgerJournalSourceReversal,
+		SourceID:       &original.ID,
+		PaymentID:      original.PaymentID,
+		WorkerPayoutID: original.WorkerPayoutID,
+		VendorID:       original.VendorID,
+		LedgerEntryID:  original.LedgerEntryID,
+		ReversalOfID:   &original.ID,
+		CreatedBy:      &adminID,
+	}
+	posted, err := ljs.post(journal, lines)
+	if err != nil {
+		return nil, err
+	}
+	if !posted {
+		return nil, errors.New("journal has already been reversed")
+	}
+
+	logrus.Infof("Reversed ledger journal %s with %s", original.Reference, journal.Reference)
+	return ljs.journalRepo.GetJournalByID(journal.ID)
+}
+
+// postEntryJournal posts the cash or bank movement of paying or receiving a ledger entry. Pay
+// entries debit the entry's account and credit cash or bank; receive entries do the opposite.
+func (ljs *LedgerJournalService) postEntryJournal(entry *models.LedgerEntry, amount float64, source models.PaymentSource, adminID uint) (*models.LedgerJournal, error) {
+	moneyAccount := models.LedgerAccountCash
+	if source == models.PaymentSourceBank {
+		moneyAccount = models.LedgerAccountBank
+	}
+
+	entryAccount := models.LedgerAccountOtherExpenses
+	if entry.EntryType == models.LedgerEntryTypeReceive {
+		entryAccount = models.LedgerAccountOtherIncome
+	}
+	if entry.AccountID != nil {
+		account, err := ljs.journalRepo.GetAccountByID(*entry.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entry account: %v", err)
+		}
+		entryAccount = account.Code
+	}
+
+	lines := []models.LedgerPostingLine{
+		{AccountCode: entryAccount, Debit: amount, Description: entry.Name},
+		{AccountCode: moneyAccount, Credit: amount, Description: entry.Name},
+	}
+	if entry.EntryType == models.LedgerEntryTypeReceive {
+		lines = []models.LedgerPostingLine{
+			{AccountCode: moneyAccount, Debit: amount, Description: entry.Name},
+			{AccountCode: entryAccount, Credit: amount, Description: entry.Name},
+		}
+	}
+
+	journal := &models.LedgerJournal{
+		Reference:      generateJournalReference(fmt.Sprintf("LE%d-", entry.ID)),
+		EntryDate:      time.Now(),
+		Description:    fmt.Sprintf("%s: %s", entry.EntryType, entry.Name),
+		SourceType:     models.LedgerJournalSourceLedgerEntry,
+		SourceID:       &entry.ID,
+		PaymentID:      entry.PaymentID,
+		WorkerPayoutID: entry.WorkerPayoutID,
+		VendorID:       entry.VendorID,
+		LedgerEntryID:  &entry.ID,
+		CreatedBy:      &adminID,
+	}
+	posted, err := ljs.post(journal, lines)
+	if err != nil {
+		return nil, err
+	}
+	if !posted {
+		return nil, errors.New("journal reference already in use, please try again")
+	}
+	return journal, nil
+}
+
+// post validates the lines of a journal and posts it. It returns false when a journal with the
+// same reference was already posted.
+func (ljs *LedgerJournalService) post(journal *models.LedgerJournal, lines []models.LedgerPostingLine) (bool, error) {
+	if len(lines) < 2 {
+		return false, errors.New("a journal needs at least two lines")
+	}
+
+	accounts := make(map[string]*models.LedgerAccount)
+	postings := make([]models.LedgerPosting, 0, len(lines))
+	var totalDebit, totalCredit float64
+	for _, line := range lines {
+		debit := roundCurrency(line.Debit)
+		credit := roundCurrency(line.Credit)
+		if debit < 0 || credit < 0 {
+			return false, errors.New("debits and credits cannot be negative")
+		}
+		if (debit == 0) == (credit == 0) {
+			return false, errors.New("each line must have either a debit or a credit")
+		}
+
+		account, ok := accounts[line.AccountCode]
+		if !ok {
+			var err error
+			account, err = ljs.GetActiveAccount(line.AccountCode)
+			if err != nil {
+				return false, err
+			}
+			accounts[line.AccountCode] = account
+		}
+
+		postings = append(postings, models.LedgerPosting{
+			AccountID:   account.ID,
+			Debit:       debit,
+			Credit:      credit,
+			Description: line.Description,
+		})
+		totalDebit += debit
+		totalCredit += credit
+	}
+
+	totalDebit = roundCurrency(totalDebit)
+	totalCredit = roundCurrency(totalCredit)
+	if totalDebit != totalCredit {
+		return false, fmt.Errorf("journal does not balance: debits %.2f, credits %.2f", totalDebit, totalCredit)
+	}
+
+	closed, err := ljs.journalRepo.IsClosed(journal.EntryDate)
+	if err != nil {
+		return false, fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return false, ErrPeriodClosed
+	}
+
+	journal.TotalAmount = totalDebit
+	journal.Postings = postings
+	posted, err := ljs.journalRepo.PostJournal(journal)
+	if err != nil {
+		return false, fmt.Errorf("failed to post journal: %v", err)
+	}
+	return posted, nil
+}
+
+// Accounting Period Operations
+
+// GetPeriods gets the accounting periods that were closed or reopened
+func (ljs *LedgerJournalService) GetPeriods() ([]models.AccountingPeriod, error) {
+	return ljs.journalRepo.GetPeriods()
+}
+
+// ClosePeriod closes a calendar month (YYYY-MM, IST) so its journals and ledger entries become
+// immutable. Only months that have ended can be closed.
+func (ljs *LedgerJournalService) ClosePeriod(req *models.CloseAccountingPeriodRequest, adminID uint) (*models.AccountingPeriod, error) {
+	start, err := time.ParseInLocation("2006-01", req.Month, workerCalendarLocation())
+	if err != nil {
+		return nil, errors.New("month must be in YYYY-MM format")
+	}
+	end := start.AddDate(0, 1, 0)
+	now := time.Now()
+	if now.Before(end) {
+		return nil, errors.New("only months that have ended can be closed")
+	}
+
+	period, err := ljs.journalRepo.GetPeriodByStart(start)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get accounting period: %v", err)
+		}
+		period = &models.AccountingPeriod{PeriodStart: start, PeriodEnd: end}
+	}
+	if period.Status == models.AccountingPeriodStatusClosed {
+		return nil, fmt.Errorf("%s is already closed", req.Month)
+	}
+
+	period.Status = models.AccountingPeriodStatusClosed
+	period.ClosedAt = &now
+	period.ClosedBy = &adminID
+	period.Notes = req.Notes
+	if err := ljs.journalRepo.SavePeriod(period); err != nil {
+		return nil, fmt.Errorf("failed to close accounting period: %v", err)
+	}
+
+	logrus.Infof("Closed accounting period %s", req.Month)
+	return period, nil
+}
+
+// ensureOpen returns ErrPeriodClosed when the given time falls in a closed accounting period
+func (ljs *LedgerJournalService) ensureOpen(at time.Time) error {
+	closed, err := ljs.journalRepo.IsClosed(at)
+	if err != nil {
+		return fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
+// Reports
+
+// GetTrialBalance gets the balance of every account up to and including a day (YYYY-MM-DD, IST)
+func (ljs *LedgerJournalService) GetTrialBalance(asOf string) (*models.TrialBalance, error) {
+	day, err := parseReportDate(asOf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := ljs.journalRepo.GetAccountTotals(nil, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account totals: %v", err)
+	}
+
+	report := &models.TrialBalance{AsOf: day, Rows: []models.TrialBalanceRow{}}
+	for _, total := range totals {
+		balance := roundCurrency(total.Debit - total.Credit)
+		if balance == 0 {
+			continue
+		}
+		row := models.TrialBalanceRow{Code: total.Code, Name: total.Name, AccountType: total.AccountType}
+		if balance > 0 {
+			row.Debit = balance
+		} else {
+			row.Credit = -balance
+		}
+		report.Rows = append(report.Rows, row)
+		report.TotalDebit += row.Debit
+		report.TotalCredit += row.Credit
+	}
+	report.TotalDebit = roundCurrency(report.TotalDebit)
+	report.TotalCredit = roundCurrency(report.TotalCredit)
+	report.IsBalanced = report.TotalDebit == report.TotalCredit
+	return report, nil
+}
+
+// GetProfitAndLoss gets the income and expenses of the days from and to (YYYY-MM-DD, IST, both
+// included). The period defaults to the current month.
+func (ljs *LedgerJournalService) GetProfitAndLoss(from, to string) (*models.ProfitAndLoss, error) {
+	now := time.Now().In(workerCalendarLocation())
+	start, err := parseReportDate(from, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
+	if err != nil {
+		return nil, err
+	}
+	endDay, err := parseReportDate(to, now)
+	if err != nil {
+		return nil, err
+	}
+	end := endDay.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	totals, err := ljs.journalRepo.GetAccountTotals(&start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account totals: %v", err)
+	}
+
+	report := &models.ProfitAndLoss{
+		From:     start,
+		To:       end,
+		Income:   []models.ProfitAndLossRow{},
+		Expenses: []models.ProfitAndLossRow{},
+	}
+	for _, total := range totals {
+		switch total.AccountType {
+		case models.LedgerAccountTypeIncome:
+			amount := roundCurrency(total.Credit - total.Debit)
+			report.Income = append(report.Income, models.ProfitAndLossRow{Code: total.Code, Name: total.Name, Amount: amount})
+			report.TotalIncome += amount
+		case models.LedgerAccountTypeExpense:
+			amount := roundCurrency(total.Debit - total.Credit)
+			report.Expenses = append(report.Expenses, models.ProfitAndLossRow{Code: total.Code, Name: total.Name, Amount: amount})
+			report.TotalExpenses += amount
+		}
+	}
+	report.TotalIncome = roundCurrency(report.TotalIncome)
+	report.TotalExpenses = roundCurrency(report.TotalExpenses)
+	report.NetProfit = roundCurrency(report.TotalIncome - report.TotalExpenses)
+	return report, nil
+}
+
+// TrialBalanceCSV renders a trial balance as CSV and returns it with its file name
+func (ljs *LedgerJournalService) TrialBalanceCSV(report *models.TrialBalance) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Account Code", "Account Name", "Account Type", "Debit", "Credit"})
+	for _, row := range report.Rows {
+		writer.Write([]string{
+			row.Code,
+			row.Name,
+			string(row.AccountType),
+			fmt.Sprintf("%.2f", row.Debit),
+			fmt.Sprintf("%.2f", row.Credit),
+		})
+	}
+	writer.Write([]string{"", "Total", "", fmt.Sprintf("%.2f", report.TotalDebit), fmt.Sprintf("%.2f", report.TotalCredit)})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to write trial balance: %v", err)
+	}
+
+	return buf.Bytes(), fmt.Sprintf("trial_balance_%s.csv", report.AsOf.Format("2006-01-02")), nil
+}
+
+// ProfitAndLossCSV renders a profit and loss statement as CSV and returns it with its file name
+func (ljs *LedgerJournalService) ProfitAndLossCSV(report *models.ProfitAndLoss) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Section", "Account Code", "Account Name", "Amount"})
+	for _, row := range report.Income {
+		writer.Write([]string{"Income", row.Code, row.Name, fmt.Sprintf("%.2f", row.Amount)})
+	}
+	writer.Write([]string{"Income", "", "Total income", fmt.Sprintf("%.2f", report.TotalIncome)})
+	for _, row := range report.Expenses {
+		writer.Write([]string{"Expenses", row.Code, row.Name, fmt.Sprintf("%.2f", row.Amount)})
+	}
+	writer.Write([]string{"Expenses", "", "Total expenses", fmt.Sprintf("%.2f", report.TotalExpenses)})
+	writer.Write([]string{"", "", "Net profit", fmt.Sprintf("%.2f", report.NetProfit)})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to write profit and loss: %v", err)
+	}
+
+	lastDay := report.To.AddDate(0, 0, -1)
+	return buf.Bytes(), fmt.Sprintf("profit_and_loss_%s_%s.csv", report.From.Format("2006-01-02"), lastDay.Format("2006-01-02")), nil
+}
+
+// Attachment Operations
+
+// AddEntryAttachment uploads a receipt and attaches it to a ledger entry
+func (ljs *LedgerJournalService) AddEntryAttachment(entryID uint, file *multipart.FileHeader, adminID uint) (*models.LedgerAttachment, error) {
+	if ljs.cloudinaryService == nil {
+		return nil, errors.New("file uploads are not configured")
+	}
+
+	entry, err := ljs.ledgerRepo.GetByID(entryID)
+	if err != nil {
+		return nil, errors.New("ledger entry not found")
+	}
+	if err := ljs.ensureOpen(entry.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	contentType := file.Header.Get("Content-Type")
+	if !containsString(ledgerAttachmentContentTypes, contentType) {
+		return nil, errors.New("receipts must be PDF, JPEG, PNG or WebP files")
+	}
+	if file.Size > maxLedgerAttachmentSize {
+		return nil, errors.New("receipts cannot be larger than 10 MB")
+	}
+
+	fileURL, err := ljs.cloudinaryService.UploadFile(file, "ledger-receipts")
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.LedgerAttachment{
+		LedgerEntryID: &entry.ID,
+		FileURL:       fileURL,
+		FileName:      file.Filename,
+		ContentType:   contentType,
+		UploadedBy:    adminID,
+	}
+	if err := ljs.journalRepo.CreateAttachment(attachment); err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// DeleteEntryAttachment removes a receipt from a ledger entry in an open period
+func (ljs *LedgerJournalService) DeleteEntryAttachment(entryID, attachmentID uint) error {
+	attachment, err := ljs.journalRepo.GetAttachmentByID(attachmentID)
+	if err != nil || attachment.LedgerEntryID == nil || *attachment.LedgerEntryID != entryID {
+		return errors.New("attachment not found")
+	}
+
+	entry, err := ljs.ledgerRepo.GetByID(entryID)
+	if err != nil {
+		return errors.New("ledger entry not found")
+	}
+	if err := ljs.ensureOpen(entry.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := ljs.journalRepo.DeleteAttachment(attachmentID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %v", err)
+	}
+	return nil
+}
+
+// Platform Events
+
+// PostPendingEvents posts journals for recent payments, refunds, worker earnings and paid payouts
+// that have none yet. Events dated in a period closed since are posted on the current date.
+func (ljs *LedgerJournalService) PostPendingEvents() (*models.LedgerPostingResult, error) {
+	since := time.Now().AddDate(0, 0, -7)
+	result := &models.LedgerPostingResult{}
+
+	payments, err := ljs.journalRepo.GetUnpostedPayments(since, 200)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unposted payments: %v", err)
+	}
+	for i := range payments {
+		journal, lines := paymentJournal(&payments[i])
+		if journal == nil {
+			result.Skipped++
+			continue
+		}
+		if ljs.postEvent(journal, lines) {
+			result.Payments++
+		}
+	}
+
+	earnings, err := ljs.journalRepo.GetUnpostedEarnings(since, 200)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unposted worker earnings: %v", err)
+	}
+	for i := range earnings {
+		earning := &earnings[i]
+		if earning.NetAmount <= 0 {
+			result.Skipped++
+			continue
+		}
+		journal := &models.LedgerJournal{
+			Reference:   fmt.Sprintf("ERN-%d", earning.ID),
+			EntryDate:   earning.EarnedAt,
+			Description: fmt.Sprintf("Worker earning for booking %d", earning.BookingID),
+			SourceType:  models.LedgerJournalSourceWorkerEarning,
+			SourceID:    &earning.ID,
+		}
+		lines := []models.LedgerPostingLine{
+			{AccountCode: models.LedgerAccountWorkerPayouts, Debit: earning.NetAmount},
+			{AccountCode: models.LedgerAccountWorkerPayables, Credit: earning.NetAmount},
+		}
+		if ljs.postEvent(journal, lines) {
+			result.Earnings++
+		}
+	}
+
+	payouts, err := ljs.journalRepo.GetUnpostedPayouts(since, 200)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unposted worker payouts: %v", err)
+	}
+	for i := range payouts {
+		payout := &payouts[i]
+		if payout.Amount <= 0 || payout.PaidAt == nil {
+			result.Skipped++
+			continue
+		}
+		journal := &models.LedgerJournal{
+			Reference:      fmt.Sprintf("PO-%d", payout.ID),
+			EntryDate:      *payout.PaidAt,
+			Description:    fmt.Sprintf("Payout to worker %d", payout.WorkerID),
+			SourceType:     models.LedgerJournalSourceWorkerPayout,
+			SourceID:       &payout.ID,
+			WorkerPayoutID: &payout.ID,
+		}
+		lines := []models.LedgerPostingLine{
+			{AccountCode: models.LedgerAccountWorkerPayables, Debit: payout.Amount},
+			{AccountCode: models.LedgerAccountBank, Credit: payout.Amount},
+		}
+		if ljs.postEvent(journal, lines) {
+			result.Payouts++
+		}
+	}
+
+	return result, nil
+}
+
+// postEvent posts the journal of a platform event, moving it out of a closed period when needed
+func (ljs *LedgerJournalService) postEvent(journal *models.LedgerJournal, lines []models.LedgerPostingLine) bool {
+	if closed, err := ljs.journalRepo.IsClosed(journal.EntryDate); err == nil && closed {
+		journal.EntryDate = time.Now()
+	}
+
+	posted, err := ljs.post(journal, lines)
+	if err != nil {
+		logrus.Errorf("Failed to post ledger journal %s: %v", journal.Reference, err)
+		return false
+	}
+	return posted
+}
+
+// StartPostingJob starts a periodic job that posts journals for platform events
+func (ljs *LedgerJournalService) StartPostingJob() {
+	ticker := time.NewTicker(15 * time.Minute) // Run every 15 minutes
+	go func() {
+		for range ticker.C {
+			result, err := ljs.PostPendingEvents()
+			if err != nil {
+				logrus.Errorf("Ledger posting job failed: %v", err)
+				continue
+			}
+			if result.Payments+result.Earnings+result.Payouts > 0 {
+				logrus.Infof("Ledger posting job posted %d payments, %d earnings and %d payouts", result.Payments, result.Earnings, result.Payouts)
+			}
+		}
+	}()
+	logrus.Info("Ledger posting job started")
+}
+
+// gatewayClearingAccount returns the clearing account of the gateway a payment went through
+func gatewayClearingAccount(payment *models.Payment) string {
+	if payment.GatewayProvider == PaymentGatewayCashfree {
+		return models.LedgerAccountCashfreeClearing
+	}
+	return models.LedgerAccountRazorpayClearing
+}
+
+// paymentJournal builds the journal of a completed payment, or returns nil for payments that do
+// not move money the books track, such as methods other than gateway, wallet and cash
+func paymentJournal(payment *models.Payment) (*models.LedgerJournal, []models.LedgerPostingLine) {
+	amount := math.Abs(payment.Amount)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	var debit, credit string
+	switch payment.Type {
+	case models.PaymentTypeRefund:
+		// Refunds of wallet top-ups give back wallet money; other refunds reduce sales
+		debit = models.LedgerAccountSalesRefunds
+		if payment.RelatedEntityType == "wallet" {
+			debit = models.LedgerAccountCustomerWallets
+		}
+		switch payment.Method {
+		case models.PaymentMethodOnline:
+			credit = gatewayClearingAccount(payment)
+		case "wallet":
+			credit = models.LedgerAccountCustomerWallets
+		}
+
+	case models.PaymentTypeWalletRecharge:
+		credit = models.LedgerAccountCustomerWallets
+		switch payment.Method {
+		case models.PaymentMethodOnline:
+			debit = gatewayClearingAccount(payment)
+		case "promotion":
+			debit = models.LedgerAccountPromotions
+		case "admin":
+			debit = models.LedgerAccountWalletAdjustments
+			if payment.Amount < 0 {
+				// Admin debits of a wallet take the money back
+				debit, credit = models.LedgerAccountCustomerWallets, models.LedgerAccountWalletAdjustments
+			}
+		}
+
+	default:
+		switch payment.Method {
+		case models.PaymentMethodOnline:
+			debit = gatewayClearingAccount(payment)
+		case "wallet":
+			debit = models.LedgerAccountCustomerWallets
+		case "cash":
+			debit = models.LedgerAccountCash
+		}
+		credit = models.LedgerAccountServiceRevenue
+		if payment.Type == models.PaymentTypeSubscription || payment.RelatedEntityType == "subscription" {
+			credit = models.LedgerAccountSubscriptionRevenue
+		}
+	}
+	if debit == "" || credit == "" || debit == credit {
+		return nil, nil
+	}
+
+	entryDate := payment.CreatedAt
+	if payment.CompletedAt != nil {
+		entryDate = *payment.CompletedAt
+	}
+	journal := &models.LedgerJournal{
+		Reference:   fmt.Sprintf("PAY-%d", payment.ID),
+		EntryDate:   entryDate,
+		Description: fmt.Sprintf("%s %s via %s", payment.PaymentReference, payment.Type, payment.Method),
+		SourceType:  models.LedgerJournalSourcePayment,
+		SourceID:    &payment.ID,
+		PaymentID:   &payment.ID,
+	}
+	lines := []models.LedgerPostingLine{
+		{AccountCode: debit, Debit: amount, Description: payment.Description},
+		{AccountCode: credit, Credit: amount, Description: payment.Description},
+	}
+	return journal, lines
+}
+
+// parseReportDate parses a report day (YYYY-MM-DD, IST), returning the day of fallback when empty
+func parseReportDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		local := fallback.In(workerCalendarLocation())
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, workerCalendarLocation())
+	if err != nil {
+		return time.Time{}, errors.New("dates must be in YYYY-MM-DD format")
+	}
+	return day, nil
+}
+
+// generateJournalReference generates a unique journal reference with the given prefix
+func generateJournalReference(prefix string) string {
+	timestamp := time.Now().Format("20060102")
+	// Generate a unique sequence number using current time in nanoseconds
+	sequence := time.Now().UnixNano() % 1000000 // Use last 6 digits of nanoseconds
+	return fmt.Sprintf("%s%s%06d", prefix, timestamp, sequence)
+}
@@ -9,12 +9,14 @@ import (
 )
 
 type LedgerService struct {
-	ledgerRepo *repositories.LedgerRepository
+	ledgerRepo     *repositories.LedgerRepository
+	journalService *LedgerJournalService
 }
 
 func NewLedgerService() *LedgerService {
 	return &LedgerService{
-		ledgerRepo: repositories.NewLedgerRepository(),
+		ledgerRepo:     repositories.NewLedgerRepository(),
+		journalService: NewLedgerJournalService(),
 	}
 }
 
@@ -30,6 +32,15 @@ func (ls *LedgerService) CreateEntry(req *models.CreateLedgerEntryRequest, admin
 		return nil, fmt.Errorf("amount_to_receive is required for receive entries")
 	}
 
+	var accountID *uint
+	if req.AccountCode != "" {
+		account, err := ls.journalService.GetActiveAccount(req.AccountCode)
+		if err != nil {
+			return nil, err
+		}
+		accountID = &account.ID
+	}
+
 	entry := &models.LedgerEntry{
 		EntryType:        req.EntryType,
 		Name:             req.Name,
@@ -40,6 +51,10 @@ func (ls *LedgerService) CreateEntry(req *models.CreateLedgerEntryRequest, admin
 		AmountReceived:   req.AmountReceived,
 		PaymentSource:    req.PaymentSource,
 		Notes:            req.Notes,
+		AccountID:        accountID,
+		PaymentID:        req.PaymentID,
+		WorkerPayoutID:   req.WorkerPayoutID,
+		VendorID:         req.VendorID,
 		CreatedBy:        adminID,
 	}
 
@@ -75,6 +90,9 @@ func (ls *LedgerService) UpdateEntry(id uint, req *models.UpdateLedgerEntryReque
 	if err != nil {
 		return nil, fmt.Errorf("ledger entry not found: %v", err)
 	}
+	if err := ls.journalService.ensureOpen(entry.CreatedAt); err != nil {
+		return nil, err
+	}
 
 	// Update fields if provided
 	if req.Name != nil {
@@ -101,6 +119,25 @@ func (ls *LedgerService) UpdateEntry(id uint, req *models.UpdateLedgerEntryReque
 	if req.Notes != nil {
 		entry.Notes = *req.Notes
 	}
+	if req.AccountCode != nil {
+		entry.AccountID = nil
+		if *req.AccountCode != "" {
+			account, err := ls.journalService.GetActiveAccount(*req.AccountCode)
+			if err != nil {
+				return nil, err
+			}
+			entry.AccountID = &account.ID
+		}
+	}
+	if req.PaymentID != nil {
+		entry.PaymentID = req.PaymentID
+	}
+	if req.WorkerPayoutID != nil {
+		entry.WorkerPayoutID = req.WorkerPayoutID
+	}
+	if req.VendorID != nil {
+		entry.VendorID = req.VendorID
+	}
 
 	// Update metadata
 	entry.UpdatedBy = &adminID
@@ -127,6 +164,9 @@ func (ls *LedgerService) DeleteEntry(id uint) error {
 	if err != nil {
 		return fmt.Errorf("ledger entry not found: %v", err)
 	}
+	if err := ls.journalService.ensureOpen(entry.CreatedAt); err != nil {
+		return err
+	}
 
 	err = ls.ledgerRepo.Delete(id)
 	if err != nil {
@@ -202,10 +242,20 @@ func (ls *LedgerService) ProcessPayment(entryID uint, req *models.ProcessPayment
 	if entry.AmountToBePaid != nil && req.Amount > *entry.AmountToBePaid {
 		return nil, fmt.Errorf("payment amount %.2f exceeds amount to be paid %.2f", req.Amount, *entry.AmountToBePaid)
 	}
+	if err := ls.journalService.ensureOpen(entry.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// Post the payment to the books before moving the balance
+	journal, err := ls.journalService.postEntryJournal(entry, req.Amount, req.PaymentSource, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post payment journal: %v", err)
+	}
 
 	// Process payment in balance
 	err = ls.ledgerRepo.ProcessPayment(req.Amount, req.PaymentSource, adminID, req.Notes)
 	if err != nil {
+		ls.reverseJournal(journal, adminID)
 		return nil, fmt.Errorf("failed to process payment: %v", err)
 	}
 
@@ -246,10 +296,20 @@ func (ls *LedgerService) ProcessReceive(entryID uint, req *models.ProcessPayment
 	if entry.AmountToReceive != nil && req.Amount > *entry.AmountToReceive {
 		return nil, fmt.Errorf("receive amount %.2f exceeds amount to be received %.2f", req.Amount, *entry.AmountToReceive)
 	}
+	if err := ls.journalService.ensureOpen(entry.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// Post the receipt to the books before moving the balance
+	journal, err := ls.journalService.postEntryJournal(entry, req.Amount, req.PaymentSource, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post receive journal: %v", err)
+	}
 
 	// Process receive in balance
 	err = ls.ledgerRepo.ProcessReceive(req.Amount, req.PaymentSource, adminID, req.Notes)
 	if err != nil {
+		ls.reverseJournal(journal, adminID)
 		return nil, fmt.Errorf("failed to process receive: %v", err)
 	}
 
@@ -273,6 +333,13 @@ func (ls *LedgerService) ProcessReceive(entryID uint, req *models.ProcessPayment
 	return entry, nil
 }
 
+// reverseJournal reverses the journal of a payment or receive whose balance update failed
+func (ls *LedgerService) reverseJournal(journal *models.LedgerJournal, adminID uint) {
+	if _, err := ls.journalService.ReverseJournal(journal.ID, "balance update failed", adminID); err != nil {
+		logrus.Errorf("Failed to reverse ledger journal %s: %v", journal.Reference, err)
+	}
+}
+
 // Cash/Bank Balance Operations
 
 // GetCurrentBalance gets the current cash/bank balance
@@ -185,11 +185,15 @@ func (lts *LocationTrackingService) UpdateLocation(workerID uint, assignmentID u
 	}
 
 	// Broadcast location update via WebSocket
-	if lts.wsService != nil && lts.wsService.hub != nil && locationResponse != nil {
-		lts.wsService.hub.BroadcastMessage(assignment.BookingID, "location_update", map[string]interface{}{
+	if locationResponse != nil {
+		update := map[string]interface{}{
 			"type": "worker_location",
 			"data": locationResponse,
-		})
+		}
+		GetRealtimeGateway().Publish(RealtimeTopic(RealtimeTopicBooking, assignment.BookingID), "location_update", update)
+		if lts.wsService != nil && lts.wsService.hub != nil {
+			lts.wsService.hub.BroadcastMessage(assignment.BookingID, "location_update", update)
+		}
 	}
 
 	logrus.Infof("Location updated for worker %d, assignment %d: lat=%.6f, lng=%.6f", 
@@ -217,19 +221,21 @@ func (lts *LocationTrackingService) StopTracking(workerID uint, assignmentID uin
 	}
 
 	// Broadcast tracking stopped via WebSocket
+	stopped := map[string]interface{}{
+		"tracking_status": map[string]interface{}{
+			"assignment_id": assignmentID,
+			"booking_id":    assignment.BookingID,
+			"worker_id":     workerID,
+			"is_tracking":   false,
+			"status":        "stopped",
+			"worker_name":   assignment.Worker.Name,
+			"customer_name": assignment.Booking.User.Name,
+		},
+		"type": "tracking_stopped",
+	}
+	GetRealtimeGateway().Publish(RealtimeTopic(RealtimeTopicBooking, assignment.BookingID), "tracking_status", stopped)
 	if lts.wsService != nil && lts.wsService.hub != nil {
-		lts.wsService.hub.BroadcastMessage(assignment.BookingID, "tracking_status", map[string]interface{}{
-			"tracking_status": map[string]interface{}{
-				"assignment_id": assignmentID,
-				"booking_id":    assignment.BookingID,
-				"worker_id":     workerID,
-				"is_tracking":   false,
-				"status":        "stopped",
-				"worker_name":   assignment.Worker.Name,
-				"customer_name": assignment.Booking.User.Name,
-			},
-			"type": "tracking_stopped",
-		})
+		lts.wsService.hub.BroadcastMessage(assignment.BookingID, "tracking_status", stopped)
 	}
 
 	logrus.Infof("Location tracking stopped for worker %d, assignment %d", workerID, assignmentID)
@@ -540,6 +546,76 @@ func (lts *LocationTrackingService) GetAssignmentByWorkerAndBooking(workerID uin
 	return lts.workerAssignmentRepo.GetByWorkerAndBooking(workerID, bookingID)
 }
 
+// AuthorizeRealtimeTopic lets the customer, the assigned worker and admins follow a booking's realtime topic
+func (lts *LocationTrackingService) AuthorizeRealtimeTopic(conn *RealtimeConnection, bookingID uint) error {
+	if conn.IsAdmin() {
+		return nil
+	}
+
+	booking, err := lts.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return errors.New("booking not found")
+	}
+	if booking.UserID == conn.UserID {
+		return nil
+	}
+	if _, err := lts.workerAssignmentRepo.GetByWorkerAndBooking(conn.UserID, bookingID); err == nil {
+		return nil
+	}
+	return errors.New("access denied")
+}
+
+// HandleRealtimeMessage handles the tracking events the assigned worker publishes to a booking's
+// realtime topic: start_tracking, location_update and stop_tracking
+func (lts *LocationTrackingService) HandleRealtimeMessage(conn *RealtimeConnection, bookingID uint, envelope *RealtimeEnvelope) (map[string]interface{}, error) {
+	assignment, err := lts.workerAssignmentRepo.GetByWorkerAndBooking(conn.UserID, bookingID)
+	if err != nil {
+		return nil, errors.New("assignment not found")
+	}
+
+	latitude, _ := envelope.Data["latitude"].(float64)
+	longitude, _ := envelope.Data["longitude"].(float64)
+	accuracy, _ := envelope.Data["accuracy"].(float64)
+
+	switch envelope.Event {
+	case "start_tracking":
+		status, err := lts.StartTracking(conn.UserID, assignment.ID)
+		if err != nil {
+			return nil, err
+		}
+		// Update initial location if provided
+		if latitude != 0 && longitude != 0 {
+			if err := lts.UpdateLocation(conn.UserID, assignment.ID, latitude, longitude, accuracy); err != nil {
+				logrus.Warnf("Failed to update initial location: %v", err)
+			}
+		}
+		GetRealtimeGateway().Publish(RealtimeTopic(RealtimeTopicBooking, bookingID), "tracking_status", map[string]interface{}{
+			"tracking_status": status,
+			"type":            "tracking_started",
+		})
+		return map[string]interface{}{"tracking_status": status}, nil
+
+	case "location_update":
+		if _, ok := envelope.Data["latitude"].(float64); !ok {
+			return nil, errors.New("latitude is required")
+		}
+		if _, ok := envelope.Data["longitude"].(float64); !ok {
+			return nil, errors.New("longitude is required")
+		}
+		if err := lts.UpdateLocation(conn.UserID, assignment.ID, latitude, longitude, accuracy); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"assignment_id": assignment.ID}, nil
+
+	case "stop_tracking":
+		if err := lts.StopTracking(conn.UserID, assignment.ID); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"assignment_id": assignment.ID}, nil
+	}
+	return nil, fmt.Errorf("unknown booking event %q", envelope.Event)
+}
+
 // CheckSystemHealth checks if the location tracking system is working properly
 func (lts *LocationTrackingService) CheckSystemHealth() error {
 	// Check if worker_locations table exists
@@ -1103,4 +1103,303 @@ func (nis *NotificationIntegrationService) NotifySubscriptionPurchase(user *mode
 		message,
 		data,
 	)
-}
\ No newline at end of file
+}
+// NotifyDisputeOpened notifies admins and the other party about a new booking dispute
+func (nis *NotificationIntegrationService) NotifyDisputeOpened(dispute *models.BookingDispute, booking *models.Booking) error {
+	adminMessage := fmt.Sprintf("New dispute raised by %s for booking %s: %s", dispute.RaisedByRole, booking.BookingReference, dispute.Reason)
+	data := map[string]interface{}{
+		"dispute_id":     dispute.ID,
+		"booking_id":     booking.ID,
+		"booking_ref":    booking.BookingReference,
+		"reason":         dispute.Reason,
+		"raised_by":      dispute.RaisedBy,
+		"raised_by_role": dispute.RaisedByRole,
+	}
+
+	err := nis.notificationService.CreateNotificationForAdmins(
+		models.InAppNotificationTypeDisputeOpened,
+		"Dispute Opened",
+		adminMessage,
+		data,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Let the other party know a dispute is open against the booking
+	for _, userID := range disputeParties(dispute, booking) {
+		if userID == dispute.RaisedBy {
+			continue
+		}
+		message := fmt.Sprintf("A dispute has been raised for booking %s. Our team will review it shortly.", booking.BookingReference)
+		if err := nis.notificationService.CreateNotificationForUser(userID, models.InAppNotificationTypeDisputeOpened, "Dispute Opened", message, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyDisputeUpdated notifies both parties about an admin update on a dispute
+func (nis *NotificationIntegrationService) NotifyDisputeUpdated(dispute *models.BookingDispute, booking *models.Booking, comment string) error {
+	message := fmt.Sprintf("Update on your dispute for booking %s: %s", booking.BookingReference, comment)
+	data := map[string]interface{}{
+		"dispute_id":  dispute.ID,
+		"booking_id":  booking.ID,
+		"booking_ref": booking.BookingReference,
+		"status":      dispute.Status,
+	}
+
+	for _, userID := range disputeParties(dispute, booking) {
+		if err := nis.notificationService.CreateNotificationForUser(userID, models.InAppNotificationTypeDisputeUpdated, "Dispute Updated", message, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyDisputeResolved notifies both parties about the outcome of a dispute
+func (nis *NotificationIntegrationService) NotifyDisputeResolved(dispute *models.BookingDispute, booking *models.Booking) error {
+	var resolution models.DisputeResolution
+	if dispute.Resolution != nil {
+		resolution = *dispute.Resolution
+	}
+
+	var message string
+	switch resolution {
+	case models.DisputeResolutionRefund, models.DisputeResolutionPartialRefund:
+		refundAmount := 0.0
+		if dispute.RefundAmount != nil {
+			refundAmount = *dispute.RefundAmount
+		}
+		message = fmt.Sprintf("Dispute for booking %s resolved with a refund of ₹%.2f", booking.BookingReference, refundAmount)
+	case models.DisputeResolutionRework:
+		message = fmt.Sprintf("Dispute for booking %s resolved: the work will be redone", booking.BookingReference)
+	default:
+		message = fmt.Sprintf("Dispute for booking %s has been reviewed and closed without further action", booking.BookingReference)
+	}
+
+	data := map[string]interface{}{
+		"dispute_id":    dispute.ID,
+		"booking_id":    booking.ID,
+		"booking_ref":   booking.BookingReference,
+		"resolution":    resolution,
+		"refund_amount": dispute.RefundAmount,
+		"notes":         dispute.ResolutionNotes,
+	}
+
+	for _, userID := range disputeParties(dispute, booking) {
+		if err := nis.notificationService.CreateNotificationForUser(userID, models.InAppNotificationTypeDisputeResolved, "Dispute Resolved", message, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disputeParties returns the user IDs of the customer and assigned worker of a disputed booking
+func disputeParties(dispute *models.BookingDispute, booking *models.Booking) []uint {
+	parties := []uint{booking.UserID}
+	if booking.WorkerAssignment != nil && booking.WorkerAssignment.WorkerID != booking.UserID {
+		parties = append(parties, booking.WorkerAssignment.WorkerID)
+	}
+	if dispute.RaisedBy != booking.UserID && (booking.WorkerAssignment == nil || dispute.RaisedBy != booking.WorkerAssignment.WorkerID) {
+		parties = append(parties, dispute.RaisedBy)
+	}
+	return parties
+}
+
+// NotifyRecurringBookingCreated tells the customer an occurrence of their booking series was booked
+func (nis *NotificationIntegrationService) NotifyRecurringBookingCreated(series *models.BookingSeries, booking *models.Booking, amount float64) error {
+	scheduled := ""
+	if booking.ScheduledTime != nil {
+		scheduled = booking.ScheduledTime.In(workerCalendarLocation()).Format("02 Jan 2006 at 15:04")
+	}
+	message := fmt.Sprintf("Your %s %s visit on %s is booked (%s). ₹%.2f was paid from your wallet.", series.Frequency, series.Service.Name, scheduled, booking.BookingReference, amount)
+	data := map[string]interface{}{
+		"series_id":   series.ID,
+		"booking_id":  booking.ID,
+		"booking_ref": booking.BookingReference,
+		"amount":      amount,
+	}
+
+	return nis.notificationService.CreateNotificationForUser(series.UserID, models.InAppNotificationTypeRecurringBookingCreated, "Recurring Booking Confirmed", message, data)
+}
+
+// NotifyRecurringBookingFailed tells the customer an occurrence of their booking series could not be booked
+func (nis *NotificationIntegrationService) NotifyRecurringBookingFailed(series *models.BookingSeries, occurrence *models.BookingSeriesOccurrence) error {
+	message := fmt.Sprintf("We could not book your %s visit on %s: %s", series.Service.Name, occurrence.ScheduledAt.In(workerCalendarLocation()).Format("02 Jan 2006 at 15:04"), occurrence.FailureReason)
+	data := map[string]interface{}{
+		"series_id":     series.ID,
+		"occurrence_id": occurrence.ID,
+		"reason":        occurrence.FailureReason,
+	}
+
+	return nis.notificationService.CreateNotificationForUser(series.UserID, models.InAppNotificationTypeRecurringBookingFailed, "Recurring Booking Failed", message, data)
+}
+
+// NotifyRescheduleRequested asks the assigned worker to accept a new booking time; the customer is told when an admin asked for it
+func (nis *NotificationIntegrationService) NotifyRescheduleRequested(request *models.BookingRescheduleRequest, booking *models.Booking) error {
+	location := workerCalendarLocation()
+	oldTime := request.OldScheduledTime.In(location).Format("02 Jan 2006 at 15:04")
+	newTime := request.NewScheduledTime.In(location).Format("02 Jan 2006 at 15:04")
+	data := map[string]interface{}{
+		"booking_id":         booking.ID,
+		"booking_ref":        booking.BookingReference,
+		"reschedule_id":      request.ID,
+		"old_scheduled_time": request.OldScheduledTime,
+		"new_scheduled_time": request.NewScheduledTime,
+	}
+
+	if request.WorkerID != nil {
+		message := fmt.Sprintf("Booking %s is requested to move from %s to %s. Please accept or decline the new time.", booking.BookingReference, oldTime, newTime)
+		if err := nis.notificationService.CreateNotificationForUser(*request.WorkerID, models.InAppNotificationTypeRescheduleRequested, "Reschedule Requested", message, data); err != nil {
+			return err
+		}
+	}
+
+	if request.RequesterRole == models.ActivityActorAdmin {
+		message := fmt.Sprintf("We have proposed moving your booking %s from %s to %s. We will confirm once your worker agrees.", booking.BookingReference, oldTime, newTime)
+		return nis.notificationService.CreateNotificationForUser(booking.UserID, models.InAppNotificationTypeRescheduleRequested, "Reschedule Requested", message, data)
+	}
+
+	return nil
+}
+
+// NotifyBookingRescheduled tells the customer and the assigned worker that a booking moved to its new time
+func (nis *NotificationIntegrationService) NotifyBookingRescheduled(request *models.BookingRescheduleRequest, booking *models.Booking) error {
+	newTime := request.NewScheduledTime.In(workerCalendarLocation()).Format("02 Jan 2006 at 15:04")
+	data := map[string]interface{}{
+		"booking_id":         booking.ID,
+		"booking_ref":        booking.BookingReference,
+		"reschedule_id":      request.ID,
+		"old_scheduled_time": request.OldScheduledTime,
+		"new_scheduled_time": request.NewScheduledTime,
+	}
+
+	message := fmt.Sprintf("Your booking %s has been rescheduled to %s.", booking.BookingReference, newTime)
+	if err := nis.notificationService.CreateNotificationForUser(booking.UserID, models.InAppNotificationTypeBookingRescheduled, "Booking Rescheduled", message, data); err != nil {
+		return err
+	}
+
+	if request.WorkerID != nil {
+		message := fmt.Sprintf("Booking %s is now scheduled for %s.", booking.BookingReference, newTime)
+		return nis.notificationService.CreateNotificationForUser(*request.WorkerID, models.InAppNotificationTypeBookingRescheduled, "Booking Rescheduled", message, data)
+	}
+
+	return nil
+}
+
+// NotifyRescheduleDeclined tells whoever asked for a new time that the worker declined it
+func (nis *NotificationIntegrationService) NotifyRescheduleDeclined(request *models.BookingRescheduleRequest, booking *models.Booking) error {
+	oldTime := request.OldScheduledTime.In(workerCalendarLocation()).Format("02 Jan 2006 at 15:04")
+	message := fmt.Sprintf("The worker could not make the new time for booking %s, so it stays on %s.", booking.BookingReference, oldTime)
+	if request.ResponseNotes != "" {
+		message += " Note: " + request.ResponseNotes
+	}
+	data := map[string]interface{}{
+		"booking_id":         booking.ID,
+		"booking_ref":        booking.BookingReference,
+		"reschedule_id":      request.ID,
+		"new_scheduled_time": request.NewScheduledTime,
+		"notes":              request.ResponseNotes,
+	}
+
+	if err := nis.notificationService.CreateNotificationForUser(booking.UserID, models.InAppNotificationTypeRescheduleDeclined, "Reschedule Declined", message, data); err != nil {
+		return err
+	}
+
+	if request.RequesterRole == models.ActivityActorAdmin {
+		return nis.notificationService.CreateNotificationForAdmins(models.InAppNotificationTypeRescheduleDeclined, "Reschedule Declined", message, data)
+	}
+
+	return nil
+}
+
+// notifyUserWithPush creates an in-app notification and sends the same message to the user's devices through FCM
+func (nis *NotificationIntegrationService) notifyUserWithPush(userID uint, notificationType models.InAppNotificationType, pushType models.NotificationType, title, message string, data map[string]interface{}) error {
+	err := nis.notificationService.CreateNotificationForUser(userID, notificationType, title, message, data)
+	SendPushNotification(userID, pushType, title, message, data)
+	return err
+}
+
+// segmentNotificationData builds the notification payload shared by payment segment notifications
+func segmentNotificationData(segment *models.PaymentSegment, booking *models.Booking) map[string]interface{} {
+	return map[string]interface{}{
+		"booking_id":     booking.ID,
+		"booking_ref":    booking.BookingReference,
+		"segment_id":     segment.ID,
+		"segment_number": segment.SegmentNumber,
+		"amount":         segment.Amount,
+		"due_date":       segment.DueDate,
+	}
+}
+
+// NotifySegmentPaymentReminder reminds the customer that a payment segment falls due soon
+func (nis *NotificationIntegrationService) NotifySegmentPaymentReminder(segment *models.PaymentSegment, booking *models.Booking) error {
+	dueDate := segment.DueDate.In(workerCalendarLocation()).Format("02 Jan 2006")
+	message := fmt.Sprintf("Payment %d of ₹%.2f for booking %s is due on %s.", segment.SegmentNumber, segment.Amount, booking.BookingReference, dueDate)
+	if segment.AutoDebit {
+		message += " It will be paid from your wallet on the due date, so please keep enough balance."
+	}
+
+	return nis.notifyUserWithPush(booking.UserID, models.InAppNotificationTypeSegmentPaymentReminder, models.NotificationTypePayment, "Payment Due Soon", message, segmentNotificationData(segment, booking))
+}
+
+// NotifySegmentPaymentOverdue tells the customer a payment segment is past its due date
+func (nis *NotificationIntegrationService) NotifySegmentPaymentOverdue(segment *models.PaymentSegment, booking *models.Booking) error {
+	message := fmt.Sprintf("Payment %d of ₹%.2f for booking %s is overdue. Please pay it to keep your work on schedule.", segment.SegmentNumber, segment.Amount, booking.BookingReference)
+
+	return nis.notifyUserWithPush(booking.UserID, models.InAppNotificationTypeSegmentPaymentOverdue, models.NotificationTypePayment, "Payment Overdue", message, segmentNotificationData(segment, booking))
+}
+
+// NotifySegmentPaymentEscalated tells admins a payment segment stayed overdue and what was done about it
+func (nis *NotificationIntegrationService) NotifySegmentPaymentEscalated(segment *models.PaymentSegment, booking *models.Booking, action models.SegmentEscalationAction) error {
+	message := fmt.Sprintf("Payment %d of ₹%.2f for booking %s is still overdue.", segment.SegmentNumber, segment.Amount, booking.BookingReference)
+	if action == models.SegmentEscalationPauseAssignment {
+		message += " The worker assignment has been paused until it is paid."
+	}
+	data := segmentNotificationData(segment, booking)
+	data["action"] = action
+
+	return nis.notificationService.CreateNotificationForAdmins(models.InAppNotificationTypeSegmentPaymentEscalated, "Overdue Payment Escalated", message, data)
+}
+
+// NotifySegmentAutoDebit tells the customer whether a due payment segment was paid from their wallet
+func (nis *NotificationIntegrationService) NotifySegmentAutoDebit(segment *models.PaymentSegment, booking *models.Booking, failure string) error {
+	data := segmentNotificationData(segment, booking)
+	if failure != "" {
+		message := fmt.Sprintf("We could not pay ₹%.2f for payment %d of booking %s from your wallet: %s. Please add money or pay another way.", segment.Amount, segment.SegmentNumber, booking.BookingReference, failure)
+		data["reason"] = failure
+		return nis.notifyUserWithPush(booking.UserID, models.InAppNotificationTypeSegmentAutoDebit, models.NotificationTypePayment, "Auto-Debit Failed", message, data)
+	}
+
+	message := fmt.Sprintf("₹%.2f was paid from your wallet for payment %d of booking %s.", segment.Amount, segment.SegmentNumber, booking.BookingReference)
+	return nis.notifyUserWithPush(booking.UserID, models.InAppNotificationTypeSegmentAutoDebit, models.NotificationTypePayment, "Payment Auto-Debited", message, data)
+}
+
+// NotifyAssignmentPaused tells the worker an assignment is on hold because the customer is behind on payment
+func (nis *NotificationIntegrationService) NotifyAssignmentPaused(assignment *models.WorkerAssignment, booking *models.Booking) error {
+	message := fmt.Sprintf("Work on booking %s is paused until the customer clears an overdue payment. We will let you know when you can continue.", booking.BookingReference)
+	data := map[string]interface{}{
+		"assignment_id": assignment.ID,
+		"booking_id":    booking.ID,
+		"booking_ref":   booking.BookingReference,
+		"reason":        assignment.PauseReason,
+	}
+
+	return nis.notifyUserWithPush(assignment.WorkerID, models.InAppNotificationTypeAssignmentPaused, models.NotificationTypeWorkerAssignment, "Assignment Paused", message, data)
+}
+
+// NotifyAssignmentResumed tells the worker a paused assignment can continue
+func (nis *NotificationIntegrationService) NotifyAssignmentResumed(assignment *models.WorkerAssignment, booking *models.Booking) error {
+	message := fmt.Sprintf("The customer has paid up. You can continue work on booking %s.", booking.BookingReference)
+	data := map[string]interface{}{
+		"assignment_id": assignment.ID,
+		"booking_id":    booking.ID,
+		"booking_ref":   booking.BookingReference,
+	}
+
+	return nis.notifyUserWithPush(assignment.WorkerID, models.InAppNotificationTypeAssignmentResumed, models.NotificationTypeWorkerAssignment, "Assignment Resumed", message, data)
+}
@@ -46,15 +46,181 @@ func (ns *NotificationService) SendBufferResponseNotification(request *models.Bu
 }
 
 // SendSubscriptionExpiryWarning sends subscription expiry warning notification
-func (ns *NotificationService) SendSubscriptionExpiryWarning(user *models.User, daysLeft int) error {
-	// TODO: Implement actual notification sending
-	// For now, just log the notification
+func (ns *NotificationService) SendSubscriptionExpiryWarning(user *models.User, subscription *models.UserSubscription, daysLeft int) error {
+	title := "Subscription Expiring Soon"
+	message := fmt.Sprintf("Your subscription expires in %d day(s) on %s. Renew now or turn on auto-renew to keep your premium features.",
+		daysLeft, subscription.EndDate.Format("January 2, 2006"))
+	
+	data := map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"plan_id":        subscription.PlanID,
+		"end_date":       subscription.EndDate,
+		"days_left":      daysLeft,
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionExpiryWarning,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription expiry warning notification: %w", err)
+	}
+	
+	SendPushNotification(user.ID, models.NotificationTypeSubscription, title, message, data)
 	return nil
 }
 
 // SendSubscriptionExpiredNotification sends subscription expired notification
 func (ns *NotificationService) SendSubscriptionExpiredNotification(user *models.User) error {
-	// TODO: Implement actual notification sending
+	title := "Subscription Expired"
+	message := "Your subscription has expired. Renew to continue using premium features."
+	
+	data := map[string]interface{}{
+		"user_id": user.ID,
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionExpired,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription expired notification: %w", err)
+	}
+	
+	SendPushNotification(user.ID, models.NotificationTypeSubscription, title, message, data)
+	return nil
+}
+
+// SendSubscriptionRenewedNotification tells the user their subscription was renewed
+func (ns *NotificationService) SendSubscriptionRenewedNotification(user *models.User, subscription *models.UserSubscription) error {
+	title := "Subscription Renewed"
+	message := fmt.Sprintf("Your subscription has been renewed for ₹%.2f and is now valid until %s.",
+		subscription.Amount, subscription.EndDate.Format("January 2, 2006"))
+	
+	data := map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"plan_id":        subscription.PlanID,
+		"start_date":     subscription.StartDate,
+		"end_date":       subscription.EndDate,
+		"amount":         subscription.Amount,
+		"payment_method": subscription.PaymentMethod,
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionRenewed,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription renewed notification: %w", err)
+	}
+	
+	SendPushNotification(user.ID, models.NotificationTypeSubscription, title, message, data)
+	return nil
+}
+
+// SendSubscriptionRenewalFailedNotification tells the user the wallet could not pay for their renewal.
+// The Razorpay order they can pay instead is included when one was created.
+func (ns *NotificationService) SendSubscriptionRenewalFailedNotification(user *models.User, subscription *models.UserSubscription, reason string, payment *models.Payment) error {
+	title := "Subscription Renewal Failed"
+	message := fmt.Sprintf("We could not renew your subscription from your wallet: %s. Add money to your wallet or pay online to keep your premium features.", reason)
+	
+	data := map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"plan_id":        subscription.PlanID,
+		"end_date":       subscription.EndDate,
+		"reason":         reason,
+	}
+	if payment != nil {
+		data["payment_id"] = payment.ID
+		data["amount"] = payment.Amount
+		if payment.GatewayOrderID != nil {
+			data["razorpay_order_id"] = *payment.GatewayOrderID
+		}
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionRenewalFailed,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription renewal failed notification: %w", err)
+	}
+	
+	SendPushNotification(user.ID, models.NotificationTypeSubscription, title, message, data)
+	return nil
+}
+
+// SendSubscriptionGracePeriodNotification tells the user their subscription ended unpaid and when access stops
+func (ns *NotificationService) SendSubscriptionGracePeriodNotification(user *models.User, subscription *models.UserSubscription) error {
+	title := "Subscription Payment Pending"
+	message := "Your subscription could not be renewed. Renew it to keep your premium features."
+	if subscription.GraceEndsAt != nil {
+		message = fmt.Sprintf("Your subscription could not be renewed. You keep your premium features until %s; renew before then to avoid losing them.",
+			subscription.GraceEndsAt.Format("January 2, 2006"))
+	}
+	
+	data := map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"plan_id":        subscription.PlanID,
+		"end_date":       subscription.EndDate,
+		"grace_ends_at":  subscription.GraceEndsAt,
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionGracePeriod,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription grace period notification: %w", err)
+	}
+	
+	SendPushNotification(user.ID, models.NotificationTypeSubscription, title, message, data)
+	return nil
+}
+
+// SendSubscriptionPlanChangedNotification tells the user their plan change took effect
+func (ns *NotificationService) SendSubscriptionPlanChangedNotification(user *models.User, subscription *models.UserSubscription, prorationCredit float64) error {
+	title := "Subscription Plan Changed"
+	message := fmt.Sprintf("Your new plan is active until %s.", subscription.EndDate.Format("January 2, 2006"))
+	if prorationCredit > 0 {
+		message = fmt.Sprintf("Your new plan is active until %s. ₹%.2f of unused time on your previous plan was credited towards it.",
+			subscription.EndDate.Format("January 2, 2006"), prorationCredit)
+	}
+	
+	data := map[string]interface{}{
+		"subscription_id":          subscription.ID,
+		"previous_subscription_id": subscription.PreviousSubscriptionID,
+		"plan_id":                  subscription.PlanID,
+		"end_date":                 subscription.EndDate,
+		"proration_credit":         prorationCredit,
+	}
+	
+	err := ns.inAppNotificationService.CreateNotificationForUser(
+		user.ID,
+		models.InAppNotificationTypeSubscriptionPlanChanged,
+		title,
+		message,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription plan changed notification: %w", err)
+	}
+	
 	return nil
 }
 
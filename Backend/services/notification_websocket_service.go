@@ -1,13 +1,17 @@
 package services
 
 import (
+	"encoding/json"
 	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
-// NotificationWebSocketService handles WebSocket connections for real-time notifications
+// NotificationWebSocketService handles the legacy notification WebSocket connections. Every event is
+// also published to the user's notifications topic on the realtime gateway. Messages go through the
+// realtime broker and are written by the running service of every replica, so any instance can send
+// them.
 type NotificationWebSocketService struct {
 	// Map of user ID to WebSocket connections
 	userConnections map[uint][]*websocket.Conn
@@ -15,8 +19,10 @@ type NotificationWebSocketService struct {
 	adminConnections map[uint][]*websocket.Conn
 	// Mutex for thread safety
 	mutex sync.RWMutex
-	// Channel for broadcasting messages
-	broadcast chan NotificationMessage
+	// Serializes writes, as a connection supports one writer at a time
+	writeMutex sync.Mutex
+	// Broker fanning messages out to the services of every replica
+	broker RealtimeBroker
 	// Channel for registering new clients
 	Register chan NotificationClient
 	// Channel for unregistering clients
@@ -30,7 +36,8 @@ type NotificationClient struct {
 	UserType string // "user" or "admin"
 }
 
-// NotificationMessage represents a message to be broadcasted
+// NotificationMessage represents a message to be broadcasted. A message without a user ID goes to
+// every connected user of its user type.
 type NotificationMessage struct {
 	UserID   uint                   `json:"user_id"`
 	UserType string                 `json:"user_type"`
@@ -43,7 +50,7 @@ func NewNotificationWebSocketService() *NotificationWebSocketService {
 	return &NotificationWebSocketService{
 		userConnections:  make(map[uint][]*websocket.Conn),
 		adminConnections: make(map[uint][]*websocket.Conn),
-		broadcast:        make(chan NotificationMessage),
+		broker:           GetRealtimeBroker(),
 		Register:         make(chan NotificationClient),
 		Unregister:       make(chan NotificationClient),
 	}
@@ -52,6 +59,9 @@ func NewNotificationWebSocketService() *NotificationWebSocketService {
 // Run starts the WebSocket service
 func (nws *NotificationWebSocketService) Run() {
 	logrus.Info("Notification WebSocket service started")
+	if err := nws.broker.Subscribe(RealtimeChannelNotifications, nws.receive); err != nil {
+		logrus.Errorf("Failed to subscribe the notification WebSocket service to the %s broker: %v", nws.broker.Name(), err)
+	}
 	
 	for {
 		select {
@@ -60,13 +70,57 @@ func (nws *NotificationWebSocketService) Run() {
 			
 		case client := <-nws.Unregister:
 			nws.unregisterClient(client)
-			
-		case message := <-nws.broadcast:
-			nws.broadcastToUser(message)
 		}
 	}
 }
 
+// publish sends a message to the services of every replica, or only to this one when the broker is unavailable
+func (nws *NotificationWebSocketService) publish(message NotificationMessage) {
+	payload, err := json.Marshal(message)
+	if err == nil {
+		err = nws.broker.Publish(RealtimeChannelNotifications, payload)
+	}
+	if err != nil {
+		logrus.Errorf("Failed to publish notification message %s through the broker: %v", message.Event, err)
+		nws.deliver(message)
+	}
+}
+
+// receive delivers a message published on the broker
+func (nws *NotificationWebSocketService) receive(payload []byte) {
+	var message NotificationMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		logrus.Errorf("Invalid notification message from the broker: %v", err)
+		return
+	}
+	nws.deliver(message)
+}
+
+// deliver writes a message to the connections of its user, or of every user of its type, on this replica
+func (nws *NotificationWebSocketService) deliver(message NotificationMessage) {
+	if message.UserID != 0 {
+		nws.broadcastToUser(message)
+		return
+	}
+
+	nws.mutex.RLock()
+	connections := nws.userConnections
+	if message.UserType == "admin" {
+		connections = nws.adminConnections
+	}
+	userIDs := make([]uint, 0, len(connections))
+	for userID := range connections {
+		userIDs = append(userIDs, userID)
+	}
+	nws.mutex.RUnlock()
+
+	for _, userID := range userIDs {
+		userMessage := message
+		userMessage.UserID = userID
+		nws.broadcastToUser(userMessage)
+	}
+}
+
 // RegisterClient registers a new WebSocket client
 func (nws *NotificationWebSocketService) registerClient(client NotificationClient) {
 	nws.mutex.Lock()
@@ -133,6 +187,8 @@ func (nws *NotificationWebSocketService) broadcastToUser(message NotificationMes
 	}
 	
 	// Send message to all connections for this user
+	nws.writeMutex.Lock()
+	defer nws.writeMutex.Unlock()
 	for _, conn := range connections {
 		err := conn.WriteJSON(message)
 		if err != nil {
@@ -154,7 +210,8 @@ func (nws *NotificationWebSocketService) SendNotification(userID uint, userType
 		Data:     notification,
 	}
 	
-	nws.broadcast <- message
+	GetRealtimeGateway().PublishToUser(userID, message.Event, message.Data)
+	nws.publish(message)
 }
 
 // SendUnreadCountUpdate sends unread count update to a user
@@ -166,7 +223,8 @@ func (nws *NotificationWebSocketService) SendUnreadCountUpdate(userID uint, user
 		Data:     map[string]interface{}{"unread_count": count},
 	}
 	
-	nws.broadcast <- message
+	GetRealtimeGateway().PublishToUser(userID, message.Event, message.Data)
+	nws.publish(message)
 }
 
 // SendNotificationRead sends notification read status update
@@ -181,7 +239,8 @@ func (nws *NotificationWebSocketService) SendNotificationRead(userID uint, userT
 		},
 	}
 	
-	nws.broadcast <- message
+	GetRealtimeGateway().PublishToUser(userID, message.Event, message.Data)
+	nws.publish(message)
 }
 
 // SendAllNotificationsRead sends all notifications read status update
@@ -193,7 +252,8 @@ func (nws *NotificationWebSocketService) SendAllNotificationsRead(userID uint, u
 		Data:     map[string]interface{}{"unread_count": 0},
 	}
 	
-	nws.broadcast <- message
+	GetRealtimeGateway().PublishToUser(userID, message.Event, message.Data)
+	nws.publish(message)
 }
 
 // GetConnectionCount returns the number of active connections for a user
@@ -228,34 +288,22 @@ func (nws *NotificationWebSocketService) GetTotalConnections() (int, int) {
 
 // BroadcastToAllAdmins broadcasts a message to all connected admins
 func (nws *NotificationWebSocketService) BroadcastToAllAdmins(event string, data map[string]interface{}) {
-	nws.mutex.RLock()
-	defer nws.mutex.RUnlock()
-	
-	for userID := range nws.adminConnections {
-		message := NotificationMessage{
-			UserID:   userID,
-			UserType: "admin",
-			Event:    event,
-			Data:     data,
-		}
-		nws.broadcastToUser(message)
-	}
+	GetRealtimeGateway().PublishToAdmins(event, data)
+	nws.publish(NotificationMessage{
+		UserType: "admin",
+		Event:    event,
+		Data:     data,
+	})
 }
 
 // BroadcastToAllUsers broadcasts a message to all connected users
 func (nws *NotificationWebSocketService) BroadcastToAllUsers(event string, data map[string]interface{}) {
-	nws.mutex.RLock()
-	defer nws.mutex.RUnlock()
-	
-	for userID := range nws.userConnections {
-		message := NotificationMessage{
-			UserID:   userID,
-			UserType: "user",
-			Event:    event,
-			Data:     data,
-		}
-		nws.broadcastToUser(message)
-	}
+	GetRealtimeGateway().Publish(RealtimeTopicBroadcast, event, data)
+	nws.publish(NotificationMessage{
+		UserType: "user",
+		Event:    event,
+		Data:     data,
+	})
 }
 
 // CloseAllConnections closes all WebSocket connections
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Payment gateway providers
+const (
+	PaymentGatewayRazorpay = "razorpay"
+	PaymentGatewayCashfree = "cashfree"
+)
+
+// Gateway payment statuses, shared by every provider
+const (
+	GatewayPaymentCaptured   = "captured"   // Money received
+	GatewayPaymentAuthorized = "authorized" // Approved by the bank, waiting for capture
+	GatewayPaymentFailed     = "failed"
+	GatewayPaymentPending    = "pending" // Still in progress at the provider
+)
+
+// Gateway order statuses, shared by every provider
+const (
+	GatewayOrderCreated   = "created"   // No payment attempt yet
+	GatewayOrderAttempted = "attempted" // Attempted but not paid
+	GatewayOrderPaid      = "paid"
+)
+
+// Gateway refund statuses, shared by every provider
+const (
+	GatewayRefundPending   = "pending"
+	GatewayRefundProcessed = "processed"
+	GatewayRefundFailed    = "failed"
+)
+
+// Gateway webhook event types we act on, shared by every provider
+const (
+	GatewayEventPaymentAuthorized = "payment.authorized"
+	GatewayEventPaymentCaptured   = "payment.captured"
+	GatewayEventPaymentFailed     = "payment.failed"
+	GatewayEventRefundProcessed   = "refund.processed"
+	GatewayEventRefundFailed      = "refund.failed"
+)
+
+// PaymentGateway is an online payment provider customers pay orders through. Amounts are in rupees.
+type PaymentGateway interface {
+	// Name returns the provider name stored on payments, such as "razorpay"
+	Name() string
+	// CreateOrder creates an order to be paid through the provider's checkout
+	CreateOrder(req *GatewayOrderRequest) (*GatewayOrder, error)
+	// VerifyPayment checks that a payment the client reported for an order succeeded
+	VerifyPayment(orderID, paymentID, signature string) (bool, error)
+	// CapturePayment captures an authorized payment
+	CapturePayment(orderID, paymentID string, amount float64) error
+	// Refund refunds part or all of a captured payment
+	Refund(req *GatewayRefundRequest) (*GatewayRefund, error)
+	// VerifyWebhook checks a webhook delivery's signature and returns the provider's event ID,
+	// or "" when the provider sends none
+	VerifyWebhook(body []byte, headers http.Header) (string, error)
+	// ParseWebhook reads the event a verified webhook body carries
+	ParseWebhook(body []byte) (*GatewayWebhookEvent, error)
+	// GetOrderStatus gets an order and its payment attempts from the provider
+	GetOrderStatus(orderID string) (*GatewayOrderStatus, error)
+}
+
+// GatewayOrderRequest represents an order to create at a payment gateway
+type GatewayOrderRequest struct {
+	Amount        float64
+	Currency      string
+	Receipt       string // Our payment reference
+	Description   string
+	CustomerID    string
+	CustomerName  string
+	CustomerPhone string
+	CustomerEmail string
+}
+
+// GatewayOrder is an order created at a payment gateway
+type GatewayOrder struct {
+	Provider string
+	OrderID  string
+	// Checkout is returned to the client to open the provider's checkout
+	Checkout map[string]interface{}
+}
+
+// GatewayRefundRequest represents a refund to issue at a payment gateway
+type GatewayRefundRequest struct {
+	OrderID   string
+	PaymentID string
+	Amount    float64
+	Reference string // Our reference for the refund, unique per refund and sent as its idempotency key
+	Notes     map[string]string
+}
+
+// GatewayRefund is a refund issued at a payment gateway
+type GatewayRefund struct {
+	ID     string
+	Status string
+}
+
+// GatewayOrderStatus is an order as the payment gateway sees it
+type GatewayOrderStatus struct {
+	OrderID  string
+	Status   string
+	Payments []GatewayPayment
+}
+
+// GatewayPayment is a payment attempt against a gateway order
+type GatewayPayment struct {
+	ID            string
+	Status        string
+	Amount        float64
+	FailureReason string
+}
+
+// GatewayWebhookEvent is a webhook event in terms shared by every provider
+type GatewayWebhookEvent struct {
+	EventType     string // The provider's own event name
+	Type          string // One of the GatewayEvent types, or "" for events we do not act on
+	OrderID       string
+	PaymentID     string
+	RefundID      string
+	Amount        float64
+	HasAmount     bool
+	FailureReason string
+	Payload       map[string]interface{}
+}
+
+var (
+	registeredGatewaysMu sync.RWMutex
+	registeredGateways   = map[string]PaymentGateway{}
+)
+
+// RegisterPaymentGateway installs a gateway under its name, replacing the built-in provider of the
+// same name. It lets tests stand a fake gateway in for a real provider.
+func RegisterPaymentGateway(gateway PaymentGateway) {
+	registeredGatewaysMu.Lock()
+	defer registeredGatewaysMu.Unlock()
+	registeredGateways[gateway.Name()] = gateway
+}
+
+// GetPaymentGateway gets the gateway of a provider
+func GetPaymentGateway(name string) (PaymentGateway, error) {
+	if gateway, ok := registeredPaymentGateway(name); ok {
+		return gateway, nil
+	}
+
+	switch name {
+	case PaymentGatewayRazorpay:
+		return NewRazorpayGateway(NewRazorpayService()), nil
+	case PaymentGatewayCashfree:
+		return NewCashfreeGateway(), nil
+	}
+	return nil, fmt.Errorf("unknown payment gateway %q", name)
+}
+
+// registeredPaymentGateway gets a gateway installed with RegisterPaymentGateway
+func registeredPaymentGateway(name string) (PaymentGateway, bool) {
+	registeredGatewaysMu.RLock()
+	defer registeredGatewaysMu.RUnlock()
+	gateway, ok := registeredGateways[name]
+	return gateway, ok
+}
+
+// ActivePaymentGateway gets the gateway new orders are created with, as chosen by admins
+func ActivePaymentGateway() (PaymentGateway, error) {
+	return GetPaymentGateway(NewAdminConfigService().GetActivePaymentGateway())
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"treesindia/models"
+)
+
+// registerTestPaymentGateway installs a gateway for the duration of the test and puts back whatever was
+// registered under its name before
+func registerTestPaymentGateway(t *testing.T, gateway PaymentGateway) {
+	t.Helper()
+	previous, hadPrevious := registeredPaymentGateway(gateway.Name())
+	RegisterPaymentGateway(gateway)
+	t.Cleanup(func() {
+		registeredGatewaysMu.Lock()
+		defer registeredGatewaysMu.Unlock()
+		if hadPrevious {
+			registeredGateways[gateway.Name()] = previous
+		} else {
+			delete(registeredGateways, gateway.Name())
+		}
+	})
+}
+
+// payFakeOrder creates an order at the fake gateway and pays it, returning a pending local payment of
+// the order and the gateway payment ID
+func payFakeOrder(t *testing.T, gateway *FakePaymentGateway, id uint, amount float64) (*models.Payment, string) {
+	t.Helper()
+	order, err := gateway.CreateOrder(&GatewayOrderRequest{Amount: amount, Currency: "INR", Receipt: "PAY_TEST"})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	gatewayPaymentID, _, err := gateway.PayOrder(order.OrderID)
+	if err != nil {
+		t.Fatalf("PayOrder() error = %v", err)
+	}
+	payment := &models.Payment{
+		Amount:          amount,
+		Status:          models.PaymentStatusPending,
+		Type:            models.PaymentTypeWalletRecharge,
+		GatewayProvider: order.Provider,
+		GatewayOrderID:  &order.OrderID,
+	}
+	payment.ID = id
+	return payment, gatewayPaymentID
+}
+
+func TestRegisteredGatewayStandsInForProviderUntilTestEnds(t *testing.T) {
+	fake := NewFakePaymentGateway(PaymentGatewayCashfree)
+
+	t.Run("registered", func(t *testing.T) {
+		registerTestPaymentGateway(t, fake)
+		gateway, err := GetPaymentGateway(PaymentGatewayCashfree)
+		if err != nil || gateway != PaymentGateway(fake) {
+			t.Fatalf("GetPaymentGateway() = %T, %v, want the fake gateway", gateway, err)
+		}
+	})
+
+	gateway, err := GetPaymentGateway(PaymentGatewayCashfree)
+	if err != nil {
+		t.Fatalf("GetPaymentGateway() error = %v", err)
+	}
+	if _, ok := gateway.(*CashfreeGateway); !ok {
+		t.Errorf("GetPaymentGateway() after the test = %T, want the built-in *CashfreeGateway", gateway)
+	}
+	if _, ok := registeredPaymentGateway(PaymentGatewayCashfree); ok {
+		t.Error("fake gateway still registered after the test ended")
+	}
+}
+
+func TestGatewayForUsesGatewayPaymentWasMadeThrough(t *testing.T) {
+	razorpay := NewFakePaymentGateway(PaymentGatewayRazorpay)
+	cashfree := NewFakePaymentGateway(PaymentGatewayCashfree)
+	registerTestPaymentGateway(t, razorpay)
+	registerTestPaymentGateway(t, cashfree)
+	ps := &PaymentService{}
+
+	// Payments made before and after admins switched gateway are each handled by their own gateway
+	for _, want := range []*FakePaymentGateway{razorpay, cashfree} {
+		payment, gatewayPaymentID := payFakeOrder(t, want, 1, 250)
+		gateway, err := ps.gatewayFor(payment)
+		if err != nil {
+			t.Fatalf("gatewayFor(%s payment) error = %v", payment.GatewayProvider, err)
+		}
+		if gateway != PaymentGateway(want) {
+			t.Errorf("gatewayFor(%s payment) = %s gateway", payment.GatewayProvider, gateway.Name())
+		}
+
+		signature := want.Signature(*payment.GatewayOrderID, gatewayPaymentID)
+		if ok, err := gateway.VerifyPayment(*payment.GatewayOrderID, gatewayPaymentID, signature); err != nil || !ok {
+			t.Errorf("VerifyPayment() through %s = %v, %v, want verified", gateway.Name(), ok, err)
+		}
+	}
+
+	if _, err := ps.gatewayFor(&models.Payment{}); err == nil {
+		t.Error("gatewayFor(payment without a gateway): want an error")
+	}
+}
+
+func TestWebhooksReachPaymentsOfTheirOwnGateway(t *testing.T) {
+	f := newWebhookFixture(t)
+	cashfree := NewFakePaymentGateway(PaymentGatewayCashfree)
+	registerTestPaymentGateway(t, cashfree)
+
+	cashfreePayment, gatewayPaymentID := payFakeOrder(t, cashfree, 2, 300)
+	f.payments.payments = append(f.payments.payments, cashfreePayment)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event":      GatewayEventPaymentCaptured,
+		"order_id":   *cashfreePayment.GatewayOrderID,
+		"payment_id": gatewayPaymentID,
+		"amount":     300.0,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode webhook: %v", err)
+	}
+	headers := http.Header{}
+	headers.Set("X-Fake-Signature", cashfree.SignWebhook(body))
+	headers.Set("X-Fake-Event-Id", "evt_fake_captured")
+
+	// A Cashfree webhook is not accepted as a Razorpay one
+	if _, err := f.service.HandleWebhook(PaymentGatewayRazorpay, body, headers); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("Cashfree webhook sent to Razorpay: error = %v, want ErrInvalidWebhookSignature", err)
+	}
+
+	event, err := f.service.HandleWebhook(PaymentGatewayCashfree, body, headers)
+	if err != nil {
+		t.Fatalf("HandleWebhook() error = %v", err)
+	}
+	if event.Provider != PaymentGatewayCashfree || event.Status != models.WebhookEventStatusProcessed {
+		t.Errorf("event from %s is %s, want cashfree processed", event.Provider, event.Status)
+	}
+	if cashfreePayment.Status != models.PaymentStatusCompleted || f.payment.Status != models.PaymentStatusPending {
+		t.Errorf("cashfree payment %s, razorpay payment %s, want only the cashfree payment completed", cashfreePayment.Status, f.payment.Status)
+	}
+}
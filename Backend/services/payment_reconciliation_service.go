@@ -0,0 +1,437 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// reconcileGracePeriod leaves fresh payments to the checkout callback and webhooks
+	reconcileGracePeriod = 15 * time.Minute
+	// reconcileLookback is how far back unsettled payments are checked against their gateway
+	reconcileLookback = 72 * time.Hour
+	// settlementCheckDays is how many days of settled payments are checked on top of the settlement window
+	settlementCheckDays = 7
+)
+
+// reconciliationPaymentStore reads the payments reconciliation checks and records their settlements
+type reconciliationPaymentStore interface {
+	GetReconcilablePayments(from, to time.Time, limit int) ([]models.Payment, error)
+	GetUnsettledPayments(from, to time.Time, limit int) ([]models.Payment, error)
+	GetByGatewayPaymentID(paymentID string) (*models.Payment, error)
+	RecordSettlement(paymentID uint, settlementID string, settledAt time.Time, fee, tax float64) (bool, error)
+}
+
+// reconciliationDiscrepancyStore records the discrepancies reconciliation finds
+type reconciliationDiscrepancyStore interface {
+	Flag(discrepancy *models.PaymentDiscrepancy, now time.Time) (bool, error)
+	ResolveSettled(now time.Time) (int64, error)
+	Resolve(id uint, adminID uint, notes string, now time.Time) (bool, error)
+	GetByID(id uint) (*models.PaymentDiscrepancy, error)
+	GetDiscrepancies(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, *repositories.Pagination, error)
+	GetDiscrepanciesForExport(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, error)
+}
+
+// reconciliationPaymentUpdater moves payments to the status their gateway reports
+type reconciliationPaymentUpdater interface {
+	CompleteReconciledPayment(payment *models.Payment, gatewayPaymentID string) (bool, error)
+	FailGatewayPayment(payment *models.Payment, gatewayPaymentID string, reason string) (bool, error)
+	AbandonGatewayPayment(payment *models.Payment, reason string) (bool, error)
+	RecordGatewayAuthorization(payment *models.Payment, gatewayPaymentID string) error
+}
+
+// reconciliationSettings are the admin settings reconciliation runs with
+type reconciliationSettings interface {
+	GetPaymentAbandonAfterMinutes() int
+	GetPaymentSettlementWindowDays() int
+}
+
+// PaymentReconciliationService reconciles payments with their payment gateway: it fixes payments whose
+// local status disagrees with their gateway order, records Razorpay settlements and flags discrepancies for admins
+type PaymentReconciliationService struct {
+	paymentRepo        reconciliationPaymentStore
+	discrepancyRepo    reconciliationDiscrepancyStore
+	paymentService     reconciliationPaymentUpdater
+	razorpayService    *RazorpayService
+	adminConfigService reconciliationSettings
+}
+
+// NewPaymentReconciliationService creates a new payment reconciliation service
+func NewPaymentReconciliationService() *PaymentReconciliationService {
+	return NewPaymentReconciliationServiceWithRazorpay(NewRazorpayService())
+}
+
+// NewPaymentReconciliationServiceWithRazorpay creates a reconciliation service that queries the given
+// Razorpay service, which may point at a local HTTP server
+func NewPaymentReconciliationServiceWithRazorpay(razorpayService *RazorpayService) *PaymentReconciliationService {
+	return &PaymentReconciliationService{
+		paymentRepo:        repositories.NewPaymentRepository(),
+		discrepancyRepo:    repositories.NewPaymentDiscrepancyRepository(),
+		paymentService:     NewPaymentService(),
+		razorpayService:    razorpayService,
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// Reconcile checks unsettled payments against their gateway orders, then checks recent Razorpay
+// settlement reports against completed payments
+func (prs *PaymentReconciliationService) Reconcile() (*models.PaymentReconciliationResult, error) {
+	now := time.Now()
+	result := &models.PaymentReconciliationResult{}
+
+	if err := prs.reconcilePayments(now, result); err != nil {
+		return nil, err
+	}
+	if err := prs.reconcileSettlements(now, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reconcilePayments fixes pending, failed, expired and abandoned payments from the gateway's view of their orders
+func (prs *PaymentReconciliationService) reconcilePayments(now time.Time, result *models.PaymentReconciliationResult) error {
+	payments, err := prs.paymentRepo.GetReconcilablePayments(now.Add(-reconcileLookback), now.Add(-reconcileGracePeriod), 200)
+	if err != nil {
+		return fmt.Errorf("failed to get payments to reconcile: %v", err)
+	}
+
+	abandonBefore := now.Add(-time.Duration(prs.adminConfigService.GetPaymentAbandonAfterMinutes()) * time.Minute)
+	for i := range payments {
+		result.PaymentsChecked++
+		if err := prs.reconcilePayment(&payments[i], abandonBefore, now, result); err != nil {
+			result.Errors++
+			logrus.Errorf("Failed to reconcile payment %d: %v", payments[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// reconcilePayment brings one payment in line with its gateway order. Captured payments are completed,
+// orders whose attempts all failed fail the payment, and orders never attempted are abandoned.
+func (prs *PaymentReconciliationService) reconcilePayment(payment *models.Payment, abandonBefore, now time.Time, result *models.PaymentReconciliationResult) error {
+	gateway, err := prs.gatewayFor(payment)
+	if err != nil {
+		return err
+	}
+	orderID := *payment.GatewayOrderID
+	order, err := gateway.GetOrderStatus(orderID)
+	if err != nil {
+		return err
+	}
+
+	if order.Status == GatewayOrderCreated {
+		// The customer never attempted to pay
+		if payment.CreatedAt.Before(abandonBefore) {
+			abandoned, err := prs.paymentService.AbandonGatewayPayment(payment, fmt.Sprintf("Payment abandoned: no payment attempt at %s", gateway.Name()))
+			if err != nil {
+				return err
+			}
+			if abandoned {
+				result.PaymentsAbandoned++
+			}
+		}
+		return nil
+	}
+
+	var captured, authorized, lastFailed *GatewayPayment
+	failed := 0
+	for i := range order.Payments {
+		attempt := &order.Payments[i]
+		switch attempt.Status {
+		case GatewayPaymentCaptured:
+			captured = attempt
+		case GatewayPaymentAuthorized:
+			authorized = attempt
+		case GatewayPaymentFailed:
+			lastFailed = attempt
+			failed++
+		}
+	}
+
+	switch {
+	case captured != nil:
+		if math.Abs(captured.Amount-payment.Amount) > 0.01 {
+			return prs.flag(&models.PaymentDiscrepancy{
+				PaymentID:        &payment.ID,
+				GatewayPaymentID: captured.ID,
+				GatewayOrderID:   orderID,
+				DiscrepancyType:  models.PaymentDiscrepancyAmountMismatch,
+				LocalStatus:      string(payment.Status),
+				GatewayStatus:    GatewayPaymentCaptured,
+				LocalAmount:      payment.Amount,
+				GatewayAmount:    captured.Amount,
+				Details:          fmt.Sprintf("%s captured a different amount, so the payment was not completed", gateway.Name()),
+			}, now, result)
+		}
+
+		previousStatus := payment.Status
+		completed, err := prs.paymentService.CompleteReconciledPayment(payment, captured.ID)
+		if err != nil {
+			return err
+		}
+		if !completed {
+			return nil
+		}
+		result.PaymentsCompleted++
+		logrus.Infof("Reconciliation completed %s payment %d captured at %s", previousStatus, payment.ID, gateway.Name())
+
+		if previousStatus != models.PaymentStatusPending {
+			// What the payment was for may have been released when it expired
+			return prs.flag(&models.PaymentDiscrepancy{
+				PaymentID:        &payment.ID,
+				GatewayPaymentID: captured.ID,
+				GatewayOrderID:   orderID,
+				DiscrepancyType:  models.PaymentDiscrepancyCapturedAfterExpiry,
+				LocalStatus:      string(previousStatus),
+				GatewayStatus:    GatewayPaymentCaptured,
+				LocalAmount:      payment.Amount,
+				GatewayAmount:    captured.Amount,
+				Details:          fmt.Sprintf("Captured after the payment was marked %s; check the %s it paid for or refund it", previousStatus, payment.RelatedEntityType),
+			}, now, result)
+		}
+		return nil
+
+	case authorized != nil:
+		return prs.paymentService.RecordGatewayAuthorization(payment, authorized.ID)
+
+	case failed > 0 && failed == len(order.Payments):
+		reason := lastFailed.FailureReason
+		if reason == "" {
+			reason = "payment failed"
+		}
+		failedNow, err := prs.paymentService.FailGatewayPayment(payment, lastFailed.ID, reason)
+		if err != nil {
+			return err
+		}
+		if failedNow {
+			result.PaymentsFailed++
+		}
+	}
+	return nil
+}
+
+// gatewayFor gets the gateway a payment went through. The Razorpay gateway uses the service the
+// reconciliation service was created with.
+func (prs *PaymentReconciliationService) gatewayFor(payment *models.Payment) (PaymentGateway, error) {
+	if payment.GatewayProvider == PaymentGatewayRazorpay {
+		if _, registered := registeredPaymentGateway(PaymentGatewayRazorpay); !registered {
+			return NewRazorpayGateway(prs.razorpayService), nil
+		}
+	}
+	return GetPaymentGateway(payment.GatewayProvider)
+}
+
+// reconcileSettlements records settlements from Razorpay's recent settlement reports, flags settled
+// payments that do not match, and flags captured payments that were not settled in time
+func (prs *PaymentReconciliationService) reconcileSettlements(now time.Time, result *models.PaymentReconciliationResult) error {
+	windowDays := prs.adminConfigService.GetPaymentSettlementWindowDays()
+	local := now.In(workerCalendarLocation())
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	checkFrom := today.AddDate(0, 0, -(windowDays + settlementCheckDays))
+
+	for day := checkFrom; !day.After(today); day = day.AddDate(0, 0, 1) {
+		items, err := prs.razorpayService.GetSettlementRecon(day)
+		if err != nil {
+			result.Errors++
+			logrus.Errorf("Failed to get Razorpay settlements of %s: %v", day.Format("2006-01-02"), err)
+			continue
+		}
+		for _, item := range items {
+			if stringField(item, "type") != "payment" {
+				continue
+			}
+			result.SettlementsChecked++
+			if err := prs.reconcileSettlement(item, now, result); err != nil {
+				result.Errors++
+				logrus.Errorf("Failed to reconcile settlement of %s: %v", stringField(item, "entity_id"), err)
+			}
+		}
+	}
+
+	// Payments captured in the checked days should have settled by the end of the window
+	unsettled, err := prs.paymentRepo.GetUnsettledPayments(checkFrom, now.AddDate(0, 0, -windowDays), 500)
+	if err != nil {
+		return fmt.Errorf("failed to get unsettled payments: %v", err)
+	}
+	for i := range unsettled {
+		payment := &unsettled[i]
+		err := prs.flag(&models.PaymentDiscrepancy{
+			PaymentID:        &payment.ID,
+			GatewayPaymentID: *payment.GatewayPaymentID,
+			DiscrepancyType:  models.PaymentDiscrepancyNotSettled,
+			LocalStatus:      string(payment.Status),
+			LocalAmount:      payment.Amount,
+			Details:          fmt.Sprintf("Not in any Razorpay settlement %d days after capture", windowDays),
+		}, now, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	resolved, err := prs.discrepancyRepo.ResolveSettled(now)
+	if err != nil {
+		return fmt.Errorf("failed to resolve settled discrepancies: %v", err)
+	}
+	result.Resolved += int(resolved)
+	return nil
+}
+
+// reconcileSettlement matches one settled Razorpay payment with its local payment and records the settlement
+func (prs *PaymentReconciliationService) reconcileSettlement(item map[string]interface{}, now time.Time, result *models.PaymentReconciliationResult) error {
+	gatewayPaymentID := stringField(item, "entity_id")
+	amount, _ := paiseField(item, "amount")
+
+	payment, err := prs.paymentRepo.GetByGatewayPaymentID(gatewayPaymentID)
+	if err != nil {
+		return prs.flag(&models.PaymentDiscrepancy{
+			GatewayPaymentID: gatewayPaymentID,
+			GatewayOrderID:   stringField(item, "order_id"),
+			DiscrepancyType:  models.PaymentDiscrepancyUnknownSettlement,
+			GatewayStatus:    "settled",
+			GatewayAmount:    amount,
+			Details:          "Razorpay settled a payment that has no local payment",
+		}, now, result)
+	}
+
+	if math.Abs(amount-payment.Amount) > 0.01 {
+		err := prs.flag(&models.PaymentDiscrepancy{
+			PaymentID:        &payment.ID,
+			GatewayPaymentID: gatewayPaymentID,
+			GatewayOrderID:   stringField(item, "order_id"),
+			DiscrepancyType:  models.PaymentDiscrepancySettlementAmountMismatch,
+			LocalStatus:      string(payment.Status),
+			GatewayStatus:    "settled",
+			LocalAmount:      payment.Amount,
+			GatewayAmount:    amount,
+			Details:          "Razorpay settled a different amount than the payment",
+		}, now, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	settlementID := stringField(item, "settlement_id")
+	if settlementID == "" {
+		return nil
+	}
+	settledAt := now
+	if unix, ok := item["settled_at"].(float64); ok && unix > 0 {
+		settledAt = time.Unix(int64(unix), 0)
+	}
+	fee, _ := paiseField(item, "fee")
+	tax, _ := paiseField(item, "tax")
+
+	recorded, err := prs.paymentRepo.RecordSettlement(payment.ID, settlementID, settledAt, fee, tax)
+	if err != nil {
+		return fmt.Errorf("failed to record settlement: %v", err)
+	}
+	if recorded {
+		result.PaymentsSettled++
+	}
+	return nil
+}
+
+// flag records a discrepancy, counting it when it is new
+func (prs *PaymentReconciliationService) flag(discrepancy *models.PaymentDiscrepancy, now time.Time, result *models.PaymentReconciliationResult) error {
+	created, err := prs.discrepancyRepo.Flag(discrepancy, now)
+	if err != nil {
+		return fmt.Errorf("failed to flag %s discrepancy: %v", discrepancy.DiscrepancyType, err)
+	}
+	if created {
+		result.Discrepancies++
+		logrus.Warnf("Payment discrepancy %s for Razorpay payment %s: %s", discrepancy.DiscrepancyType, discrepancy.GatewayPaymentID, discrepancy.Details)
+	}
+	return nil
+}
+
+// GetDiscrepancies gets payment discrepancies (admin)
+func (prs *PaymentReconciliationService) GetDiscrepancies(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, *repositories.Pagination, error) {
+	return prs.discrepancyRepo.GetDiscrepancies(filters)
+}
+
+// ResolveDiscrepancy marks a discrepancy resolved once an admin has dealt with it
+func (prs *PaymentReconciliationService) ResolveDiscrepancy(id uint, req *models.ResolvePaymentDiscrepancyRequest, adminID uint) (*models.PaymentDiscrepancy, error) {
+	if _, err := prs.discrepancyRepo.GetByID(id); err != nil {
+		return nil, errors.New("discrepancy not found")
+	}
+
+	resolved, err := prs.discrepancyRepo.Resolve(id, adminID, req.Notes, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve discrepancy: %v", err)
+	}
+	if !resolved {
+		return nil, errors.New("discrepancy is already resolved")
+	}
+	return prs.discrepancyRepo.GetByID(id)
+}
+
+// ExportDiscrepancies builds the CSV reconciliation report of the discrepancies matching the filters
+func (prs *PaymentReconciliationService) ExportDiscrepancies(filters *models.PaymentDiscrepancyFilters) ([]byte, string, error) {
+	discrepancies, err := prs.discrepancyRepo.GetDiscrepanciesForExport(filters)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get discrepancies: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"ID", "Type", "Status", "Payment Reference", "Razorpay Payment ID", "Razorpay Order ID",
+		"Local Status", "Gateway Status", "Local Amount", "Gateway Amount", "Details", "Detected At", "Resolved At", "Resolution Notes"})
+	for _, discrepancy := range discrepancies {
+		paymentReference := ""
+		if discrepancy.Payment != nil {
+			paymentReference = discrepancy.Payment.PaymentReference
+		}
+		resolvedAt := ""
+		if discrepancy.ResolvedAt != nil {
+			resolvedAt = discrepancy.ResolvedAt.In(workerCalendarLocation()).Format("2006-01-02 15:04")
+		}
+		writer.Write([]string{
+			fmt.Sprintf("%d", discrepancy.ID),
+			string(discrepancy.DiscrepancyType),
+			string(discrepancy.Status),
+			paymentReference,
+			discrepancy.GatewayPaymentID,
+			discrepancy.GatewayOrderID,
+			discrepancy.LocalStatus,
+			discrepancy.GatewayStatus,
+			fmt.Sprintf("%.2f", discrepancy.LocalAmount),
+			fmt.Sprintf("%.2f", discrepancy.GatewayAmount),
+			discrepancy.Details,
+			discrepancy.DetectedAt.In(workerCalendarLocation()).Format("2006-01-02 15:04"),
+			resolvedAt,
+			discrepancy.ResolutionNotes,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to write reconciliation report: %v", err)
+	}
+
+	return buf.Bytes(), fmt.Sprintf("payment_reconciliation_%s.csv", time.Now().In(workerCalendarLocation()).Format("2006-01-02")), nil
+}
+
+// StartReconciliationJob starts a periodic job that reconciles payments with Razorpay
+func (prs *PaymentReconciliationService) StartReconciliationJob() {
+	ticker := time.NewTicker(1 * time.Hour) // Run every hour
+	go func() {
+		for range ticker.C {
+			result, err := prs.Reconcile()
+			if err != nil {
+				logrus.Errorf("Payment reconciliation job failed: %v", err)
+				continue
+			}
+			logrus.Infof("Payment reconciliation checked %d payments and %d settlements: %d completed, %d failed, %d abandoned, %d settled, %d discrepancies, %d resolved",
+				result.PaymentsChecked, result.SettlementsChecked, result.PaymentsCompleted, result.PaymentsFailed,
+				result.PaymentsAbandoned, result.PaymentsSettled, result.Discrepancies, result.Resolved)
+		}
+	}()
+	logrus.Info("Payment reconciliation job started")
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+)
+
+// fakeReconciliationPayments is an in-memory reconciliationPaymentStore
+type fakeReconciliationPayments struct {
+	reconcilable []models.Payment
+	unsettled    []models.Payment
+	completed    []models.Payment // Looked up by gateway payment ID when settled
+	settled      map[uint]string  // Payment ID to settlement ID
+}
+
+func (f *fakeReconciliationPayments) GetReconcilablePayments(from, to time.Time, limit int) ([]models.Payment, error) {
+	return f.reconcilable, nil
+}
+
+func (f *fakeReconciliationPayments) GetUnsettledPayments(from, to time.Time, limit int) ([]models.Payment, error) {
+	return f.unsettled, nil
+}
+
+func (f *fakeReconciliationPayments) GetByGatewayPaymentID(paymentID string) (*models.Payment, error) {
+	for i := range f.completed {
+		payment := &f.completed[i]
+		if payment.GatewayPaymentID != nil && *payment.GatewayPaymentID == paymentID {
+			return payment, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeReconciliationPayments) RecordSettlement(paymentID uint, settlementID string, settledAt time.Time, fee, tax float64) (bool, error) {
+	if _, ok := f.settled[paymentID]; ok {
+		return false, nil
+	}
+	f.settled[paymentID] = settlementID
+	return true, nil
+}
+
+// fakeReconciliationDiscrepancies is an in-memory reconciliationDiscrepancyStore
+type fakeReconciliationDiscrepancies struct {
+	flagged []models.PaymentDiscrepancy
+}
+
+func (f *fakeReconciliationDiscrepancies) Flag(discrepancy *models.PaymentDiscrepancy, now time.Time) (bool, error) {
+	f.flagged = append(f.flagged, *discrepancy)
+	return true, nil
+}
+
+func (f *fakeReconciliationDiscrepancies) ResolveSettled(now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeReconciliationDiscrepancies) Resolve(id uint, adminID uint, notes string, now time.Time) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeReconciliationDiscrepancies) GetByID(id uint) (*models.PaymentDiscrepancy, error) {
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeReconciliationDiscrepancies) GetDiscrepancies(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, *repositories.Pagination, error) {
+	return f.flagged, &repositories.Pagination{}, nil
+}
+
+func (f *fakeReconciliationDiscrepancies) GetDiscrepanciesForExport(filters *models.PaymentDiscrepancyFilters) ([]models.PaymentDiscrepancy, error) {
+	return f.flagged, nil
+}
+
+// ofType returns the flagged discrepancies of a type
+func (f *fakeReconciliationDiscrepancies) ofType(discrepancyType models.PaymentDiscrepancyType) []models.PaymentDiscrepancy {
+	var found []models.PaymentDiscrepancy
+	for _, discrepancy := range f.flagged {
+		if discrepancy.DiscrepancyType == discrepancyType {
+			found = append(found, discrepancy)
+		}
+	}
+	return found
+}
+
+// fakeReconciliationUpdater records the status changes reconciliation makes
+type fakeReconciliationUpdater struct {
+	completed  map[uint]string // Payment ID to gateway payment ID
+	failed     map[uint]string // Payment ID to failure reason
+	abandoned  map[uint]string // Payment ID to reason
+	authorized map[uint]string // Payment ID to gateway payment ID
+}
+
+func (f *fakeReconciliationUpdater) CompleteReconciledPayment(payment *models.Payment, gatewayPaymentID string) (bool, error) {
+	f.completed[payment.ID] = gatewayPaymentID
+	payment.Status = models.PaymentStatusCompleted
+	return true, nil
+}
+
+func (f *fakeReconciliationUpdater) FailGatewayPayment(payment *models.Payment, gatewayPaymentID string, reason string) (bool, error) {
+	f.failed[payment.ID] = reason
+	payment.Status = models.PaymentStatusFailed
+	return true, nil
+}
+
+func (f *fakeReconciliationUpdater) AbandonGatewayPayment(payment *models.Payment, reason string) (bool, error) {
+	f.abandoned[payment.ID] = reason
+	payment.Status = models.PaymentStatusAbandoned
+	return true, nil
+}
+
+func (f *fakeReconciliationUpdater) RecordGatewayAuthorization(payment *models.Payment, gatewayPaymentID string) error {
+	f.authorized[payment.ID] = gatewayPaymentID
+	return nil
+}
+
+// fakeReconciliationSettings are fixed reconciliation settings
+type fakeReconciliationSettings struct {
+	abandonAfterMinutes  int
+	settlementWindowDays int
+}
+
+func (f fakeReconciliationSettings) GetPaymentAbandonAfterMinutes() int {
+	return f.abandonAfterMinutes
+}
+
+func (f fakeReconciliationSettings) GetPaymentSettlementWindowDays() int {
+	return f.settlementWindowDays
+}
+
+// reconciliationFixture is a reconciliation service wired to a fake Razorpay API and in-memory stores
+type reconciliationFixture struct {
+	razorpay      *fakeRazorpay
+	payments      *fakeReconciliationPayments
+	discrepancies *fakeReconciliationDiscrepancies
+	updater       *fakeReconciliationUpdater
+	service       *PaymentReconciliationService
+}
+
+func newReconciliationFixture(t *testing.T, payments ...models.Payment) *reconciliationFixture {
+	t.Helper()
+	fixture := &reconciliationFixture{
+		razorpay:      newFakeRazorpay(t),
+		payments:      &fakeReconciliationPayments{reconcilable: payments, settled: map[uint]string{}},
+		discrepancies: &fakeReconciliationDiscrepancies{},
+		updater: &fakeReconciliationUpdater{
+			completed:  map[uint]string{},
+			failed:     map[uint]string{},
+			abandoned:  map[uint]string{},
+			authorized: map[uint]string{},
+		},
+	}
+	fixture.service = &PaymentReconciliationService{
+		paymentRepo:        fixture.payments,
+		discrepancyRepo:    fixture.discrepancies,
+		paymentService:     fixture.updater,
+		razorpayService:    fixture.razorpay.service(),
+		adminConfigService: fakeReconciliationSettings{abandonAfterMinutes: 60, settlementWindowDays: 3},
+	}
+	return fixture
+}
+
+// testGatewayPayment builds a Razorpay payment of a gateway order created some time ago
+func testGatewayPayment(id uint, orderID string, amount float64, status models.PaymentStatus, age time.Duration) models.Payment {
+	payment := models.Payment{
+		Amount:            amount,
+		Status:            status,
+		Method:            models.PaymentMethodOnline,
+		GatewayProvider:   PaymentGatewayRazorpay,
+		GatewayOrderID:    &orderID,
+		RelatedEntityType: "booking",
+	}
+	payment.ID = id
+	payment.CreatedAt = time.Now().Add(-age)
+	return payment
+}
+
+func TestReconcileCompletesCapturedPayment(t *testing.T) {
+	fixture := newReconciliationFixture(t,
+		testGatewayPayment(1, "order_captured", 499, models.PaymentStatusPending, 30*time.Minute),
+		testGatewayPayment(2, "order_late", 250, models.PaymentStatusExpired, 3*time.Hour),
+	)
+	fixture.razorpay.addOrder("order_captured", GatewayOrderPaid,
+		map[string]interface{}{"id": "pay_failed_first", "status": "failed", "amount": 49900.0},
+		map[string]interface{}{"id": "pay_captured", "status": "captured", "amount": 49900.0},
+	)
+	fixture.razorpay.addOrder("order_late", GatewayOrderPaid,
+		map[string]interface{}{"id": "pay_late", "status": "captured", "amount": 25000.0},
+	)
+
+	result, err := fixture.service.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if got := fixture.updater.completed[1]; got != "pay_captured" {
+		t.Errorf("payment 1 completed with %q, want pay_captured", got)
+	}
+	if got := fixture.updater.completed[2]; got != "pay_late" {
+		t.Errorf("payment 2 completed with %q, want pay_late", got)
+	}
+	if result.PaymentsChecked != 2 || result.PaymentsCompleted != 2 || result.Errors != 0 {
+		t.Errorf("result = %+v, want 2 checked, 2 completed and no errors", result)
+	}
+
+	// Completing a payment that had already expired is flagged for an admin to check
+	late := fixture.discrepancies.ofType(models.PaymentDiscrepancyCapturedAfterExpiry)
+	if len(late) != 1 || *late[0].PaymentID != 2 || late[0].LocalStatus != string(models.PaymentStatusExpired) {
+		t.Errorf("captured after expiry discrepancies = %+v, want one for payment 2", late)
+	}
+}
+
+func TestReconcileFlagsCapturedAmountMismatch(t *testing.T) {
+	fixture := newReconciliationFixture(t, testGatewayPayment(1, "order_short", 499, models.PaymentStatusPending, time.Hour))
+	fixture.razorpay.addOrder("order_short", GatewayOrderPaid,
+		map[string]interface{}{"id": "pay_short", "status": "captured", "amount": 100.0},
+	)
+
+	if _, err := fixture.service.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if _, completed := fixture.updater.completed[1]; completed {
+		t.Error("payment captured for a different amount was completed")
+	}
+	mismatches := fixture.discrepancies.ofType(models.PaymentDiscrepancyAmountMismatch)
+	if len(mismatches) != 1 || mismatches[0].GatewayAmount != 1 || mismatches[0].LocalAmount != 499 {
+		t.Errorf("amount mismatch discrepancies = %+v, want one of 499 against 1", mismatches)
+	}
+}
+
+func TestReconcileFailsPaymentWhoseAttemptsAllFailed(t *testing.T) {
+	fixture := newReconciliationFixture(t,
+		testGatewayPayment(1, "order_failed", 499, models.PaymentStatusPending, 30*time.Minute),
+		testGatewayPayment(2, "order_retrying", 499, models.PaymentStatusPending, 30*time.Minute),
+	)
+	fixture.razorpay.addOrder("order_failed", GatewayOrderAttempted,
+		map[string]interface{}{"id": "pay_1", "status": "failed", "amount": 49900.0, "error_description": "Card declined"},
+		map[string]interface{}{"id": "pay_2", "status": "failed", "amount": 49900.0, "error_description": "Insufficient funds"},
+	)
+	fixture.razorpay.addOrder("order_retrying", GatewayOrderAttempted,
+		map[string]interface{}{"id": "pay_3", "status": "failed", "amount": 49900.0},
+		map[string]interface{}{"id": "pay_4", "status": "created", "amount": 49900.0},
+	)
+
+	result, err := fixture.service.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if got := fixture.updater.failed[1]; got != "Insufficient funds" {
+		t.Errorf("payment 1 failed with %q, want the last attempt's reason", got)
+	}
+	if _, failed := fixture.updater.failed[2]; failed {
+		t.Error("payment with an attempt still in progress was failed")
+	}
+	if result.PaymentsFailed != 1 {
+		t.Errorf("PaymentsFailed = %d, want 1", result.PaymentsFailed)
+	}
+}
+
+func TestReconcileAbandonsUnattemptedOrders(t *testing.T) {
+	fixture := newReconciliationFixture(t,
+		testGatewayPayment(1, "order_abandoned", 499, models.PaymentStatusPending, 2*time.Hour),
+		testGatewayPayment(2, "order_fresh", 499, models.PaymentStatusPending, 30*time.Minute),
+		testGatewayPayment(3, "order_expired", 499, models.PaymentStatusExpired, 5*time.Hour),
+	)
+	fixture.razorpay.addOrder("order_abandoned", GatewayOrderCreated)
+	fixture.razorpay.addOrder("order_fresh", GatewayOrderCreated)
+	fixture.razorpay.addOrder("order_expired", GatewayOrderCreated)
+
+	result, err := fixture.service.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	for _, id := range []uint{1, 3} {
+		if _, abandoned := fixture.updater.abandoned[id]; !abandoned {
+			t.Errorf("payment %d was not abandoned", id)
+		}
+	}
+	if _, abandoned := fixture.updater.abandoned[2]; abandoned {
+		t.Error("payment still inside the abandon window was abandoned")
+	}
+	if result.PaymentsAbandoned != 2 {
+		t.Errorf("PaymentsAbandoned = %d, want 2", result.PaymentsAbandoned)
+	}
+}
+
+func TestReconcileCountsUnknownOrdersAsErrors(t *testing.T) {
+	fixture := newReconciliationFixture(t, testGatewayPayment(1, "order_missing", 499, models.PaymentStatusPending, time.Hour))
+
+	result, err := fixture.service.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.Errors != 1 || len(fixture.updater.abandoned)+len(fixture.updater.failed)+len(fixture.updater.completed) != 0 {
+		t.Errorf("result = %+v, want one error and no status changes", result)
+	}
+}
+
+func TestReconcileSettlements(t *testing.T) {
+	settledID := "pay_settled"
+	mismatchedID := "pay_mismatched"
+	settled := testGatewayPayment(1, "order_settled", 499, models.PaymentStatusCompleted, 48*time.Hour)
+	settled.GatewayPaymentID = &settledID
+	mismatched := testGatewayPayment(2, "order_mismatched", 800, models.PaymentStatusCompleted, 48*time.Hour)
+	mismatched.GatewayPaymentID = &mismatchedID
+
+	fixture := newReconciliationFixture(t)
+	fixture.payments.completed = []models.Payment{settled, mismatched}
+
+	day := time.Now().In(workerCalendarLocation()).AddDate(0, 0, -1).Format("2006-01-02")
+	settledAt := float64(time.Now().Add(-24 * time.Hour).Unix())
+	fixture.razorpay.addSettlement(day, map[string]interface{}{
+		"type": "payment", "entity_id": settledID, "order_id": "order_settled",
+		"amount": 49900.0, "fee": 1180.0, "tax": 180.0, "settlement_id": "setl_1", "settled_at": settledAt,
+	})
+	fixture.razorpay.addSettlement(day, map[string]interface{}{
+		"type": "payment", "entity_id": mismatchedID, "order_id": "order_mismatched",
+		"amount": 75000.0, "fee": 1770.0, "tax": 270.0, "settlement_id": "setl_1", "settled_at": settledAt,
+	})
+	fixture.razorpay.addSettlement(day, map[string]interface{}{
+		"type": "payment", "entity_id": "pay_unknown", "order_id": "order_unknown",
+		"amount": 10000.0, "settlement_id": "setl_1", "settled_at": settledAt,
+	})
+	fixture.razorpay.addSettlement(day, map[string]interface{}{
+		"type": "refund", "entity_id": "rfnd_1", "amount": 5000.0, "settlement_id": "setl_1",
+	})
+
+	result, err := fixture.service.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if result.SettlementsChecked != 3 {
+		t.Errorf("SettlementsChecked = %d, want the 3 payments and not the refund", result.SettlementsChecked)
+	}
+	if fixture.payments.settled[1] != "setl_1" || fixture.payments.settled[2] != "setl_1" || result.PaymentsSettled != 2 {
+		t.Errorf("settlements recorded = %v (%d), want payments 1 and 2 in setl_1", fixture.payments.settled, result.PaymentsSettled)
+	}
+
+	mismatches := fixture.discrepancies.ofType(models.PaymentDiscrepancySettlementAmountMismatch)
+	if len(mismatches) != 1 || *mismatches[0].PaymentID != 2 || mismatches[0].GatewayAmount != 750 || mismatches[0].LocalAmount != 800 {
+		t.Errorf("settlement amount mismatches = %+v, want one for payment 2 of 750 against 800", mismatches)
+	}
+	unknown := fixture.discrepancies.ofType(models.PaymentDiscrepancyUnknownSettlement)
+	if len(unknown) != 1 || unknown[0].GatewayPaymentID != "pay_unknown" || unknown[0].PaymentID != nil {
+		t.Errorf("unknown settlements = %+v, want one for pay_unknown", unknown)
+	}
+}
+
+func TestReconcileFlagsPaymentsNotSettledInTime(t *testing.T) {
+	gatewayPaymentID := "pay_unsettled"
+	unsettled := testGatewayPayment(1, "order_unsettled", 499, models.PaymentStatusCompleted, 5*24*time.Hour)
+	unsettled.GatewayPaymentID = &gatewayPaymentID
+
+	fixture := newReconciliationFixture(t)
+	fixture.payments.unsettled = []models.Payment{unsettled}
+
+	if _, err := fixture.service.Reconcile(); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	flagged := fixture.discrepancies.ofType(models.PaymentDiscrepancyNotSettled)
+	if len(flagged) != 1 || flagged[0].GatewayPaymentID != gatewayPaymentID {
+		t.Errorf("not settled discrepancies = %+v, want one for %s", flagged, gatewayPaymentID)
+	}
+}
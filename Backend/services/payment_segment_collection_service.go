@@ -0,0 +1,381 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pausableAssignmentStatuses are the assignment statuses an escalation can put on hold
+var pausableAssignmentStatuses = []models.AssignmentStatus{
+	models.AssignmentStatusAssigned,
+	models.AssignmentStatusAccepted,
+	models.AssignmentStatusInProgress,
+}
+
+// PaymentSegmentCollectionService reminds customers about upcoming payment segments, marks them overdue,
+// escalates the ones that stay unpaid and pays the segments customers opted in to from their wallet
+type PaymentSegmentCollectionService struct {
+	paymentSegmentRepo   *repositories.PaymentSegmentRepository
+	bookingRepo          *repositories.BookingRepository
+	workerAssignmentRepo *repositories.WorkerAssignmentRepository
+	adminConfigService   *AdminConfigService
+	activityService      *BookingActivityService
+}
+
+// NewPaymentSegmentCollectionService creates a new payment segment collection service
+func NewPaymentSegmentCollectionService() *PaymentSegmentCollectionService {
+	return &PaymentSegmentCollectionService{
+		paymentSegmentRepo:   repositories.NewPaymentSegmentRepository(),
+		bookingRepo:          repositories.NewBookingRepository(),
+		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
+		adminConfigService:   NewAdminConfigService(),
+		activityService:      NewBookingActivityService(),
+	}
+}
+
+// SetAutoDebit turns wallet auto-debit on or off for the customer's unpaid segments of a booking
+func (pscs *PaymentSegmentCollectionService) SetAutoDebit(bookingID uint, userID uint, req *models.SegmentAutoDebitRequest) (*models.PaymentProgress, error) {
+	booking, err := pscs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+	if booking.UserID != userID {
+		return nil, errors.New("unauthorized access to booking")
+	}
+	if booking.Status == models.BookingStatusCancelled {
+		return nil, errors.New("booking is cancelled")
+	}
+
+	updated, err := pscs.paymentSegmentRepo.SetAutoDebit(bookingID, req.SegmentNumber, *req.Enabled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update auto-debit: %v", err)
+	}
+	if updated == 0 {
+		return nil, errors.New("no unpaid payment segments found")
+	}
+
+	logrus.Infof("User %d set auto-debit to %t on %d payment segments of booking %d", userID, *req.Enabled, updated, bookingID)
+	return pscs.paymentSegmentRepo.GetPaymentProgress(bookingID)
+}
+
+// ProcessSegments pays due auto-debit segments, marks unpaid ones overdue, sends due-date reminders
+// and escalates segments that stayed overdue
+func (pscs *PaymentSegmentCollectionService) ProcessSegments() error {
+	now := time.Now()
+
+	var firstErr error
+	steps := []func(time.Time) error{
+		pscs.autoDebitDueSegments,
+		pscs.markOverdueSegments,
+		pscs.sendReminders,
+		pscs.escalateOverdueSegments,
+	}
+	for _, step := range steps {
+		if err := step(now); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// StartCollectionJob starts a periodic job that collects payment segments
+func (pscs *PaymentSegmentCollectionService) StartCollectionJob() {
+	ticker := time.NewTicker(1 * time.Hour) // Run every hour
+	go func() {
+		for range ticker.C {
+			if err := pscs.ProcessSegments(); err != nil {
+				logrus.Errorf("Payment segment collection job failed: %v", err)
+			}
+		}
+	}()
+	logrus.Info("Payment segment collection job started")
+}
+
+// ResumeAssignmentIfPaidUp lifts an overdue-payment pause once the booking has no overdue segments left
+func (pscs *PaymentSegmentCollectionService) ResumeAssignmentIfPaidUp(bookingID uint, actor models.ActivityActor) {
+	overdue, err := pscs.paymentSegmentRepo.CountSegmentsByStatus(bookingID, models.PaymentSegmentStatusOverdue)
+	if err != nil || overdue > 0 {
+		return
+	}
+
+	assignment, err := pscs.workerAssignmentRepo.GetByBookingID(bookingID)
+	if err != nil || assignment.PausedAt == nil {
+		return
+	}
+
+	if err := pscs.workerAssignmentRepo.SetPaused(assignment.ID, nil, ""); err != nil {
+		logrus.Errorf("Failed to resume assignment %d: %v", assignment.ID, err)
+		return
+	}
+	assignment.PausedAt = nil
+	assignment.PauseReason = ""
+
+	pscs.activityService.Record(bookingID, models.BookingActivityAssignmentResumed, actor, nil, map[string]interface{}{"assignment_id": assignment.ID}, "Overdue payments cleared, assignment resumed")
+	NotifyAssignmentResumed(assignment, &assignment.Booking)
+	logrus.Infof("Assignment %d of booking %d resumed after overdue payments were cleared", assignment.ID, bookingID)
+}
+
+// autoDebitDueSegments pays due segments from the wallet for customers who opted in
+func (pscs *PaymentSegmentCollectionService) autoDebitDueSegments(now time.Time) error {
+	retryBefore := now.Add(-time.Duration(pscs.adminConfigService.GetSegmentAutoDebitRetryHours()) * time.Hour)
+	segments, err := pscs.paymentSegmentRepo.GetSegmentsForAutoDebit(now, retryBefore)
+	if err != nil {
+		return fmt.Errorf("failed to get segments for auto-debit: %v", err)
+	}
+
+	for i := range segments {
+		segment := &segments[i]
+		if segment.Booking.Status == models.BookingStatusCancelled {
+			continue
+		}
+
+		// Claim the attempt so concurrent runs cannot debit the wallet twice
+		claimed, err := pscs.paymentSegmentRepo.ClaimAutoDebit(segment.ID, retryBefore)
+		if err != nil {
+			logrus.Errorf("Failed to claim auto-debit for payment segment %d: %v", segment.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		pscs.autoDebitSegment(segment)
+	}
+	return nil
+}
+
+// autoDebitSegment pays one segment from the customer's wallet and tells them how it went
+func (pscs *PaymentSegmentCollectionService) autoDebitSegment(segment *models.PaymentSegment) {
+	booking := &segment.Booking
+	description := fmt.Sprintf("Segment %d payment for booking", segment.SegmentNumber)
+
+	payment, err := NewUnifiedWalletService().DeductFromWalletForBooking(booking.UserID, segment.Amount, booking.ID, description)
+	if err != nil {
+		failure := err.Error()
+		if err := pscs.paymentSegmentRepo.SetAutoDebitFailure(segment.ID, failure); err != nil {
+			logrus.Errorf("Failed to record auto-debit failure for payment segment %d: %v", segment.ID, err)
+		}
+		logrus.Warnf("Auto-debit of payment segment %d for booking %d failed: %v", segment.ID, booking.ID, err)
+		NotifySegmentAutoDebit(segment, booking, failure)
+		return
+	}
+
+	if err := pscs.markSegmentPaid(segment, payment); err != nil {
+		// The wallet was debited; the segment has to be reconciled by hand
+		logrus.Errorf("Auto-debit payment %d taken but payment segment %d not updated: %v", payment.ID, segment.ID, err)
+		return
+	}
+
+	logrus.Infof("Auto-debited payment segment %d for booking %d: ₹%.2f", segment.ID, booking.ID, segment.Amount)
+	NotifySegmentAutoDebit(segment, booking, "")
+}
+
+// markSegmentPaid records an auto-debit payment on a segment and settles the booking once every segment is paid
+func (pscs *PaymentSegmentCollectionService) markSegmentPaid(segment *models.PaymentSegment, payment *models.Payment) error {
+	if err := pscs.paymentSegmentRepo.MarkAsPaid(segment.ID, payment.ID); err != nil {
+		return fmt.Errorf("failed to mark segment as paid: %v", err)
+	}
+	now := time.Now()
+	segment.Status = models.PaymentSegmentStatusPaid
+	segment.PaymentID = &payment.ID
+	segment.PaidAt = &now
+
+	allPaid, err := pscs.paymentSegmentRepo.IsAllSegmentsPaid(segment.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to check if all segments are paid: %v", err)
+	}
+	if allPaid {
+		booking, err := pscs.bookingRepo.GetByID(segment.BookingID)
+		if err != nil {
+			return fmt.Errorf("failed to get booking: %v", err)
+		}
+
+		booking.PaymentStatus = "completed"
+		if booking.Status == models.BookingStatusPartiallyPaid {
+			transition := TransitionContext{Actor: models.SystemActor(), Reason: fmt.Sprintf("Segment %d auto-debited from wallet", segment.SegmentNumber)}
+			if err := NewBookingStateMachine().TransitionBooking(booking, models.BookingStatusConfirmed, transition); err != nil {
+				return err
+			}
+		} else if err := pscs.bookingRepo.Update(booking); err != nil {
+			return fmt.Errorf("failed to update booking payment status: %v", err)
+		}
+	}
+
+	pscs.ResumeAssignmentIfPaidUp(segment.BookingID, models.SystemActor())
+	return nil
+}
+
+// markOverdueSegments moves pending segments past their due date to overdue and tells the customer
+func (pscs *PaymentSegmentCollectionService) markOverdueSegments(now time.Time) error {
+	segments, err := pscs.paymentSegmentRepo.GetPastDueSegments(now)
+	if err != nil {
+		return fmt.Errorf("failed to get past due segments: %v", err)
+	}
+
+	markedCount := 0
+	for i := range segments {
+		segment := &segments[i]
+		if segment.Booking.Status == models.BookingStatusCancelled {
+			continue
+		}
+
+		marked, err := pscs.paymentSegmentRepo.MarkOverdue(segment.ID)
+		if err != nil {
+			logrus.Errorf("Failed to mark payment segment %d overdue: %v", segment.ID, err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		segment.Status = models.PaymentSegmentStatusOverdue
+		markedCount++
+
+		pscs.activityService.Record(segment.BookingID, models.BookingActivitySegmentOverdue, models.SystemActor(), nil, map[string]interface{}{
+			"segment_number": segment.SegmentNumber,
+			"amount":         segment.Amount,
+			"due_date":       segment.DueDate,
+		}, fmt.Sprintf("Payment segment %d is overdue", segment.SegmentNumber))
+		NotifySegmentPaymentOverdue(segment, &segment.Booking)
+	}
+
+	if markedCount > 0 {
+		logrus.Infof("Marked %d payment segments overdue", markedCount)
+	}
+	return nil
+}
+
+// sendReminders reminds customers as each configured reminder day before a segment's due date passes
+func (pscs *PaymentSegmentCollectionService) sendReminders(now time.Time) error {
+	reminderDays := parseSegmentReminderDays(pscs.adminConfigService.GetSegmentReminderDays())
+	if len(reminderDays) == 0 {
+		return nil
+	}
+
+	segments, err := pscs.paymentSegmentRepo.GetSegmentsDueBefore(now, now.AddDate(0, 0, reminderDays[0]))
+	if err != nil {
+		return fmt.Errorf("failed to get segments due soon: %v", err)
+	}
+
+	for i := range segments {
+		segment := &segments[i]
+		if segment.Booking.Status == models.BookingStatusCancelled {
+			continue
+		}
+
+		// One reminder covers every reminder day passed since the last one
+		passed := 0
+		for _, days := range reminderDays {
+			if !segment.DueDate.After(now.AddDate(0, 0, days)) {
+				passed++
+			}
+		}
+		if passed <= segment.RemindersSent {
+			continue
+		}
+
+		if err := pscs.paymentSegmentRepo.SetRemindersSent(segment.ID, passed); err != nil {
+			logrus.Errorf("Failed to record reminder for payment segment %d: %v", segment.ID, err)
+			continue
+		}
+		segment.RemindersSent = passed
+		NotifySegmentPaymentReminder(segment, &segment.Booking)
+	}
+	return nil
+}
+
+// escalateOverdueSegments runs the configured escalation action for segments that stayed overdue
+func (pscs *PaymentSegmentCollectionService) escalateOverdueSegments(now time.Time) error {
+	action := pscs.adminConfigService.GetSegmentEscalationAction()
+	if action == models.SegmentEscalationNone {
+		return nil
+	}
+
+	overdueBefore := now.AddDate(0, 0, -pscs.adminConfigService.GetSegmentEscalationDays())
+	segments, err := pscs.paymentSegmentRepo.GetSegmentsToEscalate(overdueBefore)
+	if err != nil {
+		return fmt.Errorf("failed to get segments to escalate: %v", err)
+	}
+
+	for i := range segments {
+		segment := &segments[i]
+		if segment.Booking.Status == models.BookingStatusCancelled {
+			continue
+		}
+
+		escalated, err := pscs.paymentSegmentRepo.MarkEscalated(segment.ID)
+		if err != nil {
+			logrus.Errorf("Failed to escalate payment segment %d: %v", segment.ID, err)
+			continue
+		}
+		if !escalated {
+			continue
+		}
+
+		if action == models.SegmentEscalationPauseAssignment {
+			pscs.pauseAssignment(segment)
+		}
+		NotifySegmentPaymentEscalated(segment, &segment.Booking, action)
+		logrus.Infof("Escalated overdue payment segment %d of booking %d (%s)", segment.ID, segment.BookingID, action)
+	}
+	return nil
+}
+
+// pauseAssignment puts the booking's worker assignment on hold until the overdue segment is paid
+func (pscs *PaymentSegmentCollectionService) pauseAssignment(segment *models.PaymentSegment) {
+	assignment, err := pscs.workerAssignmentRepo.GetByBookingID(segment.BookingID)
+	if err != nil {
+		// No worker assigned yet, so there is nothing to pause
+		return
+	}
+	if assignment.PausedAt != nil || !containsAssignmentStatus(pausableAssignmentStatuses, assignment.Status) {
+		return
+	}
+
+	now := time.Now()
+	reason := fmt.Sprintf("Payment %d of ₹%.2f is overdue", segment.SegmentNumber, segment.Amount)
+	if err := pscs.workerAssignmentRepo.SetPaused(assignment.ID, &now, reason); err != nil {
+		logrus.Errorf("Failed to pause assignment %d: %v", assignment.ID, err)
+		return
+	}
+	assignment.PausedAt = &now
+	assignment.PauseReason = reason
+
+	pscs.activityService.Record(segment.BookingID, models.BookingActivityAssignmentPaused, models.SystemActor(), nil, map[string]interface{}{
+		"assignment_id":  assignment.ID,
+		"segment_number": segment.SegmentNumber,
+	}, reason)
+	NotifyAssignmentPaused(assignment, &segment.Booking)
+}
+
+// parseSegmentReminderDays parses the comma separated reminder days, largest first
+func parseSegmentReminderDays(value string) []int {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || day <= 0 || containsInt(days, day) {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// containsInt reports whether value is in values
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -6,26 +6,40 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"treesindia/database"
 	"treesindia/models"
 	"treesindia/repositories"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type PaymentService struct {
-	paymentRepo     *repositories.PaymentRepository
-	razorpayService *RazorpayService
+	paymentRepo *repositories.PaymentRepository
+	userRepo    *repositories.UserRepository
 }
 
 func NewPaymentService() *PaymentService {
 	return &PaymentService{
-		paymentRepo:     repositories.NewPaymentRepository(),
-		razorpayService: NewRazorpayService(),
+		paymentRepo: repositories.NewPaymentRepository(),
+		userRepo:    repositories.NewUserRepository(),
 	}
 }
 
 // CreatePayment creates a new payment record
 func (ps *PaymentService) CreatePayment(req *models.CreatePaymentRequest) (*models.Payment, error) {
+	payment := ps.newPayment(req)
+
+	err := ps.paymentRepo.Create(payment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment: %v", err)
+	}
+
+	return payment, nil
+}
+
+// newPayment builds a pending payment record from a request without saving it
+func (ps *PaymentService) newPayment(req *models.CreatePaymentRequest) *models.Payment {
 	// Generate payment reference
 	paymentReference := ps.generatePaymentReference()
 
@@ -55,86 +69,197 @@ func (ps *PaymentService) CreatePayment(req *models.CreatePaymentRequest) (*mode
 		InitiatedAt:      time.Now(),
 	}
 
-	err := ps.paymentRepo.Create(payment)
+	return payment
+}
+
+// CreateGatewayOrder creates a payment record and an order for it at the active payment gateway.
+// The returned checkout data is what the client opens the gateway's checkout with.
+func (ps *PaymentService) CreateGatewayOrder(req *models.CreatePaymentRequest) (*models.Payment, map[string]interface{}, error) {
+	gateway, err := ActivePaymentGateway()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment: %v", err)
+		return nil, nil, err
 	}
 
-	return payment, nil
-}
-
-// CreateRazorpayOrder creates a Razorpay order and payment record
-func (ps *PaymentService) CreateRazorpayOrder(req *models.CreatePaymentRequest) (*models.Payment, map[string]interface{}, error) {
 	// Create payment record first
 	payment, err := ps.CreatePayment(req)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Create Razorpay order
-	razorpayOrder, err := ps.razorpayService.CreateOrder(payment.Amount, payment.PaymentReference, req.Description)
+	checkout, err := ps.attachGatewayOrder(gateway, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, checkout, nil
+}
+
+// RefreshGatewayOrder replaces the gateway order of a pending payment with a new order at the
+// active payment gateway, returning the new checkout data
+func (ps *PaymentService) RefreshGatewayOrder(payment *models.Payment) (map[string]interface{}, error) {
+	gateway, err := ActivePaymentGateway()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create razorpay order: %v", err)
+		return nil, err
+	}
+	return ps.attachGatewayOrder(gateway, payment)
+}
+
+// attachGatewayOrder creates an order for the payment at the gateway and stores it on the payment
+func (ps *PaymentService) attachGatewayOrder(gateway PaymentGateway, payment *models.Payment) (map[string]interface{}, error) {
+	orderReq := &GatewayOrderRequest{
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Receipt:     payment.PaymentReference,
+		Description: payment.Description,
+		CustomerID:  fmt.Sprintf("user_%d", payment.UserID),
+	}
+	var user models.User
+	if err := ps.userRepo.FindByID(&user, payment.UserID); err == nil {
+		orderReq.CustomerName = user.Name
+		orderReq.CustomerPhone = user.Phone
+		if user.Email != nil {
+			orderReq.CustomerEmail = *user.Email
+		}
 	}
 
-	// Update payment with Razorpay order ID
-	orderID := razorpayOrder["id"].(string)
-	payment.RazorpayOrderID = &orderID
+	order, err := gateway.CreateOrder(orderReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s order: %v", gateway.Name(), err)
+	}
+
+	// Update payment with the gateway order ID
+	payment.GatewayProvider = order.Provider
+	payment.GatewayOrderID = &order.OrderID
 	err = ps.paymentRepo.Update(payment)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to update payment with order ID: %v", err)
+		return nil, fmt.Errorf("failed to update payment with order ID: %v", err)
+	}
+
+	return order.Checkout, nil
+}
+
+// gatewayFor gets the gateway a payment was made through
+func (ps *PaymentService) gatewayFor(payment *models.Payment) (PaymentGateway, error) {
+	if payment.GatewayProvider == "" {
+		return nil, fmt.Errorf("payment %d was not made through a payment gateway", payment.ID)
 	}
+	return GetPaymentGateway(payment.GatewayProvider)
+}
 
-	return payment, razorpayOrder, nil
+// VerifyGatewayPayment verifies a payment the client reported against a gateway order, using the
+// gateway the order was created at
+func (ps *PaymentService) VerifyGatewayPayment(orderID, gatewayPaymentID, gatewaySignature string) (bool, error) {
+	payment, err := ps.paymentRepo.GetByGatewayOrderID(orderID)
+	if err != nil {
+		return false, fmt.Errorf("payment not found for order %s: %v", orderID, err)
+	}
+	gateway, err := ps.gatewayFor(payment)
+	if err != nil {
+		return false, err
+	}
+	return gateway.VerifyPayment(orderID, gatewayPaymentID, gatewaySignature)
 }
 
-// VerifyAndCompletePayment verifies Razorpay payment and completes the payment
-func (ps *PaymentService) VerifyAndCompletePayment(paymentID uint, razorpayPaymentID, razorpaySignature string) (*models.Payment, error) {
+// VerifyAndCompletePayment verifies a gateway payment the client reported and completes the payment
+func (ps *PaymentService) VerifyAndCompletePayment(paymentID uint, gatewayPaymentID, gatewaySignature string) (*models.Payment, error) {
 	// Get payment record
 	payment, err := ps.paymentRepo.GetByID(paymentID)
 	if err != nil {
 		return nil, fmt.Errorf("payment not found: %v", err)
 	}
 
-	// Check if payment has Razorpay order ID
-	if payment.RazorpayOrderID == nil {
-		return nil, fmt.Errorf("payment does not have Razorpay order ID")
+	// Check if payment has a gateway order ID
+	if payment.GatewayOrderID == nil {
+		return nil, fmt.Errorf("payment does not have a gateway order ID")
+	}
+	gateway, err := ps.gatewayFor(payment)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify Razorpay payment
-	isValid, err := ps.razorpayService.VerifyPayment(razorpayPaymentID, *payment.RazorpayOrderID, razorpaySignature)
+	// Verify the payment with its gateway
+	isValid, err := gateway.VerifyPayment(*payment.GatewayOrderID, gatewayPaymentID, gatewaySignature)
 	if err != nil {
 		return nil, fmt.Errorf("payment verification failed: %v", err)
 	}
 
 	if !isValid {
-		// Update payment status to failed
-		payment.Status = models.PaymentStatusFailed
-		now := time.Now()
-		payment.FailedAt = &now
-		payment.Notes = "Payment verification failed"
-		err = ps.paymentRepo.Update(payment)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update payment status: %v", err)
+		// Update payment status to failed, unless the gateway already confirmed it
+		if payment.Status == models.PaymentStatusPending {
+			payment.Status = models.PaymentStatusFailed
+			now := time.Now()
+			payment.FailedAt = &now
+			payment.Notes = "Payment verification failed"
+			err = ps.paymentRepo.Update(payment)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update payment status: %v", err)
+			}
+			ps.releaseCoupon(payment)
 		}
 		return nil, fmt.Errorf("payment signature verification failed")
 	}
 
-	// Update payment as completed
-	payment.Status = models.PaymentStatusCompleted
-	payment.RazorpayPaymentID = &razorpayPaymentID
-	payment.RazorpaySignature = &razorpaySignature
+	// The gateway's capture webhook may already have completed it
+	if payment.Status == models.PaymentStatusCompleted && payment.GatewayPaymentID != nil && *payment.GatewayPaymentID == gatewayPaymentID {
+		return payment, nil
+	}
+
+	if _, err := ps.completePayment(payment, gatewayPaymentID, &gatewaySignature, "Payment completed successfully"); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CompleteGatewayPayment completes a payment the gateway reported as captured, without a client
+// signature. It reports false when the payment had already been completed.
+func (ps *PaymentService) CompleteGatewayPayment(payment *models.Payment, gatewayPaymentID string) (bool, error) {
+	if payment.Status == models.PaymentStatusCompleted || payment.Status == models.PaymentStatusRefunded || payment.Status == models.PaymentStatusPartiallyRefunded {
+		return false, nil
+	}
+	return ps.completePayment(payment, gatewayPaymentID, nil, "Payment captured (confirmed by gateway webhook)")
+}
+
+// CompleteReconciledPayment completes a payment reconciliation found captured at its gateway, including
+// ones that had failed, expired or been abandoned locally. It reports false when the payment had
+// already been completed.
+func (ps *PaymentService) CompleteReconciledPayment(payment *models.Payment, gatewayPaymentID string) (bool, error) {
+	if payment.Status == models.PaymentStatusCompleted || payment.Status == models.PaymentStatusRefunded || payment.Status == models.PaymentStatusPartiallyRefunded {
+		return false, nil
+	}
+	return ps.completePayment(payment, gatewayPaymentID, nil, "Payment captured (confirmed by reconciliation)")
+}
+
+// completePayment marks the payment completed and runs the completion logic for its type.
+// When the payment was completed concurrently by another caller it is reloaded and false is returned.
+func (ps *PaymentService) completePayment(payment *models.Payment, gatewayPaymentID string, gatewaySignature *string, notes string) (bool, error) {
 	now := time.Now()
+	payment.GatewayPaymentID = &gatewayPaymentID
+	if gatewaySignature != nil {
+		payment.GatewaySignature = gatewaySignature
+	}
 	payment.CompletedAt = &now
-	payment.Notes = "Payment completed successfully"
+	payment.Notes = notes
 
-	err = ps.paymentRepo.Update(payment)
+	completed, err := ps.paymentRepo.CompleteIfUnsettled(payment)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update payment status: %v", err)
+		return false, fmt.Errorf("failed to update payment status: %v", err)
+	}
+	if !completed {
+		if current, err := ps.paymentRepo.GetByID(payment.ID); err == nil {
+			*payment = *current
+		}
+		return false, nil
+	}
+	payment.Status = models.PaymentStatusCompleted
+
+	// Redeem the coupon reserved for this payment before the order is fulfilled
+	if err := NewCouponService().ConfirmForPayment(payment); err != nil {
+		logrus.Errorf("Failed to confirm coupon for payment %d: %v", payment.ID, err)
 	}
 
 	// Handle payment completion based on type
 	if payment.RelatedEntityType == "booking" && payment.RelatedEntityID != 0 {
+		NewBookingActivityService().RecordPayment(payment.RelatedEntityID, models.BookingActivityPaymentReceived, payment, models.UserActor(payment.UserID), payment.Description)
 		err = ps.handleBookingPaymentCompletion(payment)
 		if err != nil {
 			logrus.Errorf("Failed to handle booking payment completion: %v", err)
@@ -146,12 +271,17 @@ func (ps *PaymentService) VerifyAndCompletePayment(paymentID uint, razorpayPayme
 			logrus.Errorf("Failed to handle subscription payment completion: %v", err)
 			// Don't fail the payment verification, just log the error
 		}
+	} else if payment.Type == models.PaymentTypeWalletRecharge {
+		err = NewUnifiedWalletService().creditRecharge(payment)
+		if err != nil {
+			logrus.Errorf("Failed to credit wallet recharge %d: %v", payment.ID, err)
+		}
 	}
 
 	// Send payment notifications
 	go ps.sendPaymentNotifications(payment)
 
-	return payment, nil
+	return true, nil
 }
 
 // handleBookingPaymentCompletion handles booking-specific payment completion logic
@@ -217,47 +347,55 @@ func (ps *PaymentService) handleSegmentPaymentCompletion(booking *models.Booking
 	}
 
 	// Update booking status
-	bookingRepo := repositories.NewBookingRepository()
+	newStatus := booking.Status
 	if allPaid {
 		// All segments paid - booking is confirmed
-		booking.Status = models.BookingStatusConfirmed
+		newStatus = models.BookingStatusConfirmed
 		booking.PaymentStatus = "completed"
 	} else {
 		// Some segments still pending - booking is partially paid
-		booking.Status = models.BookingStatusPartiallyPaid
+		newStatus = models.BookingStatusPartiallyPaid
 		booking.PaymentStatus = "partial"
 	}
 
-	err = bookingRepo.Update(booking)
-	if err != nil {
-		return fmt.Errorf("failed to update booking status: %v", err)
+	// Later segments are often paid once work is under way; only the payment status changes then
+	if booking.Status != models.BookingStatusQuoteAccepted && booking.Status != models.BookingStatusPartiallyPaid {
+		if err := repositories.NewBookingRepository().Update(booking); err != nil {
+			return fmt.Errorf("failed to update booking payment status: %v", err)
+		}
+	} else {
+		transition := TransitionContext{Actor: models.UserActor(payment.UserID), Reason: fmt.Sprintf("Segment %d paid", segmentNumber)}
+		err = NewBookingStateMachine().TransitionBooking(booking, newStatus, transition)
+		if err != nil {
+			return err
+		}
 	}
 
+	// Lift an overdue-payment pause on the worker assignment once nothing is overdue
+	NewPaymentSegmentCollectionService().ResumeAssignmentIfPaidUp(booking.ID, models.UserActor(payment.UserID))
+
 	return nil
 }
 
 // handleRegularBookingPaymentCompletion handles regular booking payment completion
 func (ps *PaymentService) handleRegularBookingPaymentCompletion(booking *models.Booking, payment *models.Payment) error {
 	// For regular bookings, just confirm the booking
-	booking.Status = models.BookingStatusConfirmed
 	booking.PaymentStatus = "completed"
 
-	bookingRepo := repositories.NewBookingRepository()
-	err := bookingRepo.Update(booking)
+	transition := TransitionContext{Actor: models.UserActor(payment.UserID), Reason: "Booking payment completed"}
+	err := NewBookingStateMachine().TransitionBooking(booking, models.BookingStatusConfirmed, transition)
 	if err != nil {
-		return fmt.Errorf("failed to update booking status: %v", err)
+		return err
 	}
 
 	return nil
 }
 
-// handleSubscriptionPaymentCompletion handles subscription payment completion
+// handleSubscriptionPaymentCompletion activates the subscription the payment was made for
 func (ps *PaymentService) handleSubscriptionPaymentCompletion(payment *models.Payment) error {
-	// For subscription payments, we don't need to do anything here
-	// The subscription creation is handled in the CompleteSubscriptionPurchase method
-	// This is just for logging and potential future enhancements
 	logrus.Infof("Subscription payment completed: Payment ID %d, Amount ₹%.2f", payment.ID, payment.Amount)
-	return nil
+	_, err := NewUserSubscriptionService().ActivatePaidSubscription(payment)
+	return err
 }
 
 // GetPaymentByID gets a payment by ID
@@ -270,9 +408,9 @@ func (ps *PaymentService) GetPaymentByReference(reference string) (*models.Payme
 	return ps.paymentRepo.GetByReference(reference)
 }
 
-// GetPaymentByRazorpayOrderID gets a payment by Razorpay order ID
-func (ps *PaymentService) GetPaymentByRazorpayOrderID(orderID string) (*models.Payment, error) {
-	return ps.paymentRepo.GetByRazorpayOrderID(orderID)
+// GetPaymentByGatewayOrderID gets a payment by its gateway order ID
+func (ps *PaymentService) GetPaymentByGatewayOrderID(orderID string) (*models.Payment, error) {
+	return ps.paymentRepo.GetByGatewayOrderID(orderID)
 }
 
 // UpdatePayment updates a payment
@@ -290,39 +428,450 @@ func (ps *PaymentService) GetPaymentStats(userID *uint) (map[string]interface{},
 	return ps.paymentRepo.GetPaymentStats(userID)
 }
 
-// RefundPayment refunds a payment
+// RefundPayment refunds a payment to the wallet or back through its payment gateway. The payment's row
+// stays locked from the refundable amount check until the refund and the refunded amount are saved in
+// the same transaction, so concurrent refunds of a payment cannot return the same money twice.
 func (ps *PaymentService) RefundPayment(paymentID uint, req *models.RefundPaymentRequest) (*models.Payment, error) {
-	// Get payment record
-	payment, err := ps.paymentRepo.GetByID(paymentID)
+	// Check if refund amount is valid
+	if req.RefundAmount <= 0 {
+		return nil, fmt.Errorf("refund amount must be greater than zero")
+	}
+
+	var payment *models.Payment
+	var refundMethod string
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		paymentRepo := ps.paymentRepo.WithTx(tx)
+
+		// Get payment record
+		var err error
+		payment, err = paymentRepo.GetByIDForUpdate(paymentID)
+		if err != nil {
+			return fmt.Errorf("payment not found: %v", err)
+		}
+
+		// Check if payment is completed, possibly with part of it refunded already
+		if payment.Status != models.PaymentStatusCompleted && payment.Status != models.PaymentStatusPartiallyRefunded {
+			return fmt.Errorf("payment is not completed, cannot refund")
+		}
+
+		if payment.Type == models.PaymentTypeRefund {
+			return fmt.Errorf("refund transactions cannot be refunded")
+		}
+
+		if remaining := refundableAmount(payment); req.RefundAmount > remaining+0.005 {
+			return fmt.Errorf("refund amount cannot exceed the ₹%.2f not yet refunded", remaining)
+		}
+
+		refundMethod = ps.resolveRefundMethod(payment, req.RefundMethod)
+		if refundMethod == models.PaymentMethodOnline && (payment.GatewayPaymentID == nil || *payment.GatewayPaymentID == "") {
+			return fmt.Errorf("payment has no gateway payment id, cannot refund to original method")
+		}
+
+		// Issue the refund
+		var refundRecord *models.Payment
+		recordedByWebhook := false
+		switch refundMethod {
+		case models.PaymentMethodOnline:
+			refundRecord, recordedByWebhook, err = ps.refundToGateway(paymentRepo, payment, req)
+		default:
+			refundRecord, err = NewUnifiedWalletService().creditWalletForRefund(repositories.NewWalletJournalRepository().WithTx(tx), payment, req.RefundAmount, req.RefundReason)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to process %s refund: %v", refundMethod, err)
+		}
+
+		// Update payment as refunded, or partially refunded while some of it is left. A refund the webhook
+		// recorded has already been added to the payment.
+		if !recordedByWebhook {
+			addRefundedAmount(payment, req.RefundAmount)
+		}
+		payment.RefundReason = &req.RefundReason
+		payment.RefundMethod = &refundMethod
+		now := time.Now()
+		payment.RefundedAt = &now
+		if req.Notes != "" {
+			payment.Notes = req.Notes
+		}
+		if payment.Metadata == nil {
+			payment.Metadata = &models.JSONMap{}
+		}
+		(*payment.Metadata)["refund_payment_id"] = refundRecord.ID
+		if refundID, ok := (*refundRecord.Metadata)["gateway_refund_id"]; ok {
+			(*payment.Metadata)["gateway_refund_id"] = refundID
+		}
+
+		if err := paymentRepo.Update(payment); err != nil {
+			return fmt.Errorf("failed to update payment status: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("payment not found: %v", err)
+		return nil, err
 	}
 
-	// Check if payment is completed
-	if payment.Status != models.PaymentStatusCompleted {
-		return nil, fmt.Errorf("payment is not completed, cannot refund")
+	if payment.RelatedEntityType == "booking" && payment.RelatedEntityID != 0 {
+		NewBookingActivityService().RecordPayment(payment.RelatedEntityID, models.BookingActivityPaymentRefunded, payment, models.SystemActor(),
+			fmt.Sprintf("Refunded ₹%.2f via %s: %s", req.RefundAmount, refundMethod, req.RefundReason))
 	}
 
-	// Check if refund amount is valid
-	if req.RefundAmount > payment.Amount {
-		return nil, fmt.Errorf("refund amount cannot exceed payment amount")
+	logrus.Infof("Refunded ₹%.2f of payment %d via %s", req.RefundAmount, payment.ID, refundMethod)
+	return payment, nil
+}
+
+// refundableAmount returns how much of a payment has not been refunded yet
+func refundableAmount(payment *models.Payment) float64 {
+	if payment.RefundAmount == nil {
+		return payment.Amount
+	}
+	return roundCurrency(payment.Amount - *payment.RefundAmount)
+}
+
+// addRefundedAmount adds a refund to the amount refunded from a payment, or takes a failed one off it,
+// and sets the payment refunded once all of it has been returned and partially refunded before that
+func addRefundedAmount(payment *models.Payment, amount float64) {
+	refunded := amount
+	if payment.RefundAmount != nil {
+		refunded += *payment.RefundAmount
+	}
+	refunded = roundCurrency(refunded)
+
+	switch {
+	case refunded <= 0:
+		payment.Status = models.PaymentStatusCompleted
+		payment.RefundAmount = nil
+	case refunded >= payment.Amount:
+		payment.Status = models.PaymentStatusRefunded
+		payment.RefundAmount = &refunded
+	default:
+		payment.Status = models.PaymentStatusPartiallyRefunded
+		payment.RefundAmount = &refunded
+	}
+}
+
+// resolveRefundMethod decides where a refund goes. An explicit "wallet" or "online" wins, where
+// "online" means the gateway the payment was made through ("razorpay" is still accepted for it);
+// otherwise the refund goes back to the original payment method when possible.
+func (ps *PaymentService) resolveRefundMethod(payment *models.Payment, requested string) string {
+	switch requested {
+	case "wallet":
+		return "wallet"
+	case models.PaymentMethodOnline, "razorpay", "original":
+		return models.PaymentMethodOnline
+	}
+
+	if payment.Method == models.PaymentMethodOnline && payment.GatewayPaymentID != nil && *payment.GatewayPaymentID != "" {
+		return models.PaymentMethodOnline
+	}
+	return "wallet"
+}
+
+// refundReference returns our reference for the next refund of a payment, made of the payment ID and the
+// refund's place among the payment's refunds. A refund retried after the gateway call failed gets the same
+// reference, which gateways use as the idempotency key so the money is not returned twice.
+func refundReference(paymentID uint, previousRefunds int64) string {
+	return fmt.Sprintf("RFD%d_%d", paymentID, previousRefunds+1)
+}
+
+// refundToGateway issues a refund at the payment's gateway and records it as a refund transaction
+// through paymentRepo, inside the transaction holding the payment's lock. A refund whose record cannot
+// be saved is reported as an error, so the transaction is rolled back and a retry reuses its reference.
+// It reports true when the gateway's refund webhook had already recorded the refund.
+func (ps *PaymentService) refundToGateway(paymentRepo *repositories.PaymentRepository, payment *models.Payment, req *models.RefundPaymentRequest) (*models.Payment, bool, error) {
+	gateway, err := ps.gatewayFor(payment)
+	if err != nil {
+		return nil, false, err
+	}
+
+	previousRefunds, err := paymentRepo.CountRefundRecords(payment.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to count refunds: %v", err)
+	}
+
+	refundReq := &GatewayRefundRequest{
+		PaymentID: *payment.GatewayPaymentID,
+		Amount:    req.RefundAmount,
+		Reference: refundReference(payment.ID, previousRefunds),
+		Notes: map[string]string{
+			"payment_reference": payment.PaymentReference,
+			"reason":            req.RefundReason,
+		},
+	}
+	if payment.GatewayOrderID != nil {
+		refundReq.OrderID = *payment.GatewayOrderID
+	}
+	refund, err := gateway.Refund(refundReq)
+	if err != nil {
+		return nil, false, err
+	}
+
+	refundID := refund.ID
+	refundStatus := refund.Status
+
+	metadata := models.JSONMap{
+		"original_payment_id":        payment.ID,
+		"original_payment_reference": payment.PaymentReference,
+		"gateway_refund_id":          refundID,
+		"gateway_refund_status":      refundStatus,
+		"refund_reference":           refundReq.Reference,
+	}
+
+	refundRecord := ps.newPayment(&models.CreatePaymentRequest{
+		UserID:            payment.UserID,
+		Amount:            req.RefundAmount,
+		Currency:          "INR",
+		Type:              models.PaymentTypeRefund,
+		Method:            models.PaymentMethodOnline,
+		RelatedEntityType: payment.RelatedEntityType,
+		RelatedEntityID:   payment.RelatedEntityID,
+		Description:       fmt.Sprintf("Refund for %s", payment.PaymentReference),
+		Notes:             req.RefundReason,
+		Metadata:          &metadata,
+	})
+	refundRecord.GatewayProvider = payment.GatewayProvider
+	if refundStatus == GatewayRefundProcessed {
+		now := time.Now()
+		refundRecord.Status = models.PaymentStatusCompleted
+		refundRecord.CompletedAt = &now
+	}
+
+	created, err := paymentRepo.CreateRefundRecord(refundRecord)
+	if err != nil {
+		logrus.Errorf("%s refund %s issued for payment %d but refund record could not be created: %v", gateway.Name(), refundID, payment.ID, err)
+		return nil, false, fmt.Errorf("failed to record %s refund %s: %v", gateway.Name(), refundID, err)
+	}
+	if !created {
+		logrus.Infof("%s refund %s of payment %d was already recorded by its webhook", gateway.Name(), refundID, payment.ID)
+	}
+
+	return refundRecord, !created, nil
+}
+
+// FailGatewayPayment marks a pending payment failed after the gateway reported the attempt failed.
+// Completed payments are left alone, since a later attempt on the same order may have succeeded.
+func (ps *PaymentService) FailGatewayPayment(payment *models.Payment, gatewayPaymentID string, reason string) (bool, error) {
+	if payment.Status != models.PaymentStatusPending {
+		return false, nil
 	}
 
-	// Update payment as refunded
-	payment.Status = models.PaymentStatusRefunded
-	payment.RefundAmount = &req.RefundAmount
-	payment.RefundReason = &req.RefundReason
-	payment.RefundMethod = &req.RefundMethod
 	now := time.Now()
-	payment.RefundedAt = &now
-	payment.Notes = req.Notes
+	payment.Status = models.PaymentStatusFailed
+	payment.FailedAt = &now
+	payment.Notes = fmt.Sprintf("Payment failed at %s: %s", payment.GatewayProvider, reason)
+	if payment.Metadata == nil {
+		payment.Metadata = &models.JSONMap{}
+	}
+	(*payment.Metadata)["failed_gateway_payment_id"] = gatewayPaymentID
 
-	err = ps.paymentRepo.Update(payment)
+	if err := ps.paymentRepo.Update(payment); err != nil {
+		return false, fmt.Errorf("failed to update payment status: %v", err)
+	}
+	ps.releaseCoupon(payment)
+	return true, nil
+}
+
+// AbandonGatewayPayment marks a pending payment abandoned when its gateway order never saw a payment attempt
+func (ps *PaymentService) AbandonGatewayPayment(payment *models.Payment, reason string) (bool, error) {
+	if payment.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+
+	now := time.Now()
+	payment.Status = models.PaymentStatusAbandoned
+	payment.FailedAt = &now
+	payment.Notes = reason
+
+	if err := ps.paymentRepo.Update(payment); err != nil {
+		return false, fmt.Errorf("failed to update payment status: %v", err)
+	}
+	ps.releaseCoupon(payment)
+	return true, nil
+}
+
+// releaseCoupon frees the coupon reserved for a payment that failed
+func (ps *PaymentService) releaseCoupon(payment *models.Payment) {
+	if err := NewCouponService().ReleaseForPayment(payment.ID); err != nil {
+		logrus.Errorf("Failed to release coupon of payment %d: %v", payment.ID, err)
+	}
+}
+
+// RecordGatewayAuthorization notes on a pending payment that the gateway authorized it and capture is pending
+func (ps *PaymentService) RecordGatewayAuthorization(payment *models.Payment, gatewayPaymentID string) error {
+	if payment.Status != models.PaymentStatusPending {
+		return nil
+	}
+
+	payment.GatewayPaymentID = &gatewayPaymentID
+	if payment.Metadata == nil {
+		payment.Metadata = &models.JSONMap{}
+	}
+	(*payment.Metadata)["gateway_status"] = GatewayPaymentAuthorized
+	payment.Notes = "Payment authorized, waiting for capture"
+
+	return ps.paymentRepo.Update(payment)
+}
+
+// ReconcileRefundProcessed settles a refund the gateway reports as processed. Refunds issued from the
+// gateway's dashboard have no refund record yet, so one is created and the refund is added to the original
+// payment. It locks the original payment like RefundPayment does, so a webhook that arrives while the refund
+// call is still being recorded waits for it and then finds its record.
+func (ps *PaymentService) ReconcileRefundProcessed(refundID string, gatewayPaymentID string, amount float64) (*models.Payment, error) {
+	now := time.Now()
+
+	if refundRecord, err := ps.paymentRepo.GetByGatewayRefundID(refundID); err == nil {
+		return ps.completeRefundRecord(ps.paymentRepo, refundRecord, refundID, now)
+	}
+
+	payment, err := ps.paymentRepo.GetByGatewayPaymentID(gatewayPaymentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update payment status: %v", err)
+		return nil, fmt.Errorf("payment not found for gateway payment %s: %v", gatewayPaymentID, err)
 	}
 
-	return payment, nil
+	var refundRecord *models.Payment
+	created := false
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		paymentRepo := ps.paymentRepo.WithTx(tx)
+		payment, err = paymentRepo.GetByIDForUpdate(payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock payment: %v", err)
+		}
+
+		metadata := models.JSONMap{
+			"original_payment_id":        payment.ID,
+			"original_payment_reference": payment.PaymentReference,
+			"gateway_refund_id":          refundID,
+			"gateway_refund_status":      GatewayRefundProcessed,
+		}
+		refundRecord = ps.newPayment(&models.CreatePaymentRequest{
+			UserID:            payment.UserID,
+			Amount:            amount,
+			Currency:          "INR",
+			Type:              models.PaymentTypeRefund,
+			Method:            models.PaymentMethodOnline,
+			RelatedEntityType: payment.RelatedEntityType,
+			RelatedEntityID:   payment.RelatedEntityID,
+			Description:       fmt.Sprintf("Refund for %s", payment.PaymentReference),
+			Notes:             fmt.Sprintf("Refund issued directly on %s", payment.GatewayProvider),
+			Metadata:          &metadata,
+		})
+		refundRecord.GatewayProvider = payment.GatewayProvider
+		refundRecord.Status = models.PaymentStatusCompleted
+		refundRecord.CompletedAt = &now
+
+		// The refund call may have recorded the refund since it was looked up
+		created, err = paymentRepo.CreateRefundRecord(refundRecord)
+		if err != nil {
+			return fmt.Errorf("failed to create refund record: %v", err)
+		}
+		if !created {
+			refundRecord, err = ps.completeRefundRecord(paymentRepo, refundRecord, refundID, now)
+			return err
+		}
+
+		reason := fmt.Sprintf("Refund issued directly on %s", payment.GatewayProvider)
+		method := models.PaymentMethodOnline
+		addRefundedAmount(payment, amount)
+		payment.RefundReason = &reason
+		payment.RefundMethod = &method
+		payment.RefundedAt = &now
+		if payment.Metadata == nil {
+			payment.Metadata = &models.JSONMap{}
+		}
+		(*payment.Metadata)["refund_payment_id"] = refundRecord.ID
+		(*payment.Metadata)["gateway_refund_id"] = refundID
+		if err := paymentRepo.Update(payment); err != nil {
+			return fmt.Errorf("failed to update payment status: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return refundRecord, nil
+	}
+
+	if payment.RelatedEntityType == "booking" && payment.RelatedEntityID != 0 {
+		NewBookingActivityService().RecordPayment(payment.RelatedEntityID, models.BookingActivityPaymentRefunded, payment, models.SystemActor(),
+			fmt.Sprintf("Refunded ₹%.2f directly on %s", amount, payment.GatewayProvider))
+	}
+
+	logrus.Infof("Recorded %s refund %s of ₹%.2f for payment %d", payment.GatewayProvider, refundID, amount, payment.ID)
+	return refundRecord, nil
+}
+
+// completeRefundRecord marks the existing record of a gateway refund completed
+func (ps *PaymentService) completeRefundRecord(paymentRepo *repositories.PaymentRepository, refundRecord *models.Payment, refundID string, now time.Time) (*models.Payment, error) {
+	if refundRecord.Status == models.PaymentStatusCompleted {
+		return refundRecord, nil
+	}
+	refundRecord.Status = models.PaymentStatusCompleted
+	refundRecord.CompletedAt = &now
+	(*refundRecord.Metadata)["gateway_refund_status"] = GatewayRefundProcessed
+	if err := paymentRepo.Update(refundRecord); err != nil {
+		return nil, fmt.Errorf("failed to update refund record: %v", err)
+	}
+	logrus.Infof("Gateway refund %s processed for refund record %d", refundID, refundRecord.ID)
+	return refundRecord, nil
+}
+
+// ReconcileRefundFailed marks a gateway refund failed and takes it off the original payment, with the
+// payment locked, so the refund can be issued again
+func (ps *PaymentService) ReconcileRefundFailed(refundID string, gatewayPaymentID string, reason string) (*models.Payment, error) {
+	refundRecord, err := ps.paymentRepo.GetByGatewayRefundID(refundID)
+	if err != nil {
+		return nil, fmt.Errorf("refund record not found for gateway refund %s: %v", refundID, err)
+	}
+	if refundRecord.Status == models.PaymentStatusFailed {
+		return refundRecord, nil
+	}
+
+	payment, paymentErr := ps.paymentRepo.GetByGatewayPaymentID(gatewayPaymentID)
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		paymentRepo := ps.paymentRepo.WithTx(tx)
+		if paymentErr == nil {
+			if payment, err = paymentRepo.GetByIDForUpdate(payment.ID); err != nil {
+				return fmt.Errorf("failed to lock payment: %v", err)
+			}
+		}
+
+		now := time.Now()
+		refundRecord.Status = models.PaymentStatusFailed
+		refundRecord.FailedAt = &now
+		refundRecord.Notes = fmt.Sprintf("Refund failed at %s: %s", refundRecord.GatewayProvider, reason)
+		(*refundRecord.Metadata)["gateway_refund_status"] = GatewayRefundFailed
+		if err := paymentRepo.Update(refundRecord); err != nil {
+			return fmt.Errorf("failed to update refund record: %v", err)
+		}
+
+		if paymentErr != nil {
+			return nil
+		}
+		if (payment.Status == models.PaymentStatusRefunded || payment.Status == models.PaymentStatusPartiallyRefunded) &&
+			payment.Metadata != nil && (*payment.Metadata)["gateway_refund_id"] == refundID {
+			addRefundedAmount(payment, -refundRecord.Amount)
+			if payment.RefundAmount == nil {
+				payment.RefundReason = nil
+				payment.RefundMethod = nil
+				payment.RefundedAt = nil
+			}
+			payment.Notes = fmt.Sprintf("Gateway refund %s failed: %s", refundID, reason)
+			if err := paymentRepo.Update(payment); err != nil {
+				return fmt.Errorf("failed to update payment status: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Warnf("Gateway refund %s for payment %s failed: %s", refundID, gatewayPaymentID, reason)
+	return refundRecord, nil
+}
+
+// GetCompletedBookingPayments gets all completed payments made for a booking, including partially refunded ones
+func (ps *PaymentService) GetCompletedBookingPayments(bookingID uint) ([]models.Payment, error) {
+	return ps.paymentRepo.GetCompletedByBooking(bookingID)
 }
 
 // GetAbandonedWalletPayments gets pending wallet payments that are older than the cutoff time
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"treesindia/models"
+)
+
+func TestAddRefundedAmountRefundsInParts(t *testing.T) {
+	payment := &models.Payment{Amount: 1000, Status: models.PaymentStatusCompleted}
+
+	addRefundedAmount(payment, 400)
+	if payment.Status != models.PaymentStatusPartiallyRefunded || *payment.RefundAmount != 400 {
+		t.Fatalf("after first refund: status %s, refunded %v, want partially_refunded 400", payment.Status, *payment.RefundAmount)
+	}
+	if got := refundableAmount(payment); got != 600 {
+		t.Fatalf("refundableAmount() = %v, want 600", got)
+	}
+
+	addRefundedAmount(payment, 599.99)
+	if payment.Status != models.PaymentStatusPartiallyRefunded {
+		t.Fatalf("status = %s with a paisa left, want partially_refunded", payment.Status)
+	}
+
+	addRefundedAmount(payment, 0.01)
+	if payment.Status != models.PaymentStatusRefunded || *payment.RefundAmount != 1000 {
+		t.Fatalf("after last refund: status %s, refunded %v, want refunded 1000", payment.Status, *payment.RefundAmount)
+	}
+	if got := refundableAmount(payment); got != 0 {
+		t.Fatalf("refundableAmount() = %v, want 0", got)
+	}
+}
+
+func TestAddRefundedAmountTakesFailedRefundOff(t *testing.T) {
+	payment := &models.Payment{Amount: 1000, Status: models.PaymentStatusCompleted}
+	addRefundedAmount(payment, 300)
+	addRefundedAmount(payment, 700)
+
+	// The second refund failed at the gateway
+	addRefundedAmount(payment, -700)
+	if payment.Status != models.PaymentStatusPartiallyRefunded || *payment.RefundAmount != 300 {
+		t.Fatalf("status %s, refunded %v, want partially_refunded 300", payment.Status, *payment.RefundAmount)
+	}
+
+	addRefundedAmount(payment, -300)
+	if payment.Status != models.PaymentStatusCompleted || payment.RefundAmount != nil {
+		t.Fatalf("status %s, refunded %v, want completed with nothing refunded", payment.Status, payment.RefundAmount)
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidWebhookSignature is returned when a webhook body does not match its signature
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// webhookProcessingTimeout is how long an event can stay processing before it is taken to be
+// abandoned by a server that stopped, and another delivery or a replay may claim it
+const webhookProcessingTimeout = 10 * time.Minute
+
+// webhookEventStore stores webhook events once per event ID and claims them for processing
+type webhookEventStore interface {
+	CreateIfNotExists(event *models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error)
+	GetByID(id uint) (*models.PaymentWebhookEvent, error)
+	Claim(id uint, from []models.WebhookEventStatus, staleBefore time.Time) (bool, error)
+	Update(event *models.PaymentWebhookEvent) error
+	GetEvents(filters *models.WebhookEventFilters) ([]models.PaymentWebhookEvent, *repositories.Pagination, error)
+}
+
+// webhookPaymentFinder finds the local payment a webhook is about
+type webhookPaymentFinder interface {
+	GetByGatewayOrderID(orderID string) (*models.Payment, error)
+	GetByGatewayPaymentID(paymentID string) (*models.Payment, error)
+}
+
+// webhookPaymentReconciler moves payments and refunds to the status a webhook reports
+type webhookPaymentReconciler interface {
+	RecordGatewayAuthorization(payment *models.Payment, gatewayPaymentID string) error
+	CompleteGatewayPayment(payment *models.Payment, gatewayPaymentID string) (bool, error)
+	FailGatewayPayment(payment *models.Payment, gatewayPaymentID string, reason string) (bool, error)
+	ReconcileRefundProcessed(refundID string, gatewayPaymentID string, amount float64) (*models.Payment, error)
+	ReconcileRefundFailed(refundID string, gatewayPaymentID string, reason string) (*models.Payment, error)
+}
+
+// PaymentWebhookService stores payment gateway webhook events once per event ID and reconciles
+// payments, refunds, wallet recharges and subscriptions from them
+type PaymentWebhookService struct {
+	eventRepo      webhookEventStore
+	paymentRepo    webhookPaymentFinder
+	paymentService webhookPaymentReconciler
+}
+
+// NewPaymentWebhookService creates a new payment webhook service
+func NewPaymentWebhookService() *PaymentWebhookService {
+	return &PaymentWebhookService{
+		eventRepo:      repositories.NewPaymentWebhookEventRepository(),
+		paymentRepo:    repositories.NewPaymentRepository(),
+		paymentService: NewPaymentService(),
+	}
+}
+
+// HandleWebhook verifies, stores and processes a webhook from a payment gateway. Deliveries of an
+// event that was already processed are acknowledged without processing it again; failed events are retried.
+func (ws *PaymentWebhookService) HandleWebhook(provider string, body []byte, headers http.Header) (*models.PaymentWebhookEvent, error) {
+	gateway, err := GetPaymentGateway(provider)
+	if err != nil {
+		return nil, err
+	}
+	eventID, err := gateway.VerifyWebhook(body, headers)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := gateway.ParseWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash the body when the gateway sends no event ID
+	if eventID == "" {
+		sum := sha256.Sum256(body)
+		eventID = "body_" + hex.EncodeToString(sum[:])
+	}
+
+	event := &models.PaymentWebhookEvent{
+		Provider:         gateway.Name(),
+		EventID:          eventID,
+		EventType:        parsed.EventType,
+		Payload:          models.JSONMap(parsed.Payload),
+		Status:           models.WebhookEventStatusReceived,
+		GatewayOrderID:   optionalValue(parsed.OrderID),
+		GatewayPaymentID: optionalValue(parsed.PaymentID),
+		GatewayRefundID:  optionalValue(parsed.RefundID),
+	}
+
+	stored, created, err := ws.eventRepo.CreateIfNotExists(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store webhook event: %v", err)
+	}
+	if !created && stored.Status != models.WebhookEventStatusReceived && stored.Status != models.WebhookEventStatusFailed && !isStuckWebhookEvent(stored) {
+		logrus.Infof("%s webhook event %s (%s) already %s, skipping", stored.Provider, eventID, stored.EventType, stored.Status)
+		return stored, nil
+	}
+
+	return ws.process(stored)
+}
+
+// ReplayEvent processes a failed event, or one stuck processing, again (admin)
+func (ws *PaymentWebhookService) ReplayEvent(eventID uint) (*models.PaymentWebhookEvent, error) {
+	event, err := ws.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, errors.New("webhook event not found")
+	}
+	if event.Status != models.WebhookEventStatusFailed && event.Status != models.WebhookEventStatusReceived && !isStuckWebhookEvent(event) {
+		return nil, fmt.Errorf("%s webhook events cannot be replayed", event.Status)
+	}
+
+	return ws.process(event)
+}
+
+// GetEvents gets webhook events with filters (admin)
+func (ws *PaymentWebhookService) GetEvents(filters *models.WebhookEventFilters) ([]models.PaymentWebhookEvent, *repositories.Pagination, error) {
+	return ws.eventRepo.GetEvents(filters)
+}
+
+// GetEventByID gets a webhook event (admin)
+func (ws *PaymentWebhookService) GetEventByID(eventID uint) (*models.PaymentWebhookEvent, error) {
+	return ws.eventRepo.GetByID(eventID)
+}
+
+// process claims the event, runs its handler and records the outcome
+func (ws *PaymentWebhookService) process(event *models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, error) {
+	claimed, err := ws.eventRepo.Claim(event.ID, []models.WebhookEventStatus{models.WebhookEventStatusReceived, models.WebhookEventStatusFailed},
+		time.Now().Add(-webhookProcessingTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook event: %v", err)
+	}
+	if !claimed {
+		// Another delivery of the same event is being processed
+		return ws.eventRepo.GetByID(event.ID)
+	}
+	event.Status = models.WebhookEventStatusProcessing
+	event.Attempts++
+
+	paymentID, handled, handleErr := ws.dispatch(event)
+
+	now := time.Now()
+	event.PaymentID = paymentID
+	switch {
+	case handleErr != nil:
+		event.Status = models.WebhookEventStatusFailed
+		event.LastError = handleErr.Error()
+		logrus.Errorf("Error handling %s webhook event %s (%s): %v", event.Provider, event.EventID, event.EventType, handleErr)
+	case !handled:
+		event.Status = models.WebhookEventStatusIgnored
+		event.ProcessedAt = &now
+		logrus.Infof("Unhandled webhook event: %s", event.EventType)
+	default:
+		event.Status = models.WebhookEventStatusProcessed
+		event.LastError = ""
+		event.ProcessedAt = &now
+	}
+
+	if err := ws.eventRepo.Update(event); err != nil {
+		return nil, fmt.Errorf("failed to update webhook event: %v", err)
+	}
+
+	return event, handleErr
+}
+
+// dispatch reads the stored event with its gateway and runs the handler for its type. It returns the
+// reconciled local payment and whether the event type is one we act on.
+func (ws *PaymentWebhookService) dispatch(event *models.PaymentWebhookEvent) (*uint, bool, error) {
+	gateway, err := GetPaymentGateway(event.Provider)
+	if err != nil {
+		return nil, true, err
+	}
+	body, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	parsed, err := gateway.ParseWebhook(body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch parsed.Type {
+	case GatewayEventPaymentAuthorized:
+		payment, err := ws.findEventPayment(parsed)
+		if err != nil {
+			return nil, true, err
+		}
+		return &payment.ID, true, ws.paymentService.RecordGatewayAuthorization(payment, parsed.PaymentID)
+
+	case GatewayEventPaymentCaptured:
+		payment, err := ws.findEventPayment(parsed)
+		if err != nil {
+			return nil, true, err
+		}
+		if parsed.PaymentID == "" {
+			return &payment.ID, true, errors.New("webhook has no payment")
+		}
+		if parsed.HasAmount && math.Abs(parsed.Amount-payment.Amount) > 0.01 {
+			return &payment.ID, true, fmt.Errorf("captured amount ₹%.2f does not match payment amount ₹%.2f", parsed.Amount, payment.Amount)
+		}
+		completed, err := ws.paymentService.CompleteGatewayPayment(payment, parsed.PaymentID)
+		if err != nil {
+			return &payment.ID, true, err
+		}
+		if completed {
+			logrus.Infof("Payment %d completed from %s %s webhook", payment.ID, event.Provider, event.EventType)
+		}
+		return &payment.ID, true, nil
+
+	case GatewayEventPaymentFailed:
+		payment, err := ws.findEventPayment(parsed)
+		if err != nil {
+			return nil, true, err
+		}
+		reason := parsed.FailureReason
+		if reason == "" {
+			reason = "payment failed"
+		}
+		_, err = ws.paymentService.FailGatewayPayment(payment, parsed.PaymentID, reason)
+		return &payment.ID, true, err
+
+	case GatewayEventRefundProcessed:
+		if parsed.RefundID == "" {
+			return nil, true, errors.New("webhook has no refund")
+		}
+		record, err := ws.paymentService.ReconcileRefundProcessed(parsed.RefundID, parsed.PaymentID, parsed.Amount)
+		if err != nil {
+			return nil, true, err
+		}
+		return &record.ID, true, nil
+
+	case GatewayEventRefundFailed:
+		if parsed.RefundID == "" {
+			return nil, true, errors.New("webhook has no refund")
+		}
+		reason := parsed.FailureReason
+		if reason == "" {
+			reason = fmt.Sprintf("refund failed at %s", event.Provider)
+		}
+		record, err := ws.paymentService.ReconcileRefundFailed(parsed.RefundID, parsed.PaymentID, reason)
+		if err != nil {
+			return nil, true, err
+		}
+		return &record.ID, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// findEventPayment finds the local payment for the event's order, falling back to the gateway payment ID
+func (ws *PaymentWebhookService) findEventPayment(event *GatewayWebhookEvent) (*models.Payment, error) {
+	if event.OrderID != "" {
+		if payment, err := ws.paymentRepo.GetByGatewayOrderID(event.OrderID); err == nil {
+			return payment, nil
+		}
+	}
+	if event.PaymentID != "" {
+		if payment, err := ws.paymentRepo.GetByGatewayPaymentID(event.PaymentID); err == nil {
+			return payment, nil
+		}
+	}
+
+	return nil, errors.New("no payment found for the webhook's order or payment ID")
+}
+
+// isStuckWebhookEvent reports whether the event has been processing for longer than processing can take
+func isStuckWebhookEvent(event *models.PaymentWebhookEvent) bool {
+	return event.Status == models.WebhookEventStatusProcessing && time.Since(event.UpdatedAt) > webhookProcessingTimeout
+}
+
+// stringField reads a string field from a webhook entity
+func stringField(entity map[string]interface{}, key string) string {
+	value, _ := entity[key].(string)
+	return value
+}
+
+// optionalValue returns a pointer to the value, or nil when it is empty
+func optionalValue(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// paiseField reads an amount in paise from a webhook entity and returns it in rupees
+func paiseField(entity map[string]interface{}, key string) (float64, bool) {
+	value, ok := entity[key].(float64)
+	if !ok {
+		return 0, false
+	}
+	return value / 100, true
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+)
+
+// razorpayWebhookFixtures is where the sample Razorpay webhook bodies live
+const razorpayWebhookFixtures = "../testdata/razorpay_webhooks"
+
+// fakeWebhookEvents is an in-memory webhookEventStore that hands out copies, like the database does
+type fakeWebhookEvents struct {
+	events map[uint]models.PaymentWebhookEvent
+	nextID uint
+}
+
+func (f *fakeWebhookEvents) CreateIfNotExists(event *models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error) {
+	for _, existing := range f.events {
+		if existing.Provider == event.Provider && existing.EventID == event.EventID {
+			return &existing, false, nil
+		}
+	}
+	f.nextID++
+	event.ID = f.nextID
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = event.CreatedAt
+	f.events[event.ID] = *event
+	return event, true, nil
+}
+
+func (f *fakeWebhookEvents) GetByID(id uint) (*models.PaymentWebhookEvent, error) {
+	event, ok := f.events[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &event, nil
+}
+
+func (f *fakeWebhookEvents) Claim(id uint, from []models.WebhookEventStatus, staleBefore time.Time) (bool, error) {
+	event, ok := f.events[id]
+	if !ok {
+		return false, nil
+	}
+	claimable := event.Status == models.WebhookEventStatusProcessing && event.UpdatedAt.Before(staleBefore)
+	for _, status := range from {
+		claimable = claimable || event.Status == status
+	}
+	if !claimable {
+		return false, nil
+	}
+	event.Status = models.WebhookEventStatusProcessing
+	event.Attempts++
+	event.UpdatedAt = time.Now()
+	f.events[id] = event
+	return true, nil
+}
+
+func (f *fakeWebhookEvents) Update(event *models.PaymentWebhookEvent) error {
+	event.UpdatedAt = time.Now()
+	f.events[event.ID] = *event
+	return nil
+}
+
+func (f *fakeWebhookEvents) GetEvents(filters *models.WebhookEventFilters) ([]models.PaymentWebhookEvent, *repositories.Pagination, error) {
+	return nil, nil, nil
+}
+
+// leaveProcessing leaves an event processing since the given time, as a server that stopped mid-event would
+func (f *fakeWebhookEvents) leaveProcessing(id uint, since time.Time) {
+	event := f.events[id]
+	event.Status = models.WebhookEventStatusProcessing
+	event.UpdatedAt = since
+	f.events[id] = event
+}
+
+// fakeWebhookPayments is an in-memory webhookPaymentFinder and webhookPaymentReconciler
+type fakeWebhookPayments struct {
+	payments    []*models.Payment
+	refunds     map[string]*models.Payment // Gateway refund ID to refund record
+	completions int
+}
+
+func (f *fakeWebhookPayments) GetByGatewayOrderID(orderID string) (*models.Payment, error) {
+	for _, payment := range f.payments {
+		if payment.GatewayOrderID != nil && *payment.GatewayOrderID == orderID {
+			return payment, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeWebhookPayments) GetByGatewayPaymentID(paymentID string) (*models.Payment, error) {
+	for _, payment := range f.payments {
+		if payment.GatewayPaymentID != nil && *payment.GatewayPaymentID == paymentID {
+			return payment, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeWebhookPayments) RecordGatewayAuthorization(payment *models.Payment, gatewayPaymentID string) error {
+	if payment.Status != models.PaymentStatusPending {
+		return nil
+	}
+	payment.GatewayPaymentID = &gatewayPaymentID
+	payment.Notes = "Payment authorized, waiting for capture"
+	return nil
+}
+
+func (f *fakeWebhookPayments) CompleteGatewayPayment(payment *models.Payment, gatewayPaymentID string) (bool, error) {
+	if payment.Status == models.PaymentStatusCompleted || payment.Status == models.PaymentStatusRefunded || payment.Status == models.PaymentStatusPartiallyRefunded {
+		return false, nil
+	}
+	payment.GatewayPaymentID = &gatewayPaymentID
+	payment.Status = models.PaymentStatusCompleted
+	f.completions++
+	return true, nil
+}
+
+func (f *fakeWebhookPayments) FailGatewayPayment(payment *models.Payment, gatewayPaymentID string, reason string) (bool, error) {
+	if payment.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+	payment.Status = models.PaymentStatusFailed
+	payment.Notes = reason
+	return true, nil
+}
+
+func (f *fakeWebhookPayments) ReconcileRefundProcessed(refundID string, gatewayPaymentID string, amount float64) (*models.Payment, error) {
+	if record, ok := f.refunds[refundID]; ok {
+		record.Status = models.PaymentStatusCompleted
+		return record, nil
+	}
+	payment, err := f.GetByGatewayPaymentID(gatewayPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	record := f.recordRefund(refundID, amount)
+	record.Status = models.PaymentStatusCompleted
+	addRefundedAmount(payment, amount)
+	return record, nil
+}
+
+func (f *fakeWebhookPayments) ReconcileRefundFailed(refundID string, gatewayPaymentID string, reason string) (*models.Payment, error) {
+	record, ok := f.refunds[refundID]
+	if !ok {
+		return nil, fmt.Errorf("refund record not found for gateway refund %s", refundID)
+	}
+	record.Status = models.PaymentStatusFailed
+	record.Notes = reason
+	return record, nil
+}
+
+// recordRefund stores a pending refund record, as the refund call does once the gateway accepts a refund
+func (f *fakeWebhookPayments) recordRefund(refundID string, amount float64) *models.Payment {
+	record := &models.Payment{Amount: amount, Type: models.PaymentTypeRefund, Status: models.PaymentStatusPending}
+	record.ID = uint(100 + len(f.refunds))
+	f.refunds[refundID] = record
+	return record
+}
+
+// webhookFixture is a webhook service wired to in-memory stores, with Razorpay webhooks verified
+// against the test key secret
+type webhookFixture struct {
+	service  *PaymentWebhookService
+	events   *fakeWebhookEvents
+	payments *fakeWebhookPayments
+	payment  *models.Payment // Pending ₹500 payment of the order the fixtures refer to
+	signer   *RazorpayService
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+	signer := NewRazorpayServiceWithConfig(testRazorpayKeyID, testRazorpayKeySecret, "http://127.0.0.1:0")
+	registerTestPaymentGateway(t, NewRazorpayGateway(signer))
+
+	orderID := "order_TEST000000001"
+	payment := &models.Payment{
+		Amount:          500,
+		Status:          models.PaymentStatusPending,
+		Type:            models.PaymentTypeWalletRecharge,
+		GatewayProvider: PaymentGatewayRazorpay,
+		GatewayOrderID:  &orderID,
+	}
+	payment.ID = 1
+
+	events := &fakeWebhookEvents{events: map[uint]models.PaymentWebhookEvent{}}
+	payments := &fakeWebhookPayments{payments: []*models.Payment{payment}, refunds: map[string]*models.Payment{}}
+	return &webhookFixture{
+		service:  &PaymentWebhookService{eventRepo: events, paymentRepo: payments, paymentService: payments},
+		events:   events,
+		payments: payments,
+		payment:  payment,
+		signer:   signer,
+	}
+}
+
+// deliver sends a fixture webhook signed with the test key secret, as Razorpay delivers it
+func (f *webhookFixture) deliver(t *testing.T, fixture string, eventID string) (*models.PaymentWebhookEvent, error) {
+	t.Helper()
+	body := loadRazorpayWebhook(t, fixture)
+	headers := http.Header{}
+	headers.Set("X-Razorpay-Signature", f.signer.SignWebhookPayload(body))
+	headers.Set("X-Razorpay-Event-Id", eventID)
+	return f.service.HandleWebhook(PaymentGatewayRazorpay, body, headers)
+}
+
+// loadRazorpayWebhook reads a sample webhook body from testdata
+func loadRazorpayWebhook(t *testing.T, fixture string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join(razorpayWebhookFixtures, fixture))
+	if err != nil {
+		t.Fatalf("failed to read webhook fixture: %v", err)
+	}
+	return body
+}
+
+func TestRazorpayGatewayVerifiesAndParsesWebhookFixtures(t *testing.T) {
+	signer := NewRazorpayServiceWithConfig(testRazorpayKeyID, testRazorpayKeySecret, "http://127.0.0.1:0")
+	gateway := NewRazorpayGateway(signer)
+
+	cases := []struct {
+		fixture  string
+		wantType string
+		refundID string
+		amount   float64
+	}{
+		{"payment_authorized.json", GatewayEventPaymentAuthorized, "", 500},
+		{"payment_captured.json", GatewayEventPaymentCaptured, "", 500},
+		{"order_paid.json", GatewayEventPaymentCaptured, "", 500},
+		{"payment_failed.json", GatewayEventPaymentFailed, "", 500},
+		{"refund_processed.json", GatewayEventRefundProcessed, "rfnd_TEST000000001", 200},
+		{"refund_failed.json", GatewayEventRefundFailed, "rfnd_TEST000000001", 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			body := loadRazorpayWebhook(t, tc.fixture)
+			headers := http.Header{}
+			headers.Set("X-Razorpay-Signature", signer.SignWebhookPayload(body))
+			headers.Set("X-Razorpay-Event-Id", "evt_"+tc.fixture)
+
+			eventID, err := gateway.VerifyWebhook(body, headers)
+			if err != nil {
+				t.Fatalf("VerifyWebhook() error = %v", err)
+			}
+			if eventID != "evt_"+tc.fixture {
+				t.Errorf("event ID = %q, want %q", eventID, "evt_"+tc.fixture)
+			}
+
+			event, err := gateway.ParseWebhook(body)
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if event.Type != tc.wantType || event.OrderID != "order_TEST000000001" || event.PaymentID != "pay_TEST000000001" ||
+				event.RefundID != tc.refundID || !event.HasAmount || event.Amount != tc.amount {
+				t.Errorf("parsed %+v, want type %s, refund %q, amount %v for order_TEST000000001/pay_TEST000000001",
+					event, tc.wantType, tc.refundID, tc.amount)
+			}
+		})
+	}
+}
+
+func TestRazorpayGatewayRejectsBadWebhookSignatures(t *testing.T) {
+	signer := NewRazorpayServiceWithConfig(testRazorpayKeyID, testRazorpayKeySecret, "http://127.0.0.1:0")
+	gateway := NewRazorpayGateway(signer)
+	other := NewRazorpayServiceWithConfig(testRazorpayKeyID, "another_secret", "http://127.0.0.1:0")
+	body := loadRazorpayWebhook(t, "payment_captured.json")
+	signature := signer.SignWebhookPayload(body)
+
+	cases := []struct {
+		name      string
+		body      []byte
+		signature string
+	}{
+		{"missing signature", body, ""},
+		{"signed with another secret", body, other.SignWebhookPayload(body)},
+		{"body changed after signing", append([]byte(" "), body...), signature},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set("X-Razorpay-Signature", tc.signature)
+			if _, err := gateway.VerifyWebhook(tc.body, headers); !errors.Is(err, ErrInvalidWebhookSignature) {
+				t.Errorf("VerifyWebhook() error = %v, want ErrInvalidWebhookSignature", err)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookRejectsBadSignatureWithoutStoringIt(t *testing.T) {
+	f := newWebhookFixture(t)
+	body := loadRazorpayWebhook(t, "payment_captured.json")
+	headers := http.Header{}
+	headers.Set("X-Razorpay-Signature", NewRazorpayServiceWithConfig(testRazorpayKeyID, "another_secret", "").SignWebhookPayload(body))
+
+	if _, err := f.service.HandleWebhook(PaymentGatewayRazorpay, body, headers); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("HandleWebhook() error = %v, want ErrInvalidWebhookSignature", err)
+	}
+	if len(f.events.events) != 0 || f.payment.Status != models.PaymentStatusPending {
+		t.Errorf("stored %d events and payment is %s, want nothing stored and payment pending", len(f.events.events), f.payment.Status)
+	}
+}
+
+func TestHandleWebhookProcessesDuplicateDeliveryOnce(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	first, err := f.deliver(t, "payment_captured.json", "evt_captured")
+	if err != nil {
+		t.Fatalf("first delivery error = %v", err)
+	}
+	second, err := f.deliver(t, "payment_captured.json", "evt_captured")
+	if err != nil {
+		t.Fatalf("second delivery error = %v", err)
+	}
+
+	if first.ID != second.ID || second.Status != models.WebhookEventStatusProcessed || second.Attempts != 1 {
+		t.Errorf("second delivery returned event %d %s after %d attempts, want event %d processed once", second.ID, second.Status, second.Attempts, first.ID)
+	}
+	if f.payments.completions != 1 || f.payment.Status != models.PaymentStatusCompleted {
+		t.Errorf("payment %s after %d completions, want completed once", f.payment.Status, f.payments.completions)
+	}
+
+	// order.paid is a separate event for the same payment, which is already completed
+	if _, err := f.deliver(t, "order_paid.json", "evt_order_paid"); err != nil {
+		t.Fatalf("order.paid delivery error = %v", err)
+	}
+	if f.payments.completions != 1 || len(f.events.events) != 2 {
+		t.Errorf("%d completions and %d events after order.paid, want 1 and 2", f.payments.completions, len(f.events.events))
+	}
+}
+
+func TestHandleWebhookAcceptsPaymentEventsOutOfOrder(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	// Captured arrives first; the late authorized and failed events must not move the payment back
+	if _, err := f.deliver(t, "payment_captured.json", "evt_captured"); err != nil {
+		t.Fatalf("payment.captured error = %v", err)
+	}
+	for fixture, eventID := range map[string]string{"payment_authorized.json": "evt_authorized", "payment_failed.json": "evt_failed"} {
+		event, err := f.deliver(t, fixture, eventID)
+		if err != nil {
+			t.Fatalf("%s error = %v", fixture, err)
+		}
+		if event.Status != models.WebhookEventStatusProcessed {
+			t.Errorf("%s event is %s, want processed", fixture, event.Status)
+		}
+	}
+
+	if f.payment.Status != models.PaymentStatusCompleted || f.payment.Notes != "" {
+		t.Errorf("payment %s with notes %q, want completed and untouched", f.payment.Status, f.payment.Notes)
+	}
+}
+
+func TestHandleWebhookRetriesRefundFailedThatArrivesBeforeItsRefund(t *testing.T) {
+	f := newWebhookFixture(t)
+	if _, err := f.deliver(t, "payment_captured.json", "evt_captured"); err != nil {
+		t.Fatalf("payment.captured error = %v", err)
+	}
+
+	// refund.failed arrives before the refund call has saved its refund record
+	event, err := f.deliver(t, "refund_failed.json", "evt_refund_failed")
+	if err == nil {
+		t.Fatal("refund.failed before its refund record: want an error so the gateway redelivers it")
+	}
+	if event.Status != models.WebhookEventStatusFailed || event.LastError == "" {
+		t.Fatalf("event %s with error %q, want failed with the error recorded", event.Status, event.LastError)
+	}
+
+	f.payments.recordRefund("rfnd_TEST000000001", 200)
+	event, err = f.deliver(t, "refund_failed.json", "evt_refund_failed")
+	if err != nil {
+		t.Fatalf("redelivery error = %v", err)
+	}
+	if event.Status != models.WebhookEventStatusProcessed || event.Attempts != 2 || event.LastError != "" {
+		t.Errorf("redelivered event %s after %d attempts with error %q, want processed on the second attempt", event.Status, event.Attempts, event.LastError)
+	}
+	if status := f.payments.refunds["rfnd_TEST000000001"].Status; status != models.PaymentStatusFailed {
+		t.Errorf("refund record %s, want failed", status)
+	}
+}
+
+func TestHandleWebhookRecordsRefundProcessedOnce(t *testing.T) {
+	f := newWebhookFixture(t)
+	if _, err := f.deliver(t, "payment_captured.json", "evt_captured"); err != nil {
+		t.Fatalf("payment.captured error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := f.deliver(t, "refund_processed.json", "evt_refund_processed"); err != nil {
+			t.Fatalf("delivery %d error = %v", i+1, err)
+		}
+	}
+
+	if len(f.payments.refunds) != 1 || f.payment.Status != models.PaymentStatusPartiallyRefunded || *f.payment.RefundAmount != 200 {
+		t.Errorf("%d refund records, payment %s with %v refunded, want 1 record and ₹200 partially refunded",
+			len(f.payments.refunds), f.payment.Status, *f.payment.RefundAmount)
+	}
+}
+
+func TestHandleWebhookTakesOverEventsStuckProcessing(t *testing.T) {
+	f := newWebhookFixture(t)
+	event, err := f.deliver(t, "payment_captured.json", "evt_captured")
+	if err != nil {
+		t.Fatalf("first delivery error = %v", err)
+	}
+	f.payment.Status = models.PaymentStatusPending
+	f.payments.completions = 0
+
+	// Another server is still working on it
+	f.events.leaveProcessing(event.ID, time.Now().Add(-time.Minute))
+	if _, err := f.deliver(t, "payment_captured.json", "evt_captured"); err != nil {
+		t.Fatalf("delivery while processing error = %v", err)
+	}
+	if _, err := f.service.ReplayEvent(event.ID); err == nil {
+		t.Error("ReplayEvent() of an event being processed: want an error")
+	}
+	if f.payments.completions != 0 {
+		t.Fatalf("event being processed was processed again %d times", f.payments.completions)
+	}
+
+	// The server processing it stopped
+	f.events.leaveProcessing(event.ID, time.Now().Add(-webhookProcessingTimeout-time.Minute))
+	redelivered, err := f.deliver(t, "payment_captured.json", "evt_captured")
+	if err != nil {
+		t.Fatalf("delivery of stuck event error = %v", err)
+	}
+	if redelivered.Status != models.WebhookEventStatusProcessed || f.payments.completions != 1 {
+		t.Errorf("stuck event %s after %d completions, want processed once", redelivered.Status, f.payments.completions)
+	}
+
+	f.payment.Status = models.PaymentStatusPending
+	f.events.leaveProcessing(event.ID, time.Now().Add(-webhookProcessingTimeout-time.Minute))
+	replayed, err := f.service.ReplayEvent(event.ID)
+	if err != nil {
+		t.Fatalf("ReplayEvent() of stuck event error = %v", err)
+	}
+	if replayed.Status != models.WebhookEventStatusProcessed || f.payments.completions != 2 {
+		t.Errorf("replayed event %s after %d completions, want processed again", replayed.Status, f.payments.completions)
+	}
+}
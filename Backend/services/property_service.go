@@ -14,16 +14,18 @@ import (
 )
 
 type PropertyService struct {
-	propertyRepo     *repositories.PropertyRepository
-	userRepo         *repositories.UserRepository
-	cloudinary       *CloudinaryService
+	propertyRepo       *repositories.PropertyRepository
+	userRepo           *repositories.UserRepository
+	cloudinary         *CloudinaryService
+	entitlementService *EntitlementService
 }
 
 func NewPropertyService(cloudinaryService *CloudinaryService) *PropertyService {
 	return &PropertyService{
-		propertyRepo: repositories.NewPropertyRepository(),
-		userRepo:     repositories.NewUserRepository(),
-		cloudinary:   cloudinaryService,
+		propertyRepo:       repositories.NewPropertyRepository(),
+		userRepo:           repositories.NewUserRepository(),
+		cloudinary:         cloudinaryService,
+		entitlementService: NewEntitlementService(),
 	}
 }
 
@@ -53,6 +55,21 @@ func (ps *PropertyService) CreateProperty(property *models.Property, userID uint
 		}
 	}
 	
+	// Check the plan's active listing quota and apply its priority boost
+	if user.UserType != models.UserTypeAdmin {
+		if _, err := ps.entitlementService.Require(userID, models.EntitlementMaxActiveListings); err != nil {
+			logrus.Errorf("PropertyService.CreateProperty entitlement check failed for user %d: %v", userID, err)
+			return err
+		}
+		
+		entitlements, _, err := ps.entitlementService.GetEntitlements(userID)
+		if err != nil {
+			logrus.Errorf("PropertyService.CreateProperty failed to get entitlements: %v", err)
+			return err
+		}
+		property.PriorityBoost = entitlements.PriorityScoreBoost
+	}
+	
 	// Set user ID
 	property.UserID = userID
 	
@@ -95,6 +112,10 @@ func (ps *PropertyService) CreateProperty(property *models.Property, userID uint
 	
 	logrus.Infof("PropertyService.CreateProperty successfully created property ID: %d", property.ID)
 	
+	if user.UserType != models.UserTypeAdmin {
+		ps.entitlementService.RecordUsage(userID, models.EntitlementMaxActiveListings)
+	}
+	
 	// Send notification to admins about new property
 	go NotifyPropertyCreated(&user, property)
 	
@@ -491,6 +512,49 @@ func (ps *PropertyService) DeleteUserProperty(id uint, userID uint) error {
 	return nil
 }
 
+// SetPropertyFeatured features or unfeatures a user's own listing using the featured listing slots of their plan
+func (ps *PropertyService) SetPropertyFeatured(id uint, userID uint, featured bool) (*models.Property, error) {
+	logrus.Infof("PropertyService.SetPropertyFeatured called for property ID: %d by user ID: %d", id, userID)
+	
+	property, err := ps.propertyRepo.GetByID(id)
+	if err != nil {
+		logrus.Errorf("PropertyService.SetPropertyFeatured property not found: %v", err)
+		return nil, err
+	}
+	
+	if property.UserID != userID {
+		logrus.Errorf("PropertyService.SetPropertyFeatured property does not belong to user: property user ID %d, requesting user ID %d", property.UserID, userID)
+		return nil, fmt.Errorf("property does not belong to you")
+	}
+	
+	if property.IsFeatured == featured {
+		return property, nil
+	}
+	
+	if featured {
+		if property.Status != models.PropertyStatusAvailable || property.IsExpired() {
+			return nil, fmt.Errorf("only available listings can be featured")
+		}
+		if _, err := ps.entitlementService.Require(userID, models.EntitlementFeaturedListingSlots); err != nil {
+			logrus.Errorf("PropertyService.SetPropertyFeatured entitlement check failed for user %d: %v", userID, err)
+			return nil, err
+		}
+	}
+	
+	if err := ps.propertyRepo.SetFeatured(id, featured); err != nil {
+		logrus.Errorf("PropertyService.SetPropertyFeatured repository error: %v", err)
+		return nil, err
+	}
+	property.IsFeatured = featured
+	
+	if featured {
+		ps.entitlementService.RecordUsage(userID, models.EntitlementFeaturedListingSlots)
+	}
+	
+	logrus.Infof("PropertyService.SetPropertyFeatured set featured=%t on property ID: %d", featured, id)
+	return property, nil
+}
+
 // ApproveProperty approves a user property listing
 func (ps *PropertyService) ApproveProperty(id uint, adminID uint) error {
 	logrus.Infof("PropertyService.ApproveProperty called for property ID: %d by admin ID: %d", id, adminID)
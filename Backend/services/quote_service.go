@@ -14,6 +14,9 @@ type QuoteService struct {
 	bookingRepo           *repositories.BookingRepository
 	userRepo              *repositories.UserRepository
 	paymentSegmentRepo    *repositories.PaymentSegmentRepository
+	activityService       *BookingActivityService
+	stateMachine          *BookingStateMachine
+	couponService         *CouponService
 }
 
 func NewQuoteService() *QuoteService {
@@ -21,6 +24,9 @@ func NewQuoteService() *QuoteService {
 		bookingRepo:        repositories.NewBookingRepository(),
 		userRepo:           repositories.NewUserRepository(),
 		paymentSegmentRepo: repositories.NewPaymentSegmentRepository(),
+		activityService:    NewBookingActivityService(),
+		stateMachine:       NewBookingStateMachine(),
+		couponService:      NewCouponService(),
 	}
 }
 
@@ -38,7 +44,7 @@ func (qs *QuoteService) ProvideQuote(bookingID uint, adminID uint, req *models.P
 	}
 
 	if booking.Status != models.BookingStatusPending {
-		return nil, errors.New("booking is not in pending status")
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusQuoteProvided), Reason: "booking is not in pending status"}
 	}
 
 	// 3. Validate segments
@@ -63,7 +69,6 @@ func (qs *QuoteService) ProvideQuote(bookingID uint, adminID uint, req *models.P
 	booking.QuoteNotes = req.Notes
 	booking.QuoteProvidedBy = &adminID
 	booking.QuoteProvidedAt = &now
-	booking.Status = models.BookingStatusQuoteProvided
 	
 	// Set quote duration if provided (for single segment quotes)
 	if req.Duration != nil && *req.Duration != "" {
@@ -77,11 +82,14 @@ func (qs *QuoteService) ProvideQuote(bookingID uint, adminID uint, req *models.P
 	}
 
 	// 6. Update booking
-	err = qs.bookingRepo.Update(booking)
+	actor := models.AdminActor(adminID)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusQuoteProvided, TransitionContext{Actor: actor})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
+	qs.activityService.Record(booking.ID, models.BookingActivityQuoteProvided, actor, nil, quoteActivityValue(segmentsTotal, req.Notes, req.Segments), fmt.Sprintf("Quote of ₹%.2f provided", segmentsTotal))
+
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -141,6 +149,10 @@ func (qs *QuoteService) UpdateQuote(bookingID uint, adminID uint, req *models.Up
 
 	// 4. Update quote details
 	now := time.Now()
+	var oldQuote interface{}
+	if booking.QuoteAmount != nil {
+		oldQuote = quoteActivityValue(*booking.QuoteAmount, booking.QuoteNotes, nil)
+	}
 	booking.QuoteAmount = &segmentsTotal
 	booking.QuoteNotes = req.Notes
 	booking.QuoteProvidedBy = &adminID
@@ -158,6 +170,8 @@ func (qs *QuoteService) UpdateQuote(bookingID uint, adminID uint, req *models.Up
 		return nil, fmt.Errorf("failed to update booking: %v", err)
 	}
 
+	qs.activityService.Record(booking.ID, models.BookingActivityQuoteUpdated, models.AdminActor(adminID), oldQuote, quoteActivityValue(segmentsTotal, req.Notes, req.Segments), fmt.Sprintf("Quote updated to ₹%.2f", segmentsTotal))
+
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -184,7 +198,7 @@ func (qs *QuoteService) AcceptQuote(bookingID uint, userID uint, req *models.Acc
 	}
 
 	if booking.Status != models.BookingStatusQuoteProvided {
-		return nil, errors.New("booking does not have a quote provided")
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusQuoteAccepted), Reason: "booking does not have a quote provided"}
 	}
 
 	// 4. Check if quote has expired
@@ -194,15 +208,17 @@ func (qs *QuoteService) AcceptQuote(bookingID uint, userID uint, req *models.Acc
 
 	// 5. Update booking status
 	now := time.Now()
-	booking.Status = models.BookingStatusQuoteAccepted
 	booking.QuoteAcceptedAt = &now
 
 	// 6. Update booking
-	err = qs.bookingRepo.Update(booking)
+	actor := models.UserActor(userID)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusQuoteAccepted, TransitionContext{Actor: actor})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
+	qs.activityService.Record(booking.ID, models.BookingActivityQuoteAccepted, actor, nil, booking.QuoteAmount, "Customer accepted the quote")
+
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -250,11 +266,14 @@ func (qs *QuoteService) RejectQuote(bookingID uint, userID uint, req *models.Rej
 	}
 
 	if booking.Status != models.BookingStatusQuoteProvided {
-		return nil, errors.New("booking does not have a quote provided")
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusPending), Reason: "booking does not have a quote provided"}
 	}
 
 	// 4. Update booking status back to pending (allows for new quote)
-	booking.Status = models.BookingStatusPending
+	oldQuote := quoteActivityValue(0, booking.QuoteNotes, nil)
+	if booking.QuoteAmount != nil {
+		oldQuote["amount"] = *booking.QuoteAmount
+	}
 	// Clear quote details
 	booking.QuoteAmount = nil
 	booking.QuoteNotes = ""
@@ -263,11 +282,14 @@ func (qs *QuoteService) RejectQuote(bookingID uint, userID uint, req *models.Rej
 	booking.QuoteExpiresAt = nil
 
 	// 5. Update booking
-	err = qs.bookingRepo.Update(booking)
+	actor := models.UserActor(userID)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusPending, TransitionContext{Actor: actor})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
+	qs.activityService.Record(booking.ID, models.BookingActivityQuoteRejected, actor, oldQuote, nil, fmt.Sprintf("Customer rejected the quote: %s", req.Reason))
+
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -342,16 +364,19 @@ func (qs *QuoteService) ScheduleAfterQuote(bookingID uint, userID uint, req *mod
 	}
 
 	// 7. Update booking with scheduling details
+	oldScheduledTime := booking.ScheduledTime
 	booking.ScheduledDate = &scheduledDate
 	booking.ScheduledTime = &scheduledDateTime
-	booking.Status = models.BookingStatusConfirmed
 
 	// 8. Update booking
-	err = qs.bookingRepo.Update(booking)
+	actor := models.UserActor(userID)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, TransitionContext{Actor: actor})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
+	qs.activityService.Record(booking.ID, models.BookingActivityScheduled, actor, oldScheduledTime, booking.ScheduledTime, "Service scheduled after quote acceptance")
+
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
@@ -419,7 +444,10 @@ func (qs *QuoteService) CleanupExpiredQuotes() error {
 	}
 
 	for _, booking := range expiredBookings {
-		booking.Status = models.BookingStatusPending
+		oldQuote := quoteActivityValue(0, booking.QuoteNotes, nil)
+		if booking.QuoteAmount != nil {
+			oldQuote["amount"] = *booking.QuoteAmount
+		}
 		// Clear quote details
 		booking.QuoteAmount = nil
 		booking.QuoteNotes = ""
@@ -427,10 +455,13 @@ func (qs *QuoteService) CleanupExpiredQuotes() error {
 		booking.QuoteProvidedAt = nil
 		booking.QuoteExpiresAt = nil
 
-		err = qs.bookingRepo.Update(&booking)
+		err = qs.stateMachine.TransitionBooking(&booking, models.BookingStatusPending, TransitionContext{Actor: models.SystemActor(), Reason: "Quote expired"})
 		if err != nil {
 			logrus.Errorf("Failed to cleanup expired quote for booking %d: %v", booking.ID, err)
+			continue
 		}
+
+		qs.activityService.Record(booking.ID, models.BookingActivityQuoteExpired, models.SystemActor(), oldQuote, nil, "Quote expired")
 	}
 
 	if len(expiredBookings) > 0 {
@@ -513,6 +544,9 @@ func (qs *QuoteService) CreateQuotePayment(bookingID uint, userID uint, req *mod
 		return qs.processSinglePayment(bookingID, userID, req)
 	} else {
 		// Multiple segments = Segmented payment (no scheduled date/time required)
+		if req.CouponCode != "" {
+			return nil, errors.New("coupons cannot be applied to quotes paid in segments")
+		}
 		return qs.processSegmentPayment(bookingID, userID, req)
 	}
 }
@@ -563,7 +597,6 @@ func (qs *QuoteService) VerifyQuotePayment(bookingID uint, userID uint, req *mod
 	}
 
 	// 6. Update booking status to confirmed
-	booking.Status = models.BookingStatusConfirmed
 	booking.PaymentStatus = "completed"
 
 	// 7. The scheduled date/time should already be set from the CreateQuotePayment step
@@ -573,9 +606,9 @@ func (qs *QuoteService) VerifyQuotePayment(bookingID uint, userID uint, req *mod
 		booking.ScheduledDate, booking.ScheduledTime)
 
 	// 8. Update booking
-	err = qs.bookingRepo.Update(booking)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, TransitionContext{Actor: models.UserActor(userID), Reason: "Quote payment verified"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
 	// 8. Mark payment segment as paid (if this is a segmented payment)
@@ -649,31 +682,53 @@ func (qs *QuoteService) WalletPayment(bookingID uint, userID uint, req *models.W
 		return nil, errors.New("scheduled time must be in the future")
 	}
 
+	// Reserve the coupon for the quote
+	amount := req.Amount
+	var redemption *models.CouponRedemption
+	if req.CouponCode != "" {
+		if len(booking.PaymentSegments) > 1 {
+			return nil, errors.New("coupons cannot be applied to quotes paid in segments")
+		}
+		var application *models.CouponApplication
+		application, redemption, err = qs.couponService.ReserveForOrder(userID, req.CouponCode, QuoteCouponTarget(booking), &bookingID)
+		if err != nil {
+			return nil, err
+		}
+		amount = application.PayableAmount
+	}
+
 	// 8. Process wallet payment using unified wallet service
 	walletService := NewUnifiedWalletService()
 	
 	// Deduct amount from wallet for booking payment
-	_, err = walletService.DeductFromWalletForBooking(userID, req.Amount, bookingID, "Quote payment for "+booking.BookingReference)
+	walletPayment, err := walletService.DeductFromWalletForBooking(userID, amount, bookingID, "Quote payment for "+booking.BookingReference)
 	if err != nil {
+		if redemption != nil {
+			qs.couponService.Release(redemption)
+		}
 		return nil, fmt.Errorf("failed to process wallet payment: %v", err)
 	}
+	if redemption != nil {
+		if err := qs.couponService.Confirm(redemption, &walletPayment.ID); err != nil {
+			logrus.Errorf("Failed to confirm coupon for booking %d: %v", bookingID, err)
+		}
+	}
 
 	// 9. Update booking with scheduling details and status
 	booking.ScheduledDate = &scheduledDate
 	booking.ScheduledTime = &scheduledDateTime
-	booking.Status = models.BookingStatusConfirmed
 	booking.PaymentStatus = "completed"
 
 	// 10. Update booking
-	err = qs.bookingRepo.Update(booking)
+	err = qs.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, TransitionContext{Actor: models.UserActor(userID), Reason: "Quote paid from wallet"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking: %v", err)
+		return nil, err
 	}
 
 	// Calculate payment progress before returning
 	booking.GetPaymentProgress()
 	
-	logrus.Infof("Wallet payment processed for booking %d: amount=%.2f", bookingID, req.Amount)
+	logrus.Infof("Wallet payment processed for booking %d: amount=%.2f", bookingID, amount)
 	return booking, nil
 }
 
@@ -720,25 +775,52 @@ func (qs *QuoteService) processSinglePayment(bookingID uint, userID uint, req *m
 		return nil, errors.New("payment amount does not match segment amount")
 	}
 	
+	booking, err := qs.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Reserve the coupon until the quote is paid
+	amount := req.Amount
+	var redemption *models.CouponRedemption
+	if req.CouponCode != "" {
+		target := QuoteCouponTarget(booking)
+		target.Amount = req.Amount
+		var application *models.CouponApplication
+		application, redemption, err = qs.couponService.ReserveForOrder(userID, req.CouponCode, target, &bookingID)
+		if err != nil {
+			return nil, err
+		}
+		amount = application.PayableAmount
+	}
+	
 	// Process payment using existing logic
 	paymentService := NewPaymentService()
 	
 	paymentReq := &models.CreatePaymentRequest{
 		UserID:            userID,
-		Amount:            req.Amount,
+		Amount:            amount,
 		Currency:          "INR",
 		Type:              "booking",
-		Method:            "razorpay",
+		Method:            models.PaymentMethodOnline,
 		RelatedEntityType: "booking",
 		RelatedEntityID:   bookingID,
 		Description:       "Quote payment for booking",
 		Notes:             "Quote payment for booking",
 	}
 	
-	_, paymentOrder, err := paymentService.CreateRazorpayOrder(paymentReq)
+	payment, paymentOrder, err := paymentService.CreateGatewayOrder(paymentReq)
 	if err != nil {
+		if redemption != nil {
+			qs.couponService.Release(redemption)
+		}
 		return nil, fmt.Errorf("failed to create payment order: %v", err)
 	}
+	if redemption != nil {
+		if err := qs.couponService.AttachPayment(redemption, payment.ID); err != nil {
+			logrus.Errorf("Failed to attach payment %d to coupon redemption %d: %v", payment.ID, redemption.ID, err)
+		}
+	}
 	
 	// Update booking with scheduling details (but don't mark as completed yet)
 	if req.ScheduledDate == nil || req.ScheduledTime == nil {
@@ -773,11 +855,6 @@ func (qs *QuoteService) processSinglePayment(bookingID uint, userID uint, req *m
 		istLocation,
 	)
 	
-	booking, err := qs.bookingRepo.GetByID(bookingID)
-	if err != nil {
-		return nil, err
-	}
-	
 	// Only update scheduling details, keep status as quote_accepted until payment is verified
 	booking.ScheduledDate = &scheduledDate
 	booking.ScheduledTime = &scheduledDateTime
@@ -812,8 +889,8 @@ func (qs *QuoteService) processSegmentPayment(bookingID uint, userID uint, req *
 		return nil, errors.New("payment segment not found")
 	}
 	
-	// Validate segment is pending
-	if segment.Status != models.PaymentSegmentStatusPending {
+	// Validate segment is still unpaid
+	if segment.Status != models.PaymentSegmentStatusPending && segment.Status != models.PaymentSegmentStatusOverdue {
 		return nil, errors.New("payment segment is not pending")
 	}
 	
@@ -830,14 +907,14 @@ func (qs *QuoteService) processSegmentPayment(bookingID uint, userID uint, req *
 		Amount:            req.Amount,
 		Currency:          "INR",
 		Type:              "booking",
-		Method:            "razorpay",
+		Method:            models.PaymentMethodOnline,
 		RelatedEntityType: "booking",
 		RelatedEntityID:   bookingID,
 		Description:       fmt.Sprintf("Segment %d payment for booking", segment.SegmentNumber),
 		Notes:             fmt.Sprintf("Segment %d payment for booking", segment.SegmentNumber),
 	}
 	
-	_, paymentOrder, err := paymentService.CreateRazorpayOrder(paymentReq)
+	_, paymentOrder, err := paymentService.CreateGatewayOrder(paymentReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment order: %v", err)
 	}
@@ -866,17 +943,16 @@ func (qs *QuoteService) processSegmentPayment(bookingID uint, userID uint, req *
 			return nil, err
 		}
 		
+		newStatus := models.BookingStatusPartiallyPaid
+		booking.PaymentStatus = "partial"
 		if allPaid {
-			booking.Status = models.BookingStatusConfirmed
+			newStatus = models.BookingStatusConfirmed
 			booking.PaymentStatus = "completed"
-		} else {
-			booking.Status = models.BookingStatusPartiallyPaid
-			booking.PaymentStatus = "partial"
 		}
 		
-		err = qs.bookingRepo.Update(booking)
+		err = qs.stateMachine.TransitionBooking(booking, newStatus, TransitionContext{Actor: models.UserActor(userID)})
 		if err != nil {
-			return nil, fmt.Errorf("failed to update booking: %v", err)
+			return nil, err
 		}
 	}
 	
@@ -922,8 +998,8 @@ func (qs *QuoteService) CreateSegmentPayment(bookingID uint, userID uint, req *m
 		return nil, errors.New("payment segment not found")
 	}
 
-	// Validate segment is pending
-	if segment.Status != models.PaymentSegmentStatusPending {
+	// Validate segment is still unpaid
+	if segment.Status != models.PaymentSegmentStatusPending && segment.Status != models.PaymentSegmentStatusOverdue {
 		return nil, errors.New("payment segment is not pending")
 	}
 
@@ -970,6 +1046,9 @@ func (qs *QuoteService) CreateSegmentPayment(bookingID uint, userID uint, req *m
 			}
 		}
 
+		// Lift an overdue-payment pause on the worker assignment once nothing is overdue
+		NewPaymentSegmentCollectionService().ResumeAssignmentIfPaidUp(bookingID, models.UserActor(userID))
+
 		return map[string]interface{}{
 			"success": true,
 			"payment": payment,
@@ -984,14 +1063,14 @@ func (qs *QuoteService) CreateSegmentPayment(bookingID uint, userID uint, req *m
 			Amount:            req.Amount,
 			Currency:          "INR",
 			Type:              "booking",
-			Method:            "razorpay",
+			Method:            models.PaymentMethodOnline,
 			RelatedEntityType: "booking",
 			RelatedEntityID:   bookingID,
 			Description:       fmt.Sprintf("Segment %d payment for booking", segment.SegmentNumber),
 			Notes:             fmt.Sprintf("Segment %d payment for booking", segment.SegmentNumber),
 		}
 		
-		_, paymentOrder, err := paymentService.CreateRazorpayOrder(paymentReq)
+		_, paymentOrder, err := paymentService.CreateGatewayOrder(paymentReq)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create payment order: %v", err)
 		}
@@ -1023,7 +1102,7 @@ func (qs *QuoteService) VerifySegmentPayment(bookingID uint, userID uint, req *m
 
 	// 4. Find payment by Razorpay order ID
 	paymentRepo := repositories.NewPaymentRepository()
-	payment, err := paymentRepo.GetByRazorpayOrderID(req.RazorpayOrderID)
+	payment, err := paymentRepo.GetByGatewayOrderID(req.RazorpayOrderID)
 	if err != nil {
 		return nil, fmt.Errorf("payment not found: %v", err)
 	}
@@ -1081,6 +1160,8 @@ func (qs *QuoteService) GetPendingSegments(bookingID uint, userID uint) ([]model
 			PaidAt:        segment.PaidAt,
 			Notes:         segment.Notes,
 			PaymentID:     segment.PaymentID,
+			IsOverdue:     segment.Status == models.PaymentSegmentStatusOverdue,
+			AutoDebit:     segment.AutoDebit,
 		}
 		segmentInfos = append(segmentInfos, segmentInfo)
 	}
@@ -1118,9 +1199,23 @@ func (qs *QuoteService) GetPaidSegments(bookingID uint, userID uint) ([]models.P
 			PaidAt:        segment.PaidAt,
 			Notes:         segment.Notes,
 			PaymentID:     segment.PaymentID,
+			IsOverdue:     segment.Status == models.PaymentSegmentStatusOverdue,
+			AutoDebit:     segment.AutoDebit,
 		}
 		segmentInfos = append(segmentInfos, segmentInfo)
 	}
 
 	return segmentInfos, nil
 }
+
+// quoteActivityValue builds the quote snapshot stored in the booking activity log
+func quoteActivityValue(amount float64, notes string, segments []models.PaymentSegmentRequest) map[string]interface{} {
+	value := map[string]interface{}{
+		"amount": amount,
+		"notes":  notes,
+	}
+	if len(segments) > 0 {
+		value["segments"] = segments
+	}
+	return value
+}
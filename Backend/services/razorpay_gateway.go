@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RazorpayGateway is the Razorpay payment gateway
+type RazorpayGateway struct {
+	service *RazorpayService
+}
+
+// NewRazorpayGateway creates the Razorpay gateway on top of a Razorpay service
+func NewRazorpayGateway(service *RazorpayService) *RazorpayGateway {
+	return &RazorpayGateway{service: service}
+}
+
+// Name returns the provider name
+func (g *RazorpayGateway) Name() string {
+	return PaymentGatewayRazorpay
+}
+
+// CreateOrder creates a Razorpay order. The checkout data is the order the Razorpay checkout is opened with.
+func (g *RazorpayGateway) CreateOrder(req *GatewayOrderRequest) (*GatewayOrder, error) {
+	order, err := g.service.CreateOrder(req.Amount, req.Receipt, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	order["provider"] = PaymentGatewayRazorpay
+
+	return &GatewayOrder{
+		Provider: PaymentGatewayRazorpay,
+		OrderID:  stringField(order, "id"),
+		Checkout: order,
+	}, nil
+}
+
+// VerifyPayment checks the signature the Razorpay checkout returned for the payment
+func (g *RazorpayGateway) VerifyPayment(orderID, paymentID, signature string) (bool, error) {
+	return g.service.VerifyPayment(paymentID, orderID, signature)
+}
+
+// CapturePayment captures an authorized Razorpay payment
+func (g *RazorpayGateway) CapturePayment(orderID, paymentID string, amount float64) error {
+	_, err := g.service.CapturePayment(paymentID, amount)
+	return err
+}
+
+// Refund issues a Razorpay refund against the payment, with our reference as its idempotency key
+func (g *RazorpayGateway) Refund(req *GatewayRefundRequest) (*GatewayRefund, error) {
+	notes := map[string]string{"refund_reference": req.Reference}
+	for key, value := range req.Notes {
+		notes[key] = value
+	}
+
+	refund, err := g.service.CreateRefund(req.PaymentID, req.Amount, notes, req.Reference)
+	if err != nil {
+		return nil, err
+	}
+	return &GatewayRefund{ID: stringField(refund, "id"), Status: stringField(refund, "status")}, nil
+}
+
+// VerifyWebhook checks the X-Razorpay-Signature of a webhook and returns its X-Razorpay-Event-Id
+func (g *RazorpayGateway) VerifyWebhook(body []byte, headers http.Header) (string, error) {
+	signature := headers.Get("X-Razorpay-Signature")
+	if signature == "" || !g.service.VerifyWebhookSignature(body, signature) {
+		return "", ErrInvalidWebhookSignature
+	}
+	return headers.Get("X-Razorpay-Event-Id"), nil
+}
+
+// ParseWebhook reads a Razorpay webhook body
+func (g *RazorpayGateway) ParseWebhook(body []byte) (*GatewayWebhookEvent, error) {
+	webhookData, err := g.service.ParseWebhookPayload(body)
+	if err != nil {
+		return nil, err
+	}
+	event := &GatewayWebhookEvent{
+		EventType: stringField(webhookData, "event"),
+		Payload:   webhookData,
+	}
+	if event.EventType == "" {
+		return nil, errors.New("webhook payload has no event type")
+	}
+
+	if payment := webhookEntity(webhookData, "payment"); payment != nil {
+		event.PaymentID = stringField(payment, "id")
+		event.OrderID = stringField(payment, "order_id")
+		event.Amount, event.HasAmount = paiseField(payment, "amount")
+		event.FailureReason = stringField(payment, "error_description")
+	}
+	if order := webhookEntity(webhookData, "order"); order != nil && event.OrderID == "" {
+		event.OrderID = stringField(order, "id")
+	}
+	// Refund events carry the refunded payment too; the refund's amount is the one that matters
+	if refund := webhookEntity(webhookData, "refund"); refund != nil {
+		event.RefundID = stringField(refund, "id")
+		if event.PaymentID == "" {
+			event.PaymentID = stringField(refund, "payment_id")
+		}
+		event.Amount, event.HasAmount = paiseField(refund, "amount")
+	}
+
+	switch event.EventType {
+	case "payment.authorized":
+		event.Type = GatewayEventPaymentAuthorized
+	case "payment.captured", "order.paid":
+		event.Type = GatewayEventPaymentCaptured
+	case "payment.failed":
+		event.Type = GatewayEventPaymentFailed
+	case "refund.processed":
+		event.Type = GatewayEventRefundProcessed
+	case "refund.failed":
+		event.Type = GatewayEventRefundFailed
+	}
+	return event, nil
+}
+
+// GetOrderStatus gets a Razorpay order and, once it has been attempted, its payments
+func (g *RazorpayGateway) GetOrderStatus(orderID string) (*GatewayOrderStatus, error) {
+	order, err := g.service.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Razorpay order %s: %v", orderID, err)
+	}
+
+	// Razorpay order statuses are created, attempted and paid
+	status := &GatewayOrderStatus{OrderID: orderID, Status: stringField(order, "status")}
+	if status.Status == GatewayOrderCreated {
+		return status, nil
+	}
+
+	attempts, err := g.service.GetOrderPayments(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments of Razorpay order %s: %v", orderID, err)
+	}
+	for _, attempt := range attempts {
+		payment := GatewayPayment{
+			ID:            stringField(attempt, "id"),
+			Status:        stringField(attempt, "status"),
+			FailureReason: stringField(attempt, "error_description"),
+		}
+		payment.Amount, _ = paiseField(attempt, "amount")
+		if payment.Status == "created" {
+			payment.Status = GatewayPaymentPending
+		}
+		status.Payments = append(status.Payments, payment)
+	}
+	return status, nil
+}
+
+// webhookEntity returns payload.<name>.entity from a Razorpay webhook body
+func webhookEntity(payload map[string]interface{}, name string) map[string]interface{} {
+	inner, _ := payload["payload"].(map[string]interface{})
+	wrapper, _ := inner[name].(map[string]interface{})
+	if entity, ok := wrapper["entity"].(map[string]interface{}); ok {
+		return entity
+	}
+	return nil
+}
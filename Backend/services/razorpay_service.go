@@ -11,19 +11,43 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 
 
+// defaultRazorpayBaseURL is the Razorpay REST API base URL
+const defaultRazorpayBaseURL = "https://api.razorpay.com/v1"
+
 type RazorpayService struct {
-	keyID     string
-	keySecret string
+	keyID         string
+	keySecret     string
+	webhookSecret string
+	baseURL       string
 }
 
 func NewRazorpayService() *RazorpayService {
+	baseURL := os.Getenv("RAZORPAY_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultRazorpayBaseURL
+	}
+	rs := NewRazorpayServiceWithConfig(os.Getenv("RAZORPAY_KEY_ID"), os.Getenv("RAZORPAY_KEY_SECRET"), baseURL)
+	// Webhooks are signed with their own secret; fall back to the key secret for older setups
+	if webhookSecret := os.Getenv("RAZORPAY_WEBHOOK_SECRET"); webhookSecret != "" {
+		rs.webhookSecret = webhookSecret
+	}
+	return rs
+}
+
+// NewRazorpayServiceWithConfig creates a Razorpay service with explicit credentials and API base URL.
+// Pointing baseURL at a local HTTP server allows exercising the API calls without hitting Razorpay.
+func NewRazorpayServiceWithConfig(keyID, keySecret, baseURL string) *RazorpayService {
 	return &RazorpayService{
-		keyID:     os.Getenv("RAZORPAY_KEY_ID"),
-		keySecret: os.Getenv("RAZORPAY_KEY_SECRET"),
+		keyID:         keyID,
+		keySecret:     keySecret,
+		webhookSecret: keySecret,
+		baseURL:       strings.TrimRight(baseURL, "/"),
 	}
 }
 
@@ -53,7 +77,7 @@ func (rs *RazorpayService) CreateOrder(amount float64, receipt string, notes str
 	}
 	
 	// Create HTTP request
-	req, err := http.NewRequest("POST", "https://api.razorpay.com/v1/orders", bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequest("POST", rs.baseURL+"/orders", bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -132,22 +156,30 @@ func (rs *RazorpayService) getBasicAuth() string {
 // VerifyWebhookSignature verifies webhook signature
 func (rs *RazorpayService) VerifyWebhookSignature(body []byte, signature string) bool {
 	// Check if Razorpay is configured
-	if rs.keySecret == "" {
+	if rs.webhookSecret == "" {
 		return false
 	}
 	
-	// Create expected signature
-	expectedSignature := hmac.New(sha256.New, []byte(rs.keySecret))
-	expectedSignature.Write(body)
-	expectedSignatureHex := hex.EncodeToString(expectedSignature.Sum(nil))
-	
-	return expectedSignatureHex == signature
+	return hmac.Equal([]byte(rs.SignWebhookPayload(body)), []byte(signature))
+}
+
+// SignWebhookPayload returns the X-Razorpay-Signature value for a webhook body.
+// Signed fixture payloads can be replayed against the webhook endpoint with it.
+func (rs *RazorpayService) SignWebhookPayload(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(rs.webhookSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetWebhookSecret overrides the secret webhooks are signed with
+func (rs *RazorpayService) SetWebhookSecret(secret string) {
+	rs.webhookSecret = secret
 }
 
 // ParseWebhookPayload parses webhook payload
 func (rs *RazorpayService) ParseWebhookPayload(body []byte) (map[string]interface{}, error) {
 	// Check if Razorpay is configured
-	if rs.keySecret == "" {
+	if rs.webhookSecret == "" {
 		return nil, fmt.Errorf("razorpay is not configured - missing API keys")
 	}
 	
@@ -166,7 +198,7 @@ func (rs *RazorpayService) GetPaymentDetails(paymentID string) (map[string]inter
 	}
 	
 	// Create HTTP request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.razorpay.com/v1/payments/%s", paymentID), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/payments/%s", rs.baseURL, paymentID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -205,6 +237,182 @@ func (rs *RazorpayService) GetPaymentDetails(paymentID string) (map[string]inter
 	return paymentDetails, nil
 }
 
+// GetOrderDetails gets a Razorpay order, whose status is created, attempted or paid
+func (rs *RazorpayService) GetOrderDetails(orderID string) (map[string]interface{}, error) {
+	return rs.get(fmt.Sprintf("/orders/%s", orderID))
+}
+
+// GetOrderPayments gets every payment attempt made against a Razorpay order
+func (rs *RazorpayService) GetOrderPayments(orderID string) ([]map[string]interface{}, error) {
+	response, err := rs.get(fmt.Sprintf("/orders/%s/payments", orderID))
+	if err != nil {
+		return nil, err
+	}
+	return collectionItems(response), nil
+}
+
+// GetSettlementRecon gets the settlement reconciliation report of a day: the payments, refunds and
+// adjustments settled to the bank on it, with their fees and settlement IDs
+func (rs *RazorpayService) GetSettlementRecon(day time.Time) ([]map[string]interface{}, error) {
+	const pageSize = 1000
+	var items []map[string]interface{}
+	for skip := 0; ; skip += pageSize {
+		response, err := rs.get(fmt.Sprintf("/settlements/recon/combined?year=%d&month=%02d&day=%02d&count=%d&skip=%d",
+			day.Year(), int(day.Month()), day.Day(), pageSize, skip))
+		if err != nil {
+			return nil, err
+		}
+		page := collectionItems(response)
+		items = append(items, page...)
+		if len(page) < pageSize {
+			return items, nil
+		}
+	}
+}
+
+// CapturePayment captures an authorized Razorpay payment
+func (rs *RazorpayService) CapturePayment(paymentID string, amount float64) (map[string]interface{}, error) {
+	return rs.request("POST", fmt.Sprintf("/payments/%s/capture", paymentID), map[string]interface{}{
+		"amount":   int64(amount*100 + 0.5),
+		"currency": "INR",
+	})
+}
+
+// get makes an authenticated GET request to the Razorpay API and parses the JSON response
+func (rs *RazorpayService) get(path string) (map[string]interface{}, error) {
+	return rs.request("GET", path, nil)
+}
+
+// request makes an authenticated request to the Razorpay API, sending the payload as JSON when
+// there is one, and parses the JSON response
+func (rs *RazorpayService) request(method, path string, payload interface{}) (map[string]interface{}, error) {
+	// Check if Razorpay is configured
+	if rs.keyID == "" || rs.keySecret == "" {
+		return nil, fmt.Errorf("razorpay is not configured - missing API keys")
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonPayload)
+	}
+
+	req, err := http.NewRequest(method, rs.baseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Basic "+rs.getBasicAuth())
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("razorpay API error: %s", string(body))
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return response, nil
+}
+
+// collectionItems returns the items of a Razorpay collection response
+func collectionItems(response map[string]interface{}) []map[string]interface{} {
+	rawItems, _ := response["items"].([]interface{})
+	items := make([]map[string]interface{}, 0, len(rawItems))
+	for _, raw := range rawItems {
+		if item, ok := raw.(map[string]interface{}); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// CreateRefund issues a (partial or full) refund against a captured Razorpay payment. Razorpay returns
+// the refund already created for an idempotency key instead of refunding again.
+func (rs *RazorpayService) CreateRefund(paymentID string, amount float64, notes map[string]string, idempotencyKey string) (map[string]interface{}, error) {
+	// Check if Razorpay is configured
+	if rs.keyID == "" || rs.keySecret == "" {
+		return nil, fmt.Errorf("razorpay is not configured - missing API keys")
+	}
+	
+	// Convert amount to paise (Razorpay expects amount in smallest currency unit)
+	amountInPaise := int64(amount*100 + 0.5)
+	
+	// Prepare request payload
+	payload := map[string]interface{}{
+		"amount": amountInPaise,
+		"speed":  "normal",
+	}
+	if len(notes) > 0 {
+		payload["notes"] = notes
+	}
+	
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	
+	// Create HTTP request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/payments/%s/refund", rs.baseURL, paymentID), bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+rs.getBasicAuth())
+	if idempotencyKey != "" {
+		req.Header.Set("X-Refund-Idempotency", idempotencyKey)
+	}
+	
+	// Make request
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	// Read response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("razorpay API error: %s", string(body))
+	}
+	
+	// Parse response
+	var refundResponse map[string]interface{}
+	if err := json.Unmarshal(body, &refundResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	
+	if _, ok := refundResponse["id"].(string); !ok {
+		return nil, fmt.Errorf("invalid refund response: missing refund id")
+	}
+	
+	return refundResponse, nil
+}
+
 // IsPaymentSuccessful checks if payment was successful
 func (rs *RazorpayService) IsPaymentSuccessful(paymentDetails map[string]interface{}) bool {
 	// Check if Razorpay is configured
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateRefundSendsAmountInPaise(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+	razorpay.addCapturedPayment("pay_captured", 1000)
+
+	refund, err := razorpay.service().CreateRefund("pay_captured", 249.99, map[string]string{"reason": "Booking cancelled"}, "")
+	if err != nil {
+		t.Fatalf("CreateRefund() error = %v", err)
+	}
+	if refund["id"] != "rfnd_test_1" || refund["status"] != GatewayRefundProcessed {
+		t.Errorf("CreateRefund() = %v, want processed refund rfnd_test_1", refund)
+	}
+
+	issued := razorpay.issuedRefunds()
+	if len(issued) != 1 {
+		t.Fatalf("issued %d refunds, want 1", len(issued))
+	}
+	if issued[0].PaymentID != "pay_captured" || issued[0].Amount != 24999 || issued[0].Speed != "normal" {
+		t.Errorf("refund request = %+v, want 24999 paise of pay_captured at normal speed", issued[0])
+	}
+	if issued[0].Notes["reason"] != "Booking cancelled" {
+		t.Errorf("refund notes = %v, want the reason passed through", issued[0].Notes)
+	}
+}
+
+func TestCreateRefundRefundsInParts(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+	razorpay.addCapturedPayment("pay_captured", 500)
+	service := razorpay.service()
+
+	if _, err := service.CreateRefund("pay_captured", 200, nil, ""); err != nil {
+		t.Fatalf("first CreateRefund() error = %v", err)
+	}
+	if _, err := service.CreateRefund("pay_captured", 300, nil, ""); err != nil {
+		t.Fatalf("second CreateRefund() error = %v", err)
+	}
+	if _, err := service.CreateRefund("pay_captured", 0.01, nil, ""); err == nil {
+		t.Fatal("CreateRefund() past the captured amount succeeded, want an error")
+	}
+	if issued := razorpay.issuedRefunds(); len(issued) != 2 {
+		t.Errorf("issued %d refunds, want 2", len(issued))
+	}
+}
+
+func TestCreateRefundReturnsRazorpayErrors(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+
+	_, err := razorpay.service().CreateRefund("pay_unknown", 100, nil, "")
+	if err == nil || !strings.Contains(err.Error(), "has not been captured") {
+		t.Fatalf("CreateRefund() error = %v, want the Razorpay error description", err)
+	}
+
+	unauthorized := NewRazorpayServiceWithConfig(testRazorpayKeyID, "wrong_secret", razorpay.server.URL)
+	if _, err := unauthorized.CreateRefund("pay_unknown", 100, nil, ""); err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Fatalf("CreateRefund() with wrong credentials error = %v, want an authentication error", err)
+	}
+
+	unconfigured := NewRazorpayServiceWithConfig("", "", razorpay.server.URL)
+	if _, err := unconfigured.CreateRefund("pay_unknown", 100, nil, ""); err == nil {
+		t.Fatal("CreateRefund() without API keys succeeded, want an error")
+	}
+}
+
+func TestRazorpayGatewayRefundAddsReference(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+	razorpay.addCapturedPayment("pay_captured", 800)
+
+	refund, err := NewRazorpayGateway(razorpay.service()).Refund(&GatewayRefundRequest{
+		OrderID:   "order_1",
+		PaymentID: "pay_captured",
+		Amount:    800,
+		Reference: "RFD1_1",
+		Notes:     map[string]string{"payment_reference": "PAY1"},
+	})
+	if err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if refund.ID != "rfnd_test_1" || refund.Status != GatewayRefundProcessed {
+		t.Errorf("Refund() = %+v, want processed refund rfnd_test_1", refund)
+	}
+
+	notes := razorpay.issuedRefunds()[0].Notes
+	if notes["refund_reference"] != "RFD1_1" || notes["payment_reference"] != "PAY1" {
+		t.Errorf("refund notes = %v, want our refund and payment references", notes)
+	}
+}
+
+func TestNewRazorpayServiceUsesBaseURLFromEnvironment(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+	razorpay.addCapturedPayment("pay_captured", 100)
+	t.Setenv("RAZORPAY_KEY_ID", testRazorpayKeyID)
+	t.Setenv("RAZORPAY_KEY_SECRET", testRazorpayKeySecret)
+	t.Setenv("RAZORPAY_BASE_URL", razorpay.server.URL+"/")
+
+	if _, err := NewRazorpayService().CreateRefund("pay_captured", 100, nil, ""); err != nil {
+		t.Fatalf("CreateRefund() error = %v", err)
+	}
+	if issued := razorpay.issuedRefunds(); len(issued) != 1 {
+		t.Errorf("issued %d refunds at the local server, want 1", len(issued))
+	}
+}
+
+func TestRazorpayGatewayRetriedRefundIsNotIssuedTwice(t *testing.T) {
+	razorpay := newFakeRazorpay(t)
+	razorpay.addCapturedPayment("pay_captured", 800)
+	gateway := NewRazorpayGateway(razorpay.service())
+
+	// The first call's response was lost, so the refund is retried with the same reference
+	req := &GatewayRefundRequest{PaymentID: "pay_captured", Amount: 300, Reference: refundReference(7, 0)}
+	first, err := gateway.Refund(req)
+	if err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	retried, err := gateway.Refund(req)
+	if err != nil {
+		t.Fatalf("retried Refund() error = %v", err)
+	}
+	if retried.ID != first.ID {
+		t.Errorf("retried refund = %s, want the first refund %s", retried.ID, first.ID)
+	}
+
+	// The next refund of the payment gets a reference of its own
+	if _, err := gateway.Refund(&GatewayRefundRequest{PaymentID: "pay_captured", Amount: 300, Reference: refundReference(7, 1)}); err != nil {
+		t.Fatalf("second Refund() error = %v", err)
+	}
+	issued := razorpay.issuedRefunds()
+	if len(issued) != 2 || issued[0].IdempotencyKey != "RFD7_1" || issued[1].IdempotencyKey != "RFD7_2" {
+		t.Errorf("issued refunds = %+v, want RFD7_1 and RFD7_2 issued once each", issued)
+	}
+}
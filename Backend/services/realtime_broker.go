@@ -0,0 +1,327 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"treesindia/database"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Realtime brokers
+const (
+	RealtimeBrokerMemory   = "memory"
+	RealtimeBrokerPostgres = "postgres"
+)
+
+// Broker channels the realtime services fan out on
+const (
+	RealtimeChannelGateway       = "realtime"      // Realtime gateway topic events
+	RealtimeChannelRooms         = "rooms"         // Legacy chat and location tracking room messages
+	RealtimeChannelNotifications = "notifications" // Legacy notification WebSocket messages
+	RealtimeChannelConversations = "conversations" // Legacy conversation WebSocket messages
+)
+
+// RealtimeBrokerHandler receives the payloads published on a broker channel
+type RealtimeBrokerHandler func(payload []byte)
+
+// RealtimeBroker fans realtime messages out to every replica of the backend. A payload published on a
+// channel reaches the handlers subscribed to it on all replicas, including the publishing one, so
+// services deliver to their local WebSocket connections only from their subscription.
+type RealtimeBroker interface {
+	Name() string
+	Publish(channel string, payload []byte) error
+	Subscribe(channel string, handler RealtimeBrokerHandler) error
+	Close() error
+}
+
+var (
+	realtimeBroker      RealtimeBroker
+	realtimeBrokerMutex sync.RWMutex
+)
+
+// SetRealtimeBroker sets the broker realtime services fan out through. It must be set before the
+// realtime gateway and the WebSocket services are created.
+func SetRealtimeBroker(broker RealtimeBroker) {
+	realtimeBrokerMutex.Lock()
+	defer realtimeBrokerMutex.Unlock()
+	realtimeBroker = broker
+}
+
+// GetRealtimeBroker returns the realtime broker, an in-memory broker unless another one was set
+func GetRealtimeBroker() RealtimeBroker {
+	realtimeBrokerMutex.RLock()
+	broker := realtimeBroker
+	realtimeBrokerMutex.RUnlock()
+	if broker != nil {
+		return broker
+	}
+
+	realtimeBrokerMutex.Lock()
+	defer realtimeBrokerMutex.Unlock()
+	if realtimeBroker == nil {
+		realtimeBroker = NewMemoryRealtimeBroker()
+	}
+	return realtimeBroker
+}
+
+// NewRealtimeBroker creates the broker with the given name
+func NewRealtimeBroker(name, databaseURL string) (RealtimeBroker, error) {
+	switch name {
+	case "", RealtimeBrokerMemory:
+		return NewMemoryRealtimeBroker(), nil
+	case RealtimeBrokerPostgres:
+		return NewPostgresRealtimeBroker(database.GetDB(), databaseURL)
+	default:
+		return nil, fmt.Errorf("unknown realtime broker %q", name)
+	}
+}
+
+// MemoryRealtimeBroker delivers messages within this process. It is the broker of single node
+// deployments and of tests.
+type MemoryRealtimeBroker struct {
+	mu       sync.RWMutex
+	handlers map[string][]RealtimeBrokerHandler
+	closed   bool
+}
+
+// NewMemoryRealtimeBroker creates an in-memory realtime broker
+func NewMemoryRealtimeBroker() *MemoryRealtimeBroker {
+	return &MemoryRealtimeBroker{
+		handlers: make(map[string][]RealtimeBrokerHandler),
+	}
+}
+
+// Name returns the broker name
+func (b *MemoryRealtimeBroker) Name() string {
+	return RealtimeBrokerMemory
+}
+
+// Publish calls the handlers subscribed to the channel before returning
+func (b *MemoryRealtimeBroker) Publish(channel string, payload []byte) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return errors.New("realtime broker is closed")
+	}
+	handlers := append([]RealtimeBrokerHandler(nil), b.handlers[channel]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+// Subscribe adds a handler to a channel
+func (b *MemoryRealtimeBroker) Subscribe(channel string, handler RealtimeBrokerHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("realtime broker is closed")
+	}
+	b.handlers[channel] = append(b.handlers[channel], handler)
+	return nil
+}
+
+// Close drops every subscription
+func (b *MemoryRealtimeBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.handlers = make(map[string][]RealtimeBrokerHandler)
+	return nil
+}
+
+const (
+	// postgresChannelPrefix namespaces the backend's NOTIFY channels in the database
+	postgresChannelPrefix = "treesindia_"
+	// postgresMaxNotifyPayload keeps notifications under the 8000 byte NOTIFY payload limit
+	postgresMaxNotifyPayload = 7900
+	// postgresMessageRetention is how long payloads too large for NOTIFY are kept for listeners to read
+	postgresMessageRetention = time.Hour
+	// Notification payloads carry the message inline or a reference to a stored message
+	postgresInlinePrefix    = "m:"
+	postgresReferencePrefix = "r:"
+)
+
+// PostgresRealtimeBroker fans messages out to every replica through Postgres LISTEN/NOTIFY. Payloads
+// too large for a notification are stored in realtime_broker_messages and the notification carries
+// their ID. Notifications sent while a replica's listener is reconnecting are lost, as realtime
+// messages are not replayed.
+type PostgresRealtimeBroker struct {
+	db       *gorm.DB
+	listener *pq.Listener
+	mu       sync.RWMutex
+	handlers map[string][]RealtimeBrokerHandler
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewPostgresRealtimeBroker creates a Postgres realtime broker. Notifications are sent through db and
+// received on a dedicated listener connection to databaseURL.
+func NewPostgresRealtimeBroker(db *gorm.DB, databaseURL string) (*PostgresRealtimeBroker, error) {
+	if db == nil {
+		return nil, errors.New("database connection required")
+	}
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		return nil, fmt.Errorf("failed to reach database: %v", err)
+	}
+
+	b := &PostgresRealtimeBroker{
+		db:       db,
+		handlers: make(map[string][]RealtimeBrokerHandler),
+		done:     make(chan struct{}),
+	}
+	b.listener = pq.NewListener(databaseURL, 2*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			logrus.Warnf("Realtime broker lost its database listener connection: %v", err)
+		case pq.ListenerEventReconnected:
+			logrus.Info("Realtime broker reconnected its database listener")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logrus.Errorf("Realtime broker failed to connect its database listener: %v", err)
+		}
+	})
+
+	go b.listen()
+	go b.cleanup()
+	logrus.Info("Postgres realtime broker started")
+	return b, nil
+}
+
+// Name returns the broker name
+func (b *PostgresRealtimeBroker) Name() string {
+	return RealtimeBrokerPostgres
+}
+
+// Publish notifies every replica listening on the channel
+func (b *PostgresRealtimeBroker) Publish(channel string, payload []byte) error {
+	notification := postgresInlinePrefix + string(payload)
+	if len(notification) > postgresMaxNotifyPayload {
+		var id uint
+		err := b.db.Raw(
+			"INSERT INTO realtime_broker_messages (channel, payload) VALUES (?, ?) RETURNING id",
+			channel, string(payload),
+		).Scan(&id).Error
+		if err != nil {
+			return fmt.Errorf("failed to store realtime message: %v", err)
+		}
+		notification = postgresReferencePrefix + strconv.FormatUint(uint64(id), 10)
+	}
+
+	if err := b.db.Exec("SELECT pg_notify(?, ?)", postgresChannelPrefix+channel, notification).Error; err != nil {
+		return fmt.Errorf("failed to notify realtime channel %s: %v", channel, err)
+	}
+	return nil
+}
+
+// Subscribe adds a handler to a channel, listening on it the first time
+func (b *PostgresRealtimeBroker) Subscribe(channel string, handler RealtimeBrokerHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.handlers[channel]) == 0 {
+		if err := b.listener.Listen(postgresChannelPrefix + channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			return fmt.Errorf("failed to listen on realtime channel %s: %v", channel, err)
+		}
+	}
+	b.handlers[channel] = append(b.handlers[channel], handler)
+	return nil
+}
+
+// Close stops listening
+func (b *PostgresRealtimeBroker) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.done)
+		err = b.listener.Close()
+	})
+	return err
+}
+
+// listen dispatches notifications to the channel handlers until the broker is closed
+func (b *PostgresRealtimeBroker) listen() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case notification := <-b.listener.Notify:
+			if notification == nil {
+				// The listener reconnected; anything published meanwhile did not reach this replica
+				logrus.Warn("Realtime broker listener reconnected, messages published during the outage were missed")
+				continue
+			}
+			b.dispatch(notification)
+		case <-time.After(90 * time.Second):
+			// Detect dead listener connections that the server never closed
+			go func() {
+				if err := b.listener.Ping(); err != nil {
+					logrus.Warnf("Realtime broker listener ping failed: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+// dispatch passes a notification's payload to the handlers of its channel
+func (b *PostgresRealtimeBroker) dispatch(notification *pq.Notification) {
+	channel := strings.TrimPrefix(notification.Channel, postgresChannelPrefix)
+	b.mu.RLock()
+	handlers := append([]RealtimeBrokerHandler(nil), b.handlers[channel]...)
+	b.mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	var payload []byte
+	switch {
+	case strings.HasPrefix(notification.Extra, postgresInlinePrefix):
+		payload = []byte(strings.TrimPrefix(notification.Extra, postgresInlinePrefix))
+	case strings.HasPrefix(notification.Extra, postgresReferencePrefix):
+		id, err := strconv.ParseUint(strings.TrimPrefix(notification.Extra, postgresReferencePrefix), 10, 64)
+		if err != nil {
+			logrus.Errorf("Invalid realtime message reference on channel %s: %s", channel, notification.Extra)
+			return
+		}
+		var stored string
+		if err := b.db.Raw("SELECT payload FROM realtime_broker_messages WHERE id = ?", id).Scan(&stored).Error; err != nil || stored == "" {
+			logrus.Errorf("Failed to load realtime message %d on channel %s: %v", id, channel, err)
+			return
+		}
+		payload = []byte(stored)
+	default:
+		logrus.Errorf("Unknown realtime notification on channel %s", channel)
+		return
+	}
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+}
+
+// cleanup deletes stored messages every listener has had time to read
+func (b *PostgresRealtimeBroker) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			result := b.db.Exec("DELETE FROM realtime_broker_messages WHERE created_at < ?", time.Now().Add(-postgresMessageRetention))
+			if result.Error != nil {
+				logrus.Errorf("Failed to clean up realtime broker messages: %v", result.Error)
+			} else if result.RowsAffected > 0 {
+				logrus.Debugf("Cleaned up %d realtime broker messages", result.RowsAffected)
+			}
+		}
+	}
+}
@@ -0,0 +1,676 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"treesindia/models"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// RealtimeProtocolVersion is the version of the realtime envelope. Clients send it as "v" and
+// envelopes of another version are rejected.
+const RealtimeProtocolVersion = 1
+
+// Realtime envelope types
+const (
+	RealtimeTypeWelcome     = "welcome"     // Server: sent once the connection is authenticated
+	RealtimeTypeSubscribe   = "subscribe"   // Client: start receiving a topic's events
+	RealtimeTypeUnsubscribe = "unsubscribe" // Client: stop receiving a topic's events
+	RealtimeTypePublish     = "publish"     // Client: send an event to a topic's handler
+	RealtimeTypeEvent       = "event"       // Server: an event published to a subscribed topic
+	RealtimeTypeAck         = "ack"         // Server: a client request succeeded
+	RealtimeTypeError       = "error"       // Server: a client request failed
+	RealtimeTypePing        = "ping"
+	RealtimeTypePong        = "pong"
+)
+
+// Realtime topic kinds. Topics are "<kind>:<id>", except the admin and broadcast topics.
+const (
+	RealtimeTopicBooking       = "booking"       // Worker location and tracking status of a booking
+	RealtimeTopicChatRoom      = "chat_room"     // Messages of a booking chat room
+	RealtimeTopicConversation  = "conversation"  // Messages and status of a conversation
+	RealtimeTopicNotifications = "notifications" // In-app notifications and unread counts of a user
+	RealtimeTopicAdmin         = "admin"         // Admin feed
+	RealtimeTopicBroadcast     = "broadcast"     // Announcements to every connection
+)
+
+// Realtime error codes
+const (
+	RealtimeErrorInvalidMessage       = "invalid_message"
+	RealtimeErrorUnsupportedVersion   = "unsupported_version"
+	RealtimeErrorUnknownType          = "unknown_type"
+	RealtimeErrorInvalidTopic         = "invalid_topic"
+	RealtimeErrorForbidden            = "forbidden"
+	RealtimeErrorNotSubscribed        = "not_subscribed"
+	RealtimeErrorTooManySubscriptions = "too_many_subscriptions"
+	RealtimeErrorRateLimited          = "rate_limited"
+	RealtimeErrorFailed               = "failed"
+)
+
+const (
+	realtimeSendBuffer      = 256
+	realtimeReadLimit       = 64 * 1024
+	realtimePongWait        = 60 * time.Second
+	realtimePingInterval    = 54 * time.Second
+	realtimeWriteWait       = 10 * time.Second
+	realtimeRateLimitWindow = time.Minute
+)
+
+// RealtimeEnvelope is every message sent over a realtime connection, in both directions
+type RealtimeEnvelope struct {
+	Version   int                    `json:"v"`
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id,omitempty"`  // Client request ID; acks and errors carry it as ref
+	Ref       string                 `json:"ref,omitempty"` // ID of the client request an ack or error answers
+	Topic     string                 `json:"topic,omitempty"`
+	Event     string                 `json:"event,omitempty"`
+	Seq       uint64                 `json:"seq,omitempty"` // Per-connection sequence of server events
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// RealtimeTopicAuthorizer decides whether a connection may subscribe to the topic of a kind with an ID
+type RealtimeTopicAuthorizer func(conn *RealtimeConnection, id uint) error
+
+// RealtimeTopicHandler handles an event a client published to the topic of a kind with an ID. The
+// returned data is sent back in the ack.
+type RealtimeTopicHandler func(conn *RealtimeConnection, id uint, envelope *RealtimeEnvelope) (map[string]interface{}, error)
+
+// RealtimeConnection is an authenticated WebSocket connection of one device
+type RealtimeConnection struct {
+	ID       string
+	UserID   uint
+	UserType string
+	DeviceID string
+
+	conn      *websocket.Conn
+	gateway   *RealtimeGateway
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	seq       uint64
+	// Subscribed topics, guarded by the gateway's mutex
+	subscriptions map[string]bool
+
+	// Rate limiting, only touched by the read loop
+	messagesPerMinute int
+	maxSubscriptions  int
+	windowStart       time.Time
+	windowCount       int
+}
+
+// RealtimeGateway is the single WebSocket endpoint clients use for every realtime feature. Each device
+// keeps one authenticated connection and subscribes to the topics it needs; services publish events
+// to topics without knowing who is connected.
+type RealtimeGateway struct {
+	mu          sync.RWMutex
+	connections map[*RealtimeConnection]bool
+	devices     map[string]*RealtimeConnection // Connection of each user's device
+	topics      map[string]map[*RealtimeConnection]bool
+	authorizers map[string]RealtimeTopicAuthorizer
+	handlers    map[string]RealtimeTopicHandler
+	sequence    uint64
+	broker      RealtimeBroker
+}
+
+// realtimeBrokerEvent is a topic event on its way to the gateways of every replica
+type realtimeBrokerEvent struct {
+	Topic     string                 `json:"topic"`
+	Event     string                 `json:"event"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+var (
+	realtimeGateway     *RealtimeGateway
+	realtimeGatewayOnce sync.Once
+)
+
+// GetRealtimeGateway returns the realtime gateway every service publishes through
+func GetRealtimeGateway() *RealtimeGateway {
+	realtimeGatewayOnce.Do(func() {
+		realtimeGateway = NewRealtimeGateway(GetRealtimeBroker())
+	})
+	return realtimeGateway
+}
+
+// NewRealtimeGateway creates a realtime gateway publishing through a broker, so events reach the
+// subscribers connected to any replica. The notifications, admin and broadcast topics are authorized
+// by the gateway itself; other topic kinds need an authorizer from the service owning them.
+func NewRealtimeGateway(broker RealtimeBroker) *RealtimeGateway {
+	g := &RealtimeGateway{
+		broker:      broker,
+		connections: make(map[*RealtimeConnection]bool),
+		devices:     make(map[string]*RealtimeConnection),
+		topics:      make(map[string]map[*RealtimeConnection]bool),
+		authorizers: make(map[string]RealtimeTopicAuthorizer),
+		handlers:    make(map[string]RealtimeTopicHandler),
+	}
+
+	g.authorizers[RealtimeTopicNotifications] = func(conn *RealtimeConnection, id uint) error {
+		if id != conn.UserID {
+			return errors.New("notifications of another user")
+		}
+		return nil
+	}
+	g.authorizers[RealtimeTopicAdmin] = func(conn *RealtimeConnection, id uint) error {
+		if !conn.IsAdmin() {
+			return errors.New("admin access required")
+		}
+		return nil
+	}
+	g.authorizers[RealtimeTopicBroadcast] = func(conn *RealtimeConnection, id uint) error {
+		return nil
+	}
+
+	if err := broker.Subscribe(RealtimeChannelGateway, g.receive); err != nil {
+		logrus.Errorf("Failed to subscribe the realtime gateway to the %s broker: %v", broker.Name(), err)
+	}
+	return g
+}
+
+// RealtimeTopic returns the topic of a kind with an ID, such as "booking:12"
+func RealtimeTopic(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+// parseRealtimeTopic splits a topic into its kind and ID
+func parseRealtimeTopic(topic string) (string, uint, error) {
+	if topic == RealtimeTopicAdmin || topic == RealtimeTopicBroadcast {
+		return topic, 0, nil
+	}
+	kind, idStr, found := strings.Cut(topic, ":")
+	if !found || kind == "" {
+		return "", 0, fmt.Errorf("invalid topic %q", topic)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil || id == 0 {
+		return "", 0, fmt.Errorf("invalid topic %q", topic)
+	}
+	return kind, uint(id), nil
+}
+
+// AuthorizeTopic sets who may subscribe to the topics of a kind
+func (g *RealtimeGateway) AuthorizeTopic(kind string, authorizer RealtimeTopicAuthorizer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.authorizers[kind] = authorizer
+}
+
+// HandleTopic sets the handler of events clients publish to the topics of a kind
+func (g *RealtimeGateway) HandleTopic(kind string, handler RealtimeTopicHandler) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handlers[kind] = handler
+}
+
+// Serve runs an upgraded WebSocket connection of an authenticated user. A device that connects again
+// replaces its previous connection. The connection starts subscribed to the user's notifications, the
+// broadcast topic and, for admins, the admin feed.
+func (g *RealtimeGateway) Serve(wsConn *websocket.Conn, user *models.User, deviceID string) *RealtimeConnection {
+	adminConfigService := NewAdminConfigService()
+	conn := &RealtimeConnection{
+		ID:                fmt.Sprintf("rt-%d-%d", user.ID, atomic.AddUint64(&g.sequence, 1)),
+		UserID:            user.ID,
+		UserType:          string(user.UserType),
+		DeviceID:          deviceID,
+		conn:              wsConn,
+		gateway:           g,
+		send:              make(chan []byte, realtimeSendBuffer),
+		done:              make(chan struct{}),
+		subscriptions:     make(map[string]bool),
+		messagesPerMinute: adminConfigService.GetRealtimeMessagesPerMinute(),
+		maxSubscriptions:  adminConfigService.GetRealtimeMaxSubscriptions(),
+	}
+
+	var replaced *RealtimeConnection
+	g.mu.Lock()
+	g.connections[conn] = true
+	if deviceID != "" {
+		key := fmt.Sprintf("%d:%s", user.ID, deviceID)
+		replaced = g.devices[key]
+		g.devices[key] = conn
+	}
+	g.subscribeLocked(conn, RealtimeTopic(RealtimeTopicNotifications, user.ID))
+	g.subscribeLocked(conn, RealtimeTopicBroadcast)
+	if conn.IsAdmin() {
+		g.subscribeLocked(conn, RealtimeTopicAdmin)
+	}
+	subscriptions := conn.subscribedTopicsLocked()
+	g.mu.Unlock()
+
+	if replaced != nil {
+		logrus.Infof("Realtime connection %s replaced by %s for device %s", replaced.ID, conn.ID, deviceID)
+		replaced.Close()
+	}
+
+	go conn.writePump()
+	go conn.readPump()
+
+	conn.write(&RealtimeEnvelope{
+		Type: RealtimeTypeWelcome,
+		Data: map[string]interface{}{
+			"connection_id":       conn.ID,
+			"user_id":             conn.UserID,
+			"subscriptions":       subscriptions,
+			"messages_per_minute": conn.messagesPerMinute,
+			"max_subscriptions":   conn.maxSubscriptions,
+		},
+	})
+	logrus.Infof("Realtime connection %s opened for user %d (%s)", conn.ID, conn.UserID, conn.UserType)
+	return conn
+}
+
+// Publish sends an event to every connection subscribed to a topic on any replica
+func (g *RealtimeGateway) Publish(topic, event string, data map[string]interface{}) {
+	message := &realtimeBrokerEvent{Topic: topic, Event: event, Data: data, Timestamp: time.Now()}
+	payload, err := json.Marshal(message)
+	if err == nil {
+		err = g.broker.Publish(RealtimeChannelGateway, payload)
+	}
+	if err != nil {
+		// Keep this replica's subscribers informed when the broker is unavailable
+		logrus.Errorf("Failed to publish realtime event %s on %s through the broker: %v", event, topic, err)
+		g.deliver(message)
+	}
+}
+
+// receive delivers a topic event published on the broker
+func (g *RealtimeGateway) receive(payload []byte) {
+	var message realtimeBrokerEvent
+	if err := json.Unmarshal(payload, &message); err != nil {
+		logrus.Errorf("Invalid realtime event from the broker: %v", err)
+		return
+	}
+	g.deliver(&message)
+}
+
+// deliver sends a topic event to this replica's subscribers of the topic
+func (g *RealtimeGateway) deliver(message *realtimeBrokerEvent) {
+	topic := message.Topic
+	g.mu.RLock()
+	subscribers := make([]*RealtimeConnection, 0, len(g.topics[topic]))
+	for conn := range g.topics[topic] {
+		subscribers = append(subscribers, conn)
+	}
+	g.mu.RUnlock()
+
+	if len(subscribers) == 0 {
+		return
+	}
+	for _, conn := range subscribers {
+		conn.write(&RealtimeEnvelope{
+			Type:      RealtimeTypeEvent,
+			Topic:     topic,
+			Event:     message.Event,
+			Data:      message.Data,
+			Timestamp: message.Timestamp,
+		})
+	}
+	logrus.Debugf("Delivered realtime event %s to %d connections on %s", message.Event, len(subscribers), topic)
+}
+
+// PublishToUser sends an event to the notifications topic of a user
+func (g *RealtimeGateway) PublishToUser(userID uint, event string, data map[string]interface{}) {
+	g.Publish(RealtimeTopic(RealtimeTopicNotifications, userID), event, data)
+}
+
+// PublishToAdmins sends an event to the admin feed
+func (g *RealtimeGateway) PublishToAdmins(event string, data map[string]interface{}) {
+	g.Publish(RealtimeTopicAdmin, event, data)
+}
+
+// ConnectionCount returns the number of open connections of a user
+func (g *RealtimeGateway) ConnectionCount(userID uint) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	count := 0
+	for conn := range g.connections {
+		if conn.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// Stats returns connection and subscription counts
+func (g *RealtimeGateway) Stats() map[string]interface{} {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	users := make(map[uint]bool)
+	admins := 0
+	for conn := range g.connections {
+		users[conn.UserID] = true
+		if conn.IsAdmin() {
+			admins++
+		}
+	}
+	topicsByKind := make(map[string]int)
+	for topic := range g.topics {
+		kind, _, _ := parseRealtimeTopic(topic)
+		topicsByKind[kind]++
+	}
+	return map[string]interface{}{
+		"protocol_version":  RealtimeProtocolVersion,
+		"connections":       len(g.connections),
+		"admin_connections": admins,
+		"users":             len(users),
+		"topics":            len(g.topics),
+		"topics_by_kind":    topicsByKind,
+		"broker":            g.broker.Name(),
+	}
+}
+
+// subscribe authorizes and adds a subscription of a connection
+func (g *RealtimeGateway) subscribe(conn *RealtimeConnection, topic string) (string, error) {
+	kind, id, err := parseRealtimeTopic(topic)
+	if err != nil {
+		return RealtimeErrorInvalidTopic, err
+	}
+
+	g.mu.RLock()
+	authorizer, ok := g.authorizers[kind]
+	subscribed := conn.subscriptions[topic]
+	count := len(conn.subscriptions)
+	g.mu.RUnlock()
+	if !ok {
+		return RealtimeErrorInvalidTopic, fmt.Errorf("unknown topic kind %q", kind)
+	}
+	if subscribed {
+		return "", nil
+	}
+	if count >= conn.maxSubscriptions {
+		return RealtimeErrorTooManySubscriptions, fmt.Errorf("at most %d subscriptions per connection", conn.maxSubscriptions)
+	}
+	if err := authorizer(conn, id); err != nil {
+		return RealtimeErrorForbidden, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.connections[conn] {
+		return RealtimeErrorFailed, errors.New("connection closed")
+	}
+	g.subscribeLocked(conn, topic)
+	return "", nil
+}
+
+// subscribeLocked adds a subscription; the caller holds the write lock
+func (g *RealtimeGateway) subscribeLocked(conn *RealtimeConnection, topic string) {
+	if g.topics[topic] == nil {
+		g.topics[topic] = make(map[*RealtimeConnection]bool)
+	}
+	g.topics[topic][conn] = true
+	conn.subscriptions[topic] = true
+}
+
+// unsubscribe removes a subscription of a connection
+func (g *RealtimeGateway) unsubscribe(conn *RealtimeConnection, topic string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.unsubscribeLocked(conn, topic)
+}
+
+// unsubscribeLocked removes a subscription; the caller holds the write lock
+func (g *RealtimeGateway) unsubscribeLocked(conn *RealtimeConnection, topic string) {
+	delete(conn.subscriptions, topic)
+	if subscribers, ok := g.topics[topic]; ok {
+		delete(subscribers, conn)
+		if len(subscribers) == 0 {
+			delete(g.topics, topic)
+		}
+	}
+}
+
+// remove drops a closed connection and its subscriptions
+func (g *RealtimeGateway) remove(conn *RealtimeConnection) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.connections, conn)
+	if conn.DeviceID != "" {
+		key := fmt.Sprintf("%d:%s", conn.UserID, conn.DeviceID)
+		if g.devices[key] == conn {
+			delete(g.devices, key)
+		}
+	}
+	for topic := range conn.subscriptions {
+		g.unsubscribeLocked(conn, topic)
+	}
+}
+
+// handle answers one client envelope
+func (g *RealtimeGateway) handle(conn *RealtimeConnection, envelope *RealtimeEnvelope) {
+	if envelope.Version != RealtimeProtocolVersion {
+		conn.fail(envelope, RealtimeErrorUnsupportedVersion, fmt.Sprintf("protocol version %d is required", RealtimeProtocolVersion))
+		return
+	}
+
+	switch envelope.Type {
+	case RealtimeTypePing:
+		conn.write(&RealtimeEnvelope{Type: RealtimeTypePong, Ref: envelope.ID})
+
+	case RealtimeTypeSubscribe:
+		if code, err := g.subscribe(conn, envelope.Topic); err != nil {
+			conn.fail(envelope, code, err.Error())
+			return
+		}
+		conn.ack(envelope, nil)
+
+	case RealtimeTypeUnsubscribe:
+		g.unsubscribe(conn, envelope.Topic)
+		conn.ack(envelope, nil)
+
+	case RealtimeTypePublish:
+		kind, id, err := parseRealtimeTopic(envelope.Topic)
+		if err != nil {
+			conn.fail(envelope, RealtimeErrorInvalidTopic, err.Error())
+			return
+		}
+		g.mu.RLock()
+		handler, ok := g.handlers[kind]
+		subscribed := conn.subscriptions[envelope.Topic]
+		g.mu.RUnlock()
+		if !ok {
+			conn.fail(envelope, RealtimeErrorInvalidTopic, fmt.Sprintf("events cannot be published to %s topics", kind))
+			return
+		}
+		// Subscribing is what authorizes a connection for a topic
+		if !subscribed {
+			conn.fail(envelope, RealtimeErrorNotSubscribed, "subscribe to the topic before publishing to it")
+			return
+		}
+		data, err := handler(conn, id, envelope)
+		if err != nil {
+			conn.fail(envelope, RealtimeErrorFailed, err.Error())
+			return
+		}
+		conn.ack(envelope, data)
+
+	default:
+		conn.fail(envelope, RealtimeErrorUnknownType, fmt.Sprintf("unknown message type %q", envelope.Type))
+	}
+}
+
+// IsAdmin reports whether the connection belongs to an admin
+func (c *RealtimeConnection) IsAdmin() bool {
+	return c.UserType == string(models.UserTypeAdmin)
+}
+
+// Close closes the connection; it is safe to call more than once
+func (c *RealtimeConnection) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.gateway.remove(c)
+		c.conn.Close()
+		logrus.Infof("Realtime connection %s closed for user %d", c.ID, c.UserID)
+	})
+}
+
+// subscribedTopicsLocked lists the connection's topics; the caller holds the gateway's lock
+func (c *RealtimeConnection) subscribedTopicsLocked() []string {
+	topics := make([]string, 0, len(c.subscriptions))
+	for topic := range c.subscriptions {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// write queues an envelope for the connection. A connection that cannot keep up is closed rather
+// than slowing down publishers.
+func (c *RealtimeConnection) write(envelope *RealtimeEnvelope) {
+	envelope.Version = RealtimeProtocolVersion
+	if envelope.Timestamp.IsZero() {
+		envelope.Timestamp = time.Now()
+	}
+	if envelope.Type == RealtimeTypeEvent {
+		// Publishers share the envelope's data, so each connection gets its own copy with its sequence
+		copied := *envelope
+		copied.Seq = atomic.AddUint64(&c.seq, 1)
+		envelope = &copied
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		logrus.Errorf("Failed to marshal realtime envelope for %s: %v", c.ID, err)
+		return
+	}
+
+	select {
+	case <-c.done:
+	case c.send <- data:
+	default:
+		logrus.Warnf("Realtime connection %s send buffer full, closing connection", c.ID)
+		go c.Close()
+	}
+}
+
+// ack acknowledges a client request
+func (c *RealtimeConnection) ack(request *RealtimeEnvelope, data map[string]interface{}) {
+	c.write(&RealtimeEnvelope{
+		Type:  RealtimeTypeAck,
+		Ref:   request.ID,
+		Topic: request.Topic,
+		Event: request.Event,
+		Data:  data,
+	})
+}
+
+// fail answers a client request with an error
+func (c *RealtimeConnection) fail(request *RealtimeEnvelope, code, message string) {
+	c.write(&RealtimeEnvelope{
+		Type:  RealtimeTypeError,
+		Ref:   request.ID,
+		Topic: request.Topic,
+		Event: request.Event,
+		Data: map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+// allow counts a client message against the connection's per-minute limit. The client is told once
+// per window when it goes over the limit; a client that keeps sending at twice the limit is disconnected.
+func (c *RealtimeConnection) allow(now time.Time) (bool, bool) {
+	if now.Sub(c.windowStart) >= realtimeRateLimitWindow {
+		c.windowStart = now
+		c.windowCount = 0
+	}
+	c.windowCount++
+
+	if c.windowCount <= c.messagesPerMinute {
+		return true, false
+	}
+	if c.windowCount == c.messagesPerMinute+1 {
+		c.write(&RealtimeEnvelope{
+			Type: RealtimeTypeError,
+			Data: map[string]interface{}{
+				"code":    RealtimeErrorRateLimited,
+				"message": fmt.Sprintf("at most %d messages per minute", c.messagesPerMinute),
+			},
+		})
+	}
+	return false, c.windowCount > 2*c.messagesPerMinute
+}
+
+// readPump reads client envelopes until the connection closes
+func (c *RealtimeConnection) readPump() {
+	defer c.Close()
+
+	c.conn.SetReadLimit(realtimeReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+		return nil
+	})
+
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logrus.Warnf("Realtime connection %s read error: %v", c.ID, err)
+			}
+			return
+		}
+		// Any client message shows the connection is alive
+		c.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+
+		allowed, disconnect := c.allow(time.Now())
+		if disconnect {
+			logrus.Warnf("Realtime connection %s of user %d exceeded its rate limit, disconnecting", c.ID, c.UserID)
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded"),
+				time.Now().Add(realtimeWriteWait))
+			return
+		}
+		if !allowed {
+			continue
+		}
+
+		var envelope RealtimeEnvelope
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			c.fail(&envelope, RealtimeErrorInvalidMessage, "message is not a valid envelope")
+			continue
+		}
+		c.gateway.handle(c, &envelope)
+	}
+}
+
+// writePump writes queued envelopes and keeps the connection alive with pings
+func (c *RealtimeConnection) writePump() {
+	ticker := time.NewTicker(realtimePingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// referralCodeAlphabet leaves out characters that are easily confused, such as 0 and O
+const referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type ReferralService struct {
+	referralRepo       *repositories.ReferralRepository
+	adminConfigService *AdminConfigService
+}
+
+func NewReferralService() *ReferralService {
+	return &ReferralService{
+		referralRepo:       repositories.NewReferralRepository(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// GetOrCreateCode gets the user's referral code, creating one on first use
+func (rs *ReferralService) GetOrCreateCode(userID uint) (*models.ReferralCode, error) {
+	if code, err := rs.referralRepo.GetCodeByUserID(userID); err == nil {
+		return code, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get referral code: %v", err)
+	}
+
+	// Retry on the rare code collision
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		var value string
+		value, err = generateReferralCode()
+		if err != nil {
+			return nil, err
+		}
+		code := &models.ReferralCode{UserID: userID, Code: value}
+		if err = rs.referralRepo.CreateCode(code); err == nil {
+			return code, nil
+		}
+		// Another request may have created the user's code meanwhile
+		if existing, getErr := rs.referralRepo.GetCodeByUserID(userID); getErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to create referral code: %v", err)
+}
+
+func generateReferralCode() (string, error) {
+	bytes := make([]byte, 6)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate referral code: %w", err)
+	}
+	code := make([]byte, len(bytes))
+	for i, b := range bytes {
+		code[i] = referralCodeAlphabet[int(b)%len(referralCodeAlphabet)]
+	}
+	return "TI" + string(code), nil
+}
+
+// GetSummary gets the user's referral code, their referrals and what they earned
+func (rs *ReferralService) GetSummary(userID uint) (*models.ReferralSummary, error) {
+	code, err := rs.GetOrCreateCode(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	referrals, err := rs.referralRepo.GetByReferrerID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrals: %v", err)
+	}
+
+	summary := &models.ReferralSummary{
+		Code:           code.Code,
+		ReferrerReward: rs.adminConfigService.GetReferralReferrerReward(),
+		RefereeReward:  rs.adminConfigService.GetReferralRefereeReward(),
+		Referrals:      referrals,
+	}
+	for _, referral := range referrals {
+		if referral.Status == models.ReferralStatusRewarded {
+			summary.RewardedCount++
+			if referral.ReferrerPaymentID != nil {
+				summary.TotalEarned += referral.ReferrerReward
+			}
+		} else {
+			summary.PendingCount++
+		}
+	}
+	summary.TotalEarned = roundCurrency(summary.TotalEarned)
+
+	if referral, err := rs.referralRepo.GetByRefereeID(userID); err == nil {
+		summary.ReferredBy = &referral.ReferrerID
+	}
+
+	return summary, nil
+}
+
+// ApplyCode links the user to the referrer whose code they entered. It must be done before the
+// user's first completed booking.
+func (rs *ReferralService) ApplyCode(userID uint, code string) (*models.Referral, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	referralCode, err := rs.referralRepo.GetCodeByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid referral code")
+		}
+		return nil, fmt.Errorf("failed to get referral code: %v", err)
+	}
+	if referralCode.UserID == userID {
+		return nil, errors.New("you cannot use your own referral code")
+	}
+
+	if _, err := rs.referralRepo.GetByRefereeID(userID); err == nil {
+		return nil, errors.New("a referral code has already been applied")
+	}
+
+	completed, err := rs.referralRepo.CountCompletedBookings(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check completed bookings: %v", err)
+	}
+	if completed > 0 {
+		return nil, errors.New("referral codes can only be applied before your first completed booking")
+	}
+
+	referral := &models.Referral{
+		ReferrerID: referralCode.UserID,
+		RefereeID:  userID,
+		Code:       referralCode.Code,
+		Status:     models.ReferralStatusPending,
+	}
+	if err := rs.referralRepo.Create(referral); err != nil {
+		// The unique referee index catches concurrent requests
+		if _, getErr := rs.referralRepo.GetByRefereeID(userID); getErr == nil {
+			return nil, errors.New("a referral code has already been applied")
+		}
+		return nil, fmt.Errorf("failed to apply referral code: %v", err)
+	}
+	return referral, nil
+}
+
+// RewardForCompletedBooking credits the referrer and the referee when the referee completes a booking
+// while their referral is pending. Each referral is rewarded once.
+func (rs *ReferralService) RewardForCompletedBooking(booking *models.Booking) {
+	referral, err := rs.referralRepo.GetByRefereeID(booking.UserID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Errorf("Failed to get referral of user %d: %v", booking.UserID, err)
+		}
+		return
+	}
+	if referral.Status != models.ReferralStatusPending {
+		return
+	}
+
+	referrerReward := rs.adminConfigService.GetReferralReferrerReward()
+	refereeReward := rs.adminConfigService.GetReferralRefereeReward()
+	claimed, err := rs.referralRepo.ClaimReward(referral.ID, booking.ID, referrerReward, refereeReward)
+	if err != nil || !claimed {
+		if err != nil {
+			logrus.Errorf("Failed to claim reward of referral %d: %v", referral.ID, err)
+		}
+		return
+	}
+
+	walletService := NewUnifiedWalletService()
+	var referrerPaymentID, refereePaymentID *uint
+
+	if referrerReward > 0 {
+		payment, err := walletService.CreditPromotion(referral.ReferrerID, referrerReward, models.WalletEntryTypeReferralReward,
+			"referral", referral.ID, "Referral reward")
+		if err != nil {
+			// Nothing has been credited yet, so the referral can be rewarded again later
+			logrus.Errorf("Failed to credit referrer reward of referral %d: %v", referral.ID, err)
+			if err := rs.referralRepo.UnclaimReward(referral.ID); err != nil {
+				logrus.Errorf("Failed to reset referral %d: %v", referral.ID, err)
+			}
+			return
+		}
+		referrerPaymentID = &payment.ID
+	}
+
+	if refereeReward > 0 {
+		payment, err := walletService.CreditPromotion(referral.RefereeID, refereeReward, models.WalletEntryTypeReferralReward,
+			"referral", referral.ID, "Welcome reward for your first booking")
+		if err != nil {
+			logrus.Errorf("Failed to credit referee reward of referral %d: %v", referral.ID, err)
+		} else {
+			refereePaymentID = &payment.ID
+		}
+	}
+
+	if err := rs.referralRepo.SetRewardPayments(referral.ID, referrerPaymentID, refereePaymentID); err != nil {
+		logrus.Errorf("Failed to record reward payments of referral %d: %v", referral.ID, err)
+	}
+	logrus.Infof("Referral %d rewarded for booking %d", referral.ID, booking.ID)
+}
+
+// GetReferrals gets referrals with filters
+func (rs *ReferralService) GetReferrals(filters *models.ReferralFilters) ([]models.Referral, *repositories.Pagination, error) {
+	return rs.referralRepo.GetReferrals(filters)
+}
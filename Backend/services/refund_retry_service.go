@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// refundRetryBatchSize is how many pending refunds one retry run attempts
+	refundRetryBatchSize = 100
+	// refundRetryBaseDelay is the wait before the first retry, doubled after every failed attempt
+	refundRetryBaseDelay = 15 * time.Minute
+	// refundRetryMaxDelay is the longest wait between retries
+	refundRetryMaxDelay = 24 * time.Hour
+)
+
+// pendingRefundStore stores the refunds waiting to be retried
+type pendingRefundStore interface {
+	Create(refund *models.PendingRefund) error
+	Update(refund *models.PendingRefund) error
+	GetDue(now time.Time, limit int) ([]models.PendingRefund, error)
+	CountPending(bookingID uint) (int64, error)
+	GetPendingRefunds(filters *models.PendingRefundFilters) ([]models.PendingRefund, *repositories.Pagination, error)
+}
+
+// pendingRefundIssuer issues refunds of payments
+type pendingRefundIssuer interface {
+	RefundPayment(paymentID uint, req *models.RefundPaymentRequest) (*models.Payment, error)
+}
+
+// pendingRefundBookings settles the payment status of bookings once their refunds are issued
+type pendingRefundBookings interface {
+	SettleRefundPending(bookingID uint, status models.PaymentStatus) error
+}
+
+// RefundRetryService keeps the cancellation refunds that could not be issued and retries them with
+// a growing delay until they are, so a failed refund is never left to be noticed by the customer
+type RefundRetryService struct {
+	refundRepo     pendingRefundStore
+	paymentService pendingRefundIssuer
+	bookingRepo    pendingRefundBookings
+}
+
+// NewRefundRetryService creates a new refund retry service
+func NewRefundRetryService() *RefundRetryService {
+	return &RefundRetryService{
+		refundRepo:     repositories.NewPendingRefundRepository(),
+		paymentService: NewPaymentService(),
+		bookingRepo:    repositories.NewBookingRepository(),
+	}
+}
+
+// QueueFailedRefunds stores the refunds of a cancellation summary that failed, to be retried. Once they
+// are all issued the booking's payment status moves to settledStatus.
+func (rrs *RefundRetryService) QueueFailedRefunds(summary *models.CancellationSummary, reason string, settledStatus models.PaymentStatus, now time.Time) error {
+	for _, item := range summary.Items {
+		if item.RefundStatus != "failed" {
+			continue
+		}
+		refund := &models.PendingRefund{
+			BookingID:            summary.BookingID,
+			PaymentID:            item.PaymentID,
+			Amount:               item.RefundAmount,
+			RefundMethod:         item.RefundMethod,
+			Reason:               reason,
+			Notes:                fmt.Sprintf("Cancellation refund for booking %d (fee %.0f%%)", summary.BookingID, item.FeePercentage),
+			SettledPaymentStatus: settledStatus,
+			Status:               models.PendingRefundStatusPending,
+			Attempts:             1,
+			LastError:            item.RefundError,
+			NextAttemptAt:        now.Add(refundRetryDelay(1)),
+		}
+		if err := rrs.refundRepo.Create(refund); err != nil {
+			return fmt.Errorf("failed to queue refund of payment %d: %v", item.PaymentID, err)
+		}
+	}
+	return nil
+}
+
+// RetryDue retries the pending refunds that are due. A booking's payment status is settled once its
+// last pending refund is issued.
+func (rrs *RefundRetryService) RetryDue(now time.Time) (*models.RefundRetryResult, error) {
+	refunds, err := rrs.refundRepo.GetDue(now, refundRetryBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending refunds: %v", err)
+	}
+
+	result := &models.RefundRetryResult{}
+	for i := range refunds {
+		refund := &refunds[i]
+		result.Retried++
+		refund.Attempts++
+
+		_, err := rrs.paymentService.RefundPayment(refund.PaymentID, &models.RefundPaymentRequest{
+			RefundAmount: refund.Amount,
+			RefundReason: refund.Reason,
+			RefundMethod: refund.RefundMethod,
+			Notes:        refund.Notes,
+		})
+		if err != nil {
+			result.Failed++
+			refund.LastError = err.Error()
+			refund.NextAttemptAt = now.Add(refundRetryDelay(refund.Attempts))
+			logrus.Warnf("Retry %d of refund of payment %d for booking %d failed: %v", refund.Attempts, refund.PaymentID, refund.BookingID, err)
+			if err := rrs.refundRepo.Update(refund); err != nil {
+				logrus.Errorf("Failed to update pending refund %d: %v", refund.ID, err)
+			}
+			continue
+		}
+
+		result.Issued++
+		refund.Status = models.PendingRefundStatusIssued
+		refund.LastError = ""
+		refund.IssuedAt = &now
+		if err := rrs.refundRepo.Update(refund); err != nil {
+			logrus.Errorf("Refund of payment %d issued but pending refund %d could not be updated: %v", refund.PaymentID, refund.ID, err)
+			continue
+		}
+		logrus.Infof("Issued pending refund of ₹%.2f of payment %d for booking %d", refund.Amount, refund.PaymentID, refund.BookingID)
+
+		left, err := rrs.refundRepo.CountPending(refund.BookingID)
+		if err != nil {
+			logrus.Errorf("Failed to count pending refunds of booking %d: %v", refund.BookingID, err)
+			continue
+		}
+		if left == 0 {
+			if err := rrs.bookingRepo.SettleRefundPending(refund.BookingID, refund.SettledPaymentStatus); err != nil {
+				logrus.Errorf("Failed to settle payment status of booking %d: %v", refund.BookingID, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// GetPendingRefunds lists refunds waiting to be retried, or already issued by a retry
+func (rrs *RefundRetryService) GetPendingRefunds(filters *models.PendingRefundFilters) ([]models.PendingRefund, *repositories.Pagination, error) {
+	return rrs.refundRepo.GetPendingRefunds(filters)
+}
+
+// refundRetryDelay returns how long to wait after the given number of failed attempts
+func refundRetryDelay(attempts int) time.Duration {
+	delay := refundRetryBaseDelay
+	for i := 1; i < attempts && delay < refundRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > refundRetryMaxDelay {
+		return refundRetryMaxDelay
+	}
+	return delay
+}
+
+// StartRetryJob starts a periodic job that retries pending refunds
+func (rrs *RefundRetryService) StartRetryJob() {
+	ticker := time.NewTicker(15 * time.Minute) // Run every 15 minutes
+	go func() {
+		for range ticker.C {
+			result, err := rrs.RetryDue(time.Now())
+			if err != nil {
+				logrus.Errorf("Refund retry job failed: %v", err)
+				continue
+			}
+			if result.Retried > 0 {
+				logrus.Infof("Refund retry job retried %d refunds: %d issued, %d failed", result.Retried, result.Issued, result.Failed)
+			}
+		}
+	}()
+	logrus.Info("Refund retry job started")
+}
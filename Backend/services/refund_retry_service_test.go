@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+)
+
+// fakePendingRefunds keeps pending refunds in memory
+type fakePendingRefunds struct {
+	refunds []*models.PendingRefund
+}
+
+func (f *fakePendingRefunds) Create(refund *models.PendingRefund) error {
+	refund.ID = uint(len(f.refunds) + 1)
+	f.refunds = append(f.refunds, refund)
+	return nil
+}
+
+func (f *fakePendingRefunds) Update(refund *models.PendingRefund) error {
+	stored := *refund
+	f.refunds[refund.ID-1] = &stored
+	return nil
+}
+
+func (f *fakePendingRefunds) GetDue(now time.Time, limit int) ([]models.PendingRefund, error) {
+	var due []models.PendingRefund
+	for _, refund := range f.refunds {
+		if refund.Status == models.PendingRefundStatusPending && !refund.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *refund)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakePendingRefunds) CountPending(bookingID uint) (int64, error) {
+	var count int64
+	for _, refund := range f.refunds {
+		if refund.BookingID == bookingID && refund.Status == models.PendingRefundStatusPending {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakePendingRefunds) GetPendingRefunds(filters *models.PendingRefundFilters) ([]models.PendingRefund, *repositories.Pagination, error) {
+	return nil, nil, nil
+}
+
+// fakeRefundIssuer fails the refunds of the payments in failing and records the ones it issues
+type fakeRefundIssuer struct {
+	failing map[uint]bool
+	issued  []uint
+}
+
+func (f *fakeRefundIssuer) RefundPayment(paymentID uint, req *models.RefundPaymentRequest) (*models.Payment, error) {
+	if f.failing[paymentID] {
+		return nil, errors.New("gateway unavailable")
+	}
+	f.issued = append(f.issued, paymentID)
+	return &models.Payment{}, nil
+}
+
+// fakeSettledBookings records the payment status each booking was settled to
+type fakeSettledBookings map[uint]models.PaymentStatus
+
+func (f fakeSettledBookings) SettleRefundPending(bookingID uint, status models.PaymentStatus) error {
+	f[bookingID] = status
+	return nil
+}
+
+func TestRetryDueIssuesQueuedRefundsAndSettlesBooking(t *testing.T) {
+	store := &fakePendingRefunds{}
+	issuer := &fakeRefundIssuer{failing: map[uint]bool{11: true, 12: true}}
+	bookings := fakeSettledBookings{}
+	service := &RefundRetryService{refundRepo: store, paymentService: issuer, bookingRepo: bookings}
+
+	cancelledAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	summary := &models.CancellationSummary{
+		BookingID:       5,
+		CancellationFee: 100,
+		Items: []models.CancellationRefundItem{
+			{PaymentID: 10, RefundAmount: 200, RefundMethod: "wallet", RefundStatus: "refunded"},
+			{PaymentID: 11, RefundAmount: 300, RefundMethod: models.PaymentMethodOnline, RefundStatus: "failed", RefundError: "gateway unavailable"},
+			{PaymentID: 12, RefundAmount: 400, RefundMethod: models.PaymentMethodOnline, RefundStatus: "failed", RefundError: "gateway unavailable"},
+		},
+	}
+	if err := service.QueueFailedRefunds(summary, "Booking cancelled", models.PaymentStatusPartiallyRefunded, cancelledAt); err != nil {
+		t.Fatalf("QueueFailedRefunds() error = %v", err)
+	}
+	if len(store.refunds) != 2 {
+		t.Fatalf("queued %d refunds, want the 2 failed ones", len(store.refunds))
+	}
+
+	// Nothing is retried before the first retry is due
+	result, err := service.RetryDue(cancelledAt.Add(time.Minute))
+	if err != nil || result.Retried != 0 {
+		t.Fatalf("RetryDue() before the delay = %+v, %v, want nothing retried", result, err)
+	}
+
+	// The gateway is still down, so the next attempt waits twice as long
+	firstRetry := cancelledAt.Add(refundRetryBaseDelay)
+	result, err = service.RetryDue(firstRetry)
+	if err != nil || result.Retried != 2 || result.Failed != 2 {
+		t.Fatalf("RetryDue() = %+v, %v, want 2 failed retries", result, err)
+	}
+	if refund := store.refunds[0]; refund.Attempts != 2 || !refund.NextAttemptAt.Equal(firstRetry.Add(2*refundRetryBaseDelay)) {
+		t.Errorf("after a failed retry: %d attempts, next at %v, want 2 attempts and a doubled delay", refund.Attempts, refund.NextAttemptAt)
+	}
+
+	// One payment can be refunded again; the booking waits for the other
+	delete(issuer.failing, 11)
+	secondRetry := firstRetry.Add(2 * refundRetryBaseDelay)
+	if result, err = service.RetryDue(secondRetry); err != nil || result.Issued != 1 || result.Failed != 1 {
+		t.Fatalf("RetryDue() = %+v, %v, want 1 issued and 1 failed", result, err)
+	}
+	if store.refunds[0].Status != models.PendingRefundStatusIssued || store.refunds[0].IssuedAt == nil {
+		t.Errorf("refund of payment 11 is %s, want issued", store.refunds[0].Status)
+	}
+	if _, settled := bookings[5]; settled {
+		t.Fatal("booking settled while a refund is still pending")
+	}
+
+	delete(issuer.failing, 12)
+	if result, err = service.RetryDue(secondRetry.Add(refundRetryMaxDelay)); err != nil || result.Issued != 1 {
+		t.Fatalf("RetryDue() = %+v, %v, want the last refund issued", result, err)
+	}
+	if bookings[5] != models.PaymentStatusPartiallyRefunded {
+		t.Errorf("booking payment status = %q, want partially_refunded once every refund is issued", bookings[5])
+	}
+	if len(issuer.issued) != 2 {
+		t.Errorf("issued refunds of payments %v, want each pending refund issued once", issuer.issued)
+	}
+}
+
+func TestRefundRetryDelayDoublesUpToADay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  15 * time.Minute,
+		2:  30 * time.Minute,
+		4:  2 * time.Hour,
+		20: 24 * time.Hour,
+	} {
+		if got := refundRetryDelay(attempts); got != want {
+			t.Errorf("refundRetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -14,6 +14,9 @@ type SimpleConversationService struct {
 	messageRepo      *repositories.SimpleConversationMessageRepository
 	userRepo         *repositories.UserRepository
 	wsService        *SimpleConversationWebSocketService
+	syncService      *ChatSyncService
+	attachmentService *ChatAttachmentService
+	moderationService *ChatModerationService
 }
 
 func NewSimpleConversationService(
@@ -27,6 +30,9 @@ func NewSimpleConversationService(
 		messageRepo:      messageRepo,
 		userRepo:         userRepo,
 		wsService:        wsService,
+		syncService:      NewChatSyncService(),
+		attachmentService: NewChatAttachmentService(),
+		moderationService: NewChatModerationService(),
 	}
 }
 
@@ -252,12 +258,29 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 		return nil, err
 	}
 
+	// Refuse muted senders and mask contact details and blocked words
+	moderation, err := s.moderationService.ModerateMessage(senderID, models.ChatThreadConversation, conversationID, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the uploaded attachments sent with the message
+	attachmentDetails, messageType, err := s.attachmentService.PrepareMessage(senderID, models.ChatThreadConversation, conversationID, req.AttachmentIDs, models.MessageTypeText)
+	if err != nil {
+		return nil, err
+	}
+	if req.Message == "" && len(attachmentDetails) == 0 {
+		return nil, errors.New("message or attachments are required")
+	}
+
 	// Create message
 	message := &models.SimpleConversationMessage{
-		ConversationID: conversationID,
-		SenderID:       senderID,
-		Message:        req.Message,
-		IsRead:         false,
+		ConversationID:    conversationID,
+		SenderID:          senderID,
+		Message:           moderation.Text,
+		IsRead:            false,
+		MessageType:       messageType,
+		AttachmentDetails: attachmentDetails,
 	}
 
 	// Save message
@@ -266,6 +289,8 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 		logrus.Errorf("SimpleConversationService.SendMessage failed to create message: %v", err)
 		return nil, err
 	}
+	s.attachmentService.AttachToMessage(attachmentDetails, message.ID)
+	s.moderationService.FlagMessage(moderation, models.ChatThreadConversation, conversationID, message.ID, senderID)
 
 	// Update conversation's last message timestamp
 	if err := s.conversationRepo.UpdateLastMessage(conversationID); err != nil {
@@ -300,6 +325,8 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 				"conversation_id": message.ConversationID,
 				"sender_id":       message.SenderID,
 				"message":         message.Message,
+				"message_type":    message.MessageType,
+				"attachment_details": message.AttachmentDetails,
 				"is_read":         message.IsRead,
 				"created_at":      message.CreatedAt,
 				"sender": map[string]interface{}{
@@ -318,7 +345,7 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 				if err != nil {
 					logrus.Errorf("Failed to get user_1 total unread count: %v", err)
 				} else {
-					s.wsService.BroadcastTotalUnreadCountToUserMonitors(user1TotalUnreadCount)
+					s.wsService.BroadcastTotalUnreadCountToUserMonitors(conversation.User1, user1TotalUnreadCount)
 				}
 
 				// Also broadcast individual conversation unread count to user_1
@@ -326,7 +353,7 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 				if err != nil {
 					logrus.Errorf("Failed to get user_1 conversation unread count: %v", err)
 				} else {
-					s.wsService.BroadcastConversationUnreadCountToUserMonitors(conversationID, int(user1ConversationUnreadCount))
+					s.wsService.BroadcastConversationUnreadCountToUserMonitors(conversation.User1, conversationID, int(user1ConversationUnreadCount))
 				}
 
 				// Broadcast to user_2
@@ -334,7 +361,7 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 				if err != nil {
 					logrus.Errorf("Failed to get user_2 total unread count: %v", err)
 				} else {
-					s.wsService.BroadcastTotalUnreadCountToUserMonitors(user2TotalUnreadCount)
+					s.wsService.BroadcastTotalUnreadCountToUserMonitors(conversation.User2, user2TotalUnreadCount)
 				}
 
 				// Also broadcast individual conversation unread count to user_2
@@ -342,7 +369,7 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 				if err != nil {
 					logrus.Errorf("Failed to get user_2 conversation unread count: %v", err)
 				} else {
-					s.wsService.BroadcastConversationUnreadCountToUserMonitors(conversationID, int(user2ConversationUnreadCount))
+					s.wsService.BroadcastConversationUnreadCountToUserMonitors(conversation.User2, conversationID, int(user2ConversationUnreadCount))
 				}
 			}
 		}()
@@ -350,6 +377,9 @@ func (s *SimpleConversationService) SendMessage(senderID uint, conversationID ui
 		logrus.Warn("WebSocket service is nil, cannot broadcast message")
 	}
 
+	// Push the message to the other participant when they do not receive it live
+	go s.syncService.MessageSent(models.ChatThreadConversation, conversationID, message.ID, senderID, sender.Name, messagePreview(message.Message, message.MessageType))
+
 	logrus.Infof("SimpleConversationService.SendMessage successfully sent message ID: %d", message.ID)
 	return message, nil
 }
@@ -476,6 +506,19 @@ func (s *SimpleConversationService) validateConversationAccess(conversation *mod
 	return errors.New("access denied")
 }
 
+// AuthorizeRealtimeTopic lets the participants and admins follow a conversation's realtime topic
+func (s *SimpleConversationService) AuthorizeRealtimeTopic(conn *RealtimeConnection, conversationID uint) error {
+	if conn.IsAdmin() {
+		return nil
+	}
+
+	conversation, err := s.conversationRepo.GetByID(conversationID)
+	if err != nil {
+		return errors.New("conversation not found")
+	}
+	return s.validateConversationAccess(conversation, conn.UserID)
+}
+
 // Helper function to get pointer to uint
 func getUintPtr(value uint) *uint {
 	if value == 0 {
@@ -9,7 +9,9 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// SimpleConversationWebSocketService handles WebSocket connections for simple conversations
+// SimpleConversationWebSocketService handles the legacy conversation WebSocket connections. Every event
+// is also published to the conversation, admin or user topics of the realtime gateway. Conversation
+// messages go through the realtime broker and are written by the running service of every replica.
 type SimpleConversationWebSocketService struct {
 	// Map of conversation ID to connected clients
 	conversationClients map[uint]map[*websocket.Conn]bool
@@ -21,8 +23,8 @@ type SimpleConversationWebSocketService struct {
 	userMonitorConnections map[uint]*websocket.Conn
 	// Mutex for thread safety
 	mutex sync.RWMutex
-	// Channel for broadcasting messages
-	broadcast chan SimpleConversationMessage
+	// Broker fanning conversation messages out to the services of every replica
+	broker RealtimeBroker
 	// Channel for registering new clients
 	register chan SimpleConversationClient
 	// Channel for unregistering clients
@@ -50,7 +52,7 @@ func NewSimpleConversationWebSocketService() *SimpleConversationWebSocketService
 		userConnections:        make(map[uint][]*websocket.Conn),
 		adminConnections:       make(map[uint]*websocket.Conn),
 		userMonitorConnections: make(map[uint]*websocket.Conn),
-		broadcast:              make(chan SimpleConversationMessage),
+		broker:                 GetRealtimeBroker(),
 		register:            make(chan SimpleConversationClient),
 		unregister:          make(chan SimpleConversationClient),
 	}
@@ -58,6 +60,10 @@ func NewSimpleConversationWebSocketService() *SimpleConversationWebSocketService
 
 // Start starts the WebSocket service
 func (s *SimpleConversationWebSocketService) Start() {
+	if err := s.broker.Subscribe(RealtimeChannelConversations, s.receive); err != nil {
+		log.Printf("Failed to subscribe the conversation WebSocket service to the %s broker: %v", s.broker.Name(), err)
+	}
+
 	for {
 		select {
 		case client := <-s.register:
@@ -65,13 +71,32 @@ func (s *SimpleConversationWebSocketService) Start() {
 
 		case client := <-s.unregister:
 			s.unregisterClient(client)
-
-		case message := <-s.broadcast:
-			s.broadcastMessage(message)
 		}
 	}
 }
 
+// publish sends a conversation message to the services of every replica, or only to this one when the broker is unavailable
+func (s *SimpleConversationWebSocketService) publish(message SimpleConversationMessage) {
+	payload, err := json.Marshal(message)
+	if err == nil {
+		err = s.broker.Publish(RealtimeChannelConversations, payload)
+	}
+	if err != nil {
+		log.Printf("Failed to publish conversation message %s through the broker: %v", message.Event, err)
+		s.broadcastMessage(message)
+	}
+}
+
+// receive delivers a conversation message published on the broker
+func (s *SimpleConversationWebSocketService) receive(payload []byte) {
+	var message SimpleConversationMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		log.Printf("Invalid conversation message from the broker: %v", err)
+		return
+	}
+	s.broadcastMessage(message)
+}
+
 // RegisterClient registers a new WebSocket client
 func (s *SimpleConversationWebSocketService) RegisterClient(conn *websocket.Conn, userID uint, conversationID uint) {
 	client := SimpleConversationClient{
@@ -97,7 +122,13 @@ func (s *SimpleConversationWebSocketService) BroadcastSimpleConversationMessage(
 		Message:        messageData,
 		Event:          "conversation_message",
 	}
-	s.broadcast <- message
+	gateway := GetRealtimeGateway()
+	gateway.Publish(RealtimeTopic(RealtimeTopicConversation, conversationID), message.Event, messageData)
+	gateway.PublishToAdmins("new_conversation_message", map[string]interface{}{
+		"conversation_id": conversationID,
+		"message":         messageData,
+	})
+	s.publish(message)
 }
 
 // BroadcastConversationStatus broadcasts conversation status updates
@@ -107,11 +138,16 @@ func (s *SimpleConversationWebSocketService) BroadcastConversationStatus(convers
 		Message:        statusData,
 		Event:          "conversation_status",
 	}
-	s.broadcast <- message
+	GetRealtimeGateway().Publish(RealtimeTopic(RealtimeTopicConversation, conversationID), message.Event, statusData)
+	s.publish(message)
 }
 
 // BroadcastTotalUnreadCount broadcasts total unread count to all admin clients
 func (s *SimpleConversationWebSocketService) BroadcastTotalUnreadCount(totalUnreadCount int) {
+	GetRealtimeGateway().PublishToAdmins("total_unread_count", map[string]interface{}{
+		"total_unread_count": totalUnreadCount,
+	})
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
@@ -139,7 +175,11 @@ func (s *SimpleConversationWebSocketService) BroadcastTotalUnreadCount(totalUnre
 
 // BroadcastConversationUnreadCount broadcasts individual conversation unread count to all admin clients
 func (s *SimpleConversationWebSocketService) BroadcastConversationUnreadCount(conversationID uint, unreadCount int) {
-	
+	GetRealtimeGateway().PublishToAdmins("conversation_unread_count", map[string]interface{}{
+		"conversation_id": conversationID,
+		"unread_count":    unreadCount,
+	})
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
@@ -226,8 +266,9 @@ func (s *SimpleConversationWebSocketService) unregisterClient(client SimpleConve
 // broadcastMessage broadcasts a message to all clients in a conversation
 func (s *SimpleConversationWebSocketService) broadcastMessage(message SimpleConversationMessage) {
 	log.Printf("broadcastMessage called for conversation %d with event %s", message.ConversationID, message.Event)
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+	// Broken connections are removed, and the broker may deliver from several goroutines
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
 	// Always notify admin clients about new messages first
 	if message.Event == "conversation_message" {
@@ -417,69 +458,55 @@ func (s *SimpleConversationWebSocketService) BroadcastToUserMonitors(event strin
 	}
 }
 
-// BroadcastTotalUnreadCountToUserMonitors broadcasts total unread count to all connected user monitor clients
-func (s *SimpleConversationWebSocketService) BroadcastTotalUnreadCountToUserMonitors(totalUnreadCount int) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	userMessage := map[string]interface{}{
-		"event": "total_unread_count",
-		"data": map[string]interface{}{
-			"total_unread_count": totalUnreadCount,
-		},
-		"timestamp": time.Now().Unix(),
-	}
-
-	userData, err := json.Marshal(userMessage)
-	if err != nil {
-		log.Printf("Error marshaling user total unread count notification: %v", err)
-		return
+// BroadcastTotalUnreadCountToUserMonitors sends a user's total unread count to their monitor connection
+func (s *SimpleConversationWebSocketService) BroadcastTotalUnreadCountToUserMonitors(userID uint, totalUnreadCount int) {
+	data := map[string]interface{}{
+		"total_unread_count": totalUnreadCount,
 	}
+	GetRealtimeGateway().PublishToUser(userID, "total_unread_count", data)
+	s.sendToUserMonitor(userID, "total_unread_count", data)
+}
 
-	for userID, conn := range s.userMonitorConnections {
-		if err := conn.WriteMessage(websocket.TextMessage, userData); err != nil {
-			log.Printf("Error sending total unread count to user monitor %d: %v", userID, err)
-			conn.Close()
-			go s.UnregisterUserMonitorClient(userID)
-		} else {
-			log.Printf("Successfully sent total unread count to user monitor %d", userID)
-		}
+// BroadcastConversationUnreadCountToUserMonitors sends a user's unread count of a conversation to their monitor connection
+func (s *SimpleConversationWebSocketService) BroadcastConversationUnreadCountToUserMonitors(userID uint, conversationID uint, unreadCount int) {
+	data := map[string]interface{}{
+		"conversation_id": conversationID,
+		"unread_count":    unreadCount,
 	}
+	GetRealtimeGateway().PublishToUser(userID, "conversation_unread_count", data)
+	s.sendToUserMonitor(userID, "conversation_unread_count", data)
 }
 
-// BroadcastConversationUnreadCountToUserMonitors broadcasts conversation unread count to all connected user monitor clients
-func (s *SimpleConversationWebSocketService) BroadcastConversationUnreadCountToUserMonitors(conversationID uint, unreadCount int) {
+// sendToUserMonitor sends an event to the monitor connection of one user
+func (s *SimpleConversationWebSocketService) sendToUserMonitor(userID uint, event string, data map[string]interface{}) {
 	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	userMessage := map[string]interface{}{
-		"event": "conversation_unread_count",
-		"data": map[string]interface{}{
-			"conversation_id": conversationID,
-			"unread_count":    unreadCount,
-		},
-		"timestamp": time.Now().Unix(),
+	conn, exists := s.userMonitorConnections[userID]
+	s.mutex.RUnlock()
+	if !exists {
+		return
 	}
 
-	userData, err := json.Marshal(userMessage)
+	userData, err := json.Marshal(map[string]interface{}{
+		"event":     event,
+		"data":      data,
+		"timestamp": time.Now().Unix(),
+	})
 	if err != nil {
-		log.Printf("Error marshaling user conversation unread count notification: %v", err)
+		log.Printf("Error marshaling user monitor %s notification: %v", event, err)
 		return
 	}
 
-	for userID, conn := range s.userMonitorConnections {
-		if err := conn.WriteMessage(websocket.TextMessage, userData); err != nil {
-			log.Printf("Error sending conversation unread count to user monitor %d: %v", userID, err)
-			conn.Close()
-			go s.UnregisterUserMonitorClient(userID)
-		} else {
-			log.Printf("Successfully sent conversation unread count to user monitor %d", userID)
-		}
+	if err := conn.WriteMessage(websocket.TextMessage, userData); err != nil {
+		log.Printf("Error sending %s to user monitor %d: %v", event, userID, err)
+		conn.Close()
+		go s.UnregisterUserMonitorClient(userID)
 	}
 }
 
 // BroadcastToAdmins broadcasts a message to all connected admin clients
 func (s *SimpleConversationWebSocketService) BroadcastToAdmins(event string, data map[string]interface{}) {
+	GetRealtimeGateway().PublishToAdmins(event, data)
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
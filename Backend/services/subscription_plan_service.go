@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"strings"
 	"treesindia/models"
@@ -89,12 +90,23 @@ func (sps *SubscriptionPlanService) CreatePlanWithPricing(planData map[string]in
 		})
 	}
 	
+	// Handle entitlements - omitted keys keep the defaults
+	var entitlements *models.PlanEntitlements
+	if entitlementsData, ok := planData["entitlements"]; ok && entitlementsData != nil {
+		parsed, err := parseEntitlements(entitlementsData, models.DefaultPlanEntitlements())
+		if err != nil {
+			return nil, err
+		}
+		entitlements = parsed
+	}
+	
 	plan := &models.SubscriptionPlan{
-		Name:        name,
-		Description: description,
-		IsActive:    isActive,
-		Features:    features,
-		Pricing:     pricingOptions,
+		Name:         name,
+		Description:  description,
+		IsActive:     isActive,
+		Features:     features,
+		Pricing:      pricingOptions,
+		Entitlements: entitlements,
 	}
 	
 	if err := sps.planRepo.Create(plan); err != nil {
@@ -205,6 +217,24 @@ func (sps *SubscriptionPlanService) UpdatePlan(id uint, planData map[string]inte
 		plan.Pricing = pricingOptions
 	}
 	
+	// Handle entitlements - omitted keys keep their current values, null resets to the defaults
+	if entitlementsData, ok := planData["entitlements"]; ok {
+		if entitlementsData == nil {
+			plan.Entitlements = nil
+		} else {
+			current := models.DefaultPlanEntitlements()
+			if plan.Entitlements != nil {
+				copied := *plan.Entitlements
+				current = &copied
+			}
+			parsed, err := parseEntitlements(entitlementsData, current)
+			if err != nil {
+				return nil, err
+			}
+			plan.Entitlements = parsed
+		}
+	}
+	
 	if err := sps.planRepo.Update(plan); err != nil {
 		return nil, err
 	}
@@ -256,3 +286,23 @@ func (sps *SubscriptionPlanService) GetPlansByDuration(duration string) ([]model
 	return filteredPlans, nil
 }
 
+// parseEntitlements applies an entitlements object from plan data over base and validates the result
+func parseEntitlements(data interface{}, base *models.PlanEntitlements) (*models.PlanEntitlements, error) {
+	entitlementsMap, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("entitlements must be an object")
+	}
+	
+	raw, err := json.Marshal(entitlementsMap)
+	if err != nil {
+		return nil, errors.New("invalid entitlements format")
+	}
+	if err := json.Unmarshal(raw, base); err != nil {
+		return nil, errors.New("invalid entitlements: quotas must be whole numbers and chatbot_lead_access a boolean")
+	}
+	
+	if err := base.Validate(); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SubscriptionRenewalService renews subscriptions from the wallet or through Razorpay, and runs
+// the job that auto-renews, moves unpaid subscriptions into their grace period and expires them
+type SubscriptionRenewalService struct {
+	subscriptionRepo    *repositories.UserSubscriptionRepository
+	paymentRepo         *repositories.PaymentRepository
+	adminConfigService  *AdminConfigService
+	notificationService *NotificationService
+	subscriptionCache   *utils.SubscriptionCache
+}
+
+// NewSubscriptionRenewalService creates a new subscription renewal service
+func NewSubscriptionRenewalService() *SubscriptionRenewalService {
+	return &SubscriptionRenewalService{
+		subscriptionRepo:    repositories.NewUserSubscriptionRepository(),
+		paymentRepo:         repositories.NewPaymentRepository(),
+		adminConfigService:  NewAdminConfigService(),
+		notificationService: NewNotificationService(),
+		subscriptionCache:   utils.NewSubscriptionCache(),
+	}
+}
+
+// RenewNow renews the user's current subscription with the same plan. Wallet renewals take effect
+// immediately; Razorpay renewals return an order and take effect when it is paid.
+func (srs *SubscriptionRenewalService) RenewNow(userID uint, paymentMethod string) (*models.SubscriptionPaymentResult, error) {
+	subscription, err := srs.subscriptionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no active subscription to renew")
+	}
+
+	switch paymentMethod {
+	case models.PaymentMethodWallet:
+		renewed, err := srs.renewFromWallet(subscription)
+		if err != nil {
+			return nil, err
+		}
+		return &models.SubscriptionPaymentResult{Subscription: renewed}, nil
+	case models.PaymentMethodRazorpay:
+		plan, pricing, err := srs.renewalPricing(subscription)
+		if err != nil {
+			return nil, err
+		}
+		payment, order, err := srs.createRenewalOrder(subscription, plan, pricing)
+		if err != nil {
+			return nil, err
+		}
+		return &models.SubscriptionPaymentResult{Payment: payment, Order: order}, nil
+	}
+
+	return nil, errors.New("invalid payment method")
+}
+
+// ActivateRenewalPayment starts the term a completed Razorpay renewal payment was made for. If the
+// subscription was renewed another way or ended in the meantime, the payment still buys a term
+// that follows whatever the user has now.
+func (srs *SubscriptionRenewalService) ActivateRenewalPayment(payment *models.Payment, plan *models.SubscriptionPlan, previousID uint) (*models.UserSubscription, error) {
+	durationType, _ := (*payment.Metadata)["duration_type"].(string)
+	pricing := findPricingOption(plan, durationType)
+	if pricing == nil {
+		return nil, errors.New("invalid duration type for this plan")
+	}
+
+	previous, err := srs.subscriptionRepo.GetByID(previousID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get renewed subscription: %v", err)
+	}
+	if previous.UserID != payment.UserID {
+		return nil, errors.New("payment does not belong to subscription owner")
+	}
+
+	base := previous
+	if !containsString([]string{models.SubscriptionStatusActive, models.SubscriptionStatusGrace}, previous.Status) {
+		base = nil
+		if current, err := srs.subscriptionRepo.GetActiveByUserID(payment.UserID); err == nil {
+			base = current
+		}
+	}
+
+	next := srs.nextTerm(base, payment.UserID, plan, pricing)
+	next.AutoRenew = previous.AutoRenew
+	next.PaymentMethod = models.PaymentMethodRazorpay
+	next.PaymentID = *payment.GatewayPaymentID
+	next.Amount = payment.Amount
+
+	started, err := srs.subscriptionRepo.StartTerm(next, base, models.SubscriptionStatusRenewed)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, errors.New("subscription was renewed or changed at the same time, please retry")
+	}
+
+	srs.subscriptionCache.Invalidate(payment.UserID)
+	srs.notifyRenewed(next)
+
+	return next, nil
+}
+
+// ProcessRenewals auto-renews subscriptions that are about to end, moves ended auto-renewing
+// subscriptions into their grace period and expires the rest
+func (srs *SubscriptionRenewalService) ProcessRenewals() error {
+	now := time.Now()
+
+	var firstErr error
+	steps := []func(time.Time) error{
+		srs.autoRenewDueSubscriptions,
+		srs.lapseEndedSubscriptions,
+		srs.expireGraceEnded,
+	}
+	for _, step := range steps {
+		if err := step(now); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// StartRenewalJob starts the background subscription renewal job
+func (srs *SubscriptionRenewalService) StartRenewalJob() {
+	ticker := time.NewTicker(1 * time.Hour) // Run every hour
+	go func() {
+		for range ticker.C {
+			if err := srs.ProcessRenewals(); err != nil {
+				logrus.Errorf("Subscription renewal job failed: %v", err)
+			}
+		}
+	}()
+	logrus.Info("Subscription renewal job started")
+}
+
+// autoRenewDueSubscriptions tries to renew auto-renewing subscriptions from the wallet. When the
+// wallet cannot pay, the user gets a Razorpay order to renew with instead.
+func (srs *SubscriptionRenewalService) autoRenewDueSubscriptions(now time.Time) error {
+	renewBefore := now.Add(time.Duration(srs.adminConfigService.GetSubscriptionRenewalLeadHours()) * time.Hour)
+	retryBefore := now.Add(-time.Duration(srs.adminConfigService.GetSubscriptionRenewalRetryHours()) * time.Hour)
+
+	subscriptions, err := srs.subscriptionRepo.GetDueForRenewal(renewBefore, retryBefore)
+	if err != nil {
+		return fmt.Errorf("failed to get subscriptions due for renewal: %v", err)
+	}
+
+	renewedCount := 0
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+
+		claimed, err := srs.subscriptionRepo.ClaimRenewal(subscription.ID, retryBefore)
+		if err != nil {
+			logrus.Errorf("Failed to claim renewal of subscription %d: %v", subscription.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := srs.renewFromWallet(subscription); err != nil {
+			srs.handleRenewalFailure(subscription, err.Error())
+			continue
+		}
+		renewedCount++
+	}
+
+	if renewedCount > 0 {
+		logrus.Infof("Auto-renewed %d subscriptions", renewedCount)
+	}
+	return nil
+}
+
+// handleRenewalFailure records a failed auto-renewal and offers the user a Razorpay order to pay instead
+func (srs *SubscriptionRenewalService) handleRenewalFailure(subscription *models.UserSubscription, failure string) {
+	logrus.Warnf("Auto-renewal of subscription %d failed: %s", subscription.ID, failure)
+
+	// Reuse the order from an earlier attempt while it can still be paid
+	var payment *models.Payment
+	if subscription.RenewalPaymentID != nil {
+		if existing, err := srs.paymentRepo.GetByID(*subscription.RenewalPaymentID); err == nil && existing.Status == models.PaymentStatusPending {
+			payment = existing
+		}
+	}
+	if payment == nil {
+		plan, pricing, err := srs.renewalPricing(subscription)
+		if err == nil {
+			payment, _, err = srs.createRenewalOrder(subscription, plan, pricing)
+		}
+		if err != nil {
+			logrus.Errorf("Failed to create renewal order for subscription %d: %v", subscription.ID, err)
+		}
+	}
+
+	var renewalPaymentID *uint
+	if payment != nil {
+		renewalPaymentID = &payment.ID
+	}
+	if err := srs.subscriptionRepo.SetRenewalFailure(subscription.ID, failure, renewalPaymentID); err != nil {
+		logrus.Errorf("Failed to record renewal failure of subscription %d: %v", subscription.ID, err)
+	}
+
+	go srs.notificationService.SendSubscriptionRenewalFailedNotification(&subscription.User, subscription, failure, payment)
+}
+
+// lapseEndedSubscriptions handles active subscriptions whose term has ended without a renewal.
+// Auto-renewing ones keep access for the grace period; the rest expire.
+func (srs *SubscriptionRenewalService) lapseEndedSubscriptions(now time.Time) error {
+	subscriptions, err := srs.subscriptionRepo.GetExpiredSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to get ended subscriptions: %v", err)
+	}
+
+	graceDays := srs.adminConfigService.GetSubscriptionGraceDays()
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+
+		if subscription.AutoRenew && graceDays > 0 {
+			graceEndsAt := subscription.EndDate.AddDate(0, 0, graceDays)
+			if graceEndsAt.After(now) {
+				updated, err := srs.subscriptionRepo.StartGrace(subscription, graceEndsAt)
+				if err != nil {
+					logrus.Errorf("Failed to start grace period of subscription %d: %v", subscription.ID, err)
+					continue
+				}
+				if updated {
+					subscription.GraceEndsAt = &graceEndsAt
+					srs.subscriptionCache.Invalidate(subscription.UserID)
+					go srs.notificationService.SendSubscriptionGracePeriodNotification(&subscription.User, subscription)
+				}
+				continue
+			}
+		}
+
+		srs.expire(subscription, models.SubscriptionStatusActive)
+	}
+
+	return nil
+}
+
+// expireGraceEnded expires subscriptions whose grace period is over
+func (srs *SubscriptionRenewalService) expireGraceEnded(now time.Time) error {
+	subscriptions, err := srs.subscriptionRepo.GetGraceEnded(now)
+	if err != nil {
+		return fmt.Errorf("failed to get subscriptions past their grace period: %v", err)
+	}
+
+	for i := range subscriptions {
+		srs.expire(&subscriptions[i], models.SubscriptionStatusGrace)
+	}
+
+	return nil
+}
+
+// expire expires a subscription and tells the user unless their access had already lapsed
+func (srs *SubscriptionRenewalService) expire(subscription *models.UserSubscription, fromStatus string) {
+	hadAccess := subscription.User.SubscriptionID != nil && *subscription.User.SubscriptionID == subscription.ID
+
+	expired, err := srs.subscriptionRepo.Expire(subscription, fromStatus)
+	if err != nil {
+		logrus.Errorf("Failed to expire subscription %d: %v", subscription.ID, err)
+		return
+	}
+	if !expired {
+		return
+	}
+
+	srs.subscriptionCache.Invalidate(subscription.UserID)
+	if hadAccess {
+		go srs.notificationService.SendSubscriptionExpiredNotification(&subscription.User)
+	}
+}
+
+// renewFromWallet pays for the next term of a subscription from the wallet and starts it
+func (srs *SubscriptionRenewalService) renewFromWallet(subscription *models.UserSubscription) (*models.UserSubscription, error) {
+	plan, pricing, err := srs.renewalPricing(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	walletService := NewUnifiedWalletService()
+	walletPayment, err := walletService.DeductFromWalletForSubscription(subscription.UserID, pricing.Price, plan.ID, fmt.Sprintf("Subscription renewal: %s", plan.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	next := srs.nextTerm(subscription, subscription.UserID, plan, pricing)
+	next.AutoRenew = subscription.AutoRenew
+	next.PaymentMethod = models.PaymentMethodWallet
+	next.PaymentID = walletPayment.PaymentReference
+	next.Amount = pricing.Price
+
+	started, err := srs.subscriptionRepo.StartTerm(next, subscription, models.SubscriptionStatusRenewed)
+	if err == nil && !started {
+		err = errors.New("subscription was renewed or changed at the same time")
+	}
+	if err != nil {
+		// Give the money back when the new term could not be started
+		if _, refundErr := walletService.CreditWalletForRefund(walletPayment, walletPayment.Amount, "Subscription renewal failed"); refundErr != nil {
+			logrus.Errorf("Failed to refund wallet payment %d after renewal error: %v", walletPayment.ID, refundErr)
+		}
+		return nil, err
+	}
+
+	srs.subscriptionCache.Invalidate(subscription.UserID)
+	srs.notifyRenewed(next)
+
+	return next, nil
+}
+
+// renewalPricing gets the plan of a subscription and the pricing option it renews with
+func (srs *SubscriptionRenewalService) renewalPricing(subscription *models.UserSubscription) (*models.SubscriptionPlan, *models.PricingOption, error) {
+	plan, err := NewSubscriptionPlanService().GetPlanByID(subscription.PlanID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get subscription plan: %v", err)
+	}
+	if !plan.IsActive {
+		return nil, nil, errors.New("subscription plan is no longer available")
+	}
+
+	if pricing := findPricingOption(plan, subscription.DurationType); pricing != nil {
+		return plan, pricing, nil
+	}
+
+	// Subscriptions bought before the duration type was stored renew with the option of the same length
+	days := int(math.Round(subscription.EndDate.Sub(subscription.StartDate).Hours() / 24))
+	for i := range plan.Pricing {
+		if plan.Pricing[i].DurationDays == days {
+			return plan, &plan.Pricing[i], nil
+		}
+	}
+
+	return nil, nil, errors.New("subscription plan no longer offers this duration")
+}
+
+// nextTerm builds the term that follows base, or starts now when there is nothing to follow
+func (srs *SubscriptionRenewalService) nextTerm(base *models.UserSubscription, userID uint, plan *models.SubscriptionPlan, pricing *models.PricingOption) *models.UserSubscription {
+	startDate := time.Now()
+	if base != nil && base.EndDate.After(startDate) {
+		startDate = base.EndDate
+	}
+
+	return &models.UserSubscription{
+		UserID:       userID,
+		PlanID:       plan.ID,
+		StartDate:    startDate,
+		EndDate:      startDate.AddDate(0, 0, pricing.DurationDays),
+		Status:       models.SubscriptionStatusActive,
+		DurationType: pricing.DurationType,
+	}
+}
+
+// createRenewalOrder creates the Razorpay order for renewing a subscription
+func (srs *SubscriptionRenewalService) createRenewalOrder(subscription *models.UserSubscription, plan *models.SubscriptionPlan, pricing *models.PricingOption) (*models.Payment, map[string]interface{}, error) {
+	payment, order, err := NewPaymentService().CreateGatewayOrder(&models.CreatePaymentRequest{
+		UserID:            subscription.UserID,
+		Amount:            pricing.Price,
+		Currency:          "INR",
+		Type:              models.PaymentTypeSubscription,
+		Method:            models.PaymentMethodOnline,
+		RelatedEntityType: "subscription",
+		RelatedEntityID:   plan.ID,
+		Description:       fmt.Sprintf("Subscription renewal: %s", plan.Name),
+		Notes:             fmt.Sprintf("Renewal of subscription %d (Duration: %d days)", subscription.ID, pricing.DurationDays),
+		Metadata: &models.JSONMap{
+			"duration_type": pricing.DurationType,
+			"duration_days": pricing.DurationDays,
+			"renewal_of":    subscription.ID,
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create renewal order: %v", err)
+	}
+	return payment, order, nil
+}
+
+// notifyRenewed sends the renewal notification to the subscription's user
+func (srs *SubscriptionRenewalService) notifyRenewed(subscription *models.UserSubscription) {
+	go func() {
+		user := &models.User{}
+		if err := repositories.NewUserRepository().FindByID(user, subscription.UserID); err != nil {
+			return
+		}
+		srs.notificationService.SendSubscriptionRenewedNotification(user, subscription)
+	}()
+}
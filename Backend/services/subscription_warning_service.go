@@ -1,13 +1,21 @@
 package services
 
 import (
+	"math"
 	"time"
+	"treesindia/models"
 	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
 )
 
+// subscriptionWarningDays are the days before expiry a warning is sent, each once per subscription
+var subscriptionWarningDays = []int{7, 1}
+
 // SubscriptionWarningService handles subscription warning notifications
 type SubscriptionWarningService struct {
 	subscriptionRepo    *repositories.UserSubscriptionRepository
+	warningRepo         *repositories.SubscriptionWarningRepository
 	notificationService *NotificationService
 }
 
@@ -15,57 +23,66 @@ type SubscriptionWarningService struct {
 func NewSubscriptionWarningService() *SubscriptionWarningService {
 	return &SubscriptionWarningService{
 		subscriptionRepo:    repositories.NewUserSubscriptionRepository(),
+		warningRepo:         repositories.NewSubscriptionWarningRepository(),
 		notificationService: NewNotificationService(),
 	}
 }
 
 // CheckAndSendExpiryWarnings checks for expiring subscriptions and sends warnings
 func (sws *SubscriptionWarningService) CheckAndSendExpiryWarnings() error {
-	// Check subscriptions expiring in 7 days
-	sevenDaysFromNow := time.Now().AddDate(0, 0, 7)
-	subscriptions7Days, err := sws.subscriptionRepo.GetExpiringSubscriptions(sevenDaysFromNow)
-	if err != nil {
-		return err
-	}
-
-	for _, subscription := range subscriptions7Days {
-		// Check if we already sent a warning for this user
-		if !sws.hasWarningBeenSent(subscription.UserID, 7) {
-					sws.notificationService.SendSubscriptionExpiryWarning(&subscription.User, 7)
-		sws.markWarningAsSent(subscription.UserID, 7)
+	now := time.Now()
+	for _, days := range subscriptionWarningDays {
+		subscriptions, err := sws.subscriptionRepo.GetExpiringSubscriptions(now.AddDate(0, 0, days))
+		if err != nil {
+			return err
 		}
-	}
 
-	// Check subscriptions expiring in 1 day
-	oneDayFromNow := time.Now().AddDate(0, 0, 1)
-	subscriptions1Day, err := sws.subscriptionRepo.GetExpiringSubscriptions(oneDayFromNow)
-	if err != nil {
-		return err
-	}
+		for i := range subscriptions {
+			subscription := &subscriptions[i]
+			// Auto-renewing subscriptions are told about the renewal instead
+			if subscription.AutoRenew {
+				continue
+			}
+			if sws.hasWarningBeenSent(subscription.ID, days) {
+				continue
+			}
+
+			// Record the warning first so a failed send is not repeated every day
+			if err := sws.markWarningAsSent(subscription, days); err != nil {
+				logrus.Errorf("Failed to record %d-day expiry warning for subscription %d: %v", days, subscription.ID, err)
+				continue
+			}
 
-	for _, subscription := range subscriptions1Day {
-		if !sws.hasWarningBeenSent(subscription.UserID, 1) {
-			sws.notificationService.SendSubscriptionExpiryWarning(&subscription.User, 1)
-			sws.markWarningAsSent(subscription.UserID, 1)
+			daysLeft := int(math.Ceil(subscription.EndDate.Sub(now).Hours() / 24))
+			if err := sws.notificationService.SendSubscriptionExpiryWarning(&subscription.User, subscription, daysLeft); err != nil {
+				logrus.Errorf("Failed to send expiry warning for subscription %d: %v", subscription.ID, err)
+			}
 		}
 	}
 
 	return nil
 }
 
-// hasWarningBeenSent checks if a warning was already sent for a user
-func (sws *SubscriptionWarningService) hasWarningBeenSent(userID uint, daysLeft int) bool {
-	// TODO: Implement with database check
-	// For now, return false to always send warnings
-	_, _ = userID, daysLeft // Suppress unused parameter warnings
-	return false
+// hasWarningBeenSent checks if the warning for the given days before expiry was already sent for a subscription
+func (sws *SubscriptionWarningService) hasWarningBeenSent(subscriptionID uint, daysLeft int) bool {
+	sent, err := sws.warningRepo.Exists(subscriptionID, daysLeft)
+	if err != nil {
+		// Treat as sent rather than risk warning again on every run
+		logrus.Errorf("Failed to check expiry warnings of subscription %d: %v", subscriptionID, err)
+		return true
+	}
+	return sent
 }
 
-// markWarningAsSent marks a warning as sent for a user
-func (sws *SubscriptionWarningService) markWarningAsSent(userID uint, daysLeft int) {
-	// TODO: Implement with database storage
-	// For now, just log the action
-	_, _ = userID, daysLeft // Suppress unused parameter warnings
+// markWarningAsSent records the warning for the given days before expiry of a subscription
+func (sws *SubscriptionWarningService) markWarningAsSent(subscription *models.UserSubscription, daysLeft int) error {
+	return sws.warningRepo.Create(&models.SubscriptionWarning{
+		UserID:         subscription.UserID,
+		SubscriptionID: subscription.ID,
+		DaysLeft:       daysLeft,
+		WarningDate:    time.Now(),
+		SentVia:        models.WarningTypeInApp,
+	})
 }
 
 // StartWarningJob starts the background warning job
@@ -74,8 +91,9 @@ func (sws *SubscriptionWarningService) StartWarningJob() {
 	go func() {
 		for range ticker.C {
 			if err := sws.CheckAndSendExpiryWarnings(); err != nil {
-				// Handle error silently
+				logrus.Errorf("Subscription warning job failed: %v", err)
 			}
 		}
 	}()
+	logrus.Info("Subscription warning job started")
 }
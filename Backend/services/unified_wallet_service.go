@@ -8,12 +8,14 @@ import (
 	"treesindia/repositories"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // UnifiedWalletService handles all wallet operations using the unified payment system
 type UnifiedWalletService struct {
 	paymentService   *PaymentService
 	userRepo         *repositories.UserRepository
+	journalRepo      *repositories.WalletJournalRepository
 	adminConfigService *AdminConfigService
 }
 
@@ -22,12 +24,13 @@ func NewUnifiedWalletService() *UnifiedWalletService {
 	return &UnifiedWalletService{
 		paymentService:   NewPaymentService(),
 		userRepo:         repositories.NewUserRepository(),
+		journalRepo:      repositories.NewWalletJournalRepository(),
 		adminConfigService: NewAdminConfigService(),
 	}
 }
 
-// RechargeWallet recharges a user's wallet using the unified payment system
-func (s *UnifiedWalletService) RechargeWallet(userID uint, amount float64, paymentMethod string) (*models.Payment, map[string]interface{}, error) {
+// RechargeWallet recharges a user's wallet with an online payment through the active payment gateway
+func (s *UnifiedWalletService) RechargeWallet(userID uint, amount float64) (*models.Payment, map[string]interface{}, error) {
 	// Validate amount
 	minRecharge := s.adminConfigService.GetMinRechargeAmount()
 	maxRecharge := s.adminConfigService.GetMaxRechargeAmount()
@@ -58,7 +61,7 @@ func (s *UnifiedWalletService) RechargeWallet(userID uint, amount float64, payme
 		Amount:            amount,
 		Currency:          "INR",
 		Type:              models.PaymentTypeWalletRecharge,
-		Method:            paymentMethod,
+		Method:            models.PaymentMethodOnline,
 		RelatedEntityType: "wallet",
 		RelatedEntityID:   userID,
 		Description:       fmt.Sprintf("Wallet recharge of ₹%.2f", amount),
@@ -66,7 +69,7 @@ func (s *UnifiedWalletService) RechargeWallet(userID uint, amount float64, payme
 	}
 
 	// Create payment with Razorpay order
-	payment, razorpayOrder, err := s.paymentService.CreateRazorpayOrder(paymentReq)
+	payment, razorpayOrder, err := s.paymentService.CreateGatewayOrder(paymentReq)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create payment: %w", err)
 	}
@@ -74,7 +77,8 @@ func (s *UnifiedWalletService) RechargeWallet(userID uint, amount float64, payme
 	return payment, razorpayOrder, nil
 }
 
-// CompleteWalletRecharge completes a wallet recharge after payment verification
+// CompleteWalletRecharge completes a wallet recharge after payment verification.
+// The wallet is credited when the payment completes, whether here or through the webhook.
 func (s *UnifiedWalletService) CompleteWalletRecharge(paymentID uint, razorpayPaymentID, razorpaySignature string) error {
 	// Verify payment
 	payment, err := s.paymentService.VerifyAndCompletePayment(paymentID, razorpayPaymentID, razorpaySignature)
@@ -86,25 +90,27 @@ func (s *UnifiedWalletService) CompleteWalletRecharge(paymentID uint, razorpayPa
 		return errors.New("payment is not a wallet recharge")
 	}
 
-	// Get user
-	var user models.User
-	if err := s.userRepo.FindByID(&user, payment.UserID); err != nil {
-		return fmt.Errorf("user not found: %w", err)
-	}
-
-	// Calculate new balance
-	newBalance := user.WalletBalance + payment.Amount
+	return nil
+}
 
-	// Update user wallet balance
-	user.WalletBalance = newBalance
-	if err := s.userRepo.Update(&user); err != nil {
-		return fmt.Errorf("failed to update user wallet: %w", err)
+// creditRecharge adds a completed recharge payment to the user's wallet
+func (s *UnifiedWalletService) creditRecharge(payment *models.Payment) error {
+	newBalance, err := s.journalRepo.Post(&repositories.WalletPosting{
+		UserID:         payment.UserID,
+		Amount:         payment.Amount,
+		CounterAccount: models.WalletAccountGatewayClearing,
+		EntryType:      models.WalletEntryTypeRecharge,
+		Description:    payment.Description,
+		Payment:        payment,
+	}, nil)
+	if err != nil {
+		return walletPostingError(err)
 	}
 
-	// Update payment with balance after transaction
-	payment.BalanceAfter = &newBalance
-	if err := s.paymentService.UpdatePayment(payment); err != nil {
-		return fmt.Errorf("failed to update payment: %w", err)
+	// Get user for notifications
+	var user models.User
+	if err := s.userRepo.FindByID(&user, payment.UserID); err != nil {
+		return fmt.Errorf("user not found: %w", err)
 	}
 
 	// Send notifications for successful wallet recharge
@@ -114,7 +120,7 @@ func (s *UnifiedWalletService) CompleteWalletRecharge(paymentID uint, razorpayPa
 	return nil
 }
 
-// RefreshWalletRechargeOrder refreshes a pending wallet recharge order with a new Razorpay order
+// RefreshWalletRechargeOrder refreshes a pending wallet recharge order with a new payment gateway order
 func (s *UnifiedWalletService) RefreshWalletRechargeOrder(paymentID uint, userID uint) (*models.Payment, map[string]interface{}, error) {
 	// Get the existing payment
 	payment, err := s.paymentService.GetPaymentByID(paymentID)
@@ -137,41 +143,18 @@ func (s *UnifiedWalletService) RefreshWalletRechargeOrder(paymentID uint, userID
 		return nil, nil, fmt.Errorf("payment is not pending (status: %s)", payment.Status)
 	}
 
-	// Create a new Razorpay order
-	razorpayOrder, err := s.paymentService.razorpayService.CreateOrder(payment.Amount, payment.PaymentReference, "Wallet recharge")
+	// Create a new order at the active payment gateway
+	checkout, err := s.paymentService.RefreshGatewayOrder(payment)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create new razorpay order: %w", err)
-	}
-
-	// Update payment with new Razorpay order ID
-	orderID := razorpayOrder["id"].(string)
-	payment.RazorpayOrderID = &orderID
-	if err := s.paymentService.UpdatePayment(payment); err != nil {
-		return nil, nil, fmt.Errorf("failed to update payment with new order ID: %w", err)
+		return nil, nil, fmt.Errorf("failed to create new payment order: %w", err)
 	}
 
-
-	return payment, razorpayOrder, nil
+	return payment, checkout, nil
 }
 
 // DeductFromWallet deducts amount from user's wallet for service payments
 func (s *UnifiedWalletService) DeductFromWallet(userID uint, amount float64, serviceID uint, description string) (*models.Payment, error) {
-	// Get user
-	var user models.User
-	if err := s.userRepo.FindByID(&user, userID); err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
-	}
-
-	// Check if user has sufficient balance
-	if user.WalletBalance < amount {
-		return nil, fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", amount, user.WalletBalance)
-	}
-
-	// Calculate new balance
-	newBalance := user.WalletBalance - amount
-
-	// Create payment record for wallet debit
-	paymentReq := &models.CreatePaymentRequest{
+	payment, newBalance, err := s.debitWallet(s.journalRepo, &models.CreatePaymentRequest{
 		UserID:            userID,
 		Amount:            amount,
 		Currency:          "INR",
@@ -181,28 +164,9 @@ func (s *UnifiedWalletService) DeductFromWallet(userID uint, amount float64, ser
 		RelatedEntityID:   serviceID,
 		Description:       description,
 		Notes:             "Service payment from wallet",
-	}
-
-	// Create payment record
-	payment, err := s.paymentService.CreatePayment(paymentReq)
+	}, models.WalletEntryTypeServicePayment, models.WalletAccountServiceRevenue)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment record: %w", err)
-	}
-
-	// Update user wallet balance
-	user.WalletBalance = newBalance
-	if err := s.userRepo.Update(&user); err != nil {
-		return nil, fmt.Errorf("failed to update user wallet: %w", err)
-	}
-
-	// Update payment status to completed
-	now := time.Now()
-	payment.Status = models.PaymentStatusCompleted
-	payment.CompletedAt = &now
-	payment.BalanceAfter = &newBalance
-
-	if err := s.paymentService.UpdatePayment(payment); err != nil {
-		return nil, fmt.Errorf("failed to update payment: %w", err)
+		return nil, err
 	}
 
 	logrus.Infof("Wallet debit for user %d: ₹%.2f, new balance: ₹%.2f", userID, amount, newBalance)
@@ -211,22 +175,19 @@ func (s *UnifiedWalletService) DeductFromWallet(userID uint, amount float64, ser
 
 // DeductFromWalletForBooking deducts amount from user's wallet for booking payments
 func (s *UnifiedWalletService) DeductFromWalletForBooking(userID uint, amount float64, bookingID uint, description string) (*models.Payment, error) {
-	// Get user
-	var user models.User
-	if err := s.userRepo.FindByID(&user, userID); err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
-	}
-
-	// Check if user has sufficient balance
-	if user.WalletBalance < amount {
-		return nil, fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", amount, user.WalletBalance)
+	payment, err := s.debitWalletForBooking(s.journalRepo, userID, amount, bookingID, description)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate new balance
-	newBalance := user.WalletBalance - amount
+	NewBookingActivityService().RecordPayment(bookingID, models.BookingActivityPaymentReceived, payment, models.UserActor(userID), description)
+	return payment, nil
+}
 
-	// Create payment record for wallet debit
-	paymentReq := &models.CreatePaymentRequest{
+// debitWalletForBooking debits a booking payment through journalRepo, which may work inside the
+// transaction creating the booking
+func (s *UnifiedWalletService) debitWalletForBooking(journalRepo *repositories.WalletJournalRepository, userID uint, amount float64, bookingID uint, description string) (*models.Payment, error) {
+	payment, newBalance, err := s.debitWallet(journalRepo, &models.CreatePaymentRequest{
 		UserID:            userID,
 		Amount:            amount,
 		Currency:          "INR",
@@ -236,31 +197,113 @@ func (s *UnifiedWalletService) DeductFromWalletForBooking(userID uint, amount fl
 		RelatedEntityID:   bookingID,
 		Description:       description,
 		Notes:             "Booking payment from wallet",
+	}, models.WalletEntryTypeBookingPayment, models.WalletAccountBookingRevenue)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create payment record
-	payment, err := s.paymentService.CreatePayment(paymentReq)
+	logrus.Infof("Wallet debit for booking %d, user %d: ₹%.2f, new balance: ₹%.2f", bookingID, userID, amount, newBalance)
+	return payment, nil
+}
+
+// DeductFromWalletForSubscription deducts a subscription price from user's wallet
+func (s *UnifiedWalletService) DeductFromWalletForSubscription(userID uint, amount float64, planID uint, description string) (*models.Payment, error) {
+	payment, newBalance, err := s.debitWallet(s.journalRepo, &models.CreatePaymentRequest{
+		UserID:            userID,
+		Amount:            amount,
+		Currency:          "INR",
+		Type:              models.PaymentTypeWalletDebit,
+		Method:            "wallet",
+		RelatedEntityType: "subscription",
+		RelatedEntityID:   planID,
+		Description:       description,
+		Notes:             "Subscription payment from wallet",
+	}, models.WalletEntryTypeSubscriptionPayment, models.WalletAccountSubscriptionRevenue)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment record: %w", err)
+		return nil, err
 	}
 
-	// Update user wallet balance
-	user.WalletBalance = newBalance
-	if err := s.userRepo.Update(&user); err != nil {
-		return nil, fmt.Errorf("failed to update user wallet: %w", err)
+	logrus.Infof("Wallet debit for subscription plan %d, user %d: ₹%.2f, new balance: ₹%.2f", planID, userID, amount, newBalance)
+	return payment, nil
+}
+
+// debitWallet creates a completed wallet debit payment and posts it to the journal against the
+// given account. The balance is checked under a row lock so concurrent debits cannot overspend.
+func (s *UnifiedWalletService) debitWallet(journalRepo *repositories.WalletJournalRepository, req *models.CreatePaymentRequest, entryType models.WalletEntryType, counterAccount models.WalletAccount) (*models.Payment, float64, error) {
+	if req.Amount <= 0 {
+		return nil, 0, errors.New("amount must be greater than zero")
 	}
 
-	// Update payment status to completed
+	payment := s.paymentService.newPayment(req)
 	now := time.Now()
 	payment.Status = models.PaymentStatusCompleted
 	payment.CompletedAt = &now
-	payment.BalanceAfter = &newBalance
 
-	if err := s.paymentService.UpdatePayment(payment); err != nil {
-		return nil, fmt.Errorf("failed to update payment: %w", err)
+	newBalance, err := journalRepo.Post(&repositories.WalletPosting{
+		UserID:         req.UserID,
+		Amount:         -req.Amount,
+		CounterAccount: counterAccount,
+		EntryType:      entryType,
+		Description:    req.Description,
+		Payment:        payment,
+	}, func(current, next float64) error {
+		if next < 0 {
+			return fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", req.Amount, current)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, walletPostingError(err)
 	}
 
-	logrus.Infof("Wallet debit for booking %d, user %d: ₹%.2f, new balance: ₹%.2f", bookingID, userID, amount, newBalance)
+	return payment, newBalance, nil
+}
+
+// CreditWalletForRefund credits a refund for the given payment back to the user's wallet
+func (s *UnifiedWalletService) CreditWalletForRefund(originalPayment *models.Payment, amount float64, reason string) (*models.Payment, error) {
+	return s.creditWalletForRefund(s.journalRepo, originalPayment, amount, reason)
+}
+
+// creditWalletForRefund credits a refund to the user's wallet through journalRepo, which may work
+// inside the transaction updating the original payment
+func (s *UnifiedWalletService) creditWalletForRefund(journalRepo *repositories.WalletJournalRepository, originalPayment *models.Payment, amount float64, reason string) (*models.Payment, error) {
+	metadata := models.JSONMap{
+		"original_payment_id":        originalPayment.ID,
+		"original_payment_reference": originalPayment.PaymentReference,
+		"original_payment_method":    originalPayment.Method,
+	}
+
+	// Create payment record for the refund
+	payment := s.paymentService.newPayment(&models.CreatePaymentRequest{
+		UserID:            originalPayment.UserID,
+		Amount:            amount,
+		Currency:          "INR",
+		Type:              models.PaymentTypeRefund,
+		Method:            "wallet",
+		RelatedEntityType: originalPayment.RelatedEntityType,
+		RelatedEntityID:   originalPayment.RelatedEntityID,
+		Description:       fmt.Sprintf("Refund for %s", originalPayment.PaymentReference),
+		Notes:             reason,
+		Metadata:          &metadata,
+	})
+	now := time.Now()
+	payment.Status = models.PaymentStatusCompleted
+	payment.CompletedAt = &now
+
+	// Refunds are not subject to the wallet balance limit - the money already belongs to the user
+	newBalance, err := journalRepo.Post(&repositories.WalletPosting{
+		UserID:         originalPayment.UserID,
+		Amount:         amount,
+		CounterAccount: models.WalletAccountRefunds,
+		EntryType:      models.WalletEntryTypeRefund,
+		Description:    payment.Description,
+		Payment:        payment,
+	}, nil)
+	if err != nil {
+		return nil, walletPostingError(err)
+	}
+
+	logrus.Infof("Wallet refund for payment %d, user %d: ₹%.2f, new balance: ₹%.2f", originalPayment.ID, originalPayment.UserID, amount, newBalance)
 	return payment, nil
 }
 
@@ -337,27 +380,53 @@ func (s *UnifiedWalletService) GetUserWalletSummary(userID uint) (map[string]int
 	return summary, nil
 }
 
-// AdminAdjustWallet allows admin to adjust user's wallet balance
-func (s *UnifiedWalletService) AdminAdjustWallet(userID uint, amount float64, reason string, adminID uint) (*models.Payment, error) {
-	// Get user
-	var user models.User
-	if err := s.userRepo.FindByID(&user, userID); err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+// CreditPromotion credits a platform-funded reward such as coupon cashback or a referral reward to the user's wallet
+func (s *UnifiedWalletService) CreditPromotion(userID uint, amount float64, entryType models.WalletEntryType, relatedEntityType string, relatedEntityID uint, description string) (*models.Payment, error) {
+	if amount <= 0 {
+		return nil, errors.New("promotional credit must be positive")
 	}
 
-	// Check wallet limit
-	maxWalletBalance := s.adminConfigService.GetMaxWalletBalance()
-	newBalance := user.WalletBalance + amount
-	if maxWalletBalance > 0 && newBalance > maxWalletBalance {
-		return nil, fmt.Errorf("wallet balance cannot exceed ₹%.2f", maxWalletBalance)
+	// Create payment record for the credit
+	payment := s.paymentService.newPayment(&models.CreatePaymentRequest{
+		UserID:            userID,
+		Amount:            amount,
+		Currency:          "INR",
+		Type:              models.PaymentTypeWalletRecharge,
+		Method:            "promotion",
+		RelatedEntityType: relatedEntityType,
+		RelatedEntityID:   relatedEntityID,
+		Description:       description,
+		Notes:             fmt.Sprintf("Promotional credit (%s)", entryType),
+	})
+	now := time.Now()
+	payment.Status = models.PaymentStatusCompleted
+	payment.CompletedAt = &now
+
+	// Promotional credits are not subject to the wallet balance limit, like refunds
+	newBalance, err := s.journalRepo.Post(&repositories.WalletPosting{
+		UserID:         userID,
+		Amount:         amount,
+		CounterAccount: models.WalletAccountPromotions,
+		EntryType:      entryType,
+		Description:    description,
+		Payment:        payment,
+	}, nil)
+	if err != nil {
+		return nil, walletPostingError(err)
 	}
 
-	if newBalance < 0 {
-		return nil, errors.New("wallet balance cannot be negative")
+	logrus.Infof("Promotional credit (%s) for user %d: ₹%.2f, new balance: ₹%.2f", entryType, userID, amount, newBalance)
+	return payment, nil
+}
+
+// AdminAdjustWallet allows admin to adjust user's wallet balance
+func (s *UnifiedWalletService) AdminAdjustWallet(userID uint, amount float64, reason string, adminID uint) (*models.Payment, error) {
+	if amount == 0 {
+		return nil, errors.New("adjustment amount cannot be zero")
 	}
 
 	// Create payment record for admin adjustment
-	paymentReq := &models.CreatePaymentRequest{
+	payment := s.paymentService.newPayment(&models.CreatePaymentRequest{
 		UserID:            userID,
 		Amount:            amount,
 		Currency:          "INR",
@@ -367,34 +436,51 @@ func (s *UnifiedWalletService) AdminAdjustWallet(userID uint, amount float64, re
 		RelatedEntityID:   userID,
 		Description:       fmt.Sprintf("Admin adjustment: %s", reason),
 		Notes:             fmt.Sprintf("Admin adjustment by admin ID %d", adminID),
-	}
-
-	// Create payment record
-	payment, err := s.paymentService.CreatePayment(paymentReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create payment record: %w", err)
-	}
-
-	// Update user wallet balance
-	user.WalletBalance = newBalance
-	if err := s.userRepo.Update(&user); err != nil {
-		return nil, fmt.Errorf("failed to update user wallet: %w", err)
-	}
-
-	// Update payment status to completed
+	})
 	now := time.Now()
 	payment.Status = models.PaymentStatusCompleted
 	payment.CompletedAt = &now
-	payment.BalanceAfter = &newBalance
 
-	if err := s.paymentService.UpdatePayment(payment); err != nil {
-		return nil, fmt.Errorf("failed to update payment: %w", err)
+	maxWalletBalance := s.adminConfigService.GetMaxWalletBalance()
+	_, err := s.journalRepo.Post(&repositories.WalletPosting{
+		UserID:         userID,
+		Amount:         amount,
+		CounterAccount: models.WalletAccountAdminAdjustments,
+		EntryType:      models.WalletEntryTypeAdminAdjustment,
+		Description:    payment.Description,
+		CreatedBy:      &adminID,
+		Payment:        payment,
+	}, func(current, next float64) error {
+		// Check wallet limit
+		if amount > 0 && maxWalletBalance > 0 && next > maxWalletBalance {
+			return fmt.Errorf("wallet balance cannot exceed ₹%.2f", maxWalletBalance)
+		}
+		if next < 0 {
+			return errors.New("wallet balance cannot be negative")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, walletPostingError(err)
 	}
 
 	logrus.Infof("Admin wallet adjustment for user %d by admin %d: ₹%.2f - %s", userID, adminID, amount, reason)
 	return payment, nil
 }
 
+// GetWalletJournal gets the journal entries of a user's wallet
+func (s *UnifiedWalletService) GetWalletJournal(userID uint, page, limit int) ([]models.WalletJournalEntry, *repositories.Pagination, error) {
+	return s.journalRepo.GetUserEntries(userID, page, limit)
+}
+
+// walletPostingError wraps an error returned while posting to the wallet journal
+func walletPostingError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	return err
+}
+
 // GetTransactionByReference gets a wallet transaction by reference ID
 func (s *UnifiedWalletService) GetTransactionByReference(referenceID string) (*models.Payment, error) {
 	return s.paymentService.GetPaymentByReference(referenceID)
@@ -9,6 +9,7 @@ import (
 	"treesindia/repositories"
 	"treesindia/utils"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -18,6 +19,7 @@ type UserSubscriptionService struct {
 	userRepo           *repositories.UserRepository
 	subscriptionCache  *utils.SubscriptionCache
 	notificationService *NotificationService
+	couponService      *CouponService
 }
 
 // NewUserSubscriptionService creates a new user subscription service
@@ -27,6 +29,7 @@ func NewUserSubscriptionService() *UserSubscriptionService {
 		userRepo:           repositories.NewUserRepository(),
 		subscriptionCache:  utils.NewSubscriptionCache(),
 		notificationService: NewNotificationService(),
+		couponService:      NewCouponService(),
 	}
 }
 
@@ -85,7 +88,7 @@ func (uss *UserSubscriptionService) CheckAndUpdateSubscriptionStatus(userID uint
 }
 
 // CreateSubscriptionPaymentOrder creates a payment order for subscription purchase
-func (uss *UserSubscriptionService) CreateSubscriptionPaymentOrder(userID uint, planID uint, durationType string) (*models.Payment, map[string]interface{}, error) {
+func (uss *UserSubscriptionService) CreateSubscriptionPaymentOrder(userID uint, planID uint, durationType string, couponCode string) (*models.Payment, map[string]interface{}, error) {
 	// Check current subscription status before purchasing
 	user, err := uss.CheckAndUpdateSubscriptionStatus(userID)
 	if err != nil {
@@ -121,14 +124,26 @@ func (uss *UserSubscriptionService) CreateSubscriptionPaymentOrder(userID uint,
 		return nil, nil, errors.New("invalid duration type for this plan")
 	}
 	
+	// Reserve the coupon until the order is paid
+	amount := selectedPricing.Price
+	var redemption *models.CouponRedemption
+	if couponCode != "" {
+		var application *models.CouponApplication
+		application, redemption, err = uss.couponService.ReserveForOrder(userID, couponCode, &models.CouponTarget{Scope: models.CouponScopeSubscription, Amount: amount}, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		amount = application.PayableAmount
+	}
+	
 	// Create payment request
 	paymentService := NewPaymentService()
 	paymentReq := &models.CreatePaymentRequest{
 		UserID:           userID,
-		Amount:           selectedPricing.Price,
+		Amount:           amount,
 		Currency:         "INR",
 		Type:             models.PaymentTypeSubscription,
-		Method:           models.PaymentMethodRazorpay,
+		Method:           models.PaymentMethodOnline,
 		RelatedEntityType: "subscription",
 		RelatedEntityID:   planID,
 		Description:      fmt.Sprintf("Subscription purchase: %s", plan.Name),
@@ -140,10 +155,18 @@ func (uss *UserSubscriptionService) CreateSubscriptionPaymentOrder(userID uint,
 	}
 	
 	// Create Razorpay order
-	payment, razorpayOrder, err := paymentService.CreateRazorpayOrder(paymentReq)
+	payment, razorpayOrder, err := paymentService.CreateGatewayOrder(paymentReq)
 	if err != nil {
+		if redemption != nil {
+			uss.couponService.Release(redemption)
+		}
 		return nil, nil, fmt.Errorf("failed to create payment order: %v", err)
 	}
+	if redemption != nil {
+		if err := uss.couponService.AttachPayment(redemption, payment.ID); err != nil {
+			logrus.Errorf("Failed to attach payment %d to coupon redemption %d: %v", payment.ID, redemption.ID, err)
+		}
+	}
 	
 	return payment, razorpayOrder, nil
 }
@@ -161,6 +184,27 @@ func (uss *UserSubscriptionService) CompleteSubscriptionPurchase(userID uint, pa
 	if payment.Type != models.PaymentTypeSubscription {
 		return nil, errors.New("invalid payment type for subscription")
 	}
+	if payment.UserID != userID {
+		return nil, errors.New("payment does not belong to user")
+	}
+	
+	// Completing the payment activates the subscription; this returns it, or retries if that failed
+	return uss.ActivatePaidSubscription(payment)
+}
+
+// ActivatePaidSubscription creates the subscription a completed payment was made for.
+// It is safe to call more than once for the same payment.
+func (uss *UserSubscriptionService) ActivatePaidSubscription(payment *models.Payment) (*models.UserSubscription, error) {
+	if payment.GatewayPaymentID == nil {
+		return nil, errors.New("payment has no razorpay payment id")
+	}
+	razorpayPaymentID := *payment.GatewayPaymentID
+	userID := payment.UserID
+	
+	// Already activated for this payment
+	if existing, err := uss.subscriptionRepo.GetByPaymentID(razorpayPaymentID); err == nil {
+		return existing, nil
+	}
 	
 	// Get subscription plan
 	planService := NewSubscriptionPlanService()
@@ -169,6 +213,14 @@ func (uss *UserSubscriptionService) CompleteSubscriptionPurchase(userID uint, pa
 		return nil, err
 	}
 	
+	// Renewals and plan changes paid through Razorpay replace the current term
+	if previousID := paymentMetadataUint(payment, "renewal_of"); previousID != 0 {
+		return NewSubscriptionRenewalService().ActivateRenewalPayment(payment, plan, previousID)
+	}
+	if previousID := paymentMetadataUint(payment, "change_from"); previousID != 0 {
+		return uss.activatePlanChange(payment, plan, previousID)
+	}
+	
 	// Check current subscription status
 	user, err := uss.CheckAndUpdateSubscriptionStatus(userID)
 	if err != nil {
@@ -227,7 +279,8 @@ func (uss *UserSubscriptionService) CompleteSubscriptionPurchase(userID uint, pa
 		Status:        models.SubscriptionStatusActive,
 		PaymentMethod: models.PaymentMethodRazorpay,
 		PaymentID:     razorpayPaymentID,
-		Amount:        selectedPricing.Price,
+		Amount:        payment.Amount, // Amount paid, after any coupon
+		DurationType:  selectedPricing.DurationType,
 	}
 	
 	// Save subscription and update user in transaction
@@ -262,7 +315,7 @@ func (uss *UserSubscriptionService) CompleteSubscriptionPurchase(userID uint, pa
 }
 
 // PurchaseSubscription purchases a subscription for a user (wallet only)
-func (uss *UserSubscriptionService) PurchaseSubscription(userID uint, planID uint, paymentMethod string, durationType string) (*models.UserSubscription, error) {
+func (uss *UserSubscriptionService) PurchaseSubscription(userID uint, planID uint, paymentMethod string, durationType string, couponCode string) (*models.UserSubscription, error) {
 	// Check current subscription status before purchasing
 	user, err := uss.CheckAndUpdateSubscriptionStatus(userID)
 	if err != nil {
@@ -306,17 +359,29 @@ func (uss *UserSubscriptionService) PurchaseSubscription(userID uint, planID uin
 	
 	// Process payment based on method
 	var paymentID string
+	var walletPayment *models.Payment
+	var redemption *models.CouponRedemption
+	amount := selectedPricing.Price
 	if paymentMethod == models.PaymentMethodWallet {
-		// Check wallet balance
-		if user.WalletBalance < selectedPricing.Price {
-			return nil, errors.New("insufficient wallet balance")
+		// Reserve the coupon while the wallet is debited
+		if couponCode != "" {
+			var application *models.CouponApplication
+			application, redemption, err = uss.couponService.ReserveForOrder(userID, couponCode, &models.CouponTarget{Scope: models.CouponScopeSubscription, Amount: amount}, nil)
+			if err != nil {
+				return nil, err
+			}
+			amount = application.PayableAmount
 		}
-		
-		// Deduct from wallet
-		user.WalletBalance -= selectedPricing.Price
-		if err := uss.userRepo.Update(user); err != nil {
+
+		// Deduct from wallet; the balance is checked while the wallet is locked
+		walletPayment, err = NewUnifiedWalletService().DeductFromWalletForSubscription(userID, amount, planID, fmt.Sprintf("Subscription: %s", plan.Name))
+		if err != nil {
+			if redemption != nil {
+				uss.couponService.Release(redemption)
+			}
 			return nil, err
 		}
+		paymentID = walletPayment.PaymentReference
 	} else if paymentMethod == models.PaymentMethodRazorpay {
 		// For Razorpay, we need to create a payment order first
 		// This method should only be called after payment verification
@@ -334,7 +399,8 @@ func (uss *UserSubscriptionService) PurchaseSubscription(userID uint, planID uin
 		Status:        models.SubscriptionStatusActive,
 		PaymentMethod: paymentMethod,
 		PaymentID:     paymentID,
-		Amount:        selectedPricing.Price,
+		Amount:        amount,
+		DurationType:  selectedPricing.DurationType,
 	}
 	
 	// Save subscription and update user in transaction
@@ -356,9 +422,24 @@ func (uss *UserSubscriptionService) PurchaseSubscription(userID uint, planID uin
 	})
 	
 	if err != nil {
+		// Give the money back when the subscription could not be created
+		if walletPayment != nil {
+			if _, refundErr := NewUnifiedWalletService().CreditWalletForRefund(walletPayment, walletPayment.Amount, "Subscription activation failed"); refundErr != nil {
+				logrus.Errorf("Failed to refund wallet payment %d after subscription error: %v", walletPayment.ID, refundErr)
+			}
+		}
+		if redemption != nil {
+			uss.couponService.Release(redemption)
+		}
 		return nil, err
 	}
 	
+	if redemption != nil {
+		if err := uss.couponService.Confirm(redemption, &walletPayment.ID); err != nil {
+			logrus.Errorf("Failed to confirm coupon for subscription %d: %v", subscription.ID, err)
+		}
+	}
+	
 	// Invalidate cache
 	uss.subscriptionCache.Invalidate(userID)
 	
@@ -451,3 +532,262 @@ func (uss *UserSubscriptionService) GetExpiringSubscriptions(days int) ([]models
 func (uss *UserSubscriptionService) GetAllSubscriptions(page, pageSize int) ([]models.UserSubscription, int64, error) {
 	return uss.subscriptionRepo.GetAll(page, pageSize)
 }
+
+// SetAutoRenew turns auto-renewal of the user's current subscription on or off
+func (uss *UserSubscriptionService) SetAutoRenew(userID uint, enabled bool) (*models.UserSubscription, error) {
+	subscription, err := uss.subscriptionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no active subscription")
+	}
+	
+	if err := uss.subscriptionRepo.SetAutoRenew(subscription.ID, enabled); err != nil {
+		return nil, fmt.Errorf("failed to update auto-renew: %v", err)
+	}
+	subscription.AutoRenew = enabled
+	
+	return subscription, nil
+}
+
+// QuotePlanChange prices moving the user's current subscription to another plan or duration
+func (uss *UserSubscriptionService) QuotePlanChange(userID uint, planID uint, durationType string) (*models.PlanChangeQuote, error) {
+	quote, _, _, err := uss.quotePlanChange(userID, planID, durationType, time.Now())
+	return quote, err
+}
+
+// ChangePlan moves the user's current subscription to another plan or duration. The unused part of
+// the current term is credited; any amount still due is paid from the wallet or through a Razorpay
+// order, in which case the change takes effect when that payment completes.
+func (uss *UserSubscriptionService) ChangePlan(userID uint, planID uint, durationType string, paymentMethod string) (*models.SubscriptionPaymentResult, error) {
+	quote, current, plan, err := uss.quotePlanChange(userID, planID, durationType, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	
+	subscription := &models.UserSubscription{
+		UserID:       userID,
+		PlanID:       plan.ID,
+		StartDate:    quote.StartDate,
+		EndDate:      quote.EndDate,
+		Status:       models.SubscriptionStatusActive,
+		Amount:       quote.Price,
+		DurationType: quote.DurationType,
+		AutoRenew:    current.AutoRenew,
+	}
+	
+	var walletPayment *models.Payment
+	if quote.AmountDue == 0 {
+		// The credit covers the new plan; anything beyond its price already lengthened the term
+		subscription.PaymentMethod = models.PaymentMethodProration
+		subscription.Amount = quote.ProrationCredit
+	} else {
+		switch paymentMethod {
+		case models.PaymentMethodWallet:
+			walletPayment, err = NewUnifiedWalletService().DeductFromWalletForSubscription(userID, quote.AmountDue, plan.ID, fmt.Sprintf("Plan change: %s", plan.Name))
+			if err != nil {
+				return nil, err
+			}
+			subscription.PaymentMethod = models.PaymentMethodWallet
+			subscription.PaymentID = walletPayment.PaymentReference
+		case models.PaymentMethodRazorpay:
+			payment, order, err := NewPaymentService().CreateGatewayOrder(&models.CreatePaymentRequest{
+				UserID:            userID,
+				Amount:            quote.AmountDue,
+				Currency:          "INR",
+				Type:              models.PaymentTypeSubscription,
+				Method:            models.PaymentMethodOnline,
+				RelatedEntityType: "subscription",
+				RelatedEntityID:   plan.ID,
+				Description:       fmt.Sprintf("Plan change: %s", plan.Name),
+				Notes:             fmt.Sprintf("Plan change from subscription %d with ₹%.2f proration credit", current.ID, quote.ProrationCredit),
+				Metadata: &models.JSONMap{
+					"duration_type":    quote.DurationType,
+					"change_from":      current.ID,
+					"proration_credit": quote.ProrationCredit,
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create payment order: %v", err)
+			}
+			return &models.SubscriptionPaymentResult{Quote: quote, Payment: payment, Order: order}, nil
+		default:
+			return nil, errors.New("payment method is required when an amount is due")
+		}
+	}
+	
+	started, err := uss.subscriptionRepo.StartTerm(subscription, current, models.SubscriptionStatusChanged)
+	if err == nil && !started {
+		err = errors.New("subscription was renewed or changed at the same time, please try again")
+	}
+	if err != nil {
+		// Give the money back when the new plan could not be started
+		if walletPayment != nil {
+			if _, refundErr := NewUnifiedWalletService().CreditWalletForRefund(walletPayment, walletPayment.Amount, "Plan change failed"); refundErr != nil {
+				logrus.Errorf("Failed to refund wallet payment %d after plan change error: %v", walletPayment.ID, refundErr)
+			}
+		}
+		return nil, err
+	}
+	
+	uss.subscriptionCache.Invalidate(userID)
+	uss.notifyPlanChanged(subscription, quote.ProrationCredit)
+	
+	return &models.SubscriptionPaymentResult{Subscription: subscription, Quote: quote}, nil
+}
+
+// activatePlanChange starts the new plan a completed Razorpay plan change payment was made for
+func (uss *UserSubscriptionService) activatePlanChange(payment *models.Payment, plan *models.SubscriptionPlan, previousID uint) (*models.UserSubscription, error) {
+	durationType, _ := (*payment.Metadata)["duration_type"].(string)
+	pricing := findPricingOption(plan, durationType)
+	if pricing == nil {
+		return nil, errors.New("invalid duration type for this plan")
+	}
+	credit, _ := (*payment.Metadata)["proration_credit"].(float64)
+	
+	previous, err := uss.subscriptionRepo.GetByID(previousID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed subscription: %v", err)
+	}
+	if previous.UserID != payment.UserID {
+		return nil, errors.New("payment does not belong to subscription owner")
+	}
+	
+	// The credit was priced when the order was created; if the old term ended since, only the payment buys the new one
+	if !containsString([]string{models.SubscriptionStatusActive, models.SubscriptionStatusGrace}, previous.Status) {
+		previous = nil
+	}
+	
+	startDate := time.Now()
+	subscription := &models.UserSubscription{
+		UserID:        payment.UserID,
+		PlanID:        plan.ID,
+		StartDate:     startDate,
+		EndDate:       startDate.AddDate(0, 0, pricing.DurationDays),
+		Status:        models.SubscriptionStatusActive,
+		PaymentMethod: models.PaymentMethodRazorpay,
+		PaymentID:     *payment.GatewayPaymentID,
+		Amount:        roundCurrency(payment.Amount + credit),
+		DurationType:  pricing.DurationType,
+	}
+	if previous != nil {
+		subscription.AutoRenew = previous.AutoRenew
+	} else {
+		subscription.Amount = payment.Amount
+	}
+	
+	started, err := uss.subscriptionRepo.StartTerm(subscription, previous, models.SubscriptionStatusChanged)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, errors.New("subscription was renewed or changed at the same time, please retry")
+	}
+	
+	uss.subscriptionCache.Invalidate(payment.UserID)
+	uss.notifyPlanChanged(subscription, credit)
+	
+	return subscription, nil
+}
+
+// quotePlanChange prices a plan change and returns the current subscription and the new plan with it
+func (uss *UserSubscriptionService) quotePlanChange(userID uint, planID uint, durationType string, now time.Time) (*models.PlanChangeQuote, *models.UserSubscription, *models.SubscriptionPlan, error) {
+	current, err := uss.subscriptionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, nil, nil, errors.New("no active subscription to change")
+	}
+	if current.Status != models.SubscriptionStatusActive {
+		return nil, nil, nil, errors.New("renew your subscription before changing plans")
+	}
+	if current.PlanID == planID && current.DurationType == durationType {
+		return nil, nil, nil, errors.New("subscription is already on this plan")
+	}
+	
+	plan, err := NewSubscriptionPlanService().GetPlanByID(planID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !plan.IsActive {
+		return nil, nil, nil, errors.New("subscription plan is not active")
+	}
+	
+	pricing := findPricingOption(plan, durationType)
+	if pricing == nil {
+		return nil, nil, nil, errors.New("invalid duration type for this plan")
+	}
+	
+	quote := &models.PlanChangeQuote{
+		CurrentSubscriptionID: current.ID,
+		PlanID:                plan.ID,
+		DurationType:          pricing.DurationType,
+		Price:                 pricing.Price,
+		ProrationCredit:       prorationCredit(current, now),
+		StartDate:             now,
+	}
+	
+	if quote.ProrationCredit >= pricing.Price {
+		// Credit beyond the price of the new plan lengthens its term
+		days := pricing.DurationDays
+		if pricing.Price > 0 {
+			days = int(float64(pricing.DurationDays) * quote.ProrationCredit / pricing.Price)
+		}
+		quote.EndDate = now.AddDate(0, 0, days)
+	} else {
+		quote.AmountDue = roundCurrency(pricing.Price - quote.ProrationCredit)
+		quote.EndDate = now.AddDate(0, 0, pricing.DurationDays)
+	}
+	
+	return quote, current, plan, nil
+}
+
+// notifyPlanChanged sends the plan changed notification to the subscription's user
+func (uss *UserSubscriptionService) notifyPlanChanged(subscription *models.UserSubscription, prorationCredit float64) {
+	go func() {
+		user := &models.User{}
+		if err := uss.userRepo.FindByID(user, subscription.UserID); err != nil {
+			return
+		}
+		uss.notificationService.SendSubscriptionPlanChangedNotification(user, subscription, prorationCredit)
+	}()
+}
+
+// prorationCredit is the value of the unused part of a subscription term
+func prorationCredit(subscription *models.UserSubscription, now time.Time) float64 {
+	term := subscription.EndDate.Sub(subscription.StartDate)
+	if term <= 0 {
+		return 0
+	}
+	
+	// A term renewed ahead of time has not started yet and is worth its full amount
+	remaining := subscription.EndDate.Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	if remaining > term {
+		remaining = term
+	}
+	
+	return roundCurrency(subscription.Amount * float64(remaining) / float64(term))
+}
+
+// findPricingOption finds the pricing option of a plan for a duration type
+func findPricingOption(plan *models.SubscriptionPlan, durationType string) *models.PricingOption {
+	for i := range plan.Pricing {
+		if plan.Pricing[i].DurationType == durationType {
+			return &plan.Pricing[i]
+		}
+	}
+	return nil
+}
+
+// paymentMetadataUint reads an ID stored in a payment's metadata
+func paymentMetadataUint(payment *models.Payment, key string) uint {
+	if payment.Metadata == nil {
+		return 0
+	}
+	switch value := (*payment.Metadata)[key].(type) {
+	case float64:
+		return uint(value)
+	case uint:
+		return value
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WalletReconciliationService checks cached wallet balances against the wallet journal
+type WalletReconciliationService struct {
+	journalRepo *repositories.WalletJournalRepository
+}
+
+// NewWalletReconciliationService creates a new wallet reconciliation service
+func NewWalletReconciliationService() *WalletReconciliationService {
+	return &WalletReconciliationService{
+		journalRepo: repositories.NewWalletJournalRepository(),
+	}
+}
+
+// ReconcileBalances flags users whose cached wallet balance disagrees with their journal and
+// resolves flags for users whose balances agree again
+func (wrs *WalletReconciliationService) ReconcileBalances() (*models.WalletReconciliationResult, error) {
+	now := time.Now()
+	result := &models.WalletReconciliationResult{}
+
+	usersChecked, err := wrs.journalRepo.CountUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %v", err)
+	}
+	result.UsersChecked = usersChecked
+
+	mismatches, err := wrs.journalRepo.GetBalanceMismatches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare wallet balances: %v", err)
+	}
+
+	mismatchedUsers := make([]uint, 0, len(mismatches))
+	for _, check := range mismatches {
+		created, err := wrs.journalRepo.FlagDiscrepancy(check, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flag wallet discrepancy for user %d: %v", check.UserID, err)
+		}
+		if created {
+			logrus.Warnf("Wallet balance mismatch for user %d: cached ₹%.2f, journal ₹%.2f", check.UserID, check.CachedBalance, check.JournalBalance)
+		}
+		mismatchedUsers = append(mismatchedUsers, check.UserID)
+	}
+	result.Discrepancies = len(mismatches)
+
+	resolved, err := wrs.journalRepo.ResolveDiscrepancies(mismatchedUsers, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve wallet discrepancies: %v", err)
+	}
+	result.Resolved = int(resolved)
+
+	unbalanced, err := wrs.journalRepo.GetUnbalancedTransactions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal transactions: %v", err)
+	}
+	if len(unbalanced) > 0 {
+		logrus.Errorf("Wallet journal has %d unbalanced transactions: %v", len(unbalanced), unbalanced)
+	}
+	result.UnbalancedTransactions = unbalanced
+
+	return result, nil
+}
+
+// GetDiscrepancies gets flagged wallet balance discrepancies (admin)
+func (wrs *WalletReconciliationService) GetDiscrepancies(filters *models.WalletDiscrepancyFilters) ([]models.WalletBalanceDiscrepancy, *repositories.Pagination, error) {
+	return wrs.journalRepo.GetDiscrepancies(filters)
+}
+
+// GetJournalBalance derives a user's wallet balance from the journal
+func (wrs *WalletReconciliationService) GetJournalBalance(userID uint) (float64, error) {
+	return wrs.journalRepo.GetJournalBalance(userID)
+}
+
+// StartReconciliationJob starts a periodic job that reconciles wallet balances
+func (wrs *WalletReconciliationService) StartReconciliationJob() {
+	ticker := time.NewTicker(6 * time.Hour) // Run every 6 hours
+	go func() {
+		for range ticker.C {
+			result, err := wrs.ReconcileBalances()
+			if err != nil {
+				logrus.Errorf("Wallet reconciliation job failed: %v", err)
+				continue
+			}
+			logrus.Infof("Wallet reconciliation checked %d users: %d discrepancies, %d resolved", result.UsersChecked, result.Discrepancies, result.Resolved)
+		}
+	}()
+	logrus.Info("Wallet reconciliation job started")
+}
@@ -105,22 +105,36 @@ type Hub struct {
 	// Broadcast flat messages to specific room
 	broadcastFlat chan *FlatWSMessage
 
+	// Broker fanning room messages out to the hubs of every replica
+	broker RealtimeBroker
+
 	mu sync.RWMutex
 }
 
+// hubBrokerMessage carries a room message between the hubs of every replica
+type hubBrokerMessage struct {
+	Message *WSMessage     `json:"message,omitempty"`
+	Flat    *FlatWSMessage `json:"flat,omitempty"`
+}
+
 // NewHub creates a new WebSocket hub
-func NewHub() *Hub {
+func NewHub(broker RealtimeBroker) *Hub {
 	return &Hub{
 		rooms:        make(map[uint]map[*Client]bool),
 		register:     make(chan *Client),
 		unregister:   make(chan *Client),
 		broadcast:    make(chan *WSMessage),
 		broadcastFlat: make(chan *FlatWSMessage),
+		broker:       broker,
 	}
 }
 
 // Run starts the hub
 func (h *Hub) Run() {
+	if err := h.broker.Subscribe(RealtimeChannelRooms, h.receive); err != nil {
+		logrus.Errorf("Failed to subscribe the WebSocket hub to the %s broker: %v", h.broker.Name(), err)
+	}
+
 	for {
 		select {
 		case client := <-h.register:
@@ -138,7 +152,7 @@ func (h *Hub) Run() {
 				UserID:    client.UserID,
 				Timestamp: time.Now(),
 			}
-			h.broadcastToRoom(joinMsg)
+			h.publish(&hubBrokerMessage{Message: joinMsg})
 
 			logrus.Infof("Client %s joined room %d", client.ID, client.RoomID)
 
@@ -162,27 +176,61 @@ func (h *Hub) Run() {
 				UserID:    client.UserID,
 				Timestamp: time.Now(),
 			}
-			h.broadcastToRoom(leaveMsg)
+			h.publish(&hubBrokerMessage{Message: leaveMsg})
 
 			logrus.Infof("Client %s left room %d", client.ID, client.RoomID)
 
 		case message := <-h.broadcast:
-			h.broadcastToRoom(message)
+			h.publish(&hubBrokerMessage{Message: message})
 
 		case flatMessage := <-h.broadcastFlat:
-			h.broadcastFlatToRoom(flatMessage)
+			h.publish(&hubBrokerMessage{Flat: flatMessage})
 		}
 	}
 }
 
+// publish sends a room message to the hubs of every replica, or only to this one when the broker is unavailable
+func (h *Hub) publish(message *hubBrokerMessage) {
+	payload, err := json.Marshal(message)
+	if err == nil {
+		err = h.broker.Publish(RealtimeChannelRooms, payload)
+	}
+	if err != nil {
+		logrus.Errorf("Failed to publish room message through the broker: %v", err)
+		h.deliver(message)
+	}
+}
+
+// receive delivers a room message published on the broker
+func (h *Hub) receive(payload []byte) {
+	var message hubBrokerMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		logrus.Errorf("Invalid room message from the broker: %v", err)
+		return
+	}
+	h.deliver(&message)
+}
+
+// deliver sends a room message to the clients of the room connected to this replica
+func (h *Hub) deliver(message *hubBrokerMessage) {
+	if message.Message != nil {
+		h.broadcastToRoom(message.Message)
+	}
+	if message.Flat != nil {
+		h.broadcastFlatToRoom(message.Flat)
+	}
+}
+
 // broadcastToRoom sends a message to all clients in a specific room
 func (h *Hub) broadcastToRoom(message *WSMessage) {
-	h.mu.RLock()
+	// Slow clients are removed from the room, and the broker may deliver while clients register
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	room, exists := h.rooms[message.RoomID]
-	h.mu.RUnlock()
 
 	if !exists {
-		logrus.Warnf("Room %d does not exist for broadcasting", message.RoomID)
+		// Rooms usually have clients on only some of the replicas
+		logrus.Debugf("Room %d has no clients on this replica", message.RoomID)
 		return
 	}
 
@@ -213,12 +261,13 @@ func (h *Hub) broadcastToRoom(message *WSMessage) {
 
 // broadcastFlatToRoom sends a flat message to all clients in a specific room
 func (h *Hub) broadcastFlatToRoom(message *FlatWSMessage) {
-	h.mu.RLock()
+	// Slow clients are removed from the room, and the broker may deliver while clients register
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	room, exists := h.rooms[message.RoomID]
-	h.mu.RUnlock()
 
 	if !exists {
-		logrus.Warnf("Room %d does not exist for broadcasting flat message", message.RoomID)
+		logrus.Debugf("Room %d has no clients on this replica for flat message", message.RoomID)
 		return
 	}
 
@@ -808,7 +857,7 @@ type WebSocketService struct {
 
 // NewWebSocketService creates a new WebSocket service
 func NewWebSocketService(locationTrackingService *LocationTrackingService) *WebSocketService {
-	hub := NewHub()
+	hub := NewHub(GetRealtimeBroker())
 	go hub.Run()
 
 	return &WebSocketService{
@@ -875,6 +924,7 @@ func (ws *WebSocketService) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 
 // BroadcastChatMessage broadcasts a new chat message to all clients in the room
 func (ws *WebSocketService) BroadcastChatMessage(roomID uint, message map[string]interface{}) {
+	GetRealtimeGateway().Publish(RealtimeTopic(RealtimeTopicChatRoom, roomID), MessageTypeMessage, message)
 	ws.hub.BroadcastMessage(roomID, MessageTypeMessage, message)
 }
 
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"time"
 	"treesindia/models"
 	"treesindia/repositories"
@@ -18,7 +19,7 @@ type WorkerAssignmentService struct {
 	notificationService  *NotificationService
 	chatService          *ChatService
 	locationTrackingService *LocationTrackingService
-	callMaskingService   *CallMaskingService
+	stateMachine         *BookingStateMachine
 }
 
 func NewWorkerAssignmentService(chatService *ChatService, locationTrackingService *LocationTrackingService) *WorkerAssignmentService {
@@ -31,7 +32,7 @@ func NewWorkerAssignmentService(chatService *ChatService, locationTrackingServic
 		notificationService:  NewNotificationService(),
 		chatService:          chatService,
 		locationTrackingService: locationTrackingService,
-		callMaskingService:   NewCallMaskingService(),
+		stateMachine:         NewBookingStateMachine(),
 	}
 }
 
@@ -93,6 +94,8 @@ func (was *WorkerAssignmentService) convertToPrivacyProtectedResponse(assignment
 		RejectedAt:      assignment.RejectedAt,
 		StartedAt:       assignment.StartedAt,
 		CompletedAt:     assignment.CompletedAt,
+		PausedAt:        assignment.PausedAt,
+		PauseReason:     assignment.PauseReason,
 		AssignmentNotes: assignment.AssignmentNotes,
 		AcceptanceNotes: assignment.AcceptanceNotes,
 		RejectionNotes:  assignment.RejectionNotes,
@@ -164,35 +167,37 @@ func (was *WorkerAssignmentService) AcceptAssignment(assignmentID uint, workerID
 		return nil, errors.New("unauthorized access to assignment")
 	}
 
-	// Check if assignment can be accepted
-	if assignment.Status != models.AssignmentStatusAssigned {
-		return nil, errors.New("assignment cannot be accepted in current status")
+	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get booking for assignment: %v", err)
+		return nil, errors.New("failed to update booking status")
+	}
+
+	// Check the assignment and booking can move before changing either
+	if err := was.stateMachine.CanTransitionAssignment(assignment, models.AssignmentStatusAccepted); err != nil {
+		return nil, err
+	}
+	if err := was.stateMachine.CanTransitionBooking(booking, models.BookingStatusConfirmed); err != nil {
+		return nil, err
 	}
 
 	// Update assignment
 	now := time.Now()
-	assignment.Status = models.AssignmentStatusAccepted
 	assignment.AcceptedAt = &now
 	assignment.AcceptanceNotes = notes
 
-	err = was.workerAssignmentRepo.Update(assignment)
+	transition := TransitionContext{Actor: models.WorkerActor(workerID), Reason: "Worker accepted the assignment"}
+	err = was.stateMachine.TransitionAssignment(assignment, models.AssignmentStatusAccepted, transition)
 	if err != nil {
 		logrus.Errorf("Failed to accept assignment: %v", err)
-		return nil, errors.New("failed to accept assignment")
+		return nil, err
 	}
 
 	// Update booking status
-	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
-	if err != nil {
-		logrus.Errorf("Failed to get booking for assignment: %v", err)
-		return nil, errors.New("failed to update booking status")
-	}
-
-	booking.Status = models.BookingStatusConfirmed
-	err = was.bookingRepo.Update(booking)
+	err = was.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, transition)
 	if err != nil {
 		logrus.Errorf("Failed to update booking status: %v", err)
-		return nil, errors.New("failed to update booking status")
+		return nil, err
 	}
 
 	// Create chat room when worker accepts assignment
@@ -202,9 +207,6 @@ func (was *WorkerAssignmentService) AcceptAssignment(assignmentID uint, workerID
 		// Don't fail the acceptance if chat room creation fails
 	}
 
-	// Enable call masking when worker accepts assignment
-	go was.callMaskingService.EnableCallMasking(assignment.BookingID)
-
 	// Send in-app notification to user about worker assignment
 	go was.sendWorkerAssignmentNotification(assignment, "accepted")
 
@@ -238,41 +240,40 @@ func (was *WorkerAssignmentService) RejectAssignment(assignmentID uint, workerID
 		return nil, errors.New("unauthorized access to assignment")
 	}
 
-	// Check if assignment can be rejected
-	if assignment.Status != models.AssignmentStatusAssigned {
-		return nil, errors.New("assignment cannot be rejected in current status")
+	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get booking for assignment: %v", err)
+		return nil, errors.New("failed to update booking status")
+	}
+
+	// Check the assignment and booking can move before changing either
+	if err := was.stateMachine.CanTransitionAssignment(assignment, models.AssignmentStatusRejected); err != nil {
+		return nil, err
+	}
+	if err := was.stateMachine.CanTransitionBooking(booking, models.BookingStatusConfirmed); err != nil {
+		return nil, err
 	}
 
 	// Update assignment
 	now := time.Now()
-	assignment.Status = models.AssignmentStatusRejected
 	assignment.RejectedAt = &now
 	assignment.RejectionReason = reason
 	assignment.RejectionNotes = notes
 
-	err = was.workerAssignmentRepo.Update(assignment)
+	transition := TransitionContext{Actor: models.WorkerActor(workerID), Reason: fmt.Sprintf("Worker rejected the assignment: %s", reason)}
+	err = was.stateMachine.TransitionAssignment(assignment, models.AssignmentStatusRejected, transition)
 	if err != nil {
 		logrus.Errorf("Failed to reject assignment: %v", err)
-		return nil, errors.New("failed to reject assignment")
+		return nil, err
 	}
 
 	// Update booking status back to confirmed
-	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
-	if err != nil {
-		logrus.Errorf("Failed to get booking for assignment: %v", err)
-		return nil, errors.New("failed to update booking status")
-	}
-
-	booking.Status = models.BookingStatusConfirmed
-	err = was.bookingRepo.Update(booking)
+	err = was.stateMachine.TransitionBooking(booking, models.BookingStatusConfirmed, transition)
 	if err != nil {
 		logrus.Errorf("Failed to update booking status: %v", err)
-		return nil, errors.New("failed to update booking status")
+		return nil, err
 	}
 
-	// Disable call masking when assignment is rejected
-	go was.callMaskingService.DisableCallMasking(assignment.BookingID)
-
 	// Send notification
 	go was.notificationService.SendWorkerAssignmentRejectedNotification(assignment)
 
@@ -306,35 +307,37 @@ func (was *WorkerAssignmentService) StartAssignment(assignmentID uint, workerID
 		return nil, errors.New("unauthorized access to assignment")
 	}
 
-	// Check if assignment can be started
-	if assignment.Status != models.AssignmentStatusAccepted {
-		return nil, errors.New("assignment cannot be started in current status")
+	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get booking for assignment: %v", err)
+		return nil, errors.New("failed to update booking status")
+	}
+
+	// Check the assignment can start and the booking is in a state that allows work to begin
+	if err := was.stateMachine.CanTransitionAssignment(assignment, models.AssignmentStatusInProgress); err != nil {
+		return nil, err
+	}
+	if !containsBookingStatus(AllowedBookingTransitions(booking.Status), models.BookingStatusInProgress) {
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusInProgress)}
 	}
 
 	// Update assignment
 	now := time.Now()
-	assignment.Status = models.AssignmentStatusInProgress
 	assignment.StartedAt = &now
 
-	err = was.workerAssignmentRepo.Update(assignment)
+	transition := TransitionContext{Actor: models.WorkerActor(workerID), Reason: "Worker started the work"}
+	err = was.stateMachine.TransitionAssignment(assignment, models.AssignmentStatusInProgress, transition)
 	if err != nil {
 		logrus.Errorf("Failed to start assignment: %v", err)
-		return nil, errors.New("failed to start assignment")
+		return nil, err
 	}
 
 	// Update booking status
-	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
-	if err != nil {
-		logrus.Errorf("Failed to get booking for assignment: %v", err)
-		return nil, errors.New("failed to update booking status")
-	}
-
-	booking.Status = models.BookingStatusInProgress
 	booking.ActualStartTime = &now
-	err = was.bookingRepo.Update(booking)
+	err = was.stateMachine.TransitionBooking(booking, models.BookingStatusInProgress, transition)
 	if err != nil {
 		logrus.Errorf("Failed to update booking status: %v", err)
-		return nil, errors.New("failed to update booking status")
+		return nil, err
 	}
 
 	// Start location tracking when assignment starts
@@ -376,30 +379,32 @@ func (was *WorkerAssignmentService) CompleteAssignment(assignmentID uint, worker
 		return nil, errors.New("unauthorized access to assignment")
 	}
 
-	// Check if assignment can be completed
-	if assignment.Status != models.AssignmentStatusInProgress {
-		return nil, errors.New("assignment cannot be completed in current status")
+	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
+	if err != nil {
+		logrus.Errorf("Failed to get booking for assignment: %v", err)
+		return nil, errors.New("failed to update booking status")
+	}
+
+	// Check the assignment can complete and the booking is in progress
+	if err := was.stateMachine.CanTransitionAssignment(assignment, models.AssignmentStatusCompleted); err != nil {
+		return nil, err
+	}
+	if !containsBookingStatus(AllowedBookingTransitions(booking.Status), models.BookingStatusCompleted) {
+		return nil, &StateTransitionError{Entity: "booking", From: string(booking.Status), To: string(models.BookingStatusCompleted)}
 	}
 
 	// Update assignment
 	now := time.Now()
-	assignment.Status = models.AssignmentStatusCompleted
 	assignment.CompletedAt = &now
 
-	err = was.workerAssignmentRepo.Update(assignment)
+	transition := TransitionContext{Actor: models.WorkerActor(workerID), Reason: "Worker completed the work"}
+	err = was.stateMachine.TransitionAssignment(assignment, models.AssignmentStatusCompleted, transition)
 	if err != nil {
 		logrus.Errorf("Failed to complete assignment: %v", err)
-		return nil, errors.New("failed to complete assignment")
+		return nil, err
 	}
 
 	// Update booking status
-	booking, err := was.bookingRepo.GetByID(assignment.BookingID)
-	if err != nil {
-		logrus.Errorf("Failed to get booking for assignment: %v", err)
-		return nil, errors.New("failed to update booking status")
-	}
-
-	booking.Status = models.BookingStatusCompleted
 	booking.ActualEndTime = &now
 	
 	// Calculate actual duration if start time is available
@@ -408,39 +413,16 @@ func (was *WorkerAssignmentService) CompleteAssignment(assignmentID uint, worker
 		booking.ActualDurationMinutes = &duration
 	}
 
-	err = was.bookingRepo.Update(booking)
+	err = was.stateMachine.TransitionBooking(booking, models.BookingStatusCompleted, transition)
 	if err != nil {
 		logrus.Errorf("Failed to update booking status: %v", err)
-		return nil, errors.New("failed to update booking status")
+		return nil, err
 	}
 
-	// Disable call masking when assignment is completed
-	go was.callMaskingService.DisableCallMasking(assignment.BookingID)
-
-	// Update worker statistics
-	worker, err := was.workerRepo.GetByUserID(assignment.WorkerID)
-	if err != nil {
-		logrus.Errorf("Failed to get worker for assignment %d: %v", assignmentID, err)
-		// Don't fail the completion if worker update fails
-	} else {
-		// Calculate earnings from booking
-		earnings := 0.0
-		
-		// Get earnings from quote amount (for inquiry bookings) or service price (for regular bookings)
-		if booking.QuoteAmount != nil {
-			earnings = *booking.QuoteAmount
-		} else if booking.Service.Price != nil {
-			earnings = *booking.Service.Price
-		}
-		
-		// Update worker statistics
-		err = was.workerRepo.IncrementCompletedJob(worker.ID, earnings)
-		if err != nil {
-			logrus.Errorf("Failed to update worker statistics for assignment %d: %v", assignmentID, err)
-			// Don't fail the completion if worker update fails
-		} else {
-			logrus.Infof("Updated worker statistics for assignment %d: worker_id=%d, earnings=%.2f", assignmentID, worker.ID, earnings)
-		}
+	// Accrue the worker's earning after commission and update worker statistics
+	if _, err := NewWorkerEarningService().AccrueEarning(assignment, booking); err != nil {
+		logrus.Errorf("Failed to accrue worker earning for assignment %d: %v", assignmentID, err)
+		// Don't fail the completion if earning accrual fails
 	}
 
 	// Close chat room when assignment is completed
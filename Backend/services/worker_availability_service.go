@@ -0,0 +1,420 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reasons a worker cannot take a job at a given time
+const (
+	UnavailableDayOff       = "day off"
+	UnavailableOutsideHours = "outside working hours"
+	UnavailableOnLeave      = "on leave"
+	UnavailableBlocked      = "blocked"
+)
+
+// WorkerCalendars holds the schedules, approved leave and blocked slots of a set of workers
+// for a period, so availability can be checked for many slots without further queries
+type WorkerCalendars struct {
+	location     *time.Location
+	defaultStart int // Minutes from midnight
+	defaultEnd   int
+	schedules    map[uint]map[int]models.WorkerSchedule
+	leaves       map[uint][]models.WorkerLeave
+	blocked      map[uint][]models.WorkerBlockedSlot
+}
+
+// IsAvailable reports whether the worker can work the whole period
+func (wc *WorkerCalendars) IsAvailable(workerID uint, startTime, endTime time.Time) bool {
+	return wc.UnavailableReason(workerID, startTime, endTime) == ""
+}
+
+// UnavailableReason returns why the worker cannot work the period, or "" when they can
+func (wc *WorkerCalendars) UnavailableReason(workerID uint, startTime, endTime time.Time) string {
+	localStart := startTime.In(wc.location)
+	localEnd := endTime.In(wc.location)
+
+	// Working hours of the day the job starts on
+	shiftStart, shiftEnd := wc.defaultStart, wc.defaultEnd
+	if days, ok := wc.schedules[workerID]; ok {
+		day, ok := days[int(localStart.Weekday())]
+		if !ok || day.IsDayOff {
+			return UnavailableDayOff
+		}
+		shiftStart, _ = parseClockMinutes(day.StartTime)
+		shiftEnd, _ = parseClockMinutes(day.EndTime)
+	}
+
+	startMinutes := localStart.Hour()*60 + localStart.Minute()
+	endMinutes := localEnd.Hour()*60 + localEnd.Minute()
+	if localEnd.Format("2006-01-02") != localStart.Format("2006-01-02") {
+		endMinutes += 24 * 60
+	}
+	if startMinutes < shiftStart || endMinutes > shiftEnd {
+		return UnavailableOutsideHours
+	}
+
+	startDate := localStart.Format("2006-01-02")
+	endDate := localEnd.Format("2006-01-02")
+	for _, leave := range wc.leaves[workerID] {
+		if leave.StartDate.Format("2006-01-02") <= endDate && leave.EndDate.Format("2006-01-02") >= startDate {
+			return UnavailableOnLeave
+		}
+	}
+
+	for _, slot := range wc.blocked[workerID] {
+		if slot.StartTime.Before(endTime) && slot.EndTime.After(startTime) {
+			return UnavailableBlocked
+		}
+	}
+
+	return ""
+}
+
+// WorkerAvailabilityService manages worker schedules, leave and blocked slots
+type WorkerAvailabilityService struct {
+	availabilityRepo *repositories.WorkerAvailabilityRepository
+	adminConfigRepo  *repositories.AdminConfigRepository
+}
+
+// NewWorkerAvailabilityService creates a new worker availability service
+func NewWorkerAvailabilityService() *WorkerAvailabilityService {
+	return &WorkerAvailabilityService{
+		availabilityRepo: repositories.NewWorkerAvailabilityRepository(),
+		adminConfigRepo:  repositories.NewAdminConfigRepository(),
+	}
+}
+
+// GetSchedule gets a worker's weekly schedule. Workers who never set one work the global working hours.
+func (was *WorkerAvailabilityService) GetSchedule(workerID uint) ([]models.WorkerScheduleDay, error) {
+	schedules, err := was.availabilityRepo.GetSchedule(workerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %v", err)
+	}
+
+	defaultStart, defaultEnd := was.workingHours()
+	days := make([]models.WorkerScheduleDay, 7)
+	for i := range days {
+		days[i] = models.WorkerScheduleDay{DayOfWeek: i, StartTime: defaultStart, EndTime: defaultEnd, IsDayOff: len(schedules) > 0}
+	}
+	for _, schedule := range schedules {
+		days[schedule.DayOfWeek] = models.WorkerScheduleDay{
+			DayOfWeek: schedule.DayOfWeek,
+			StartTime: schedule.StartTime,
+			EndTime:   schedule.EndTime,
+			IsDayOff:  schedule.IsDayOff,
+		}
+	}
+
+	return days, nil
+}
+
+// UpdateSchedule replaces a worker's weekly schedule. Days not in the request become days off.
+func (was *WorkerAvailabilityService) UpdateSchedule(workerID uint, req *models.UpdateWorkerScheduleRequest) ([]models.WorkerScheduleDay, error) {
+	defaultStart, defaultEnd := was.workingHours()
+
+	byDay := make(map[int]models.WorkerScheduleDay)
+	for _, day := range req.Days {
+		if _, exists := byDay[day.DayOfWeek]; exists {
+			return nil, fmt.Errorf("day %d is listed more than once", day.DayOfWeek)
+		}
+		if !day.IsDayOff {
+			start, err := parseClockMinutes(day.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start time for day %d, expected HH:MM", day.DayOfWeek)
+			}
+			end, err := parseClockMinutes(day.EndTime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end time for day %d, expected HH:MM", day.DayOfWeek)
+			}
+			if end <= start {
+				return nil, fmt.Errorf("end time must be after start time for day %d", day.DayOfWeek)
+			}
+		}
+		byDay[day.DayOfWeek] = day
+	}
+
+	schedules := make([]models.WorkerSchedule, 0, 7)
+	for dayOfWeek := 0; dayOfWeek < 7; dayOfWeek++ {
+		day, ok := byDay[dayOfWeek]
+		if !ok || day.IsDayOff {
+			schedules = append(schedules, models.WorkerSchedule{WorkerID: workerID, DayOfWeek: dayOfWeek, StartTime: defaultStart, EndTime: defaultEnd, IsDayOff: true})
+			continue
+		}
+		schedules = append(schedules, models.WorkerSchedule{WorkerID: workerID, DayOfWeek: dayOfWeek, StartTime: day.StartTime, EndTime: day.EndTime})
+	}
+
+	if err := was.availabilityRepo.ReplaceSchedule(workerID, schedules); err != nil {
+		return nil, fmt.Errorf("failed to save schedule: %v", err)
+	}
+
+	logrus.Infof("Worker %d updated their weekly schedule", workerID)
+	return was.GetSchedule(workerID)
+}
+
+// RequestLeave creates a pending leave request for a worker
+func (was *WorkerAvailabilityService) RequestLeave(workerID uint, req *models.CreateLeaveRequest) (*models.WorkerLeave, error) {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date, expected YYYY-MM-DD")
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end date, expected YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date cannot be before start date")
+	}
+
+	today := time.Now().In(workerCalendarLocation()).Format("2006-01-02")
+	if req.StartDate < today {
+		return nil, errors.New("leave cannot start in the past")
+	}
+
+	overlaps, err := was.availabilityRepo.HasOverlappingLeave(workerID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing leave: %v", err)
+	}
+	if overlaps {
+		return nil, errors.New("you already have leave requested for these dates")
+	}
+
+	leave := &models.WorkerLeave{
+		WorkerID:  workerID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Reason:    req.Reason,
+		Status:    models.LeaveStatusPending,
+	}
+	if err := was.availabilityRepo.CreateLeave(leave); err != nil {
+		return nil, fmt.Errorf("failed to create leave request: %v", err)
+	}
+
+	logrus.Infof("Worker %d requested leave from %s to %s", workerID, req.StartDate, req.EndDate)
+	return leave, nil
+}
+
+// CancelLeave withdraws a worker's own pending or upcoming approved leave
+func (was *WorkerAvailabilityService) CancelLeave(workerID uint, leaveID uint) (*models.WorkerLeave, error) {
+	leave, err := was.availabilityRepo.GetLeaveByID(leaveID)
+	if err != nil {
+		return nil, errors.New("leave request not found")
+	}
+	if leave.WorkerID != workerID {
+		return nil, errors.New("unauthorized")
+	}
+	if leave.Status != models.LeaveStatusPending && leave.Status != models.LeaveStatusApproved {
+		return nil, fmt.Errorf("%s leave cannot be cancelled", leave.Status)
+	}
+
+	today := time.Now().In(workerCalendarLocation()).Format("2006-01-02")
+	if leave.StartDate.Format("2006-01-02") <= today {
+		return nil, errors.New("leave that has already started cannot be cancelled")
+	}
+
+	leave.Status = models.LeaveStatusCancelled
+	if err := was.availabilityRepo.UpdateLeave(leave); err != nil {
+		return nil, fmt.Errorf("failed to cancel leave: %v", err)
+	}
+	return leave, nil
+}
+
+// GetWorkerLeaves gets a worker's leave requests
+func (was *WorkerAvailabilityService) GetWorkerLeaves(workerID uint) ([]models.WorkerLeave, error) {
+	return was.availabilityRepo.GetWorkerLeaves(workerID)
+}
+
+// GetLeaves gets leave requests with filters (admin)
+func (was *WorkerAvailabilityService) GetLeaves(filters *repositories.WorkerLeaveFilters) ([]models.WorkerLeave, *repositories.Pagination, error) {
+	return was.availabilityRepo.GetLeaves(filters)
+}
+
+// ReviewLeave approves or rejects a pending leave request (admin)
+func (was *WorkerAvailabilityService) ReviewLeave(adminID uint, leaveID uint, req *models.ReviewLeaveRequest) (*models.WorkerLeave, error) {
+	leave, err := was.availabilityRepo.GetLeaveByID(leaveID)
+	if err != nil {
+		return nil, errors.New("leave request not found")
+	}
+	if leave.Status != models.LeaveStatusPending {
+		return nil, fmt.Errorf("leave request is already %s", leave.Status)
+	}
+
+	now := time.Now()
+	leave.Status = req.Status
+	leave.AdminNotes = req.Notes
+	leave.ReviewedBy = &adminID
+	leave.ReviewedAt = &now
+
+	if err := was.availabilityRepo.UpdateLeave(leave); err != nil {
+		return nil, fmt.Errorf("failed to update leave request: %v", err)
+	}
+
+	logrus.Infof("Leave %d of worker %d %s by admin %d", leave.ID, leave.WorkerID, leave.Status, adminID)
+	return leave, nil
+}
+
+// AddBlockedSlot blocks a period in a worker's calendar
+func (was *WorkerAvailabilityService) AddBlockedSlot(workerID uint, req *models.CreateBlockedSlotRequest) (*models.WorkerBlockedSlot, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New("end time must be after start time")
+	}
+	if req.EndTime.Before(time.Now()) {
+		return nil, errors.New("cannot block a period in the past")
+	}
+
+	slot := &models.WorkerBlockedSlot{
+		WorkerID:  workerID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    req.Reason,
+	}
+	if err := was.availabilityRepo.CreateBlockedSlot(slot); err != nil {
+		return nil, fmt.Errorf("failed to block slot: %v", err)
+	}
+	return slot, nil
+}
+
+// RemoveBlockedSlot removes one of a worker's blocked slots
+func (was *WorkerAvailabilityService) RemoveBlockedSlot(workerID uint, slotID uint) error {
+	slot, err := was.availabilityRepo.GetBlockedSlotByID(slotID)
+	if err != nil {
+		return errors.New("blocked slot not found")
+	}
+	if slot.WorkerID != workerID {
+		return errors.New("unauthorized")
+	}
+	return was.availabilityRepo.DeleteBlockedSlot(slotID)
+}
+
+// GetBlockedSlots gets a worker's blocked slots overlapping the period
+func (was *WorkerAvailabilityService) GetBlockedSlots(workerID uint, from, to time.Time) ([]models.WorkerBlockedSlot, error) {
+	return was.availabilityRepo.GetBlockedSlotsInRange([]uint{workerID}, from, to)
+}
+
+// GetWorkerAvailability gets a worker's schedule, upcoming leave and blocked slots (admin)
+func (was *WorkerAvailabilityService) GetWorkerAvailability(workerID uint) (map[string]interface{}, error) {
+	schedule, err := was.GetSchedule(workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves, err := was.availabilityRepo.GetWorkerLeaves(workerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leave: %v", err)
+	}
+
+	now := time.Now()
+	blocked, err := was.availabilityRepo.GetBlockedSlotsInRange([]uint{workerID}, now, now.AddDate(0, 3, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked slots: %v", err)
+	}
+
+	return map[string]interface{}{
+		"worker_id":     workerID,
+		"schedule":      schedule,
+		"leaves":        leaves,
+		"blocked_slots": blocked,
+	}, nil
+}
+
+// LoadCalendars loads the calendars of the workers for the period
+func (was *WorkerAvailabilityService) LoadCalendars(workerIDs []uint, from, to time.Time) (*WorkerCalendars, error) {
+	defaultStart, defaultEnd := was.workingHours()
+	startMinutes, _ := parseClockMinutes(defaultStart)
+	endMinutes, _ := parseClockMinutes(defaultEnd)
+
+	calendars := &WorkerCalendars{
+		location:     workerCalendarLocation(),
+		defaultStart: startMinutes,
+		defaultEnd:   endMinutes,
+		schedules:    make(map[uint]map[int]models.WorkerSchedule),
+		leaves:       make(map[uint][]models.WorkerLeave),
+		blocked:      make(map[uint][]models.WorkerBlockedSlot),
+	}
+
+	schedules, err := was.availabilityRepo.GetSchedulesForWorkers(workerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker schedules: %v", err)
+	}
+	for _, schedule := range schedules {
+		if calendars.schedules[schedule.WorkerID] == nil {
+			calendars.schedules[schedule.WorkerID] = make(map[int]models.WorkerSchedule)
+		}
+		calendars.schedules[schedule.WorkerID][schedule.DayOfWeek] = schedule
+	}
+
+	// Leave is stored by calendar date, so widen the period to whole days
+	fromDate := from.In(calendars.location).Format("2006-01-02")
+	toDate := to.In(calendars.location).Format("2006-01-02")
+	fromDay, _ := time.Parse("2006-01-02", fromDate)
+	toDay, _ := time.Parse("2006-01-02", toDate)
+	leaves, err := was.availabilityRepo.GetApprovedLeavesInRange(workerIDs, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker leave: %v", err)
+	}
+	for _, leave := range leaves {
+		calendars.leaves[leave.WorkerID] = append(calendars.leaves[leave.WorkerID], leave)
+	}
+
+	blocked, err := was.availabilityRepo.GetBlockedSlotsInRange(workerIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked slots: %v", err)
+	}
+	for _, slot := range blocked {
+		calendars.blocked[slot.WorkerID] = append(calendars.blocked[slot.WorkerID], slot)
+	}
+
+	return calendars, nil
+}
+
+// CheckWorkerAvailable returns an error when the worker's calendar does not allow the period
+func (was *WorkerAvailabilityService) CheckWorkerAvailable(workerID uint, startTime, endTime time.Time) error {
+	calendars, err := was.LoadCalendars([]uint{workerID}, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if reason := calendars.UnavailableReason(workerID, startTime, endTime); reason != "" {
+		return fmt.Errorf("worker is not available for this time slot: %s", reason)
+	}
+	return nil
+}
+
+// workingHours returns the global working hours used for workers without a schedule
+func (was *WorkerAvailabilityService) workingHours() (string, string) {
+	start, end := "09:00", "22:00"
+	if config, err := was.adminConfigRepo.GetByKey("working_hours_start"); err == nil {
+		if _, err := parseClockMinutes(config.Value); err == nil {
+			start = config.Value
+		}
+	}
+	if config, err := was.adminConfigRepo.GetByKey("working_hours_end"); err == nil {
+		if _, err := parseClockMinutes(config.Value); err == nil {
+			end = config.Value
+		}
+	}
+	return start, end
+}
+
+// workerCalendarLocation returns the timezone schedules and leave dates are kept in
+func workerCalendarLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		location = time.FixedZone("IST", 5*60*60+30*60)
+	}
+	return location
+}
+
+// parseClockMinutes parses an HH:MM time into minutes from midnight
+func parseClockMinutes(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// WorkerEarningService accrues worker earnings after commission and settles them in payout batches
+type WorkerEarningService struct {
+	earningRepo        *repositories.WorkerEarningRepository
+	workerRepo         *repositories.WorkerRepository
+	serviceRepo        *repositories.ServiceRepository
+	adminConfigService *AdminConfigService
+}
+
+// NewWorkerEarningService creates a new worker earning service
+func NewWorkerEarningService() *WorkerEarningService {
+	return &WorkerEarningService{
+		earningRepo:        repositories.NewWorkerEarningRepository(),
+		workerRepo:         repositories.NewWorkerRepository(),
+		serviceRepo:        repositories.NewServiceRepository(),
+		adminConfigService: NewAdminConfigService(),
+	}
+}
+
+// AccrueEarning records the worker's earning for a completed booking and adds it to the worker's
+// statistics. A booking accrues once; completing it again returns the existing earning.
+func (ess *WorkerEarningService) AccrueEarning(assignment *models.WorkerAssignment, booking *models.Booking) (*models.WorkerEarning, error) {
+	// Get earnings from quote amount (for inquiry bookings) or service price (for regular bookings)
+	gross := 0.0
+	if booking.QuoteAmount != nil {
+		gross = *booking.QuoteAmount
+	} else if booking.Service.Price != nil {
+		gross = *booking.Service.Price
+	}
+
+	categoryID := booking.Service.CategoryID
+	if categoryID == 0 {
+		var service models.Service
+		if err := ess.serviceRepo.FindByID(&service, booking.ServiceID); err == nil {
+			categoryID = service.CategoryID
+		}
+	}
+
+	earning := &models.WorkerEarning{
+		WorkerID:     assignment.WorkerID,
+		BookingID:    booking.ID,
+		AssignmentID: assignment.ID,
+		GrossAmount:  roundCurrency(gross),
+		Status:       models.EarningStatusPending,
+		EarnedAt:     time.Now(),
+	}
+
+	rule, err := ess.earningRepo.FindRule(booking.ServiceID, categoryID)
+	if err == nil {
+		earning.CommissionRuleID = &rule.ID
+		earning.CommissionType = rule.CommissionType
+		earning.CommissionRate = rule.Value
+	} else {
+		earning.CommissionType = models.CommissionTypePercentage
+		earning.CommissionRate = ess.adminConfigService.GetDefaultCommissionPercentage()
+	}
+	earning.CommissionAmount = commissionAmount(earning.GrossAmount, earning.CommissionType, earning.CommissionRate)
+	earning.NetAmount = roundCurrency(earning.GrossAmount - earning.CommissionAmount)
+
+	created, err := ess.earningRepo.CreateEarning(earning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker earning: %v", err)
+	}
+	if !created {
+		logrus.Infof("Booking %d already has a worker earning, not accruing again", booking.ID)
+		return earning, nil
+	}
+
+	// Update worker statistics
+	worker, err := ess.workerRepo.GetByUserID(assignment.WorkerID)
+	if err != nil {
+		return earning, fmt.Errorf("failed to get worker: %v", err)
+	}
+	if err := ess.workerRepo.IncrementCompletedJob(worker.ID, earning.NetAmount); err != nil {
+		return earning, fmt.Errorf("failed to update worker statistics: %v", err)
+	}
+
+	logrus.Infof("Accrued earning for booking %d: worker=%d gross=%.2f commission=%.2f net=%.2f", booking.ID, assignment.WorkerID, earning.GrossAmount, earning.CommissionAmount, earning.NetAmount)
+	return earning, nil
+}
+
+// ErrEarningAlreadyPaidOut is returned when a refunded booking's earning is in a payout or already
+// paid, so the refund can no longer be taken off it
+var ErrEarningAlreadyPaidOut = errors.New("worker earning is already in a payout")
+
+// DeductRefund takes a refund of a booking off the worker's unpaid earning for it and recalculates
+// the commission on what is left. An earning refunded in full is reversed and never paid out.
+// Bookings without an earning return nil.
+func (ess *WorkerEarningService) DeductRefund(bookingID uint, refunded float64) (*models.WorkerEarning, error) {
+	earning, err := ess.earningRepo.GetEarningByBookingID(bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get worker earning: %v", err)
+	}
+	if earning.Status != models.EarningStatusPending {
+		return earning, ErrEarningAlreadyPaidOut
+	}
+
+	previouslyRefunded := earning.RefundedAmount
+	previousNet := earning.NetAmount
+	earning.RefundedAmount = roundCurrency(math.Min(previouslyRefunded+refunded, earning.GrossAmount))
+	remaining := roundCurrency(earning.GrossAmount - earning.RefundedAmount)
+	earning.CommissionAmount = commissionAmount(remaining, earning.CommissionType, earning.CommissionRate)
+	earning.NetAmount = roundCurrency(remaining - earning.CommissionAmount)
+	if remaining <= 0 {
+		earning.Status = models.EarningStatusReversed
+	}
+
+	deducted, err := ess.earningRepo.DeductEarning(earning, previouslyRefunded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update worker earning: %v", err)
+	}
+	if !deducted {
+		// A payout batch or another refund changed the earning after it was read
+		return ess.DeductRefund(bookingID, refunded)
+	}
+
+	if worker, err := ess.workerRepo.GetByUserID(earning.WorkerID); err != nil {
+		logrus.Errorf("Failed to get worker %d to update earnings: %v", earning.WorkerID, err)
+	} else if err := ess.workerRepo.DeductEarnings(worker.ID, roundCurrency(previousNet-earning.NetAmount)); err != nil {
+		logrus.Errorf("Failed to update earnings of worker %d: %v", earning.WorkerID, err)
+	}
+
+	logrus.Infof("Deducted refund of %.2f from earning %d for booking %d: net=%.2f status=%s", refunded, earning.ID, bookingID, earning.NetAmount, earning.Status)
+	return earning, nil
+}
+
+// GetCommissionRules gets all commission rules (admin)
+func (ess *WorkerEarningService) GetCommissionRules() ([]models.CommissionRule, error) {
+	return ess.earningRepo.GetRules()
+}
+
+// CreateCommissionRule creates a commission rule (admin)
+func (ess *WorkerEarningService) CreateCommissionRule(adminID uint, req *models.CommissionRuleRequest) (*models.CommissionRule, error) {
+	if err := validateCommissionRule(req); err != nil {
+		return nil, err
+	}
+
+	rule := &models.CommissionRule{
+		Name:           req.Name,
+		CategoryID:     req.CategoryID,
+		ServiceID:      req.ServiceID,
+		CommissionType: req.CommissionType,
+		Value:          req.Value,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		CreatedBy:      &adminID,
+	}
+	if err := ess.earningRepo.CreateRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to create commission rule: %v", err)
+	}
+
+	return ess.earningRepo.GetRuleByID(rule.ID)
+}
+
+// UpdateCommissionRule updates a commission rule (admin). Earnings already accrued keep the
+// commission they were calculated with.
+func (ess *WorkerEarningService) UpdateCommissionRule(ruleID uint, req *models.CommissionRuleRequest) (*models.CommissionRule, error) {
+	rule, err := ess.earningRepo.GetRuleByID(ruleID)
+	if err != nil {
+		return nil, errors.New("commission rule not found")
+	}
+	if err := validateCommissionRule(req); err != nil {
+		return nil, err
+	}
+
+	rule.Name = req.Name
+	rule.CategoryID = req.CategoryID
+	rule.ServiceID = req.ServiceID
+	rule.CommissionType = req.CommissionType
+	rule.Value = req.Value
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	rule.Category = nil
+	rule.Service = nil
+	if err := ess.earningRepo.UpdateRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to update commission rule: %v", err)
+	}
+
+	return ess.earningRepo.GetRuleByID(rule.ID)
+}
+
+// DeleteCommissionRule deletes a commission rule (admin)
+func (ess *WorkerEarningService) DeleteCommissionRule(ruleID uint) error {
+	if _, err := ess.earningRepo.GetRuleByID(ruleID); err != nil {
+		return errors.New("commission rule not found")
+	}
+	return ess.earningRepo.DeleteRule(ruleID)
+}
+
+// GetEarnings gets earning lines with filters
+func (ess *WorkerEarningService) GetEarnings(filters *models.WorkerEarningFilters) ([]models.WorkerEarning, *repositories.Pagination, error) {
+	return ess.earningRepo.GetEarnings(filters)
+}
+
+// GetWorkerStatement gets a worker's earnings and payouts for a period along with lifetime totals
+func (ess *WorkerEarningService) GetWorkerStatement(workerID uint, from, to *time.Time) (*models.WorkerEarningsStatement, error) {
+	period, err := ess.earningRepo.GetSummary(workerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings summary: %v", err)
+	}
+
+	lifetime, err := ess.earningRepo.GetSummary(workerID, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings summary: %v", err)
+	}
+
+	payouts, err := ess.earningRepo.GetWorkerPayouts(workerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payouts: %v", err)
+	}
+
+	return &models.WorkerEarningsStatement{
+		From:              from,
+		To:                to,
+		Period:            *period,
+		Lifetime:          *lifetime,
+		OutstandingAmount: roundCurrency(lifetime.PendingAmount + lifetime.InPayoutAmount),
+		Payouts:           payouts,
+	}, nil
+}
+
+// CreatePayoutBatch creates a payout batch for every pending earning up to the end of the
+// period. Workers without complete banking info are skipped and stay pending.
+func (ess *WorkerEarningService) CreatePayoutBatch(adminID uint, req *models.CreatePayoutBatchRequest) (*models.PayoutBatch, error) {
+	location := workerCalendarLocation()
+	today := time.Now().In(location)
+	periodDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, location).AddDate(0, 0, -1)
+	if req.PeriodEnd != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.PeriodEnd, location)
+		if err != nil {
+			return nil, errors.New("invalid period end, use YYYY-MM-DD")
+		}
+		if parsed.After(today) {
+			return nil, errors.New("period end cannot be in the future")
+		}
+		periodDay = parsed
+	}
+	periodEnd := periodDay.AddDate(0, 0, 1).Add(-time.Second)
+
+	earnings, err := ess.earningRepo.GetPendingEarnings(periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending earnings: %v", err)
+	}
+	if len(earnings) == 0 {
+		return nil, errors.New("no pending earnings to pay out for this period")
+	}
+
+	// Group earnings by worker, keeping the order of the first earning
+	var workerOrder []uint
+	byWorker := make(map[uint][]models.WorkerEarning)
+	for _, earning := range earnings {
+		if _, ok := byWorker[earning.WorkerID]; !ok {
+			workerOrder = append(workerOrder, earning.WorkerID)
+		}
+		byWorker[earning.WorkerID] = append(byWorker[earning.WorkerID], earning)
+	}
+
+	batch := &models.PayoutBatch{
+		BatchReference: ess.generateBatchReference(),
+		PeriodEnd:      periodEnd,
+		Status:         models.PayoutBatchStatusDraft,
+		Notes:          req.Notes,
+		CreatedBy:      adminID,
+	}
+	if latest, err := ess.earningRepo.GetLatestBatch(); err == nil {
+		periodStart := latest.PeriodEnd.Add(time.Second)
+		batch.PeriodStart = &periodStart
+	}
+
+	var payouts []models.WorkerPayout
+	var earningIDs [][]uint
+	for _, workerID := range workerOrder {
+		workerEarnings := byWorker[workerID]
+
+		amount := 0.0
+		ids := make([]uint, 0, len(workerEarnings))
+		for _, earning := range workerEarnings {
+			amount += earning.NetAmount
+			ids = append(ids, earning.ID)
+		}
+		amount = roundCurrency(amount)
+		if amount <= 0 {
+			continue
+		}
+
+		banking, err := ess.workerBankingInfo(workerID)
+		if err != nil {
+			logrus.Warnf("Skipping payout for worker %d: %v", workerID, err)
+			batch.SkippedWorkers++
+			continue
+		}
+
+		payouts = append(payouts, models.WorkerPayout{
+			WorkerID:          workerID,
+			Amount:            amount,
+			EarningsCount:     len(workerEarnings),
+			AccountHolderName: banking.AccountHolderName,
+			AccountNumber:     banking.AccountNumber,
+			IfscCode:          strings.ToUpper(banking.IfscCode),
+			BankName:          banking.BankName,
+			Status:            models.PayoutStatusPending,
+		})
+		earningIDs = append(earningIDs, ids)
+		batch.TotalAmount += amount
+	}
+
+	if len(payouts) == 0 {
+		return nil, fmt.Errorf("no workers can be paid: %d workers have incomplete banking info", batch.SkippedWorkers)
+	}
+	batch.TotalAmount = roundCurrency(batch.TotalAmount)
+	batch.PayoutCount = len(payouts)
+
+	if err := ess.earningRepo.CreateBatch(batch, payouts, earningIDs); err != nil {
+		if errors.Is(err, gorm.ErrInvalidData) {
+			return nil, errors.New("some earnings were added to another payout batch, try again")
+		}
+		return nil, fmt.Errorf("failed to create payout batch: %v", err)
+	}
+
+	logrus.Infof("Created payout batch %s: %d payouts, ₹%.2f, %d workers skipped", batch.BatchReference, batch.PayoutCount, batch.TotalAmount, batch.SkippedWorkers)
+	return ess.earningRepo.GetBatchByID(batch.ID)
+}
+
+// GetPayoutBatches gets payout batches with filters (admin)
+func (ess *WorkerEarningService) GetPayoutBatches(filters *models.PayoutBatchFilters) ([]models.PayoutBatch, *repositories.Pagination, error) {
+	return ess.earningRepo.GetBatches(filters)
+}
+
+// GetPayoutBatch gets a payout batch with its payouts (admin)
+func (ess *WorkerEarningService) GetPayoutBatch(batchID uint) (*models.PayoutBatch, error) {
+	batch, err := ess.earningRepo.GetBatchByID(batchID)
+	if err != nil {
+		return nil, errors.New("payout batch not found")
+	}
+	return batch, nil
+}
+
+// ExportPayoutBatch writes the bank transfer file of a batch as CSV and marks the batch exported
+func (ess *WorkerEarningService) ExportPayoutBatch(batchID uint) ([]byte, string, error) {
+	batch, err := ess.GetPayoutBatch(batchID)
+	if err != nil {
+		return nil, "", err
+	}
+	if batch.Status != models.PayoutBatchStatusDraft && batch.Status != models.PayoutBatchStatusExported {
+		return nil, "", fmt.Errorf("%s payout batches cannot be exported", batch.Status)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Payment Type", "Beneficiary Name", "Account Number", "IFSC Code", "Bank Name", "Amount", "Payment Reference", "Narration"})
+	for _, payout := range batch.Payouts {
+		if payout.Status != models.PayoutStatusPending {
+			continue
+		}
+		writer.Write([]string{
+			"NEFT",
+			csvText(payout.AccountHolderName),
+			csvText(payout.AccountNumber),
+			csvText(payout.IfscCode),
+			csvText(payout.BankName),
+			fmt.Sprintf("%.2f", payout.Amount),
+			fmt.Sprintf("%s-%d", batch.BatchReference, payout.ID),
+			fmt.Sprintf("TREESINDIA PAYOUT %s", batch.BatchReference),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to write bank file: %v", err)
+	}
+
+	if batch.Status == models.PayoutBatchStatusDraft {
+		now := time.Now()
+		batch.Status = models.PayoutBatchStatusExported
+		batch.ExportedAt = &now
+		if err := ess.earningRepo.UpdateBatch(batch); err != nil {
+			return nil, "", fmt.Errorf("failed to update payout batch: %v", err)
+		}
+	}
+
+	return buf.Bytes(), fmt.Sprintf("payouts_%s.csv", batch.BatchReference), nil
+}
+
+// RecordPayoutResults records the bank outcome of payouts in an exported batch. The batch
+// completes once no payout is waiting for the bank.
+func (ess *WorkerEarningService) RecordPayoutResults(batchID uint, req *models.RecordPayoutResultsRequest) (*models.PayoutBatch, error) {
+	batch, err := ess.GetPayoutBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != models.PayoutBatchStatusExported {
+		return nil, errors.New("payout results can only be recorded for exported batches")
+	}
+
+	payouts := make(map[uint]models.WorkerPayout, len(batch.Payouts))
+	for _, payout := range batch.Payouts {
+		payouts[payout.ID] = payout
+	}
+
+	now := time.Now()
+	for _, result := range req.Results {
+		payout, ok := payouts[result.PayoutID]
+		if !ok {
+			return nil, fmt.Errorf("payout %d is not in this batch", result.PayoutID)
+		}
+		if payout.Status != models.PayoutStatusPending {
+			return nil, fmt.Errorf("payout %d is already %s", result.PayoutID, payout.Status)
+		}
+
+		payout.Worker = nil
+		if result.Paid {
+			if result.TransactionRef == "" {
+				return nil, fmt.Errorf("transaction reference is required for paid payout %d", result.PayoutID)
+			}
+			payout.Status = models.PayoutStatusPaid
+			payout.TransactionRef = result.TransactionRef
+			payout.PaidAt = &now
+		} else {
+			payout.Status = models.PayoutStatusFailed
+			payout.FailureReason = result.FailureReason
+		}
+
+		if err := ess.earningRepo.SettlePayout(&payout); err != nil {
+			return nil, fmt.Errorf("failed to record payout %d: %v", result.PayoutID, err)
+		}
+	}
+
+	pending, err := ess.earningRepo.CountPendingPayouts(batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check payouts: %v", err)
+	}
+	if pending == 0 {
+		batch.Status = models.PayoutBatchStatusCompleted
+		batch.CompletedAt = &now
+		if err := ess.earningRepo.UpdateBatch(batch); err != nil {
+			return nil, fmt.Errorf("failed to update payout batch: %v", err)
+		}
+	}
+
+	return ess.earningRepo.GetBatchByID(batch.ID)
+}
+
+// CancelPayoutBatch cancels a batch before any payout is recorded and releases its earnings
+func (ess *WorkerEarningService) CancelPayoutBatch(batchID uint) (*models.PayoutBatch, error) {
+	batch, err := ess.GetPayoutBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != models.PayoutBatchStatusDraft && batch.Status != models.PayoutBatchStatusExported {
+		return nil, fmt.Errorf("%s payout batches cannot be cancelled", batch.Status)
+	}
+	for _, payout := range batch.Payouts {
+		if payout.Status != models.PayoutStatusPending {
+			return nil, errors.New("payout batch has recorded payouts and cannot be cancelled")
+		}
+	}
+
+	if err := ess.earningRepo.CancelBatch(batch); err != nil {
+		return nil, fmt.Errorf("failed to cancel payout batch: %v", err)
+	}
+
+	return ess.earningRepo.GetBatchByID(batch.ID)
+}
+
+// workerBankingInfo reads the banking info stored on a worker and checks it can receive transfers
+func (ess *WorkerEarningService) workerBankingInfo(workerUserID uint) (*models.BankingInfo, error) {
+	worker, err := ess.workerRepo.GetByUserID(workerUserID)
+	if err != nil {
+		return nil, errors.New("worker not found")
+	}
+
+	var banking models.BankingInfo
+	if worker.BankingInfo == "" || json.Unmarshal([]byte(worker.BankingInfo), &banking) != nil {
+		return nil, errors.New("no banking info")
+	}
+	if banking.AccountNumber == "" || banking.IfscCode == "" || banking.AccountHolderName == "" {
+		return nil, errors.New("incomplete banking info")
+	}
+
+	return &banking, nil
+}
+
+// generateBatchReference generates a unique payout batch reference
+func (ess *WorkerEarningService) generateBatchReference() string {
+	timestamp := time.Now().Format("20060102")
+	sequence := time.Now().UnixNano() % 1000000
+	return fmt.Sprintf("PB%s%06d", timestamp, sequence)
+}
+
+// validateCommissionRule checks a commission rule targets a category or service and has a sensible value
+func validateCommissionRule(req *models.CommissionRuleRequest) error {
+	if req.CategoryID == nil && req.ServiceID == nil {
+		return errors.New("commission rule needs a category or a service")
+	}
+	if req.CommissionType == models.CommissionTypePercentage && req.Value > 100 {
+		return errors.New("commission percentage cannot exceed 100")
+	}
+	return nil
+}
+
+// csvText escapes a value workers entered for a CSV cell. Values starting with =, +, - or @ are
+// prefixed with ' so spreadsheet apps show them as text instead of running them as formulas.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// commissionAmount calculates the platform's share of a job, never more than the job itself
+func commissionAmount(gross float64, commissionType models.CommissionType, rate float64) float64 {
+	commission := rate
+	if commissionType == models.CommissionTypePercentage {
+		commission = gross * rate / 100
+	}
+	return roundCurrency(math.Min(commission, gross))
+}
@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestCSVTextKeepsFormulasFromRunning(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"Ramesh Kumar", "Ramesh Kumar"},
+		{"50100012345678", "50100012345678"},
+		{"", ""},
+		{"=HYPERLINK(\"http://example.com\",\"Bank\")", "'=HYPERLINK(\"http://example.com\",\"Bank\")"},
+		{"+91 Bank", "'+91 Bank"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			if got := csvText(tc.value); got != tc.want {
+				t.Errorf("csvText(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
@@ -10,16 +10,18 @@ import (
 )
 
 type WorkerInquiryService struct {
-	inquiryRepo *repositories.WorkerInquiryRepository
-	workerRepo  *repositories.UserRepository
-	userRepo    *repositories.UserRepository
+	inquiryRepo        *repositories.WorkerInquiryRepository
+	workerRepo         *repositories.UserRepository
+	userRepo           *repositories.UserRepository
+	entitlementService *EntitlementService
 }
 
 func NewWorkerInquiryService() *WorkerInquiryService {
 	return &WorkerInquiryService{
-		inquiryRepo: repositories.NewWorkerInquiryRepository(),
-		workerRepo:  repositories.NewUserRepository(),
-		userRepo:    repositories.NewUserRepository(),
+		inquiryRepo:        repositories.NewWorkerInquiryRepository(),
+		workerRepo:         repositories.NewUserRepository(),
+		userRepo:           repositories.NewUserRepository(),
+		entitlementService: NewEntitlementService(),
 	}
 }
 
@@ -47,6 +49,12 @@ func (wis *WorkerInquiryService) CreateInquiry(userID uint, workerID uint, req *
 		return nil, errors.New("user is not active")
 	}
 
+	// Use one of this month's worker inquiry contacts from the user's plan
+	periodStart, err := wis.entitlementService.Consume(userID, models.EntitlementWorkerInquiryContacts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create inquiry
 	inquiry := &models.WorkerInquiry{
 		UserID:        userID,
@@ -64,6 +72,7 @@ func (wis *WorkerInquiryService) CreateInquiry(userID uint, workerID uint, req *
 	err = wis.inquiryRepo.Create(inquiry)
 	if err != nil {
 		logrus.Errorf("Failed to create inquiry: %v", err)
+		wis.entitlementService.Release(userID, models.EntitlementWorkerInquiryContacts, periodStart)
 		return nil, errors.New("failed to create inquiry")
 	}
 
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"treesindia/models"
+	"treesindia/repositories"
+	"treesindia/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Matching weights. They add up to 1 so a perfect candidate scores 1.
+const (
+	matchWeightSkill    = 0.35
+	matchWeightArea     = 0.15
+	matchWeightDistance = 0.20
+	matchWeightLoad     = 0.10
+	matchWeightRating   = 0.15
+	matchWeightType     = 0.05
+)
+
+// defaultMatchDurationMinutes is used when neither the booking nor the service has a duration
+const defaultMatchDurationMinutes = 120
+
+// SkillMatch describes how well a worker's skills cover a service
+type SkillMatch string
+
+const (
+	SkillMatchSubcategory SkillMatch = "subcategory" // Skill names the service's subcategory
+	SkillMatchCategory    SkillMatch = "category"    // Skill names the service's category only
+	SkillMatchNone        SkillMatch = "none"        // No related skill
+)
+
+// WorkerMatch is a ranked candidate worker for a booking
+type WorkerMatch struct {
+	WorkerID          uint              `json:"worker_id"` // User ID of the worker
+	Name              string            `json:"name"`
+	Phone             string            `json:"phone"`
+	WorkerType        models.WorkerType `json:"worker_type"`
+	Rating            float64           `json:"rating"`
+	TotalReviews      int               `json:"total_reviews"`
+	ActiveAssignments int               `json:"active_assignments"`
+	City              string            `json:"city"`
+	DistanceKm        *float64          `json:"distance_km,omitempty"`
+	SkillMatch        SkillMatch        `json:"skill_match"`
+	InServiceArea     bool              `json:"in_service_area"`
+	Eligible          bool              `json:"eligible"` // Safe to auto-assign
+	Score             float64           `json:"score"`
+}
+
+// MatchCriteria describes the job candidate workers are matched against
+type MatchCriteria struct {
+	Service          *models.Service
+	StartTime        *time.Time // Optional; workers busy or off in [StartTime, EndTime) are left out
+	EndTime          *time.Time
+	City             string
+	State            string
+	Latitude         float64 // Zero when the job location has no coordinates
+	Longitude        float64
+	ExcludeWorkerIDs []uint
+}
+
+// WorkerMatchingService ranks workers for a job by skill, service area, distance, load, rating and worker type
+type WorkerMatchingService struct {
+	workerRepo           *repositories.WorkerRepository
+	workerAssignmentRepo *repositories.WorkerAssignmentRepository
+	locationRepo         *repositories.LocationRepository
+	bookingRepo          *repositories.BookingRepository
+	serviceRepo          *repositories.ServiceRepository
+	adminConfigService   *AdminConfigService
+	availabilityService  *WorkerAvailabilityService
+}
+
+// NewWorkerMatchingService creates a new worker matching service
+func NewWorkerMatchingService() *WorkerMatchingService {
+	return &WorkerMatchingService{
+		workerRepo:           repositories.NewWorkerRepository(),
+		workerAssignmentRepo: repositories.NewWorkerAssignmentRepository(),
+		locationRepo:         repositories.NewLocationRepository(),
+		bookingRepo:          repositories.NewBookingRepository(),
+		serviceRepo:          repositories.NewServiceRepository(),
+		adminConfigService:   NewAdminConfigService(),
+		availabilityService:  NewWorkerAvailabilityService(),
+	}
+}
+
+// MatchWorkers returns the workers free for the job, best match first
+func (wms *WorkerMatchingService) MatchWorkers(criteria *MatchCriteria) ([]WorkerMatch, error) {
+	if criteria.Service == nil {
+		return nil, errors.New("service is required for worker matching")
+	}
+
+	workers, err := wms.workerRepo.GetActiveWorkers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workers: %v", err)
+	}
+
+	excluded := make(map[uint]bool)
+	for _, id := range criteria.ExcludeWorkerIDs {
+		excluded[id] = true
+	}
+
+	if criteria.StartTime != nil && criteria.EndTime != nil {
+		busyIDs, err := wms.workerAssignmentRepo.GetBusyWorkerIDs(*criteria.StartTime, *criteria.EndTime, defaultMatchDurationMinutes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get busy workers: %v", err)
+		}
+		for _, id := range busyIDs {
+			excluded[id] = true
+		}
+	}
+
+	candidates := []models.Worker{}
+	userIDs := []uint{}
+	for _, worker := range workers {
+		if excluded[worker.UserID] {
+			continue
+		}
+		candidates = append(candidates, worker)
+		userIDs = append(userIDs, worker.UserID)
+	}
+
+	// Leave out workers whose schedule, leave or blocked slots rule out the time
+	if criteria.StartTime != nil && criteria.EndTime != nil {
+		calendars, err := wms.availabilityService.LoadCalendars(userIDs, *criteria.StartTime, *criteria.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		available := []models.Worker{}
+		userIDs = []uint{}
+		for _, worker := range candidates {
+			if !calendars.IsAvailable(worker.UserID, *criteria.StartTime, *criteria.EndTime) {
+				continue
+			}
+			available = append(available, worker)
+			userIDs = append(userIDs, worker.UserID)
+		}
+		candidates = available
+	}
+
+	var locations []models.Location
+	if err := wms.locationRepo.FindLocationsByUserIDs(&locations, userIDs); err != nil {
+		return nil, fmt.Errorf("failed to get worker locations: %v", err)
+	}
+	locationsByUser := make(map[uint]models.Location)
+	for _, location := range locations {
+		locationsByUser[location.UserID] = location
+	}
+
+	loads, err := wms.workerAssignmentRepo.GetActiveAssignmentCounts(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker load: %v", err)
+	}
+
+	maxDistanceKm := wms.adminConfigService.GetWorkerMatchingMaxDistanceKm()
+
+	matches := make([]WorkerMatch, 0, len(candidates))
+	for _, worker := range candidates {
+		location, hasLocation := locationsByUser[worker.UserID]
+		matches = append(matches, scoreWorker(worker, location, hasLocation, loads[worker.UserID], criteria, maxDistanceKm))
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Eligible != matches[j].Eligible {
+			return matches[i].Eligible
+		}
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+// SuggestWorkersForBooking ranks the workers free for a booking's scheduled time (admin)
+func (wms *WorkerMatchingService) SuggestWorkersForBooking(bookingID uint, limit int) ([]WorkerMatch, error) {
+	booking, err := wms.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+
+	criteria, err := wms.criteriaForBooking(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := wms.MatchWorkers(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// FindBestWorkerForBooking returns the top eligible worker for a booking
+func (wms *WorkerMatchingService) FindBestWorkerForBooking(booking *models.Booking) (*WorkerMatch, error) {
+	criteria, err := wms.criteriaForBooking(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := wms.MatchWorkers(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 || !matches[0].Eligible {
+		return nil, errors.New("no matching worker is available for this booking")
+	}
+	return &matches[0], nil
+}
+
+// CountAvailableWorkers counts the eligible workers free for the job
+func (wms *WorkerMatchingService) CountAvailableWorkers(criteria *MatchCriteria) (int, error) {
+	matches, err := wms.MatchWorkers(criteria)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, match := range matches {
+		if match.Eligible {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// criteriaForBooking builds match criteria from a booking's service, schedule and address.
+// The worker the booking is currently assigned to is left out so a reassignment picks someone else.
+func (wms *WorkerMatchingService) criteriaForBooking(booking *models.Booking) (*MatchCriteria, error) {
+	// Load the service with its category, subcategory and areas
+	service, err := wms.serviceRepo.GetByID(booking.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("service not found: %v", err)
+	}
+	criteria := &MatchCriteria{Service: service}
+
+	if booking.ScheduledTime != nil {
+		start := *booking.ScheduledTime
+		end := start.Add(time.Duration(matchDurationMinutes(service)) * time.Minute)
+		if booking.ScheduledEndTime != nil {
+			end = *booking.ScheduledEndTime
+		}
+		criteria.StartTime = &start
+		criteria.EndTime = &end
+	}
+
+	if booking.Address != nil {
+		var address models.BookingAddress
+		if err := json.Unmarshal([]byte(*booking.Address), &address); err == nil {
+			criteria.City = address.City
+			criteria.State = address.State
+			criteria.Latitude = address.Latitude
+			criteria.Longitude = address.Longitude
+		}
+	}
+
+	if booking.WorkerAssignment != nil {
+		criteria.ExcludeWorkerIDs = append(criteria.ExcludeWorkerIDs, booking.WorkerAssignment.WorkerID)
+	}
+
+	return criteria, nil
+}
+
+// scoreWorker works out how well a single worker fits the job
+func scoreWorker(worker models.Worker, location models.Location, hasLocation bool, load int, criteria *MatchCriteria, maxDistanceKm float64) WorkerMatch {
+	city, state := workerCity(worker, location, hasLocation)
+
+	match := WorkerMatch{
+		WorkerID:          worker.UserID,
+		Name:              worker.User.Name,
+		Phone:             worker.User.Phone,
+		WorkerType:        worker.WorkerType,
+		Rating:            worker.Rating,
+		TotalReviews:      worker.TotalReviews,
+		ActiveAssignments: load,
+		City:              city,
+		SkillMatch:        matchSkills(parseWorkerSkills(worker.Skills), criteria.Service),
+		InServiceArea:     coversServiceArea(criteria.Service, city, state, criteria.City, criteria.State),
+	}
+
+	skillScore := 0.0
+	switch match.SkillMatch {
+	case SkillMatchSubcategory:
+		skillScore = 1
+	case SkillMatchCategory:
+		skillScore = 0.6
+	}
+
+	areaScore := 0.0
+	if match.InServiceArea {
+		areaScore = 1
+	}
+
+	// Unknown distance scores in the middle so it neither helps nor sinks a worker
+	distanceScore := 0.5
+	withinRange := true
+	if hasLocation && hasCoordinates(location.Latitude, location.Longitude) && hasCoordinates(criteria.Latitude, criteria.Longitude) {
+		distance := math.Round(haversineKm(criteria.Latitude, criteria.Longitude, location.Latitude, location.Longitude)*10) / 10
+		match.DistanceKm = &distance
+		withinRange = maxDistanceKm <= 0 || distance <= maxDistanceKm
+		if maxDistanceKm > 0 {
+			distanceScore = math.Max(0, 1-distance/maxDistanceKm)
+		} else {
+			distanceScore = 1 / (1 + distance/10)
+		}
+	}
+
+	loadScore := 1 / float64(1+load)
+
+	ratingScore := 0.5
+	if worker.TotalReviews > 0 {
+		ratingScore = worker.Rating / 5
+	}
+
+	typeScore := 0.5
+	if worker.WorkerType == models.WorkerTypeTreesIndia {
+		typeScore = 1
+	}
+
+	match.Score = math.Round((skillScore*matchWeightSkill+
+		areaScore*matchWeightArea+
+		distanceScore*matchWeightDistance+
+		loadScore*matchWeightLoad+
+		ratingScore*matchWeightRating+
+		typeScore*matchWeightType)*1000) / 1000
+	match.Eligible = match.SkillMatch != SkillMatchNone && match.InServiceArea && withinRange
+
+	return match
+}
+
+// parseWorkerSkills reads the worker's skills, stored as a JSON array or a comma separated list
+func parseWorkerSkills(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var skills []string
+	if err := json.Unmarshal([]byte(raw), &skills); err != nil {
+		skills = strings.Split(strings.Trim(raw, "[]"), ",")
+	}
+
+	normalized := []string{}
+	for _, skill := range skills {
+		skill = strings.ToLower(strings.Trim(strings.TrimSpace(skill), "\""))
+		if skill != "" {
+			normalized = append(normalized, skill)
+		}
+	}
+	return normalized
+}
+
+// matchSkills compares skills with the service's subcategory and category names
+func matchSkills(skills []string, service *models.Service) SkillMatch {
+	subcategory := strings.ToLower(strings.TrimSpace(service.Subcategory.Name))
+	category := strings.ToLower(strings.TrimSpace(service.Category.Name))
+
+	result := SkillMatchNone
+	for _, skill := range skills {
+		if namesMatch(skill, subcategory) {
+			return SkillMatchSubcategory
+		}
+		if namesMatch(skill, category) {
+			result = SkillMatchCategory
+		}
+	}
+	return result
+}
+
+// namesMatch reports whether a skill and a category name refer to the same trade
+func namesMatch(skill, name string) bool {
+	if skill == "" || name == "" {
+		return false
+	}
+	return skill == name || strings.Contains(skill, name) || strings.Contains(name, skill)
+}
+
+// workerCity returns the worker's city and state from their saved location, falling back to their address
+func workerCity(worker models.Worker, location models.Location, hasLocation bool) (string, string) {
+	if hasLocation && location.City != "" {
+		return location.City, location.State
+	}
+
+	var address struct {
+		City  string `json:"city"`
+		State string `json:"state"`
+	}
+	if worker.Address != "" {
+		if err := json.Unmarshal([]byte(worker.Address), &address); err != nil {
+			logrus.Debugf("Failed to parse address of worker %d: %v", worker.ID, err)
+		}
+	}
+	return address.City, address.State
+}
+
+// coversServiceArea checks the worker's city against the service's areas. Services without areas
+// are offered everywhere, in which case the worker only needs to be in the job's city when it is known.
+func coversServiceArea(service *models.Service, workerCity, workerState, jobCity, jobState string) bool {
+	if len(service.ServiceAreas) == 0 {
+		return jobCity == "" || workerCity == "" || sameCity(workerCity, workerState, jobCity, jobState)
+	}
+
+	for _, area := range service.ServiceAreas {
+		if area.IsActive && sameCity(workerCity, workerState, area.City, area.State) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameCity compares two city/state pairs, ignoring case and a missing state
+func sameCity(cityA, stateA, cityB, stateB string) bool {
+	if !strings.EqualFold(strings.TrimSpace(cityA), strings.TrimSpace(cityB)) {
+		return false
+	}
+	return stateA == "" || stateB == "" || strings.EqualFold(strings.TrimSpace(stateA), strings.TrimSpace(stateB))
+}
+
+// matchDurationMinutes returns the service's duration in minutes or the default
+func matchDurationMinutes(service *models.Service) int {
+	if service != nil && service.Duration != nil && *service.Duration != "" {
+		if duration, err := utils.ParseDuration(*service.Duration); err == nil && duration.ToMinutes() > 0 {
+			return duration.ToMinutes()
+		}
+	}
+	return defaultMatchDurationMinutes
+}
+
+func hasCoordinates(lat, lng float64) bool {
+	return lat != 0 || lng != 0
+}
+
+// haversineKm calculates the great-circle distance between two points in kilometers
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const R = 6371 // Earth's radius in kilometers
+
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLng/2)*math.Sin(deltaLng/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return R * c
+}
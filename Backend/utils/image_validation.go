@@ -1,6 +1,12 @@
 package utils
 
-import "strings"
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
 
 // IsValidImageType checks if the content type is a valid image type
 func IsValidImageType(contentType string) bool {
@@ -18,3 +24,39 @@ func IsValidImageType(contentType string) bool {
 	}
 	return false
 }
+
+// IsValidDocumentType checks if the content type is a document type that can be shared in chat
+func IsValidDocumentType(contentType string) bool {
+	validTypes := []string{
+		"application/pdf",
+	}
+
+	for _, validType := range validTypes {
+		if strings.EqualFold(contentType, validType) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectFileType sniffs the content type of an uploaded file from its first bytes, so a client
+// cannot pass off another kind of file by setting its Content-Type header
+func DetectFileType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer src.Close()
+
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	contentType := http.DetectContentType(buffer[:n])
+	if index := strings.Index(contentType, ";"); index != -1 {
+		contentType = contentType[:index]
+	}
+	return contentType, nil
+}
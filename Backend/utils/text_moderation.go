@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Kinds of contact details MaskContactDetailsInText detects
+const (
+	ContactDetailPhone         = "phone"
+	ContactDetailEmail         = "email"
+	ContactDetailPaymentHandle = "payment_handle"
+)
+
+var (
+	// Email addresses such as name@example.com
+	emailInTextRegex = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9\-]+(\.[a-z0-9\-]+)*\.[a-z]{2,}`)
+	// UPI IDs and other payment handles such as name@okaxis or 9876543210@ybl, checked after emails
+	paymentHandleRegex = regexp.MustCompile(`(?i)[a-z0-9._\-]{2,}@[a-z][a-z0-9]{1,63}`)
+	// Indian mobile numbers, also when split with spaces, dashes or dots such as 98765 43210 or +91-98765-43210
+	chatPhoneRegex = regexp.MustCompile(`(\+?91[\s\-.]*)?(0[\s\-.]*)?[6-9]([\s\-.]*\d){9}`)
+)
+
+// MaskContactDetailsInText masks phone numbers, email addresses and payment handles such as UPI IDs in text,
+// so they cannot be shared to take a conversation off the platform. It returns the masked text and the kinds
+// of contact details it found.
+func MaskContactDetailsInText(text string) (string, []string) {
+	if text == "" {
+		return text, nil
+	}
+
+	var detected []string
+	masked := emailInTextRegex.ReplaceAllStringFunc(text, func(match string) string {
+		detected = appendOnce(detected, ContactDetailEmail)
+		at := strings.Index(match, "@")
+		return match[:1] + strings.Repeat("*", at-1) + "@" + strings.Repeat("*", len(match)-at-1)
+	})
+
+	masked = paymentHandleRegex.ReplaceAllStringFunc(masked, func(match string) string {
+		detected = appendOnce(detected, ContactDetailPaymentHandle)
+		at := strings.Index(match, "@")
+		return strings.Repeat("*", at) + match[at:]
+	})
+
+	// Skip digit runs that are part of a longer number, such as order or account numbers
+	var builder strings.Builder
+	last := 0
+	for _, loc := range chatPhoneRegex.FindAllStringIndex(masked, -1) {
+		start, end := loc[0], loc[1]
+		if (start > 0 && isDigit(masked[start-1])) || (end < len(masked) && isDigit(masked[end])) {
+			continue
+		}
+		detected = appendOnce(detected, ContactDetailPhone)
+		builder.WriteString(masked[last:start])
+		builder.WriteString(maskDigits(masked[start:end], 3))
+		last = end
+	}
+	builder.WriteString(masked[last:])
+
+	return builder.String(), detected
+}
+
+// FilterBlockedWords replaces whole words from a word list in text with asterisks, ignoring case. It returns the
+// filtered text and whether any word was replaced.
+func FilterBlockedWords(text string, words []string) (string, bool) {
+	var patterns []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			patterns = append(patterns, regexp.QuoteMeta(word))
+		}
+	}
+	if text == "" || len(patterns) == 0 {
+		return text, false
+	}
+
+	wordRegex := regexp.MustCompile(`(?i)\b(` + strings.Join(patterns, "|") + `)\b`)
+	found := false
+	filtered := wordRegex.ReplaceAllStringFunc(text, func(match string) string {
+		found = true
+		return strings.Repeat("*", len([]rune(match)))
+	})
+	return filtered, found
+}
+
+// ParseWordList splits a comma separated word list into lower case words
+func ParseWordList(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// maskDigits replaces every digit after the first keep digits with an asterisk, leaving separators in place
+func maskDigits(value string, keep int) string {
+	seen := 0
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsDigit(r) {
+			return r
+		}
+		seen++
+		if seen > keep {
+			return '*'
+		}
+		return r
+	}, value)
+}
+
+// isDigit reports whether a byte is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// appendOnce appends a value to a slice unless it is already there
+func appendOnce(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}